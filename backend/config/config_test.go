@@ -1,6 +1,9 @@
 package config
 
-import "testing"
+import (
+	"testing"
+	"time"
+)
 
 func TestLoadConfigDefaults(t *testing.T) {
 	t.Setenv("SECRET_KEY", "")
@@ -22,6 +25,18 @@ func TestLoadConfigDefaults(t *testing.T) {
 	if cfg.Port != 3000 {
 		t.Fatalf("expected default port 3000, got %d", cfg.Port)
 	}
+	if cfg.LoginRateLimit != (RateLimit{Limit: 10, Window: time.Minute}) {
+		t.Fatalf("expected default login rate limit, got %+v", cfg.LoginRateLimit)
+	}
+	if cfg.MessageRateLimit != (RateLimit{Limit: 10, Window: 10 * time.Second}) {
+		t.Fatalf("expected default message rate limit, got %+v", cfg.MessageRateLimit)
+	}
+	if cfg.LockoutThreshold != 5 || cfg.LockoutBaseDuration != time.Minute || cfg.LockoutMaxDuration != time.Hour {
+		t.Fatalf("expected default lockout policy, got %d/%s/%s", cfg.LockoutThreshold, cfg.LockoutBaseDuration, cfg.LockoutMaxDuration)
+	}
+	if cfg.FriendRequestMaxOutgoing != 100 || cfg.FriendRequestExpiry != 720*time.Hour || cfg.FriendRequestRejectCooldown != 168*time.Hour {
+		t.Fatalf("expected default friend request policy, got %d/%s/%s", cfg.FriendRequestMaxOutgoing, cfg.FriendRequestExpiry, cfg.FriendRequestRejectCooldown)
+	}
 }
 
 func TestLoadConfigEnvOverrides(t *testing.T) {
@@ -29,6 +44,8 @@ func TestLoadConfigEnvOverrides(t *testing.T) {
 	t.Setenv("DATABASE_URL", "postgresql://db-user:db-pass@db:5432/app")
 	t.Setenv("REDIS_URL", "redis://cache:6379/1")
 	t.Setenv("PORT", "8080")
+	t.Setenv("LOGIN_RATE_LIMIT", "3/30s")
+	t.Setenv("LOCKOUT_BASE_DURATION", "2m")
 
 	cfg := LoadConfig()
 
@@ -44,6 +61,12 @@ func TestLoadConfigEnvOverrides(t *testing.T) {
 	if cfg.Port != 8080 {
 		t.Fatalf("expected port override 8080, got %d", cfg.Port)
 	}
+	if cfg.LoginRateLimit != (RateLimit{Limit: 3, Window: 30 * time.Second}) {
+		t.Fatalf("expected login rate limit override, got %+v", cfg.LoginRateLimit)
+	}
+	if cfg.LockoutBaseDuration != 2*time.Minute {
+		t.Fatalf("expected lockout base duration override, got %s", cfg.LockoutBaseDuration)
+	}
 }
 
 func TestLoadConfigInvalidPortFallsBackToDefault(t *testing.T) {
@@ -55,3 +78,54 @@ func TestLoadConfigInvalidPortFallsBackToDefault(t *testing.T) {
 		t.Fatalf("expected invalid port to fall back to 3000, got %d", cfg.Port)
 	}
 }
+
+func TestLoadConfigInvalidRateLimitsFallBackToDefault(t *testing.T) {
+	for _, value := range []string{"ten/1m", "10", "10/forever", "0/1m", "5/-1s"} {
+		t.Setenv("LOGIN_RATE_LIMIT", value)
+		t.Setenv("LOCKOUT_MAX_DURATION", value)
+
+		cfg := LoadConfig()
+
+		if cfg.LoginRateLimit != (RateLimit{Limit: 10, Window: time.Minute}) {
+			t.Fatalf("expected invalid rate limit %q to fall back, got %+v", value, cfg.LoginRateLimit)
+		}
+		if cfg.LockoutMaxDuration != time.Hour {
+			t.Fatalf("expected invalid duration %q to fall back, got %s", value, cfg.LockoutMaxDuration)
+		}
+	}
+}
+
+func TestLoadConfigOIDCProviders(t *testing.T) {
+	t.Setenv("OIDC_PROVIDERS", "acme, broken")
+	t.Setenv("OIDC_ACME_ISSUER_URL", "https://id.acme.test")
+	t.Setenv("OIDC_ACME_CLIENT_ID", "concord")
+	t.Setenv("OIDC_ACME_CLIENT_SECRET", "shh")
+	t.Setenv("OIDC_ACME_REDIRECT_URL", "http://localhost:5173/login/sso/callback")
+	t.Setenv("OIDC_ACME_SCOPES", "openid,email")
+	t.Setenv("OIDC_BROKEN_CLIENT_ID", "missing-issuer")
+
+	cfg := LoadConfig()
+
+	if len(cfg.OIDCProviders) != 1 {
+		t.Fatalf("expected only the complete provider, got %+v", cfg.OIDCProviders)
+	}
+	provider := cfg.OIDCProviders[0]
+	if provider.Name != "acme" || provider.IssuerURL != "https://id.acme.test" || provider.ClientID != "concord" || provider.ClientSecret != "shh" {
+		t.Fatalf("unexpected provider %+v", provider)
+	}
+	if len(provider.Scopes) != 2 || provider.Scopes[0] != "openid" || provider.Scopes[1] != "email" {
+		t.Fatalf("expected scopes override, got %v", provider.Scopes)
+	}
+}
+
+func TestLoadConfigEventDeliveryAllowPrivateNetworks(t *testing.T) {
+	t.Setenv("EVENT_DELIVERY_ALLOW_PRIVATE_NETWORKS", "true")
+	if cfg := LoadConfig(); !cfg.EventDeliveryAllowPrivateNetworks {
+		t.Fatal("expected private networks to be allowed")
+	}
+
+	t.Setenv("EVENT_DELIVERY_ALLOW_PRIVATE_NETWORKS", "maybe")
+	if cfg := LoadConfig(); cfg.EventDeliveryAllowPrivateNetworks {
+		t.Fatal("expected invalid bool to fall back to false")
+	}
+}
@@ -0,0 +1,67 @@
+package annotations
+
+import (
+	"strconv"
+
+	"github.com/andrelcunha/Concord/backend/internal/middleware"
+	"github.com/gofiber/fiber/v2"
+)
+
+type Handler struct {
+	service *Service
+}
+
+func NewHandler(service *Service) *Handler {
+	return &Handler{service: service}
+}
+
+func (h *Handler) GetAnnotation(c *fiber.Ctx) error {
+	targetID, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid user ID"})
+	}
+
+	userID := c.Locals("userID").(int32)
+	annotation, err := h.service.GetAnnotation(c.Context(), userID, int32(targetID))
+	if err != nil {
+		return annotationErrorResponse(c, err)
+	}
+	return c.JSON(annotation)
+}
+
+func (h *Handler) UpdateAnnotation(c *fiber.Ctx) error {
+	targetID, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid user ID"})
+	}
+	var req AnnotationUpdate
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request"})
+	}
+
+	userID := c.Locals("userID").(int32)
+	annotation, err := h.service.UpdateAnnotation(c.Context(), userID, int32(targetID), req)
+	if err != nil {
+		return annotationErrorResponse(c, err)
+	}
+	return c.JSON(annotation)
+}
+
+func annotationErrorResponse(c *fiber.Ctx, err error) error {
+	switch err {
+	case ErrCannotAnnotateYourself, ErrNicknameTooLong, ErrNoteTooLong:
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	case ErrUserNotFound:
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
+	default:
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+}
+
+func RegisterAnnotationRoutes(api fiber.Router, service *Service) {
+	handler := NewHandler(service)
+	interactive := middleware.RequireInteractive()
+
+	api.Get("/users/:id/annotation", interactive, handler.GetAnnotation)
+	api.Patch("/users/:id/annotation", interactive, handler.UpdateAnnotation)
+}
@@ -0,0 +1,57 @@
+package annotations
+
+import (
+	"context"
+
+	"github.com/andrelcunha/Concord/backend/internal/db"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type Repository interface {
+	GetUser(ctx context.Context, userID int32) (db.GetUserByIDRow, error)
+	GetAnnotation(ctx context.Context, ownerID, targetID int32) (db.UserAnnotation, error)
+	ListAnnotations(ctx context.Context, ownerID int32) ([]db.UserAnnotation, error)
+	UpsertAnnotation(ctx context.Context, ownerID, targetID int32, nickname, note string) (db.UserAnnotation, error)
+	DeleteAnnotation(ctx context.Context, ownerID, targetID int32) error
+}
+
+type repository struct {
+	db *db.Queries
+}
+
+func NewRepository(dbPool *pgxpool.Pool) Repository {
+	return &repository{
+		db: db.New(dbPool),
+	}
+}
+
+func (r *repository) GetUser(ctx context.Context, userID int32) (db.GetUserByIDRow, error) {
+	return r.db.GetUserByID(ctx, userID)
+}
+
+func (r *repository) GetAnnotation(ctx context.Context, ownerID, targetID int32) (db.UserAnnotation, error) {
+	return r.db.GetUserAnnotation(ctx, db.GetUserAnnotationParams{
+		OwnerID:  ownerID,
+		TargetID: targetID,
+	})
+}
+
+func (r *repository) ListAnnotations(ctx context.Context, ownerID int32) ([]db.UserAnnotation, error) {
+	return r.db.ListUserAnnotations(ctx, ownerID)
+}
+
+func (r *repository) UpsertAnnotation(ctx context.Context, ownerID, targetID int32, nickname, note string) (db.UserAnnotation, error) {
+	return r.db.UpsertUserAnnotation(ctx, db.UpsertUserAnnotationParams{
+		OwnerID:  ownerID,
+		TargetID: targetID,
+		Nickname: nickname,
+		Note:     note,
+	})
+}
+
+func (r *repository) DeleteAnnotation(ctx context.Context, ownerID, targetID int32) error {
+	return r.db.DeleteUserAnnotation(ctx, db.DeleteUserAnnotationParams{
+		OwnerID:  ownerID,
+		TargetID: targetID,
+	})
+}
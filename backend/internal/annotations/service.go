@@ -0,0 +1,147 @@
+package annotations
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/andrelcunha/Concord/backend/internal/db"
+	"github.com/andrelcunha/Concord/backend/pkg/dtos"
+	"github.com/jackc/pgx/v5"
+)
+
+const (
+	MaxNicknameLength = 32
+	MaxNoteLength     = 256
+)
+
+var (
+	ErrCannotAnnotateYourself = errors.New("cannot annotate yourself")
+	ErrUserNotFound           = errors.New("user not found")
+	ErrNicknameTooLong        = fmt.Errorf("nickname must be at most %d characters", MaxNicknameLength)
+	ErrNoteTooLong            = fmt.Errorf("note must be at most %d characters", MaxNoteLength)
+)
+
+// AnnotationUpdate carries the fields of a partial update; nil fields keep
+// their current value.
+type AnnotationUpdate struct {
+	Nickname *string `json:"nickname"`
+	Note     *string `json:"note"`
+}
+
+// Set is one owner's annotations keyed by the annotated user.
+type Set map[int32]db.UserAnnotation
+
+// Apply copies the owner's nickname and note for user into the summary.
+func (s Set) Apply(user *dtos.UserSummaryDto) {
+	if annotation, ok := s[user.UserID]; ok {
+		user.Nickname = annotation.Nickname
+		user.Note = annotation.Note
+	}
+}
+
+func (s Set) ApplyAll(users []dtos.UserSummaryDto) {
+	for i := range users {
+		s.Apply(&users[i])
+	}
+}
+
+func (s Set) Nickname(userID int32) string {
+	return s[userID].Nickname
+}
+
+type Service struct {
+	repo Repository
+}
+
+func NewService(repo Repository) *Service {
+	return &Service{repo: repo}
+}
+
+// ForOwner loads every annotation ownerID has written, for decorating the
+// users in a response to them.
+func (s *Service) ForOwner(ctx context.Context, ownerID int32) (Set, error) {
+	rows, err := s.repo.ListAnnotations(ctx, ownerID)
+	if err != nil {
+		return nil, err
+	}
+
+	set := make(Set, len(rows))
+	for _, row := range rows {
+		set[row.TargetID] = row
+	}
+	return set, nil
+}
+
+// GetAnnotation returns the owner's annotation of the target, or an empty one
+// if they never wrote any.
+func (s *Service) GetAnnotation(ctx context.Context, ownerID, targetID int32) (dtos.UserAnnotationDto, error) {
+	if err := s.checkTarget(ctx, ownerID, targetID); err != nil {
+		return dtos.UserAnnotationDto{}, err
+	}
+
+	annotation, err := s.repo.GetAnnotation(ctx, ownerID, targetID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return dtos.UserAnnotationDto{TargetID: targetID}, nil
+	}
+	if err != nil {
+		return dtos.UserAnnotationDto{}, err
+	}
+	return annotationDto(annotation), nil
+}
+
+// UpdateAnnotation changes the owner's nickname or note for the target.
+// Clearing both removes the annotation.
+func (s *Service) UpdateAnnotation(ctx context.Context, ownerID, targetID int32, update AnnotationUpdate) (dtos.UserAnnotationDto, error) {
+	current, err := s.GetAnnotation(ctx, ownerID, targetID)
+	if err != nil {
+		return dtos.UserAnnotationDto{}, err
+	}
+	if update.Nickname != nil {
+		current.Nickname = strings.TrimSpace(*update.Nickname)
+	}
+	if update.Note != nil {
+		current.Note = strings.TrimSpace(*update.Note)
+	}
+	if utf8.RuneCountInString(current.Nickname) > MaxNicknameLength {
+		return dtos.UserAnnotationDto{}, ErrNicknameTooLong
+	}
+	if utf8.RuneCountInString(current.Note) > MaxNoteLength {
+		return dtos.UserAnnotationDto{}, ErrNoteTooLong
+	}
+
+	if current.Nickname == "" && current.Note == "" {
+		if err := s.repo.DeleteAnnotation(ctx, ownerID, targetID); err != nil {
+			return dtos.UserAnnotationDto{}, err
+		}
+		return dtos.UserAnnotationDto{TargetID: targetID}, nil
+	}
+
+	annotation, err := s.repo.UpsertAnnotation(ctx, ownerID, targetID, current.Nickname, current.Note)
+	if err != nil {
+		return dtos.UserAnnotationDto{}, err
+	}
+	return annotationDto(annotation), nil
+}
+
+func (s *Service) checkTarget(ctx context.Context, ownerID, targetID int32) error {
+	if ownerID == targetID {
+		return ErrCannotAnnotateYourself
+	}
+	_, err := s.repo.GetUser(ctx, targetID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return ErrUserNotFound
+	}
+	return err
+}
+
+func annotationDto(annotation db.UserAnnotation) dtos.UserAnnotationDto {
+	return dtos.UserAnnotationDto{
+		TargetID:  annotation.TargetID,
+		Nickname:  annotation.Nickname,
+		Note:      annotation.Note,
+		UpdatedAt: annotation.UpdatedAt.Time.Format("2006-01-02T15:04:05Z07:00"),
+	}
+}
@@ -0,0 +1,107 @@
+package annotations
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/andrelcunha/Concord/backend/internal/db"
+	"github.com/andrelcunha/Concord/backend/pkg/dtos"
+	"github.com/jackc/pgx/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type mockRepository struct {
+	annotations map[[2]int32]db.UserAnnotation
+}
+
+func (m *mockRepository) GetUser(ctx context.Context, userID int32) (db.GetUserByIDRow, error) {
+	if userID > 100 {
+		return db.GetUserByIDRow{}, pgx.ErrNoRows
+	}
+	return db.GetUserByIDRow{ID: userID}, nil
+}
+
+func (m *mockRepository) GetAnnotation(ctx context.Context, ownerID, targetID int32) (db.UserAnnotation, error) {
+	annotation, ok := m.annotations[[2]int32{ownerID, targetID}]
+	if !ok {
+		return db.UserAnnotation{}, pgx.ErrNoRows
+	}
+	return annotation, nil
+}
+
+func (m *mockRepository) ListAnnotations(ctx context.Context, ownerID int32) ([]db.UserAnnotation, error) {
+	var annotations []db.UserAnnotation
+	for key, annotation := range m.annotations {
+		if key[0] == ownerID {
+			annotations = append(annotations, annotation)
+		}
+	}
+	return annotations, nil
+}
+
+func (m *mockRepository) UpsertAnnotation(ctx context.Context, ownerID, targetID int32, nickname, note string) (db.UserAnnotation, error) {
+	annotation := db.UserAnnotation{OwnerID: ownerID, TargetID: targetID, Nickname: nickname, Note: note}
+	m.annotations[[2]int32{ownerID, targetID}] = annotation
+	return annotation, nil
+}
+
+func (m *mockRepository) DeleteAnnotation(ctx context.Context, ownerID, targetID int32) error {
+	delete(m.annotations, [2]int32{ownerID, targetID})
+	return nil
+}
+
+func newTestService() (*Service, *mockRepository) {
+	repo := &mockRepository{annotations: map[[2]int32]db.UserAnnotation{}}
+	return NewService(repo), repo
+}
+
+func stringPtr(value string) *string {
+	return &value
+}
+
+func TestUpdateAnnotationKeepsUnsetFieldsAndDeletesWhenCleared(t *testing.T) {
+	service, repo := newTestService()
+	ctx := context.Background()
+
+	_, err := service.UpdateAnnotation(ctx, 1, 2, AnnotationUpdate{Nickname: stringPtr("  Sam  ")})
+	require.NoError(t, err)
+	annotation, err := service.UpdateAnnotation(ctx, 1, 2, AnnotationUpdate{Note: stringPtr("met at the meetup")})
+	require.NoError(t, err)
+	assert.Equal(t, "Sam", annotation.Nickname)
+	assert.Equal(t, "met at the meetup", annotation.Note)
+
+	_, err = service.UpdateAnnotation(ctx, 1, 2, AnnotationUpdate{Nickname: stringPtr(""), Note: stringPtr("")})
+	require.NoError(t, err)
+	assert.Empty(t, repo.annotations)
+}
+
+func TestUpdateAnnotationValidates(t *testing.T) {
+	service, _ := newTestService()
+	ctx := context.Background()
+
+	_, err := service.UpdateAnnotation(ctx, 1, 1, AnnotationUpdate{Nickname: stringPtr("me")})
+	assert.ErrorIs(t, err, ErrCannotAnnotateYourself)
+	_, err = service.UpdateAnnotation(ctx, 1, 101, AnnotationUpdate{Nickname: stringPtr("ghost")})
+	assert.ErrorIs(t, err, ErrUserNotFound)
+	_, err = service.UpdateAnnotation(ctx, 1, 2, AnnotationUpdate{Nickname: stringPtr(strings.Repeat("a", MaxNicknameLength+1))})
+	assert.ErrorIs(t, err, ErrNicknameTooLong)
+	_, err = service.UpdateAnnotation(ctx, 1, 2, AnnotationUpdate{Note: stringPtr(strings.Repeat("a", MaxNoteLength+1))})
+	assert.ErrorIs(t, err, ErrNoteTooLong)
+}
+
+func TestForOwnerOnlyAppliesTheOwnersAnnotations(t *testing.T) {
+	service, repo := newTestService()
+	repo.annotations[[2]int32{1, 3}] = db.UserAnnotation{OwnerID: 1, TargetID: 3, Nickname: "Sam", Note: "note"}
+	repo.annotations[[2]int32{2, 4}] = db.UserAnnotation{OwnerID: 2, TargetID: 4, Nickname: "Other"}
+
+	notes, err := service.ForOwner(context.Background(), 1)
+	require.NoError(t, err)
+
+	users := []dtos.UserSummaryDto{{UserID: 3}, {UserID: 4}}
+	notes.ApplyAll(users)
+	assert.Equal(t, "Sam", users[0].Nickname)
+	assert.Equal(t, "note", users[0].Note)
+	assert.Empty(t, users[1].Nickname)
+}
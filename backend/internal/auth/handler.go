@@ -1,6 +1,10 @@
 package auth
 
 import (
+	"errors"
+
+	"github.com/andrelcunha/Concord/backend/internal/middleware"
+	"github.com/andrelcunha/Concord/backend/pkg/dtos"
 	"github.com/gofiber/fiber/v2"
 )
 
@@ -21,6 +25,16 @@ type RefreshRequest struct {
 	RefreshToken string `json:"refresh_token"`
 }
 
+type MfaLoginRequest struct {
+	MfaTicket string `json:"mfa_ticket"`
+	Code      string `json:"code"`
+}
+
+type OIDCCallbackRequest struct {
+	State string `json:"state"`
+	Code  string `json:"code"`
+}
+
 func (h *Handler) Register(c *fiber.Ctx) error {
 	var req struct {
 		Username string `json:"username"`
@@ -31,6 +45,9 @@ func (h *Handler) Register(c *fiber.Ctx) error {
 	}
 
 	user, err := h.service.Register(c.Context(), req.Username, req.Password)
+	if err == ErrUsernameTaken {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": err.Error()})
+	}
 	if err != nil {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
 	}
@@ -44,20 +61,161 @@ func (h *Handler) Login(c *fiber.Ctx) error {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request"})
 	}
 
-	accessToken, refreshToken, err := h.service.Login(c.Context(), req.Username, req.Password)
+	result, err := h.service.Login(c.Context(), req.Username, req.Password)
 	if err != nil {
+		var lockedErr *AccountLockedError
+		if errors.As(err, &lockedErr) {
+			middleware.SetRetryAfter(c, lockedErr.RetryAfter.Seconds())
+			return c.Status(fiber.StatusTooManyRequests).JSON(fiber.Map{"error": err.Error()})
+		}
 		if err == ErrInvalidCredentials {
 			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": err.Error()})
 		}
 		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": err.Error()})
 	}
 
+	return loginResponse(c, result)
+}
+
+// loginResponse writes either the token pair or the MFA challenge.
+func loginResponse(c *fiber.Ctx, result *LoginResult) error {
+	if result.MfaRequired() {
+		return c.JSON(fiber.Map{
+			"mfa_required": true,
+			"mfa_ticket":   result.MfaTicket,
+			"expires_in":   int(MfaTicketTTL.Seconds()),
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"access_token":  result.AccessToken,
+		"refresh_token": result.RefreshToken,
+	})
+}
+
+func (h *Handler) ListOIDCProviders(c *fiber.Ctx) error {
+	return c.JSON(fiber.Map{"providers": h.service.OIDCProviders()})
+}
+
+func (h *Handler) BeginOIDCLogin(c *fiber.Ctx) error {
+	authorizationURL, err := h.service.BeginOIDCLogin(c.Context(), c.Params("provider"))
+	if err != nil {
+		if err == ErrUnknownOIDCProvider {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(fiber.Map{
+		"authorization_url": authorizationURL,
+		"expires_in":        int(OIDCStateTTL.Seconds()),
+	})
+}
+
+func (h *Handler) CompleteOIDCLogin(c *fiber.Ctx) error {
+	var req OIDCCallbackRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request"})
+	}
+
+	result, err := h.service.CompleteOIDCLogin(c.Context(), req.State, req.Code)
+	if err != nil {
+		switch err {
+		case ErrInvalidOIDCState, ErrUnknownOIDCProvider:
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		case ErrOIDCLoginFailed:
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": err.Error()})
+		default:
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+		}
+	}
+	return loginResponse(c, result)
+}
+
+func (h *Handler) LoginMfa(c *fiber.Ctx) error {
+	var req MfaLoginRequest
+	if err := c.BodyParser(&req); err != nil || req.MfaTicket == "" || req.Code == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request"})
+	}
+
+	accessToken, refreshToken, err := h.service.CompleteMfaLogin(c.Context(), req.MfaTicket, req.Code)
+	if err != nil {
+		return mfaErrorResponse(c, err)
+	}
+
 	return c.JSON(fiber.Map{
 		"access_token":  accessToken,
 		"refresh_token": refreshToken,
 	})
 }
 
+func (h *Handler) EnrollTotp(c *fiber.Ctx) error {
+	userID := c.Locals("userID").(int32)
+	secret, uri, err := h.service.BeginTotpEnrollment(c.Context(), userID)
+	if err != nil {
+		return mfaErrorResponse(c, err)
+	}
+	return c.JSON(fiber.Map{
+		"secret":      secret,
+		"otpauth_uri": uri,
+	})
+}
+
+func (h *Handler) VerifyTotp(c *fiber.Ctx) error {
+	var req struct {
+		Code string `json:"code"`
+	}
+	if err := c.BodyParser(&req); err != nil || req.Code == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request"})
+	}
+
+	userID := c.Locals("userID").(int32)
+	codes, err := h.service.ConfirmTotpEnrollment(c.Context(), userID, req.Code)
+	if err != nil {
+		return mfaErrorResponse(c, err)
+	}
+	return c.JSON(fiber.Map{"recovery_codes": codes})
+}
+
+func (h *Handler) DisableTotp(c *fiber.Ctx) error {
+	userID := c.Locals("userID").(int32)
+	if err := h.service.DisableTotp(c.Context(), userID); err != nil {
+		return mfaErrorResponse(c, err)
+	}
+	return c.SendStatus(fiber.StatusOK)
+}
+
+func (h *Handler) RegenerateRecoveryCodes(c *fiber.Ctx) error {
+	userID := c.Locals("userID").(int32)
+	codes, err := h.service.RegenerateRecoveryCodes(c.Context(), userID)
+	if err != nil {
+		return mfaErrorResponse(c, err)
+	}
+	return c.JSON(fiber.Map{"recovery_codes": codes})
+}
+
+func (h *Handler) ChangePassword(c *fiber.Ctx) error {
+	var req struct {
+		CurrentPassword string `json:"current_password"`
+		NewPassword     string `json:"new_password"`
+	}
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request"})
+	}
+
+	userID := c.Locals("userID").(int32)
+	if err := h.service.ChangePassword(c.Context(), userID, req.CurrentPassword, req.NewPassword); err != nil {
+		switch err {
+		case ErrInvalidPassword:
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		case ErrInvalidCredentials:
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": err.Error()})
+		default:
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+		}
+	}
+	return c.SendStatus(fiber.StatusOK)
+}
+
 func (h *Handler) Refresh(c *fiber.Ctx) error {
 	var req RefreshRequest
 
@@ -97,9 +255,128 @@ func (h *Handler) Refresh(c *fiber.Ctx) error {
 	})
 }
 
+func (h *Handler) CreateWebSocketTicket(c *fiber.Ctx) error {
+	userID := c.Locals("userID").(int32)
+	username, _ := c.Locals("username").(string)
+	displayName, _ := c.Locals("display_name").(string)
+	avatarURL, _ := c.Locals("avatar_url").(string)
+	avatarColor, _ := c.Locals("avatar_color").(string)
+	isBot, _ := c.Locals("isBot").(bool)
+	scopes, _ := c.Locals("scopes").([]string)
+
+	ticket, err := h.service.CreateWebSocketTicket(c.Context(), &dtos.UserDto{
+		UserId:      userID,
+		Username:    username,
+		DisplayName: displayName,
+		AvatarUrl:   avatarURL,
+		AvatarColor: avatarColor,
+		IsBot:       isBot,
+	}, scopes)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return c.JSON(fiber.Map{
+		"ticket":     ticket,
+		"expires_in": int(WebSocketTicketTTL.Seconds()),
+	})
+}
+
+func (h *Handler) UpdateProfile(c *fiber.Ctx) error {
+	var req struct {
+		DisplayName string `json:"display_name"`
+	}
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request"})
+	}
+
+	userID := c.Locals("userID").(int32)
+	user, err := h.service.UpdateDisplayName(c.Context(), userID, req.DisplayName)
+	if err != nil {
+		return usernameErrorResponse(c, err)
+	}
+	return c.JSON(user)
+}
+
+func (h *Handler) ChangeUsername(c *fiber.Ctx) error {
+	var req struct {
+		Username string `json:"username"`
+	}
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request"})
+	}
+
+	userID := c.Locals("userID").(int32)
+	user, err := h.service.ChangeUsername(c.Context(), userID, req.Username)
+	if err != nil {
+		return usernameErrorResponse(c, err)
+	}
+	return c.JSON(user)
+}
+
+func (h *Handler) ListUsernameHistory(c *fiber.Ctx) error {
+	userID := c.Locals("userID").(int32)
+	history, err := h.service.ListUsernameHistory(c.Context(), userID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(fiber.Map{"usernames": history})
+}
+
+func usernameErrorResponse(c *fiber.Ctx, err error) error {
+	switch err {
+	case ErrInvalidUsernameLength, ErrInvalidUsernameChars, ErrReservedUsername, ErrUsernameUnchanged,
+		ErrDisplayNameTooLong, ErrInvalidDisplayName:
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	case ErrUsernameTaken:
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": err.Error()})
+	case ErrUsernameChangeCooldown:
+		return c.Status(fiber.StatusTooManyRequests).JSON(fiber.Map{"error": err.Error()})
+	default:
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+}
+
+func mfaErrorResponse(c *fiber.Ctx, err error) error {
+	switch err {
+	case ErrInvalidMfaTicket, ErrInvalidTotpCode:
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": err.Error()})
+	case ErrTotpAlreadyEnabled:
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": err.Error()})
+	case ErrTotpNotEnabled, ErrTotpNotEnrolled:
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	default:
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+}
+
 func RegisterAuthRoutes(app *fiber.App, service *Service) {
 	handler := NewHandler(service)
 	app.Post("/register", handler.Register)
 	app.Post("/login", handler.Login)
+	app.Post("/login/mfa", handler.LoginMfa)
+	app.Get("/login/oidc", handler.ListOIDCProviders)
+	app.Get("/login/oidc/:provider", handler.BeginOIDCLogin)
+	app.Post("/login/oidc/callback", handler.CompleteOIDCLogin)
 	app.Post("/refresh", handler.Refresh)
 }
+
+// RegisterAccountRoutes mounts the authenticated account endpoints.
+// Operations that weaken or change credentials, including the login handle,
+// require a fresh TOTP code.
+func RegisterAccountRoutes(api fiber.Router, service *Service) {
+	handler := NewHandler(service)
+	requireFreshTotp := middleware.RequireFreshTotp(service)
+
+	account := api.Group("/auth", middleware.RequireInteractive())
+	account.Post("/password", requireFreshTotp, handler.ChangePassword)
+	account.Patch("/profile", handler.UpdateProfile)
+	account.Post("/username", requireFreshTotp, handler.ChangeUsername)
+	account.Get("/username/history", handler.ListUsernameHistory)
+	account.Post("/mfa/totp/enroll", handler.EnrollTotp)
+	account.Post("/mfa/totp/verify", handler.VerifyTotp)
+	account.Delete("/mfa/totp", requireFreshTotp, handler.DisableTotp)
+	account.Post("/mfa/recovery-codes", requireFreshTotp, handler.RegenerateRecoveryCodes)
+
+	api.Post("/ws/ticket", middleware.RequireScope(middleware.ScopeMessagesRead, middleware.ScopeMessagesSend), handler.CreateWebSocketTicket)
+}
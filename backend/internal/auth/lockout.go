@@ -0,0 +1,86 @@
+package auth
+
+import (
+	"context"
+	"strings"
+	"time"
+)
+
+// LockoutPolicy locks an account after Threshold consecutive failed logins.
+// Each further failure doubles the lock, starting at BaseDuration and capped
+// at MaxDuration.
+type LockoutPolicy struct {
+	Threshold    int
+	BaseDuration time.Duration
+	MaxDuration  time.Duration
+}
+
+var DefaultLockoutPolicy = LockoutPolicy{
+	Threshold:    5,
+	BaseDuration: time.Minute,
+	MaxDuration:  time.Hour,
+}
+
+// failureMemory is how long failed attempts are remembered without a
+// successful login in between.
+const failureMemory = 24 * time.Hour
+
+type AccountLockedError struct {
+	RetryAfter time.Duration
+}
+
+func (e *AccountLockedError) Error() string {
+	return "account temporarily locked after repeated failed logins"
+}
+
+func (s *Service) SetLockoutPolicy(policy LockoutPolicy) {
+	s.lockout = policy
+}
+
+// Lockout state is keyed by the submitted username, whether or not it exists,
+// so responses do not reveal which accounts are real.
+func lockoutKeys(username string) (string, string) {
+	normalized := strings.ToLower(username)
+	return "login_failures:" + normalized, "login_lock:" + normalized
+}
+
+func (s *Service) checkLockout(ctx context.Context, username string) error {
+	_, lockKey := lockoutKeys(username)
+	ttl, err := s.redis.PTTL(ctx, lockKey).Result()
+	if err != nil || ttl <= 0 {
+		return nil
+	}
+	return &AccountLockedError{RetryAfter: ttl}
+}
+
+func (s *Service) recordFailedLogin(ctx context.Context, username string) {
+	if s.lockout.Threshold <= 0 {
+		return
+	}
+
+	failuresKey, lockKey := lockoutKeys(username)
+	failures, err := s.redis.Incr(ctx, failuresKey).Result()
+	if err != nil {
+		return
+	}
+	s.redis.Expire(ctx, failuresKey, failureMemory)
+
+	over := int(failures) - s.lockout.Threshold
+	if over < 0 {
+		return
+	}
+
+	duration := s.lockout.BaseDuration
+	for i := 0; i < over && duration < s.lockout.MaxDuration; i++ {
+		duration *= 2
+	}
+	if duration > s.lockout.MaxDuration {
+		duration = s.lockout.MaxDuration
+	}
+	s.redis.Set(ctx, lockKey, failures, duration)
+}
+
+func (s *Service) clearFailedLogins(ctx context.Context, username string) {
+	failuresKey, lockKey := lockoutKeys(username)
+	s.redis.Del(ctx, failuresKey, lockKey)
+}
@@ -0,0 +1,280 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base32"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	MfaTicketTTL         = 5 * time.Minute
+	mfaTicketMaxAttempts = 5
+	recoveryCodeCount    = 10
+)
+
+var (
+	ErrInvalidMfaTicket   = errors.New("invalid or expired mfa ticket")
+	ErrInvalidTotpCode    = errors.New("invalid two-factor code")
+	ErrTotpAlreadyEnabled = errors.New("two-factor authentication is already enabled")
+	ErrTotpNotEnabled     = errors.New("two-factor authentication is not enabled")
+	ErrTotpNotEnrolled    = errors.New("two-factor enrollment has not been started")
+	ErrFreshTotpRequired  = errors.New("a fresh two-factor code is required")
+)
+
+var recoveryCodeEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// mfaAttemptScript counts an attempt against a ticket and returns its user ID
+// with the new count, or nil if the ticket is gone. Counting before the code
+// is checked stops parallel guesses from all passing the limit, and the
+// EXISTS guard keeps a late attempt from recreating a deleted ticket without
+// a TTL.
+var mfaAttemptScript = redis.NewScript(`
+if redis.call('EXISTS', KEYS[1]) == 0 then
+  return false
+end
+local attempts = redis.call('HINCRBY', KEYS[1], 'attempts', 1)
+return {redis.call('HGET', KEYS[1], 'user_id'), attempts}
+`)
+
+// createMfaTicket stores a short-lived, single-use ticket proving the
+// password step of login succeeded for userID.
+func (s *Service) createMfaTicket(ctx context.Context, userID int32) (string, error) {
+	ticket, err := s.generateRefreshToken()
+	if err != nil {
+		return "", err
+	}
+
+	redisKey := "mfa_ticket:" + ticket
+	err = s.redis.HSet(ctx, redisKey, map[string]interface{}{
+		"user_id":  userID,
+		"attempts": 0,
+	}).Err()
+	if err != nil {
+		return "", err
+	}
+	s.redis.Expire(ctx, redisKey, MfaTicketTTL)
+
+	return ticket, nil
+}
+
+// CompleteMfaLogin redeems an MFA ticket with a TOTP or recovery code and
+// issues the same token pair as a password-only login.
+func (s *Service) CompleteMfaLogin(ctx context.Context, ticket, code string) (string, string, error) {
+	redisKey := "mfa_ticket:" + ticket
+	values, err := mfaAttemptScript.Run(ctx, s.redis, []string{redisKey}).Slice()
+	if err != nil || len(values) != 2 {
+		return "", "", ErrInvalidMfaTicket
+	}
+	rawUserID, _ := values[0].(string)
+	attempts, _ := values[1].(int64)
+
+	userID, err := strconv.ParseInt(rawUserID, 10, 32)
+	if err != nil {
+		return "", "", ErrInvalidMfaTicket
+	}
+	if attempts > mfaTicketMaxAttempts {
+		s.redis.Del(ctx, redisKey)
+		return "", "", ErrInvalidMfaTicket
+	}
+
+	if err := s.verifySecondFactor(ctx, int32(userID), code); err != nil {
+		if attempts >= mfaTicketMaxAttempts {
+			s.redis.Del(ctx, redisKey)
+		}
+		return "", "", err
+	}
+
+	// Tickets are single use, so a successful redemption burns it.
+	if deleted, err := s.redis.Del(ctx, redisKey).Result(); err != nil || deleted == 0 {
+		return "", "", ErrInvalidMfaTicket
+	}
+
+	user, err := s.repo.GetUserByID(ctx, int32(userID))
+	if err != nil {
+		return "", "", err
+	}
+	return s.issueTokens(ctx, user)
+}
+
+// BeginTotpEnrollment stores a new pending secret for the user and returns it
+// with an otpauth URI. 2FA stays disabled until ConfirmTotpEnrollment.
+func (s *Service) BeginTotpEnrollment(ctx context.Context, userID int32) (string, string, error) {
+	totp, err := s.repo.GetUserTotp(ctx, userID)
+	if err != nil {
+		return "", "", err
+	}
+	if totp.TotpEnabled {
+		return "", "", ErrTotpAlreadyEnabled
+	}
+
+	user, err := s.repo.GetUserByID(ctx, userID)
+	if err != nil {
+		return "", "", err
+	}
+
+	secret, err := generateTotpSecret()
+	if err != nil {
+		return "", "", err
+	}
+	if err := s.repo.SetUserTotpSecret(ctx, userID, secret); err != nil {
+		return "", "", err
+	}
+
+	return secret, totpURI(user.Username, secret), nil
+}
+
+// ConfirmTotpEnrollment enables 2FA once the user proves their authenticator
+// produces valid codes, and returns the plaintext recovery codes. They are
+// only stored hashed, so this is the one time they can be shown.
+func (s *Service) ConfirmTotpEnrollment(ctx context.Context, userID int32, code string) ([]string, error) {
+	totp, err := s.repo.GetUserTotp(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if totp.TotpEnabled {
+		return nil, ErrTotpAlreadyEnabled
+	}
+	if !totp.TotpSecret.Valid {
+		return nil, ErrTotpNotEnrolled
+	}
+	if !s.consumeTotp(ctx, userID, totp.TotpSecret.String, code) {
+		return nil, ErrInvalidTotpCode
+	}
+
+	codes, hashes, err := generateRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+	if err := s.repo.EnableUserTotp(ctx, userID, hashes); err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// DisableTotp turns 2FA off. Callers are expected to have verified a fresh
+// code through VerifyFreshTotp first.
+func (s *Service) DisableTotp(ctx context.Context, userID int32) error {
+	totp, err := s.repo.GetUserTotp(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if !totp.TotpEnabled {
+		return ErrTotpNotEnabled
+	}
+	return s.repo.DisableUserTotp(ctx, userID)
+}
+
+// RegenerateRecoveryCodes replaces every recovery code, used or not.
+func (s *Service) RegenerateRecoveryCodes(ctx context.Context, userID int32) ([]string, error) {
+	totp, err := s.repo.GetUserTotp(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if !totp.TotpEnabled {
+		return nil, ErrTotpNotEnabled
+	}
+
+	codes, hashes, err := generateRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+	if err := s.repo.ReplaceRecoveryCodes(ctx, userID, hashes); err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// VerifyFreshTotp guards sensitive operations. Users without 2FA pass
+// through; users with 2FA must supply a current, not yet used TOTP code or an
+// unused recovery code, which is consumed. Accepting recovery codes lets a
+// user who lost their authenticator still reset 2FA.
+func (s *Service) VerifyFreshTotp(ctx context.Context, userID int32, code string) error {
+	totp, err := s.repo.GetUserTotp(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if !totp.TotpEnabled {
+		return nil
+	}
+	if code == "" {
+		return ErrFreshTotpRequired
+	}
+	return s.consumeSecondFactor(ctx, userID, totp.TotpSecret.String, code)
+}
+
+func (s *Service) verifySecondFactor(ctx context.Context, userID int32, code string) error {
+	totp, err := s.repo.GetUserTotp(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if !totp.TotpEnabled {
+		return ErrTotpNotEnabled
+	}
+	return s.consumeSecondFactor(ctx, userID, totp.TotpSecret.String, code)
+}
+
+// consumeSecondFactor accepts a fresh TOTP code or burns a recovery code.
+func (s *Service) consumeSecondFactor(ctx context.Context, userID int32, secret, code string) error {
+	if s.consumeTotp(ctx, userID, secret, code) {
+		return nil
+	}
+
+	used, err := s.repo.UseRecoveryCode(ctx, userID, hashRecoveryCode(code))
+	if err != nil {
+		return err
+	}
+	if !used {
+		return ErrInvalidTotpCode
+	}
+	return nil
+}
+
+// consumeTotp validates code and records its time step in Redis so the same
+// code cannot be replayed within its validity window.
+func (s *Service) consumeTotp(ctx context.Context, userID int32, secret, code string) bool {
+	step, ok := validateTotp(secret, code, time.Now())
+	if !ok {
+		return false
+	}
+
+	redisKey := fmt.Sprintf("totp_used:%d:%d", userID, step)
+	window := time.Duration(2*totpSkew+1) * totpPeriod
+	fresh, err := s.redis.SetNX(ctx, redisKey, 1, window).Result()
+	if err != nil {
+		return false
+	}
+	return fresh
+}
+
+func generateRecoveryCodes() ([]string, []string, error) {
+	codes := make([]string, 0, recoveryCodeCount)
+	hashes := make([]string, 0, recoveryCodeCount)
+	for i := 0; i < recoveryCodeCount; i++ {
+		b := make([]byte, 6)
+		if _, err := rand.Read(b); err != nil {
+			return nil, nil, err
+		}
+		raw := strings.ToLower(recoveryCodeEncoding.EncodeToString(b))[:10]
+		code := raw[:5] + "-" + raw[5:]
+		codes = append(codes, code)
+		hashes = append(hashes, hashRecoveryCode(code))
+	}
+	return codes, hashes, nil
+}
+
+// Recovery codes are random and high-entropy, so a plain SHA-256 is enough
+// and lets us look them up directly instead of bcrypt-comparing each one.
+func hashRecoveryCode(code string) string {
+	normalized := strings.ToLower(strings.NewReplacer("-", "", " ", "").Replace(code))
+	sum := sha256.Sum256([]byte(normalized))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,239 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/andrelcunha/Concord/backend/internal/db"
+	"github.com/andrelcunha/Concord/backend/pkg/dtos"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/jackc/pgx/v5"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+)
+
+// mockOIDCServer is a minimal OpenID Connect provider: discovery, JWKS and a
+// token endpoint that enforces PKCE. Authorization is simulated by authorize.
+type mockOIDCServer struct {
+	*httptest.Server
+	key      *rsa.PrivateKey
+	clientID string
+
+	mu    sync.Mutex
+	codes map[string]mockAuthorization
+}
+
+type mockAuthorization struct {
+	challenge string
+	nonce     string
+	subject   string
+	username  string
+}
+
+func newMockOIDCServer(t *testing.T, clientID string) *mockOIDCServer {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+
+	m := &mockOIDCServer{key: key, clientID: clientID, codes: map[string]mockAuthorization{}}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]string{
+			"issuer":                 m.URL,
+			"authorization_endpoint": m.URL + "/authorize",
+			"token_endpoint":         m.URL + "/token",
+			"jwks_uri":               m.URL + "/jwks",
+		})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"keys": []map[string]string{{
+				"kty": "RSA",
+				"kid": "test-key",
+				"use": "sig",
+				"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+				"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+			}},
+		})
+	})
+	mux.HandleFunc("/token", m.handleToken)
+	m.Server = httptest.NewServer(mux)
+	t.Cleanup(m.Close)
+	return m
+}
+
+// authorize plays the user approving the login and returns the code the
+// provider would send to the redirect URL.
+func (m *mockOIDCServer) authorize(t *testing.T, authorizationURL, subject, username string) (string, string) {
+	parsed, err := url.Parse(authorizationURL)
+	assert.NoError(t, err)
+	query := parsed.Query()
+	assert.Equal(t, m.clientID, query.Get("client_id"))
+	assert.Equal(t, "S256", query.Get("code_challenge_method"))
+	assert.Contains(t, query.Get("scope"), "openid")
+
+	code := "code-" + subject + "-" + query.Get("state")[:8]
+	m.mu.Lock()
+	m.codes[code] = mockAuthorization{
+		challenge: query.Get("code_challenge"),
+		nonce:     query.Get("nonce"),
+		subject:   subject,
+		username:  username,
+	}
+	m.mu.Unlock()
+	return code, query.Get("state")
+}
+
+func (m *mockOIDCServer) handleToken(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "bad form", http.StatusBadRequest)
+		return
+	}
+
+	m.mu.Lock()
+	authorization, ok := m.codes[r.PostForm.Get("code")]
+	delete(m.codes, r.PostForm.Get("code"))
+	m.mu.Unlock()
+
+	verifierHash := sha256.Sum256([]byte(r.PostForm.Get("code_verifier")))
+	if !ok || base64.RawURLEncoding.EncodeToString(verifierHash[:]) != authorization.challenge {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "invalid_grant"})
+		return
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{
+		"iss":                m.URL,
+		"sub":                authorization.subject,
+		"aud":                m.clientID,
+		"exp":                time.Now().Add(time.Minute).Unix(),
+		"iat":                time.Now().Unix(),
+		"nonce":              authorization.nonce,
+		"preferred_username": authorization.username,
+		"email":              authorization.username + "@example.com",
+	})
+	token.Header["kid"] = "test-key"
+	idToken, err := token.SignedString(m.key)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"access_token": "provider-access-token",
+		"token_type":   "Bearer",
+		"expires_in":   60,
+		"id_token":     idToken,
+	})
+}
+
+func TestService_OIDCLogin(t *testing.T) {
+	ctx := context.Background()
+	idp := newMockOIDCServer(t, "concord")
+
+	provider, err := NewOIDCProvider(ctx, OIDCProviderConfig{
+		Name:        "mock",
+		IssuerURL:   idp.URL,
+		ClientID:    "concord",
+		RedirectURL: "http://localhost:5173/login/sso/callback",
+		Scopes:      []string{"profile", "email"},
+	})
+	assert.NoError(t, err)
+
+	identities := map[string]int32{}
+	users := map[int32]*dtos.UserDto{}
+	mockRepo := &mockRepository{
+		getUserIDByIdentityFunc: func(ctx context.Context, issuer, subject string) (int32, error) {
+			if userID, ok := identities[issuer+"|"+subject]; ok {
+				return userID, nil
+			}
+			return 0, pgx.ErrNoRows
+		},
+		usernameTakenFunc: func(ctx context.Context, username string, userID int32) (bool, error) {
+			return username == "alice", nil
+		},
+		createUserWithIdentityFunc: func(ctx context.Context, user *dtos.UserDto, identity ExternalIdentity) (*dtos.UserDto, error) {
+			user.UserId = int32(len(users) + 1)
+			users[user.UserId] = user
+			identities[identity.Issuer+"|"+identity.Subject] = user.UserId
+			return user, nil
+		},
+		getUserTotpFunc: func(ctx context.Context, userID int32) (db.GetUserTotpRow, error) {
+			return db.GetUserTotpRow{ID: userID}, nil
+		},
+		getUserByIDFunc: func(ctx context.Context, userID int32) (*dtos.UserDto, error) {
+			return users[userID], nil
+		},
+	}
+	mr, err := miniredis.Run()
+	if err != nil {
+		t.Fatalf("failed to start miniredis: %v", err)
+	}
+	t.Cleanup(mr.Close)
+	service := NewService(mockRepo, redis.NewClient(&redis.Options{Addr: mr.Addr()}), "testsecret")
+	service.AddOIDCProvider(provider)
+	assert.Equal(t, []string{"mock"}, service.OIDCProviders())
+
+	_, err = service.BeginOIDCLogin(ctx, "unknown")
+	assert.Equal(t, ErrUnknownOIDCProvider, err)
+
+	// First login provisions a user, avoiding the taken username
+	authorizationURL, err := service.BeginOIDCLogin(ctx, "mock")
+	assert.NoError(t, err)
+	code, state := idp.authorize(t, authorizationURL, "alice-sub", "alice")
+
+	result, err := service.CompleteOIDCLogin(ctx, state, code)
+	assert.NoError(t, err)
+	assert.False(t, result.MfaRequired())
+	assert.NotEmpty(t, result.AccessToken)
+	assert.NotEmpty(t, result.RefreshToken)
+	assert.Len(t, users, 1)
+	assert.True(t, strings.HasPrefix(users[1].Username, "alice-"))
+
+	// State is single use
+	_, err = service.CompleteOIDCLogin(ctx, state, code)
+	assert.Equal(t, ErrInvalidOIDCState, err)
+
+	// Second login reuses the linked user
+	authorizationURL, err = service.BeginOIDCLogin(ctx, "mock")
+	assert.NoError(t, err)
+	code, state = idp.authorize(t, authorizationURL, "alice-sub", "alice")
+	result, err = service.CompleteOIDCLogin(ctx, state, code)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, result.AccessToken)
+	assert.Len(t, users, 1)
+
+	// A code bound to another login's PKCE challenge is rejected
+	firstURL, err := service.BeginOIDCLogin(ctx, "mock")
+	assert.NoError(t, err)
+	secondURL, err := service.BeginOIDCLogin(ctx, "mock")
+	assert.NoError(t, err)
+	code, _ = idp.authorize(t, firstURL, "mallory-sub", "mallory")
+	_, secondState := idp.authorize(t, secondURL, "mallory-sub", "mallory")
+	_, err = service.CompleteOIDCLogin(ctx, secondState, code)
+	assert.Equal(t, ErrOIDCLoginFailed, err)
+	assert.Len(t, users, 1)
+}
+
+func TestSanitizeUsername(t *testing.T) {
+	assert.Equal(t, "jane.doe", sanitizeUsername("jane.doe"))
+	assert.Equal(t, "Jane-Doe", sanitizeUsername("Jane Doe"))
+	assert.Equal(t, "", sanitizeUsername("  "))
+	assert.Len(t, sanitizeUsername(strings.Repeat("a", 80)), MaxUsernameLength)
+}
@@ -2,9 +2,11 @@ package auth
 
 import (
 	"context"
+	"time"
 
 	"github.com/andrelcunha/Concord/backend/internal/db"
 	"github.com/andrelcunha/Concord/backend/pkg/dtos"
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgtype"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
@@ -14,16 +16,36 @@ type Repository interface {
 	GetUserByUsername(ctx context.Context, username string) (*dtos.UserDto, error)
 
 	GetUserByID(ctx context.Context, userID int32) (*dtos.UserDto, error)
+	GetUserCredentialsByID(ctx context.Context, userID int32) (*dtos.UserDto, error)
+	UpdateUserPassword(ctx context.Context, userID int32, hashedPassword string) error
+
+	GetUserTotp(ctx context.Context, userID int32) (db.GetUserTotpRow, error)
+	SetUserTotpSecret(ctx context.Context, userID int32, secret string) error
+	EnableUserTotp(ctx context.Context, userID int32, recoveryCodeHashes []string) error
+	DisableUserTotp(ctx context.Context, userID int32) error
+	ReplaceRecoveryCodes(ctx context.Context, userID int32, recoveryCodeHashes []string) error
+	UseRecoveryCode(ctx context.Context, userID int32, codeHash string) (bool, error)
+
+	GetUserIDByIdentity(ctx context.Context, issuer, subject string) (int32, error)
+	CreateUserWithIdentity(ctx context.Context, user *dtos.UserDto, identity ExternalIdentity) (*dtos.UserDto, error)
+	UsernameTaken(ctx context.Context, username string, userID int32) (bool, error)
+
+	UpdateDisplayName(ctx context.Context, userID int32, displayName string) error
+	GetUsernameChangedAt(ctx context.Context, userID int32) (time.Time, error)
+	ChangeUsername(ctx context.Context, userID int32, oldUsername, newUsername string, reservedUntil time.Time) error
+	ListUsernameHistory(ctx context.Context, userID int32) ([]db.ListUsernameHistoryRow, error)
 }
 
 type repository struct {
-	db *db.Queries
+	pool *pgxpool.Pool
+	db   *db.Queries
 }
 
 func NewRepository(dbPool *pgxpool.Pool) Repository {
 	db := db.New(dbPool)
 	return &repository{
-		db: db,
+		pool: dbPool,
+		db:   db,
 	}
 }
 
@@ -32,6 +54,7 @@ func (r *repository) CreateUser(ctx context.Context, user *dtos.UserDto) (*dtos.
 		Username:    user.Username,
 		Password:    user.Password,
 		AvatarColor: pgtype.Text{String: user.AvatarColor, Valid: true},
+		DisplayName: pgtype.Text{String: user.DisplayName, Valid: user.DisplayName != ""},
 	})
 	if err != nil {
 		return nil, err
@@ -40,6 +63,7 @@ func (r *repository) CreateUser(ctx context.Context, user *dtos.UserDto) (*dtos.
 	return &dtos.UserDto{
 		UserId:      userDb.ID,
 		Username:    userDb.Username,
+		DisplayName: userDb.DisplayName.String,
 		AvatarUrl:   userDb.AvatarUrl.String,
 		AvatarColor: userDb.AvatarColor.String,
 	}, nil
@@ -53,6 +77,7 @@ func (r *repository) GetUserByID(ctx context.Context, userID int32) (*dtos.UserD
 	return &dtos.UserDto{
 		UserId:      userDb.ID,
 		Username:    userDb.Username,
+		DisplayName: userDb.DisplayName.String,
 		AvatarUrl:   userDb.AvatarUrl.String,
 		AvatarColor: userDb.AvatarColor.String,
 	}, nil
@@ -64,8 +89,230 @@ func (r *repository) GetUserByUsername(ctx context.Context, username string) (*d
 		return nil, err
 	}
 	return &dtos.UserDto{
-		UserId:   user.ID,
-		Username: user.Username,
-		Password: user.Password,
+		UserId:      user.ID,
+		Username:    user.Username,
+		Password:    user.Password,
+		TotpEnabled: user.TotpEnabled,
+		IsBot:       user.IsBot,
+	}, nil
+}
+
+func (r *repository) GetUserCredentialsByID(ctx context.Context, userID int32) (*dtos.UserDto, error) {
+	user, err := r.db.GetUserCredentialsByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	return &dtos.UserDto{
+		UserId:      user.ID,
+		Username:    user.Username,
+		Password:    user.Password,
+		TotpEnabled: user.TotpEnabled,
 	}, nil
 }
+
+func (r *repository) UpdateUserPassword(ctx context.Context, userID int32, hashedPassword string) error {
+	return r.db.UpdateUserPassword(ctx, db.UpdateUserPasswordParams{
+		ID:       userID,
+		Password: hashedPassword,
+	})
+}
+
+func (r *repository) GetUserTotp(ctx context.Context, userID int32) (db.GetUserTotpRow, error) {
+	return r.db.GetUserTotp(ctx, userID)
+}
+
+func (r *repository) SetUserTotpSecret(ctx context.Context, userID int32, secret string) error {
+	return r.db.SetUserTotpSecret(ctx, db.SetUserTotpSecretParams{
+		ID:         userID,
+		TotpSecret: pgtype.Text{String: secret, Valid: true},
+	})
+}
+
+// EnableUserTotp flips the enabled flag and stores the initial recovery codes
+// in one transaction so a user never ends up with 2FA but no way to recover.
+func (r *repository) EnableUserTotp(ctx context.Context, userID int32, recoveryCodeHashes []string) error {
+	tx, err := r.pool.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return err
+	}
+
+	queries := db.New(tx)
+	if err := queries.EnableUserTotp(ctx, userID); err != nil {
+		tx.Rollback(ctx)
+		return err
+	}
+	if err := replaceRecoveryCodes(ctx, queries, userID, recoveryCodeHashes); err != nil {
+		tx.Rollback(ctx)
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+func (r *repository) DisableUserTotp(ctx context.Context, userID int32) error {
+	tx, err := r.pool.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return err
+	}
+
+	queries := db.New(tx)
+	if err := queries.DisableUserTotp(ctx, userID); err != nil {
+		tx.Rollback(ctx)
+		return err
+	}
+	if err := queries.DeleteRecoveryCodesForUser(ctx, userID); err != nil {
+		tx.Rollback(ctx)
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+func (r *repository) ReplaceRecoveryCodes(ctx context.Context, userID int32, recoveryCodeHashes []string) error {
+	tx, err := r.pool.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return err
+	}
+
+	if err := replaceRecoveryCodes(ctx, db.New(tx), userID, recoveryCodeHashes); err != nil {
+		tx.Rollback(ctx)
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+func (r *repository) UseRecoveryCode(ctx context.Context, userID int32, codeHash string) (bool, error) {
+	rows, err := r.db.UseRecoveryCode(ctx, db.UseRecoveryCodeParams{
+		UserID:   userID,
+		CodeHash: codeHash,
+	})
+	if err != nil {
+		return false, err
+	}
+	return rows == 1, nil
+}
+
+func (r *repository) GetUserIDByIdentity(ctx context.Context, issuer, subject string) (int32, error) {
+	return r.db.GetUserIDByIdentity(ctx, db.GetUserIDByIdentityParams{
+		Issuer:  issuer,
+		Subject: subject,
+	})
+}
+
+// CreateUserWithIdentity provisions a user and links the external identity in
+// one transaction, so a failed link never leaves an orphaned account behind.
+func (r *repository) CreateUserWithIdentity(ctx context.Context, user *dtos.UserDto, identity ExternalIdentity) (*dtos.UserDto, error) {
+	tx, err := r.pool.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	queries := db.New(tx)
+	userDb, err := queries.CreateUser(ctx, db.CreateUserParams{
+		Username:    user.Username,
+		Password:    user.Password,
+		AvatarColor: pgtype.Text{String: user.AvatarColor, Valid: true},
+		DisplayName: pgtype.Text{String: user.DisplayName, Valid: user.DisplayName != ""},
+	})
+	if err != nil {
+		tx.Rollback(ctx)
+		return nil, err
+	}
+
+	err = queries.CreateUserIdentity(ctx, db.CreateUserIdentityParams{
+		UserID:  userDb.ID,
+		Issuer:  identity.Issuer,
+		Subject: identity.Subject,
+		Email:   pgtype.Text{String: identity.Email, Valid: identity.Email != ""},
+	})
+	if err != nil {
+		tx.Rollback(ctx)
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+
+	return &dtos.UserDto{
+		UserId:      userDb.ID,
+		Username:    userDb.Username,
+		DisplayName: userDb.DisplayName.String,
+		AvatarUrl:   userDb.AvatarUrl.String,
+		AvatarColor: userDb.AvatarColor.String,
+	}, nil
+}
+
+func (r *repository) UsernameTaken(ctx context.Context, username string, userID int32) (bool, error) {
+	return r.db.UsernameTaken(ctx, db.UsernameTakenParams{
+		Username: username,
+		UserID:   userID,
+	})
+}
+
+// UpdateDisplayName stores an empty name as NULL so it falls back to the
+// username.
+func (r *repository) UpdateDisplayName(ctx context.Context, userID int32, displayName string) error {
+	return r.db.UpdateUserDisplayName(ctx, db.UpdateUserDisplayNameParams{
+		ID:          userID,
+		DisplayName: pgtype.Text{String: displayName, Valid: displayName != ""},
+	})
+}
+
+// GetUsernameChangedAt returns the zero time for users who never changed
+// their username.
+func (r *repository) GetUsernameChangedAt(ctx context.Context, userID int32) (time.Time, error) {
+	user, err := r.db.GetUserByID(ctx, userID)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return user.UsernameChangedAt.Time, nil
+}
+
+// ChangeUsername records the old username and switches to the new one in a
+// single transaction, so a handle is never freed without its reservation.
+func (r *repository) ChangeUsername(ctx context.Context, userID int32, oldUsername, newUsername string, reservedUntil time.Time) error {
+	tx, err := r.pool.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return err
+	}
+
+	queries := db.New(tx)
+	if err := queries.CreateUsernameHistory(ctx, db.CreateUsernameHistoryParams{
+		UserID:        userID,
+		Username:      oldUsername,
+		ReservedUntil: pgtype.Timestamptz{Time: reservedUntil, Valid: true},
+	}); err != nil {
+		tx.Rollback(ctx)
+		return err
+	}
+	if err := queries.UpdateUsername(ctx, db.UpdateUsernameParams{
+		ID:       userID,
+		Username: newUsername,
+	}); err != nil {
+		tx.Rollback(ctx)
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+func (r *repository) ListUsernameHistory(ctx context.Context, userID int32) ([]db.ListUsernameHistoryRow, error) {
+	return r.db.ListUsernameHistory(ctx, userID)
+}
+
+func replaceRecoveryCodes(ctx context.Context, queries *db.Queries, userID int32, recoveryCodeHashes []string) error {
+	if err := queries.DeleteRecoveryCodesForUser(ctx, userID); err != nil {
+		return err
+	}
+	for _, hash := range recoveryCodeHashes {
+		if err := queries.CreateRecoveryCode(ctx, db.CreateRecoveryCodeParams{
+			UserID:   userID,
+			CodeHash: hash,
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -6,9 +6,11 @@ import (
 	"encoding/base64"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"log"
 	mathrand "math/rand"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/andrelcunha/Concord/backend/pkg/dtos"
@@ -27,23 +29,35 @@ var (
 	ErrInvalidCredentials  = errors.New("invalid credentials")
 	ErrInvalidRefreshToken = errors.New("invalid refresh token")
 	ErrExpiredRefreshToken = errors.New("expired refresh token")
+	ErrInvalidPassword     = errors.New("new password must not be empty")
 )
 
 type Service struct {
-	repo   Repository
-	redis  *redis.Client
-	secret string
+	repo          Repository
+	redis         *redis.Client
+	secret        string
+	lockout       LockoutPolicy
+	oidcProviders map[string]*OIDCProvider
 }
 
 func NewService(repo Repository, redis *redis.Client, secret string) *Service {
 	return &Service{
-		repo:   repo,
-		redis:  redis,
-		secret: secret,
+		repo:          repo,
+		redis:         redis,
+		secret:        secret,
+		lockout:       DefaultLockoutPolicy,
+		oidcProviders: map[string]*OIDCProvider{},
 	}
 }
 
+// Register creates an account with the given handle. The display name is
+// left empty and falls back to the handle until the user sets one.
 func (s *Service) Register(ctx context.Context, username, password string) (*dtos.UserDto, error) {
+	username = strings.TrimSpace(username)
+	if err := s.checkUsernameAvailable(ctx, username, 0); err != nil {
+		return nil, err
+	}
+
 	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
 	if err != nil {
 		return nil, err
@@ -62,26 +76,73 @@ func (s *Service) Register(ctx context.Context, username, password string) (*dto
 	return newUser, nil
 }
 
-func (s *Service) Login(ctx context.Context, username, password string) (string, string, error) {
-	userID, ok, err := authUser(ctx, s, username, password)
+// LoginResult carries either a token pair or, for users with two-factor
+// enabled, an MFA ticket to redeem through CompleteMfaLogin.
+type LoginResult struct {
+	AccessToken  string
+	RefreshToken string
+	MfaTicket    string
+}
+
+func (r *LoginResult) MfaRequired() bool {
+	return r.MfaTicket != ""
+}
+
+func (s *Service) Login(ctx context.Context, username, password string) (*LoginResult, error) {
+	authenticated, ok, err := authUser(ctx, s, username, password)
 	if !ok {
-		return "", "", err
+		return nil, err
 	}
+
+	return s.loginResultFor(ctx, authenticated.UserId, authenticated.TotpEnabled)
+}
+
+// loginResultFor finishes a login whose first factor has been verified,
+// either by password or by an identity provider.
+func (s *Service) loginResultFor(ctx context.Context, userID int32, totpEnabled bool) (*LoginResult, error) {
+	if totpEnabled {
+		ticket, err := s.createMfaTicket(ctx, userID)
+		if err != nil {
+			return nil, err
+		}
+		return &LoginResult{MfaTicket: ticket}, nil
+	}
+
 	user, err := s.repo.GetUserByID(ctx, userID)
 	if err != nil {
-		return "", "", err
+		return nil, err
 	}
 
+	accessToken, refreshToken, err := s.issueTokens(ctx, user)
+	if err != nil {
+		return nil, err
+	}
+	return &LoginResult{AccessToken: accessToken, RefreshToken: refreshToken}, nil
+}
+
+// issueTokens creates a new access token and stores a fresh refresh token.
+func (s *Service) issueTokens(ctx context.Context, user *dtos.UserDto) (string, string, error) {
 	accesToken, err := s.generateAccessToken(user)
 	if err != nil {
 		return "", "", err
 	}
 
-	refreshToken, err := s.generateRefreshToken()
+	refreshToken, err := s.storeRefreshToken(ctx, user)
 	if err != nil {
 		return "", "", err
 	}
 
+	return accesToken, refreshToken, nil
+}
+
+// storeRefreshToken saves a new refresh token for user and indexes it under
+// the user so every session can be revoked at once.
+func (s *Service) storeRefreshToken(ctx context.Context, user *dtos.UserDto) (string, error) {
+	refreshToken, err := s.generateRefreshToken()
+	if err != nil {
+		return "", err
+	}
+
 	redisKey := "refresh_token:" + refreshToken
 	err = s.redis.HSet(ctx, redisKey, map[string]interface{}{
 		"user_id":      user.UserId,
@@ -91,26 +152,93 @@ func (s *Service) Login(ctx context.Context, username, password string) (string,
 		"expires_at":   time.Now().Add(RefreshTokenTTL).Format(time.RFC3339),
 	}).Err()
 	if err != nil {
-		return "", "", err
+		return "", err
 	}
 	s.redis.Expire(ctx, redisKey, RefreshTokenTTL)
 
-	return accesToken, refreshToken, nil
+	sessionsKey := userRefreshTokensKey(user.UserId)
+	if err := s.redis.SAdd(ctx, sessionsKey, refreshToken).Err(); err != nil {
+		return "", err
+	}
+	s.redis.Expire(ctx, sessionsKey, RefreshTokenTTL)
+
+	return refreshToken, nil
 }
 
-func authUser(ctx context.Context, s *Service, username string, password string) (int32, bool, error) {
+func userRefreshTokensKey(userID int32) string {
+	return fmt.Sprintf("user_refresh_tokens:%d", userID)
+}
+
+// RevokeRefreshTokens ends every session of the user. Access tokens already
+// issued stay valid until they expire.
+func (s *Service) RevokeRefreshTokens(ctx context.Context, userID int32) error {
+	sessionsKey := userRefreshTokensKey(userID)
+	tokens, err := s.redis.SMembers(ctx, sessionsKey).Result()
+	if err != nil {
+		return err
+	}
+
+	keys := make([]string, 0, len(tokens)+1)
+	for _, token := range tokens {
+		keys = append(keys, "refresh_token:"+token)
+	}
+	keys = append(keys, sessionsKey)
+	return s.redis.Del(ctx, keys...).Err()
+}
+
+func authUser(ctx context.Context, s *Service, username string, password string) (*dtos.UserDto, bool, error) {
+	if err := s.checkLockout(ctx, username); err != nil {
+		return nil, false, err
+	}
+
 	user, err := s.repo.GetUserByUsername(ctx, username)
 	if err != nil {
+		s.recordFailedLogin(ctx, username)
 		if err.Error() == "no rows in result set" {
-			return 0, false, ErrInvalidCredentials
+			return nil, false, ErrInvalidCredentials
 		}
-		return 0, false, ErrInvalidCredentials
+		return nil, false, ErrInvalidCredentials
+	}
+
+	// Bots authenticate with bot tokens only
+	if user.IsBot {
+		s.recordFailedLogin(ctx, username)
+		return nil, false, ErrInvalidCredentials
 	}
 
 	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(password)); err != nil {
-		return 0, false, errors.New("invalid credentials")
+		s.recordFailedLogin(ctx, username)
+		return nil, false, errors.New("invalid credentials")
+	}
+
+	s.clearFailedLogins(ctx, username)
+	return user, true, nil
+}
+
+// ChangePassword replaces the user's password after checking the current one
+// and revokes every refresh token, so a stolen session does not survive it.
+// The route is expected to be guarded by a fresh TOTP check.
+func (s *Service) ChangePassword(ctx context.Context, userID int32, currentPassword, newPassword string) error {
+	if newPassword == "" {
+		return ErrInvalidPassword
+	}
+
+	user, err := s.repo.GetUserCredentialsByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(currentPassword)); err != nil {
+		return ErrInvalidCredentials
+	}
+
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(newPassword), bcrypt.DefaultCost)
+	if err != nil {
+		return err
+	}
+	if err := s.repo.UpdateUserPassword(ctx, userID, string(hashedPassword)); err != nil {
+		return err
 	}
-	return user.UserId, true, nil
+	return s.RevokeRefreshTokens(ctx, userID)
 }
 
 func (s *Service) Refresh(ctx context.Context, refreshToken string) (string, string, error) {
@@ -168,25 +296,12 @@ func (s *Service) Refresh(ctx context.Context, refreshToken string) (string, str
 		return "", "", err
 	}
 
-	// Generate a new refresh token (rotation)
-	newRefreshToken, err := s.generateRefreshToken()
-	if err != nil {
-		return "", "", err
-	}
-
-	// Store new refresh token in Redis
-	newRedisKey := "refresh_token:" + newRefreshToken
-	err = s.redis.HSet(ctx, newRedisKey, map[string]interface{}{
-		"user_id":      userID,
-		"username":     username,
-		"avatar_url":   user.AvatarUrl,
-		"avatar_color": user.AvatarColor,
-		"expires_at":   time.Now().Add(RefreshTokenTTL).Format(time.RFC3339),
-	}).Err()
+	// Generate and store a new refresh token (rotation)
+	s.redis.SRem(ctx, userRefreshTokensKey(int32(userID)), refreshToken)
+	newRefreshToken, err := s.storeRefreshToken(ctx, user)
 	if err != nil {
 		return "", "", err
 	}
-	s.redis.Expire(ctx, newRedisKey, RefreshTokenTTL)
 
 	return accessToken, newRefreshToken, nil
 }
@@ -3,21 +3,39 @@ package auth
 import (
 	"context"
 	"errors"
+	"strconv"
 	"testing"
 	"time"
 
 	"github.com/alicebob/miniredis/v2"
+	"github.com/andrelcunha/Concord/backend/internal/db"
 	"github.com/andrelcunha/Concord/backend/pkg/dtos"
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/jackc/pgx/v5/pgtype"
 	"github.com/redis/go-redis/v9"
 	"github.com/stretchr/testify/assert"
 	"golang.org/x/crypto/bcrypt"
 )
 
 type mockRepository struct {
-	createUserFunc  func(ctx context.Context, user *dtos.UserDto) (*dtos.UserDto, error)
-	getUserFunc     func(ctx context.Context, username string) (*dtos.UserDto, error)
-	getUserByIDFunc func(ctx context.Context, userID int32) (*dtos.UserDto, error)
+	createUserFunc             func(ctx context.Context, user *dtos.UserDto) (*dtos.UserDto, error)
+	getUserFunc                func(ctx context.Context, username string) (*dtos.UserDto, error)
+	getUserByIDFunc            func(ctx context.Context, userID int32) (*dtos.UserDto, error)
+	getUserCredentialsFunc     func(ctx context.Context, userID int32) (*dtos.UserDto, error)
+	updateUserPasswordFunc     func(ctx context.Context, userID int32, hashedPassword string) error
+	getUserTotpFunc            func(ctx context.Context, userID int32) (db.GetUserTotpRow, error)
+	setUserTotpSecretFunc      func(ctx context.Context, userID int32, secret string) error
+	enableUserTotpFunc         func(ctx context.Context, userID int32, recoveryCodeHashes []string) error
+	disableUserTotpFunc        func(ctx context.Context, userID int32) error
+	replaceRecoveryCodesFunc   func(ctx context.Context, userID int32, recoveryCodeHashes []string) error
+	useRecoveryCodeFunc        func(ctx context.Context, userID int32, codeHash string) (bool, error)
+	getUserIDByIdentityFunc    func(ctx context.Context, issuer, subject string) (int32, error)
+	createUserWithIdentityFunc func(ctx context.Context, user *dtos.UserDto, identity ExternalIdentity) (*dtos.UserDto, error)
+	usernameTakenFunc          func(ctx context.Context, username string, userID int32) (bool, error)
+	updateDisplayNameFunc      func(ctx context.Context, userID int32, displayName string) error
+	getUsernameChangedAtFunc   func(ctx context.Context, userID int32) (time.Time, error)
+	changeUsernameFunc         func(ctx context.Context, userID int32, oldUsername, newUsername string, reservedUntil time.Time) error
+	listUsernameHistoryFunc    func(ctx context.Context, userID int32) ([]db.ListUsernameHistoryRow, error)
 }
 
 func (m *mockRepository) CreateUser(ctx context.Context, user *dtos.UserDto) (*dtos.UserDto, error) {
@@ -32,6 +50,66 @@ func (m *mockRepository) GetUserByID(ctx context.Context, userID int32) (*dtos.U
 	return m.getUserByIDFunc(ctx, userID)
 }
 
+func (m *mockRepository) GetUserCredentialsByID(ctx context.Context, userID int32) (*dtos.UserDto, error) {
+	return m.getUserCredentialsFunc(ctx, userID)
+}
+
+func (m *mockRepository) UpdateUserPassword(ctx context.Context, userID int32, hashedPassword string) error {
+	return m.updateUserPasswordFunc(ctx, userID, hashedPassword)
+}
+
+func (m *mockRepository) GetUserTotp(ctx context.Context, userID int32) (db.GetUserTotpRow, error) {
+	return m.getUserTotpFunc(ctx, userID)
+}
+
+func (m *mockRepository) SetUserTotpSecret(ctx context.Context, userID int32, secret string) error {
+	return m.setUserTotpSecretFunc(ctx, userID, secret)
+}
+
+func (m *mockRepository) EnableUserTotp(ctx context.Context, userID int32, recoveryCodeHashes []string) error {
+	return m.enableUserTotpFunc(ctx, userID, recoveryCodeHashes)
+}
+
+func (m *mockRepository) DisableUserTotp(ctx context.Context, userID int32) error {
+	return m.disableUserTotpFunc(ctx, userID)
+}
+
+func (m *mockRepository) ReplaceRecoveryCodes(ctx context.Context, userID int32, recoveryCodeHashes []string) error {
+	return m.replaceRecoveryCodesFunc(ctx, userID, recoveryCodeHashes)
+}
+
+func (m *mockRepository) UseRecoveryCode(ctx context.Context, userID int32, codeHash string) (bool, error) {
+	return m.useRecoveryCodeFunc(ctx, userID, codeHash)
+}
+
+func (m *mockRepository) GetUserIDByIdentity(ctx context.Context, issuer, subject string) (int32, error) {
+	return m.getUserIDByIdentityFunc(ctx, issuer, subject)
+}
+
+func (m *mockRepository) CreateUserWithIdentity(ctx context.Context, user *dtos.UserDto, identity ExternalIdentity) (*dtos.UserDto, error) {
+	return m.createUserWithIdentityFunc(ctx, user, identity)
+}
+
+func (m *mockRepository) UsernameTaken(ctx context.Context, username string, userID int32) (bool, error) {
+	return m.usernameTakenFunc(ctx, username, userID)
+}
+
+func (m *mockRepository) UpdateDisplayName(ctx context.Context, userID int32, displayName string) error {
+	return m.updateDisplayNameFunc(ctx, userID, displayName)
+}
+
+func (m *mockRepository) GetUsernameChangedAt(ctx context.Context, userID int32) (time.Time, error) {
+	return m.getUsernameChangedAtFunc(ctx, userID)
+}
+
+func (m *mockRepository) ChangeUsername(ctx context.Context, userID int32, oldUsername, newUsername string, reservedUntil time.Time) error {
+	return m.changeUsernameFunc(ctx, userID, oldUsername, newUsername, reservedUntil)
+}
+
+func (m *mockRepository) ListUsernameHistory(ctx context.Context, userID int32) ([]db.ListUsernameHistoryRow, error) {
+	return m.listUsernameHistoryFunc(ctx, userID)
+}
+
 // Mock getRandomColor for deterministic tests
 var mockGetRandomColor = func() string {
 	return "#FF6B6B"
@@ -50,6 +128,9 @@ func TestService_Register(t *testing.T) {
 				Password:    string(hashedPassword),
 			}, nil
 		},
+		usernameTakenFunc: func(ctx context.Context, username string, userID int32) (bool, error) {
+			return false, nil
+		},
 	}
 	mockRedis := redis.NewClient(&redis.Options{})
 	service := NewService(mockRepo, mockRedis, "testsecret")
@@ -107,8 +188,10 @@ func TestService_Login(t *testing.T) {
 	mockRedis := redis.NewClient(&redis.Options{Addr: mr.Addr()})
 	service := NewService(mockRepo, mockRedis, secret)
 
-	accessToken, refreshToken, err := service.Login(ctx, "testuser", "password123")
+	result, err := service.Login(ctx, "testuser", "password123")
 	assert.NoError(t, err)
+	assert.False(t, result.MfaRequired())
+	accessToken, refreshToken := result.AccessToken, result.RefreshToken
 	assert.NotEmpty(t, accessToken)
 	assert.NotEmpty(t, refreshToken)
 
@@ -132,7 +215,7 @@ func TestService_Login(t *testing.T) {
 	assert.NotEmpty(t, storedToken["expires_at"])
 
 	// Test invalid password
-	_, _, err = service.Login(ctx, "testuser", "wrongpassword")
+	_, err = service.Login(ctx, "testuser", "wrongpassword")
 	assert.Error(t, err)
 	assert.Equal(t, "invalid credentials", err.Error())
 }
@@ -217,3 +300,335 @@ func TestService_Refresh(t *testing.T) {
 	assert.Error(t, err)
 	assert.Equal(t, ErrExpiredRefreshToken, err)
 }
+
+func TestService_ChangePasswordRevokesRefreshTokens(t *testing.T) {
+	ctx := context.Background()
+	hashedPassword, _ := bcrypt.GenerateFromPassword([]byte("password123"), bcrypt.DefaultCost)
+	user := &dtos.UserDto{UserId: 1, Username: "testuser", Password: string(hashedPassword)}
+	mockRepo := &mockRepository{
+		getUserByIDFunc: func(ctx context.Context, userID int32) (*dtos.UserDto, error) {
+			return user, nil
+		},
+		getUserCredentialsFunc: func(ctx context.Context, userID int32) (*dtos.UserDto, error) {
+			return user, nil
+		},
+		updateUserPasswordFunc: func(ctx context.Context, userID int32, hashedPassword string) error {
+			user.Password = hashedPassword
+			return nil
+		},
+	}
+	mr, err := miniredis.Run()
+	if err != nil {
+		t.Fatalf("failed to start miniredis: %v", err)
+	}
+	t.Cleanup(mr.Close)
+	redisClient := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	service := NewService(mockRepo, redisClient, "testsecret")
+
+	_, firstSession, err := service.issueTokens(ctx, user)
+	assert.NoError(t, err)
+	_, secondSession, err := service.issueTokens(ctx, user)
+	assert.NoError(t, err)
+	_, secondSession, err = service.Refresh(ctx, secondSession)
+	assert.NoError(t, err)
+
+	assert.Equal(t, ErrInvalidCredentials, service.ChangePassword(ctx, 1, "wrong", "new-password123"))
+	_, _, err = service.Refresh(ctx, firstSession)
+	assert.NoError(t, err, "a failed change must not revoke sessions")
+
+	_, thirdSession, err := service.issueTokens(ctx, user)
+	assert.NoError(t, err)
+	assert.NoError(t, service.ChangePassword(ctx, 1, "password123", "new-password123"))
+
+	for _, session := range []string{secondSession, thirdSession} {
+		_, _, err = service.Refresh(ctx, session)
+		assert.Equal(t, ErrInvalidRefreshToken, err)
+	}
+	assert.False(t, mr.Exists(userRefreshTokensKey(1)))
+}
+
+func TestService_LoginWithMfa(t *testing.T) {
+	ctx := context.Background()
+	hashedPassword, _ := bcrypt.GenerateFromPassword([]byte("password123"), bcrypt.DefaultCost)
+	secret, err := generateTotpSecret()
+	assert.NoError(t, err)
+
+	recoveryCode := "abcde-fghij"
+	usedRecoveryCodes := map[string]bool{}
+	mockRepo := &mockRepository{
+		getUserFunc: func(ctx context.Context, username string) (*dtos.UserDto, error) {
+			return &dtos.UserDto{
+				UserId:      1,
+				Username:    "testuser",
+				Password:    string(hashedPassword),
+				TotpEnabled: true,
+			}, nil
+		},
+		getUserByIDFunc: func(ctx context.Context, userID int32) (*dtos.UserDto, error) {
+			return &dtos.UserDto{UserId: 1, Username: "testuser", AvatarColor: "#FF6B6B"}, nil
+		},
+		getUserTotpFunc: func(ctx context.Context, userID int32) (db.GetUserTotpRow, error) {
+			return db.GetUserTotpRow{
+				ID:          1,
+				TotpSecret:  pgtype.Text{String: secret, Valid: true},
+				TotpEnabled: true,
+			}, nil
+		},
+		useRecoveryCodeFunc: func(ctx context.Context, userID int32, codeHash string) (bool, error) {
+			if codeHash != hashRecoveryCode(recoveryCode) || usedRecoveryCodes[codeHash] {
+				return false, nil
+			}
+			usedRecoveryCodes[codeHash] = true
+			return true, nil
+		},
+	}
+	mr, err := miniredis.Run()
+	if err != nil {
+		t.Fatalf("failed to start miniredis: %v", err)
+	}
+	t.Cleanup(mr.Close)
+	redisClient := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	service := NewService(mockRepo, redisClient, "testsecret")
+
+	// Password step only yields a ticket
+	result, err := service.Login(ctx, "testuser", "password123")
+	assert.NoError(t, err)
+	assert.True(t, result.MfaRequired())
+	assert.Empty(t, result.AccessToken)
+	assert.Empty(t, result.RefreshToken)
+
+	code, err := totpCodeAt(secret, totpStep(time.Now()))
+	assert.NoError(t, err)
+
+	// Wrong code keeps the ticket alive
+	wrongCode := code[:5] + string('0'+(code[5]-'0'+1)%10)
+	_, _, err = service.CompleteMfaLogin(ctx, result.MfaTicket, wrongCode)
+	assert.Equal(t, ErrInvalidTotpCode, err)
+
+	accessToken, refreshToken, err := service.CompleteMfaLogin(ctx, result.MfaTicket, code)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, accessToken)
+	assert.NotEmpty(t, refreshToken)
+
+	// Tickets are single use
+	_, _, err = service.CompleteMfaLogin(ctx, result.MfaTicket, code)
+	assert.Equal(t, ErrInvalidMfaTicket, err)
+
+	// The same TOTP code cannot be replayed on a new ticket
+	result, err = service.Login(ctx, "testuser", "password123")
+	assert.NoError(t, err)
+	_, _, err = service.CompleteMfaLogin(ctx, result.MfaTicket, code)
+	assert.Equal(t, ErrInvalidTotpCode, err)
+
+	// Recovery codes work once
+	_, _, err = service.CompleteMfaLogin(ctx, result.MfaTicket, "ABCDE-FGHIJ")
+	assert.NoError(t, err)
+	result, err = service.Login(ctx, "testuser", "password123")
+	assert.NoError(t, err)
+	_, _, err = service.CompleteMfaLogin(ctx, result.MfaTicket, recoveryCode)
+	assert.Equal(t, ErrInvalidTotpCode, err)
+}
+
+func TestService_CompleteMfaLoginLimitsAttempts(t *testing.T) {
+	ctx := context.Background()
+	secret, err := generateTotpSecret()
+	assert.NoError(t, err)
+
+	mockRepo := &mockRepository{
+		getUserByIDFunc: func(ctx context.Context, userID int32) (*dtos.UserDto, error) {
+			return &dtos.UserDto{UserId: 1, Username: "testuser"}, nil
+		},
+		getUserTotpFunc: func(ctx context.Context, userID int32) (db.GetUserTotpRow, error) {
+			return db.GetUserTotpRow{
+				ID:          1,
+				TotpSecret:  pgtype.Text{String: secret, Valid: true},
+				TotpEnabled: true,
+			}, nil
+		},
+		useRecoveryCodeFunc: func(ctx context.Context, userID int32, codeHash string) (bool, error) {
+			return false, nil
+		},
+	}
+	mr, err := miniredis.Run()
+	if err != nil {
+		t.Fatalf("failed to start miniredis: %v", err)
+	}
+	t.Cleanup(mr.Close)
+	redisClient := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	service := NewService(mockRepo, redisClient, "testsecret")
+
+	code, err := totpCodeAt(secret, totpStep(time.Now()))
+	assert.NoError(t, err)
+	wrongCode := code[:5] + string('0'+(code[5]-'0'+1)%10)
+
+	ticket, err := service.createMfaTicket(ctx, 1)
+	assert.NoError(t, err)
+	for i := 0; i < mfaTicketMaxAttempts; i++ {
+		_, _, err = service.CompleteMfaLogin(ctx, ticket, wrongCode)
+		assert.Equal(t, ErrInvalidTotpCode, err)
+	}
+
+	// The last failed attempt burns the ticket, and a late attempt does not
+	// bring it back.
+	assert.False(t, mr.Exists("mfa_ticket:"+ticket))
+	_, _, err = service.CompleteMfaLogin(ctx, ticket, code)
+	assert.Equal(t, ErrInvalidMfaTicket, err)
+	assert.False(t, mr.Exists("mfa_ticket:"+ticket))
+
+	// Attempts past the limit are refused before the code is checked.
+	ticket, err = service.createMfaTicket(ctx, 1)
+	assert.NoError(t, err)
+	mr.HSet("mfa_ticket:"+ticket, "attempts", strconv.Itoa(mfaTicketMaxAttempts))
+	_, _, err = service.CompleteMfaLogin(ctx, ticket, code)
+	assert.Equal(t, ErrInvalidMfaTicket, err)
+	assert.False(t, mr.Exists("mfa_ticket:"+ticket))
+}
+
+func TestService_VerifyFreshTotp(t *testing.T) {
+	ctx := context.Background()
+	secret, err := generateTotpSecret()
+	assert.NoError(t, err)
+
+	enabled := false
+	recoveryCode := "abcde-fghij"
+	usedRecoveryCodes := map[string]bool{}
+	mockRepo := &mockRepository{
+		getUserTotpFunc: func(ctx context.Context, userID int32) (db.GetUserTotpRow, error) {
+			return db.GetUserTotpRow{
+				ID:          1,
+				TotpSecret:  pgtype.Text{String: secret, Valid: true},
+				TotpEnabled: enabled,
+			}, nil
+		},
+		useRecoveryCodeFunc: func(ctx context.Context, userID int32, codeHash string) (bool, error) {
+			if codeHash != hashRecoveryCode(recoveryCode) || usedRecoveryCodes[codeHash] {
+				return false, nil
+			}
+			usedRecoveryCodes[codeHash] = true
+			return true, nil
+		},
+	}
+	mr, err := miniredis.Run()
+	if err != nil {
+		t.Fatalf("failed to start miniredis: %v", err)
+	}
+	t.Cleanup(mr.Close)
+	redisClient := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	service := NewService(mockRepo, redisClient, "testsecret")
+
+	// Users without 2FA pass through
+	assert.NoError(t, service.VerifyFreshTotp(ctx, 1, ""))
+
+	enabled = true
+	assert.Equal(t, ErrFreshTotpRequired, service.VerifyFreshTotp(ctx, 1, ""))
+	assert.Equal(t, ErrInvalidTotpCode, service.VerifyFreshTotp(ctx, 1, "abc"))
+
+	code, err := totpCodeAt(secret, totpStep(time.Now()))
+	assert.NoError(t, err)
+	assert.NoError(t, service.VerifyFreshTotp(ctx, 1, code))
+	assert.Equal(t, ErrInvalidTotpCode, service.VerifyFreshTotp(ctx, 1, code))
+
+	// A recovery code works once, for users who lost their authenticator
+	assert.NoError(t, service.VerifyFreshTotp(ctx, 1, "ABCDE-FGHIJ"))
+	assert.Equal(t, ErrInvalidTotpCode, service.VerifyFreshTotp(ctx, 1, recoveryCode))
+}
+
+func TestService_LoginLockout(t *testing.T) {
+	ctx := context.Background()
+	hashedPassword, _ := bcrypt.GenerateFromPassword([]byte("password123"), bcrypt.MinCost)
+	mockRepo := &mockRepository{
+		getUserFunc: func(ctx context.Context, username string) (*dtos.UserDto, error) {
+			return &dtos.UserDto{UserId: 1, Username: "testuser", Password: string(hashedPassword)}, nil
+		},
+		getUserByIDFunc: func(ctx context.Context, userID int32) (*dtos.UserDto, error) {
+			return &dtos.UserDto{UserId: 1, Username: "testuser"}, nil
+		},
+	}
+	mr, err := miniredis.Run()
+	if err != nil {
+		t.Fatalf("failed to start miniredis: %v", err)
+	}
+	t.Cleanup(mr.Close)
+	service := NewService(mockRepo, redis.NewClient(&redis.Options{Addr: mr.Addr()}), "testsecret")
+	service.SetLockoutPolicy(LockoutPolicy{Threshold: 3, BaseDuration: time.Minute, MaxDuration: 3 * time.Minute})
+
+	for i := 0; i < 2; i++ {
+		_, err := service.Login(ctx, "testuser", "wrongpassword")
+		assert.Equal(t, "invalid credentials", err.Error())
+	}
+
+	// A success before the threshold resets the counter
+	_, err = service.Login(ctx, "testuser", "password123")
+	assert.NoError(t, err)
+
+	for i := 0; i < 3; i++ {
+		_, err = service.Login(ctx, "TestUser", "wrongpassword")
+	}
+	assert.Equal(t, "invalid credentials", err.Error())
+
+	// Locked, even with the right password
+	_, err = service.Login(ctx, "testuser", "password123")
+	var lockedErr *AccountLockedError
+	assert.ErrorAs(t, err, &lockedErr)
+	assert.InDelta(t, time.Minute.Seconds(), lockedErr.RetryAfter.Seconds(), 1)
+
+	// Each failure after the lock expires doubles it, up to the cap
+	mr.FastForward(time.Minute)
+	_, err = service.Login(ctx, "testuser", "wrongpassword")
+	assert.Equal(t, "invalid credentials", err.Error())
+	_, err = service.Login(ctx, "testuser", "password123")
+	assert.ErrorAs(t, err, &lockedErr)
+	assert.InDelta(t, (2 * time.Minute).Seconds(), lockedErr.RetryAfter.Seconds(), 1)
+
+	mr.FastForward(2 * time.Minute)
+	_, err = service.Login(ctx, "testuser", "wrongpassword")
+	assert.Equal(t, "invalid credentials", err.Error())
+	_, err = service.Login(ctx, "testuser", "password123")
+	assert.ErrorAs(t, err, &lockedErr)
+	assert.InDelta(t, (3 * time.Minute).Seconds(), lockedErr.RetryAfter.Seconds(), 1)
+}
+
+func TestService_WebSocketTicket(t *testing.T) {
+	ctx := context.Background()
+	mr, err := miniredis.Run()
+	if err != nil {
+		t.Fatalf("failed to start miniredis: %v", err)
+	}
+	t.Cleanup(mr.Close)
+	service := NewService(&mockRepository{}, redis.NewClient(&redis.Options{Addr: mr.Addr()}), "testsecret")
+
+	ticket, err := service.CreateWebSocketTicket(ctx, &dtos.UserDto{UserId: 1, Username: "testuser", AvatarColor: "#FF6B6B"}, nil)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, ticket)
+
+	user, scopes, err := service.RedeemWebSocketTicket(ctx, ticket)
+	assert.NoError(t, err)
+	assert.Equal(t, int32(1), user.UserId)
+	assert.Equal(t, "testuser", user.Username)
+	assert.Equal(t, "#FF6B6B", user.AvatarColor)
+	assert.Nil(t, scopes)
+
+	// Single use
+	_, _, err = service.RedeemWebSocketTicket(ctx, ticket)
+	assert.Equal(t, ErrInvalidWebSocketTicket, err)
+
+	// Scoped credentials keep their scopes, even when empty
+	ticket, err = service.CreateWebSocketTicket(ctx, &dtos.UserDto{UserId: 1, Username: "testuser"}, []string{"messages:read"})
+	assert.NoError(t, err)
+	_, scopes, err = service.RedeemWebSocketTicket(ctx, ticket)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"messages:read"}, scopes)
+
+	ticket, err = service.CreateWebSocketTicket(ctx, &dtos.UserDto{UserId: 1, Username: "testuser"}, []string{})
+	assert.NoError(t, err)
+	_, scopes, err = service.RedeemWebSocketTicket(ctx, ticket)
+	assert.NoError(t, err)
+	assert.NotNil(t, scopes)
+
+	// Expires
+	ticket, err = service.CreateWebSocketTicket(ctx, &dtos.UserDto{UserId: 1, Username: "testuser"}, nil)
+	assert.NoError(t, err)
+	mr.FastForward(WebSocketTicketTTL + time.Second)
+	_, _, err = service.RedeemWebSocketTicket(ctx, ticket)
+	assert.Equal(t, ErrInvalidWebSocketTicket, err)
+}
@@ -0,0 +1,233 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	mathrand "math/rand"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/andrelcunha/Concord/backend/pkg/dtos"
+	"github.com/jackc/pgx/v5"
+	"golang.org/x/crypto/bcrypt"
+	"golang.org/x/oauth2"
+)
+
+const (
+	OIDCStateTTL = 10 * time.Minute
+
+	usernameSuffixRetries = 5
+)
+
+var (
+	ErrUnknownOIDCProvider = errors.New("unknown sso provider")
+	ErrInvalidOIDCState    = errors.New("invalid or expired sso state")
+	ErrOIDCLoginFailed     = errors.New("sso login failed")
+)
+
+var usernameDisallowedChars = regexp.MustCompile(`[^a-zA-Z0-9._-]+`)
+
+// ExternalIdentity identifies a user at an identity provider. Identities are
+// keyed by issuer and subject, never by email, so a provider cannot claim an
+// existing local account just by asserting its address.
+type ExternalIdentity struct {
+	Issuer  string
+	Subject string
+	Email   string
+}
+
+type oidcLoginState struct {
+	Provider string `json:"provider"`
+	Verifier string `json:"verifier"`
+	Nonce    string `json:"nonce"`
+}
+
+func (s *Service) AddOIDCProvider(provider *OIDCProvider) {
+	s.oidcProviders[provider.Name] = provider
+}
+
+// OIDCProviders lists the configured provider names for the login page.
+func (s *Service) OIDCProviders() []string {
+	names := make([]string, 0, len(s.oidcProviders))
+	for name := range s.oidcProviders {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// BeginOIDCLogin returns the provider's authorization URL. The state, nonce
+// and PKCE verifier are kept in Redis until the callback redeems them.
+func (s *Service) BeginOIDCLogin(ctx context.Context, providerName string) (string, error) {
+	provider, ok := s.oidcProviders[providerName]
+	if !ok {
+		return "", ErrUnknownOIDCProvider
+	}
+
+	state, err := s.generateRefreshToken()
+	if err != nil {
+		return "", err
+	}
+	nonce, err := s.generateRefreshToken()
+	if err != nil {
+		return "", err
+	}
+	verifier := oauth2.GenerateVerifier()
+
+	stateJSON, err := json.Marshal(oidcLoginState{
+		Provider: providerName,
+		Verifier: verifier,
+		Nonce:    nonce,
+	})
+	if err != nil {
+		return "", err
+	}
+	if err := s.redis.Set(ctx, "oidc_state:"+state, stateJSON, OIDCStateTTL).Err(); err != nil {
+		return "", err
+	}
+
+	return provider.authCodeURL(state, nonce, verifier), nil
+}
+
+// CompleteOIDCLogin redeems the callback's state and code, links or
+// provisions the user, and finishes login exactly like a password login,
+// including the MFA step for users with 2FA enabled.
+func (s *Service) CompleteOIDCLogin(ctx context.Context, state, code string) (*LoginResult, error) {
+	if state == "" || code == "" {
+		return nil, ErrInvalidOIDCState
+	}
+
+	stateJSON, err := s.redis.GetDel(ctx, "oidc_state:"+state).Result()
+	if err != nil {
+		return nil, ErrInvalidOIDCState
+	}
+	var loginState oidcLoginState
+	if err := json.Unmarshal([]byte(stateJSON), &loginState); err != nil {
+		return nil, ErrInvalidOIDCState
+	}
+
+	provider, ok := s.oidcProviders[loginState.Provider]
+	if !ok {
+		return nil, ErrUnknownOIDCProvider
+	}
+
+	claims, err := provider.exchange(ctx, code, loginState.Verifier, loginState.Nonce)
+	if err != nil {
+		log.Printf("OIDC login with %s failed: %v", provider.Name, err)
+		return nil, ErrOIDCLoginFailed
+	}
+
+	userID, err := s.findOrProvisionOIDCUser(ctx, provider, claims)
+	if err != nil {
+		return nil, err
+	}
+
+	totp, err := s.repo.GetUserTotp(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	return s.loginResultFor(ctx, userID, totp.TotpEnabled)
+}
+
+// findOrProvisionOIDCUser returns the user linked to the identity, creating
+// one just in time on first login.
+func (s *Service) findOrProvisionOIDCUser(ctx context.Context, provider *OIDCProvider, claims *idTokenClaims) (int32, error) {
+	identity := ExternalIdentity{
+		Issuer:  provider.issuer,
+		Subject: claims.Subject,
+		Email:   claims.Email,
+	}
+
+	userID, err := s.repo.GetUserIDByIdentity(ctx, identity.Issuer, identity.Subject)
+	if err == nil {
+		return userID, nil
+	}
+	if !errors.Is(err, pgx.ErrNoRows) {
+		return 0, err
+	}
+
+	username, err := s.availableUsername(ctx, claims)
+	if err != nil {
+		return 0, err
+	}
+
+	// SSO users never learn this password, so password login stays closed to
+	// provisioned accounts.
+	password, err := randomPassword()
+	if err != nil {
+		return 0, err
+	}
+
+	// The provider's full name becomes the display name when it fits.
+	displayName, err := normalizeDisplayName(claims.Name)
+	if err != nil {
+		displayName = ""
+	}
+
+	user, err := s.repo.CreateUserWithIdentity(ctx, &dtos.UserDto{
+		Username:    username,
+		DisplayName: displayName,
+		Password:    password,
+		AvatarColor: getRandomColor(),
+	}, identity)
+	if err != nil {
+		return 0, err
+	}
+	return user.UserId, nil
+}
+
+// availableUsername derives a valid username from the ID token claims and
+// appends a numeric suffix when it is already taken or reserved.
+func (s *Service) availableUsername(ctx context.Context, claims *idTokenClaims) (string, error) {
+	base := "user"
+	emailName, _, _ := strings.Cut(claims.Email, "@")
+	for _, candidate := range []string{claims.PreferredUsername, emailName, claims.Name} {
+		if sanitized := sanitizeUsername(candidate); ValidateUsername(sanitized) == nil {
+			base = sanitized
+			break
+		}
+	}
+
+	username := base
+	for i := 0; i < usernameSuffixRetries; i++ {
+		taken, err := s.repo.UsernameTaken(ctx, username, 0)
+		if err != nil {
+			return "", err
+		}
+		if !taken {
+			return username, nil
+		}
+		username = fmt.Sprintf("%s-%04d", truncate(base, MaxUsernameLength-5), mathrand.Intn(10000))
+	}
+	return "", ErrOIDCLoginFailed
+}
+
+func sanitizeUsername(value string) string {
+	sanitized := strings.Trim(usernameDisallowedChars.ReplaceAllString(value, "-"), "-._")
+	return truncate(sanitized, MaxUsernameLength)
+}
+
+func truncate(value string, length int) string {
+	if len(value) > length {
+		return value[:length]
+	}
+	return value
+}
+
+func randomPassword() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	hashed, err := bcrypt.GenerateFromPassword([]byte(fmt.Sprintf("%x", b)), bcrypt.DefaultCost)
+	if err != nil {
+		return "", err
+	}
+	return string(hashed), nil
+}
@@ -0,0 +1,59 @@
+package auth
+
+import (
+	"encoding/base32"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTotpCodeAt_RFC6238Vectors(t *testing.T) {
+	// RFC 6238 appendix B SHA1 seed, truncated to 6 digits.
+	secret := base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString([]byte("12345678901234567890"))
+
+	vectors := map[int64]string{
+		59:          "287082",
+		1111111109:  "081804",
+		1111111111:  "050471",
+		1234567890:  "005924",
+		2000000000:  "279037",
+		20000000000: "353130",
+	}
+	for unix, expected := range vectors {
+		code, err := totpCodeAt(secret, totpStep(time.Unix(unix, 0)))
+		assert.NoError(t, err)
+		assert.Equal(t, expected, code, "unix time %d", unix)
+	}
+}
+
+func TestValidateTotp(t *testing.T) {
+	secret, err := generateTotpSecret()
+	assert.NoError(t, err)
+	now := time.Now()
+
+	current, _ := totpCodeAt(secret, totpStep(now))
+	previous, _ := totpCodeAt(secret, totpStep(now)-1)
+	stale, _ := totpCodeAt(secret, totpStep(now)-3)
+
+	step, ok := validateTotp(secret, current, now)
+	assert.True(t, ok)
+	assert.Equal(t, totpStep(now), step)
+
+	_, ok = validateTotp(secret, previous, now)
+	assert.True(t, ok)
+
+	_, ok = validateTotp(secret, stale, now)
+	assert.False(t, ok)
+
+	_, ok = validateTotp(secret, "12345", now)
+	assert.False(t, ok)
+}
+
+func TestTotpURI(t *testing.T) {
+	uri := totpURI("alice", "JBSWY3DPEHPK3PXP")
+	assert.True(t, strings.HasPrefix(uri, "otpauth://totp/Concord:alice?"))
+	assert.Contains(t, uri, "secret=JBSWY3DPEHPK3PXP")
+	assert.Contains(t, uri, "issuer=Concord")
+}
@@ -0,0 +1,152 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/andrelcunha/Concord/backend/pkg/dtos"
+)
+
+const (
+	MinUsernameLength    = 3
+	MaxUsernameLength    = 32
+	MaxDisplayNameLength = 32
+
+	// UsernameChangeCooldown is how long a user waits between handle changes.
+	UsernameChangeCooldown = 30 * 24 * time.Hour
+	// UsernameReservation is how long a handle that was given up stays
+	// reserved for its previous owner.
+	UsernameReservation = 14 * 24 * time.Hour
+)
+
+var (
+	ErrInvalidUsernameLength  = fmt.Errorf("username must be between %d and %d characters", MinUsernameLength, MaxUsernameLength)
+	ErrInvalidUsernameChars   = errors.New("username may only contain letters, digits, '.', '_' and '-', and must start with a letter or digit")
+	ErrReservedUsername       = errors.New("username is reserved")
+	ErrUsernameTaken          = errors.New("username is already taken")
+	ErrUsernameUnchanged      = errors.New("username is unchanged")
+	ErrUsernameChangeCooldown = errors.New("username was changed too recently")
+	ErrDisplayNameTooLong     = fmt.Errorf("display name must be at most %d characters", MaxDisplayNameLength)
+	ErrInvalidDisplayName     = errors.New("display name must not contain control characters")
+)
+
+var usernamePattern = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9._-]*$`)
+
+// reservedUsernames could be mistaken for staff or system accounts, or clash
+// with mentions and routes. They are compared case-insensitively.
+var reservedUsernames = []string{
+	"admin", "administrator", "api", "bot", "concord", "everyone", "help",
+	"here", "me", "mod", "moderator", "null", "official", "root", "security",
+	"staff", "support", "system", "undefined",
+}
+
+// ValidateUsername checks a handle's length, characters and reserved words.
+// Whether it is free is checked separately.
+func ValidateUsername(username string) error {
+	if len(username) < MinUsernameLength || len(username) > MaxUsernameLength {
+		return ErrInvalidUsernameLength
+	}
+	if !usernamePattern.MatchString(username) {
+		return ErrInvalidUsernameChars
+	}
+	if slices.Contains(reservedUsernames, strings.ToLower(username)) {
+		return ErrReservedUsername
+	}
+	return nil
+}
+
+// normalizeDisplayName trims the name; an empty result clears it.
+func normalizeDisplayName(displayName string) (string, error) {
+	displayName = strings.TrimSpace(displayName)
+	if utf8.RuneCountInString(displayName) > MaxDisplayNameLength {
+		return "", ErrDisplayNameTooLong
+	}
+	if strings.IndexFunc(displayName, unicode.IsControl) >= 0 {
+		return "", ErrInvalidDisplayName
+	}
+	return displayName, nil
+}
+
+// checkUsernameAvailable validates the handle and makes sure no other
+// account holds or reserves it. userID is zero for new accounts.
+func (s *Service) checkUsernameAvailable(ctx context.Context, username string, userID int32) error {
+	if err := ValidateUsername(username); err != nil {
+		return err
+	}
+	taken, err := s.repo.UsernameTaken(ctx, username, userID)
+	if err != nil {
+		return err
+	}
+	if taken {
+		return ErrUsernameTaken
+	}
+	return nil
+}
+
+// UpdateDisplayName sets the user's display name. Access tokens pick it up
+// on the next refresh.
+func (s *Service) UpdateDisplayName(ctx context.Context, userID int32, displayName string) (*dtos.UserDto, error) {
+	displayName, err := normalizeDisplayName(displayName)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.repo.UpdateDisplayName(ctx, userID, displayName); err != nil {
+		return nil, err
+	}
+	return s.repo.GetUserByID(ctx, userID)
+}
+
+// ChangeUsername moves the user to a new handle, at most once per
+// UsernameChangeCooldown. The old handle is kept in the user's history and
+// stays reserved for them for UsernameReservation.
+func (s *Service) ChangeUsername(ctx context.Context, userID int32, username string) (*dtos.UserDto, error) {
+	username = strings.TrimSpace(username)
+	user, err := s.repo.GetUserByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if username == user.Username {
+		return nil, ErrUsernameUnchanged
+	}
+
+	changedAt, err := s.repo.GetUsernameChangedAt(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if !changedAt.IsZero() && time.Since(changedAt) < UsernameChangeCooldown {
+		return nil, ErrUsernameChangeCooldown
+	}
+
+	if err := s.checkUsernameAvailable(ctx, username, userID); err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.ChangeUsername(ctx, userID, user.Username, username, time.Now().Add(UsernameReservation)); err != nil {
+		return nil, err
+	}
+	return s.repo.GetUserByID(ctx, userID)
+}
+
+func (s *Service) ListUsernameHistory(ctx context.Context, userID int32) ([]dtos.UsernameHistoryDto, error) {
+	rows, err := s.repo.ListUsernameHistory(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	history := make([]dtos.UsernameHistoryDto, 0, len(rows))
+	for _, row := range rows {
+		history = append(history, dtos.UsernameHistoryDto{
+			Username:      row.Username,
+			ChangedAt:     row.ChangedAt.Time.Format("2006-01-02T15:04:05Z07:00"),
+			ReservedUntil: row.ReservedUntil.Time.Format("2006-01-02T15:04:05Z07:00"),
+		})
+	}
+	return history, nil
+}
@@ -0,0 +1,101 @@
+package auth
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/andrelcunha/Concord/backend/pkg/dtos"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateUsername(t *testing.T) {
+	assert.NoError(t, ValidateUsername("jane.doe"))
+	assert.NoError(t, ValidateUsername("Jane_Doe-2"))
+	assert.Equal(t, ErrInvalidUsernameLength, ValidateUsername("jd"))
+	assert.Equal(t, ErrInvalidUsernameLength, ValidateUsername(strings.Repeat("a", MaxUsernameLength+1)))
+	assert.Equal(t, ErrInvalidUsernameChars, ValidateUsername("jane doe"))
+	assert.Equal(t, ErrInvalidUsernameChars, ValidateUsername(".jane"))
+	assert.Equal(t, ErrInvalidUsernameChars, ValidateUsername("jäne"))
+	assert.Equal(t, ErrReservedUsername, ValidateUsername("Admin"))
+}
+
+func TestRegisterRejectsInvalidOrTakenUsernames(t *testing.T) {
+	mockRepo := &mockRepository{
+		usernameTakenFunc: func(ctx context.Context, username string, userID int32) (bool, error) {
+			return strings.EqualFold(username, "alice"), nil
+		},
+	}
+	service := NewService(mockRepo, redis.NewClient(&redis.Options{}), "testsecret")
+
+	_, err := service.Register(context.Background(), "ALICE", "password123")
+	assert.Equal(t, ErrUsernameTaken, err)
+	_, err = service.Register(context.Background(), "support", "password123")
+	assert.Equal(t, ErrReservedUsername, err)
+}
+
+func newUsernameTestService(changedAt time.Time) (*Service, *dtos.UserDto, *[]string) {
+	user := &dtos.UserDto{UserId: 1, Username: "alice"}
+	var reserved []string
+	mockRepo := &mockRepository{
+		getUserByIDFunc: func(ctx context.Context, userID int32) (*dtos.UserDto, error) {
+			return user, nil
+		},
+		getUsernameChangedAtFunc: func(ctx context.Context, userID int32) (time.Time, error) {
+			return changedAt, nil
+		},
+		usernameTakenFunc: func(ctx context.Context, username string, userID int32) (bool, error) {
+			return username == "bob", nil
+		},
+		changeUsernameFunc: func(ctx context.Context, userID int32, oldUsername, newUsername string, reservedUntil time.Time) error {
+			reserved = append(reserved, oldUsername)
+			user.Username = newUsername
+			return nil
+		},
+		updateDisplayNameFunc: func(ctx context.Context, userID int32, displayName string) error {
+			user.DisplayName = displayName
+			return nil
+		},
+	}
+	return NewService(mockRepo, redis.NewClient(&redis.Options{}), "testsecret"), user, &reserved
+}
+
+func TestChangeUsernameReservesOldHandle(t *testing.T) {
+	service, _, reserved := newUsernameTestService(time.Time{})
+	ctx := context.Background()
+
+	_, err := service.ChangeUsername(ctx, 1, "bob")
+	assert.Equal(t, ErrUsernameTaken, err)
+	_, err = service.ChangeUsername(ctx, 1, "alice")
+	assert.Equal(t, ErrUsernameUnchanged, err)
+
+	user, err := service.ChangeUsername(ctx, 1, "alice.w")
+	require.NoError(t, err)
+	assert.Equal(t, "alice.w", user.Username)
+	assert.Equal(t, []string{"alice"}, *reserved)
+}
+
+func TestChangeUsernameCooldown(t *testing.T) {
+	service, _, reserved := newUsernameTestService(time.Now().Add(-24 * time.Hour))
+
+	_, err := service.ChangeUsername(context.Background(), 1, "alice.w")
+	assert.Equal(t, ErrUsernameChangeCooldown, err)
+	assert.Empty(t, *reserved)
+}
+
+func TestUpdateDisplayName(t *testing.T) {
+	service, _, _ := newUsernameTestService(time.Time{})
+	ctx := context.Background()
+
+	user, err := service.UpdateDisplayName(ctx, 1, "  Alice Wonder  ")
+	require.NoError(t, err)
+	assert.Equal(t, "Alice Wonder", user.DisplayName)
+
+	_, err = service.UpdateDisplayName(ctx, 1, strings.Repeat("a", MaxDisplayNameLength+1))
+	assert.Equal(t, ErrDisplayNameTooLong, err)
+	_, err = service.UpdateDisplayName(ctx, 1, "Alice\nWonder")
+	assert.Equal(t, ErrInvalidDisplayName, err)
+}
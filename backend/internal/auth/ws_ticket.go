@@ -0,0 +1,62 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/andrelcunha/Concord/backend/pkg/dtos"
+)
+
+const WebSocketTicketTTL = 30 * time.Second
+
+var ErrInvalidWebSocketTicket = errors.New("invalid or expired websocket ticket")
+
+// webSocketTicket is what a ticket stands for in Redis. Scopes is nil for
+// interactive sessions, so a token's scopes survive the upgrade and scoped
+// credentials cannot trade themselves for an unscoped socket.
+type webSocketTicket struct {
+	User   *dtos.UserDto `json:"user"`
+	Scopes []string      `json:"scopes"`
+}
+
+// CreateWebSocketTicket mints a short-lived, single-use ticket bound to user
+// and the scopes of the credential that asked for it. Browsers cannot set
+// headers on WebSocket upgrades, so the ticket travels in the query string
+// instead of the long-lived access token.
+func (s *Service) CreateWebSocketTicket(ctx context.Context, user *dtos.UserDto, scopes []string) (string, error) {
+	ticket, err := s.generateRefreshToken()
+	if err != nil {
+		return "", err
+	}
+
+	payload, err := json.Marshal(webSocketTicket{User: user, Scopes: scopes})
+	if err != nil {
+		return "", err
+	}
+
+	if err := s.redis.Set(ctx, "ws_ticket:"+ticket, payload, WebSocketTicketTTL).Err(); err != nil {
+		return "", err
+	}
+	return ticket, nil
+}
+
+// RedeemWebSocketTicket atomically consumes the ticket and returns the user
+// and scopes it was minted for.
+func (s *Service) RedeemWebSocketTicket(ctx context.Context, ticket string) (*dtos.UserDto, []string, error) {
+	if ticket == "" {
+		return nil, nil, ErrInvalidWebSocketTicket
+	}
+
+	payload, err := s.redis.GetDel(ctx, "ws_ticket:"+ticket).Result()
+	if err != nil {
+		return nil, nil, ErrInvalidWebSocketTicket
+	}
+
+	var stored webSocketTicket
+	if err := json.Unmarshal([]byte(payload), &stored); err != nil || stored.User == nil {
+		return nil, nil, ErrInvalidWebSocketTicket
+	}
+	return stored.User, stored.Scopes, nil
+}
@@ -1,6 +1,9 @@
 package blocks
 
-import "github.com/gofiber/fiber/v2"
+import (
+	"github.com/andrelcunha/Concord/backend/internal/middleware"
+	"github.com/gofiber/fiber/v2"
+)
 
 type Handler struct {
 	service *Service
@@ -61,7 +64,7 @@ func (h *Handler) ListBlockedUsers(c *fiber.Ctx) error {
 
 func RegisterBlockRoutes(api fiber.Router, service *Service) {
 	handler := NewHandler(service)
-	blocked := api.Group("/blocks")
+	blocked := api.Group("/blocks", middleware.RequireInteractive())
 	blocked.Get("/", handler.ListBlockedUsers)
 	blocked.Post("/", handler.BlockUser)
 	blocked.Delete("/", handler.UnblockUser)
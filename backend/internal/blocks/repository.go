@@ -2,33 +2,96 @@ package blocks
 
 import (
 	"context"
+	"errors"
 
 	"github.com/andrelcunha/Concord/backend/internal/db"
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
+// CreatedBlock is a stored block and the pending or accepted friendship it
+// removed, if there was one.
+type CreatedBlock struct {
+	Block             db.Block
+	RemovedFriendship *db.Friendship
+}
+
 type Repository interface {
-	CreateBlock(ctx context.Context, blockerID, blockedID int32) (db.Block, error)
+	CreateBlock(ctx context.Context, blockerID, blockedID int32) (CreatedBlock, error)
 	DeleteBlock(ctx context.Context, blockerID, blockedID int32) error
 	GetBlock(ctx context.Context, blockerID, blockedID int32) (db.Block, error)
 	ListBlockedUsers(ctx context.Context, blockerID int32) ([]db.ListBlockedUsersRow, error)
+	ListBlockedUserIDs(ctx context.Context, blockerID int32) ([]int32, error)
+	ListBlockRelatedUserIDs(ctx context.Context, userID int32) ([]int32, error)
 }
 
 type repository struct {
-	db *db.Queries
+	pool *pgxpool.Pool
+	db   *db.Queries
 }
 
 func NewRepository(dbPool *pgxpool.Pool) Repository {
 	return &repository{
-		db: db.New(dbPool),
+		pool: dbPool,
+		db:   db.New(dbPool),
 	}
 }
 
-func (r *repository) CreateBlock(ctx context.Context, blockerID, blockedID int32) (db.Block, error) {
-	return r.db.CreateBlock(ctx, db.CreateBlockParams{
+// CreateBlock stores the block and, in the same transaction, removes any
+// pending or accepted friendship between the two users and hides their
+// one-to-one conversation for the blocker.
+func (r *repository) CreateBlock(ctx context.Context, blockerID, blockedID int32) (CreatedBlock, error) {
+	tx, err := r.pool.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return CreatedBlock{}, err
+	}
+	defer tx.Rollback(ctx)
+
+	queries := db.New(tx)
+	block, err := queries.CreateBlock(ctx, db.CreateBlockParams{
 		BlockerID: blockerID,
 		BlockedID: blockedID,
 	})
+	if err != nil {
+		return CreatedBlock{}, err
+	}
+	created := CreatedBlock{Block: block}
+
+	low, high := blockerID, blockedID
+	if low > high {
+		low, high = high, low
+	}
+	friendship, err := queries.DeleteActiveFriendship(ctx, db.DeleteActiveFriendshipParams{
+		UserID:   low,
+		FriendID: high,
+	})
+	switch {
+	case err == nil:
+		created.RemovedFriendship = &friendship
+	case !errors.Is(err, pgx.ErrNoRows):
+		return CreatedBlock{}, err
+	}
+
+	conversation, err := queries.GetDmConversationByUserPair(ctx, db.GetDmConversationByUserPairParams{
+		UserID:   blockerID,
+		UserID_2: blockedID,
+	})
+	switch {
+	case err == nil:
+		if _, err := queries.HideDmConversationForUser(ctx, db.HideDmConversationForUserParams{
+			ConversationID: conversation.ID,
+			UserID:         blockerID,
+		}); err != nil {
+			return CreatedBlock{}, err
+		}
+	case !errors.Is(err, pgx.ErrNoRows):
+		return CreatedBlock{}, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return CreatedBlock{}, err
+	}
+	return created, nil
 }
 
 func (r *repository) DeleteBlock(ctx context.Context, blockerID, blockedID int32) error {
@@ -48,3 +111,14 @@ func (r *repository) GetBlock(ctx context.Context, blockerID, blockedID int32) (
 func (r *repository) ListBlockedUsers(ctx context.Context, blockerID int32) ([]db.ListBlockedUsersRow, error) {
 	return r.db.ListBlockedUsers(ctx, blockerID)
 }
+
+// ListBlockedUserIDs returns only the users blockerID has blocked, not those
+// who blocked them.
+func (r *repository) ListBlockedUserIDs(ctx context.Context, blockerID int32) ([]int32, error) {
+	return r.db.ListBlockedUserIDs(ctx, blockerID)
+}
+
+// ListBlockRelatedUserIDs returns users on either side of a block with userID.
+func (r *repository) ListBlockRelatedUserIDs(ctx context.Context, userID int32) ([]int32, error) {
+	return r.db.ListBlockRelatedUserIDs(ctx, userID)
+}
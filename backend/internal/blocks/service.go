@@ -4,6 +4,8 @@ import (
 	"context"
 	"errors"
 
+	"github.com/andrelcunha/Concord/backend/internal/db"
+	"github.com/andrelcunha/Concord/backend/internal/realtime"
 	"github.com/andrelcunha/Concord/backend/pkg/dtos"
 )
 
@@ -12,12 +14,20 @@ var (
 	ErrAlreadyBlocked      = errors.New("user is already blocked")
 )
 
+// Listener is told about new blocks after they are stored, so that live
+// connections can catch up without reconnecting.
+type Listener interface {
+	UserBlocked(ctx context.Context, blockerID, blockedID int32)
+}
+
 type Service struct {
-	repo Repository
+	repo      Repository
+	realtime  realtime.Publisher
+	listeners []Listener
 }
 
-func NewService(repo Repository) *Service {
-	return &Service{repo: repo}
+func NewService(repo Repository, realtime realtime.Publisher, listeners ...Listener) *Service {
+	return &Service{repo: repo, realtime: realtime, listeners: listeners}
 }
 
 func (s *Service) BlockUser(ctx context.Context, blockerID, blockedID int32) (dtos.BlockDto, error) {
@@ -28,10 +38,15 @@ func (s *Service) BlockUser(ctx context.Context, blockerID, blockedID int32) (dt
 		return dtos.BlockDto{}, ErrAlreadyBlocked
 	}
 
-	block, err := s.repo.CreateBlock(ctx, blockerID, blockedID)
+	created, err := s.repo.CreateBlock(ctx, blockerID, blockedID)
 	if err != nil {
 		return dtos.BlockDto{}, err
 	}
+	block := created.Block
+	for _, listener := range s.listeners {
+		listener.UserBlocked(ctx, blockerID, blockedID)
+	}
+	s.publishBlockCreated(ctx, blockerID, blockedID, created.RemovedFriendship)
 
 	return dtos.BlockDto{
 		ID:        block.ID,
@@ -44,7 +59,42 @@ func (s *Service) UnblockUser(ctx context.Context, blockerID, blockedID int32) e
 	if blockerID == blockedID {
 		return ErrCannotBlockYourself
 	}
-	return s.repo.DeleteBlock(ctx, blockerID, blockedID)
+	if err := s.repo.DeleteBlock(ctx, blockerID, blockedID); err != nil {
+		return err
+	}
+
+	s.realtime.PublishToUser(ctx, blockerID, realtime.Event{
+		Type: realtime.EventBlockRemoved,
+		Data: realtime.UserData{UserID: blockedID},
+	})
+	return nil
+}
+
+// publishBlockCreated tells the blocker about the block. The blocked user is
+// only told that the friendship or request the block removed is gone.
+func (s *Service) publishBlockCreated(ctx context.Context, blockerID, blockedID int32, removed *db.Friendship) {
+	s.realtime.PublishToUser(ctx, blockerID, realtime.Event{
+		Type: realtime.EventBlockCreated,
+		Data: realtime.UserData{UserID: blockedID},
+	})
+	if removed == nil {
+		return
+	}
+
+	for _, pair := range [][2]int32{{blockerID, blockedID}, {blockedID, blockerID}} {
+		userID, otherUserID := pair[0], pair[1]
+		event := realtime.Event{
+			Type: realtime.EventFriendRemoved,
+			Data: realtime.UserData{UserID: otherUserID},
+		}
+		if removed.Status == "pending" {
+			event = realtime.Event{
+				Type: realtime.EventFriendRequestRemoved,
+				Data: realtime.FriendRequestData{ID: removed.ID, UserID: otherUserID},
+			}
+		}
+		s.realtime.PublishToUser(ctx, userID, event)
+	}
 }
 
 func (s *Service) ListBlockedUsers(ctx context.Context, blockerID int32) ([]dtos.BlockDto, error) {
@@ -62,6 +112,7 @@ func (s *Service) ListBlockedUsers(ctx context.Context, blockerID int32) ([]dtos
 			User: dtos.UserSummaryDto{
 				UserID:      row.BlockedID,
 				Username:    row.Username,
+				DisplayName: row.DisplayName.String,
 				AvatarURL:   row.AvatarUrl.String,
 				AvatarColor: row.AvatarColor.String,
 			},
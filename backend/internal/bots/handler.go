@@ -0,0 +1,148 @@
+package bots
+
+import (
+	"strconv"
+
+	"github.com/andrelcunha/Concord/backend/internal/auth"
+	"github.com/andrelcunha/Concord/backend/internal/middleware"
+	"github.com/andrelcunha/Concord/backend/internal/servers"
+	"github.com/gofiber/fiber/v2"
+)
+
+type Handler struct {
+	service *Service
+}
+
+func NewHandler(service *Service) *Handler {
+	return &Handler{service: service}
+}
+
+func (h *Handler) CreateBot(c *fiber.Ctx) error {
+	var req struct {
+		Username string `json:"username"`
+	}
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request"})
+	}
+
+	userID := c.Locals("userID").(int32)
+	bot, err := h.service.CreateBot(c.Context(), userID, req.Username)
+	if err != nil {
+		return botErrorResponse(c, err)
+	}
+	return c.Status(fiber.StatusCreated).JSON(bot)
+}
+
+func (h *Handler) ListBots(c *fiber.Ctx) error {
+	userID := c.Locals("userID").(int32)
+	bots, err := h.service.ListBots(c.Context(), userID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(fiber.Map{"bots": bots})
+}
+
+func (h *Handler) GetBot(c *fiber.Ctx) error {
+	botID, err := strconv.ParseInt(c.Params("id"), 10, 32)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid bot ID"})
+	}
+
+	bot, err := h.service.GetBot(c.Context(), int32(botID))
+	if err != nil {
+		return botErrorResponse(c, err)
+	}
+	return c.JSON(bot)
+}
+
+func (h *Handler) ResetToken(c *fiber.Ctx) error {
+	botID, err := strconv.ParseInt(c.Params("id"), 10, 32)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid bot ID"})
+	}
+
+	userID := c.Locals("userID").(int32)
+	bot, err := h.service.ResetToken(c.Context(), userID, int32(botID))
+	if err != nil {
+		return botErrorResponse(c, err)
+	}
+	return c.JSON(bot)
+}
+
+func (h *Handler) DeleteBot(c *fiber.Ctx) error {
+	botID, err := strconv.ParseInt(c.Params("id"), 10, 32)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid bot ID"})
+	}
+
+	userID := c.Locals("userID").(int32)
+	if err := h.service.DeleteBot(c.Context(), userID, int32(botID)); err != nil {
+		return botErrorResponse(c, err)
+	}
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+func (h *Handler) AuthorizeBot(c *fiber.Ctx) error {
+	botID, err := strconv.ParseInt(c.Params("id"), 10, 32)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid bot ID"})
+	}
+	var req struct {
+		ServerID int32 `json:"server_id"`
+	}
+	if err := c.BodyParser(&req); err != nil || req.ServerID == 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Server ID is required"})
+	}
+
+	userID := c.Locals("userID").(int32)
+	if err := h.service.AuthorizeBot(c.Context(), userID, int32(botID), req.ServerID); err != nil {
+		return botErrorResponse(c, err)
+	}
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+func (h *Handler) RemoveBot(c *fiber.Ctx) error {
+	botID, err := strconv.ParseInt(c.Params("id"), 10, 32)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid bot ID"})
+	}
+	serverID, err := strconv.ParseInt(c.Params("serverId"), 10, 32)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid server ID"})
+	}
+
+	userID := c.Locals("userID").(int32)
+	if err := h.service.RemoveBot(c.Context(), userID, int32(botID), int32(serverID)); err != nil {
+		return botErrorResponse(c, err)
+	}
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+func botErrorResponse(c *fiber.Ctx, err error) error {
+	switch err {
+	case auth.ErrInvalidUsernameLength, auth.ErrInvalidUsernameChars, auth.ErrReservedUsername:
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	case ErrBotNameTaken:
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": err.Error()})
+	case ErrBotNotFound, ErrNotBotMember, servers.ErrServerNotFound:
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
+	case servers.ErrNotServerOwner:
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": err.Error()})
+	default:
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+}
+
+// RegisterBotRoutes mounts owner-managed bot administration and the server
+// authorize flow. Bots and tokens cannot manage bots.
+func RegisterBotRoutes(api fiber.Router, service *Service) {
+	handler := NewHandler(service)
+	bots := api.Group("/bots", middleware.RequireInteractive())
+	bots.Get("/", handler.ListBots)
+	bots.Post("/", handler.CreateBot)
+	bots.Get("/:id", handler.GetBot)
+	bots.Delete("/:id", handler.DeleteBot)
+	bots.Post("/:id/token", handler.ResetToken)
+	bots.Post("/:id/servers", handler.AuthorizeBot)
+	bots.Delete("/:id/servers/:serverId", handler.RemoveBot)
+}
@@ -0,0 +1,113 @@
+package bots
+
+import (
+	"context"
+
+	"github.com/andrelcunha/Concord/backend/internal/db"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type Repository interface {
+	CreateBot(ctx context.Context, ownerID int32, username, password, avatarColor, tokenHash string) (db.CreateBotUserRow, error)
+	ListBots(ctx context.Context, ownerID int32) ([]db.ListBotsByOwnerRow, error)
+	GetBot(ctx context.Context, botID int32) (db.GetBotRow, error)
+	SetBotToken(ctx context.Context, botID int32, tokenHash string) error
+	GetBotByTokenHash(ctx context.Context, tokenHash string) (db.GetBotByTokenHashRow, error)
+	DeleteBot(ctx context.Context, botID, ownerID int32) (bool, error)
+	UsernameTaken(ctx context.Context, username string) (bool, error)
+}
+
+type repository struct {
+	pool *pgxpool.Pool
+	db   *db.Queries
+}
+
+func NewRepository(dbPool *pgxpool.Pool) Repository {
+	return &repository{
+		pool: dbPool,
+		db:   db.New(dbPool),
+	}
+}
+
+// CreateBot stores the bot user and its first token together so a bot never
+// exists without a way to authenticate.
+func (r *repository) CreateBot(ctx context.Context, ownerID int32, username, password, avatarColor, tokenHash string) (db.CreateBotUserRow, error) {
+	tx, err := r.pool.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return db.CreateBotUserRow{}, err
+	}
+
+	queries := db.New(tx)
+	bot, err := queries.CreateBotUser(ctx, db.CreateBotUserParams{
+		Username:    username,
+		Password:    password,
+		AvatarColor: pgtype.Text{String: avatarColor, Valid: true},
+		BotOwnerID:  pgtype.Int4{Int32: ownerID, Valid: true},
+	})
+	if err != nil {
+		tx.Rollback(ctx)
+		return db.CreateBotUserRow{}, err
+	}
+
+	err = queries.UpsertBotToken(ctx, db.UpsertBotTokenParams{
+		BotID:     bot.ID,
+		TokenHash: tokenHash,
+	})
+	if err != nil {
+		tx.Rollback(ctx)
+		return db.CreateBotUserRow{}, err
+	}
+
+	return bot, tx.Commit(ctx)
+}
+
+func (r *repository) ListBots(ctx context.Context, ownerID int32) ([]db.ListBotsByOwnerRow, error) {
+	return r.db.ListBotsByOwner(ctx, pgtype.Int4{Int32: ownerID, Valid: true})
+}
+
+func (r *repository) GetBot(ctx context.Context, botID int32) (db.GetBotRow, error) {
+	return r.db.GetBot(ctx, botID)
+}
+
+// UsernameTaken reports whether any account holds or reserves the handle.
+func (r *repository) UsernameTaken(ctx context.Context, username string) (bool, error) {
+	return r.db.UsernameTaken(ctx, db.UsernameTakenParams{Username: username})
+}
+
+func (r *repository) SetBotToken(ctx context.Context, botID int32, tokenHash string) error {
+	return r.db.UpsertBotToken(ctx, db.UpsertBotTokenParams{
+		BotID:     botID,
+		TokenHash: tokenHash,
+	})
+}
+
+func (r *repository) GetBotByTokenHash(ctx context.Context, tokenHash string) (db.GetBotByTokenHashRow, error) {
+	return r.db.GetBotByTokenHash(ctx, tokenHash)
+}
+
+// DeleteBot removes the bot from every server before deleting the user;
+// its messages go with it through the messages foreign key cascade.
+func (r *repository) DeleteBot(ctx context.Context, botID, ownerID int32) (bool, error) {
+	tx, err := r.pool.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return false, err
+	}
+
+	queries := db.New(tx)
+	if err := queries.DeleteUserServerMemberships(ctx, botID); err != nil {
+		tx.Rollback(ctx)
+		return false, err
+	}
+	rows, err := queries.DeleteBot(ctx, db.DeleteBotParams{
+		ID:         botID,
+		BotOwnerID: pgtype.Int4{Int32: ownerID, Valid: true},
+	})
+	if err != nil || rows == 0 {
+		tx.Rollback(ctx)
+		return false, err
+	}
+
+	return true, tx.Commit(ctx)
+}
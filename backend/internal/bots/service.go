@@ -0,0 +1,243 @@
+package bots
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"strings"
+
+	"github.com/andrelcunha/Concord/backend/internal/auth"
+	"github.com/andrelcunha/Concord/backend/internal/db"
+	"github.com/andrelcunha/Concord/backend/internal/events"
+	"github.com/andrelcunha/Concord/backend/internal/servers"
+	"github.com/andrelcunha/Concord/backend/pkg/dtos"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+const (
+	botTokenPrefix    = "cbot_"
+	botAvatarColor    = "#3498DB"
+	uniqueViolationPG = "23505"
+
+	// botPassword is not a valid bcrypt hash, so password login can never
+	// succeed for a bot even if the explicit bot check were bypassed.
+	botPassword = "!"
+)
+
+var (
+	ErrBotNameTaken    = errors.New("username is already taken")
+	ErrBotNotFound     = errors.New("bot not found")
+	ErrInvalidBotToken = errors.New("invalid bot token")
+	ErrNotBotMember    = errors.New("bot is not a member of this server")
+)
+
+type Service struct {
+	repo       Repository
+	serverRepo servers.Repository
+	events     events.Publisher
+}
+
+func NewService(repo Repository, serverRepo servers.Repository, events events.Publisher) *Service {
+	return &Service{repo: repo, serverRepo: serverRepo, events: events}
+}
+
+// CreateBot creates a bot account owned by ownerID and returns it with its
+// token. Tokens are stored hashed, so this is the only time it is shown.
+// Bot names follow the same rules as user handles, including reservations,
+// so a bot cannot pose as staff or take a handle someone just gave up.
+func (s *Service) CreateBot(ctx context.Context, ownerID int32, username string) (dtos.BotDto, error) {
+	username = strings.TrimSpace(username)
+	if err := auth.ValidateUsername(username); err != nil {
+		return dtos.BotDto{}, err
+	}
+	taken, err := s.repo.UsernameTaken(ctx, username)
+	if err != nil {
+		return dtos.BotDto{}, err
+	}
+	if taken {
+		return dtos.BotDto{}, ErrBotNameTaken
+	}
+
+	token, err := generateBotToken()
+	if err != nil {
+		return dtos.BotDto{}, err
+	}
+
+	bot, err := s.repo.CreateBot(ctx, ownerID, username, botPassword, botAvatarColor, hashBotToken(token))
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == uniqueViolationPG {
+			return dtos.BotDto{}, ErrBotNameTaken
+		}
+		return dtos.BotDto{}, err
+	}
+
+	botDto := toBotDto(db.GetBotRow(bot))
+	botDto.Token = token
+	return botDto, nil
+}
+
+func (s *Service) ListBots(ctx context.Context, ownerID int32) ([]dtos.BotDto, error) {
+	bots, err := s.repo.ListBots(ctx, ownerID)
+	if err != nil {
+		return nil, err
+	}
+
+	botDtos := make([]dtos.BotDto, 0, len(bots))
+	for _, bot := range bots {
+		botDtos = append(botDtos, toBotDto(db.GetBotRow(bot)))
+	}
+	return botDtos, nil
+}
+
+// GetBot returns the public profile shown to server owners before they
+// authorize a bot.
+func (s *Service) GetBot(ctx context.Context, botID int32) (dtos.BotDto, error) {
+	bot, err := s.repo.GetBot(ctx, botID)
+	if err != nil {
+		return dtos.BotDto{}, ErrBotNotFound
+	}
+	return toBotDto(bot), nil
+}
+
+// ResetToken replaces the bot's token, invalidating the previous one.
+func (s *Service) ResetToken(ctx context.Context, ownerID, botID int32) (dtos.BotDto, error) {
+	bot, err := s.ownedBot(ctx, ownerID, botID)
+	if err != nil {
+		return dtos.BotDto{}, err
+	}
+
+	token, err := generateBotToken()
+	if err != nil {
+		return dtos.BotDto{}, err
+	}
+	if err := s.repo.SetBotToken(ctx, botID, hashBotToken(token)); err != nil {
+		return dtos.BotDto{}, err
+	}
+
+	botDto := toBotDto(bot)
+	botDto.Token = token
+	return botDto, nil
+}
+
+func (s *Service) DeleteBot(ctx context.Context, ownerID, botID int32) error {
+	deleted, err := s.repo.DeleteBot(ctx, botID, ownerID)
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		return ErrBotNotFound
+	}
+	return nil
+}
+
+// AuthorizeBot adds a bot to a server. Only the server owner can do this;
+// bots cannot join servers on their own.
+func (s *Service) AuthorizeBot(ctx context.Context, userID, botID, serverID int32) error {
+	if _, err := s.repo.GetBot(ctx, botID); err != nil {
+		return ErrBotNotFound
+	}
+
+	server, err := s.serverRepo.GetServer(ctx, serverID)
+	if err != nil {
+		return servers.ErrServerNotFound
+	}
+	if !server.CreatorID.Valid || server.CreatorID.Int32 != userID {
+		return servers.ErrNotServerOwner
+	}
+
+	if err := s.serverRepo.JoinServer(ctx, serverID, botID); err != nil {
+		return err
+	}
+	s.events.Publish(ctx, events.Event{
+		Type:     events.EventMemberJoined,
+		ServerID: serverID,
+		Data:     events.MemberJoinedData{ServerID: serverID, UserID: botID, IsBot: true},
+	})
+	return nil
+}
+
+// RemoveBot takes a bot out of a server. Either the server owner or the bot
+// owner may do this.
+func (s *Service) RemoveBot(ctx context.Context, userID, botID, serverID int32) error {
+	bot, err := s.repo.GetBot(ctx, botID)
+	if err != nil {
+		return ErrBotNotFound
+	}
+
+	server, err := s.serverRepo.GetServer(ctx, serverID)
+	if err != nil {
+		return servers.ErrServerNotFound
+	}
+	isServerOwner := server.CreatorID.Valid && server.CreatorID.Int32 == userID
+	isBotOwner := bot.BotOwnerID.Valid && bot.BotOwnerID.Int32 == userID
+	if !isServerOwner && !isBotOwner {
+		return servers.ErrNotServerOwner
+	}
+
+	removed, err := s.serverRepo.LeaveServer(ctx, serverID, botID)
+	if err != nil {
+		return err
+	}
+	if !removed {
+		return ErrNotBotMember
+	}
+	return nil
+}
+
+// AuthenticateBot resolves a bot token to the bot user. It satisfies
+// middleware.BotAuthenticator.
+func (s *Service) AuthenticateBot(ctx context.Context, token string) (*dtos.UserDto, error) {
+	if !strings.HasPrefix(token, botTokenPrefix) {
+		return nil, ErrInvalidBotToken
+	}
+
+	bot, err := s.repo.GetBotByTokenHash(ctx, hashBotToken(token))
+	if err != nil {
+		return nil, ErrInvalidBotToken
+	}
+
+	return &dtos.UserDto{
+		UserId:      bot.ID,
+		Username:    bot.Username,
+		AvatarUrl:   bot.AvatarUrl.String,
+		AvatarColor: bot.AvatarColor.String,
+		IsBot:       true,
+	}, nil
+}
+
+func (s *Service) ownedBot(ctx context.Context, ownerID, botID int32) (db.GetBotRow, error) {
+	bot, err := s.repo.GetBot(ctx, botID)
+	if err != nil || !bot.BotOwnerID.Valid || bot.BotOwnerID.Int32 != ownerID {
+		return db.GetBotRow{}, ErrBotNotFound
+	}
+	return bot, nil
+}
+
+func generateBotToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return botTokenPrefix + base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+func hashBotToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+func toBotDto(bot db.GetBotRow) dtos.BotDto {
+	return dtos.BotDto{
+		ID:          bot.ID,
+		Username:    bot.Username,
+		AvatarURL:   bot.AvatarUrl.String,
+		AvatarColor: bot.AvatarColor.String,
+		OwnerID:     bot.BotOwnerID.Int32,
+		IsBot:       true,
+		CreatedAt:   bot.CreatedAt.Time.Format("2006-01-02T15:04:05Z07:00"),
+	}
+}
@@ -0,0 +1,58 @@
+package bots
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/andrelcunha/Concord/backend/internal/auth"
+	"github.com/andrelcunha/Concord/backend/internal/db"
+	"github.com/stretchr/testify/assert"
+)
+
+// mockRepository treats takenUsernames as held or reserved handles,
+// compared case-insensitively like the real query.
+type mockRepository struct {
+	Repository
+	takenUsernames []string
+	created        []string
+}
+
+func (m *mockRepository) UsernameTaken(ctx context.Context, username string) (bool, error) {
+	for _, taken := range m.takenUsernames {
+		if strings.EqualFold(taken, username) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (m *mockRepository) CreateBot(ctx context.Context, ownerID int32, username, password, avatarColor, tokenHash string) (db.CreateBotUserRow, error) {
+	m.created = append(m.created, username)
+	return db.CreateBotUserRow{ID: int32(len(m.created)), Username: username}, nil
+}
+
+func TestService_CreateBotValidatesUsername(t *testing.T) {
+	ctx := context.Background()
+	repo := &mockRepository{takenUsernames: []string{"alice", "formerhandle"}}
+	service := NewService(repo, nil, nil)
+
+	bot, err := service.CreateBot(ctx, 1, "  deploy-bot ")
+	assert.NoError(t, err)
+	assert.Equal(t, "deploy-bot", bot.Username)
+	assert.True(t, strings.HasPrefix(bot.Token, botTokenPrefix))
+
+	for username, want := range map[string]error{
+		"Admin":                 auth.ErrReservedUsername,
+		"support":               auth.ErrReservedUsername,
+		"my bot":                auth.ErrInvalidUsernameChars,
+		"ab":                    auth.ErrInvalidUsernameLength,
+		strings.Repeat("b", 33): auth.ErrInvalidUsernameLength,
+		"ALICE":                 ErrBotNameTaken,
+		"formerhandle":          ErrBotNameTaken,
+	} {
+		_, err := service.CreateBot(ctx, 1, username)
+		assert.Equal(t, want, err, username)
+	}
+	assert.Equal(t, []string{"deploy-bot"}, repo.created)
+}
@@ -4,6 +4,7 @@ import (
 	"log"
 	"strconv"
 
+	"github.com/andrelcunha/Concord/backend/internal/middleware"
 	"github.com/gofiber/fiber/v2"
 )
 
@@ -76,7 +77,6 @@ func (h *Handler) ListChannels(c *fiber.Ctx) error {
 func RegisterChannelsRoutes(api fiber.Router, service *Service) {
 	handler := NewHandler(service)
 	channels := api.Group("/channels")
-	channels.Post("/", handler.CreateChannel)
-	channels.Get("/", handler.ListChannels)
-
+	channels.Post("/", middleware.RequireScope(middleware.ScopeServersManage), handler.CreateChannel)
+	channels.Get("/", middleware.RequireScope(middleware.ScopeMessagesRead), handler.ListChannels)
 }
@@ -0,0 +1,15 @@
+package common
+
+import "strings"
+
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// ContainsPattern and PrefixPattern build ILIKE patterns that match the
+// query literally, so "%" or "_" typed by a user are not wildcards.
+func ContainsPattern(query string) string {
+	return "%" + likeEscaper.Replace(query) + "%"
+}
+
+func PrefixPattern(query string) string {
+	return likeEscaper.Replace(query) + "%"
+}
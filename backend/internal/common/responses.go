@@ -1,12 +1,28 @@
 package common
 
+import "github.com/andrelcunha/Concord/backend/pkg/dtos"
+
 type MessageResponse struct {
-	ID          int    `json:"id"`
-	ChannelID   int    `json:"channel_id"`
-	UserID      int    `json:"user_id"`
-	Content     string `json:"content"`
-	Username    string `json:"username"`
-	CreatedAt   string `json:"created_at"`
-	AvatarURL   string `json:"avatar_url"`
-	AvatarColor string `json:"avatar_color"`
+	ID          int          `json:"id"`
+	ChannelID   int          `json:"channel_id"`
+	UserID      int          `json:"user_id"`
+	Content     string       `json:"content"`
+	Username    string       `json:"username"`
+	CreatedAt   string       `json:"created_at"`
+	AvatarURL   string       `json:"avatar_url"`
+	AvatarColor string       `json:"avatar_color"`
+	IsBot       bool         `json:"is_bot"`
+	WebhookID   int          `json:"webhook_id,omitempty"`
+	Embeds      []dtos.Embed `json:"embeds,omitempty"`
+	Ephemeral   bool         `json:"ephemeral,omitempty"` // Only shown to one user, never stored
+	// AuthorBlocked is set per recipient when they have blocked the author;
+	// clients collapse these messages and do not notify for their mentions.
+	AuthorBlocked bool `json:"author_blocked,omitempty"`
+	// AuthorNickname is the recipient's private nickname for the author.
+	AuthorNickname string `json:"author_nickname,omitempty"`
+	// ServerNickname is the author's nickname in the channel's server, and
+	// AvatarURL already holds their server avatar if they set one.
+	ServerNickname string `json:"server_nickname,omitempty"`
+	// DisplayName is the author's own display name; Username stays the handle.
+	DisplayName string `json:"display_name,omitempty"`
 }
@@ -0,0 +1,117 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.30.0
+// source: annotations.sql
+
+package db
+
+import (
+	"context"
+)
+
+const deleteUserAnnotation = `-- name: DeleteUserAnnotation :exec
+DELETE FROM user_annotations
+WHERE owner_id = $1 AND target_id = $2
+`
+
+type DeleteUserAnnotationParams struct {
+	OwnerID  int32
+	TargetID int32
+}
+
+func (q *Queries) DeleteUserAnnotation(ctx context.Context, arg DeleteUserAnnotationParams) error {
+	_, err := q.db.Exec(ctx, deleteUserAnnotation, arg.OwnerID, arg.TargetID)
+	return err
+}
+
+const getUserAnnotation = `-- name: GetUserAnnotation :one
+SELECT owner_id, target_id, nickname, note, updated_at
+FROM user_annotations
+WHERE owner_id = $1 AND target_id = $2
+`
+
+type GetUserAnnotationParams struct {
+	OwnerID  int32
+	TargetID int32
+}
+
+func (q *Queries) GetUserAnnotation(ctx context.Context, arg GetUserAnnotationParams) (UserAnnotation, error) {
+	row := q.db.QueryRow(ctx, getUserAnnotation, arg.OwnerID, arg.TargetID)
+	var i UserAnnotation
+	err := row.Scan(
+		&i.OwnerID,
+		&i.TargetID,
+		&i.Nickname,
+		&i.Note,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const listUserAnnotations = `-- name: ListUserAnnotations :many
+SELECT owner_id, target_id, nickname, note, updated_at
+FROM user_annotations
+WHERE owner_id = $1
+`
+
+func (q *Queries) ListUserAnnotations(ctx context.Context, ownerID int32) ([]UserAnnotation, error) {
+	rows, err := q.db.Query(ctx, listUserAnnotations, ownerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []UserAnnotation
+	for rows.Next() {
+		var i UserAnnotation
+		if err := rows.Scan(
+			&i.OwnerID,
+			&i.TargetID,
+			&i.Nickname,
+			&i.Note,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const upsertUserAnnotation = `-- name: UpsertUserAnnotation :one
+INSERT INTO user_annotations (owner_id, target_id, nickname, note, updated_at)
+VALUES ($1, $2, $3, $4, CURRENT_TIMESTAMP)
+ON CONFLICT (owner_id, target_id)
+DO UPDATE SET
+    nickname = EXCLUDED.nickname,
+    note = EXCLUDED.note,
+    updated_at = EXCLUDED.updated_at
+RETURNING owner_id, target_id, nickname, note, updated_at
+`
+
+type UpsertUserAnnotationParams struct {
+	OwnerID  int32
+	TargetID int32
+	Nickname string
+	Note     string
+}
+
+func (q *Queries) UpsertUserAnnotation(ctx context.Context, arg UpsertUserAnnotationParams) (UserAnnotation, error) {
+	row := q.db.QueryRow(ctx, upsertUserAnnotation,
+		arg.OwnerID,
+		arg.TargetID,
+		arg.Nickname,
+		arg.Note,
+	)
+	var i UserAnnotation
+	err := row.Scan(
+		&i.OwnerID,
+		&i.TargetID,
+		&i.Nickname,
+		&i.Note,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
@@ -72,6 +72,32 @@ func (q *Queries) GetBlock(ctx context.Context, arg GetBlockParams) (Block, erro
 	return i, err
 }
 
+const listBlockedUserIDs = `-- name: ListBlockedUserIDs :many
+SELECT blocked_id
+FROM blocks
+WHERE blocker_id = $1
+`
+
+func (q *Queries) ListBlockedUserIDs(ctx context.Context, blockerID int32) ([]int32, error) {
+	rows, err := q.db.Query(ctx, listBlockedUserIDs, blockerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []int32
+	for rows.Next() {
+		var blocked_id int32
+		if err := rows.Scan(&blocked_id); err != nil {
+			return nil, err
+		}
+		items = append(items, blocked_id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const listBlockedUsers = `-- name: ListBlockedUsers :many
 SELECT
     b.id,
@@ -80,7 +106,8 @@ SELECT
     b.created_at,
     u.username,
     u.avatar_url,
-    u.avatar_color
+    u.avatar_color,
+    u.display_name
 FROM blocks b
 JOIN users u ON u.id = b.blocked_id
 WHERE b.blocker_id = $1
@@ -95,6 +122,7 @@ type ListBlockedUsersRow struct {
 	Username    string
 	AvatarUrl   pgtype.Text
 	AvatarColor pgtype.Text
+	DisplayName pgtype.Text
 }
 
 func (q *Queries) ListBlockedUsers(ctx context.Context, blockerID int32) ([]ListBlockedUsersRow, error) {
@@ -114,6 +142,7 @@ func (q *Queries) ListBlockedUsers(ctx context.Context, blockerID int32) ([]List
 			&i.Username,
 			&i.AvatarUrl,
 			&i.AvatarColor,
+			&i.DisplayName,
 		); err != nil {
 			return nil, err
 		}
@@ -0,0 +1,196 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.30.0
+// source: bots.sql
+
+package db
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const createBotUser = `-- name: CreateBotUser :one
+INSERT INTO users (username, password, avatar_color, is_bot, bot_owner_id)
+VALUES ($1, $2, $3, TRUE, $4)
+RETURNING id, username, avatar_url, avatar_color, bot_owner_id, created_at
+`
+
+type CreateBotUserParams struct {
+	Username    string
+	Password    string
+	AvatarColor pgtype.Text
+	BotOwnerID  pgtype.Int4
+}
+
+type CreateBotUserRow struct {
+	ID          int32
+	Username    string
+	AvatarUrl   pgtype.Text
+	AvatarColor pgtype.Text
+	BotOwnerID  pgtype.Int4
+	CreatedAt   pgtype.Timestamptz
+}
+
+func (q *Queries) CreateBotUser(ctx context.Context, arg CreateBotUserParams) (CreateBotUserRow, error) {
+	row := q.db.QueryRow(ctx, createBotUser,
+		arg.Username,
+		arg.Password,
+		arg.AvatarColor,
+		arg.BotOwnerID,
+	)
+	var i CreateBotUserRow
+	err := row.Scan(
+		&i.ID,
+		&i.Username,
+		&i.AvatarUrl,
+		&i.AvatarColor,
+		&i.BotOwnerID,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const deleteBot = `-- name: DeleteBot :execrows
+DELETE FROM users
+WHERE id = $1 AND is_bot = TRUE AND bot_owner_id = $2
+`
+
+type DeleteBotParams struct {
+	ID         int32
+	BotOwnerID pgtype.Int4
+}
+
+func (q *Queries) DeleteBot(ctx context.Context, arg DeleteBotParams) (int64, error) {
+	result, err := q.db.Exec(ctx, deleteBot, arg.ID, arg.BotOwnerID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const deleteUserServerMemberships = `-- name: DeleteUserServerMemberships :exec
+DELETE FROM server_members
+WHERE user_id = $1
+`
+
+func (q *Queries) DeleteUserServerMemberships(ctx context.Context, userID int32) error {
+	_, err := q.db.Exec(ctx, deleteUserServerMemberships, userID)
+	return err
+}
+
+const getBot = `-- name: GetBot :one
+SELECT id, username, avatar_url, avatar_color, bot_owner_id, created_at
+FROM users
+WHERE id = $1 AND is_bot = TRUE
+`
+
+type GetBotRow struct {
+	ID          int32
+	Username    string
+	AvatarUrl   pgtype.Text
+	AvatarColor pgtype.Text
+	BotOwnerID  pgtype.Int4
+	CreatedAt   pgtype.Timestamptz
+}
+
+func (q *Queries) GetBot(ctx context.Context, id int32) (GetBotRow, error) {
+	row := q.db.QueryRow(ctx, getBot, id)
+	var i GetBotRow
+	err := row.Scan(
+		&i.ID,
+		&i.Username,
+		&i.AvatarUrl,
+		&i.AvatarColor,
+		&i.BotOwnerID,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getBotByTokenHash = `-- name: GetBotByTokenHash :one
+SELECT u.id, u.username, u.avatar_url, u.avatar_color
+FROM bot_tokens t
+JOIN users u ON u.id = t.bot_id
+WHERE t.token_hash = $1 AND u.is_bot = TRUE
+`
+
+type GetBotByTokenHashRow struct {
+	ID          int32
+	Username    string
+	AvatarUrl   pgtype.Text
+	AvatarColor pgtype.Text
+}
+
+func (q *Queries) GetBotByTokenHash(ctx context.Context, tokenHash string) (GetBotByTokenHashRow, error) {
+	row := q.db.QueryRow(ctx, getBotByTokenHash, tokenHash)
+	var i GetBotByTokenHashRow
+	err := row.Scan(
+		&i.ID,
+		&i.Username,
+		&i.AvatarUrl,
+		&i.AvatarColor,
+	)
+	return i, err
+}
+
+const listBotsByOwner = `-- name: ListBotsByOwner :many
+SELECT id, username, avatar_url, avatar_color, bot_owner_id, created_at
+FROM users
+WHERE is_bot = TRUE AND bot_owner_id = $1
+ORDER BY created_at ASC
+`
+
+type ListBotsByOwnerRow struct {
+	ID          int32
+	Username    string
+	AvatarUrl   pgtype.Text
+	AvatarColor pgtype.Text
+	BotOwnerID  pgtype.Int4
+	CreatedAt   pgtype.Timestamptz
+}
+
+func (q *Queries) ListBotsByOwner(ctx context.Context, botOwnerID pgtype.Int4) ([]ListBotsByOwnerRow, error) {
+	rows, err := q.db.Query(ctx, listBotsByOwner, botOwnerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListBotsByOwnerRow
+	for rows.Next() {
+		var i ListBotsByOwnerRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Username,
+			&i.AvatarUrl,
+			&i.AvatarColor,
+			&i.BotOwnerID,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const upsertBotToken = `-- name: UpsertBotToken :exec
+INSERT INTO bot_tokens (bot_id, token_hash)
+VALUES ($1, $2)
+ON CONFLICT (bot_id) DO UPDATE
+SET token_hash = EXCLUDED.token_hash, created_at = CURRENT_TIMESTAMP
+`
+
+type UpsertBotTokenParams struct {
+	BotID     int32
+	TokenHash string
+}
+
+func (q *Queries) UpsertBotToken(ctx context.Context, arg UpsertBotTokenParams) error {
+	_, err := q.db.Exec(ctx, upsertBotToken, arg.BotID, arg.TokenHash)
+	return err
+}
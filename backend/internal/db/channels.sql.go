@@ -11,6 +11,27 @@ import (
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
+const canAccessChannel = `-- name: CanAccessChannel :one
+SELECT EXISTS (
+    SELECT 1
+    FROM channels c
+    JOIN server_members sm ON sm.server_id = c.server_id
+    WHERE c.id = $1 AND sm.user_id = $2
+)
+`
+
+type CanAccessChannelParams struct {
+	ID     int32
+	UserID int32
+}
+
+func (q *Queries) CanAccessChannel(ctx context.Context, arg CanAccessChannelParams) (bool, error) {
+	row := q.db.QueryRow(ctx, canAccessChannel, arg.ID, arg.UserID)
+	var exists bool
+	err := row.Scan(&exists)
+	return exists, err
+}
+
 const createChannel = `-- name: CreateChannel :one
 INSERT INTO channels (name, created_by, server_id)
 VALUES ($1, $2, $3)
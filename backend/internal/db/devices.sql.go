@@ -0,0 +1,216 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.30.0
+// source: devices.sql
+
+package db
+
+import (
+	"context"
+)
+
+const addOneTimePrekey = `-- name: AddOneTimePrekey :exec
+INSERT INTO device_one_time_prekeys (device_id, key_id, public_key)
+VALUES ($1, $2, $3)
+ON CONFLICT (device_id, key_id) DO NOTHING
+`
+
+type AddOneTimePrekeyParams struct {
+	DeviceID  int32
+	KeyID     int32
+	PublicKey string
+}
+
+func (q *Queries) AddOneTimePrekey(ctx context.Context, arg AddOneTimePrekeyParams) error {
+	_, err := q.db.Exec(ctx, addOneTimePrekey, arg.DeviceID, arg.KeyID, arg.PublicKey)
+	return err
+}
+
+const claimOneTimePrekey = `-- name: ClaimOneTimePrekey :one
+DELETE FROM device_one_time_prekeys claimed
+WHERE claimed.id = (
+    SELECT p.id
+    FROM device_one_time_prekeys p
+    WHERE p.device_id = $1::int
+    ORDER BY p.key_id ASC
+    LIMIT 1
+    FOR UPDATE SKIP LOCKED
+)
+RETURNING claimed.key_id, claimed.public_key
+`
+
+type ClaimOneTimePrekeyRow struct {
+	KeyID     int32
+	PublicKey string
+}
+
+// Hands out the oldest unused prekey exactly once, even under concurrency.
+func (q *Queries) ClaimOneTimePrekey(ctx context.Context, deviceID int32) (ClaimOneTimePrekeyRow, error) {
+	row := q.db.QueryRow(ctx, claimOneTimePrekey, deviceID)
+	var i ClaimOneTimePrekeyRow
+	err := row.Scan(&i.KeyID, &i.PublicKey)
+	return i, err
+}
+
+const countOneTimePrekeys = `-- name: CountOneTimePrekeys :one
+SELECT COUNT(*)
+FROM device_one_time_prekeys
+WHERE device_id = $1
+`
+
+func (q *Queries) CountOneTimePrekeys(ctx context.Context, deviceID int32) (int64, error) {
+	row := q.db.QueryRow(ctx, countOneTimePrekeys, deviceID)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const createUserDevice = `-- name: CreateUserDevice :one
+INSERT INTO user_devices (user_id, name, identity_key, signed_prekey_id, signed_prekey, signed_prekey_signature)
+VALUES ($1, $2, $3, $4, $5, $6)
+RETURNING id, user_id, name, identity_key, signed_prekey_id, signed_prekey, signed_prekey_signature, created_at
+`
+
+type CreateUserDeviceParams struct {
+	UserID                int32
+	Name                  string
+	IdentityKey           string
+	SignedPrekeyID        int32
+	SignedPrekey          string
+	SignedPrekeySignature string
+}
+
+func (q *Queries) CreateUserDevice(ctx context.Context, arg CreateUserDeviceParams) (UserDevice, error) {
+	row := q.db.QueryRow(ctx, createUserDevice,
+		arg.UserID,
+		arg.Name,
+		arg.IdentityKey,
+		arg.SignedPrekeyID,
+		arg.SignedPrekey,
+		arg.SignedPrekeySignature,
+	)
+	var i UserDevice
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.Name,
+		&i.IdentityKey,
+		&i.SignedPrekeyID,
+		&i.SignedPrekey,
+		&i.SignedPrekeySignature,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const deleteUserDevice = `-- name: DeleteUserDevice :execrows
+DELETE FROM user_devices
+WHERE id = $1 AND user_id = $2
+`
+
+type DeleteUserDeviceParams struct {
+	ID     int32
+	UserID int32
+}
+
+func (q *Queries) DeleteUserDevice(ctx context.Context, arg DeleteUserDeviceParams) (int64, error) {
+	result, err := q.db.Exec(ctx, deleteUserDevice, arg.ID, arg.UserID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const getUserDevice = `-- name: GetUserDevice :one
+SELECT id, user_id, name, identity_key, signed_prekey_id, signed_prekey, signed_prekey_signature, created_at
+FROM user_devices
+WHERE id = $1 AND user_id = $2
+`
+
+type GetUserDeviceParams struct {
+	ID     int32
+	UserID int32
+}
+
+func (q *Queries) GetUserDevice(ctx context.Context, arg GetUserDeviceParams) (UserDevice, error) {
+	row := q.db.QueryRow(ctx, getUserDevice, arg.ID, arg.UserID)
+	var i UserDevice
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.Name,
+		&i.IdentityKey,
+		&i.SignedPrekeyID,
+		&i.SignedPrekey,
+		&i.SignedPrekeySignature,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const listDeviceOwners = `-- name: ListDeviceOwners :many
+SELECT id, user_id
+FROM user_devices
+WHERE id = ANY($1::int[])
+`
+
+type ListDeviceOwnersRow struct {
+	ID     int32
+	UserID int32
+}
+
+func (q *Queries) ListDeviceOwners(ctx context.Context, deviceIds []int32) ([]ListDeviceOwnersRow, error) {
+	rows, err := q.db.Query(ctx, listDeviceOwners, deviceIds)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListDeviceOwnersRow
+	for rows.Next() {
+		var i ListDeviceOwnersRow
+		if err := rows.Scan(&i.ID, &i.UserID); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listUserDevices = `-- name: ListUserDevices :many
+SELECT id, user_id, name, identity_key, signed_prekey_id, signed_prekey, signed_prekey_signature, created_at
+FROM user_devices
+WHERE user_id = $1
+ORDER BY created_at ASC, id ASC
+`
+
+func (q *Queries) ListUserDevices(ctx context.Context, userID int32) ([]UserDevice, error) {
+	rows, err := q.db.Query(ctx, listUserDevices, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []UserDevice
+	for rows.Next() {
+		var i UserDevice
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.Name,
+			&i.IdentityKey,
+			&i.SignedPrekeyID,
+			&i.SignedPrekey,
+			&i.SignedPrekeySignature,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
@@ -14,7 +14,7 @@ import (
 const addDmConversationParticipant = `-- name: AddDmConversationParticipant :one
 INSERT INTO dm_conversation_participants (conversation_id, user_id)
 VALUES ($1, $2)
-RETURNING conversation_id, user_id, joined_at
+RETURNING conversation_id, user_id, joined_at, last_read_message_id, last_read_at
 `
 
 type AddDmConversationParticipantParams struct {
@@ -25,31 +25,144 @@ type AddDmConversationParticipantParams struct {
 func (q *Queries) AddDmConversationParticipant(ctx context.Context, arg AddDmConversationParticipantParams) (DmConversationParticipant, error) {
 	row := q.db.QueryRow(ctx, addDmConversationParticipant, arg.ConversationID, arg.UserID)
 	var i DmConversationParticipant
-	err := row.Scan(&i.ConversationID, &i.UserID, &i.JoinedAt)
+	err := row.Scan(
+		&i.ConversationID,
+		&i.UserID,
+		&i.JoinedAt,
+		&i.LastReadMessageID,
+		&i.LastReadAt,
+	)
 	return i, err
 }
 
+const countDmConversationParticipants = `-- name: CountDmConversationParticipants :one
+SELECT COUNT(*)
+FROM dm_conversation_participants
+WHERE conversation_id = $1
+`
+
+func (q *Queries) CountDmConversationParticipants(ctx context.Context, conversationID int32) (int64, error) {
+	row := q.db.QueryRow(ctx, countDmConversationParticipants, conversationID)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
 const createDmConversation = `-- name: CreateDmConversation :one
 INSERT INTO dm_conversations DEFAULT VALUES
-RETURNING id, created_at
+RETURNING id, created_at, is_group, name, icon_url, owner_id, requester_id, request_status, is_encrypted
 `
 
 func (q *Queries) CreateDmConversation(ctx context.Context) (DmConversation, error) {
 	row := q.db.QueryRow(ctx, createDmConversation)
 	var i DmConversation
-	err := row.Scan(&i.ID, &i.CreatedAt)
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.IsGroup,
+		&i.Name,
+		&i.IconUrl,
+		&i.OwnerID,
+		&i.RequesterID,
+		&i.RequestStatus,
+		&i.IsEncrypted,
+	)
+	return i, err
+}
+
+const createDmRequestConversation = `-- name: CreateDmRequestConversation :one
+INSERT INTO dm_conversations (requester_id, request_status)
+VALUES ($1, 'pending')
+RETURNING id, created_at, is_group, name, icon_url, owner_id, requester_id, request_status, is_encrypted
+`
+
+func (q *Queries) CreateDmRequestConversation(ctx context.Context, requesterID pgtype.Int4) (DmConversation, error) {
+	row := q.db.QueryRow(ctx, createDmRequestConversation, requesterID)
+	var i DmConversation
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.IsGroup,
+		&i.Name,
+		&i.IconUrl,
+		&i.OwnerID,
+		&i.RequesterID,
+		&i.RequestStatus,
+		&i.IsEncrypted,
+	)
+	return i, err
+}
+
+const createGroupDmConversation = `-- name: CreateGroupDmConversation :one
+INSERT INTO dm_conversations (is_group, name, icon_url, owner_id)
+VALUES (TRUE, $1, $2, $3)
+RETURNING id, created_at, is_group, name, icon_url, owner_id, requester_id, request_status, is_encrypted
+`
+
+type CreateGroupDmConversationParams struct {
+	Name    pgtype.Text
+	IconUrl pgtype.Text
+	OwnerID pgtype.Int4
+}
+
+func (q *Queries) CreateGroupDmConversation(ctx context.Context, arg CreateGroupDmConversationParams) (DmConversation, error) {
+	row := q.db.QueryRow(ctx, createGroupDmConversation, arg.Name, arg.IconUrl, arg.OwnerID)
+	var i DmConversation
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.IsGroup,
+		&i.Name,
+		&i.IconUrl,
+		&i.OwnerID,
+		&i.RequesterID,
+		&i.RequestStatus,
+		&i.IsEncrypted,
+	)
 	return i, err
 }
 
+const deleteDmConversation = `-- name: DeleteDmConversation :exec
+DELETE FROM dm_conversations
+WHERE id = $1
+`
+
+func (q *Queries) DeleteDmConversation(ctx context.Context, id int32) error {
+	_, err := q.db.Exec(ctx, deleteDmConversation, id)
+	return err
+}
+
+const enableDmConversationEncryption = `-- name: EnableDmConversationEncryption :execrows
+UPDATE dm_conversations
+SET is_encrypted = TRUE
+WHERE id = $1 AND NOT is_encrypted
+`
+
+func (q *Queries) EnableDmConversationEncryption(ctx context.Context, id int32) (int64, error) {
+	result, err := q.db.Exec(ctx, enableDmConversationEncryption, id)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
 const getDmConversationByUserPair = `-- name: GetDmConversationByUserPair :one
 SELECT
     c.id,
-    c.created_at
+    c.created_at,
+    c.is_group,
+    c.name,
+    c.icon_url,
+    c.owner_id,
+    c.requester_id,
+    c.request_status,
+    c.is_encrypted
 FROM dm_conversations c
 JOIN dm_conversation_participants p1 ON p1.conversation_id = c.id
 JOIN dm_conversation_participants p2 ON p2.conversation_id = c.id
 WHERE p1.user_id = $1
   AND p2.user_id = $2
+  AND NOT c.is_group
 LIMIT 1
 `
 
@@ -61,7 +174,17 @@ type GetDmConversationByUserPairParams struct {
 func (q *Queries) GetDmConversationByUserPair(ctx context.Context, arg GetDmConversationByUserPairParams) (DmConversation, error) {
 	row := q.db.QueryRow(ctx, getDmConversationByUserPair, arg.UserID, arg.UserID_2)
 	var i DmConversation
-	err := row.Scan(&i.ID, &i.CreatedAt)
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.IsGroup,
+		&i.Name,
+		&i.IconUrl,
+		&i.OwnerID,
+		&i.RequesterID,
+		&i.RequestStatus,
+		&i.IsEncrypted,
+	)
 	return i, err
 }
 
@@ -69,20 +192,18 @@ const getDmConversationForUser = `-- name: GetDmConversationForUser :one
 SELECT
     c.id,
     c.created_at,
-    other_user.id AS other_user_id,
-    other_user.username AS other_username,
-    other_user.avatar_url AS other_avatar_url,
-    other_user.avatar_color AS other_avatar_color
+    c.is_group,
+    c.name,
+    c.icon_url,
+    c.owner_id,
+    c.requester_id,
+    c.request_status,
+    c.is_encrypted
 FROM dm_conversations c
 JOIN dm_conversation_participants self_participant
     ON self_participant.conversation_id = c.id
-JOIN dm_conversation_participants other_participant
-    ON other_participant.conversation_id = c.id
-   AND other_participant.user_id <> self_participant.user_id
-JOIN users other_user ON other_user.id = other_participant.user_id
 WHERE c.id = $1
   AND self_participant.user_id = $2
-LIMIT 1
 `
 
 type GetDmConversationForUserParams struct {
@@ -90,31 +211,25 @@ type GetDmConversationForUserParams struct {
 	UserID int32
 }
 
-type GetDmConversationForUserRow struct {
-	ID               int32
-	CreatedAt        pgtype.Timestamptz
-	OtherUserID      int32
-	OtherUsername    string
-	OtherAvatarUrl   pgtype.Text
-	OtherAvatarColor pgtype.Text
-}
-
-func (q *Queries) GetDmConversationForUser(ctx context.Context, arg GetDmConversationForUserParams) (GetDmConversationForUserRow, error) {
+func (q *Queries) GetDmConversationForUser(ctx context.Context, arg GetDmConversationForUserParams) (DmConversation, error) {
 	row := q.db.QueryRow(ctx, getDmConversationForUser, arg.ID, arg.UserID)
-	var i GetDmConversationForUserRow
+	var i DmConversation
 	err := row.Scan(
 		&i.ID,
 		&i.CreatedAt,
-		&i.OtherUserID,
-		&i.OtherUsername,
-		&i.OtherAvatarUrl,
-		&i.OtherAvatarColor,
+		&i.IsGroup,
+		&i.Name,
+		&i.IconUrl,
+		&i.OwnerID,
+		&i.RequesterID,
+		&i.RequestStatus,
+		&i.IsEncrypted,
 	)
 	return i, err
 }
 
 const getDmConversationParticipant = `-- name: GetDmConversationParticipant :one
-SELECT conversation_id, user_id, joined_at
+SELECT conversation_id, user_id, joined_at, last_read_message_id, last_read_at
 FROM dm_conversation_participants
 WHERE conversation_id = $1
   AND user_id = $2
@@ -128,7 +243,13 @@ type GetDmConversationParticipantParams struct {
 func (q *Queries) GetDmConversationParticipant(ctx context.Context, arg GetDmConversationParticipantParams) (DmConversationParticipant, error) {
 	row := q.db.QueryRow(ctx, getDmConversationParticipant, arg.ConversationID, arg.UserID)
 	var i DmConversationParticipant
-	err := row.Scan(&i.ConversationID, &i.UserID, &i.JoinedAt)
+	err := row.Scan(
+		&i.ConversationID,
+		&i.UserID,
+		&i.JoinedAt,
+		&i.LastReadMessageID,
+		&i.LastReadAt,
+	)
 	return i, err
 }
 
@@ -170,23 +291,208 @@ func (q *Queries) HideDmConversationForUser(ctx context.Context, arg HideDmConve
 	return i, err
 }
 
+const listDmConversationParticipants = `-- name: ListDmConversationParticipants :many
+SELECT
+    p.conversation_id,
+    p.joined_at,
+    u.id AS user_id,
+    u.username,
+    u.avatar_url,
+    u.avatar_color,
+    u.display_name
+FROM dm_conversation_participants p
+JOIN users u ON u.id = p.user_id
+WHERE p.conversation_id = $1
+ORDER BY p.joined_at ASC, u.id ASC
+`
+
+type ListDmConversationParticipantsRow struct {
+	ConversationID int32
+	JoinedAt       pgtype.Timestamptz
+	UserID         int32
+	Username       string
+	AvatarUrl      pgtype.Text
+	AvatarColor    pgtype.Text
+	DisplayName    pgtype.Text
+}
+
+// Oldest members first, so the first row inherits ownership of a group.
+func (q *Queries) ListDmConversationParticipants(ctx context.Context, conversationID int32) ([]ListDmConversationParticipantsRow, error) {
+	rows, err := q.db.Query(ctx, listDmConversationParticipants, conversationID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListDmConversationParticipantsRow
+	for rows.Next() {
+		var i ListDmConversationParticipantsRow
+		if err := rows.Scan(
+			&i.ConversationID,
+			&i.JoinedAt,
+			&i.UserID,
+			&i.Username,
+			&i.AvatarUrl,
+			&i.AvatarColor,
+			&i.DisplayName,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listDmParticipantsForUser = `-- name: ListDmParticipantsForUser :many
+SELECT
+    p.conversation_id,
+    p.joined_at,
+    u.id AS user_id,
+    u.username,
+    u.avatar_url,
+    u.avatar_color,
+    u.display_name
+FROM dm_conversation_participants self_participant
+JOIN dm_conversation_participants p ON p.conversation_id = self_participant.conversation_id
+JOIN users u ON u.id = p.user_id
+WHERE self_participant.user_id = $1
+ORDER BY p.conversation_id, p.joined_at ASC, u.id ASC
+`
+
+type ListDmParticipantsForUserRow struct {
+	ConversationID int32
+	JoinedAt       pgtype.Timestamptz
+	UserID         int32
+	Username       string
+	AvatarUrl      pgtype.Text
+	AvatarColor    pgtype.Text
+	DisplayName    pgtype.Text
+}
+
+// Members of every conversation the user is in, for the conversation list.
+func (q *Queries) ListDmParticipantsForUser(ctx context.Context, userID int32) ([]ListDmParticipantsForUserRow, error) {
+	rows, err := q.db.Query(ctx, listDmParticipantsForUser, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListDmParticipantsForUserRow
+	for rows.Next() {
+		var i ListDmParticipantsForUserRow
+		if err := rows.Scan(
+			&i.ConversationID,
+			&i.JoinedAt,
+			&i.UserID,
+			&i.Username,
+			&i.AvatarUrl,
+			&i.AvatarColor,
+			&i.DisplayName,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listDmReadStates = `-- name: ListDmReadStates :many
+SELECT
+    p.user_id,
+    p.last_read_message_id,
+    p.last_read_at,
+    COALESCE(ps.send_read_receipts, TRUE)::boolean AS send_read_receipts
+FROM dm_conversation_participants p
+LEFT JOIN user_privacy_settings ps ON ps.user_id = p.user_id
+WHERE p.conversation_id = $1
+ORDER BY p.joined_at ASC, p.user_id ASC
+`
+
+type ListDmReadStatesRow struct {
+	UserID            int32
+	LastReadMessageID pgtype.Int4
+	LastReadAt        pgtype.Timestamptz
+	SendReadReceipts  bool
+}
+
+func (q *Queries) ListDmReadStates(ctx context.Context, conversationID int32) ([]ListDmReadStatesRow, error) {
+	rows, err := q.db.Query(ctx, listDmReadStates, conversationID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListDmReadStatesRow
+	for rows.Next() {
+		var i ListDmReadStatesRow
+		if err := rows.Scan(
+			&i.UserID,
+			&i.LastReadMessageID,
+			&i.LastReadAt,
+			&i.SendReadReceipts,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listSharedDmConversationIDs = `-- name: ListSharedDmConversationIDs :many
+SELECT p1.conversation_id
+FROM dm_conversation_participants p1
+JOIN dm_conversation_participants p2 ON p2.conversation_id = p1.conversation_id
+WHERE p1.user_id = $1
+  AND p2.user_id = $2
+`
+
+type ListSharedDmConversationIDsParams struct {
+	UserID   int32
+	UserID_2 int32
+}
+
+func (q *Queries) ListSharedDmConversationIDs(ctx context.Context, arg ListSharedDmConversationIDsParams) ([]int32, error) {
+	rows, err := q.db.Query(ctx, listSharedDmConversationIDs, arg.UserID, arg.UserID_2)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []int32
+	for rows.Next() {
+		var conversation_id int32
+		if err := rows.Scan(&conversation_id); err != nil {
+			return nil, err
+		}
+		items = append(items, conversation_id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const listVisibleDmConversationsForUser = `-- name: ListVisibleDmConversationsForUser :many
 SELECT
     c.id,
     c.created_at,
-    other_user.id AS other_user_id,
-    other_user.username AS other_username,
-    other_user.avatar_url AS other_avatar_url,
-    other_user.avatar_color AS other_avatar_color,
+    c.is_group,
+    c.name,
+    c.icon_url,
+    c.owner_id,
+    c.requester_id,
+    c.request_status,
+    c.is_encrypted,
     COALESCE(last_message.content, '') AS last_message_content,
     last_message.created_at AS last_message_created_at
 FROM dm_conversations c
 JOIN dm_conversation_participants self_participant
     ON self_participant.conversation_id = c.id
-JOIN dm_conversation_participants other_participant
-    ON other_participant.conversation_id = c.id
-   AND other_participant.user_id <> self_participant.user_id
-JOIN users other_user ON other_user.id = other_participant.user_id
 LEFT JOIN dm_conversation_visibility visibility
     ON visibility.conversation_id = c.id
    AND visibility.user_id = self_participant.user_id
@@ -205,10 +511,13 @@ ORDER BY COALESCE(last_message.created_at, c.created_at) DESC
 type ListVisibleDmConversationsForUserRow struct {
 	ID                   int32
 	CreatedAt            pgtype.Timestamptz
-	OtherUserID          int32
-	OtherUsername        string
-	OtherAvatarUrl       pgtype.Text
-	OtherAvatarColor     pgtype.Text
+	IsGroup              bool
+	Name                 pgtype.Text
+	IconUrl              pgtype.Text
+	OwnerID              pgtype.Int4
+	RequesterID          pgtype.Int4
+	RequestStatus        pgtype.Text
+	IsEncrypted          bool
 	LastMessageContent   string
 	LastMessageCreatedAt pgtype.Timestamptz
 }
@@ -225,10 +534,13 @@ func (q *Queries) ListVisibleDmConversationsForUser(ctx context.Context, userID
 		if err := rows.Scan(
 			&i.ID,
 			&i.CreatedAt,
-			&i.OtherUserID,
-			&i.OtherUsername,
-			&i.OtherAvatarUrl,
-			&i.OtherAvatarColor,
+			&i.IsGroup,
+			&i.Name,
+			&i.IconUrl,
+			&i.OwnerID,
+			&i.RequesterID,
+			&i.RequestStatus,
+			&i.IsEncrypted,
 			&i.LastMessageContent,
 			&i.LastMessageCreatedAt,
 		); err != nil {
@@ -242,6 +554,96 @@ func (q *Queries) ListVisibleDmConversationsForUser(ctx context.Context, userID
 	return items, nil
 }
 
+const lockDmConversation = `-- name: LockDmConversation :exec
+SELECT id
+FROM dm_conversations
+WHERE id = $1
+FOR UPDATE
+`
+
+func (q *Queries) LockDmConversation(ctx context.Context, id int32) error {
+	_, err := q.db.Exec(ctx, lockDmConversation, id)
+	return err
+}
+
+const markDmConversationRead = `-- name: MarkDmConversationRead :execrows
+UPDATE dm_conversation_participants p
+SET last_read_message_id = $1::int, last_read_at = CURRENT_TIMESTAMP
+WHERE p.conversation_id = $2::int
+  AND p.user_id = $3::int
+  AND (p.last_read_message_id IS NULL OR p.last_read_message_id < $1::int)
+  AND EXISTS (
+      SELECT 1
+      FROM dm_messages m
+      WHERE m.id = $1::int AND m.conversation_id = $2::int
+  )
+`
+
+type MarkDmConversationReadParams struct {
+	MessageID      int32
+	ConversationID int32
+	UserID         int32
+}
+
+// Read pointers only move forward, and only to messages in the conversation.
+func (q *Queries) MarkDmConversationRead(ctx context.Context, arg MarkDmConversationReadParams) (int64, error) {
+	result, err := q.db.Exec(ctx, markDmConversationRead, arg.MessageID, arg.ConversationID, arg.UserID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const removeDmConversationParticipant = `-- name: RemoveDmConversationParticipant :execrows
+DELETE FROM dm_conversation_participants
+WHERE conversation_id = $1 AND user_id = $2
+`
+
+type RemoveDmConversationParticipantParams struct {
+	ConversationID int32
+	UserID         int32
+}
+
+func (q *Queries) RemoveDmConversationParticipant(ctx context.Context, arg RemoveDmConversationParticipantParams) (int64, error) {
+	result, err := q.db.Exec(ctx, removeDmConversationParticipant, arg.ConversationID, arg.UserID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const setDmConversationRequestStatus = `-- name: SetDmConversationRequestStatus :exec
+UPDATE dm_conversations
+SET request_status = $2
+WHERE id = $1 AND request_status IS NOT NULL
+`
+
+type SetDmConversationRequestStatusParams struct {
+	ID            int32
+	RequestStatus pgtype.Text
+}
+
+func (q *Queries) SetDmConversationRequestStatus(ctx context.Context, arg SetDmConversationRequestStatusParams) error {
+	_, err := q.db.Exec(ctx, setDmConversationRequestStatus, arg.ID, arg.RequestStatus)
+	return err
+}
+
+const setGroupDmOwner = `-- name: SetGroupDmOwner :exec
+UPDATE dm_conversations
+SET owner_id = $2
+WHERE id = $1 AND is_group
+`
+
+type SetGroupDmOwnerParams struct {
+	ID      int32
+	OwnerID pgtype.Int4
+}
+
+func (q *Queries) SetGroupDmOwner(ctx context.Context, arg SetGroupDmOwnerParams) error {
+	_, err := q.db.Exec(ctx, setGroupDmOwner, arg.ID, arg.OwnerID)
+	return err
+}
+
 const unhideDmConversationForUser = `-- name: UnhideDmConversationForUser :one
 INSERT INTO dm_conversation_visibility (conversation_id, user_id, hidden_at)
 VALUES ($1, $2, NULL)
@@ -261,3 +663,33 @@ func (q *Queries) UnhideDmConversationForUser(ctx context.Context, arg UnhideDmC
 	err := row.Scan(&i.ConversationID, &i.UserID, &i.HiddenAt)
 	return i, err
 }
+
+const updateGroupDmConversation = `-- name: UpdateGroupDmConversation :one
+UPDATE dm_conversations
+SET name = $2, icon_url = $3
+WHERE id = $1 AND is_group
+RETURNING id, created_at, is_group, name, icon_url, owner_id, requester_id, request_status, is_encrypted
+`
+
+type UpdateGroupDmConversationParams struct {
+	ID      int32
+	Name    pgtype.Text
+	IconUrl pgtype.Text
+}
+
+func (q *Queries) UpdateGroupDmConversation(ctx context.Context, arg UpdateGroupDmConversationParams) (DmConversation, error) {
+	row := q.db.QueryRow(ctx, updateGroupDmConversation, arg.ID, arg.Name, arg.IconUrl)
+	var i DmConversation
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.IsGroup,
+		&i.Name,
+		&i.IconUrl,
+		&i.OwnerID,
+		&i.RequesterID,
+		&i.RequestStatus,
+		&i.IsEncrypted,
+	)
+	return i, err
+}
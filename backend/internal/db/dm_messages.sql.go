@@ -11,10 +11,26 @@ import (
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
+const addDmMessageEnvelope = `-- name: AddDmMessageEnvelope :exec
+INSERT INTO dm_message_envelopes (message_id, device_id, ciphertext)
+VALUES ($1, $2, $3)
+`
+
+type AddDmMessageEnvelopeParams struct {
+	MessageID  int32
+	DeviceID   int32
+	Ciphertext string
+}
+
+func (q *Queries) AddDmMessageEnvelope(ctx context.Context, arg AddDmMessageEnvelopeParams) error {
+	_, err := q.db.Exec(ctx, addDmMessageEnvelope, arg.MessageID, arg.DeviceID, arg.Ciphertext)
+	return err
+}
+
 const createDmMessage = `-- name: CreateDmMessage :one
 INSERT INTO dm_messages (conversation_id, user_id, content)
 VALUES ($1, $2, $3)
-RETURNING id, conversation_id, user_id, content, created_at
+RETURNING id, conversation_id, user_id, content, created_at, type, target_user_id, sender_device_id
 `
 
 type CreateDmMessageParams struct {
@@ -23,15 +39,115 @@ type CreateDmMessageParams struct {
 	Content        string
 }
 
-func (q *Queries) CreateDmMessage(ctx context.Context, arg CreateDmMessageParams) (DmMessage, error) {
+type CreateDmMessageRow struct {
+	ID             int32
+	ConversationID int32
+	UserID         int32
+	Content        string
+	CreatedAt      pgtype.Timestamptz
+	Type           string
+	TargetUserID   pgtype.Int4
+	SenderDeviceID pgtype.Int4
+}
+
+func (q *Queries) CreateDmMessage(ctx context.Context, arg CreateDmMessageParams) (CreateDmMessageRow, error) {
 	row := q.db.QueryRow(ctx, createDmMessage, arg.ConversationID, arg.UserID, arg.Content)
-	var i DmMessage
+	var i CreateDmMessageRow
 	err := row.Scan(
 		&i.ID,
 		&i.ConversationID,
 		&i.UserID,
 		&i.Content,
 		&i.CreatedAt,
+		&i.Type,
+		&i.TargetUserID,
+		&i.SenderDeviceID,
+	)
+	return i, err
+}
+
+const createDmSystemMessage = `-- name: CreateDmSystemMessage :one
+INSERT INTO dm_messages (conversation_id, user_id, content, type, target_user_id)
+VALUES ($1, $2, $3, $4, $5)
+RETURNING id, conversation_id, user_id, content, created_at, type, target_user_id, sender_device_id
+`
+
+type CreateDmSystemMessageParams struct {
+	ConversationID int32
+	UserID         int32
+	Content        string
+	Type           string
+	TargetUserID   pgtype.Int4
+}
+
+type CreateDmSystemMessageRow struct {
+	ID             int32
+	ConversationID int32
+	UserID         int32
+	Content        string
+	CreatedAt      pgtype.Timestamptz
+	Type           string
+	TargetUserID   pgtype.Int4
+	SenderDeviceID pgtype.Int4
+}
+
+func (q *Queries) CreateDmSystemMessage(ctx context.Context, arg CreateDmSystemMessageParams) (CreateDmSystemMessageRow, error) {
+	row := q.db.QueryRow(ctx, createDmSystemMessage,
+		arg.ConversationID,
+		arg.UserID,
+		arg.Content,
+		arg.Type,
+		arg.TargetUserID,
+	)
+	var i CreateDmSystemMessageRow
+	err := row.Scan(
+		&i.ID,
+		&i.ConversationID,
+		&i.UserID,
+		&i.Content,
+		&i.CreatedAt,
+		&i.Type,
+		&i.TargetUserID,
+		&i.SenderDeviceID,
+	)
+	return i, err
+}
+
+const createEncryptedDmMessage = `-- name: CreateEncryptedDmMessage :one
+INSERT INTO dm_messages (conversation_id, user_id, content, sender_device_id)
+VALUES ($1, $2, '', $3)
+RETURNING id, conversation_id, user_id, content, created_at, type, target_user_id, sender_device_id
+`
+
+type CreateEncryptedDmMessageParams struct {
+	ConversationID int32
+	UserID         int32
+	SenderDeviceID pgtype.Int4
+}
+
+type CreateEncryptedDmMessageRow struct {
+	ID             int32
+	ConversationID int32
+	UserID         int32
+	Content        string
+	CreatedAt      pgtype.Timestamptz
+	Type           string
+	TargetUserID   pgtype.Int4
+	SenderDeviceID pgtype.Int4
+}
+
+func (q *Queries) CreateEncryptedDmMessage(ctx context.Context, arg CreateEncryptedDmMessageParams) (CreateEncryptedDmMessageRow, error) {
+	row := q.db.QueryRow(ctx, createEncryptedDmMessage, arg.ConversationID, arg.UserID, arg.SenderDeviceID)
+	var i CreateEncryptedDmMessageRow
+	err := row.Scan(
+		&i.ID,
+		&i.ConversationID,
+		&i.UserID,
+		&i.Content,
+		&i.CreatedAt,
+		&i.Type,
+		&i.TargetUserID,
+		&i.SenderDeviceID,
 	)
 	return i, err
 }
@@ -43,20 +159,28 @@ SELECT
     m.user_id,
     m.content,
     m.created_at,
+    m.type,
+    m.target_user_id,
+    m.sender_device_id,
+    e.ciphertext,
     u.username AS username,
     u.avatar_url AS avatar_url,
     u.avatar_color AS avatar_color
 FROM dm_messages m
 LEFT JOIN users u ON m.user_id = u.id
-WHERE m.conversation_id = $1
+LEFT JOIN dm_message_envelopes e
+    ON e.message_id = m.id
+   AND e.device_id = $1::int
+WHERE m.conversation_id = $2
 ORDER BY m.created_at ASC
-LIMIT $2 OFFSET $3
+LIMIT $4 OFFSET $3
 `
 
 type ListDmMessagesByConversationParams struct {
+	DeviceID       pgtype.Int4
 	ConversationID int32
-	Limit          int32
-	Offset         int32
+	PageOffset     int32
+	PageLimit      int32
 }
 
 type ListDmMessagesByConversationRow struct {
@@ -65,13 +189,23 @@ type ListDmMessagesByConversationRow struct {
 	UserID         int32
 	Content        string
 	CreatedAt      pgtype.Timestamptz
+	Type           string
+	TargetUserID   pgtype.Int4
+	SenderDeviceID pgtype.Int4
+	Ciphertext     pgtype.Text
 	Username       pgtype.Text
 	AvatarUrl      pgtype.Text
 	AvatarColor    pgtype.Text
 }
 
+// ciphertext is the envelope for the given device, if any.
 func (q *Queries) ListDmMessagesByConversation(ctx context.Context, arg ListDmMessagesByConversationParams) ([]ListDmMessagesByConversationRow, error) {
-	rows, err := q.db.Query(ctx, listDmMessagesByConversation, arg.ConversationID, arg.Limit, arg.Offset)
+	rows, err := q.db.Query(ctx, listDmMessagesByConversation,
+		arg.DeviceID,
+		arg.ConversationID,
+		arg.PageOffset,
+		arg.PageLimit,
+	)
 	if err != nil {
 		return nil, err
 	}
@@ -85,6 +219,10 @@ func (q *Queries) ListDmMessagesByConversation(ctx context.Context, arg ListDmMe
 			&i.UserID,
 			&i.Content,
 			&i.CreatedAt,
+			&i.Type,
+			&i.TargetUserID,
+			&i.SenderDeviceID,
+			&i.Ciphertext,
 			&i.Username,
 			&i.AvatarUrl,
 			&i.AvatarColor,
@@ -0,0 +1,362 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.30.0
+// source: events.sql
+
+package db
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const claimEventDeliveries = `-- name: ClaimEventDeliveries :many
+WITH claimed AS (
+    UPDATE event_deliveries d
+    SET attempts = d.attempts + 1,
+        next_attempt_at = NOW() + ($1::int * INTERVAL '1 second')
+    WHERE d.id IN (
+        SELECT id
+        FROM event_deliveries
+        WHERE status = 'pending' AND next_attempt_at <= NOW()
+        ORDER BY next_attempt_at
+        LIMIT $2
+        FOR UPDATE SKIP LOCKED
+    )
+    RETURNING d.id, d.subscription_id, d.event_id, d.event_type, d.payload, d.attempts
+)
+SELECT c.id, c.subscription_id, c.event_id, c.event_type, c.payload, c.attempts, s.url, s.secret
+FROM claimed c
+JOIN event_subscriptions s ON s.id = c.subscription_id
+`
+
+type ClaimEventDeliveriesParams struct {
+	LeaseSeconds int32
+	BatchSize    int32
+}
+
+type ClaimEventDeliveriesRow struct {
+	ID             int32
+	SubscriptionID int32
+	EventID        string
+	EventType      string
+	Payload        []byte
+	Attempts       int32
+	Url            string
+	Secret         string
+}
+
+// Claimed deliveries are leased by pushing next_attempt_at forward, so a
+// worker that dies mid-delivery only delays the retry.
+func (q *Queries) ClaimEventDeliveries(ctx context.Context, arg ClaimEventDeliveriesParams) ([]ClaimEventDeliveriesRow, error) {
+	rows, err := q.db.Query(ctx, claimEventDeliveries, arg.LeaseSeconds, arg.BatchSize)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ClaimEventDeliveriesRow
+	for rows.Next() {
+		var i ClaimEventDeliveriesRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.SubscriptionID,
+			&i.EventID,
+			&i.EventType,
+			&i.Payload,
+			&i.Attempts,
+			&i.Url,
+			&i.Secret,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const createEventSubscription = `-- name: CreateEventSubscription :one
+INSERT INTO event_subscriptions (owner_id, server_id, url, secret, event_types)
+VALUES ($1, $2, $3, $4, $5)
+RETURNING id, owner_id, server_id, url, event_types, created_at
+`
+
+type CreateEventSubscriptionParams struct {
+	OwnerID    int32
+	ServerID   pgtype.Int4
+	Url        string
+	Secret     string
+	EventTypes []string
+}
+
+type CreateEventSubscriptionRow struct {
+	ID         int32
+	OwnerID    int32
+	ServerID   pgtype.Int4
+	Url        string
+	EventTypes []string
+	CreatedAt  pgtype.Timestamptz
+}
+
+func (q *Queries) CreateEventSubscription(ctx context.Context, arg CreateEventSubscriptionParams) (CreateEventSubscriptionRow, error) {
+	row := q.db.QueryRow(ctx, createEventSubscription,
+		arg.OwnerID,
+		arg.ServerID,
+		arg.Url,
+		arg.Secret,
+		arg.EventTypes,
+	)
+	var i CreateEventSubscriptionRow
+	err := row.Scan(
+		&i.ID,
+		&i.OwnerID,
+		&i.ServerID,
+		&i.Url,
+		&i.EventTypes,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const deleteEventSubscription = `-- name: DeleteEventSubscription :execrows
+DELETE FROM event_subscriptions
+WHERE id = $1 AND owner_id = $2
+`
+
+type DeleteEventSubscriptionParams struct {
+	ID      int32
+	OwnerID int32
+}
+
+func (q *Queries) DeleteEventSubscription(ctx context.Context, arg DeleteEventSubscriptionParams) (int64, error) {
+	result, err := q.db.Exec(ctx, deleteEventSubscription, arg.ID, arg.OwnerID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const enqueueEventDeliveries = `-- name: EnqueueEventDeliveries :execrows
+INSERT INTO event_deliveries (subscription_id, event_id, event_type, payload)
+SELECT s.id, $1, $2::text, $3
+FROM event_subscriptions s
+WHERE $2::text = ANY(s.event_types)
+  AND (
+    (s.server_id IS NOT NULL AND s.server_id = $4)
+    OR (s.server_id IS NULL AND s.owner_id = $5)
+  )
+`
+
+type EnqueueEventDeliveriesParams struct {
+	EventID   string
+	EventType string
+	Payload   []byte
+	ServerID  pgtype.Int4
+	UserID    pgtype.Int4
+}
+
+func (q *Queries) EnqueueEventDeliveries(ctx context.Context, arg EnqueueEventDeliveriesParams) (int64, error) {
+	result, err := q.db.Exec(ctx, enqueueEventDeliveries,
+		arg.EventID,
+		arg.EventType,
+		arg.Payload,
+		arg.ServerID,
+		arg.UserID,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const getEventSubscription = `-- name: GetEventSubscription :one
+SELECT id, owner_id, server_id, url, event_types, created_at
+FROM event_subscriptions
+WHERE id = $1 AND owner_id = $2
+`
+
+type GetEventSubscriptionParams struct {
+	ID      int32
+	OwnerID int32
+}
+
+type GetEventSubscriptionRow struct {
+	ID         int32
+	OwnerID    int32
+	ServerID   pgtype.Int4
+	Url        string
+	EventTypes []string
+	CreatedAt  pgtype.Timestamptz
+}
+
+func (q *Queries) GetEventSubscription(ctx context.Context, arg GetEventSubscriptionParams) (GetEventSubscriptionRow, error) {
+	row := q.db.QueryRow(ctx, getEventSubscription, arg.ID, arg.OwnerID)
+	var i GetEventSubscriptionRow
+	err := row.Scan(
+		&i.ID,
+		&i.OwnerID,
+		&i.ServerID,
+		&i.Url,
+		&i.EventTypes,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const listEventDeliveries = `-- name: ListEventDeliveries :many
+SELECT id, subscription_id, event_id, event_type, payload, status, attempts, next_attempt_at,
+       last_status_code, last_error, created_at, delivered_at
+FROM event_deliveries
+WHERE subscription_id = $1
+  AND ($2::text IS NULL OR status = $2::text)
+ORDER BY created_at DESC
+LIMIT $3
+`
+
+type ListEventDeliveriesParams struct {
+	SubscriptionID int32
+	Status         pgtype.Text
+	RowLimit       int32
+}
+
+func (q *Queries) ListEventDeliveries(ctx context.Context, arg ListEventDeliveriesParams) ([]EventDelivery, error) {
+	rows, err := q.db.Query(ctx, listEventDeliveries, arg.SubscriptionID, arg.Status, arg.RowLimit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []EventDelivery
+	for rows.Next() {
+		var i EventDelivery
+		if err := rows.Scan(
+			&i.ID,
+			&i.SubscriptionID,
+			&i.EventID,
+			&i.EventType,
+			&i.Payload,
+			&i.Status,
+			&i.Attempts,
+			&i.NextAttemptAt,
+			&i.LastStatusCode,
+			&i.LastError,
+			&i.CreatedAt,
+			&i.DeliveredAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listEventSubscriptionsByOwner = `-- name: ListEventSubscriptionsByOwner :many
+SELECT id, owner_id, server_id, url, event_types, created_at
+FROM event_subscriptions
+WHERE owner_id = $1
+ORDER BY created_at DESC
+`
+
+type ListEventSubscriptionsByOwnerRow struct {
+	ID         int32
+	OwnerID    int32
+	ServerID   pgtype.Int4
+	Url        string
+	EventTypes []string
+	CreatedAt  pgtype.Timestamptz
+}
+
+func (q *Queries) ListEventSubscriptionsByOwner(ctx context.Context, ownerID int32) ([]ListEventSubscriptionsByOwnerRow, error) {
+	rows, err := q.db.Query(ctx, listEventSubscriptionsByOwner, ownerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListEventSubscriptionsByOwnerRow
+	for rows.Next() {
+		var i ListEventSubscriptionsByOwnerRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.OwnerID,
+			&i.ServerID,
+			&i.Url,
+			&i.EventTypes,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const markEventDeliveryDelivered = `-- name: MarkEventDeliveryDelivered :exec
+UPDATE event_deliveries
+SET status = 'delivered', last_status_code = $2, last_error = NULL, delivered_at = NOW()
+WHERE id = $1
+`
+
+type MarkEventDeliveryDeliveredParams struct {
+	ID             int32
+	LastStatusCode pgtype.Int4
+}
+
+func (q *Queries) MarkEventDeliveryDelivered(ctx context.Context, arg MarkEventDeliveryDeliveredParams) error {
+	_, err := q.db.Exec(ctx, markEventDeliveryDelivered, arg.ID, arg.LastStatusCode)
+	return err
+}
+
+const markEventDeliveryFailed = `-- name: MarkEventDeliveryFailed :exec
+UPDATE event_deliveries
+SET status = $1,
+    last_status_code = $2,
+    last_error = $3,
+    next_attempt_at = NOW() + ($4::int * INTERVAL '1 second')
+WHERE id = $5
+`
+
+type MarkEventDeliveryFailedParams struct {
+	Status         string
+	LastStatusCode pgtype.Int4
+	LastError      pgtype.Text
+	RetrySeconds   int32
+	ID             int32
+}
+
+func (q *Queries) MarkEventDeliveryFailed(ctx context.Context, arg MarkEventDeliveryFailedParams) error {
+	_, err := q.db.Exec(ctx, markEventDeliveryFailed,
+		arg.Status,
+		arg.LastStatusCode,
+		arg.LastError,
+		arg.RetrySeconds,
+		arg.ID,
+	)
+	return err
+}
+
+const redeliverEventDelivery = `-- name: RedeliverEventDelivery :execrows
+UPDATE event_deliveries
+SET status = 'pending', attempts = 0, next_attempt_at = NOW(), delivered_at = NULL
+WHERE id = $1 AND subscription_id = $2 AND status <> 'pending'
+`
+
+type RedeliverEventDeliveryParams struct {
+	ID             int32
+	SubscriptionID int32
+}
+
+func (q *Queries) RedeliverEventDelivery(ctx context.Context, arg RedeliverEventDeliveryParams) (int64, error) {
+	result, err := q.db.Exec(ctx, redeliverEventDelivery, arg.ID, arg.SubscriptionID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
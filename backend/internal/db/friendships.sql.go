@@ -11,10 +11,24 @@ import (
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
+const countOutgoingFriendRequests = `-- name: CountOutgoingFriendRequests :one
+SELECT COUNT(*)
+FROM friendships
+WHERE requester_id = $1
+  AND status = 'pending'
+`
+
+func (q *Queries) CountOutgoingFriendRequests(ctx context.Context, requesterID int32) (int64, error) {
+	row := q.db.QueryRow(ctx, countOutgoingFriendRequests, requesterID)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
 const createFriendship = `-- name: CreateFriendship :one
 INSERT INTO friendships (user_id, friend_id, requester_id, status)
 VALUES ($1, $2, $3, $4)
-RETURNING id, user_id, friend_id, requester_id, status, created_at
+RETURNING id, user_id, friend_id, requester_id, status, created_at, responded_at
 `
 
 type CreateFriendshipParams struct {
@@ -39,10 +53,77 @@ func (q *Queries) CreateFriendship(ctx context.Context, arg CreateFriendshipPara
 		&i.RequesterID,
 		&i.Status,
 		&i.CreatedAt,
+		&i.RespondedAt,
+	)
+	return i, err
+}
+
+const deleteActiveFriendship = `-- name: DeleteActiveFriendship :one
+DELETE FROM friendships
+WHERE user_id = $1 AND friend_id = $2
+  AND status IN ('pending', 'accepted')
+RETURNING id, user_id, friend_id, requester_id, status, created_at, responded_at
+`
+
+type DeleteActiveFriendshipParams struct {
+	UserID   int32
+	FriendID int32
+}
+
+func (q *Queries) DeleteActiveFriendship(ctx context.Context, arg DeleteActiveFriendshipParams) (Friendship, error) {
+	row := q.db.QueryRow(ctx, deleteActiveFriendship, arg.UserID, arg.FriendID)
+	var i Friendship
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.FriendID,
+		&i.RequesterID,
+		&i.Status,
+		&i.CreatedAt,
+		&i.RespondedAt,
 	)
 	return i, err
 }
 
+const deleteExpiredFriendRequests = `-- name: DeleteExpiredFriendRequests :many
+DELETE FROM friendships
+WHERE status = 'pending'
+  AND created_at < $1::timestamptz
+RETURNING id, user_id, friend_id, requester_id
+`
+
+type DeleteExpiredFriendRequestsRow struct {
+	ID          int32
+	UserID      int32
+	FriendID    int32
+	RequesterID int32
+}
+
+func (q *Queries) DeleteExpiredFriendRequests(ctx context.Context, cutoff pgtype.Timestamptz) ([]DeleteExpiredFriendRequestsRow, error) {
+	rows, err := q.db.Query(ctx, deleteExpiredFriendRequests, cutoff)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []DeleteExpiredFriendRequestsRow
+	for rows.Next() {
+		var i DeleteExpiredFriendRequestsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.FriendID,
+			&i.RequesterID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const deleteFriendship = `-- name: DeleteFriendship :exec
 DELETE FROM friendships
 WHERE user_id = $1 AND friend_id = $2
@@ -59,7 +140,7 @@ func (q *Queries) DeleteFriendship(ctx context.Context, arg DeleteFriendshipPara
 }
 
 const getFriendshipByID = `-- name: GetFriendshipByID :one
-SELECT id, user_id, friend_id, requester_id, status, created_at
+SELECT id, user_id, friend_id, requester_id, status, created_at, responded_at
 FROM friendships
 WHERE id = $1
 `
@@ -74,12 +155,13 @@ func (q *Queries) GetFriendshipByID(ctx context.Context, id int32) (Friendship,
 		&i.RequesterID,
 		&i.Status,
 		&i.CreatedAt,
+		&i.RespondedAt,
 	)
 	return i, err
 }
 
 const getFriendshipByUsers = `-- name: GetFriendshipByUsers :one
-SELECT id, user_id, friend_id, requester_id, status, created_at
+SELECT id, user_id, friend_id, requester_id, status, created_at, responded_at
 FROM friendships
 WHERE user_id = $1 AND friend_id = $2
 `
@@ -99,6 +181,7 @@ func (q *Queries) GetFriendshipByUsers(ctx context.Context, arg GetFriendshipByU
 		&i.RequesterID,
 		&i.Status,
 		&i.CreatedAt,
+		&i.RespondedAt,
 	)
 	return i, err
 }
@@ -109,6 +192,7 @@ SELECT
     u.username,
     u.avatar_url,
     u.avatar_color,
+    u.display_name,
     f.created_at AS friended_at
 FROM friendships f
 JOIN users u
@@ -126,6 +210,7 @@ type ListAcceptedFriendsRow struct {
 	Username    string
 	AvatarUrl   pgtype.Text
 	AvatarColor pgtype.Text
+	DisplayName pgtype.Text
 	FriendedAt  pgtype.Timestamptz
 }
 
@@ -143,6 +228,7 @@ func (q *Queries) ListAcceptedFriends(ctx context.Context, userID int32) ([]List
 			&i.Username,
 			&i.AvatarUrl,
 			&i.AvatarColor,
+			&i.DisplayName,
 			&i.FriendedAt,
 		); err != nil {
 			return nil, err
@@ -155,6 +241,113 @@ func (q *Queries) ListAcceptedFriends(ctx context.Context, userID int32) ([]List
 	return items, nil
 }
 
+const listFriendSuggestions = `-- name: ListFriendSuggestions :many
+WITH my_friends AS (
+    SELECT CASE WHEN f.user_id = $1::int THEN f.friend_id ELSE f.user_id END AS friend_id
+    FROM friendships f
+    WHERE (f.user_id = $1::int OR f.friend_id = $1::int)
+      AND f.status = 'accepted'
+),
+friends_of_friends AS (
+    SELECT CASE WHEN f.user_id = mf.friend_id THEN f.friend_id ELSE f.user_id END AS candidate_id,
+           COUNT(*) AS mutual_friends
+    FROM my_friends mf
+    JOIN friendships f
+        ON (f.user_id = mf.friend_id OR f.friend_id = mf.friend_id)
+       AND f.status = 'accepted'
+    GROUP BY 1
+),
+shared_servers AS (
+    SELECT other.user_id AS candidate_id, COUNT(*) AS mutual_servers
+    FROM server_members mine
+    JOIN server_members other
+        ON other.server_id = mine.server_id
+       AND other.user_id <> mine.user_id
+    WHERE mine.user_id = $1::int
+    GROUP BY other.user_id
+)
+SELECT
+    u.id,
+    u.username,
+    u.avatar_url,
+    u.avatar_color,
+    u.display_name,
+    COALESCE(fof.mutual_friends, 0)::int AS mutual_friends,
+    COALESCE(ss.mutual_servers, 0)::int AS mutual_servers
+FROM users u
+LEFT JOIN friends_of_friends fof ON fof.candidate_id = u.id
+LEFT JOIN shared_servers ss ON ss.candidate_id = u.id
+WHERE (fof.candidate_id IS NOT NULL OR ss.candidate_id IS NOT NULL)
+  AND u.id <> $1::int
+  AND u.is_bot = FALSE
+  AND NOT EXISTS (
+      SELECT 1 FROM friendships existing
+      WHERE existing.user_id = LEAST($1::int, u.id)
+        AND existing.friend_id = GREATEST($1::int, u.id)
+  )
+  AND NOT EXISTS (
+      SELECT 1 FROM blocks b
+      WHERE (b.blocker_id = $1::int AND b.blocked_id = u.id)
+         OR (b.blocker_id = u.id AND b.blocked_id = $1::int)
+  )
+  AND NOT EXISTS (
+      SELECT 1 FROM user_privacy_settings ps
+      WHERE ps.user_id = u.id
+        AND (ps.friend_request_policy = 'nobody' OR ps.discoverable = FALSE)
+  )
+ORDER BY
+    COALESCE(fof.mutual_friends, 0) * 3 + COALESCE(ss.mutual_servers, 0) DESC,
+    u.username ASC
+LIMIT $2
+`
+
+type ListFriendSuggestionsParams struct {
+	UserID    int32
+	PageLimit int32
+}
+
+type ListFriendSuggestionsRow struct {
+	ID            int32
+	Username      string
+	AvatarUrl     pgtype.Text
+	AvatarColor   pgtype.Text
+	DisplayName   pgtype.Text
+	MutualFriends int32
+	MutualServers int32
+}
+
+// Candidates are friends of the user's friends and members of the user's
+// servers. A mutual friend weighs three times a shared server. Bots, anyone
+// with a friendship row of any status, anyone on either side of a block and
+// anyone who accepts no friend requests are excluded.
+func (q *Queries) ListFriendSuggestions(ctx context.Context, arg ListFriendSuggestionsParams) ([]ListFriendSuggestionsRow, error) {
+	rows, err := q.db.Query(ctx, listFriendSuggestions, arg.UserID, arg.PageLimit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListFriendSuggestionsRow
+	for rows.Next() {
+		var i ListFriendSuggestionsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Username,
+			&i.AvatarUrl,
+			&i.AvatarColor,
+			&i.DisplayName,
+			&i.MutualFriends,
+			&i.MutualServers,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const listIncomingFriendRequests = `-- name: ListIncomingFriendRequests :many
 SELECT
     f.id,
@@ -165,7 +358,8 @@ SELECT
     f.created_at,
     u.username,
     u.avatar_url,
-    u.avatar_color
+    u.avatar_color,
+    u.display_name
 FROM friendships f
 JOIN users u ON u.id = f.requester_id
 WHERE (f.user_id = $1 OR f.friend_id = $1)
@@ -184,6 +378,7 @@ type ListIncomingFriendRequestsRow struct {
 	Username    string
 	AvatarUrl   pgtype.Text
 	AvatarColor pgtype.Text
+	DisplayName pgtype.Text
 }
 
 func (q *Queries) ListIncomingFriendRequests(ctx context.Context, userID int32) ([]ListIncomingFriendRequestsRow, error) {
@@ -205,6 +400,7 @@ func (q *Queries) ListIncomingFriendRequests(ctx context.Context, userID int32)
 			&i.Username,
 			&i.AvatarUrl,
 			&i.AvatarColor,
+			&i.DisplayName,
 		); err != nil {
 			return nil, err
 		}
@@ -226,7 +422,8 @@ SELECT
     f.created_at,
     u.username,
     u.avatar_url,
-    u.avatar_color
+    u.avatar_color,
+    u.display_name
 FROM friendships f
 JOIN users u
     ON u.id = CASE
@@ -249,6 +446,7 @@ type ListOutgoingFriendRequestsRow struct {
 	Username    string
 	AvatarUrl   pgtype.Text
 	AvatarColor pgtype.Text
+	DisplayName pgtype.Text
 }
 
 func (q *Queries) ListOutgoingFriendRequests(ctx context.Context, userID int32) ([]ListOutgoingFriendRequestsRow, error) {
@@ -270,6 +468,7 @@ func (q *Queries) ListOutgoingFriendRequests(ctx context.Context, userID int32)
 			&i.Username,
 			&i.AvatarUrl,
 			&i.AvatarColor,
+			&i.DisplayName,
 		); err != nil {
 			return nil, err
 		}
@@ -281,11 +480,42 @@ func (q *Queries) ListOutgoingFriendRequests(ctx context.Context, userID int32)
 	return items, nil
 }
 
+const reopenFriendRequest = `-- name: ReopenFriendRequest :one
+UPDATE friendships
+SET requester_id = $3,
+    status = 'pending',
+    created_at = CURRENT_TIMESTAMP,
+    responded_at = NULL
+WHERE user_id = $1 AND friend_id = $2
+RETURNING id, user_id, friend_id, requester_id, status, created_at, responded_at
+`
+
+type ReopenFriendRequestParams struct {
+	UserID      int32
+	FriendID    int32
+	RequesterID int32
+}
+
+func (q *Queries) ReopenFriendRequest(ctx context.Context, arg ReopenFriendRequestParams) (Friendship, error) {
+	row := q.db.QueryRow(ctx, reopenFriendRequest, arg.UserID, arg.FriendID, arg.RequesterID)
+	var i Friendship
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.FriendID,
+		&i.RequesterID,
+		&i.Status,
+		&i.CreatedAt,
+		&i.RespondedAt,
+	)
+	return i, err
+}
+
 const updateFriendshipStatus = `-- name: UpdateFriendshipStatus :one
 UPDATE friendships
-SET status = $3
+SET status = $3, responded_at = CURRENT_TIMESTAMP
 WHERE user_id = $1 AND friend_id = $2
-RETURNING id, user_id, friend_id, requester_id, status, created_at
+RETURNING id, user_id, friend_id, requester_id, status, created_at, responded_at
 `
 
 type UpdateFriendshipStatusParams struct {
@@ -304,6 +534,33 @@ func (q *Queries) UpdateFriendshipStatus(ctx context.Context, arg UpdateFriendsh
 		&i.RequesterID,
 		&i.Status,
 		&i.CreatedAt,
+		&i.RespondedAt,
 	)
 	return i, err
 }
+
+const usersShareFriend = `-- name: UsersShareFriend :one
+SELECT EXISTS (
+    SELECT 1
+    FROM friendships a
+    JOIN friendships b
+        ON CASE WHEN b.user_id = $1::int THEN b.friend_id ELSE b.user_id END
+         = CASE WHEN a.user_id = $2::int THEN a.friend_id ELSE a.user_id END
+    WHERE (a.user_id = $2::int OR a.friend_id = $2::int)
+      AND a.status = 'accepted'
+      AND (b.user_id = $1::int OR b.friend_id = $1::int)
+      AND b.status = 'accepted'
+)::boolean AS shares_friend
+`
+
+type UsersShareFriendParams struct {
+	OtherUserID int32
+	UserID      int32
+}
+
+func (q *Queries) UsersShareFriend(ctx context.Context, arg UsersShareFriendParams) (bool, error) {
+	row := q.db.QueryRow(ctx, usersShareFriend, arg.OtherUserID, arg.UserID)
+	var shares_friend bool
+	err := row.Scan(&shares_friend)
+	return shares_friend, err
+}
@@ -0,0 +1,262 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.30.0
+// source: interactions.sql
+
+package db
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const deleteBotCommand = `-- name: DeleteBotCommand :execrows
+DELETE FROM bot_commands
+WHERE id = $1 AND bot_id = $2
+`
+
+type DeleteBotCommandParams struct {
+	ID    int32
+	BotID int32
+}
+
+func (q *Queries) DeleteBotCommand(ctx context.Context, arg DeleteBotCommandParams) (int64, error) {
+	result, err := q.db.Exec(ctx, deleteBotCommand, arg.ID, arg.BotID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const deleteBotInteractionEndpoint = `-- name: DeleteBotInteractionEndpoint :execrows
+DELETE FROM bot_interaction_endpoints
+WHERE bot_id = $1
+`
+
+func (q *Queries) DeleteBotInteractionEndpoint(ctx context.Context, botID int32) (int64, error) {
+	result, err := q.db.Exec(ctx, deleteBotInteractionEndpoint, botID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const findChannelCommands = `-- name: FindChannelCommands :many
+SELECT bc.id, bc.bot_id, bc.name, bc.options, c.server_id AS channel_server_id,
+       u.username AS bot_username, u.avatar_url AS bot_avatar_url, u.avatar_color AS bot_avatar_color
+FROM channels c
+JOIN server_members sm ON sm.server_id = c.server_id
+JOIN bot_commands bc ON bc.bot_id = sm.user_id AND (bc.server_id IS NULL OR bc.server_id = c.server_id)
+JOIN users u ON u.id = bc.bot_id
+WHERE c.id = $1 AND bc.name = $2
+ORDER BY bc.server_id NULLS LAST
+`
+
+type FindChannelCommandsParams struct {
+	ID   int32
+	Name string
+}
+
+type FindChannelCommandsRow struct {
+	ID              int32
+	BotID           int32
+	Name            string
+	Options         []byte
+	ChannelServerID int32
+	BotUsername     string
+	BotAvatarUrl    pgtype.Text
+	BotAvatarColor  pgtype.Text
+}
+
+// Server-specific commands come before a bot's global command of the same name.
+func (q *Queries) FindChannelCommands(ctx context.Context, arg FindChannelCommandsParams) ([]FindChannelCommandsRow, error) {
+	rows, err := q.db.Query(ctx, findChannelCommands, arg.ID, arg.Name)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []FindChannelCommandsRow
+	for rows.Next() {
+		var i FindChannelCommandsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.BotID,
+			&i.Name,
+			&i.Options,
+			&i.ChannelServerID,
+			&i.BotUsername,
+			&i.BotAvatarUrl,
+			&i.BotAvatarColor,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getBotInteractionEndpoint = `-- name: GetBotInteractionEndpoint :one
+SELECT bot_id, url, secret, updated_at
+FROM bot_interaction_endpoints
+WHERE bot_id = $1
+`
+
+func (q *Queries) GetBotInteractionEndpoint(ctx context.Context, botID int32) (BotInteractionEndpoint, error) {
+	row := q.db.QueryRow(ctx, getBotInteractionEndpoint, botID)
+	var i BotInteractionEndpoint
+	err := row.Scan(
+		&i.BotID,
+		&i.Url,
+		&i.Secret,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const listBotCommands = `-- name: ListBotCommands :many
+SELECT id, bot_id, server_id, name, description, options, created_at, updated_at
+FROM bot_commands
+WHERE bot_id = $1
+ORDER BY name, server_id NULLS FIRST
+`
+
+func (q *Queries) ListBotCommands(ctx context.Context, botID int32) ([]BotCommand, error) {
+	rows, err := q.db.Query(ctx, listBotCommands, botID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []BotCommand
+	for rows.Next() {
+		var i BotCommand
+		if err := rows.Scan(
+			&i.ID,
+			&i.BotID,
+			&i.ServerID,
+			&i.Name,
+			&i.Description,
+			&i.Options,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listServerCommands = `-- name: ListServerCommands :many
+SELECT bc.id, bc.bot_id, bc.server_id, bc.name, bc.description, bc.options, bc.created_at, bc.updated_at,
+       u.username AS bot_username
+FROM bot_commands bc
+JOIN server_members sm ON sm.user_id = bc.bot_id AND sm.server_id = $1
+JOIN users u ON u.id = bc.bot_id
+WHERE bc.server_id IS NULL OR bc.server_id = $1
+ORDER BY bc.name, u.username
+`
+
+type ListServerCommandsRow struct {
+	ID          int32
+	BotID       int32
+	ServerID    pgtype.Int4
+	Name        string
+	Description string
+	Options     []byte
+	CreatedAt   pgtype.Timestamptz
+	UpdatedAt   pgtype.Timestamptz
+	BotUsername string
+}
+
+func (q *Queries) ListServerCommands(ctx context.Context, serverID int32) ([]ListServerCommandsRow, error) {
+	rows, err := q.db.Query(ctx, listServerCommands, serverID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListServerCommandsRow
+	for rows.Next() {
+		var i ListServerCommandsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.BotID,
+			&i.ServerID,
+			&i.Name,
+			&i.Description,
+			&i.Options,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.BotUsername,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const upsertBotCommand = `-- name: UpsertBotCommand :one
+INSERT INTO bot_commands (bot_id, server_id, name, description, options)
+VALUES ($1, $2, $3, $4, $5)
+ON CONFLICT (bot_id, server_id, name) DO UPDATE
+SET description = EXCLUDED.description, options = EXCLUDED.options, updated_at = CURRENT_TIMESTAMP
+RETURNING id, bot_id, server_id, name, description, options, created_at, updated_at
+`
+
+type UpsertBotCommandParams struct {
+	BotID       int32
+	ServerID    pgtype.Int4
+	Name        string
+	Description string
+	Options     []byte
+}
+
+func (q *Queries) UpsertBotCommand(ctx context.Context, arg UpsertBotCommandParams) (BotCommand, error) {
+	row := q.db.QueryRow(ctx, upsertBotCommand,
+		arg.BotID,
+		arg.ServerID,
+		arg.Name,
+		arg.Description,
+		arg.Options,
+	)
+	var i BotCommand
+	err := row.Scan(
+		&i.ID,
+		&i.BotID,
+		&i.ServerID,
+		&i.Name,
+		&i.Description,
+		&i.Options,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const upsertBotInteractionEndpoint = `-- name: UpsertBotInteractionEndpoint :exec
+INSERT INTO bot_interaction_endpoints (bot_id, url, secret)
+VALUES ($1, $2, $3)
+ON CONFLICT (bot_id) DO UPDATE
+SET url = EXCLUDED.url, secret = EXCLUDED.secret, updated_at = CURRENT_TIMESTAMP
+`
+
+type UpsertBotInteractionEndpointParams struct {
+	BotID  int32
+	Url    string
+	Secret string
+}
+
+func (q *Queries) UpsertBotInteractionEndpoint(ctx context.Context, arg UpsertBotInteractionEndpointParams) error {
+	_, err := q.db.Exec(ctx, upsertBotInteractionEndpoint, arg.BotID, arg.Url, arg.Secret)
+	return err
+}
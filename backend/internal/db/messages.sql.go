@@ -19,13 +19,21 @@ RETURNING id, channel_id, user_id, content, created_at
 
 type CreateMessageParams struct {
 	ChannelID int32
-	UserID    int32
+	UserID    pgtype.Int4
 	Content   string
 }
 
-func (q *Queries) CreateMessage(ctx context.Context, arg CreateMessageParams) (Message, error) {
+type CreateMessageRow struct {
+	ID        int32
+	ChannelID int32
+	UserID    pgtype.Int4
+	Content   string
+	CreatedAt pgtype.Timestamptz
+}
+
+func (q *Queries) CreateMessage(ctx context.Context, arg CreateMessageParams) (CreateMessageRow, error) {
 	row := q.db.QueryRow(ctx, createMessage, arg.ChannelID, arg.UserID, arg.Content)
-	var i Message
+	var i CreateMessageRow
 	err := row.Scan(
 		&i.ID,
 		&i.ChannelID,
@@ -36,18 +44,50 @@ func (q *Queries) CreateMessage(ctx context.Context, arg CreateMessageParams) (M
 	return i, err
 }
 
+const getChannelMemberProfile = `-- name: GetChannelMemberProfile :one
+SELECT sm.nickname, sm.avatar_url
+FROM channels c
+JOIN server_members sm ON sm.server_id = c.server_id
+WHERE c.id = $1 AND sm.user_id = $2
+`
+
+type GetChannelMemberProfileParams struct {
+	ID     int32
+	UserID int32
+}
+
+type GetChannelMemberProfileRow struct {
+	Nickname  pgtype.Text
+	AvatarUrl pgtype.Text
+}
+
+// The author's nickname and avatar in the server that owns the channel.
+func (q *Queries) GetChannelMemberProfile(ctx context.Context, arg GetChannelMemberProfileParams) (GetChannelMemberProfileRow, error) {
+	row := q.db.QueryRow(ctx, getChannelMemberProfile, arg.ID, arg.UserID)
+	var i GetChannelMemberProfileRow
+	err := row.Scan(&i.Nickname, &i.AvatarUrl)
+	return i, err
+}
+
 const listMessagesByChannel = `-- name: ListMessagesByChannel :many
 SELECT 
     m.id, 
     m.channel_id, 
     m.user_id, 
     m.content, 
-    u.username AS username, 
+    COALESCE(m.author_name, u.username, '')::text AS username, 
     m.created_at,
-    u.avatar_url AS avatar_url,
-    u.avatar_color AS avatar_color
+    COALESCE(m.author_avatar_url, sm.avatar_url, u.avatar_url, '')::text AS avatar_url,
+    u.avatar_color AS avatar_color,
+    (m.webhook_id IS NOT NULL OR COALESCE(u.is_bot, FALSE))::boolean AS is_bot,
+    m.webhook_id,
+    m.embeds,
+    COALESCE(sm.nickname, '')::text AS server_nickname,
+    COALESCE(u.display_name, '')::text AS display_name
 FROM messages m
 LEFT JOIN users u ON m.user_id = u.id
+JOIN channels c ON c.id = m.channel_id
+LEFT JOIN server_members sm ON sm.server_id = c.server_id AND sm.user_id = m.user_id
 WHERE m.channel_id = $1
 ORDER BY m.created_at ASC
 LIMIT $2 OFFSET $3
@@ -60,14 +100,19 @@ type ListMessagesByChannelParams struct {
 }
 
 type ListMessagesByChannelRow struct {
-	ID          int32
-	ChannelID   int32
-	UserID      int32
-	Content     string
-	Username    pgtype.Text
-	CreatedAt   pgtype.Timestamptz
-	AvatarUrl   pgtype.Text
-	AvatarColor pgtype.Text
+	ID             int32
+	ChannelID      int32
+	UserID         pgtype.Int4
+	Content        string
+	Username       string
+	CreatedAt      pgtype.Timestamptz
+	AvatarUrl      string
+	AvatarColor    pgtype.Text
+	IsBot          bool
+	WebhookID      pgtype.Int4
+	Embeds         []byte
+	ServerNickname string
+	DisplayName    string
 }
 
 func (q *Queries) ListMessagesByChannel(ctx context.Context, arg ListMessagesByChannelParams) ([]ListMessagesByChannelRow, error) {
@@ -88,6 +133,11 @@ func (q *Queries) ListMessagesByChannel(ctx context.Context, arg ListMessagesByC
 			&i.CreatedAt,
 			&i.AvatarUrl,
 			&i.AvatarColor,
+			&i.IsBot,
+			&i.WebhookID,
+			&i.Embeds,
+			&i.ServerNickname,
+			&i.DisplayName,
 		); err != nil {
 			return nil, err
 		}
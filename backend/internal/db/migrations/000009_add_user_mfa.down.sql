@@ -0,0 +1,3 @@
+DROP TABLE user_recovery_codes;
+ALTER TABLE users DROP COLUMN totp_enabled;
+ALTER TABLE users DROP COLUMN totp_secret;
@@ -0,0 +1,7 @@
+DROP TABLE bot_tokens;
+
+DROP INDEX idx_users_bot_owner_id;
+
+ALTER TABLE users
+DROP COLUMN bot_owner_id,
+DROP COLUMN is_bot;
@@ -0,0 +1,11 @@
+ALTER TABLE users
+ADD COLUMN is_bot BOOLEAN NOT NULL DEFAULT FALSE,
+ADD COLUMN bot_owner_id INT REFERENCES users(id) ON DELETE CASCADE;
+
+CREATE INDEX idx_users_bot_owner_id ON users(bot_owner_id);
+
+CREATE TABLE bot_tokens (
+    bot_id INT PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    token_hash TEXT NOT NULL UNIQUE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
@@ -0,0 +1,10 @@
+DELETE FROM messages WHERE user_id IS NULL;
+
+ALTER TABLE messages
+DROP COLUMN embeds,
+DROP COLUMN author_avatar_url,
+DROP COLUMN author_name,
+DROP COLUMN webhook_id,
+ALTER COLUMN user_id SET NOT NULL;
+
+DROP TABLE IF EXISTS channel_webhooks;
@@ -0,0 +1,20 @@
+CREATE TABLE channel_webhooks (
+    id SERIAL PRIMARY KEY,
+    channel_id INT NOT NULL REFERENCES channels(id) ON DELETE CASCADE,
+    created_by INT REFERENCES users(id) ON DELETE SET NULL,
+    name VARCHAR(80) NOT NULL,
+    avatar_url TEXT,
+    token_hash TEXT NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_channel_webhooks_channel_id ON channel_webhooks(channel_id);
+
+-- Webhook messages have no author account; the display name and avatar are
+-- stored on the message instead.
+ALTER TABLE messages
+ALTER COLUMN user_id DROP NOT NULL,
+ADD COLUMN webhook_id INT REFERENCES channel_webhooks(id) ON DELETE SET NULL,
+ADD COLUMN author_name VARCHAR(80),
+ADD COLUMN author_avatar_url TEXT,
+ADD COLUMN embeds JSONB;
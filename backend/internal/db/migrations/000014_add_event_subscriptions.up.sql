@@ -0,0 +1,32 @@
+-- server_id is set for server events; user-level subscriptions leave it NULL
+-- and receive the owner's own events.
+CREATE TABLE event_subscriptions (
+    id SERIAL PRIMARY KEY,
+    owner_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    server_id INT REFERENCES servers(id) ON DELETE CASCADE,
+    url TEXT NOT NULL,
+    secret TEXT NOT NULL,
+    event_types TEXT[] NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_event_subscriptions_owner_id ON event_subscriptions(owner_id);
+CREATE INDEX idx_event_subscriptions_server_id ON event_subscriptions(server_id);
+
+CREATE TABLE event_deliveries (
+    id SERIAL PRIMARY KEY,
+    subscription_id INT NOT NULL REFERENCES event_subscriptions(id) ON DELETE CASCADE,
+    event_id TEXT NOT NULL,
+    event_type TEXT NOT NULL,
+    payload JSONB NOT NULL,
+    status VARCHAR(16) NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'delivered', 'dead')),
+    attempts INT NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    last_status_code INT,
+    last_error TEXT,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    delivered_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE INDEX idx_event_deliveries_pending ON event_deliveries(next_attempt_at) WHERE status = 'pending';
+CREATE INDEX idx_event_deliveries_subscription_id ON event_deliveries(subscription_id, created_at);
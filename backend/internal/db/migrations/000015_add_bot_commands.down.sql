@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS bot_interaction_endpoints;
+DROP TABLE IF EXISTS bot_commands;
@@ -0,0 +1,22 @@
+-- Commands with a NULL server_id are global and available in every server
+-- the bot has been added to.
+CREATE TABLE bot_commands (
+    id SERIAL PRIMARY KEY,
+    bot_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    server_id INT REFERENCES servers(id) ON DELETE CASCADE,
+    name VARCHAR(32) NOT NULL,
+    description VARCHAR(100) NOT NULL DEFAULT '',
+    options JSONB NOT NULL DEFAULT '[]',
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE NULLS NOT DISTINCT (bot_id, server_id, name)
+);
+
+CREATE INDEX idx_bot_commands_name ON bot_commands(name);
+
+CREATE TABLE bot_interaction_endpoints (
+    bot_id INT PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    url TEXT NOT NULL,
+    secret TEXT NOT NULL,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
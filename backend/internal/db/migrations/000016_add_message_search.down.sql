@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_dm_messages_search_vector;
+DROP INDEX IF EXISTS idx_messages_search_vector;
+
+ALTER TABLE dm_messages DROP COLUMN IF EXISTS search_vector;
+ALTER TABLE messages DROP COLUMN IF EXISTS search_vector;
@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_messages_channel_id_created_at;
+DROP INDEX IF EXISTS idx_servers_name_trgm;
+DROP INDEX IF EXISTS idx_users_username_trgm;
+
+DROP EXTENSION IF EXISTS pg_trgm;
@@ -0,0 +1,7 @@
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+CREATE INDEX idx_users_username_trgm ON users USING GIN (username gin_trgm_ops);
+CREATE INDEX idx_servers_name_trgm ON servers USING GIN (name gin_trgm_ops);
+
+-- Discovery counts recent messages per channel
+CREATE INDEX idx_messages_channel_id_created_at ON messages(channel_id, created_at);
@@ -0,0 +1,13 @@
+DELETE FROM dm_messages WHERE type <> 'default';
+
+ALTER TABLE dm_messages
+    DROP COLUMN IF EXISTS target_user_id,
+    DROP COLUMN IF EXISTS type;
+
+DELETE FROM dm_conversations WHERE is_group;
+
+ALTER TABLE dm_conversations
+    DROP COLUMN IF EXISTS owner_id,
+    DROP COLUMN IF EXISTS icon_url,
+    DROP COLUMN IF EXISTS name,
+    DROP COLUMN IF EXISTS is_group;
@@ -0,0 +1,12 @@
+ALTER TABLE dm_conversations
+    ADD COLUMN is_group BOOLEAN NOT NULL DEFAULT FALSE,
+    ADD COLUMN name VARCHAR(100),
+    ADD COLUMN icon_url TEXT,
+    ADD COLUMN owner_id INT REFERENCES users(id) ON DELETE SET NULL;
+
+-- System messages record membership changes in group DMs. user_id is the
+-- member who made the change and target_user_id the member it affected.
+ALTER TABLE dm_messages
+    ADD COLUMN type TEXT NOT NULL DEFAULT 'default'
+        CHECK (type IN ('default', 'member_added', 'member_removed', 'member_left', 'group_updated')),
+    ADD COLUMN target_user_id INT REFERENCES users(id) ON DELETE SET NULL;
@@ -0,0 +1,5 @@
+ALTER TABLE dm_conversations
+    DROP COLUMN IF EXISTS request_status,
+    DROP COLUMN IF EXISTS requester_id;
+
+DROP TABLE IF EXISTS user_privacy_settings;
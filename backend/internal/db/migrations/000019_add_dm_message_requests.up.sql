@@ -0,0 +1,12 @@
+-- Privacy settings are created on first change; a missing row means defaults.
+CREATE TABLE user_privacy_settings (
+    user_id INT PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    allow_server_member_dms BOOLEAN NOT NULL DEFAULT FALSE,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+-- A conversation opened by a non-friend is a message request until the
+-- recipient accepts it. request_status is NULL for ordinary conversations.
+ALTER TABLE dm_conversations
+    ADD COLUMN requester_id INT REFERENCES users(id) ON DELETE SET NULL,
+    ADD COLUMN request_status TEXT CHECK (request_status IN ('pending', 'accepted', 'ignored'));
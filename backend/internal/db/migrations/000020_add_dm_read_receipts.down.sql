@@ -0,0 +1,6 @@
+ALTER TABLE user_privacy_settings
+    DROP COLUMN IF EXISTS send_read_receipts;
+
+ALTER TABLE dm_conversation_participants
+    DROP COLUMN IF EXISTS last_read_at,
+    DROP COLUMN IF EXISTS last_read_message_id;
@@ -0,0 +1,8 @@
+-- Each participant's read pointer. Messages up to and including
+-- last_read_message_id have been seen.
+ALTER TABLE dm_conversation_participants
+    ADD COLUMN last_read_message_id INT REFERENCES dm_messages(id) ON DELETE SET NULL,
+    ADD COLUMN last_read_at TIMESTAMP WITH TIME ZONE;
+
+ALTER TABLE user_privacy_settings
+    ADD COLUMN send_read_receipts BOOLEAN NOT NULL DEFAULT TRUE;
@@ -0,0 +1,15 @@
+DROP TABLE IF EXISTS dm_message_envelopes;
+
+DELETE FROM dm_messages WHERE type = 'encryption_enabled';
+
+ALTER TABLE dm_messages
+    DROP CONSTRAINT dm_messages_type_check,
+    ADD CONSTRAINT dm_messages_type_check
+        CHECK (type IN ('default', 'member_added', 'member_removed', 'member_left', 'group_updated')),
+    DROP COLUMN IF EXISTS sender_device_id;
+
+ALTER TABLE dm_conversations
+    DROP COLUMN IF EXISTS is_encrypted;
+
+DROP TABLE IF EXISTS device_one_time_prekeys;
+DROP TABLE IF EXISTS user_devices;
@@ -0,0 +1,42 @@
+-- Public keys for each client device. The server never sees private keys.
+CREATE TABLE user_devices (
+    id SERIAL PRIMARY KEY,
+    user_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    name VARCHAR(100) NOT NULL,
+    identity_key TEXT NOT NULL,
+    signed_prekey_id INT NOT NULL,
+    signed_prekey TEXT NOT NULL,
+    signed_prekey_signature TEXT NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_user_devices_user_id ON user_devices(user_id);
+
+-- One-time prekeys are handed out once and deleted when claimed.
+CREATE TABLE device_one_time_prekeys (
+    id SERIAL PRIMARY KEY,
+    device_id INT NOT NULL REFERENCES user_devices(id) ON DELETE CASCADE,
+    key_id INT NOT NULL,
+    public_key TEXT NOT NULL,
+    CONSTRAINT device_one_time_prekeys_unique UNIQUE (device_id, key_id)
+);
+
+ALTER TABLE dm_conversations
+    ADD COLUMN is_encrypted BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- Encrypted messages keep empty content; the ciphertext for each recipient
+-- device lives in dm_message_envelopes.
+ALTER TABLE dm_messages
+    ADD COLUMN sender_device_id INT REFERENCES user_devices(id) ON DELETE SET NULL,
+    DROP CONSTRAINT dm_messages_type_check,
+    ADD CONSTRAINT dm_messages_type_check
+        CHECK (type IN ('default', 'member_added', 'member_removed', 'member_left', 'group_updated', 'encryption_enabled'));
+
+CREATE TABLE dm_message_envelopes (
+    message_id INT NOT NULL REFERENCES dm_messages(id) ON DELETE CASCADE,
+    device_id INT NOT NULL REFERENCES user_devices(id) ON DELETE CASCADE,
+    ciphertext TEXT NOT NULL,
+    PRIMARY KEY (message_id, device_id)
+);
+
+CREATE INDEX idx_dm_message_envelopes_device_id ON dm_message_envelopes(device_id);
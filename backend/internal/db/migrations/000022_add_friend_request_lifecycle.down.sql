@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_friendships_pending_created_at;
+
+ALTER TABLE friendships
+    DROP COLUMN IF EXISTS responded_at;
@@ -0,0 +1,8 @@
+-- When the recipient accepted or rejected the request. Rejections start the
+-- requester's cooldown from this time.
+ALTER TABLE friendships
+    ADD COLUMN responded_at TIMESTAMP WITH TIME ZONE;
+
+-- Supports the expiry job, which only looks at pending requests.
+CREATE INDEX idx_friendships_pending_created_at ON friendships(created_at)
+    WHERE status = 'pending';
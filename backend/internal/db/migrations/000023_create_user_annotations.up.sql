@@ -0,0 +1,11 @@
+-- Private notes and nicknames one user keeps about another. Only the owner
+-- ever sees them.
+CREATE TABLE user_annotations (
+    owner_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    target_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    nickname TEXT NOT NULL DEFAULT '',
+    note TEXT NOT NULL DEFAULT '',
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (owner_id, target_id),
+    CHECK (owner_id <> target_id)
+);
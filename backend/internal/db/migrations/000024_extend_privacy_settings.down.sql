@@ -0,0 +1,10 @@
+ALTER TABLE user_privacy_settings
+    ADD COLUMN allow_server_member_dms BOOLEAN NOT NULL DEFAULT FALSE;
+
+UPDATE user_privacy_settings
+SET allow_server_member_dms = (dm_policy <> 'friends');
+
+ALTER TABLE user_privacy_settings
+    DROP COLUMN IF EXISTS discoverable,
+    DROP COLUMN IF EXISTS friend_request_policy,
+    DROP COLUMN IF EXISTS dm_policy;
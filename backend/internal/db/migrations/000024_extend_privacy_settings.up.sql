@@ -0,0 +1,15 @@
+-- Who may open DMs is now a policy rather than a single switch:
+-- 'friends' only, 'server_members' as message requests, or 'everyone'.
+ALTER TABLE user_privacy_settings
+    ADD COLUMN dm_policy TEXT NOT NULL DEFAULT 'friends'
+        CHECK (dm_policy IN ('friends', 'server_members', 'everyone')),
+    ADD COLUMN friend_request_policy TEXT NOT NULL DEFAULT 'everyone'
+        CHECK (friend_request_policy IN ('everyone', 'friends_of_friends', 'server_members', 'nobody')),
+    ADD COLUMN discoverable BOOLEAN NOT NULL DEFAULT TRUE;
+
+UPDATE user_privacy_settings
+SET dm_policy = 'server_members'
+WHERE allow_server_member_dms;
+
+ALTER TABLE user_privacy_settings
+    DROP COLUMN allow_server_member_dms;
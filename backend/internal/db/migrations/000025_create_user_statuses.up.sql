@@ -0,0 +1,10 @@
+-- Durable copy of each user's custom status. Reads go through Redis; this
+-- table refills the cache after a miss or a restart. Activities are
+-- short-lived and only kept in Redis.
+CREATE TABLE user_statuses (
+    user_id INT PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    text TEXT NOT NULL DEFAULT '',
+    emoji TEXT NOT NULL DEFAULT '',
+    expires_at TIMESTAMP WITH TIME ZONE,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
@@ -0,0 +1,3 @@
+ALTER TABLE server_members
+    DROP COLUMN avatar_url,
+    DROP COLUMN nickname;
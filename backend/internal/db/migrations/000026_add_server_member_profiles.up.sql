@@ -0,0 +1,4 @@
+-- Per-server nickname and avatar; NULL falls back to the user's own.
+ALTER TABLE server_members
+    ADD COLUMN nickname TEXT,
+    ADD COLUMN avatar_url TEXT;
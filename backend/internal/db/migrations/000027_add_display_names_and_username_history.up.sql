@@ -0,0 +1,18 @@
+-- users.username stays the unique login handle; display_name is a free-form
+-- label that falls back to it when NULL.
+ALTER TABLE users
+    ADD COLUMN display_name TEXT,
+    ADD COLUMN username_changed_at TIMESTAMP WITH TIME ZONE;
+
+-- Every handle a user gave up. Nobody else may take it until reserved_until,
+-- so a freed handle cannot be used to impersonate its previous owner.
+CREATE TABLE username_history (
+    id SERIAL PRIMARY KEY,
+    user_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    username TEXT NOT NULL,
+    changed_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    reserved_until TIMESTAMP WITH TIME ZONE NOT NULL
+);
+
+CREATE INDEX idx_username_history_username ON username_history (lower(username));
+CREATE INDEX idx_username_history_user ON username_history (user_id, changed_at DESC);
@@ -0,0 +1,2 @@
+-- Discovery looks up each public server's channels to find its recent messages
+CREATE INDEX idx_channels_server_id ON channels(server_id);
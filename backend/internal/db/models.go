@@ -15,6 +15,30 @@ type Block struct {
 	CreatedAt pgtype.Timestamptz
 }
 
+type BotCommand struct {
+	ID          int32
+	BotID       int32
+	ServerID    pgtype.Int4
+	Name        string
+	Description string
+	Options     []byte
+	CreatedAt   pgtype.Timestamptz
+	UpdatedAt   pgtype.Timestamptz
+}
+
+type BotInteractionEndpoint struct {
+	BotID     int32
+	Url       string
+	Secret    string
+	UpdatedAt pgtype.Timestamptz
+}
+
+type BotToken struct {
+	BotID     int32
+	TokenHash string
+	CreatedAt pgtype.Timestamptz
+}
+
 type Channel struct {
 	ID        int32
 	Name      string
@@ -23,15 +47,41 @@ type Channel struct {
 	ServerID  int32
 }
 
-type DmConversation struct {
+type ChannelWebhook struct {
 	ID        int32
+	ChannelID int32
+	CreatedBy pgtype.Int4
+	Name      string
+	AvatarUrl pgtype.Text
+	TokenHash string
 	CreatedAt pgtype.Timestamptz
 }
 
+type DeviceOneTimePrekey struct {
+	ID        int32
+	DeviceID  int32
+	KeyID     int32
+	PublicKey string
+}
+
+type DmConversation struct {
+	ID            int32
+	CreatedAt     pgtype.Timestamptz
+	IsGroup       bool
+	Name          pgtype.Text
+	IconUrl       pgtype.Text
+	OwnerID       pgtype.Int4
+	RequesterID   pgtype.Int4
+	RequestStatus pgtype.Text
+	IsEncrypted   bool
+}
+
 type DmConversationParticipant struct {
-	ConversationID int32
-	UserID         int32
-	JoinedAt       pgtype.Timestamptz
+	ConversationID    int32
+	UserID            int32
+	JoinedAt          pgtype.Timestamptz
+	LastReadMessageID pgtype.Int4
+	LastReadAt        pgtype.Timestamptz
 }
 
 type DmConversationVisibility struct {
@@ -46,6 +96,41 @@ type DmMessage struct {
 	UserID         int32
 	Content        string
 	CreatedAt      pgtype.Timestamptz
+	SearchVector   interface{}
+	Type           string
+	TargetUserID   pgtype.Int4
+	SenderDeviceID pgtype.Int4
+}
+
+type DmMessageEnvelope struct {
+	MessageID  int32
+	DeviceID   int32
+	Ciphertext string
+}
+
+type EventDelivery struct {
+	ID             int32
+	SubscriptionID int32
+	EventID        string
+	EventType      string
+	Payload        []byte
+	Status         string
+	Attempts       int32
+	NextAttemptAt  pgtype.Timestamptz
+	LastStatusCode pgtype.Int4
+	LastError      pgtype.Text
+	CreatedAt      pgtype.Timestamptz
+	DeliveredAt    pgtype.Timestamptz
+}
+
+type EventSubscription struct {
+	ID         int32
+	OwnerID    int32
+	ServerID   pgtype.Int4
+	Url        string
+	Secret     string
+	EventTypes []string
+	CreatedAt  pgtype.Timestamptz
 }
 
 type Friendship struct {
@@ -55,14 +140,31 @@ type Friendship struct {
 	RequesterID int32
 	Status      string
 	CreatedAt   pgtype.Timestamptz
+	RespondedAt pgtype.Timestamptz
 }
 
 type Message struct {
-	ID        int32
-	ChannelID int32
-	UserID    int32
-	Content   string
-	CreatedAt pgtype.Timestamptz
+	ID              int32
+	ChannelID       int32
+	UserID          pgtype.Int4
+	Content         string
+	CreatedAt       pgtype.Timestamptz
+	WebhookID       pgtype.Int4
+	AuthorName      pgtype.Text
+	AuthorAvatarUrl pgtype.Text
+	Embeds          []byte
+	SearchVector    interface{}
+}
+
+type PersonalAccessToken struct {
+	ID         int32
+	UserID     int32
+	Name       string
+	TokenHash  string
+	Scopes     []string
+	ExpiresAt  pgtype.Timestamptz
+	LastUsedAt pgtype.Timestamptz
+	CreatedAt  pgtype.Timestamptz
 }
 
 type Server struct {
@@ -74,16 +176,85 @@ type Server struct {
 }
 
 type ServerMember struct {
-	ServerID int32
-	UserID   int32
-	JoinedAt pgtype.Timestamp
+	ServerID  int32
+	UserID    int32
+	JoinedAt  pgtype.Timestamp
+	Nickname  pgtype.Text
+	AvatarUrl pgtype.Text
 }
 
 type User struct {
-	ID          int32
-	Username    string
-	Password    string
-	CreatedAt   pgtype.Timestamptz
-	AvatarUrl   pgtype.Text
-	AvatarColor pgtype.Text
+	ID                int32
+	Username          string
+	Password          string
+	CreatedAt         pgtype.Timestamptz
+	AvatarUrl         pgtype.Text
+	AvatarColor       pgtype.Text
+	TotpSecret        pgtype.Text
+	TotpEnabled       bool
+	IsBot             bool
+	BotOwnerID        pgtype.Int4
+	DisplayName       pgtype.Text
+	UsernameChangedAt pgtype.Timestamptz
+}
+
+type UserAnnotation struct {
+	OwnerID   int32
+	TargetID  int32
+	Nickname  string
+	Note      string
+	UpdatedAt pgtype.Timestamptz
+}
+
+type UserDevice struct {
+	ID                    int32
+	UserID                int32
+	Name                  string
+	IdentityKey           string
+	SignedPrekeyID        int32
+	SignedPrekey          string
+	SignedPrekeySignature string
+	CreatedAt             pgtype.Timestamptz
+}
+
+type UserIdentity struct {
+	ID        int32
+	UserID    int32
+	Issuer    string
+	Subject   string
+	Email     pgtype.Text
+	CreatedAt pgtype.Timestamptz
+}
+
+type UserPrivacySetting struct {
+	UserID              int32
+	UpdatedAt           pgtype.Timestamptz
+	SendReadReceipts    bool
+	DmPolicy            string
+	FriendRequestPolicy string
+	Discoverable        bool
+}
+
+type UserRecoveryCode struct {
+	ID        int32
+	UserID    int32
+	CodeHash  string
+	UsedAt    pgtype.Timestamptz
+	CreatedAt pgtype.Timestamptz
+}
+
+type UserStatus struct {
+	UserID    int32
+	Text      string
+	Emoji     string
+	ExpiresAt pgtype.Timestamptz
+	UpdatedAt pgtype.Timestamptz
+}
+
+type UsernameHistory struct {
+	ID            int32
+	UserID        int32
+	Username      string
+	ChangedAt     pgtype.Timestamptz
+	ReservedUntil pgtype.Timestamptz
 }
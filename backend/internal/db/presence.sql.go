@@ -0,0 +1,207 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.30.0
+// source: presence.sql
+
+package db
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const deleteUserStatus = `-- name: DeleteUserStatus :exec
+DELETE FROM user_statuses
+WHERE user_id = $1
+`
+
+func (q *Queries) DeleteUserStatus(ctx context.Context, userID int32) error {
+	_, err := q.db.Exec(ctx, deleteUserStatus, userID)
+	return err
+}
+
+const listStatusAudience = `-- name: ListStatusAudience :many
+SELECT audience.user_id::int AS user_id
+FROM (
+    SELECT candidates.user_id, bool_or(candidates.is_friend) AS is_friend
+    FROM (
+        SELECT CASE WHEN f.user_id = $1::int THEN f.friend_id ELSE f.user_id END AS user_id,
+               TRUE AS is_friend
+        FROM friendships f
+        WHERE (f.user_id = $1::int OR f.friend_id = $1::int)
+          AND f.status = 'accepted'
+        UNION ALL
+        SELECT other.user_id, FALSE AS is_friend
+        FROM server_members mine
+        JOIN server_members other ON other.server_id = mine.server_id
+        WHERE mine.user_id = $1::int
+          AND (
+              SELECT COUNT(*) FROM server_members sm WHERE sm.server_id = mine.server_id
+          ) <= $2::int
+    ) candidates
+    GROUP BY candidates.user_id
+) audience
+WHERE audience.user_id <> $1::int
+  AND NOT EXISTS (
+      SELECT 1 FROM blocks b
+      WHERE (b.blocker_id = $1::int AND b.blocked_id = audience.user_id)
+         OR (b.blocker_id = audience.user_id AND b.blocked_id = $1::int)
+  )
+ORDER BY audience.is_friend DESC, audience.user_id
+LIMIT $3::int
+`
+
+type ListStatusAudienceParams struct {
+	UserID           int32
+	MaxServerMembers int32
+	MaxAudience      int32
+}
+
+// Everyone who sees the user's status change live: accepted friends and
+// members of the user's servers, minus blocks in either direction. Only
+// servers up to max_server_members count, and at most max_audience users
+// are returned, friends first; larger audiences see the status when they
+// next load a profile or list.
+func (q *Queries) ListStatusAudience(ctx context.Context, arg ListStatusAudienceParams) ([]int32, error) {
+	rows, err := q.db.Query(ctx, listStatusAudience, arg.UserID, arg.MaxServerMembers, arg.MaxAudience)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []int32
+	for rows.Next() {
+		var user_id int32
+		if err := rows.Scan(&user_id); err != nil {
+			return nil, err
+		}
+		items = append(items, user_id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listStatusVisibleUsers = `-- name: ListStatusVisibleUsers :many
+SELECT target.id::int AS user_id
+FROM unnest($1::int[]) AS target(id)
+WHERE target.id = $2::int
+   OR (
+       (
+           EXISTS (
+               SELECT 1 FROM friendships f
+               WHERE f.status = 'accepted'
+                 AND f.user_id = LEAST(target.id, $2::int)
+                 AND f.friend_id = GREATEST(target.id, $2::int)
+           ) OR EXISTS (
+               SELECT 1
+               FROM server_members mine
+               JOIN server_members theirs ON theirs.server_id = mine.server_id
+               WHERE mine.user_id = $2::int AND theirs.user_id = target.id
+           )
+       )
+       AND NOT EXISTS (
+           SELECT 1 FROM blocks b
+           WHERE (b.blocker_id = $2::int AND b.blocked_id = target.id)
+              OR (b.blocker_id = target.id AND b.blocked_id = $2::int)
+       )
+   )
+`
+
+type ListStatusVisibleUsersParams struct {
+	UserIds  []int32
+	ViewerID int32
+}
+
+// The given users whose status the viewer may see: the viewer themselves,
+// accepted friends and server co-members, minus blocks in either direction.
+func (q *Queries) ListStatusVisibleUsers(ctx context.Context, arg ListStatusVisibleUsersParams) ([]int32, error) {
+	rows, err := q.db.Query(ctx, listStatusVisibleUsers, arg.UserIds, arg.ViewerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []int32
+	for rows.Next() {
+		var user_id int32
+		if err := rows.Scan(&user_id); err != nil {
+			return nil, err
+		}
+		items = append(items, user_id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listUserStatuses = `-- name: ListUserStatuses :many
+SELECT user_id, text, emoji, expires_at, updated_at
+FROM user_statuses
+WHERE user_id = ANY($1::int[])
+  AND (expires_at IS NULL OR expires_at > CURRENT_TIMESTAMP)
+`
+
+func (q *Queries) ListUserStatuses(ctx context.Context, userIds []int32) ([]UserStatus, error) {
+	rows, err := q.db.Query(ctx, listUserStatuses, userIds)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []UserStatus
+	for rows.Next() {
+		var i UserStatus
+		if err := rows.Scan(
+			&i.UserID,
+			&i.Text,
+			&i.Emoji,
+			&i.ExpiresAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const upsertUserStatus = `-- name: UpsertUserStatus :one
+INSERT INTO user_statuses (user_id, text, emoji, expires_at, updated_at)
+VALUES ($1, $2, $3, $4, CURRENT_TIMESTAMP)
+ON CONFLICT (user_id)
+DO UPDATE SET
+    text = EXCLUDED.text,
+    emoji = EXCLUDED.emoji,
+    expires_at = EXCLUDED.expires_at,
+    updated_at = EXCLUDED.updated_at
+RETURNING user_id, text, emoji, expires_at, updated_at
+`
+
+type UpsertUserStatusParams struct {
+	UserID    int32
+	Text      string
+	Emoji     string
+	ExpiresAt pgtype.Timestamptz
+}
+
+func (q *Queries) UpsertUserStatus(ctx context.Context, arg UpsertUserStatusParams) (UserStatus, error) {
+	row := q.db.QueryRow(ctx, upsertUserStatus,
+		arg.UserID,
+		arg.Text,
+		arg.Emoji,
+		arg.ExpiresAt,
+	)
+	var i UserStatus
+	err := row.Scan(
+		&i.UserID,
+		&i.Text,
+		&i.Emoji,
+		&i.ExpiresAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
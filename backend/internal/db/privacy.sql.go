@@ -0,0 +1,71 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.30.0
+// source: privacy.sql
+
+package db
+
+import (
+	"context"
+)
+
+const getUserPrivacySettings = `-- name: GetUserPrivacySettings :one
+SELECT user_id, updated_at, send_read_receipts, dm_policy, friend_request_policy, discoverable
+FROM user_privacy_settings
+WHERE user_id = $1
+`
+
+func (q *Queries) GetUserPrivacySettings(ctx context.Context, userID int32) (UserPrivacySetting, error) {
+	row := q.db.QueryRow(ctx, getUserPrivacySettings, userID)
+	var i UserPrivacySetting
+	err := row.Scan(
+		&i.UserID,
+		&i.UpdatedAt,
+		&i.SendReadReceipts,
+		&i.DmPolicy,
+		&i.FriendRequestPolicy,
+		&i.Discoverable,
+	)
+	return i, err
+}
+
+const upsertUserPrivacySettings = `-- name: UpsertUserPrivacySettings :one
+INSERT INTO user_privacy_settings (user_id, dm_policy, friend_request_policy, discoverable, send_read_receipts, updated_at)
+VALUES ($1, $2, $3, $4, $5, CURRENT_TIMESTAMP)
+ON CONFLICT (user_id)
+DO UPDATE SET
+    dm_policy = EXCLUDED.dm_policy,
+    friend_request_policy = EXCLUDED.friend_request_policy,
+    discoverable = EXCLUDED.discoverable,
+    send_read_receipts = EXCLUDED.send_read_receipts,
+    updated_at = EXCLUDED.updated_at
+RETURNING user_id, updated_at, send_read_receipts, dm_policy, friend_request_policy, discoverable
+`
+
+type UpsertUserPrivacySettingsParams struct {
+	UserID              int32
+	DmPolicy            string
+	FriendRequestPolicy string
+	Discoverable        bool
+	SendReadReceipts    bool
+}
+
+func (q *Queries) UpsertUserPrivacySettings(ctx context.Context, arg UpsertUserPrivacySettingsParams) (UserPrivacySetting, error) {
+	row := q.db.QueryRow(ctx, upsertUserPrivacySettings,
+		arg.UserID,
+		arg.DmPolicy,
+		arg.FriendRequestPolicy,
+		arg.Discoverable,
+		arg.SendReadReceipts,
+	)
+	var i UserPrivacySetting
+	err := row.Scan(
+		&i.UserID,
+		&i.UpdatedAt,
+		&i.SendReadReceipts,
+		&i.DmPolicy,
+		&i.FriendRequestPolicy,
+		&i.Discoverable,
+	)
+	return i, err
+}
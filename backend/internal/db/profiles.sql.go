@@ -0,0 +1,50 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.30.0
+// source: profiles.sql
+
+package db
+
+import (
+	"context"
+)
+
+const listMutualServers = `-- name: ListMutualServers :many
+SELECT s.id, s.name
+FROM servers s
+JOIN server_members viewer ON viewer.server_id = s.id
+JOIN server_members target ON target.server_id = s.id
+WHERE viewer.user_id = $1::int
+  AND target.user_id = $2::int
+ORDER BY s.name ASC
+`
+
+type ListMutualServersParams struct {
+	ViewerID int32
+	TargetID int32
+}
+
+type ListMutualServersRow struct {
+	ID   int32
+	Name string
+}
+
+func (q *Queries) ListMutualServers(ctx context.Context, arg ListMutualServersParams) ([]ListMutualServersRow, error) {
+	rows, err := q.db.Query(ctx, listMutualServers, arg.ViewerID, arg.TargetID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListMutualServersRow
+	for rows.Next() {
+		var i ListMutualServersRow
+		if err := rows.Scan(&i.ID, &i.Name); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
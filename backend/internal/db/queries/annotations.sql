@@ -0,0 +1,23 @@
+-- name: GetUserAnnotation :one
+SELECT owner_id, target_id, nickname, note, updated_at
+FROM user_annotations
+WHERE owner_id = $1 AND target_id = $2;
+
+-- name: ListUserAnnotations :many
+SELECT owner_id, target_id, nickname, note, updated_at
+FROM user_annotations
+WHERE owner_id = $1;
+
+-- name: UpsertUserAnnotation :one
+INSERT INTO user_annotations (owner_id, target_id, nickname, note, updated_at)
+VALUES ($1, $2, $3, $4, CURRENT_TIMESTAMP)
+ON CONFLICT (owner_id, target_id)
+DO UPDATE SET
+    nickname = EXCLUDED.nickname,
+    note = EXCLUDED.note,
+    updated_at = EXCLUDED.updated_at
+RETURNING owner_id, target_id, nickname, note, updated_at;
+
+-- name: DeleteUserAnnotation :exec
+DELETE FROM user_annotations
+WHERE owner_id = $1 AND target_id = $2;
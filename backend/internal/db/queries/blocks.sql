@@ -20,8 +20,14 @@ SELECT
     b.created_at,
     u.username,
     u.avatar_url,
-    u.avatar_color
+    u.avatar_color,
+    u.display_name
 FROM blocks b
 JOIN users u ON u.id = b.blocked_id
 WHERE b.blocker_id = $1
 ORDER BY b.created_at DESC;
+
+-- name: ListBlockedUserIDs :many
+SELECT blocked_id
+FROM blocks
+WHERE blocker_id = $1;
@@ -0,0 +1,35 @@
+-- name: CreateBotUser :one
+INSERT INTO users (username, password, avatar_color, is_bot, bot_owner_id)
+VALUES ($1, $2, $3, TRUE, $4)
+RETURNING id, username, avatar_url, avatar_color, bot_owner_id, created_at;
+
+-- name: ListBotsByOwner :many
+SELECT id, username, avatar_url, avatar_color, bot_owner_id, created_at
+FROM users
+WHERE is_bot = TRUE AND bot_owner_id = $1
+ORDER BY created_at ASC;
+
+-- name: GetBot :one
+SELECT id, username, avatar_url, avatar_color, bot_owner_id, created_at
+FROM users
+WHERE id = $1 AND is_bot = TRUE;
+
+-- name: UpsertBotToken :exec
+INSERT INTO bot_tokens (bot_id, token_hash)
+VALUES ($1, $2)
+ON CONFLICT (bot_id) DO UPDATE
+SET token_hash = EXCLUDED.token_hash, created_at = CURRENT_TIMESTAMP;
+
+-- name: GetBotByTokenHash :one
+SELECT u.id, u.username, u.avatar_url, u.avatar_color
+FROM bot_tokens t
+JOIN users u ON u.id = t.bot_id
+WHERE t.token_hash = $1 AND u.is_bot = TRUE;
+
+-- name: DeleteUserServerMemberships :exec
+DELETE FROM server_members
+WHERE user_id = $1;
+
+-- name: DeleteBot :execrows
+DELETE FROM users
+WHERE id = $1 AND is_bot = TRUE AND bot_owner_id = $2;
@@ -12,4 +12,11 @@ ORDER BY created_at ASC;
 -- name: GetChannel :one
 SELECT id, name, created_by, server_id, created_at
 FROM channels
-WHERE id = $1;
\ No newline at end of file
+WHERE id = $1;
+-- name: CanAccessChannel :one
+SELECT EXISTS (
+    SELECT 1
+    FROM channels c
+    JOIN server_members sm ON sm.server_id = c.server_id
+    WHERE c.id = $1 AND sm.user_id = $2
+);
@@ -0,0 +1,47 @@
+-- name: CreateUserDevice :one
+INSERT INTO user_devices (user_id, name, identity_key, signed_prekey_id, signed_prekey, signed_prekey_signature)
+VALUES ($1, $2, $3, $4, $5, $6)
+RETURNING id, user_id, name, identity_key, signed_prekey_id, signed_prekey, signed_prekey_signature, created_at;
+
+-- name: ListUserDevices :many
+SELECT id, user_id, name, identity_key, signed_prekey_id, signed_prekey, signed_prekey_signature, created_at
+FROM user_devices
+WHERE user_id = $1
+ORDER BY created_at ASC, id ASC;
+
+-- name: GetUserDevice :one
+SELECT id, user_id, name, identity_key, signed_prekey_id, signed_prekey, signed_prekey_signature, created_at
+FROM user_devices
+WHERE id = $1 AND user_id = $2;
+
+-- name: DeleteUserDevice :execrows
+DELETE FROM user_devices
+WHERE id = $1 AND user_id = $2;
+
+-- name: AddOneTimePrekey :exec
+INSERT INTO device_one_time_prekeys (device_id, key_id, public_key)
+VALUES ($1, $2, $3)
+ON CONFLICT (device_id, key_id) DO NOTHING;
+
+-- name: CountOneTimePrekeys :one
+SELECT COUNT(*)
+FROM device_one_time_prekeys
+WHERE device_id = $1;
+
+-- name: ClaimOneTimePrekey :one
+-- Hands out the oldest unused prekey exactly once, even under concurrency.
+DELETE FROM device_one_time_prekeys claimed
+WHERE claimed.id = (
+    SELECT p.id
+    FROM device_one_time_prekeys p
+    WHERE p.device_id = sqlc.arg(device_id)::int
+    ORDER BY p.key_id ASC
+    LIMIT 1
+    FOR UPDATE SKIP LOCKED
+)
+RETURNING claimed.key_id, claimed.public_key;
+
+-- name: ListDeviceOwners :many
+SELECT id, user_id
+FROM user_devices
+WHERE id = ANY(sqlc.arg(device_ids)::int[]);
@@ -1,25 +1,79 @@
 -- name: CreateDmConversation :one
 INSERT INTO dm_conversations DEFAULT VALUES
-RETURNING id, created_at;
+RETURNING id, created_at, is_group, name, icon_url, owner_id, requester_id, request_status, is_encrypted;
+
+-- name: CreateDmRequestConversation :one
+INSERT INTO dm_conversations (requester_id, request_status)
+VALUES ($1, 'pending')
+RETURNING id, created_at, is_group, name, icon_url, owner_id, requester_id, request_status, is_encrypted;
+
+-- name: SetDmConversationRequestStatus :exec
+UPDATE dm_conversations
+SET request_status = $2
+WHERE id = $1 AND request_status IS NOT NULL;
+
+-- name: CreateGroupDmConversation :one
+INSERT INTO dm_conversations (is_group, name, icon_url, owner_id)
+VALUES (TRUE, $1, $2, $3)
+RETURNING id, created_at, is_group, name, icon_url, owner_id, requester_id, request_status, is_encrypted;
+
+-- name: UpdateGroupDmConversation :one
+UPDATE dm_conversations
+SET name = $2, icon_url = $3
+WHERE id = $1 AND is_group
+RETURNING id, created_at, is_group, name, icon_url, owner_id, requester_id, request_status, is_encrypted;
+
+-- name: SetGroupDmOwner :exec
+UPDATE dm_conversations
+SET owner_id = $2
+WHERE id = $1 AND is_group;
+
+-- name: EnableDmConversationEncryption :execrows
+UPDATE dm_conversations
+SET is_encrypted = TRUE
+WHERE id = $1 AND NOT is_encrypted;
+
+-- name: DeleteDmConversation :exec
+DELETE FROM dm_conversations
+WHERE id = $1;
 
 -- name: AddDmConversationParticipant :one
 INSERT INTO dm_conversation_participants (conversation_id, user_id)
 VALUES ($1, $2)
-RETURNING conversation_id, user_id, joined_at;
+RETURNING conversation_id, user_id, joined_at, last_read_message_id, last_read_at;
+
+-- name: RemoveDmConversationParticipant :execrows
+DELETE FROM dm_conversation_participants
+WHERE conversation_id = $1 AND user_id = $2;
 
 -- name: GetDmConversationByUserPair :one
 SELECT
     c.id,
-    c.created_at
+    c.created_at,
+    c.is_group,
+    c.name,
+    c.icon_url,
+    c.owner_id,
+    c.requester_id,
+    c.request_status,
+    c.is_encrypted
 FROM dm_conversations c
 JOIN dm_conversation_participants p1 ON p1.conversation_id = c.id
 JOIN dm_conversation_participants p2 ON p2.conversation_id = c.id
 WHERE p1.user_id = $1
   AND p2.user_id = $2
+  AND NOT c.is_group
 LIMIT 1;
 
+-- name: ListSharedDmConversationIDs :many
+SELECT p1.conversation_id
+FROM dm_conversation_participants p1
+JOIN dm_conversation_participants p2 ON p2.conversation_id = p1.conversation_id
+WHERE p1.user_id = $1
+  AND p2.user_id = $2;
+
 -- name: GetDmConversationParticipant :one
-SELECT conversation_id, user_id, joined_at
+SELECT conversation_id, user_id, joined_at, last_read_message_id, last_read_at
 FROM dm_conversation_participants
 WHERE conversation_id = $1
   AND user_id = $2;
@@ -28,38 +82,101 @@ WHERE conversation_id = $1
 SELECT
     c.id,
     c.created_at,
-    other_user.id AS other_user_id,
-    other_user.username AS other_username,
-    other_user.avatar_url AS other_avatar_url,
-    other_user.avatar_color AS other_avatar_color
+    c.is_group,
+    c.name,
+    c.icon_url,
+    c.owner_id,
+    c.requester_id,
+    c.request_status,
+    c.is_encrypted
 FROM dm_conversations c
 JOIN dm_conversation_participants self_participant
     ON self_participant.conversation_id = c.id
-JOIN dm_conversation_participants other_participant
-    ON other_participant.conversation_id = c.id
-   AND other_participant.user_id <> self_participant.user_id
-JOIN users other_user ON other_user.id = other_participant.user_id
 WHERE c.id = $1
-  AND self_participant.user_id = $2
-LIMIT 1;
+  AND self_participant.user_id = $2;
+
+-- name: ListDmConversationParticipants :many
+-- Oldest members first, so the first row inherits ownership of a group.
+SELECT
+    p.conversation_id,
+    p.joined_at,
+    u.id AS user_id,
+    u.username,
+    u.avatar_url,
+    u.avatar_color,
+    u.display_name
+FROM dm_conversation_participants p
+JOIN users u ON u.id = p.user_id
+WHERE p.conversation_id = $1
+ORDER BY p.joined_at ASC, u.id ASC;
+
+-- name: ListDmParticipantsForUser :many
+-- Members of every conversation the user is in, for the conversation list.
+SELECT
+    p.conversation_id,
+    p.joined_at,
+    u.id AS user_id,
+    u.username,
+    u.avatar_url,
+    u.avatar_color,
+    u.display_name
+FROM dm_conversation_participants self_participant
+JOIN dm_conversation_participants p ON p.conversation_id = self_participant.conversation_id
+JOIN users u ON u.id = p.user_id
+WHERE self_participant.user_id = $1
+ORDER BY p.conversation_id, p.joined_at ASC, u.id ASC;
+
+-- name: MarkDmConversationRead :execrows
+-- Read pointers only move forward, and only to messages in the conversation.
+UPDATE dm_conversation_participants p
+SET last_read_message_id = sqlc.arg(message_id)::int, last_read_at = CURRENT_TIMESTAMP
+WHERE p.conversation_id = sqlc.arg(conversation_id)::int
+  AND p.user_id = sqlc.arg(user_id)::int
+  AND (p.last_read_message_id IS NULL OR p.last_read_message_id < sqlc.arg(message_id)::int)
+  AND EXISTS (
+      SELECT 1
+      FROM dm_messages m
+      WHERE m.id = sqlc.arg(message_id)::int AND m.conversation_id = sqlc.arg(conversation_id)::int
+  );
+
+-- name: ListDmReadStates :many
+SELECT
+    p.user_id,
+    p.last_read_message_id,
+    p.last_read_at,
+    COALESCE(ps.send_read_receipts, TRUE)::boolean AS send_read_receipts
+FROM dm_conversation_participants p
+LEFT JOIN user_privacy_settings ps ON ps.user_id = p.user_id
+WHERE p.conversation_id = $1
+ORDER BY p.joined_at ASC, p.user_id ASC;
+
+-- name: LockDmConversation :exec
+SELECT id
+FROM dm_conversations
+WHERE id = $1
+FOR UPDATE;
+
+-- name: CountDmConversationParticipants :one
+SELECT COUNT(*)
+FROM dm_conversation_participants
+WHERE conversation_id = $1;
 
 -- name: ListVisibleDmConversationsForUser :many
 SELECT
     c.id,
     c.created_at,
-    other_user.id AS other_user_id,
-    other_user.username AS other_username,
-    other_user.avatar_url AS other_avatar_url,
-    other_user.avatar_color AS other_avatar_color,
+    c.is_group,
+    c.name,
+    c.icon_url,
+    c.owner_id,
+    c.requester_id,
+    c.request_status,
+    c.is_encrypted,
     COALESCE(last_message.content, '') AS last_message_content,
     last_message.created_at AS last_message_created_at
 FROM dm_conversations c
 JOIN dm_conversation_participants self_participant
     ON self_participant.conversation_id = c.id
-JOIN dm_conversation_participants other_participant
-    ON other_participant.conversation_id = c.id
-   AND other_participant.user_id <> self_participant.user_id
-JOIN users other_user ON other_user.id = other_participant.user_id
 LEFT JOIN dm_conversation_visibility visibility
     ON visibility.conversation_id = c.id
    AND visibility.user_id = self_participant.user_id
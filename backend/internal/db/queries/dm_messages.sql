@@ -1,20 +1,42 @@
 -- name: CreateDmMessage :one
 INSERT INTO dm_messages (conversation_id, user_id, content)
 VALUES ($1, $2, $3)
-RETURNING id, conversation_id, user_id, content, created_at;
+RETURNING id, conversation_id, user_id, content, created_at, type, target_user_id, sender_device_id;
+
+-- name: CreateEncryptedDmMessage :one
+INSERT INTO dm_messages (conversation_id, user_id, content, sender_device_id)
+VALUES ($1, $2, '', $3)
+RETURNING id, conversation_id, user_id, content, created_at, type, target_user_id, sender_device_id;
+
+-- name: AddDmMessageEnvelope :exec
+INSERT INTO dm_message_envelopes (message_id, device_id, ciphertext)
+VALUES ($1, $2, $3);
+
+-- name: CreateDmSystemMessage :one
+INSERT INTO dm_messages (conversation_id, user_id, content, type, target_user_id)
+VALUES ($1, $2, $3, $4, $5)
+RETURNING id, conversation_id, user_id, content, created_at, type, target_user_id, sender_device_id;
 
 -- name: ListDmMessagesByConversation :many
+-- ciphertext is the envelope for the given device, if any.
 SELECT
     m.id,
     m.conversation_id,
     m.user_id,
     m.content,
     m.created_at,
+    m.type,
+    m.target_user_id,
+    m.sender_device_id,
+    e.ciphertext,
     u.username AS username,
     u.avatar_url AS avatar_url,
     u.avatar_color AS avatar_color
 FROM dm_messages m
 LEFT JOIN users u ON m.user_id = u.id
-WHERE m.conversation_id = $1
+LEFT JOIN dm_message_envelopes e
+    ON e.message_id = m.id
+   AND e.device_id = sqlc.narg(device_id)::int
+WHERE m.conversation_id = sqlc.arg(conversation_id)
 ORDER BY m.created_at ASC
-LIMIT $2 OFFSET $3;
+LIMIT sqlc.arg(page_limit) OFFSET sqlc.arg(page_offset);
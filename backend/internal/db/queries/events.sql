@@ -0,0 +1,77 @@
+-- name: CreateEventSubscription :one
+INSERT INTO event_subscriptions (owner_id, server_id, url, secret, event_types)
+VALUES ($1, $2, $3, $4, $5)
+RETURNING id, owner_id, server_id, url, event_types, created_at;
+
+-- name: ListEventSubscriptionsByOwner :many
+SELECT id, owner_id, server_id, url, event_types, created_at
+FROM event_subscriptions
+WHERE owner_id = $1
+ORDER BY created_at DESC;
+
+-- name: GetEventSubscription :one
+SELECT id, owner_id, server_id, url, event_types, created_at
+FROM event_subscriptions
+WHERE id = $1 AND owner_id = $2;
+
+-- name: DeleteEventSubscription :execrows
+DELETE FROM event_subscriptions
+WHERE id = $1 AND owner_id = $2;
+
+-- name: EnqueueEventDeliveries :execrows
+INSERT INTO event_deliveries (subscription_id, event_id, event_type, payload)
+SELECT s.id, sqlc.arg(event_id), sqlc.arg(event_type)::text, sqlc.arg(payload)
+FROM event_subscriptions s
+WHERE sqlc.arg(event_type)::text = ANY(s.event_types)
+  AND (
+    (s.server_id IS NOT NULL AND s.server_id = sqlc.narg(server_id))
+    OR (s.server_id IS NULL AND s.owner_id = sqlc.narg(user_id))
+  );
+
+-- name: ClaimEventDeliveries :many
+-- Claimed deliveries are leased by pushing next_attempt_at forward, so a
+-- worker that dies mid-delivery only delays the retry.
+WITH claimed AS (
+    UPDATE event_deliveries d
+    SET attempts = d.attempts + 1,
+        next_attempt_at = NOW() + (sqlc.arg(lease_seconds)::int * INTERVAL '1 second')
+    WHERE d.id IN (
+        SELECT id
+        FROM event_deliveries
+        WHERE status = 'pending' AND next_attempt_at <= NOW()
+        ORDER BY next_attempt_at
+        LIMIT sqlc.arg(batch_size)
+        FOR UPDATE SKIP LOCKED
+    )
+    RETURNING d.id, d.subscription_id, d.event_id, d.event_type, d.payload, d.attempts
+)
+SELECT c.id, c.subscription_id, c.event_id, c.event_type, c.payload, c.attempts, s.url, s.secret
+FROM claimed c
+JOIN event_subscriptions s ON s.id = c.subscription_id;
+
+-- name: MarkEventDeliveryDelivered :exec
+UPDATE event_deliveries
+SET status = 'delivered', last_status_code = $2, last_error = NULL, delivered_at = NOW()
+WHERE id = $1;
+
+-- name: MarkEventDeliveryFailed :exec
+UPDATE event_deliveries
+SET status = sqlc.arg(status),
+    last_status_code = sqlc.narg(last_status_code),
+    last_error = sqlc.arg(last_error),
+    next_attempt_at = NOW() + (sqlc.arg(retry_seconds)::int * INTERVAL '1 second')
+WHERE id = sqlc.arg(id);
+
+-- name: ListEventDeliveries :many
+SELECT id, subscription_id, event_id, event_type, payload, status, attempts, next_attempt_at,
+       last_status_code, last_error, created_at, delivered_at
+FROM event_deliveries
+WHERE subscription_id = sqlc.arg(subscription_id)
+  AND (sqlc.narg(status)::text IS NULL OR status = sqlc.narg(status)::text)
+ORDER BY created_at DESC
+LIMIT sqlc.arg(row_limit);
+
+-- name: RedeliverEventDelivery :execrows
+UPDATE event_deliveries
+SET status = 'pending', attempts = 0, next_attempt_at = NOW(), delivered_at = NULL
+WHERE id = $1 AND subscription_id = $2 AND status <> 'pending';
@@ -1,23 +1,44 @@
 -- name: CreateFriendship :one
 INSERT INTO friendships (user_id, friend_id, requester_id, status)
 VALUES ($1, $2, $3, $4)
-RETURNING id, user_id, friend_id, requester_id, status, created_at;
+RETURNING id, user_id, friend_id, requester_id, status, created_at, responded_at;
 
 -- name: GetFriendshipByUsers :one
-SELECT id, user_id, friend_id, requester_id, status, created_at
+SELECT id, user_id, friend_id, requester_id, status, created_at, responded_at
 FROM friendships
 WHERE user_id = $1 AND friend_id = $2;
 
 -- name: GetFriendshipByID :one
-SELECT id, user_id, friend_id, requester_id, status, created_at
+SELECT id, user_id, friend_id, requester_id, status, created_at, responded_at
 FROM friendships
 WHERE id = $1;
 
 -- name: UpdateFriendshipStatus :one
 UPDATE friendships
-SET status = $3
+SET status = $3, responded_at = CURRENT_TIMESTAMP
 WHERE user_id = $1 AND friend_id = $2
-RETURNING id, user_id, friend_id, requester_id, status, created_at;
+RETURNING id, user_id, friend_id, requester_id, status, created_at, responded_at;
+
+-- name: ReopenFriendRequest :one
+UPDATE friendships
+SET requester_id = $3,
+    status = 'pending',
+    created_at = CURRENT_TIMESTAMP,
+    responded_at = NULL
+WHERE user_id = $1 AND friend_id = $2
+RETURNING id, user_id, friend_id, requester_id, status, created_at, responded_at;
+
+-- name: CountOutgoingFriendRequests :one
+SELECT COUNT(*)
+FROM friendships
+WHERE requester_id = $1
+  AND status = 'pending';
+
+-- name: DeleteExpiredFriendRequests :many
+DELETE FROM friendships
+WHERE status = 'pending'
+  AND created_at < sqlc.arg(cutoff)::timestamptz
+RETURNING id, user_id, friend_id, requester_id;
 
 -- name: DeleteFriendship :exec
 DELETE FROM friendships
@@ -29,6 +50,7 @@ SELECT
     u.username,
     u.avatar_url,
     u.avatar_color,
+    u.display_name,
     f.created_at AS friended_at
 FROM friendships f
 JOIN users u
@@ -50,7 +72,8 @@ SELECT
     f.created_at,
     u.username,
     u.avatar_url,
-    u.avatar_color
+    u.avatar_color,
+    u.display_name
 FROM friendships f
 JOIN users u ON u.id = f.requester_id
 WHERE (f.user_id = $1 OR f.friend_id = $1)
@@ -68,7 +91,8 @@ SELECT
     f.created_at,
     u.username,
     u.avatar_url,
-    u.avatar_color
+    u.avatar_color,
+    u.display_name
 FROM friendships f
 JOIN users u
     ON u.id = CASE
@@ -79,3 +103,85 @@ WHERE (f.user_id = $1 OR f.friend_id = $1)
   AND f.requester_id = $1
   AND f.status = 'pending'
 ORDER BY f.created_at ASC;
+
+-- name: DeleteActiveFriendship :one
+DELETE FROM friendships
+WHERE user_id = $1 AND friend_id = $2
+  AND status IN ('pending', 'accepted')
+RETURNING id, user_id, friend_id, requester_id, status, created_at, responded_at;
+
+-- name: ListFriendSuggestions :many
+-- Candidates are friends of the user's friends and members of the user's
+-- servers. A mutual friend weighs three times a shared server. Bots, anyone
+-- with a friendship row of any status, anyone on either side of a block and
+-- anyone who accepts no friend requests are excluded.
+WITH my_friends AS (
+    SELECT CASE WHEN f.user_id = sqlc.arg(user_id)::int THEN f.friend_id ELSE f.user_id END AS friend_id
+    FROM friendships f
+    WHERE (f.user_id = sqlc.arg(user_id)::int OR f.friend_id = sqlc.arg(user_id)::int)
+      AND f.status = 'accepted'
+),
+friends_of_friends AS (
+    SELECT CASE WHEN f.user_id = mf.friend_id THEN f.friend_id ELSE f.user_id END AS candidate_id,
+           COUNT(*) AS mutual_friends
+    FROM my_friends mf
+    JOIN friendships f
+        ON (f.user_id = mf.friend_id OR f.friend_id = mf.friend_id)
+       AND f.status = 'accepted'
+    GROUP BY 1
+),
+shared_servers AS (
+    SELECT other.user_id AS candidate_id, COUNT(*) AS mutual_servers
+    FROM server_members mine
+    JOIN server_members other
+        ON other.server_id = mine.server_id
+       AND other.user_id <> mine.user_id
+    WHERE mine.user_id = sqlc.arg(user_id)::int
+    GROUP BY other.user_id
+)
+SELECT
+    u.id,
+    u.username,
+    u.avatar_url,
+    u.avatar_color,
+    u.display_name,
+    COALESCE(fof.mutual_friends, 0)::int AS mutual_friends,
+    COALESCE(ss.mutual_servers, 0)::int AS mutual_servers
+FROM users u
+LEFT JOIN friends_of_friends fof ON fof.candidate_id = u.id
+LEFT JOIN shared_servers ss ON ss.candidate_id = u.id
+WHERE (fof.candidate_id IS NOT NULL OR ss.candidate_id IS NOT NULL)
+  AND u.id <> sqlc.arg(user_id)::int
+  AND u.is_bot = FALSE
+  AND NOT EXISTS (
+      SELECT 1 FROM friendships existing
+      WHERE existing.user_id = LEAST(sqlc.arg(user_id)::int, u.id)
+        AND existing.friend_id = GREATEST(sqlc.arg(user_id)::int, u.id)
+  )
+  AND NOT EXISTS (
+      SELECT 1 FROM blocks b
+      WHERE (b.blocker_id = sqlc.arg(user_id)::int AND b.blocked_id = u.id)
+         OR (b.blocker_id = u.id AND b.blocked_id = sqlc.arg(user_id)::int)
+  )
+  AND NOT EXISTS (
+      SELECT 1 FROM user_privacy_settings ps
+      WHERE ps.user_id = u.id
+        AND (ps.friend_request_policy = 'nobody' OR ps.discoverable = FALSE)
+  )
+ORDER BY
+    COALESCE(fof.mutual_friends, 0) * 3 + COALESCE(ss.mutual_servers, 0) DESC,
+    u.username ASC
+LIMIT sqlc.arg(page_limit);
+
+-- name: UsersShareFriend :one
+SELECT EXISTS (
+    SELECT 1
+    FROM friendships a
+    JOIN friendships b
+        ON CASE WHEN b.user_id = sqlc.arg(other_user_id)::int THEN b.friend_id ELSE b.user_id END
+         = CASE WHEN a.user_id = sqlc.arg(user_id)::int THEN a.friend_id ELSE a.user_id END
+    WHERE (a.user_id = sqlc.arg(user_id)::int OR a.friend_id = sqlc.arg(user_id)::int)
+      AND a.status = 'accepted'
+      AND (b.user_id = sqlc.arg(other_user_id)::int OR b.friend_id = sqlc.arg(other_user_id)::int)
+      AND b.status = 'accepted'
+)::boolean AS shares_friend;
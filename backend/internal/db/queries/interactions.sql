@@ -0,0 +1,51 @@
+-- name: UpsertBotCommand :one
+INSERT INTO bot_commands (bot_id, server_id, name, description, options)
+VALUES ($1, $2, $3, $4, $5)
+ON CONFLICT (bot_id, server_id, name) DO UPDATE
+SET description = EXCLUDED.description, options = EXCLUDED.options, updated_at = CURRENT_TIMESTAMP
+RETURNING id, bot_id, server_id, name, description, options, created_at, updated_at;
+
+-- name: ListBotCommands :many
+SELECT id, bot_id, server_id, name, description, options, created_at, updated_at
+FROM bot_commands
+WHERE bot_id = $1
+ORDER BY name, server_id NULLS FIRST;
+
+-- name: DeleteBotCommand :execrows
+DELETE FROM bot_commands
+WHERE id = $1 AND bot_id = $2;
+
+-- name: ListServerCommands :many
+SELECT bc.id, bc.bot_id, bc.server_id, bc.name, bc.description, bc.options, bc.created_at, bc.updated_at,
+       u.username AS bot_username
+FROM bot_commands bc
+JOIN server_members sm ON sm.user_id = bc.bot_id AND sm.server_id = sqlc.arg(server_id)
+JOIN users u ON u.id = bc.bot_id
+WHERE bc.server_id IS NULL OR bc.server_id = sqlc.arg(server_id)
+ORDER BY bc.name, u.username;
+
+-- name: FindChannelCommands :many
+-- Server-specific commands come before a bot's global command of the same name.
+SELECT bc.id, bc.bot_id, bc.name, bc.options, c.server_id AS channel_server_id,
+       u.username AS bot_username, u.avatar_url AS bot_avatar_url, u.avatar_color AS bot_avatar_color
+FROM channels c
+JOIN server_members sm ON sm.server_id = c.server_id
+JOIN bot_commands bc ON bc.bot_id = sm.user_id AND (bc.server_id IS NULL OR bc.server_id = c.server_id)
+JOIN users u ON u.id = bc.bot_id
+WHERE c.id = $1 AND bc.name = $2
+ORDER BY bc.server_id NULLS LAST;
+
+-- name: UpsertBotInteractionEndpoint :exec
+INSERT INTO bot_interaction_endpoints (bot_id, url, secret)
+VALUES ($1, $2, $3)
+ON CONFLICT (bot_id) DO UPDATE
+SET url = EXCLUDED.url, secret = EXCLUDED.secret, updated_at = CURRENT_TIMESTAMP;
+
+-- name: GetBotInteractionEndpoint :one
+SELECT bot_id, url, secret, updated_at
+FROM bot_interaction_endpoints
+WHERE bot_id = $1;
+
+-- name: DeleteBotInteractionEndpoint :execrows
+DELETE FROM bot_interaction_endpoints
+WHERE bot_id = $1;
@@ -3,18 +3,32 @@ INSERT INTO messages (channel_id, user_id, content)
 VALUES ($1, $2, $3)
 RETURNING id, channel_id, user_id, content, created_at;
 
+-- name: GetChannelMemberProfile :one
+-- The author's nickname and avatar in the server that owns the channel.
+SELECT sm.nickname, sm.avatar_url
+FROM channels c
+JOIN server_members sm ON sm.server_id = c.server_id
+WHERE c.id = $1 AND sm.user_id = $2;
+
 -- name: ListMessagesByChannel :many
 SELECT 
     m.id, 
     m.channel_id, 
     m.user_id, 
     m.content, 
-    u.username AS username, 
+    COALESCE(m.author_name, u.username, '')::text AS username, 
     m.created_at,
-    u.avatar_url AS avatar_url,
-    u.avatar_color AS avatar_color
+    COALESCE(m.author_avatar_url, sm.avatar_url, u.avatar_url, '')::text AS avatar_url,
+    u.avatar_color AS avatar_color,
+    (m.webhook_id IS NOT NULL OR COALESCE(u.is_bot, FALSE))::boolean AS is_bot,
+    m.webhook_id,
+    m.embeds,
+    COALESCE(sm.nickname, '')::text AS server_nickname,
+    COALESCE(u.display_name, '')::text AS display_name
 FROM messages m
 LEFT JOIN users u ON m.user_id = u.id
+JOIN channels c ON c.id = m.channel_id
+LEFT JOIN server_members sm ON sm.server_id = c.server_id AND sm.user_id = m.user_id
 WHERE m.channel_id = $1
 ORDER BY m.created_at ASC
 LIMIT $2 OFFSET $3;
\ No newline at end of file
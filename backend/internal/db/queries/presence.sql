@@ -0,0 +1,82 @@
+-- name: ListUserStatuses :many
+SELECT user_id, text, emoji, expires_at, updated_at
+FROM user_statuses
+WHERE user_id = ANY(sqlc.arg(user_ids)::int[])
+  AND (expires_at IS NULL OR expires_at > CURRENT_TIMESTAMP);
+
+-- name: UpsertUserStatus :one
+INSERT INTO user_statuses (user_id, text, emoji, expires_at, updated_at)
+VALUES ($1, $2, $3, $4, CURRENT_TIMESTAMP)
+ON CONFLICT (user_id)
+DO UPDATE SET
+    text = EXCLUDED.text,
+    emoji = EXCLUDED.emoji,
+    expires_at = EXCLUDED.expires_at,
+    updated_at = EXCLUDED.updated_at
+RETURNING user_id, text, emoji, expires_at, updated_at;
+
+-- name: DeleteUserStatus :exec
+DELETE FROM user_statuses
+WHERE user_id = $1;
+
+-- name: ListStatusAudience :many
+-- Everyone who sees the user's status change live: accepted friends and
+-- members of the user's servers, minus blocks in either direction. Only
+-- servers up to max_server_members count, and at most max_audience users
+-- are returned, friends first; larger audiences see the status when they
+-- next load a profile or list.
+SELECT audience.user_id::int AS user_id
+FROM (
+    SELECT candidates.user_id, bool_or(candidates.is_friend) AS is_friend
+    FROM (
+        SELECT CASE WHEN f.user_id = sqlc.arg(user_id)::int THEN f.friend_id ELSE f.user_id END AS user_id,
+               TRUE AS is_friend
+        FROM friendships f
+        WHERE (f.user_id = sqlc.arg(user_id)::int OR f.friend_id = sqlc.arg(user_id)::int)
+          AND f.status = 'accepted'
+        UNION ALL
+        SELECT other.user_id, FALSE AS is_friend
+        FROM server_members mine
+        JOIN server_members other ON other.server_id = mine.server_id
+        WHERE mine.user_id = sqlc.arg(user_id)::int
+          AND (
+              SELECT COUNT(*) FROM server_members sm WHERE sm.server_id = mine.server_id
+          ) <= sqlc.arg(max_server_members)::int
+    ) candidates
+    GROUP BY candidates.user_id
+) audience
+WHERE audience.user_id <> sqlc.arg(user_id)::int
+  AND NOT EXISTS (
+      SELECT 1 FROM blocks b
+      WHERE (b.blocker_id = sqlc.arg(user_id)::int AND b.blocked_id = audience.user_id)
+         OR (b.blocker_id = audience.user_id AND b.blocked_id = sqlc.arg(user_id)::int)
+  )
+ORDER BY audience.is_friend DESC, audience.user_id
+LIMIT sqlc.arg(max_audience)::int;
+
+-- name: ListStatusVisibleUsers :many
+-- The given users whose status the viewer may see: the viewer themselves,
+-- accepted friends and server co-members, minus blocks in either direction.
+SELECT target.id::int AS user_id
+FROM unnest(sqlc.arg(user_ids)::int[]) AS target(id)
+WHERE target.id = sqlc.arg(viewer_id)::int
+   OR (
+       (
+           EXISTS (
+               SELECT 1 FROM friendships f
+               WHERE f.status = 'accepted'
+                 AND f.user_id = LEAST(target.id, sqlc.arg(viewer_id)::int)
+                 AND f.friend_id = GREATEST(target.id, sqlc.arg(viewer_id)::int)
+           ) OR EXISTS (
+               SELECT 1
+               FROM server_members mine
+               JOIN server_members theirs ON theirs.server_id = mine.server_id
+               WHERE mine.user_id = sqlc.arg(viewer_id)::int AND theirs.user_id = target.id
+           )
+       )
+       AND NOT EXISTS (
+           SELECT 1 FROM blocks b
+           WHERE (b.blocker_id = sqlc.arg(viewer_id)::int AND b.blocked_id = target.id)
+              OR (b.blocker_id = target.id AND b.blocked_id = sqlc.arg(viewer_id)::int)
+       )
+   );
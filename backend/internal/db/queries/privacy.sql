@@ -0,0 +1,16 @@
+-- name: GetUserPrivacySettings :one
+SELECT user_id, updated_at, send_read_receipts, dm_policy, friend_request_policy, discoverable
+FROM user_privacy_settings
+WHERE user_id = $1;
+
+-- name: UpsertUserPrivacySettings :one
+INSERT INTO user_privacy_settings (user_id, dm_policy, friend_request_policy, discoverable, send_read_receipts, updated_at)
+VALUES ($1, $2, $3, $4, $5, CURRENT_TIMESTAMP)
+ON CONFLICT (user_id)
+DO UPDATE SET
+    dm_policy = EXCLUDED.dm_policy,
+    friend_request_policy = EXCLUDED.friend_request_policy,
+    discoverable = EXCLUDED.discoverable,
+    send_read_receipts = EXCLUDED.send_read_receipts,
+    updated_at = EXCLUDED.updated_at
+RETURNING user_id, updated_at, send_read_receipts, dm_policy, friend_request_policy, discoverable;
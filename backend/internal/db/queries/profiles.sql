@@ -0,0 +1,8 @@
+-- name: ListMutualServers :many
+SELECT s.id, s.name
+FROM servers s
+JOIN server_members viewer ON viewer.server_id = s.id
+JOIN server_members target ON target.server_id = s.id
+WHERE viewer.user_id = sqlc.arg(viewer_id)::int
+  AND target.user_id = sqlc.arg(target_id)::int
+ORDER BY s.name ASC;
@@ -0,0 +1,101 @@
+-- name: ListMemberServerIDs :many
+SELECT server_id FROM server_members WHERE user_id = $1;
+
+-- name: ListParticipantConversationIDs :many
+-- Encrypted conversations are left out: the server only holds ciphertext.
+SELECT p.conversation_id
+FROM dm_conversation_participants p
+JOIN dm_conversations c ON c.id = p.conversation_id
+WHERE p.user_id = $1 AND NOT c.is_encrypted;
+
+-- name: ListBlockRelatedUserIDs :many
+-- Users the given user blocked or was blocked by.
+SELECT (CASE WHEN blocker_id = sqlc.arg(user_id) THEN blocked_id ELSE blocker_id END)::int AS user_id
+FROM blocks
+WHERE blocker_id = sqlc.arg(user_id) OR blocked_id = sqlc.arg(user_id);
+
+-- name: SearchMessages :many
+-- Ranks channel and DM messages together. Access is decided by the caller,
+-- which passes the servers and conversations the searcher can read. The
+-- headline is computed only for the returned page.
+WITH q AS (
+    SELECT websearch_to_tsquery('english', sqlc.arg(query)::text) AS query
+),
+hits AS (
+    SELECT
+        'channel'::text AS source,
+        m.id,
+        m.channel_id::int AS channel_id,
+        c.server_id::int AS server_id,
+        0::int AS conversation_id,
+        COALESCE(m.user_id, 0)::int AS user_id,
+        COALESCE(m.author_name, u.username, '')::text AS username,
+        COALESCE(m.author_avatar_url, u.avatar_url, '')::text AS avatar_url,
+        COALESCE(u.avatar_color, '')::text AS avatar_color,
+        (m.webhook_id IS NOT NULL OR COALESCE(u.is_bot, FALSE))::boolean AS is_bot,
+        m.content,
+        m.created_at,
+        ts_rank(m.search_vector, q.query) AS rank
+    FROM messages m
+    CROSS JOIN q
+    JOIN channels c ON c.id = m.channel_id
+    LEFT JOIN users u ON u.id = m.user_id
+    WHERE m.search_vector @@ q.query
+      AND c.server_id = ANY(sqlc.arg(server_ids)::int[])
+      AND (sqlc.narg(channel_id)::int IS NULL OR m.channel_id = sqlc.narg(channel_id)::int)
+      AND (sqlc.narg(author_id)::int IS NULL OR m.user_id = sqlc.narg(author_id)::int)
+      AND (sqlc.narg(after)::timestamptz IS NULL OR m.created_at >= sqlc.narg(after)::timestamptz)
+      AND (sqlc.narg(before)::timestamptz IS NULL OR m.created_at < sqlc.narg(before)::timestamptz)
+      AND (NOT sqlc.arg(has_attachment)::boolean OR m.embeds IS NOT NULL OR m.content ~* 'https?://')
+      AND (sqlc.narg(mention_pattern)::text IS NULL OR m.content ~* sqlc.narg(mention_pattern)::text)
+      AND (m.user_id IS NULL OR NOT m.user_id = ANY(sqlc.arg(excluded_user_ids)::int[]))
+    UNION ALL
+    SELECT
+        'dm'::text AS source,
+        d.id,
+        0::int AS channel_id,
+        0::int AS server_id,
+        d.conversation_id::int AS conversation_id,
+        d.user_id::int AS user_id,
+        u.username::text AS username,
+        COALESCE(u.avatar_url, '')::text AS avatar_url,
+        COALESCE(u.avatar_color, '')::text AS avatar_color,
+        u.is_bot::boolean AS is_bot,
+        d.content,
+        d.created_at,
+        ts_rank(d.search_vector, q.query) AS rank
+    FROM dm_messages d
+    CROSS JOIN q
+    JOIN users u ON u.id = d.user_id
+    WHERE d.search_vector @@ q.query
+      AND d.type = 'default'
+      AND d.conversation_id = ANY(sqlc.arg(conversation_ids)::int[])
+      AND (sqlc.narg(author_id)::int IS NULL OR d.user_id = sqlc.narg(author_id)::int)
+      AND (sqlc.narg(after)::timestamptz IS NULL OR d.created_at >= sqlc.narg(after)::timestamptz)
+      AND (sqlc.narg(before)::timestamptz IS NULL OR d.created_at < sqlc.narg(before)::timestamptz)
+      AND (NOT sqlc.arg(has_attachment)::boolean OR d.content ~* 'https?://')
+      AND (sqlc.narg(mention_pattern)::text IS NULL OR d.content ~* sqlc.narg(mention_pattern)::text)
+      AND NOT d.user_id = ANY(sqlc.arg(excluded_user_ids)::int[])
+),
+page AS (
+    SELECT * FROM hits
+    ORDER BY rank DESC, created_at DESC, id DESC
+    LIMIT sqlc.arg(page_limit) OFFSET sqlc.arg(page_offset)
+)
+SELECT
+    page.source,
+    page.id,
+    page.channel_id,
+    page.server_id,
+    page.conversation_id,
+    page.user_id,
+    page.username,
+    page.avatar_url,
+    page.avatar_color,
+    page.is_bot,
+    page.content,
+    page.created_at,
+    ts_headline('english', page.content, q.query, sqlc.arg(headline_options)::text)::text AS snippet
+FROM page
+CROSS JOIN q
+ORDER BY page.rank DESC, page.created_at DESC, page.id DESC;
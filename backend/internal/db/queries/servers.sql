@@ -31,5 +31,91 @@ SELECT EXISTS (
     WHERE server_id = $1 AND user_id = $2
 );
 
+-- name: GetServerMemberProfile :one
+SELECT server_id, user_id, nickname, avatar_url
+FROM server_members
+WHERE server_id = $1 AND user_id = $2;
 
+-- name: UpdateServerMemberProfile :one
+UPDATE server_members
+SET nickname = sqlc.narg(nickname), avatar_url = sqlc.narg(avatar_url)
+WHERE server_id = sqlc.arg(server_id) AND user_id = sqlc.arg(user_id)
+RETURNING server_id, user_id, nickname, avatar_url;
 
+-- name: UsersShareServer :one
+SELECT EXISTS (
+    SELECT 1
+    FROM server_members a
+    JOIN server_members b ON b.server_id = a.server_id
+    WHERE a.user_id = $1 AND b.user_id = $2
+);
+
+-- name: DeleteServerChannels :exec
+DELETE FROM channels
+WHERE server_id = $1;
+
+-- name: DeleteServerMembers :exec
+DELETE FROM server_members
+WHERE server_id = $1;
+
+-- name: DeleteServer :exec
+DELETE FROM servers
+WHERE id = $1;
+
+-- name: LeaveServer :execrows
+DELETE FROM server_members
+WHERE server_id = $1 AND user_id = $2;
+
+-- name: ListDiscoverableServers :many
+-- Public servers the user is not in. Without a query they are ranked by
+-- popularity: members plus messages in the last week, both log-scaled. With a
+-- query, name relevance comes first.
+SELECT
+    s.id,
+    s.name,
+    s.creator_id,
+    s.is_public,
+    s.created_at,
+    members.member_count::bigint AS member_count,
+    activity.recent_message_count::bigint AS recent_message_count,
+    latest.last_message_at::timestamptz AS last_message_at
+FROM servers s
+CROSS JOIN LATERAL (
+    SELECT COUNT(*) AS member_count
+    FROM server_members sm
+    WHERE sm.server_id = s.id
+) members
+-- Both activity lookups stay on idx_messages_channel_id_created_at, so their
+-- cost follows last week's messages rather than the whole history.
+CROSS JOIN LATERAL (
+    SELECT COUNT(*) AS recent_message_count
+    FROM channels c
+    JOIN messages m ON m.channel_id = c.id
+    WHERE c.server_id = s.id
+      AND m.created_at > NOW() - INTERVAL '7 days'
+) activity
+CROSS JOIN LATERAL (
+    SELECT MAX(newest.created_at) AS last_message_at
+    FROM channels c
+    CROSS JOIN LATERAL (
+        SELECT m.created_at
+        FROM messages m
+        WHERE m.channel_id = c.id
+        ORDER BY m.created_at DESC
+        LIMIT 1
+    ) newest
+    WHERE c.server_id = s.id
+) latest
+WHERE s.is_public = TRUE
+  AND NOT EXISTS (
+      SELECT 1 FROM server_members sm
+      WHERE sm.server_id = s.id AND sm.user_id = sqlc.arg(user_id)
+  )
+  AND (sqlc.arg(query)::text = '' OR s.name ILIKE sqlc.arg(pattern)::text OR s.name % sqlc.arg(query)::text)
+ORDER BY
+    CASE WHEN sqlc.arg(query)::text = '' THEN 0
+         ELSE similarity(s.name, sqlc.arg(query)::text) + (s.name ILIKE sqlc.arg(prefix_pattern)::text)::int
+    END DESC,
+    ln(1 + members.member_count) + ln(1 + activity.recent_message_count) DESC,
+    s.id ASC
+LIMIT sqlc.arg(page_limit) OFFSET sqlc.arg(page_offset);
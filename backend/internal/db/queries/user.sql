@@ -1,19 +1,108 @@
 -- name: CreateUser :one
-INSERT INTO users (username, password, avatar_color) 
-VALUES ($1, $2, $3)
-RETURNING  id, username, avatar_url, avatar_color;
+INSERT INTO users (username, password, avatar_color, display_name)
+VALUES ($1, $2, $3, $4)
+RETURNING  id, username, avatar_url, avatar_color, display_name;
 
 -- name: GetUserByUsername :one
-SELECT id, username, password FROM users WHERE username = $1;
+SELECT id, username, password, totp_enabled, is_bot FROM users WHERE username = $1;
 
 -- name: GetUserByID :one
-SELECT id, username, avatar_url, avatar_color 
+SELECT id, username, avatar_url, avatar_color, display_name, username_changed_at
 FROM users 
 WHERE id = $1;
 
 -- name: SearchUsersByUsername :many
-SELECT id, username, avatar_url, avatar_color, created_at
+-- Fuzzy username search for adding friends. Exact and prefix matches rank
+-- first, then trigram similarity. Users already related to the searcher by a
+-- friendship row or a block in either direction are excluded, as are users
+-- who turned off discoverability.
+SELECT id, username, avatar_url, avatar_color, created_at, display_name
+FROM users u
+WHERE u.is_bot = FALSE
+  AND u.id <> sqlc.arg(searcher_id)
+  AND (u.username ILIKE sqlc.arg(pattern)::text OR u.username % sqlc.arg(query)::text)
+  AND NOT EXISTS (
+      SELECT 1 FROM friendships f
+      WHERE f.user_id = LEAST(sqlc.arg(searcher_id), u.id)
+        AND f.friend_id = GREATEST(sqlc.arg(searcher_id), u.id)
+  )
+  AND NOT EXISTS (
+      SELECT 1 FROM blocks b
+      WHERE (b.blocker_id = sqlc.arg(searcher_id) AND b.blocked_id = u.id)
+         OR (b.blocker_id = u.id AND b.blocked_id = sqlc.arg(searcher_id))
+  )
+  AND NOT EXISTS (
+      SELECT 1 FROM user_privacy_settings ps
+      WHERE ps.user_id = u.id AND ps.discoverable = FALSE
+  )
+ORDER BY
+    (lower(u.username) = lower(sqlc.arg(query)::text)) DESC,
+    (u.username ILIKE sqlc.arg(prefix_pattern)::text) DESC,
+    similarity(u.username, sqlc.arg(query)::text) DESC,
+    u.username ASC
+LIMIT sqlc.arg(page_limit) OFFSET sqlc.arg(page_offset);
+
+-- name: GetUserCredentialsByID :one
+SELECT id, username, password, totp_enabled
+FROM users
+WHERE id = $1;
+
+-- name: UpdateUserPassword :exec
+UPDATE users
+SET password = $2
+WHERE id = $1;
+
+-- name: GetUserTotp :one
+SELECT id, totp_secret, totp_enabled
 FROM users
-WHERE username ILIKE '%' || $1 || '%'
-ORDER BY username ASC
-LIMIT $2;
+WHERE id = $1;
+
+-- name: SetUserTotpSecret :exec
+UPDATE users
+SET totp_secret = $2, totp_enabled = FALSE
+WHERE id = $1;
+
+-- name: EnableUserTotp :exec
+UPDATE users
+SET totp_enabled = TRUE
+WHERE id = $1;
+
+-- name: DisableUserTotp :exec
+UPDATE users
+SET totp_secret = NULL, totp_enabled = FALSE
+WHERE id = $1;
+
+-- name: UsernameTaken :one
+-- Handles are compared case-insensitively. A handle someone else gave up is
+-- still taken while its reservation lasts; its previous owner may reclaim it.
+SELECT (
+    EXISTS (
+        SELECT 1 FROM users
+        WHERE lower(username) = lower(sqlc.arg(username)::text) AND id <> sqlc.arg(user_id)::int
+    ) OR EXISTS (
+        SELECT 1 FROM username_history h
+        WHERE lower(h.username) = lower(sqlc.arg(username)::text)
+          AND h.user_id <> sqlc.arg(user_id)::int
+          AND h.reserved_until > NOW()
+    )
+)::boolean AS taken;
+
+-- name: UpdateUserDisplayName :exec
+UPDATE users
+SET display_name = $2
+WHERE id = $1;
+
+-- name: UpdateUsername :exec
+UPDATE users
+SET username = $2, username_changed_at = NOW()
+WHERE id = $1;
+
+-- name: CreateUsernameHistory :exec
+INSERT INTO username_history (user_id, username, reserved_until)
+VALUES ($1, $2, $3);
+
+-- name: ListUsernameHistory :many
+SELECT username, changed_at, reserved_until
+FROM username_history
+WHERE user_id = $1
+ORDER BY changed_at DESC;
@@ -0,0 +1,8 @@
+-- name: GetUserIDByIdentity :one
+SELECT user_id
+FROM user_identities
+WHERE issuer = $1 AND subject = $2;
+
+-- name: CreateUserIdentity :exec
+INSERT INTO user_identities (user_id, issuer, subject, email)
+VALUES ($1, $2, $3, $4);
@@ -0,0 +1,26 @@
+-- name: CreateWebhook :one
+INSERT INTO channel_webhooks (channel_id, created_by, name, avatar_url, token_hash)
+VALUES ($1, $2, $3, $4, $5)
+RETURNING id, channel_id, created_by, name, avatar_url, created_at;
+
+-- name: ListWebhooksByChannel :many
+SELECT id, channel_id, created_by, name, avatar_url, created_at
+FROM channel_webhooks
+WHERE channel_id = $1
+ORDER BY created_at ASC;
+
+-- name: GetWebhook :one
+SELECT w.id, w.channel_id, w.created_by, w.name, w.avatar_url, w.token_hash, w.created_at, s.creator_id AS server_owner_id
+FROM channel_webhooks w
+JOIN channels c ON c.id = w.channel_id
+JOIN servers s ON s.id = c.server_id
+WHERE w.id = $1;
+
+-- name: DeleteWebhook :exec
+DELETE FROM channel_webhooks
+WHERE id = $1;
+
+-- name: CreateWebhookMessage :one
+INSERT INTO messages (channel_id, webhook_id, author_name, author_avatar_url, content, embeds)
+VALUES ($1, $2, $3, $4, $5, $6)
+RETURNING id, channel_id, webhook_id, content, created_at;
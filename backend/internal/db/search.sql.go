@@ -0,0 +1,258 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.30.0
+// source: search.sql
+
+package db
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const listBlockRelatedUserIDs = `-- name: ListBlockRelatedUserIDs :many
+SELECT (CASE WHEN blocker_id = $1 THEN blocked_id ELSE blocker_id END)::int AS user_id
+FROM blocks
+WHERE blocker_id = $1 OR blocked_id = $1
+`
+
+// Users the given user blocked or was blocked by.
+func (q *Queries) ListBlockRelatedUserIDs(ctx context.Context, userID int32) ([]int32, error) {
+	rows, err := q.db.Query(ctx, listBlockRelatedUserIDs, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []int32
+	for rows.Next() {
+		var user_id int32
+		if err := rows.Scan(&user_id); err != nil {
+			return nil, err
+		}
+		items = append(items, user_id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listMemberServerIDs = `-- name: ListMemberServerIDs :many
+SELECT server_id FROM server_members WHERE user_id = $1
+`
+
+func (q *Queries) ListMemberServerIDs(ctx context.Context, userID int32) ([]int32, error) {
+	rows, err := q.db.Query(ctx, listMemberServerIDs, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []int32
+	for rows.Next() {
+		var server_id int32
+		if err := rows.Scan(&server_id); err != nil {
+			return nil, err
+		}
+		items = append(items, server_id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listParticipantConversationIDs = `-- name: ListParticipantConversationIDs :many
+SELECT p.conversation_id
+FROM dm_conversation_participants p
+JOIN dm_conversations c ON c.id = p.conversation_id
+WHERE p.user_id = $1 AND NOT c.is_encrypted
+`
+
+// Encrypted conversations are left out: the server only holds ciphertext.
+func (q *Queries) ListParticipantConversationIDs(ctx context.Context, userID int32) ([]int32, error) {
+	rows, err := q.db.Query(ctx, listParticipantConversationIDs, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []int32
+	for rows.Next() {
+		var conversation_id int32
+		if err := rows.Scan(&conversation_id); err != nil {
+			return nil, err
+		}
+		items = append(items, conversation_id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const searchMessages = `-- name: SearchMessages :many
+WITH q AS (
+    SELECT websearch_to_tsquery('english', $2::text) AS query
+),
+hits AS (
+    SELECT
+        'channel'::text AS source,
+        m.id,
+        m.channel_id::int AS channel_id,
+        c.server_id::int AS server_id,
+        0::int AS conversation_id,
+        COALESCE(m.user_id, 0)::int AS user_id,
+        COALESCE(m.author_name, u.username, '')::text AS username,
+        COALESCE(m.author_avatar_url, u.avatar_url, '')::text AS avatar_url,
+        COALESCE(u.avatar_color, '')::text AS avatar_color,
+        (m.webhook_id IS NOT NULL OR COALESCE(u.is_bot, FALSE))::boolean AS is_bot,
+        m.content,
+        m.created_at,
+        ts_rank(m.search_vector, q.query) AS rank
+    FROM messages m
+    CROSS JOIN q
+    JOIN channels c ON c.id = m.channel_id
+    LEFT JOIN users u ON u.id = m.user_id
+    WHERE m.search_vector @@ q.query
+      AND c.server_id = ANY($3::int[])
+      AND ($4::int IS NULL OR m.channel_id = $4::int)
+      AND ($5::int IS NULL OR m.user_id = $5::int)
+      AND ($6::timestamptz IS NULL OR m.created_at >= $6::timestamptz)
+      AND ($7::timestamptz IS NULL OR m.created_at < $7::timestamptz)
+      AND (NOT $8::boolean OR m.embeds IS NOT NULL OR m.content ~* 'https?://')
+      AND ($9::text IS NULL OR m.content ~* $9::text)
+      AND (m.user_id IS NULL OR NOT m.user_id = ANY($10::int[]))
+    UNION ALL
+    SELECT
+        'dm'::text AS source,
+        d.id,
+        0::int AS channel_id,
+        0::int AS server_id,
+        d.conversation_id::int AS conversation_id,
+        d.user_id::int AS user_id,
+        u.username::text AS username,
+        COALESCE(u.avatar_url, '')::text AS avatar_url,
+        COALESCE(u.avatar_color, '')::text AS avatar_color,
+        u.is_bot::boolean AS is_bot,
+        d.content,
+        d.created_at,
+        ts_rank(d.search_vector, q.query) AS rank
+    FROM dm_messages d
+    CROSS JOIN q
+    JOIN users u ON u.id = d.user_id
+    WHERE d.search_vector @@ q.query
+      AND d.type = 'default'
+      AND d.conversation_id = ANY($11::int[])
+      AND ($5::int IS NULL OR d.user_id = $5::int)
+      AND ($6::timestamptz IS NULL OR d.created_at >= $6::timestamptz)
+      AND ($7::timestamptz IS NULL OR d.created_at < $7::timestamptz)
+      AND (NOT $8::boolean OR d.content ~* 'https?://')
+      AND ($9::text IS NULL OR d.content ~* $9::text)
+      AND NOT d.user_id = ANY($10::int[])
+),
+page AS (
+    SELECT source, id, channel_id, server_id, conversation_id, user_id, username, avatar_url, avatar_color, is_bot, content, created_at, rank FROM hits
+    ORDER BY rank DESC, created_at DESC, id DESC
+    LIMIT $13 OFFSET $12
+)
+SELECT
+    page.source,
+    page.id,
+    page.channel_id,
+    page.server_id,
+    page.conversation_id,
+    page.user_id,
+    page.username,
+    page.avatar_url,
+    page.avatar_color,
+    page.is_bot,
+    page.content,
+    page.created_at,
+    ts_headline('english', page.content, q.query, $1::text)::text AS snippet
+FROM page
+CROSS JOIN q
+ORDER BY page.rank DESC, page.created_at DESC, page.id DESC
+`
+
+type SearchMessagesParams struct {
+	HeadlineOptions string
+	Query           string
+	ServerIds       []int32
+	ChannelID       pgtype.Int4
+	AuthorID        pgtype.Int4
+	After           pgtype.Timestamptz
+	Before          pgtype.Timestamptz
+	HasAttachment   bool
+	MentionPattern  pgtype.Text
+	ExcludedUserIds []int32
+	ConversationIds []int32
+	PageOffset      int32
+	PageLimit       int32
+}
+
+type SearchMessagesRow struct {
+	Source         string
+	ID             int32
+	ChannelID      int32
+	ServerID       int32
+	ConversationID int32
+	UserID         int32
+	Username       string
+	AvatarUrl      string
+	AvatarColor    string
+	IsBot          bool
+	Content        string
+	CreatedAt      pgtype.Timestamptz
+	Snippet        string
+}
+
+// Ranks channel and DM messages together. Access is decided by the caller,
+// which passes the servers and conversations the searcher can read. The
+// headline is computed only for the returned page.
+func (q *Queries) SearchMessages(ctx context.Context, arg SearchMessagesParams) ([]SearchMessagesRow, error) {
+	rows, err := q.db.Query(ctx, searchMessages,
+		arg.HeadlineOptions,
+		arg.Query,
+		arg.ServerIds,
+		arg.ChannelID,
+		arg.AuthorID,
+		arg.After,
+		arg.Before,
+		arg.HasAttachment,
+		arg.MentionPattern,
+		arg.ExcludedUserIds,
+		arg.ConversationIds,
+		arg.PageOffset,
+		arg.PageLimit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []SearchMessagesRow
+	for rows.Next() {
+		var i SearchMessagesRow
+		if err := rows.Scan(
+			&i.Source,
+			&i.ID,
+			&i.ChannelID,
+			&i.ServerID,
+			&i.ConversationID,
+			&i.UserID,
+			&i.Username,
+			&i.AvatarUrl,
+			&i.AvatarColor,
+			&i.IsBot,
+			&i.Content,
+			&i.CreatedAt,
+			&i.Snippet,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
@@ -36,6 +36,36 @@ func (q *Queries) CreateServer(ctx context.Context, arg CreateServerParams) (Ser
 	return i, err
 }
 
+const deleteServer = `-- name: DeleteServer :exec
+DELETE FROM servers
+WHERE id = $1
+`
+
+func (q *Queries) DeleteServer(ctx context.Context, id int32) error {
+	_, err := q.db.Exec(ctx, deleteServer, id)
+	return err
+}
+
+const deleteServerChannels = `-- name: DeleteServerChannels :exec
+DELETE FROM channels
+WHERE server_id = $1
+`
+
+func (q *Queries) DeleteServerChannels(ctx context.Context, serverID int32) error {
+	_, err := q.db.Exec(ctx, deleteServerChannels, serverID)
+	return err
+}
+
+const deleteServerMembers = `-- name: DeleteServerMembers :exec
+DELETE FROM server_members
+WHERE server_id = $1
+`
+
+func (q *Queries) DeleteServerMembers(ctx context.Context, serverID int32) error {
+	_, err := q.db.Exec(ctx, deleteServerMembers, serverID)
+	return err
+}
+
 const getServer = `-- name: GetServer :one
 SELECT id, name, creator_id, is_public, created_at
 FROM servers
@@ -55,6 +85,36 @@ func (q *Queries) GetServer(ctx context.Context, id int32) (Server, error) {
 	return i, err
 }
 
+const getServerMemberProfile = `-- name: GetServerMemberProfile :one
+SELECT server_id, user_id, nickname, avatar_url
+FROM server_members
+WHERE server_id = $1 AND user_id = $2
+`
+
+type GetServerMemberProfileParams struct {
+	ServerID int32
+	UserID   int32
+}
+
+type GetServerMemberProfileRow struct {
+	ServerID  int32
+	UserID    int32
+	Nickname  pgtype.Text
+	AvatarUrl pgtype.Text
+}
+
+func (q *Queries) GetServerMemberProfile(ctx context.Context, arg GetServerMemberProfileParams) (GetServerMemberProfileRow, error) {
+	row := q.db.QueryRow(ctx, getServerMemberProfile, arg.ServerID, arg.UserID)
+	var i GetServerMemberProfileRow
+	err := row.Scan(
+		&i.ServerID,
+		&i.UserID,
+		&i.Nickname,
+		&i.AvatarUrl,
+	)
+	return i, err
+}
+
 const isServerMember = `-- name: IsServerMember :one
 SELECT EXISTS (
     SELECT 1
@@ -91,6 +151,135 @@ func (q *Queries) JoinServer(ctx context.Context, arg JoinServerParams) error {
 	return err
 }
 
+const leaveServer = `-- name: LeaveServer :execrows
+DELETE FROM server_members
+WHERE server_id = $1 AND user_id = $2
+`
+
+type LeaveServerParams struct {
+	ServerID int32
+	UserID   int32
+}
+
+func (q *Queries) LeaveServer(ctx context.Context, arg LeaveServerParams) (int64, error) {
+	result, err := q.db.Exec(ctx, leaveServer, arg.ServerID, arg.UserID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const listDiscoverableServers = `-- name: ListDiscoverableServers :many
+SELECT
+    s.id,
+    s.name,
+    s.creator_id,
+    s.is_public,
+    s.created_at,
+    members.member_count::bigint AS member_count,
+    activity.recent_message_count::bigint AS recent_message_count,
+    latest.last_message_at::timestamptz AS last_message_at
+FROM servers s
+CROSS JOIN LATERAL (
+    SELECT COUNT(*) AS member_count
+    FROM server_members sm
+    WHERE sm.server_id = s.id
+) members
+CROSS JOIN LATERAL (
+    SELECT COUNT(*) AS recent_message_count
+    FROM channels c
+    JOIN messages m ON m.channel_id = c.id
+    WHERE c.server_id = s.id
+      AND m.created_at > NOW() - INTERVAL '7 days'
+) activity
+CROSS JOIN LATERAL (
+    SELECT MAX(newest.created_at) AS last_message_at
+    FROM channels c
+    CROSS JOIN LATERAL (
+        SELECT m.created_at
+        FROM messages m
+        WHERE m.channel_id = c.id
+        ORDER BY m.created_at DESC
+        LIMIT 1
+    ) newest
+    WHERE c.server_id = s.id
+) latest
+WHERE s.is_public = TRUE
+  AND NOT EXISTS (
+      SELECT 1 FROM server_members sm
+      WHERE sm.server_id = s.id AND sm.user_id = $1
+  )
+  AND ($2::text = '' OR s.name ILIKE $3::text OR s.name % $2::text)
+ORDER BY
+    CASE WHEN $2::text = '' THEN 0
+         ELSE similarity(s.name, $2::text) + (s.name ILIKE $4::text)::int
+    END DESC,
+    ln(1 + members.member_count) + ln(1 + activity.recent_message_count) DESC,
+    s.id ASC
+LIMIT $6 OFFSET $5
+`
+
+type ListDiscoverableServersParams struct {
+	UserID        int32
+	Query         string
+	Pattern       string
+	PrefixPattern string
+	PageOffset    int32
+	PageLimit     int32
+}
+
+type ListDiscoverableServersRow struct {
+	ID                 int32
+	Name               string
+	CreatorID          pgtype.Int4
+	IsPublic           pgtype.Bool
+	CreatedAt          pgtype.Timestamp
+	MemberCount        int64
+	RecentMessageCount int64
+	LastMessageAt      pgtype.Timestamptz
+}
+
+// Public servers the user is not in. Without a query they are ranked by
+// popularity: members plus messages in the last week, both log-scaled. With a
+// query, name relevance comes first.
+// Both activity lookups stay on idx_messages_channel_id_created_at, so their
+// cost follows last week's messages rather than the whole history.
+func (q *Queries) ListDiscoverableServers(ctx context.Context, arg ListDiscoverableServersParams) ([]ListDiscoverableServersRow, error) {
+	rows, err := q.db.Query(ctx, listDiscoverableServers,
+		arg.UserID,
+		arg.Query,
+		arg.Pattern,
+		arg.PrefixPattern,
+		arg.PageOffset,
+		arg.PageLimit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListDiscoverableServersRow
+	for rows.Next() {
+		var i ListDiscoverableServersRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.CreatorID,
+			&i.IsPublic,
+			&i.CreatedAt,
+			&i.MemberCount,
+			&i.RecentMessageCount,
+			&i.LastMessageAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const listUserServers = `-- name: ListUserServers :many
 SELECT s.id, 
     s.name, 
@@ -128,3 +317,62 @@ func (q *Queries) ListUserServers(ctx context.Context, userID int32) ([]Server,
 	}
 	return items, nil
 }
+
+const updateServerMemberProfile = `-- name: UpdateServerMemberProfile :one
+UPDATE server_members
+SET nickname = $1, avatar_url = $2
+WHERE server_id = $3 AND user_id = $4
+RETURNING server_id, user_id, nickname, avatar_url
+`
+
+type UpdateServerMemberProfileParams struct {
+	Nickname  pgtype.Text
+	AvatarUrl pgtype.Text
+	ServerID  int32
+	UserID    int32
+}
+
+type UpdateServerMemberProfileRow struct {
+	ServerID  int32
+	UserID    int32
+	Nickname  pgtype.Text
+	AvatarUrl pgtype.Text
+}
+
+func (q *Queries) UpdateServerMemberProfile(ctx context.Context, arg UpdateServerMemberProfileParams) (UpdateServerMemberProfileRow, error) {
+	row := q.db.QueryRow(ctx, updateServerMemberProfile,
+		arg.Nickname,
+		arg.AvatarUrl,
+		arg.ServerID,
+		arg.UserID,
+	)
+	var i UpdateServerMemberProfileRow
+	err := row.Scan(
+		&i.ServerID,
+		&i.UserID,
+		&i.Nickname,
+		&i.AvatarUrl,
+	)
+	return i, err
+}
+
+const usersShareServer = `-- name: UsersShareServer :one
+SELECT EXISTS (
+    SELECT 1
+    FROM server_members a
+    JOIN server_members b ON b.server_id = a.server_id
+    WHERE a.user_id = $1 AND b.user_id = $2
+)
+`
+
+type UsersShareServerParams struct {
+	UserID   int32
+	UserID_2 int32
+}
+
+func (q *Queries) UsersShareServer(ctx context.Context, arg UsersShareServerParams) (bool, error) {
+	row := q.db.QueryRow(ctx, usersShareServer, arg.UserID, arg.UserID_2)
+	var exists bool
+	err := row.Scan(&exists)
+	return exists, err
+}
@@ -12,15 +12,16 @@ import (
 )
 
 const createUser = `-- name: CreateUser :one
-INSERT INTO users (username, password, avatar_color) 
-VALUES ($1, $2, $3)
-RETURNING  id, username, avatar_url, avatar_color
+INSERT INTO users (username, password, avatar_color, display_name)
+VALUES ($1, $2, $3, $4)
+RETURNING  id, username, avatar_url, avatar_color, display_name
 `
 
 type CreateUserParams struct {
 	Username    string
 	Password    string
 	AvatarColor pgtype.Text
+	DisplayName pgtype.Text
 }
 
 type CreateUserRow struct {
@@ -28,31 +29,78 @@ type CreateUserRow struct {
 	Username    string
 	AvatarUrl   pgtype.Text
 	AvatarColor pgtype.Text
+	DisplayName pgtype.Text
 }
 
 func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) (CreateUserRow, error) {
-	row := q.db.QueryRow(ctx, createUser, arg.Username, arg.Password, arg.AvatarColor)
+	row := q.db.QueryRow(ctx, createUser,
+		arg.Username,
+		arg.Password,
+		arg.AvatarColor,
+		arg.DisplayName,
+	)
 	var i CreateUserRow
 	err := row.Scan(
 		&i.ID,
 		&i.Username,
 		&i.AvatarUrl,
 		&i.AvatarColor,
+		&i.DisplayName,
 	)
 	return i, err
 }
 
+const createUsernameHistory = `-- name: CreateUsernameHistory :exec
+INSERT INTO username_history (user_id, username, reserved_until)
+VALUES ($1, $2, $3)
+`
+
+type CreateUsernameHistoryParams struct {
+	UserID        int32
+	Username      string
+	ReservedUntil pgtype.Timestamptz
+}
+
+func (q *Queries) CreateUsernameHistory(ctx context.Context, arg CreateUsernameHistoryParams) error {
+	_, err := q.db.Exec(ctx, createUsernameHistory, arg.UserID, arg.Username, arg.ReservedUntil)
+	return err
+}
+
+const disableUserTotp = `-- name: DisableUserTotp :exec
+UPDATE users
+SET totp_secret = NULL, totp_enabled = FALSE
+WHERE id = $1
+`
+
+func (q *Queries) DisableUserTotp(ctx context.Context, id int32) error {
+	_, err := q.db.Exec(ctx, disableUserTotp, id)
+	return err
+}
+
+const enableUserTotp = `-- name: EnableUserTotp :exec
+UPDATE users
+SET totp_enabled = TRUE
+WHERE id = $1
+`
+
+func (q *Queries) EnableUserTotp(ctx context.Context, id int32) error {
+	_, err := q.db.Exec(ctx, enableUserTotp, id)
+	return err
+}
+
 const getUserByID = `-- name: GetUserByID :one
-SELECT id, username, avatar_url, avatar_color 
+SELECT id, username, avatar_url, avatar_color, display_name, username_changed_at
 FROM users 
 WHERE id = $1
 `
 
 type GetUserByIDRow struct {
-	ID          int32
-	Username    string
-	AvatarUrl   pgtype.Text
-	AvatarColor pgtype.Text
+	ID                int32
+	Username          string
+	AvatarUrl         pgtype.Text
+	AvatarColor       pgtype.Text
+	DisplayName       pgtype.Text
+	UsernameChangedAt pgtype.Timestamptz
 }
 
 func (q *Queries) GetUserByID(ctx context.Context, id int32) (GetUserByIDRow, error) {
@@ -63,38 +111,149 @@ func (q *Queries) GetUserByID(ctx context.Context, id int32) (GetUserByIDRow, er
 		&i.Username,
 		&i.AvatarUrl,
 		&i.AvatarColor,
+		&i.DisplayName,
+		&i.UsernameChangedAt,
 	)
 	return i, err
 }
 
 const getUserByUsername = `-- name: GetUserByUsername :one
-SELECT id, username, password FROM users WHERE username = $1
+SELECT id, username, password, totp_enabled, is_bot FROM users WHERE username = $1
 `
 
 type GetUserByUsernameRow struct {
-	ID       int32
-	Username string
-	Password string
+	ID          int32
+	Username    string
+	Password    string
+	TotpEnabled bool
+	IsBot       bool
 }
 
 func (q *Queries) GetUserByUsername(ctx context.Context, username string) (GetUserByUsernameRow, error) {
 	row := q.db.QueryRow(ctx, getUserByUsername, username)
 	var i GetUserByUsernameRow
-	err := row.Scan(&i.ID, &i.Username, &i.Password)
+	err := row.Scan(
+		&i.ID,
+		&i.Username,
+		&i.Password,
+		&i.TotpEnabled,
+		&i.IsBot,
+	)
 	return i, err
 }
 
-const searchUsersByUsername = `-- name: SearchUsersByUsername :many
-SELECT id, username, avatar_url, avatar_color, created_at
+const getUserCredentialsByID = `-- name: GetUserCredentialsByID :one
+SELECT id, username, password, totp_enabled
+FROM users
+WHERE id = $1
+`
+
+type GetUserCredentialsByIDRow struct {
+	ID          int32
+	Username    string
+	Password    string
+	TotpEnabled bool
+}
+
+func (q *Queries) GetUserCredentialsByID(ctx context.Context, id int32) (GetUserCredentialsByIDRow, error) {
+	row := q.db.QueryRow(ctx, getUserCredentialsByID, id)
+	var i GetUserCredentialsByIDRow
+	err := row.Scan(
+		&i.ID,
+		&i.Username,
+		&i.Password,
+		&i.TotpEnabled,
+	)
+	return i, err
+}
+
+const getUserTotp = `-- name: GetUserTotp :one
+SELECT id, totp_secret, totp_enabled
 FROM users
-WHERE username ILIKE '%' || $1 || '%'
-ORDER BY username ASC
-LIMIT $2
+WHERE id = $1
+`
+
+type GetUserTotpRow struct {
+	ID          int32
+	TotpSecret  pgtype.Text
+	TotpEnabled bool
+}
+
+func (q *Queries) GetUserTotp(ctx context.Context, id int32) (GetUserTotpRow, error) {
+	row := q.db.QueryRow(ctx, getUserTotp, id)
+	var i GetUserTotpRow
+	err := row.Scan(&i.ID, &i.TotpSecret, &i.TotpEnabled)
+	return i, err
+}
+
+const listUsernameHistory = `-- name: ListUsernameHistory :many
+SELECT username, changed_at, reserved_until
+FROM username_history
+WHERE user_id = $1
+ORDER BY changed_at DESC
+`
+
+type ListUsernameHistoryRow struct {
+	Username      string
+	ChangedAt     pgtype.Timestamptz
+	ReservedUntil pgtype.Timestamptz
+}
+
+func (q *Queries) ListUsernameHistory(ctx context.Context, userID int32) ([]ListUsernameHistoryRow, error) {
+	rows, err := q.db.Query(ctx, listUsernameHistory, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListUsernameHistoryRow
+	for rows.Next() {
+		var i ListUsernameHistoryRow
+		if err := rows.Scan(&i.Username, &i.ChangedAt, &i.ReservedUntil); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const searchUsersByUsername = `-- name: SearchUsersByUsername :many
+SELECT id, username, avatar_url, avatar_color, created_at, display_name
+FROM users u
+WHERE u.is_bot = FALSE
+  AND u.id <> $1
+  AND (u.username ILIKE $2::text OR u.username % $3::text)
+  AND NOT EXISTS (
+      SELECT 1 FROM friendships f
+      WHERE f.user_id = LEAST($1, u.id)
+        AND f.friend_id = GREATEST($1, u.id)
+  )
+  AND NOT EXISTS (
+      SELECT 1 FROM blocks b
+      WHERE (b.blocker_id = $1 AND b.blocked_id = u.id)
+         OR (b.blocker_id = u.id AND b.blocked_id = $1)
+  )
+  AND NOT EXISTS (
+      SELECT 1 FROM user_privacy_settings ps
+      WHERE ps.user_id = u.id AND ps.discoverable = FALSE
+  )
+ORDER BY
+    (lower(u.username) = lower($3::text)) DESC,
+    (u.username ILIKE $4::text) DESC,
+    similarity(u.username, $3::text) DESC,
+    u.username ASC
+LIMIT $6 OFFSET $5
 `
 
 type SearchUsersByUsernameParams struct {
-	Column1 pgtype.Text
-	Limit   int32
+	SearcherID    int32
+	Pattern       string
+	Query         string
+	PrefixPattern string
+	PageOffset    int32
+	PageLimit     int32
 }
 
 type SearchUsersByUsernameRow struct {
@@ -103,10 +262,22 @@ type SearchUsersByUsernameRow struct {
 	AvatarUrl   pgtype.Text
 	AvatarColor pgtype.Text
 	CreatedAt   pgtype.Timestamptz
+	DisplayName pgtype.Text
 }
 
+// Fuzzy username search for adding friends. Exact and prefix matches rank
+// first, then trigram similarity. Users already related to the searcher by a
+// friendship row or a block in either direction are excluded, as are users
+// who turned off discoverability.
 func (q *Queries) SearchUsersByUsername(ctx context.Context, arg SearchUsersByUsernameParams) ([]SearchUsersByUsernameRow, error) {
-	rows, err := q.db.Query(ctx, searchUsersByUsername, arg.Column1, arg.Limit)
+	rows, err := q.db.Query(ctx, searchUsersByUsername,
+		arg.SearcherID,
+		arg.Pattern,
+		arg.Query,
+		arg.PrefixPattern,
+		arg.PageOffset,
+		arg.PageLimit,
+	)
 	if err != nil {
 		return nil, err
 	}
@@ -120,6 +291,7 @@ func (q *Queries) SearchUsersByUsername(ctx context.Context, arg SearchUsersByUs
 			&i.AvatarUrl,
 			&i.AvatarColor,
 			&i.CreatedAt,
+			&i.DisplayName,
 		); err != nil {
 			return nil, err
 		}
@@ -130,3 +302,95 @@ func (q *Queries) SearchUsersByUsername(ctx context.Context, arg SearchUsersByUs
 	}
 	return items, nil
 }
+
+const setUserTotpSecret = `-- name: SetUserTotpSecret :exec
+UPDATE users
+SET totp_secret = $2, totp_enabled = FALSE
+WHERE id = $1
+`
+
+type SetUserTotpSecretParams struct {
+	ID         int32
+	TotpSecret pgtype.Text
+}
+
+func (q *Queries) SetUserTotpSecret(ctx context.Context, arg SetUserTotpSecretParams) error {
+	_, err := q.db.Exec(ctx, setUserTotpSecret, arg.ID, arg.TotpSecret)
+	return err
+}
+
+const updateUserDisplayName = `-- name: UpdateUserDisplayName :exec
+UPDATE users
+SET display_name = $2
+WHERE id = $1
+`
+
+type UpdateUserDisplayNameParams struct {
+	ID          int32
+	DisplayName pgtype.Text
+}
+
+func (q *Queries) UpdateUserDisplayName(ctx context.Context, arg UpdateUserDisplayNameParams) error {
+	_, err := q.db.Exec(ctx, updateUserDisplayName, arg.ID, arg.DisplayName)
+	return err
+}
+
+const updateUserPassword = `-- name: UpdateUserPassword :exec
+UPDATE users
+SET password = $2
+WHERE id = $1
+`
+
+type UpdateUserPasswordParams struct {
+	ID       int32
+	Password string
+}
+
+func (q *Queries) UpdateUserPassword(ctx context.Context, arg UpdateUserPasswordParams) error {
+	_, err := q.db.Exec(ctx, updateUserPassword, arg.ID, arg.Password)
+	return err
+}
+
+const updateUsername = `-- name: UpdateUsername :exec
+UPDATE users
+SET username = $2, username_changed_at = NOW()
+WHERE id = $1
+`
+
+type UpdateUsernameParams struct {
+	ID       int32
+	Username string
+}
+
+func (q *Queries) UpdateUsername(ctx context.Context, arg UpdateUsernameParams) error {
+	_, err := q.db.Exec(ctx, updateUsername, arg.ID, arg.Username)
+	return err
+}
+
+const usernameTaken = `-- name: UsernameTaken :one
+SELECT (
+    EXISTS (
+        SELECT 1 FROM users
+        WHERE lower(username) = lower($1::text) AND id <> $2::int
+    ) OR EXISTS (
+        SELECT 1 FROM username_history h
+        WHERE lower(h.username) = lower($1::text)
+          AND h.user_id <> $2::int
+          AND h.reserved_until > NOW()
+    )
+)::boolean AS taken
+`
+
+type UsernameTakenParams struct {
+	Username string
+	UserID   int32
+}
+
+// Handles are compared case-insensitively. A handle someone else gave up is
+// still taken while its reservation lasts; its previous owner may reclaim it.
+func (q *Queries) UsernameTaken(ctx context.Context, arg UsernameTakenParams) (bool, error) {
+	row := q.db.QueryRow(ctx, usernameTaken, arg.Username, arg.UserID)
+	var taken bool
+	err := row.Scan(&taken)
+	return taken, err
+}
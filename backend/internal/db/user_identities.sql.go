@@ -0,0 +1,52 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.30.0
+// source: user_identities.sql
+
+package db
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const createUserIdentity = `-- name: CreateUserIdentity :exec
+INSERT INTO user_identities (user_id, issuer, subject, email)
+VALUES ($1, $2, $3, $4)
+`
+
+type CreateUserIdentityParams struct {
+	UserID  int32
+	Issuer  string
+	Subject string
+	Email   pgtype.Text
+}
+
+func (q *Queries) CreateUserIdentity(ctx context.Context, arg CreateUserIdentityParams) error {
+	_, err := q.db.Exec(ctx, createUserIdentity,
+		arg.UserID,
+		arg.Issuer,
+		arg.Subject,
+		arg.Email,
+	)
+	return err
+}
+
+const getUserIDByIdentity = `-- name: GetUserIDByIdentity :one
+SELECT user_id
+FROM user_identities
+WHERE issuer = $1 AND subject = $2
+`
+
+type GetUserIDByIdentityParams struct {
+	Issuer  string
+	Subject string
+}
+
+func (q *Queries) GetUserIDByIdentity(ctx context.Context, arg GetUserIDByIdentityParams) (int32, error) {
+	row := q.db.QueryRow(ctx, getUserIDByIdentity, arg.Issuer, arg.Subject)
+	var user_id int32
+	err := row.Scan(&user_id)
+	return user_id, err
+}
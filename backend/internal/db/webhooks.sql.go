@@ -0,0 +1,186 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.30.0
+// source: webhooks.sql
+
+package db
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const createWebhook = `-- name: CreateWebhook :one
+INSERT INTO channel_webhooks (channel_id, created_by, name, avatar_url, token_hash)
+VALUES ($1, $2, $3, $4, $5)
+RETURNING id, channel_id, created_by, name, avatar_url, created_at
+`
+
+type CreateWebhookParams struct {
+	ChannelID int32
+	CreatedBy pgtype.Int4
+	Name      string
+	AvatarUrl pgtype.Text
+	TokenHash string
+}
+
+type CreateWebhookRow struct {
+	ID        int32
+	ChannelID int32
+	CreatedBy pgtype.Int4
+	Name      string
+	AvatarUrl pgtype.Text
+	CreatedAt pgtype.Timestamptz
+}
+
+func (q *Queries) CreateWebhook(ctx context.Context, arg CreateWebhookParams) (CreateWebhookRow, error) {
+	row := q.db.QueryRow(ctx, createWebhook,
+		arg.ChannelID,
+		arg.CreatedBy,
+		arg.Name,
+		arg.AvatarUrl,
+		arg.TokenHash,
+	)
+	var i CreateWebhookRow
+	err := row.Scan(
+		&i.ID,
+		&i.ChannelID,
+		&i.CreatedBy,
+		&i.Name,
+		&i.AvatarUrl,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const createWebhookMessage = `-- name: CreateWebhookMessage :one
+INSERT INTO messages (channel_id, webhook_id, author_name, author_avatar_url, content, embeds)
+VALUES ($1, $2, $3, $4, $5, $6)
+RETURNING id, channel_id, webhook_id, content, created_at
+`
+
+type CreateWebhookMessageParams struct {
+	ChannelID       int32
+	WebhookID       pgtype.Int4
+	AuthorName      pgtype.Text
+	AuthorAvatarUrl pgtype.Text
+	Content         string
+	Embeds          []byte
+}
+
+type CreateWebhookMessageRow struct {
+	ID        int32
+	ChannelID int32
+	WebhookID pgtype.Int4
+	Content   string
+	CreatedAt pgtype.Timestamptz
+}
+
+func (q *Queries) CreateWebhookMessage(ctx context.Context, arg CreateWebhookMessageParams) (CreateWebhookMessageRow, error) {
+	row := q.db.QueryRow(ctx, createWebhookMessage,
+		arg.ChannelID,
+		arg.WebhookID,
+		arg.AuthorName,
+		arg.AuthorAvatarUrl,
+		arg.Content,
+		arg.Embeds,
+	)
+	var i CreateWebhookMessageRow
+	err := row.Scan(
+		&i.ID,
+		&i.ChannelID,
+		&i.WebhookID,
+		&i.Content,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const deleteWebhook = `-- name: DeleteWebhook :exec
+DELETE FROM channel_webhooks
+WHERE id = $1
+`
+
+func (q *Queries) DeleteWebhook(ctx context.Context, id int32) error {
+	_, err := q.db.Exec(ctx, deleteWebhook, id)
+	return err
+}
+
+const getWebhook = `-- name: GetWebhook :one
+SELECT w.id, w.channel_id, w.created_by, w.name, w.avatar_url, w.token_hash, w.created_at, s.creator_id AS server_owner_id
+FROM channel_webhooks w
+JOIN channels c ON c.id = w.channel_id
+JOIN servers s ON s.id = c.server_id
+WHERE w.id = $1
+`
+
+type GetWebhookRow struct {
+	ID            int32
+	ChannelID     int32
+	CreatedBy     pgtype.Int4
+	Name          string
+	AvatarUrl     pgtype.Text
+	TokenHash     string
+	CreatedAt     pgtype.Timestamptz
+	ServerOwnerID pgtype.Int4
+}
+
+func (q *Queries) GetWebhook(ctx context.Context, id int32) (GetWebhookRow, error) {
+	row := q.db.QueryRow(ctx, getWebhook, id)
+	var i GetWebhookRow
+	err := row.Scan(
+		&i.ID,
+		&i.ChannelID,
+		&i.CreatedBy,
+		&i.Name,
+		&i.AvatarUrl,
+		&i.TokenHash,
+		&i.CreatedAt,
+		&i.ServerOwnerID,
+	)
+	return i, err
+}
+
+const listWebhooksByChannel = `-- name: ListWebhooksByChannel :many
+SELECT id, channel_id, created_by, name, avatar_url, created_at
+FROM channel_webhooks
+WHERE channel_id = $1
+ORDER BY created_at ASC
+`
+
+type ListWebhooksByChannelRow struct {
+	ID        int32
+	ChannelID int32
+	CreatedBy pgtype.Int4
+	Name      string
+	AvatarUrl pgtype.Text
+	CreatedAt pgtype.Timestamptz
+}
+
+func (q *Queries) ListWebhooksByChannel(ctx context.Context, channelID int32) ([]ListWebhooksByChannelRow, error) {
+	rows, err := q.db.Query(ctx, listWebhooksByChannel, channelID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListWebhooksByChannelRow
+	for rows.Next() {
+		var i ListWebhooksByChannelRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.ChannelID,
+			&i.CreatedBy,
+			&i.Name,
+			&i.AvatarUrl,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
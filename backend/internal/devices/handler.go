@@ -0,0 +1,115 @@
+package devices
+
+import (
+	"strconv"
+
+	"github.com/andrelcunha/Concord/backend/internal/middleware"
+	"github.com/andrelcunha/Concord/backend/pkg/dtos"
+	"github.com/gofiber/fiber/v2"
+)
+
+type Handler struct {
+	service *Service
+}
+
+func NewHandler(service *Service) *Handler {
+	return &Handler{service: service}
+}
+
+func (h *Handler) RegisterDevice(c *fiber.Ctx) error {
+	var req RegisterDeviceRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request"})
+	}
+
+	userID := c.Locals("userID").(int32)
+	device, err := h.service.RegisterDevice(c.Context(), userID, req)
+	if err != nil {
+		return deviceErrorResponse(c, err)
+	}
+	return c.Status(fiber.StatusCreated).JSON(device)
+}
+
+func (h *Handler) ListDevices(c *fiber.Ctx) error {
+	userID := c.Locals("userID").(int32)
+	devices, err := h.service.ListDevices(c.Context(), userID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(fiber.Map{"devices": devices})
+}
+
+func (h *Handler) DeleteDevice(c *fiber.Ctx) error {
+	deviceID, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid device ID"})
+	}
+
+	userID := c.Locals("userID").(int32)
+	if err := h.service.DeleteDevice(c.Context(), userID, int32(deviceID)); err != nil {
+		return deviceErrorResponse(c, err)
+	}
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+func (h *Handler) AddPrekeys(c *fiber.Ctx) error {
+	deviceID, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid device ID"})
+	}
+	var req struct {
+		OneTimePrekeys []dtos.OneTimePrekeyDto `json:"one_time_prekeys"`
+	}
+	if err := c.BodyParser(&req); err != nil || len(req.OneTimePrekeys) == 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request"})
+	}
+
+	userID := c.Locals("userID").(int32)
+	device, err := h.service.AddPrekeys(c.Context(), userID, int32(deviceID), req.OneTimePrekeys)
+	if err != nil {
+		return deviceErrorResponse(c, err)
+	}
+	return c.JSON(device)
+}
+
+func (h *Handler) GetKeyBundles(c *fiber.Ctx) error {
+	targetUserID, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid user ID"})
+	}
+
+	userID := c.Locals("userID").(int32)
+	bundles, err := h.service.GetKeyBundles(c.Context(), userID, int32(targetUserID))
+	if err != nil {
+		return deviceErrorResponse(c, err)
+	}
+	return c.JSON(fiber.Map{"devices": bundles})
+}
+
+func deviceErrorResponse(c *fiber.Ctx, err error) error {
+	switch err {
+	case ErrInvalidDeviceName, ErrInvalidKey, ErrTooManyPrekeys:
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	case ErrTooManyDevices:
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": err.Error()})
+	case ErrDeviceNotFound, ErrKeysUnavailable:
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
+	default:
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+}
+
+// RegisterDeviceRoutes mounts the device key registry. Bots do not take part
+// in encrypted conversations, so every route is interactive only.
+func RegisterDeviceRoutes(api fiber.Router, service *Service) {
+	handler := NewHandler(service)
+	interactive := middleware.RequireInteractive()
+
+	devices := api.Group("/devices", interactive)
+	devices.Get("/", handler.ListDevices)
+	devices.Post("/", handler.RegisterDevice)
+	devices.Delete("/:id", handler.DeleteDevice)
+	devices.Post("/:id/prekeys", handler.AddPrekeys)
+
+	api.Get("/users/:id/devices", interactive, handler.GetKeyBundles)
+}
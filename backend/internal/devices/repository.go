@@ -0,0 +1,100 @@
+package devices
+
+import (
+	"context"
+
+	"github.com/andrelcunha/Concord/backend/internal/db"
+	"github.com/andrelcunha/Concord/backend/pkg/dtos"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type Repository interface {
+	CreateDevice(ctx context.Context, params db.CreateUserDeviceParams, prekeys []dtos.OneTimePrekeyDto) (db.UserDevice, error)
+	ListDevices(ctx context.Context, userID int32) ([]db.UserDevice, error)
+	GetDevice(ctx context.Context, deviceID, userID int32) (db.UserDevice, error)
+	DeleteDevice(ctx context.Context, deviceID, userID int32) (bool, error)
+	AddPrekeys(ctx context.Context, deviceID int32, prekeys []dtos.OneTimePrekeyDto) error
+	CountPrekeys(ctx context.Context, deviceID int32) (int64, error)
+	ClaimPrekey(ctx context.Context, deviceID int32) (db.ClaimOneTimePrekeyRow, error)
+}
+
+type repository struct {
+	pool *pgxpool.Pool
+	db   *db.Queries
+}
+
+func NewRepository(dbPool *pgxpool.Pool) Repository {
+	return &repository{
+		pool: dbPool,
+		db:   db.New(dbPool),
+	}
+}
+
+// CreateDevice stores the device and its first batch of one-time prekeys in
+// one transaction.
+func (r *repository) CreateDevice(ctx context.Context, params db.CreateUserDeviceParams, prekeys []dtos.OneTimePrekeyDto) (db.UserDevice, error) {
+	tx, err := r.pool.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return db.UserDevice{}, err
+	}
+	defer tx.Rollback(ctx)
+
+	queries := db.New(tx)
+	device, err := queries.CreateUserDevice(ctx, params)
+	if err != nil {
+		return db.UserDevice{}, err
+	}
+	if err := addPrekeys(ctx, queries, device.ID, prekeys); err != nil {
+		return db.UserDevice{}, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return db.UserDevice{}, err
+	}
+	return device, nil
+}
+
+func (r *repository) ListDevices(ctx context.Context, userID int32) ([]db.UserDevice, error) {
+	return r.db.ListUserDevices(ctx, userID)
+}
+
+func (r *repository) GetDevice(ctx context.Context, deviceID, userID int32) (db.UserDevice, error) {
+	return r.db.GetUserDevice(ctx, db.GetUserDeviceParams{
+		ID:     deviceID,
+		UserID: userID,
+	})
+}
+
+func (r *repository) DeleteDevice(ctx context.Context, deviceID, userID int32) (bool, error) {
+	rows, err := r.db.DeleteUserDevice(ctx, db.DeleteUserDeviceParams{
+		ID:     deviceID,
+		UserID: userID,
+	})
+	return rows > 0, err
+}
+
+func (r *repository) AddPrekeys(ctx context.Context, deviceID int32, prekeys []dtos.OneTimePrekeyDto) error {
+	return addPrekeys(ctx, r.db, deviceID, prekeys)
+}
+
+func (r *repository) CountPrekeys(ctx context.Context, deviceID int32) (int64, error) {
+	return r.db.CountOneTimePrekeys(ctx, deviceID)
+}
+
+func (r *repository) ClaimPrekey(ctx context.Context, deviceID int32) (db.ClaimOneTimePrekeyRow, error) {
+	return r.db.ClaimOneTimePrekey(ctx, deviceID)
+}
+
+func addPrekeys(ctx context.Context, queries *db.Queries, deviceID int32, prekeys []dtos.OneTimePrekeyDto) error {
+	for _, prekey := range prekeys {
+		if err := queries.AddOneTimePrekey(ctx, db.AddOneTimePrekeyParams{
+			DeviceID:  deviceID,
+			KeyID:     prekey.KeyID,
+			PublicKey: prekey.PublicKey,
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,215 @@
+package devices
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/andrelcunha/Concord/backend/internal/blocks"
+	"github.com/andrelcunha/Concord/backend/internal/db"
+	"github.com/andrelcunha/Concord/backend/pkg/dtos"
+	"github.com/jackc/pgx/v5"
+)
+
+const (
+	MaxDevicesPerUser   = 10
+	MaxPrekeysPerUpload = 100
+	maxDeviceNameLength = 100
+	maxKeyLength        = 1024
+)
+
+var (
+	ErrInvalidDeviceName = errors.New("device name must be between 1 and 100 characters")
+	ErrInvalidKey        = errors.New("keys must be base64-encoded and at most 1024 characters")
+	ErrTooManyDevices    = fmt.Errorf("a user can register at most %d devices", MaxDevicesPerUser)
+	ErrTooManyPrekeys    = fmt.Errorf("at most %d one-time prekeys can be uploaded at once", MaxPrekeysPerUpload)
+	ErrDeviceNotFound    = errors.New("device not found")
+	ErrKeysUnavailable   = errors.New("keys for this user are not available")
+)
+
+// RegisterDeviceRequest carries a device's public identity and prekeys.
+type RegisterDeviceRequest struct {
+	Name                  string                  `json:"name"`
+	IdentityKey           string                  `json:"identity_key"`
+	SignedPrekeyID        int32                   `json:"signed_prekey_id"`
+	SignedPrekey          string                  `json:"signed_prekey"`
+	SignedPrekeySignature string                  `json:"signed_prekey_signature"`
+	OneTimePrekeys        []dtos.OneTimePrekeyDto `json:"one_time_prekeys"`
+}
+
+type Service struct {
+	repo      Repository
+	blockRepo blocks.Repository
+}
+
+func NewService(repo Repository, blockRepo blocks.Repository) *Service {
+	return &Service{repo: repo, blockRepo: blockRepo}
+}
+
+func (s *Service) RegisterDevice(ctx context.Context, userID int32, req RegisterDeviceRequest) (dtos.DeviceDto, error) {
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name == "" || len(req.Name) > maxDeviceNameLength {
+		return dtos.DeviceDto{}, ErrInvalidDeviceName
+	}
+	for _, key := range []string{req.IdentityKey, req.SignedPrekey, req.SignedPrekeySignature} {
+		if !validKey(key) {
+			return dtos.DeviceDto{}, ErrInvalidKey
+		}
+	}
+	if err := validatePrekeys(req.OneTimePrekeys); err != nil {
+		return dtos.DeviceDto{}, err
+	}
+
+	devices, err := s.repo.ListDevices(ctx, userID)
+	if err != nil {
+		return dtos.DeviceDto{}, err
+	}
+	if len(devices) >= MaxDevicesPerUser {
+		return dtos.DeviceDto{}, ErrTooManyDevices
+	}
+
+	device, err := s.repo.CreateDevice(ctx, db.CreateUserDeviceParams{
+		UserID:                userID,
+		Name:                  req.Name,
+		IdentityKey:           req.IdentityKey,
+		SignedPrekeyID:        req.SignedPrekeyID,
+		SignedPrekey:          req.SignedPrekey,
+		SignedPrekeySignature: req.SignedPrekeySignature,
+	}, req.OneTimePrekeys)
+	if err != nil {
+		return dtos.DeviceDto{}, err
+	}
+	return s.deviceDto(ctx, device)
+}
+
+func (s *Service) ListDevices(ctx context.Context, userID int32) ([]dtos.DeviceDto, error) {
+	devices, err := s.repo.ListDevices(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]dtos.DeviceDto, 0, len(devices))
+	for _, device := range devices {
+		dto, err := s.deviceDto(ctx, device)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, dto)
+	}
+	return result, nil
+}
+
+// DeleteDevice removes the device and its prekeys. Envelopes addressed to it
+// are deleted too, so its past messages become unreadable on that device.
+func (s *Service) DeleteDevice(ctx context.Context, userID, deviceID int32) error {
+	deleted, err := s.repo.DeleteDevice(ctx, deviceID, userID)
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		return ErrDeviceNotFound
+	}
+	return nil
+}
+
+// AddPrekeys tops up a device's one-time prekeys. Key IDs already uploaded
+// are skipped.
+func (s *Service) AddPrekeys(ctx context.Context, userID, deviceID int32, prekeys []dtos.OneTimePrekeyDto) (dtos.DeviceDto, error) {
+	if err := validatePrekeys(prekeys); err != nil {
+		return dtos.DeviceDto{}, err
+	}
+	device, err := s.repo.GetDevice(ctx, deviceID, userID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return dtos.DeviceDto{}, ErrDeviceNotFound
+	}
+	if err != nil {
+		return dtos.DeviceDto{}, err
+	}
+
+	if err := s.repo.AddPrekeys(ctx, deviceID, prekeys); err != nil {
+		return dtos.DeviceDto{}, err
+	}
+	return s.deviceDto(ctx, device)
+}
+
+// GetKeyBundles returns a bundle for each of the target's devices, claiming
+// one one-time prekey from each. Users on either side of a block get no keys.
+func (s *Service) GetKeyBundles(ctx context.Context, requesterID, targetUserID int32) ([]dtos.DeviceKeyBundleDto, error) {
+	if requesterID != targetUserID && s.isBlocked(ctx, requesterID, targetUserID) {
+		return nil, ErrKeysUnavailable
+	}
+
+	devices, err := s.repo.ListDevices(ctx, targetUserID)
+	if err != nil {
+		return nil, err
+	}
+
+	bundles := make([]dtos.DeviceKeyBundleDto, 0, len(devices))
+	for _, device := range devices {
+		bundle := dtos.DeviceKeyBundleDto{
+			DeviceID:              device.ID,
+			UserID:                device.UserID,
+			IdentityKey:           device.IdentityKey,
+			SignedPrekeyID:        device.SignedPrekeyID,
+			SignedPrekey:          device.SignedPrekey,
+			SignedPrekeySignature: device.SignedPrekeySignature,
+		}
+		prekey, err := s.repo.ClaimPrekey(ctx, device.ID)
+		if err == nil {
+			bundle.OneTimePrekey = &dtos.OneTimePrekeyDto{KeyID: prekey.KeyID, PublicKey: prekey.PublicKey}
+		} else if !errors.Is(err, pgx.ErrNoRows) {
+			return nil, err
+		}
+		bundles = append(bundles, bundle)
+	}
+	return bundles, nil
+}
+
+func (s *Service) isBlocked(ctx context.Context, userID, otherUserID int32) bool {
+	if _, err := s.blockRepo.GetBlock(ctx, userID, otherUserID); err == nil {
+		return true
+	}
+	if _, err := s.blockRepo.GetBlock(ctx, otherUserID, userID); err == nil {
+		return true
+	}
+	return false
+}
+
+func (s *Service) deviceDto(ctx context.Context, device db.UserDevice) (dtos.DeviceDto, error) {
+	count, err := s.repo.CountPrekeys(ctx, device.ID)
+	if err != nil {
+		return dtos.DeviceDto{}, err
+	}
+	return dtos.DeviceDto{
+		ID:                    device.ID,
+		Name:                  device.Name,
+		IdentityKey:           device.IdentityKey,
+		SignedPrekeyID:        device.SignedPrekeyID,
+		SignedPrekey:          device.SignedPrekey,
+		SignedPrekeySignature: device.SignedPrekeySignature,
+		OneTimePrekeyCount:    count,
+		CreatedAt:             device.CreatedAt.Time.Format("2006-01-02T15:04:05Z07:00"),
+	}, nil
+}
+
+func validatePrekeys(prekeys []dtos.OneTimePrekeyDto) error {
+	if len(prekeys) > MaxPrekeysPerUpload {
+		return ErrTooManyPrekeys
+	}
+	for _, prekey := range prekeys {
+		if !validKey(prekey.PublicKey) {
+			return ErrInvalidKey
+		}
+	}
+	return nil
+}
+
+func validKey(key string) bool {
+	if key == "" || len(key) > maxKeyLength {
+		return false
+	}
+	_, err := base64.StdEncoding.DecodeString(key)
+	return err == nil
+}
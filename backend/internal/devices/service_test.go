@@ -0,0 +1,197 @@
+package devices
+
+import (
+	"context"
+	"testing"
+
+	"github.com/andrelcunha/Concord/backend/internal/blocks"
+	"github.com/andrelcunha/Concord/backend/internal/db"
+	"github.com/andrelcunha/Concord/backend/pkg/dtos"
+	"github.com/jackc/pgx/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type mockRepository struct {
+	devices map[int32]db.UserDevice
+	prekeys map[int32][]dtos.OneTimePrekeyDto
+	nextID  int32
+}
+
+func newMockRepository() *mockRepository {
+	return &mockRepository{
+		devices: map[int32]db.UserDevice{},
+		prekeys: map[int32][]dtos.OneTimePrekeyDto{},
+	}
+}
+
+func (m *mockRepository) CreateDevice(ctx context.Context, params db.CreateUserDeviceParams, prekeys []dtos.OneTimePrekeyDto) (db.UserDevice, error) {
+	m.nextID++
+	device := db.UserDevice{
+		ID:                    m.nextID,
+		UserID:                params.UserID,
+		Name:                  params.Name,
+		IdentityKey:           params.IdentityKey,
+		SignedPrekeyID:        params.SignedPrekeyID,
+		SignedPrekey:          params.SignedPrekey,
+		SignedPrekeySignature: params.SignedPrekeySignature,
+	}
+	m.devices[device.ID] = device
+	m.prekeys[device.ID] = append(m.prekeys[device.ID], prekeys...)
+	return device, nil
+}
+
+func (m *mockRepository) ListDevices(ctx context.Context, userID int32) ([]db.UserDevice, error) {
+	var devices []db.UserDevice
+	for id := int32(1); id <= m.nextID; id++ {
+		if device, ok := m.devices[id]; ok && device.UserID == userID {
+			devices = append(devices, device)
+		}
+	}
+	return devices, nil
+}
+
+func (m *mockRepository) GetDevice(ctx context.Context, deviceID, userID int32) (db.UserDevice, error) {
+	device, ok := m.devices[deviceID]
+	if !ok || device.UserID != userID {
+		return db.UserDevice{}, pgx.ErrNoRows
+	}
+	return device, nil
+}
+
+func (m *mockRepository) DeleteDevice(ctx context.Context, deviceID, userID int32) (bool, error) {
+	if _, err := m.GetDevice(ctx, deviceID, userID); err != nil {
+		return false, nil
+	}
+	delete(m.devices, deviceID)
+	return true, nil
+}
+
+func (m *mockRepository) AddPrekeys(ctx context.Context, deviceID int32, prekeys []dtos.OneTimePrekeyDto) error {
+	m.prekeys[deviceID] = append(m.prekeys[deviceID], prekeys...)
+	return nil
+}
+
+func (m *mockRepository) CountPrekeys(ctx context.Context, deviceID int32) (int64, error) {
+	return int64(len(m.prekeys[deviceID])), nil
+}
+
+func (m *mockRepository) ClaimPrekey(ctx context.Context, deviceID int32) (db.ClaimOneTimePrekeyRow, error) {
+	prekeys := m.prekeys[deviceID]
+	if len(prekeys) == 0 {
+		return db.ClaimOneTimePrekeyRow{}, pgx.ErrNoRows
+	}
+	m.prekeys[deviceID] = prekeys[1:]
+	return db.ClaimOneTimePrekeyRow{KeyID: prekeys[0].KeyID, PublicKey: prekeys[0].PublicKey}, nil
+}
+
+type mockBlockRepository struct {
+	blocks map[[2]int32]bool
+}
+
+func (m *mockBlockRepository) CreateBlock(ctx context.Context, blockerID, blockedID int32) (blocks.CreatedBlock, error) {
+	m.blocks[[2]int32{blockerID, blockedID}] = true
+	return blocks.CreatedBlock{Block: db.Block{BlockerID: blockerID, BlockedID: blockedID}}, nil
+}
+
+func (m *mockBlockRepository) DeleteBlock(ctx context.Context, blockerID, blockedID int32) error {
+	delete(m.blocks, [2]int32{blockerID, blockedID})
+	return nil
+}
+
+func (m *mockBlockRepository) GetBlock(ctx context.Context, blockerID, blockedID int32) (db.Block, error) {
+	if !m.blocks[[2]int32{blockerID, blockedID}] {
+		return db.Block{}, pgx.ErrNoRows
+	}
+	return db.Block{BlockerID: blockerID, BlockedID: blockedID}, nil
+}
+
+func (m *mockBlockRepository) ListBlockedUsers(ctx context.Context, blockerID int32) ([]db.ListBlockedUsersRow, error) {
+	return nil, nil
+}
+
+func (m *mockBlockRepository) ListBlockedUserIDs(ctx context.Context, blockerID int32) ([]int32, error) {
+	return nil, nil
+}
+
+func (m *mockBlockRepository) ListBlockRelatedUserIDs(ctx context.Context, userID int32) ([]int32, error) {
+	return nil, nil
+}
+
+func newTestService() (*Service, *mockBlockRepository) {
+	blockRepo := &mockBlockRepository{blocks: map[[2]int32]bool{}}
+	return NewService(newMockRepository(), blockRepo), blockRepo
+}
+
+func validRequest() RegisterDeviceRequest {
+	return RegisterDeviceRequest{
+		Name:                  "Laptop",
+		IdentityKey:           "aWRlbnRpdHk=",
+		SignedPrekeyID:        1,
+		SignedPrekey:          "c2lnbmVk",
+		SignedPrekeySignature: "c2lnbmF0dXJl",
+		OneTimePrekeys: []dtos.OneTimePrekeyDto{
+			{KeyID: 1, PublicKey: "b25l"},
+			{KeyID: 2, PublicKey: "dHdv"},
+		},
+	}
+}
+
+func TestRegisterDeviceRejectsInvalidKeys(t *testing.T) {
+	service, _ := newTestService()
+
+	req := validRequest()
+	req.IdentityKey = "not base64!"
+	_, err := service.RegisterDevice(context.Background(), 1, req)
+	assert.ErrorIs(t, err, ErrInvalidKey)
+
+	req = validRequest()
+	req.OneTimePrekeys = append(req.OneTimePrekeys, dtos.OneTimePrekeyDto{KeyID: 3})
+	_, err = service.RegisterDevice(context.Background(), 1, req)
+	assert.ErrorIs(t, err, ErrInvalidKey)
+
+	req = validRequest()
+	req.Name = "  "
+	_, err = service.RegisterDevice(context.Background(), 1, req)
+	assert.ErrorIs(t, err, ErrInvalidDeviceName)
+}
+
+func TestRegisterDeviceEnforcesDeviceLimit(t *testing.T) {
+	service, _ := newTestService()
+
+	for range MaxDevicesPerUser {
+		_, err := service.RegisterDevice(context.Background(), 1, validRequest())
+		require.NoError(t, err)
+	}
+	_, err := service.RegisterDevice(context.Background(), 1, validRequest())
+	assert.ErrorIs(t, err, ErrTooManyDevices)
+}
+
+func TestGetKeyBundlesClaimsEachPrekeyOnce(t *testing.T) {
+	service, _ := newTestService()
+	device, err := service.RegisterDevice(context.Background(), 2, validRequest())
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), device.OneTimePrekeyCount)
+
+	var claimed []int32
+	for range 3 {
+		bundles, err := service.GetKeyBundles(context.Background(), 1, 2)
+		require.NoError(t, err)
+		require.Len(t, bundles, 1)
+		assert.Equal(t, "aWRlbnRpdHk=", bundles[0].IdentityKey)
+		if bundles[0].OneTimePrekey != nil {
+			claimed = append(claimed, bundles[0].OneTimePrekey.KeyID)
+		}
+	}
+	assert.Equal(t, []int32{1, 2}, claimed)
+}
+
+func TestGetKeyBundlesHidesKeysAcrossBlocks(t *testing.T) {
+	service, blockRepo := newTestService()
+	_, err := service.RegisterDevice(context.Background(), 2, validRequest())
+	require.NoError(t, err)
+	blockRepo.blocks[[2]int32{2, 1}] = true
+
+	_, err = service.GetKeyBundles(context.Background(), 1, 2)
+	assert.ErrorIs(t, err, ErrKeysUnavailable)
+}
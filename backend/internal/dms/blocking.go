@@ -0,0 +1,44 @@
+package dms
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+)
+
+// EventTypeBlock is published on dm:<id> for every conversation two users
+// share when one blocks the other. It is not stored as a message and is only
+// delivered to the two users.
+const EventTypeBlock = "block"
+
+type dmBlockEvent struct {
+	Type           string `json:"type"`
+	ConversationID int32  `json:"conversation_id"`
+	UserID         int32  `json:"user_id"`
+	TargetUserID   int32  `json:"target_user_id"`
+}
+
+// UserBlocked implements blocks.Listener. Sockets on the two users'
+// one-to-one conversation are closed, and open group sockets start hiding
+// each other's messages.
+func (s *Service) UserBlocked(ctx context.Context, blockerID, blockedID int32) {
+	conversationIDs, err := s.repo.ListSharedConversationIDs(ctx, blockerID, blockedID)
+	if err != nil {
+		log.Printf("ListSharedConversationIDs error: %v", err)
+		return
+	}
+
+	for _, conversationID := range conversationIDs {
+		payload, err := json.Marshal(dmBlockEvent{
+			Type:           EventTypeBlock,
+			ConversationID: conversationID,
+			UserID:         blockerID,
+			TargetUserID:   blockedID,
+		})
+		if err != nil {
+			log.Printf("DM marshal error: %v", err)
+			continue
+		}
+		_ = s.BroadcastMessage(ctx, conversationID, payload)
+	}
+}
@@ -0,0 +1,103 @@
+package dms
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"slices"
+
+	"github.com/andrelcunha/Concord/backend/internal/devices"
+	"github.com/andrelcunha/Concord/backend/pkg/dtos"
+)
+
+const (
+	// maxEnvelopes covers every device of a full group.
+	maxEnvelopes      = MaxGroupMembers * devices.MaxDevicesPerUser
+	maxCiphertextSize = 64 * 1024
+)
+
+// OutgoingMessage is a message a participant sends. Encrypted conversations
+// set SenderDeviceID and Envelopes and leave Content empty.
+type OutgoingMessage struct {
+	Content        string
+	SenderDeviceID int32
+	Envelopes      []dtos.DmEnvelopeDto
+}
+
+// EnableEncryption switches a conversation to end-to-end encryption. It
+// cannot be turned off, and in groups only the owner can turn it on.
+func (s *Service) EnableEncryption(ctx context.Context, userID, conversationID int32) (dtos.DmConversationDto, error) {
+	conversation, participants, err := s.checkAccess(ctx, userID, conversationID)
+	if err != nil {
+		return dtos.DmConversationDto{}, err
+	}
+	if conversation.IsGroup && conversation.OwnerID.Int32 != userID {
+		return dtos.DmConversationDto{}, ErrNotGroupOwner
+	}
+
+	enabled, err := s.repo.EnableEncryption(ctx, conversationID)
+	if err != nil {
+		return dtos.DmConversationDto{}, err
+	}
+	if enabled {
+		actor := findParticipant(participants, userID)
+		s.postSystemMessage(ctx, conversationID, actor, 0, MessageTypeEncryptionEnabled, fmt.Sprintf("%s turned on end-to-end encryption.", shownName(actor)))
+	}
+	return s.GetConversation(ctx, userID, conversationID)
+}
+
+// validateEncryptedMessage checks that the message carries no plaintext, is
+// sent from one of the sender's devices, and only addresses devices of
+// current participants, each at most once.
+func (s *Service) validateEncryptedMessage(ctx context.Context, userID int32, participants []dtos.UserSummaryDto, outgoing OutgoingMessage) error {
+	if outgoing.Content != "" {
+		return ErrEncryptedConversation
+	}
+	if len(outgoing.Envelopes) == 0 || len(outgoing.Envelopes) > maxEnvelopes {
+		return ErrInvalidEnvelopes
+	}
+	if err := s.checkDeviceOwner(ctx, userID, outgoing.SenderDeviceID); err != nil {
+		return err
+	}
+
+	deviceIDs := make([]int32, 0, len(outgoing.Envelopes))
+	for _, envelope := range outgoing.Envelopes {
+		if slices.Contains(deviceIDs, envelope.DeviceID) || !validCiphertext(envelope.Ciphertext) {
+			return ErrInvalidEnvelopes
+		}
+		deviceIDs = append(deviceIDs, envelope.DeviceID)
+	}
+
+	owners, err := s.repo.ListDeviceOwners(ctx, deviceIDs)
+	if err != nil {
+		return err
+	}
+	if len(owners) != len(deviceIDs) {
+		return ErrInvalidEnvelopes
+	}
+	for _, owner := range owners {
+		if findParticipant(participants, owner.UserID).UserID == 0 {
+			return ErrInvalidEnvelopes
+		}
+	}
+	return nil
+}
+
+func (s *Service) checkDeviceOwner(ctx context.Context, userID, deviceID int32) error {
+	owners, err := s.repo.ListDeviceOwners(ctx, []int32{deviceID})
+	if err != nil {
+		return err
+	}
+	if len(owners) != 1 || owners[0].UserID != userID {
+		return ErrInvalidDevice
+	}
+	return nil
+}
+
+func validCiphertext(ciphertext string) bool {
+	if ciphertext == "" || len(ciphertext) > maxCiphertextSize {
+		return false
+	}
+	_, err := base64.StdEncoding.DecodeString(ciphertext)
+	return err == nil
+}
@@ -0,0 +1,290 @@
+package dms
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/url"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/andrelcunha/Concord/backend/internal/db"
+	"github.com/andrelcunha/Concord/backend/pkg/dtos"
+)
+
+// CreateGroup starts a group conversation owned by ownerID. Like one-to-one
+// conversations, every initial member must be a friend of the owner and not
+// blocked either way.
+func (s *Service) CreateGroup(ctx context.Context, ownerID int32, name, iconURL string, memberIDs []int32) (dtos.DmConversationDto, error) {
+	name, iconURL = strings.TrimSpace(name), strings.TrimSpace(iconURL)
+	if err := validateGroupProfile(name, iconURL); err != nil {
+		return dtos.DmConversationDto{}, err
+	}
+
+	var members []int32
+	for _, memberID := range memberIDs {
+		if memberID != ownerID && !slices.Contains(members, memberID) {
+			members = append(members, memberID)
+		}
+	}
+	if len(members) == 0 {
+		return dtos.DmConversationDto{}, ErrGroupTooSmall
+	}
+	if len(members)+1 > MaxGroupMembers {
+		return dtos.DmConversationDto{}, ErrGroupFull
+	}
+	for _, memberID := range members {
+		if err := s.checkCanInvite(ctx, ownerID, memberID); err != nil {
+			return dtos.DmConversationDto{}, err
+		}
+	}
+
+	conversation, err := s.repo.CreateGroupConversation(ctx, ownerID, name, iconURL, members)
+	if err != nil {
+		return dtos.DmConversationDto{}, err
+	}
+	return s.GetConversation(ctx, ownerID, conversation.ID)
+}
+
+// UpdateGroup changes the group's name and icon. Empty values clear them.
+func (s *Service) UpdateGroup(ctx context.Context, userID, conversationID int32, name, iconURL string) (dtos.DmConversationDto, error) {
+	name, iconURL = strings.TrimSpace(name), strings.TrimSpace(iconURL)
+	if err := validateGroupProfile(name, iconURL); err != nil {
+		return dtos.DmConversationDto{}, err
+	}
+	_, participants, err := s.requireGroupOwner(ctx, userID, conversationID)
+	if err != nil {
+		return dtos.DmConversationDto{}, err
+	}
+
+	if _, err := s.repo.UpdateGroupConversation(ctx, conversationID, name, iconURL); err != nil {
+		return dtos.DmConversationDto{}, err
+	}
+
+	actor := findParticipant(participants, userID)
+	s.postSystemMessage(ctx, conversationID, actor, 0, MessageTypeGroupUpdated, fmt.Sprintf("%s updated the group.", shownName(actor)))
+	return s.GetConversation(ctx, userID, conversationID)
+}
+
+// AddMember lets the owner add one of their friends.
+func (s *Service) AddMember(ctx context.Context, ownerID, conversationID, userID int32) (dtos.DmConversationDto, error) {
+	_, participants, err := s.requireGroupOwner(ctx, ownerID, conversationID)
+	if err != nil {
+		return dtos.DmConversationDto{}, err
+	}
+	if findParticipant(participants, userID).UserID != 0 {
+		return dtos.DmConversationDto{}, ErrAlreadyGroupMember
+	}
+	if len(participants) >= MaxGroupMembers {
+		return dtos.DmConversationDto{}, ErrGroupFull
+	}
+	if err := s.checkCanInvite(ctx, ownerID, userID); err != nil {
+		return dtos.DmConversationDto{}, err
+	}
+
+	joined, err := s.repo.AddParticipant(ctx, conversationID, userID, MaxGroupMembers)
+	if err != nil {
+		return dtos.DmConversationDto{}, err
+	}
+	if !joined {
+		return dtos.DmConversationDto{}, ErrGroupFull
+	}
+
+	conversation, err := s.GetConversation(ctx, ownerID, conversationID)
+	if err != nil {
+		return dtos.DmConversationDto{}, err
+	}
+	actor := findParticipant(conversation.Participants, ownerID)
+	added := findParticipant(conversation.Participants, userID)
+	s.postSystemMessage(ctx, conversationID, actor, userID, MessageTypeMemberAdded, fmt.Sprintf("%s added %s to the group.", shownName(actor), shownName(added)))
+	return conversation, nil
+}
+
+// RemoveMember lets the owner remove someone else from the group.
+func (s *Service) RemoveMember(ctx context.Context, ownerID, conversationID, userID int32) error {
+	if userID == ownerID {
+		return ErrCannotRemoveOwner
+	}
+	_, participants, err := s.requireGroupOwner(ctx, ownerID, conversationID)
+	if err != nil {
+		return err
+	}
+	removed := findParticipant(participants, userID)
+	if removed.UserID == 0 {
+		return ErrNotGroupMember
+	}
+
+	if _, err := s.repo.RemoveParticipant(ctx, conversationID, userID); err != nil {
+		return err
+	}
+
+	actor := findParticipant(participants, ownerID)
+	s.postSystemMessage(ctx, conversationID, actor, userID, MessageTypeMemberRemoved, fmt.Sprintf("%s removed %s from the group.", shownName(actor), shownName(removed)))
+	return nil
+}
+
+// LeaveGroup removes the caller. An owner who leaves hands the group to the
+// longest-standing member; the last member to leave deletes it.
+func (s *Service) LeaveGroup(ctx context.Context, userID, conversationID int32) error {
+	conversation, participants, err := s.checkAccess(ctx, userID, conversationID)
+	if err != nil {
+		return err
+	}
+	if !conversation.IsGroup {
+		return ErrNotGroupConversation
+	}
+
+	if _, err := s.repo.RemoveParticipant(ctx, conversationID, userID); err != nil {
+		return err
+	}
+
+	var remaining []dtos.UserSummaryDto
+	for _, participant := range participants {
+		if participant.UserID != userID {
+			remaining = append(remaining, participant)
+		}
+	}
+	if len(remaining) == 0 {
+		return s.repo.DeleteConversation(ctx, conversationID)
+	}
+
+	leaver := findParticipant(participants, userID)
+	content := fmt.Sprintf("%s left the group.", shownName(leaver))
+	if conversation.OwnerID.Int32 == userID {
+		newOwner := remaining[0]
+		if err := s.repo.SetGroupOwner(ctx, conversationID, newOwner.UserID); err != nil {
+			return err
+		}
+		content = fmt.Sprintf("%s left the group. %s is now the owner.", shownName(leaver), shownName(newOwner))
+	}
+	s.postSystemMessage(ctx, conversationID, leaver, userID, MessageTypeMemberLeft, content)
+	return nil
+}
+
+func (s *Service) requireGroupOwner(ctx context.Context, userID, conversationID int32) (db.DmConversation, []dtos.UserSummaryDto, error) {
+	conversation, participants, err := s.checkAccess(ctx, userID, conversationID)
+	if err != nil {
+		return db.DmConversation{}, nil, err
+	}
+	if !conversation.IsGroup {
+		return db.DmConversation{}, nil, ErrNotGroupConversation
+	}
+	if conversation.OwnerID.Int32 != userID {
+		return db.DmConversation{}, nil, ErrNotGroupOwner
+	}
+	return conversation, participants, nil
+}
+
+// checkCanInvite applies the one-to-one rules between the inviter and each
+// invitee. Blocks between other members do not prevent joining; they only
+// hide those members' messages from each other.
+func (s *Service) checkCanInvite(ctx context.Context, inviterID, userID int32) error {
+	if s.isBlocked(ctx, inviterID, userID) {
+		return ErrDmBlockedRelationship
+	}
+	low, high := normalizePair(inviterID, userID)
+	friendship, err := s.friendshipRepo.GetFriendshipByUsers(ctx, low, high)
+	if err != nil || friendship.Status != "accepted" {
+		return ErrDmRequiresFriendship
+	}
+	return nil
+}
+
+// postSystemMessage stores and broadcasts a membership change. Failures are
+// logged; the change itself has already happened.
+func (s *Service) postSystemMessage(ctx context.Context, conversationID int32, actor dtos.UserSummaryDto, targetUserID int32, messageType, content string) {
+	message, err := s.repo.CreateDmSystemMessage(ctx, conversationID, actor.UserID, targetUserID, messageType, content)
+	if err != nil {
+		log.Printf("CreateDmSystemMessage error: %v", err)
+		return
+	}
+
+	payload, err := json.Marshal(newDmWSResponse(dtos.DmMessageDto{
+		ID:             message.ID,
+		ConversationID: message.ConversationID,
+		UserID:         message.UserID,
+		Username:       actor.Username,
+		Content:        message.Content,
+		CreatedAt:      message.CreatedAt.Time,
+		AvatarURL:      actor.AvatarURL,
+		AvatarColor:    actor.AvatarColor,
+		Type:           message.Type,
+		TargetUserID:   message.TargetUserID.Int32,
+	}))
+	if err != nil {
+		log.Printf("DM marshal error: %v", err)
+		return
+	}
+	s.BroadcastMessage(ctx, conversationID, payload)
+}
+
+func validateGroupProfile(name, iconURL string) error {
+	if utf8.RuneCountInString(name) > 100 {
+		return ErrInvalidGroupName
+	}
+	if iconURL != "" {
+		parsed, err := url.Parse(iconURL)
+		if err != nil || parsed.Host == "" || (parsed.Scheme != "http" && parsed.Scheme != "https") {
+			return ErrInvalidGroupIconURL
+		}
+	}
+	return nil
+}
+
+func findParticipant(participants []dtos.UserSummaryDto, userID int32) dtos.UserSummaryDto {
+	for _, participant := range participants {
+		if participant.UserID == userID {
+			return participant
+		}
+	}
+	return dtos.UserSummaryDto{}
+}
+
+// shownName is how system messages name a user: their display name, or
+// their username if they have not set one.
+func shownName(user dtos.UserSummaryDto) string {
+	if user.DisplayName != "" {
+		return user.DisplayName
+	}
+	return user.Username
+}
+
+func userSummary(userID int32, username, displayName, avatarURL, avatarColor string) dtos.UserSummaryDto {
+	return dtos.UserSummaryDto{
+		UserID:      userID,
+		Username:    username,
+		DisplayName: displayName,
+		AvatarURL:   avatarURL,
+		AvatarColor: avatarColor,
+	}
+}
+
+// conversationDto lists every member; one-to-one conversations also get the
+// other member as OtherUser.
+func conversationDto(conversation db.DmConversation, participants []dtos.UserSummaryDto, userID int32) dtos.DmConversationDto {
+	dto := dtos.DmConversationDto{
+		ID:            conversation.ID,
+		CreatedAt:     conversation.CreatedAt.Time.Format("2006-01-02T15:04:05Z07:00"),
+		IsGroup:       conversation.IsGroup,
+		Name:          conversation.Name.String,
+		IconURL:       conversation.IconUrl.String,
+		OwnerID:       conversation.OwnerID.Int32,
+		Participants:  participants,
+		RequesterID:   conversation.RequesterID.Int32,
+		RequestStatus: conversation.RequestStatus.String,
+	}
+	if dto.Participants == nil {
+		dto.Participants = []dtos.UserSummaryDto{}
+	}
+	if !conversation.IsGroup {
+		for _, participant := range participants {
+			if participant.UserID != userID {
+				other := participant
+				dto.OtherUser = &other
+			}
+		}
+	}
+	return dto
+}
@@ -3,6 +3,7 @@ package dms
 import (
 	"strconv"
 
+	"github.com/andrelcunha/Concord/backend/internal/middleware"
 	"github.com/gofiber/fiber/v2"
 )
 
@@ -73,13 +74,186 @@ func (h *Handler) ListMessages(c *fiber.Ctx) error {
 	}
 
 	userID := c.Locals("userID").(int32)
-	messages, err := h.service.ListMessages(c.Context(), userID, int32(conversationID), 50, 0)
+	deviceID := c.QueryInt("device_id", 0)
+	messages, err := h.service.ListMessages(c.Context(), userID, int32(conversationID), int32(deviceID), 50, 0)
 	if err != nil {
 		return dmErrorResponse(c, err)
 	}
 	return c.JSON(messages)
 }
 
+func (h *Handler) MarkRead(c *fiber.Ctx) error {
+	conversationID, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid conversation ID"})
+	}
+	var req struct {
+		MessageID int32 `json:"message_id"`
+	}
+	if err := c.BodyParser(&req); err != nil || req.MessageID == 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request"})
+	}
+
+	userID := c.Locals("userID").(int32)
+	if err := h.service.MarkRead(c.Context(), userID, int32(conversationID), req.MessageID); err != nil {
+		return dmErrorResponse(c, err)
+	}
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+func (h *Handler) EnableEncryption(c *fiber.Ctx) error {
+	conversationID, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid conversation ID"})
+	}
+
+	userID := c.Locals("userID").(int32)
+	conversation, err := h.service.EnableEncryption(c.Context(), userID, int32(conversationID))
+	if err != nil {
+		return dmErrorResponse(c, err)
+	}
+	return c.JSON(conversation)
+}
+
+func (h *Handler) ListMessageRequests(c *fiber.Ctx) error {
+	userID := c.Locals("userID").(int32)
+	requests, err := h.service.ListMessageRequests(c.Context(), userID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(fiber.Map{"requests": requests})
+}
+
+func (h *Handler) AcceptRequest(c *fiber.Ctx) error {
+	conversationID, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid conversation ID"})
+	}
+
+	userID := c.Locals("userID").(int32)
+	conversation, err := h.service.AcceptRequest(c.Context(), userID, int32(conversationID))
+	if err != nil {
+		return dmErrorResponse(c, err)
+	}
+	return c.JSON(conversation)
+}
+
+func (h *Handler) IgnoreRequest(c *fiber.Ctx) error {
+	conversationID, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid conversation ID"})
+	}
+
+	userID := c.Locals("userID").(int32)
+	if err := h.service.IgnoreRequest(c.Context(), userID, int32(conversationID)); err != nil {
+		return dmErrorResponse(c, err)
+	}
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+func (h *Handler) BlockRequester(c *fiber.Ctx) error {
+	conversationID, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid conversation ID"})
+	}
+
+	userID := c.Locals("userID").(int32)
+	if err := h.service.BlockRequester(c.Context(), userID, int32(conversationID)); err != nil {
+		return dmErrorResponse(c, err)
+	}
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+type groupProfileRequest struct {
+	Name    string `json:"name"`
+	IconURL string `json:"icon_url"`
+}
+
+func (h *Handler) CreateGroup(c *fiber.Ctx) error {
+	var req struct {
+		groupProfileRequest
+		UserIDs []int32 `json:"user_ids"`
+	}
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request"})
+	}
+
+	userID := c.Locals("userID").(int32)
+	conversation, err := h.service.CreateGroup(c.Context(), userID, req.Name, req.IconURL, req.UserIDs)
+	if err != nil {
+		return dmErrorResponse(c, err)
+	}
+	return c.Status(fiber.StatusCreated).JSON(conversation)
+}
+
+func (h *Handler) UpdateGroup(c *fiber.Ctx) error {
+	conversationID, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid conversation ID"})
+	}
+	var req groupProfileRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request"})
+	}
+
+	userID := c.Locals("userID").(int32)
+	conversation, err := h.service.UpdateGroup(c.Context(), userID, int32(conversationID), req.Name, req.IconURL)
+	if err != nil {
+		return dmErrorResponse(c, err)
+	}
+	return c.JSON(conversation)
+}
+
+func (h *Handler) AddMember(c *fiber.Ctx) error {
+	conversationID, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid conversation ID"})
+	}
+	var req struct {
+		UserID int32 `json:"user_id"`
+	}
+	if err := c.BodyParser(&req); err != nil || req.UserID == 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request"})
+	}
+
+	userID := c.Locals("userID").(int32)
+	conversation, err := h.service.AddMember(c.Context(), userID, int32(conversationID), req.UserID)
+	if err != nil {
+		return dmErrorResponse(c, err)
+	}
+	return c.JSON(conversation)
+}
+
+func (h *Handler) RemoveMember(c *fiber.Ctx) error {
+	conversationID, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid conversation ID"})
+	}
+	memberID, err := strconv.Atoi(c.Params("userId"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid user ID"})
+	}
+
+	userID := c.Locals("userID").(int32)
+	if err := h.service.RemoveMember(c.Context(), userID, int32(conversationID), int32(memberID)); err != nil {
+		return dmErrorResponse(c, err)
+	}
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+func (h *Handler) LeaveGroup(c *fiber.Ctx) error {
+	conversationID, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid conversation ID"})
+	}
+
+	userID := c.Locals("userID").(int32)
+	if err := h.service.LeaveGroup(c.Context(), userID, int32(conversationID)); err != nil {
+		return dmErrorResponse(c, err)
+	}
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
 func dmErrorResponse(c *fiber.Ctx, err error) error {
 	switch err {
 	case ErrDmForbidden:
@@ -88,6 +262,18 @@ func dmErrorResponse(c *fiber.Ctx, err error) error {
 		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": err.Error()})
 	case ErrDmBlockedRelationship:
 		return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": err.Error()})
+	case ErrNotMessageRequest, ErrEncryptedConversation, ErrNotEncryptedConversation, ErrInvalidEnvelopes:
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	case ErrInvalidDevice:
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
+	case ErrNotGroupOwner:
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": err.Error()})
+	case ErrNotGroupMember:
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
+	case ErrAlreadyGroupMember, ErrGroupFull:
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": err.Error()})
+	case ErrNotGroupConversation, ErrGroupTooSmall, ErrCannotRemoveOwner, ErrInvalidGroupName, ErrInvalidGroupIconURL:
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
 	default:
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
 	}
@@ -96,9 +282,22 @@ func dmErrorResponse(c *fiber.Ctx, err error) error {
 func RegisterDmRoutes(api fiber.Router, service *Service) {
 	handler := NewHandler(service)
 	dms := api.Group("/dms")
-	dms.Get("/", handler.ListConversations)
-	dms.Post("/", handler.CreateOrGetConversation)
-	dms.Get("/:id", handler.GetConversation)
-	dms.Delete("/:id", handler.HideConversation)
-	dms.Get("/:id/messages", handler.ListMessages)
+	read := middleware.RequireScope(middleware.ScopeMessagesRead)
+	send := middleware.RequireScope(middleware.ScopeMessagesSend)
+	dms.Get("/", read, handler.ListConversations)
+	dms.Post("/", send, handler.CreateOrGetConversation)
+	dms.Post("/groups", send, handler.CreateGroup)
+	dms.Get("/requests", read, handler.ListMessageRequests)
+	dms.Post("/requests/:id/accept", send, handler.AcceptRequest)
+	dms.Post("/requests/:id/ignore", send, handler.IgnoreRequest)
+	dms.Post("/requests/:id/block", middleware.RequireInteractive(), handler.BlockRequester)
+	dms.Get("/:id", read, handler.GetConversation)
+	dms.Patch("/:id", send, handler.UpdateGroup)
+	dms.Delete("/:id", send, handler.HideConversation)
+	dms.Get("/:id/messages", read, handler.ListMessages)
+	dms.Post("/:id/read", read, handler.MarkRead)
+	dms.Post("/:id/encryption", middleware.RequireInteractive(), handler.EnableEncryption)
+	dms.Post("/:id/members", send, handler.AddMember)
+	dms.Delete("/:id/members/:userId", send, handler.RemoveMember)
+	dms.Post("/:id/leave", send, handler.LeaveGroup)
 }
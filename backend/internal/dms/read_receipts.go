@@ -0,0 +1,80 @@
+package dms
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"time"
+
+	"github.com/andrelcunha/Concord/backend/pkg/dtos"
+)
+
+// EventTypeRead is published on dm:<id> when a participant's read pointer
+// moves. It is not stored as a message.
+const EventTypeRead = "read"
+
+type dmReadEvent struct {
+	Type              string `json:"type"`
+	ConversationID    int32  `json:"conversation_id"`
+	UserID            int32  `json:"user_id"`
+	LastReadMessageID int32  `json:"last_read_message_id"`
+	LastReadAt        string `json:"last_read_at"`
+}
+
+// MarkRead moves the user's read pointer forward to messageID. Pointers never
+// move back, and the event is only published if the user sends read
+// receipts.
+func (s *Service) MarkRead(ctx context.Context, userID, conversationID, messageID int32) error {
+	if _, _, err := s.checkAccess(ctx, userID, conversationID); err != nil {
+		return err
+	}
+
+	updated, err := s.repo.MarkRead(ctx, conversationID, userID, messageID)
+	if err != nil || !updated {
+		return err
+	}
+
+	settings, err := s.privacy.GetSettings(ctx, userID)
+	if err != nil {
+		log.Printf("GetSettings error: %v", err)
+		return nil
+	}
+	if !settings.SendReadReceipts {
+		return nil
+	}
+
+	payload, err := json.Marshal(dmReadEvent{
+		Type:              EventTypeRead,
+		ConversationID:    conversationID,
+		UserID:            userID,
+		LastReadMessageID: messageID,
+		LastReadAt:        time.Now().UTC().Format("2006-01-02T15:04:05Z07:00"),
+	})
+	if err != nil {
+		log.Printf("DM marshal error: %v", err)
+		return nil
+	}
+	s.BroadcastMessage(ctx, conversationID, payload)
+	return nil
+}
+
+// readStates lists read pointers visible to viewerID.
+func (s *Service) readStates(ctx context.Context, viewerID, conversationID int32) ([]dtos.DmReadStateDto, error) {
+	rows, err := s.repo.ListReadStates(ctx, conversationID)
+	if err != nil {
+		return nil, err
+	}
+
+	states := make([]dtos.DmReadStateDto, 0, len(rows))
+	for _, row := range rows {
+		if !row.LastReadMessageID.Valid || (row.UserID != viewerID && !row.SendReadReceipts) {
+			continue
+		}
+		states = append(states, dtos.DmReadStateDto{
+			UserID:            row.UserID,
+			LastReadMessageID: row.LastReadMessageID.Int32,
+			LastReadAt:        row.LastReadAt.Time.Format("2006-01-02T15:04:05Z07:00"),
+		})
+	}
+	return states, nil
+}
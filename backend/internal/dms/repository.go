@@ -4,20 +4,40 @@ import (
 	"context"
 
 	"github.com/andrelcunha/Concord/backend/internal/db"
+	"github.com/andrelcunha/Concord/backend/pkg/dtos"
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
 type Repository interface {
 	GetDmConversationByUserPair(ctx context.Context, userID, otherUserID int32) (db.DmConversation, error)
 	CreateConversationWithParticipants(ctx context.Context, firstUserID, secondUserID int32) (db.DmConversation, error)
-	GetDmConversationForUser(ctx context.Context, conversationID, userID int32) (db.GetDmConversationForUserRow, error)
+	CreateRequestConversation(ctx context.Context, requesterID, recipientID int32) (db.DmConversation, error)
+	SetRequestStatus(ctx context.Context, conversationID int32, status string) error
+	CreateGroupConversation(ctx context.Context, ownerID int32, name, iconURL string, memberIDs []int32) (db.DmConversation, error)
+	UpdateGroupConversation(ctx context.Context, conversationID int32, name, iconURL string) (db.DmConversation, error)
+	AddParticipant(ctx context.Context, conversationID, userID int32, maxMembers int) (bool, error)
+	RemoveParticipant(ctx context.Context, conversationID, userID int32) (bool, error)
+	SetGroupOwner(ctx context.Context, conversationID, ownerID int32) error
+	DeleteConversation(ctx context.Context, conversationID int32) error
+	GetDmConversationForUser(ctx context.Context, conversationID, userID int32) (db.DmConversation, error)
 	GetDmConversationParticipant(ctx context.Context, conversationID, userID int32) (db.DmConversationParticipant, error)
+	ListParticipants(ctx context.Context, conversationID int32) ([]db.ListDmConversationParticipantsRow, error)
+	ListParticipantsForUser(ctx context.Context, userID int32) ([]db.ListDmParticipantsForUserRow, error)
+	ListSharedConversationIDs(ctx context.Context, userID, otherUserID int32) ([]int32, error)
+	MarkRead(ctx context.Context, conversationID, userID, messageID int32) (bool, error)
+	ListReadStates(ctx context.Context, conversationID int32) ([]db.ListDmReadStatesRow, error)
+	CountParticipants(ctx context.Context, conversationID int32) (int64, error)
 	ListVisibleDmConversationsForUser(ctx context.Context, userID int32) ([]db.ListVisibleDmConversationsForUserRow, error)
 	HideDmConversationForUser(ctx context.Context, conversationID, userID int32) error
 	UnhideDmConversationForUser(ctx context.Context, conversationID, userID int32) error
-	ListDmMessagesByConversation(ctx context.Context, conversationID, limit, offset int32) ([]db.ListDmMessagesByConversationRow, error)
-	CreateDmMessage(ctx context.Context, conversationID, userID int32, content string) (db.DmMessage, error)
+	ListDmMessagesByConversation(ctx context.Context, conversationID, deviceID, limit, offset int32) ([]db.ListDmMessagesByConversationRow, error)
+	CreateDmMessage(ctx context.Context, conversationID, userID int32, content string) (db.CreateDmMessageRow, error)
+	CreateEncryptedDmMessage(ctx context.Context, conversationID, userID, senderDeviceID int32, envelopes []dtos.DmEnvelopeDto) (db.CreateEncryptedDmMessageRow, error)
+	EnableEncryption(ctx context.Context, conversationID int32) (bool, error)
+	ListDeviceOwners(ctx context.Context, deviceIDs []int32) ([]db.ListDeviceOwnersRow, error)
+	CreateDmSystemMessage(ctx context.Context, conversationID, actorID, targetUserID int32, messageType, content string) (db.CreateDmSystemMessageRow, error)
 }
 
 type repository struct {
@@ -75,7 +95,148 @@ func (r *repository) CreateConversationWithParticipants(ctx context.Context, fir
 	return conversation, nil
 }
 
-func (r *repository) GetDmConversationForUser(ctx context.Context, conversationID, userID int32) (db.GetDmConversationForUserRow, error) {
+// CreateRequestConversation opens a one-to-one conversation as a pending
+// message request from requesterID.
+func (r *repository) CreateRequestConversation(ctx context.Context, requesterID, recipientID int32) (db.DmConversation, error) {
+	tx, err := r.pool.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return db.DmConversation{}, err
+	}
+	defer tx.Rollback(ctx)
+
+	queries := db.New(tx)
+	conversation, err := queries.CreateDmRequestConversation(ctx, pgtype.Int4{Int32: requesterID, Valid: true})
+	if err != nil {
+		return db.DmConversation{}, err
+	}
+
+	for _, userID := range []int32{requesterID, recipientID} {
+		if _, err := queries.AddDmConversationParticipant(ctx, db.AddDmConversationParticipantParams{
+			ConversationID: conversation.ID,
+			UserID:         userID,
+		}); err != nil {
+			return db.DmConversation{}, err
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return db.DmConversation{}, err
+	}
+	return conversation, nil
+}
+
+func (r *repository) SetRequestStatus(ctx context.Context, conversationID int32, status string) error {
+	return r.db.SetDmConversationRequestStatus(ctx, db.SetDmConversationRequestStatusParams{
+		ID:            conversationID,
+		RequestStatus: pgtype.Text{String: status, Valid: true},
+	})
+}
+
+// CreateGroupConversation creates the group and adds the owner and members
+// in one transaction.
+func (r *repository) CreateGroupConversation(ctx context.Context, ownerID int32, name, iconURL string, memberIDs []int32) (db.DmConversation, error) {
+	tx, err := r.pool.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return db.DmConversation{}, err
+	}
+	defer tx.Rollback(ctx)
+
+	queries := db.New(tx)
+	conversation, err := queries.CreateGroupDmConversation(ctx, db.CreateGroupDmConversationParams{
+		Name:    pgtype.Text{String: name, Valid: name != ""},
+		IconUrl: pgtype.Text{String: iconURL, Valid: iconURL != ""},
+		OwnerID: pgtype.Int4{Int32: ownerID, Valid: true},
+	})
+	if err != nil {
+		return db.DmConversation{}, err
+	}
+
+	for _, userID := range append([]int32{ownerID}, memberIDs...) {
+		if _, err := queries.AddDmConversationParticipant(ctx, db.AddDmConversationParticipantParams{
+			ConversationID: conversation.ID,
+			UserID:         userID,
+		}); err != nil {
+			return db.DmConversation{}, err
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return db.DmConversation{}, err
+	}
+	return conversation, nil
+}
+
+func (r *repository) UpdateGroupConversation(ctx context.Context, conversationID int32, name, iconURL string) (db.DmConversation, error) {
+	return r.db.UpdateGroupDmConversation(ctx, db.UpdateGroupDmConversationParams{
+		ID:      conversationID,
+		Name:    pgtype.Text{String: name, Valid: name != ""},
+		IconUrl: pgtype.Text{String: iconURL, Valid: iconURL != ""},
+	})
+}
+
+// AddParticipant adds userID unless the conversation already has maxMembers
+// members. The conversation row stays locked between the count and the
+// insert, so concurrent adds cannot both take the last place.
+func (r *repository) AddParticipant(ctx context.Context, conversationID, userID int32, maxMembers int) (bool, error) {
+	tx, err := r.pool.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback(ctx)
+
+	queries := db.New(tx)
+	if err := queries.LockDmConversation(ctx, conversationID); err != nil {
+		return false, err
+	}
+	count, err := queries.CountDmConversationParticipants(ctx, conversationID)
+	if err != nil {
+		return false, err
+	}
+	if count >= int64(maxMembers) {
+		return false, nil
+	}
+
+	if _, err := queries.AddDmConversationParticipant(ctx, db.AddDmConversationParticipantParams{
+		ConversationID: conversationID,
+		UserID:         userID,
+	}); err != nil {
+		return false, err
+	}
+	return true, tx.Commit(ctx)
+}
+
+func (r *repository) RemoveParticipant(ctx context.Context, conversationID, userID int32) (bool, error) {
+	removed, err := r.db.RemoveDmConversationParticipant(ctx, db.RemoveDmConversationParticipantParams{
+		ConversationID: conversationID,
+		UserID:         userID,
+	})
+	return removed > 0, err
+}
+
+func (r *repository) SetGroupOwner(ctx context.Context, conversationID, ownerID int32) error {
+	return r.db.SetGroupDmOwner(ctx, db.SetGroupDmOwnerParams{
+		ID:      conversationID,
+		OwnerID: pgtype.Int4{Int32: ownerID, Valid: true},
+	})
+}
+
+func (r *repository) DeleteConversation(ctx context.Context, conversationID int32) error {
+	return r.db.DeleteDmConversation(ctx, conversationID)
+}
+
+func (r *repository) ListParticipants(ctx context.Context, conversationID int32) ([]db.ListDmConversationParticipantsRow, error) {
+	return r.db.ListDmConversationParticipants(ctx, conversationID)
+}
+
+func (r *repository) ListParticipantsForUser(ctx context.Context, userID int32) ([]db.ListDmParticipantsForUserRow, error) {
+	return r.db.ListDmParticipantsForUser(ctx, userID)
+}
+
+func (r *repository) CountParticipants(ctx context.Context, conversationID int32) (int64, error) {
+	return r.db.CountDmConversationParticipants(ctx, conversationID)
+}
+
+func (r *repository) GetDmConversationForUser(ctx context.Context, conversationID, userID int32) (db.DmConversation, error) {
 	return r.db.GetDmConversationForUser(ctx, db.GetDmConversationForUserParams{
 		ID:     conversationID,
 		UserID: userID,
@@ -93,6 +254,15 @@ func (r *repository) ListVisibleDmConversationsForUser(ctx context.Context, user
 	return r.db.ListVisibleDmConversationsForUser(ctx, userID)
 }
 
+// ListSharedConversationIDs returns every conversation, one-to-one or group,
+// that both users take part in.
+func (r *repository) ListSharedConversationIDs(ctx context.Context, userID, otherUserID int32) ([]int32, error) {
+	return r.db.ListSharedDmConversationIDs(ctx, db.ListSharedDmConversationIDsParams{
+		UserID:   userID,
+		UserID_2: otherUserID,
+	})
+}
+
 func (r *repository) HideDmConversationForUser(ctx context.Context, conversationID, userID int32) error {
 	_, err := r.db.HideDmConversationForUser(ctx, db.HideDmConversationForUserParams{
 		ConversationID: conversationID,
@@ -109,18 +279,88 @@ func (r *repository) UnhideDmConversationForUser(ctx context.Context, conversati
 	return err
 }
 
-func (r *repository) ListDmMessagesByConversation(ctx context.Context, conversationID, limit, offset int32) ([]db.ListDmMessagesByConversationRow, error) {
+// ListDmMessagesByConversation includes each message's envelope for
+// deviceID; pass 0 to skip envelopes.
+func (r *repository) ListDmMessagesByConversation(ctx context.Context, conversationID, deviceID, limit, offset int32) ([]db.ListDmMessagesByConversationRow, error) {
 	return r.db.ListDmMessagesByConversation(ctx, db.ListDmMessagesByConversationParams{
+		DeviceID:       pgtype.Int4{Int32: deviceID, Valid: deviceID != 0},
 		ConversationID: conversationID,
-		Limit:          limit,
-		Offset:         offset,
+		PageLimit:      limit,
+		PageOffset:     offset,
 	})
 }
 
-func (r *repository) CreateDmMessage(ctx context.Context, conversationID, userID int32, content string) (db.DmMessage, error) {
+func (r *repository) CreateDmMessage(ctx context.Context, conversationID, userID int32, content string) (db.CreateDmMessageRow, error) {
 	return r.db.CreateDmMessage(ctx, db.CreateDmMessageParams{
 		ConversationID: conversationID,
 		UserID:         userID,
 		Content:        content,
 	})
 }
+
+func (r *repository) CreateDmSystemMessage(ctx context.Context, conversationID, actorID, targetUserID int32, messageType, content string) (db.CreateDmSystemMessageRow, error) {
+	return r.db.CreateDmSystemMessage(ctx, db.CreateDmSystemMessageParams{
+		ConversationID: conversationID,
+		UserID:         actorID,
+		Content:        content,
+		Type:           messageType,
+		TargetUserID:   pgtype.Int4{Int32: targetUserID, Valid: targetUserID != 0},
+	})
+}
+
+func (r *repository) MarkRead(ctx context.Context, conversationID, userID, messageID int32) (bool, error) {
+	rows, err := r.db.MarkDmConversationRead(ctx, db.MarkDmConversationReadParams{
+		MessageID:      messageID,
+		ConversationID: conversationID,
+		UserID:         userID,
+	})
+	return rows > 0, err
+}
+
+func (r *repository) ListReadStates(ctx context.Context, conversationID int32) ([]db.ListDmReadStatesRow, error) {
+	return r.db.ListDmReadStates(ctx, conversationID)
+}
+
+// CreateEncryptedDmMessage stores an empty-content message and its
+// per-device envelopes in one transaction.
+func (r *repository) CreateEncryptedDmMessage(ctx context.Context, conversationID, userID, senderDeviceID int32, envelopes []dtos.DmEnvelopeDto) (db.CreateEncryptedDmMessageRow, error) {
+	tx, err := r.pool.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return db.CreateEncryptedDmMessageRow{}, err
+	}
+	defer tx.Rollback(ctx)
+
+	queries := db.New(tx)
+	message, err := queries.CreateEncryptedDmMessage(ctx, db.CreateEncryptedDmMessageParams{
+		ConversationID: conversationID,
+		UserID:         userID,
+		SenderDeviceID: pgtype.Int4{Int32: senderDeviceID, Valid: true},
+	})
+	if err != nil {
+		return db.CreateEncryptedDmMessageRow{}, err
+	}
+
+	for _, envelope := range envelopes {
+		if err := queries.AddDmMessageEnvelope(ctx, db.AddDmMessageEnvelopeParams{
+			MessageID:  message.ID,
+			DeviceID:   envelope.DeviceID,
+			Ciphertext: envelope.Ciphertext,
+		}); err != nil {
+			return db.CreateEncryptedDmMessageRow{}, err
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return db.CreateEncryptedDmMessageRow{}, err
+	}
+	return message, nil
+}
+
+func (r *repository) EnableEncryption(ctx context.Context, conversationID int32) (bool, error) {
+	rows, err := r.db.EnableDmConversationEncryption(ctx, conversationID)
+	return rows > 0, err
+}
+
+func (r *repository) ListDeviceOwners(ctx context.Context, deviceIDs []int32) ([]db.ListDeviceOwnersRow, error) {
+	return r.db.ListDeviceOwners(ctx, deviceIDs)
+}
@@ -0,0 +1,118 @@
+package dms
+
+import (
+	"context"
+	"errors"
+
+	"github.com/andrelcunha/Concord/backend/internal/blocks"
+	"github.com/andrelcunha/Concord/backend/pkg/dtos"
+)
+
+// ListMessageRequests returns pending requests other users opened with
+// userID. Ignored requests are not listed but can still be accepted.
+func (s *Service) ListMessageRequests(ctx context.Context, userID int32) ([]dtos.DmConversationDto, error) {
+	conversations, err := s.listConversations(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	requests := make([]dtos.DmConversationDto, 0)
+	for _, conversation := range conversations {
+		if isIncomingRequest(conversation, userID) && conversation.RequestStatus == RequestStatusPending {
+			requests = append(requests, conversation)
+		}
+	}
+	return requests, nil
+}
+
+// AcceptRequest moves the conversation into the recipient's regular list.
+func (s *Service) AcceptRequest(ctx context.Context, userID, conversationID int32) (dtos.DmConversationDto, error) {
+	if _, err := s.incomingRequest(ctx, userID, conversationID); err != nil {
+		return dtos.DmConversationDto{}, err
+	}
+	if err := s.repo.SetRequestStatus(ctx, conversationID, RequestStatusAccepted); err != nil {
+		return dtos.DmConversationDto{}, err
+	}
+	if err := s.repo.UnhideDmConversationForUser(ctx, conversationID, userID); err != nil {
+		return dtos.DmConversationDto{}, err
+	}
+	return s.GetConversation(ctx, userID, conversationID)
+}
+
+// IgnoreRequest drops the request from the inbox without telling the sender.
+func (s *Service) IgnoreRequest(ctx context.Context, userID, conversationID int32) error {
+	if _, err := s.incomingRequest(ctx, userID, conversationID); err != nil {
+		return err
+	}
+	return s.repo.SetRequestStatus(ctx, conversationID, RequestStatusIgnored)
+}
+
+// BlockRequester ignores the request and blocks its sender, which also
+// closes the conversation for both of them.
+func (s *Service) BlockRequester(ctx context.Context, userID, conversationID int32) error {
+	request, err := s.incomingRequest(ctx, userID, conversationID)
+	if err != nil {
+		return err
+	}
+	if err := s.repo.SetRequestStatus(ctx, conversationID, RequestStatusIgnored); err != nil {
+		return err
+	}
+	if err := s.repo.HideDmConversationForUser(ctx, conversationID, userID); err != nil {
+		return err
+	}
+	if _, err := s.blocker.BlockUser(ctx, userID, request.RequesterID); err != nil && !errors.Is(err, blocks.ErrAlreadyBlocked) {
+		return err
+	}
+	return nil
+}
+
+func (s *Service) incomingRequest(ctx context.Context, userID, conversationID int32) (dtos.DmConversationDto, error) {
+	conversation, err := s.GetConversation(ctx, userID, conversationID)
+	if err != nil {
+		return dtos.DmConversationDto{}, err
+	}
+	if !isIncomingRequest(conversation, userID) {
+		return dtos.DmConversationDto{}, ErrNotMessageRequest
+	}
+	return conversation, nil
+}
+
+// isIncomingRequest reports whether someone else opened the conversation as a
+// message request that userID has not accepted yet.
+func isIncomingRequest(conversation dtos.DmConversationDto, userID int32) bool {
+	switch conversation.RequestStatus {
+	case RequestStatusPending, RequestStatusIgnored:
+		return conversation.RequesterID != userID
+	default:
+		return false
+	}
+}
+
+// isOutgoingRequest reports whether userID opened the conversation as a
+// message request the recipient has not accepted yet.
+func isOutgoingRequest(conversation dtos.DmConversationDto, userID int32) bool {
+	switch conversation.RequestStatus {
+	case RequestStatusPending, RequestStatusIgnored:
+		return conversation.RequesterID == userID
+	default:
+		return false
+	}
+}
+
+// canFollowUpRequest reports whether the requester may add to their own
+// request. An ignored request takes no more messages, and a pending one
+// only while the recipient's DM policy still allows it or the two became
+// friends. The sender gets the same error either way, so ignoring stays
+// silent.
+func (s *Service) canFollowUpRequest(ctx context.Context, conversation dtos.DmConversationDto, userID int32) bool {
+	if conversation.RequestStatus == RequestStatusIgnored || conversation.OtherUser == nil {
+		return false
+	}
+	otherUserID := conversation.OtherUser.UserID
+	low, high := normalizePair(userID, otherUserID)
+	friendship, err := s.friendshipRepo.GetFriendshipByUsers(ctx, low, high)
+	if err == nil && friendship.Status == "accepted" {
+		return true
+	}
+	return s.canRequest(ctx, userID, otherUserID)
+}
@@ -5,28 +5,93 @@ import (
 	"errors"
 	"fmt"
 	"log"
+	"slices"
 
+	"github.com/andrelcunha/Concord/backend/internal/annotations"
 	"github.com/andrelcunha/Concord/backend/internal/blocks"
+	"github.com/andrelcunha/Concord/backend/internal/db"
 	"github.com/andrelcunha/Concord/backend/internal/friendships"
+	"github.com/andrelcunha/Concord/backend/internal/presence"
+	"github.com/andrelcunha/Concord/backend/internal/privacy"
+	"github.com/andrelcunha/Concord/backend/internal/servers"
 	"github.com/andrelcunha/Concord/backend/pkg/dtos"
 	"github.com/redis/go-redis/v9"
 )
 
+// MaxGroupMembers includes the owner.
+const MaxGroupMembers = 10
+
+// Message types. System messages record membership changes in groups.
+const (
+	MessageTypeDefault           = "default"
+	MessageTypeMemberAdded       = "member_added"
+	MessageTypeMemberRemoved     = "member_removed"
+	MessageTypeMemberLeft        = "member_left"
+	MessageTypeGroupUpdated      = "group_updated"
+	MessageTypeEncryptionEnabled = "encryption_enabled"
+)
+
+// Message request states. Conversations opened by friends have none.
+const (
+	RequestStatusPending  = "pending"
+	RequestStatusAccepted = "accepted"
+	RequestStatusIgnored  = "ignored"
+)
+
 var (
-	ErrDmForbidden             = errors.New("you do not have access to this direct message")
-	ErrDmRequiresFriendship    = errors.New("starting a new direct message requires an accepted friendship")
-	ErrDmBlockedRelationship   = errors.New("direct message is blocked")
+	ErrDmForbidden              = errors.New("you do not have access to this direct message")
+	ErrDmRequiresFriendship     = errors.New("starting a new direct message requires an accepted friendship unless the recipient's privacy settings allow it")
+	ErrNotMessageRequest        = errors.New("this conversation is not a message request to you")
+	ErrEncryptedConversation    = errors.New("this conversation is end-to-end encrypted; send envelopes instead of content")
+	ErrNotEncryptedConversation = errors.New("envelopes can only be sent to encrypted conversations")
+	ErrInvalidEnvelopes         = errors.New("envelopes must address participants' devices with base64 ciphertext")
+	ErrInvalidDevice            = errors.New("device not found")
+	ErrDmBlockedRelationship    = errors.New("direct message is blocked")
+	ErrNotGroupConversation     = errors.New("this is not a group conversation")
+	ErrNotGroupOwner            = errors.New("only the group owner can do this")
+	ErrGroupFull                = fmt.Errorf("a group can have at most %d members", MaxGroupMembers)
+	ErrGroupTooSmall            = errors.New("a group needs at least one other member")
+	ErrAlreadyGroupMember       = errors.New("user is already in this group")
+	ErrNotGroupMember           = errors.New("user is not in this group")
+	ErrCannotRemoveOwner        = errors.New("the owner leaves the group instead of removing themselves")
+	ErrInvalidGroupName         = errors.New("group name must be at most 100 characters")
+	ErrInvalidGroupIconURL      = errors.New("icon_url must be an http or https URL")
 )
 
 type Service struct {
 	repo           Repository
 	friendshipRepo friendships.Repository
 	blockRepo      blocks.Repository
+	serversRepo    servers.Repository
+	privacy        *privacy.Service
+	annotations    *annotations.Service
+	presence       *presence.Service
 	redis          *redis.Client
+	blocker        Blocker
+}
+
+// Blocker creates blocks with all their side effects. blocks.Service
+// implements it; it is set after construction because it also listens to
+// this service.
+type Blocker interface {
+	BlockUser(ctx context.Context, blockerID, blockedID int32) (dtos.BlockDto, error)
+}
+
+func NewService(repo Repository, friendshipRepo friendships.Repository, blockRepo blocks.Repository, serversRepo servers.Repository, privacyService *privacy.Service, annotationsService *annotations.Service, presenceService *presence.Service, redis *redis.Client) *Service {
+	return &Service{
+		repo:           repo,
+		friendshipRepo: friendshipRepo,
+		blockRepo:      blockRepo,
+		serversRepo:    serversRepo,
+		privacy:        privacyService,
+		annotations:    annotationsService,
+		presence:       presenceService,
+		redis:          redis,
+	}
 }
 
-func NewService(repo Repository, friendshipRepo friendships.Repository, blockRepo blocks.Repository, redis *redis.Client) *Service {
-	return &Service{repo: repo, friendshipRepo: friendshipRepo, blockRepo: blockRepo, redis: redis}
+func (s *Service) SetBlocker(blocker Blocker) {
+	s.blocker = blocker
 }
 
 func normalizePair(a, b int32) (int32, int32) {
@@ -46,29 +111,73 @@ func (s *Service) isBlocked(ctx context.Context, userID, otherUserID int32) bool
 	return false
 }
 
+// ListConversations lists the user's visible conversations, leaving out
+// message requests they have not accepted.
 func (s *Service) ListConversations(ctx context.Context, userID int32) ([]dtos.DmConversationDto, error) {
+	all, err := s.listConversations(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	conversations := make([]dtos.DmConversationDto, 0, len(all))
+	for _, conversation := range all {
+		if !isIncomingRequest(conversation, userID) {
+			conversations = append(conversations, conversation)
+		}
+	}
+	return conversations, nil
+}
+
+func (s *Service) listConversations(ctx context.Context, userID int32) ([]dtos.DmConversationDto, error) {
 	rows, err := s.repo.ListVisibleDmConversationsForUser(ctx, userID)
 	if err != nil {
 		return nil, err
 	}
+	participantRows, err := s.repo.ListParticipantsForUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	notes, err := s.annotations.ForOwner(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	participantIDs := make([]int32, 0, len(participantRows))
+	for _, row := range participantRows {
+		participantIDs = append(participantIDs, row.UserID)
+	}
+	statuses, err := s.presence.ForViewer(ctx, userID, participantIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	participants := map[int32][]dtos.UserSummaryDto{}
+	for _, row := range participantRows {
+		participant := userSummary(row.UserID, row.Username, row.DisplayName.String, row.AvatarUrl.String, row.AvatarColor.String)
+		notes.Apply(&participant)
+		statuses.Apply(&participant)
+		participants[row.ConversationID] = append(participants[row.ConversationID], participant)
+	}
 
 	conversations := make([]dtos.DmConversationDto, 0, len(rows))
 	for _, row := range rows {
-		if s.isBlocked(ctx, userID, row.OtherUserID) {
+		dto := conversationDto(db.DmConversation{
+			ID:            row.ID,
+			CreatedAt:     row.CreatedAt,
+			IsGroup:       row.IsGroup,
+			Name:          row.Name,
+			IconUrl:       row.IconUrl,
+			OwnerID:       row.OwnerID,
+			RequesterID:   row.RequesterID,
+			RequestStatus: row.RequestStatus,
+		}, participants[row.ID], userID)
+
+		// A block hides a one-to-one conversation; group members stay visible
+		// and only each other's messages are hidden.
+		if dto.OtherUser != nil && s.isBlocked(ctx, userID, dto.OtherUser.UserID) {
 			continue
 		}
 
-		dto := dtos.DmConversationDto{
-			ID:        row.ID,
-			CreatedAt: row.CreatedAt.Time.Format("2006-01-02T15:04:05Z07:00"),
-			OtherUser: dtos.UserSummaryDto{
-				UserID:      row.OtherUserID,
-				Username:    row.OtherUsername,
-				AvatarURL:   row.OtherAvatarUrl.String,
-				AvatarColor: row.OtherAvatarColor.String,
-			},
-			LastMessage: row.LastMessageContent,
-		}
+		dto.LastMessage = row.LastMessageContent
 		if row.LastMessageCreatedAt.Valid {
 			dto.LastMessageAt = row.LastMessageCreatedAt.Time.Format("2006-01-02T15:04:05Z07:00")
 		}
@@ -93,36 +202,88 @@ func (s *Service) CreateOrGetConversation(ctx context.Context, userID, otherUser
 
 	low, high := normalizePair(userID, otherUserID)
 	friendship, err := s.friendshipRepo.GetFriendshipByUsers(ctx, low, high)
-	if err != nil || friendship.Status != "accepted" {
-		return dtos.DmConversationDto{}, ErrDmRequiresFriendship
+	if err == nil && friendship.Status == "accepted" {
+		conversation, err = s.repo.CreateConversationWithParticipants(ctx, userID, otherUserID)
+	} else {
+		if !s.canRequest(ctx, userID, otherUserID) {
+			return dtos.DmConversationDto{}, ErrDmRequiresFriendship
+		}
+		conversation, err = s.repo.CreateRequestConversation(ctx, userID, otherUserID)
 	}
-
-	conversation, err = s.repo.CreateConversationWithParticipants(ctx, userID, otherUserID)
 	if err != nil {
 		return dtos.DmConversationDto{}, err
 	}
 	return s.GetConversation(ctx, userID, conversation.ID)
 }
 
+// canRequest reports whether a non-friend may open a message request, as
+// allowed by the recipient's DM policy.
+func (s *Service) canRequest(ctx context.Context, userID, otherUserID int32) bool {
+	settings, err := s.privacy.GetSettings(ctx, otherUserID)
+	if err != nil {
+		log.Printf("GetSettings error: %v", err)
+		return false
+	}
+
+	switch settings.DmPolicy {
+	case privacy.DmPolicyEveryone:
+		return true
+	case privacy.DmPolicyServerMembers:
+		shared, err := s.serversRepo.UsersShareServer(ctx, userID, otherUserID)
+		return err == nil && shared
+	default:
+		return false
+	}
+}
+
 func (s *Service) GetConversation(ctx context.Context, userID, conversationID int32) (dtos.DmConversationDto, error) {
-	row, err := s.repo.GetDmConversationForUser(ctx, conversationID, userID)
+	conversation, participants, err := s.checkAccess(ctx, userID, conversationID)
 	if err != nil {
-		return dtos.DmConversationDto{}, ErrDmForbidden
+		return dtos.DmConversationDto{}, err
 	}
-	if s.isBlocked(ctx, userID, row.OtherUserID) {
-		return dtos.DmConversationDto{}, ErrDmBlockedRelationship
+	notes, err := s.annotations.ForOwner(ctx, userID)
+	if err != nil {
+		return dtos.DmConversationDto{}, err
 	}
+	notes.ApplyAll(participants)
+	participantIDs := make([]int32, 0, len(participants))
+	for _, participant := range participants {
+		participantIDs = append(participantIDs, participant.UserID)
+	}
+	statuses, err := s.presence.ForViewer(ctx, userID, participantIDs)
+	if err != nil {
+		return dtos.DmConversationDto{}, err
+	}
+	statuses.ApplyAll(participants)
 
-	return dtos.DmConversationDto{
-		ID:        row.ID,
-		CreatedAt: row.CreatedAt.Time.Format("2006-01-02T15:04:05Z07:00"),
-		OtherUser: dtos.UserSummaryDto{
-			UserID:      row.OtherUserID,
-			Username:    row.OtherUsername,
-			AvatarURL:   row.OtherAvatarUrl.String,
-			AvatarColor: row.OtherAvatarColor.String,
-		},
-	}, nil
+	dto := conversationDto(conversation, participants, userID)
+	dto.ReadStates, err = s.readStates(ctx, userID, conversationID)
+	if err != nil {
+		return dtos.DmConversationDto{}, err
+	}
+	return dto, nil
+}
+
+// checkAccess returns the conversation and its members if userID may read
+// it. One-to-one conversations are closed by a block either way.
+func (s *Service) checkAccess(ctx context.Context, userID, conversationID int32) (db.DmConversation, []dtos.UserSummaryDto, error) {
+	conversation, err := s.repo.GetDmConversationForUser(ctx, conversationID, userID)
+	if err != nil {
+		return db.DmConversation{}, nil, ErrDmForbidden
+	}
+	rows, err := s.repo.ListParticipants(ctx, conversationID)
+	if err != nil {
+		return db.DmConversation{}, nil, err
+	}
+
+	participants := make([]dtos.UserSummaryDto, 0, len(rows))
+	for _, row := range rows {
+		participants = append(participants, userSummary(row.UserID, row.Username, row.DisplayName.String, row.AvatarUrl.String, row.AvatarColor.String))
+		if !conversation.IsGroup && row.UserID != userID && s.isBlocked(ctx, userID, row.UserID) {
+			return db.DmConversation{}, nil, ErrDmBlockedRelationship
+		}
+	}
+	return conversation, participants, nil
 }
 
 func (s *Service) HideConversation(ctx context.Context, userID, conversationID int32) error {
@@ -132,52 +293,107 @@ func (s *Service) HideConversation(ctx context.Context, userID, conversationID i
 	return s.repo.HideDmConversationForUser(ctx, conversationID, userID)
 }
 
-func (s *Service) ListMessages(ctx context.Context, userID, conversationID, limit, offset int32) ([]dtos.DmMessageDto, error) {
-	if _, err := s.repo.GetDmConversationParticipant(ctx, conversationID, userID); err != nil {
-		return nil, ErrDmForbidden
-	}
-	conversation, err := s.repo.GetDmConversationForUser(ctx, conversationID, userID)
+// ListMessages returns a page of history. In encrypted conversations, pass
+// the reading device to get each message's envelope for it.
+func (s *Service) ListMessages(ctx context.Context, userID, conversationID, deviceID, limit, offset int32) ([]dtos.DmMessageDto, error) {
+	conversation, _, err := s.checkAccess(ctx, userID, conversationID)
 	if err != nil {
-		return nil, ErrDmForbidden
+		return nil, err
 	}
-	if s.isBlocked(ctx, userID, conversation.OtherUserID) {
-		return nil, ErrDmBlockedRelationship
+	if deviceID != 0 {
+		if err := s.checkDeviceOwner(ctx, userID, deviceID); err != nil {
+			return nil, err
+		}
+	}
+
+	var hiddenAuthorIDs []int32
+	if conversation.IsGroup {
+		hiddenAuthorIDs, err = s.blockRepo.ListBlockRelatedUserIDs(ctx, userID)
+		if err != nil {
+			return nil, err
+		}
 	}
 
-	rows, err := s.repo.ListDmMessagesByConversation(ctx, conversationID, limit, offset)
+	notes, err := s.annotations.ForOwner(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := s.repo.ListDmMessagesByConversation(ctx, conversationID, deviceID, limit, offset)
 	if err != nil {
 		return nil, err
 	}
 
 	messages := make([]dtos.DmMessageDto, 0, len(rows))
 	for _, row := range rows {
-		messages = append(messages, dtos.DmMessageDto{
+		if row.Type == MessageTypeDefault && slices.Contains(hiddenAuthorIDs, row.UserID) {
+			continue
+		}
+		message := dtos.DmMessageDto{
 			ID:             row.ID,
 			ConversationID: row.ConversationID,
 			UserID:         row.UserID,
 			Username:       row.Username.String,
+			AuthorNickname: notes.Nickname(row.UserID),
 			Content:        row.Content,
 			CreatedAt:      row.CreatedAt.Time,
 			AvatarURL:      row.AvatarUrl.String,
 			AvatarColor:    row.AvatarColor.String,
-		})
+			Type:           row.Type,
+			TargetUserID:   row.TargetUserID.Int32,
+			SenderDeviceID: row.SenderDeviceID.Int32,
+		}
+		if row.Ciphertext.Valid {
+			message.Envelopes = []dtos.DmEnvelopeDto{{DeviceID: deviceID, Ciphertext: row.Ciphertext.String}}
+		}
+		messages = append(messages, message)
 	}
 	return messages, nil
 }
 
-func (s *Service) StoreMessage(ctx context.Context, userID, conversationID int32, content string) (dtos.DmMessageDto, error) {
-	if _, err := s.repo.GetDmConversationParticipant(ctx, conversationID, userID); err != nil {
-		return dtos.DmMessageDto{}, ErrDmForbidden
-	}
-	conversation, err := s.repo.GetDmConversationForUser(ctx, conversationID, userID)
+// StoreMessage saves a message from userID. Plain conversations take
+// Content; encrypted ones take only envelopes, one per recipient device.
+func (s *Service) StoreMessage(ctx context.Context, userID, conversationID int32, outgoing OutgoingMessage) (dtos.DmMessageDto, error) {
+	conversation, participants, err := s.checkAccess(ctx, userID, conversationID)
 	if err != nil {
-		return dtos.DmMessageDto{}, ErrDmForbidden
+		return dtos.DmMessageDto{}, err
 	}
-	if s.isBlocked(ctx, userID, conversation.OtherUserID) {
-		return dtos.DmMessageDto{}, ErrDmBlockedRelationship
+	if conversation.IsEncrypted {
+		if err := s.validateEncryptedMessage(ctx, userID, participants, outgoing); err != nil {
+			return dtos.DmMessageDto{}, err
+		}
+	} else if len(outgoing.Envelopes) > 0 || outgoing.SenderDeviceID != 0 {
+		return dtos.DmMessageDto{}, ErrNotEncryptedConversation
+	}
+	// Replying to a message request accepts it. Until then the requester may
+	// only keep writing while the request is pending and still allowed.
+	dto := conversationDto(conversation, participants, userID)
+	if isIncomingRequest(dto, userID) {
+		if err := s.repo.SetRequestStatus(ctx, conversationID, RequestStatusAccepted); err != nil {
+			return dtos.DmMessageDto{}, err
+		}
+	} else if isOutgoingRequest(dto, userID) && !s.canFollowUpRequest(ctx, dto, userID) {
+		return dtos.DmMessageDto{}, ErrDmRequiresFriendship
+	}
+
+	if conversation.IsEncrypted {
+		message, err := s.repo.CreateEncryptedDmMessage(ctx, conversationID, userID, outgoing.SenderDeviceID, outgoing.Envelopes)
+		if err != nil {
+			log.Printf("CreateEncryptedDmMessage error: %v", err)
+			return dtos.DmMessageDto{}, err
+		}
+		return dtos.DmMessageDto{
+			ID:             message.ID,
+			ConversationID: message.ConversationID,
+			UserID:         message.UserID,
+			CreatedAt:      message.CreatedAt.Time,
+			Type:           message.Type,
+			SenderDeviceID: message.SenderDeviceID.Int32,
+			Envelopes:      outgoing.Envelopes,
+		}, nil
 	}
 
-	message, err := s.repo.CreateDmMessage(ctx, conversationID, userID, content)
+	message, err := s.repo.CreateDmMessage(ctx, conversationID, userID, outgoing.Content)
 	if err != nil {
 		log.Printf("CreateDmMessage error: %v", err)
 		return dtos.DmMessageDto{}, err
@@ -187,9 +403,9 @@ func (s *Service) StoreMessage(ctx context.Context, userID, conversationID int32
 		ID:             message.ID,
 		ConversationID: message.ConversationID,
 		UserID:         message.UserID,
-		Username:       conversation.OtherUsername,
 		Content:        message.Content,
 		CreatedAt:      message.CreatedAt.Time,
+		Type:           message.Type,
 	}, nil
 }
 
@@ -0,0 +1,518 @@
+package dms
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/andrelcunha/Concord/backend/internal/annotations"
+	"github.com/andrelcunha/Concord/backend/internal/blocks"
+	"github.com/andrelcunha/Concord/backend/internal/db"
+	"github.com/andrelcunha/Concord/backend/internal/friendships"
+	"github.com/andrelcunha/Concord/backend/internal/presence"
+	"github.com/andrelcunha/Concord/backend/internal/privacy"
+	"github.com/andrelcunha/Concord/backend/internal/realtime"
+	"github.com/andrelcunha/Concord/backend/internal/servers"
+	"github.com/andrelcunha/Concord/backend/pkg/dtos"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// mockRepository keeps conversations in memory. participants lists members
+// oldest first, like ListDmConversationParticipants.
+type mockRepository struct {
+	Repository
+	conversations  map[int32]db.DmConversation
+	participants   map[int32][]int32
+	deviceOwners   map[int32]int32
+	messages       []db.ListDmMessagesByConversationRow
+	systemMessages []db.CreateDmSystemMessageRow
+	deleted        []int32
+	// racingMember joins just before AddParticipant takes its count, like a
+	// concurrent add that passed the same service checks.
+	racingMember int32
+}
+
+func newMockRepository() *mockRepository {
+	return &mockRepository{
+		conversations: map[int32]db.DmConversation{},
+		participants:  map[int32][]int32{},
+		deviceOwners:  map[int32]int32{},
+	}
+}
+
+func (m *mockRepository) addGroup(conversationID, ownerID int32, memberIDs ...int32) {
+	m.conversations[conversationID] = db.DmConversation{
+		ID:      conversationID,
+		IsGroup: true,
+		OwnerID: pgtype.Int4{Int32: ownerID, Valid: true},
+	}
+	m.participants[conversationID] = append([]int32{ownerID}, memberIDs...)
+}
+
+func (m *mockRepository) GetDmConversationForUser(ctx context.Context, conversationID, userID int32) (db.DmConversation, error) {
+	conversation, ok := m.conversations[conversationID]
+	if !ok || !slices.Contains(m.participants[conversationID], userID) {
+		return db.DmConversation{}, pgx.ErrNoRows
+	}
+	return conversation, nil
+}
+
+func (m *mockRepository) ListParticipants(ctx context.Context, conversationID int32) ([]db.ListDmConversationParticipantsRow, error) {
+	var rows []db.ListDmConversationParticipantsRow
+	for _, userID := range m.participants[conversationID] {
+		rows = append(rows, db.ListDmConversationParticipantsRow{
+			ConversationID: conversationID,
+			UserID:         userID,
+			Username:       testUsernames[userID],
+			DisplayName:    pgtype.Text{String: testDisplayNames[userID], Valid: testDisplayNames[userID] != ""},
+		})
+	}
+	return rows, nil
+}
+
+func (m *mockRepository) AddParticipant(ctx context.Context, conversationID, userID int32, maxMembers int) (bool, error) {
+	if m.racingMember != 0 {
+		m.participants[conversationID] = append(m.participants[conversationID], m.racingMember)
+	}
+	if len(m.participants[conversationID]) >= maxMembers {
+		return false, nil
+	}
+	m.participants[conversationID] = append(m.participants[conversationID], userID)
+	return true, nil
+}
+
+func (m *mockRepository) RemoveParticipant(ctx context.Context, conversationID, userID int32) (bool, error) {
+	before := len(m.participants[conversationID])
+	m.participants[conversationID] = slices.DeleteFunc(m.participants[conversationID], func(id int32) bool { return id == userID })
+	return len(m.participants[conversationID]) < before, nil
+}
+
+func (m *mockRepository) SetGroupOwner(ctx context.Context, conversationID, ownerID int32) error {
+	conversation := m.conversations[conversationID]
+	conversation.OwnerID = pgtype.Int4{Int32: ownerID, Valid: true}
+	m.conversations[conversationID] = conversation
+	return nil
+}
+
+func (m *mockRepository) DeleteConversation(ctx context.Context, conversationID int32) error {
+	delete(m.conversations, conversationID)
+	m.deleted = append(m.deleted, conversationID)
+	return nil
+}
+
+func (m *mockRepository) SetRequestStatus(ctx context.Context, conversationID int32, status string) error {
+	conversation := m.conversations[conversationID]
+	conversation.RequestStatus = pgtype.Text{String: status, Valid: true}
+	m.conversations[conversationID] = conversation
+	return nil
+}
+
+func (m *mockRepository) ListReadStates(ctx context.Context, conversationID int32) ([]db.ListDmReadStatesRow, error) {
+	return nil, nil
+}
+
+func (m *mockRepository) ListDeviceOwners(ctx context.Context, deviceIDs []int32) ([]db.ListDeviceOwnersRow, error) {
+	var owners []db.ListDeviceOwnersRow
+	for _, deviceID := range deviceIDs {
+		if userID, ok := m.deviceOwners[deviceID]; ok {
+			owners = append(owners, db.ListDeviceOwnersRow{ID: deviceID, UserID: userID})
+		}
+	}
+	return owners, nil
+}
+
+func (m *mockRepository) ListDmMessagesByConversation(ctx context.Context, conversationID, deviceID, limit, offset int32) ([]db.ListDmMessagesByConversationRow, error) {
+	return m.messages, nil
+}
+
+func (m *mockRepository) CreateDmMessage(ctx context.Context, conversationID, userID int32, content string) (db.CreateDmMessageRow, error) {
+	return db.CreateDmMessageRow{ConversationID: conversationID, UserID: userID, Content: content, Type: MessageTypeDefault}, nil
+}
+
+func (m *mockRepository) CreateDmSystemMessage(ctx context.Context, conversationID, actorID, targetUserID int32, messageType, content string) (db.CreateDmSystemMessageRow, error) {
+	message := db.CreateDmSystemMessageRow{
+		ID:             int32(len(m.systemMessages) + 1),
+		ConversationID: conversationID,
+		UserID:         actorID,
+		Content:        content,
+		Type:           messageType,
+		TargetUserID:   pgtype.Int4{Int32: targetUserID, Valid: targetUserID != 0},
+	}
+	m.systemMessages = append(m.systemMessages, message)
+	return message, nil
+}
+
+func (m *mockRepository) lastSystemMessage() db.CreateDmSystemMessageRow {
+	return m.systemMessages[len(m.systemMessages)-1]
+}
+
+var testUsernames = map[int32]string{1: "alice", 2: "bob", 3: "carol", 4: "dave", 5: "erin", 6: "frank"}
+
+// testDisplayNames gives carol a display name; system messages should use it.
+var testDisplayNames = map[int32]string{3: "Carol Danvers"}
+
+type mockFriendshipRepository struct {
+	friendships.Repository
+	accepted map[[2]int32]bool
+}
+
+func (m *mockFriendshipRepository) befriend(a, b int32) {
+	low, high := normalizePair(a, b)
+	m.accepted[[2]int32{low, high}] = true
+}
+
+func (m *mockFriendshipRepository) GetFriendshipByUsers(ctx context.Context, userID, friendID int32) (db.Friendship, error) {
+	if !m.accepted[[2]int32{userID, friendID}] {
+		return db.Friendship{}, pgx.ErrNoRows
+	}
+	return db.Friendship{UserID: userID, FriendID: friendID, Status: "accepted"}, nil
+}
+
+type mockBlockRepository struct {
+	blocks.Repository
+	blocks map[[2]int32]bool
+}
+
+func (m *mockBlockRepository) GetBlock(ctx context.Context, blockerID, blockedID int32) (db.Block, error) {
+	if !m.blocks[[2]int32{blockerID, blockedID}] {
+		return db.Block{}, pgx.ErrNoRows
+	}
+	return db.Block{BlockerID: blockerID, BlockedID: blockedID}, nil
+}
+
+func (m *mockBlockRepository) ListBlockRelatedUserIDs(ctx context.Context, userID int32) ([]int32, error) {
+	var ids []int32
+	for pair := range m.blocks {
+		switch userID {
+		case pair[0]:
+			ids = append(ids, pair[1])
+		case pair[1]:
+			ids = append(ids, pair[0])
+		}
+	}
+	return ids, nil
+}
+
+type mockServersRepository struct {
+	servers.Repository
+}
+
+func (m *mockServersRepository) UsersShareServer(ctx context.Context, userID, otherUserID int32) (bool, error) {
+	return false, nil
+}
+
+// mockPrivacyRepository returns the default settings for users without a
+// policy in dmPolicies.
+type mockPrivacyRepository struct {
+	privacy.Repository
+	dmPolicies map[int32]string
+}
+
+func (m *mockPrivacyRepository) GetSettings(ctx context.Context, userID int32) (db.UserPrivacySetting, error) {
+	policy, ok := m.dmPolicies[userID]
+	if !ok {
+		return db.UserPrivacySetting{}, pgx.ErrNoRows
+	}
+	return db.UserPrivacySetting{UserID: userID, DmPolicy: policy, SendReadReceipts: true}, nil
+}
+
+type mockAnnotationRepository struct {
+	annotations.Repository
+}
+
+func (m *mockAnnotationRepository) ListAnnotations(ctx context.Context, ownerID int32) ([]db.UserAnnotation, error) {
+	return nil, nil
+}
+
+type mockPresenceRepository struct {
+	presence.Repository
+}
+
+func (m *mockPresenceRepository) ListVisible(ctx context.Context, viewerID int32, userIDs []int32) ([]int32, error) {
+	return nil, nil
+}
+
+type nopPublisher struct{}
+
+func (nopPublisher) PublishToUser(ctx context.Context, userID int32, event realtime.Event) {}
+
+type testDeps struct {
+	repo        *mockRepository
+	friendships *mockFriendshipRepository
+	blocks      *mockBlockRepository
+	privacy     *mockPrivacyRepository
+}
+
+func newTestService(t *testing.T) (*Service, testDeps) {
+	mr, err := miniredis.Run()
+	if err != nil {
+		t.Fatalf("failed to start miniredis: %v", err)
+	}
+	t.Cleanup(mr.Close)
+	redisClient := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+
+	deps := testDeps{
+		repo:        newMockRepository(),
+		friendships: &mockFriendshipRepository{accepted: map[[2]int32]bool{}},
+		blocks:      &mockBlockRepository{blocks: map[[2]int32]bool{}},
+		privacy:     &mockPrivacyRepository{dmPolicies: map[int32]string{}},
+	}
+	service := NewService(
+		deps.repo,
+		deps.friendships,
+		deps.blocks,
+		&mockServersRepository{},
+		privacy.NewService(deps.privacy),
+		annotations.NewService(&mockAnnotationRepository{}),
+		presence.NewService(&mockPresenceRepository{}, redisClient, nopPublisher{}),
+		redisClient,
+	)
+	return service, deps
+}
+
+func TestAddMember(t *testing.T) {
+	ctx := context.Background()
+	service, deps := newTestService(t)
+	deps.repo.addGroup(10, 1, 2)
+	deps.friendships.befriend(1, 2)
+	deps.friendships.befriend(1, 3)
+	deps.friendships.befriend(1, 4)
+	deps.blocks.blocks[[2]int32{4, 1}] = true
+
+	_, err := service.AddMember(ctx, 2, 10, 3)
+	assert.Equal(t, ErrNotGroupOwner, err)
+
+	conversation, err := service.AddMember(ctx, 1, 10, 3)
+	require.NoError(t, err)
+	assert.Len(t, conversation.Participants, 3)
+	added := deps.repo.lastSystemMessage()
+	assert.Equal(t, MessageTypeMemberAdded, added.Type)
+	assert.Equal(t, int32(3), added.TargetUserID.Int32)
+	assert.Equal(t, "alice added Carol Danvers to the group.", added.Content)
+
+	_, err = service.AddMember(ctx, 1, 10, 3)
+	assert.Equal(t, ErrAlreadyGroupMember, err)
+	_, err = service.AddMember(ctx, 1, 10, 4)
+	assert.Equal(t, ErrDmBlockedRelationship, err)
+	_, err = service.AddMember(ctx, 1, 10, 5)
+	assert.Equal(t, ErrDmRequiresFriendship, err)
+
+	deps.repo.addGroup(20, 1, 2, 3, 4, 5, 6, 7, 8, 9, 11)
+	_, err = service.AddMember(ctx, 1, 20, 12)
+	assert.Equal(t, ErrGroupFull, err)
+
+	deps.repo.addGroup(30, 1, 5, 6, 7, 8, 9, 11, 12, 13)
+	deps.repo.racingMember = 14
+	_, err = service.AddMember(ctx, 1, 30, 3)
+	assert.Equal(t, ErrGroupFull, err)
+	assert.Len(t, deps.repo.participants[30], MaxGroupMembers)
+}
+
+func TestRemoveMember(t *testing.T) {
+	ctx := context.Background()
+	service, deps := newTestService(t)
+	deps.repo.addGroup(10, 1, 2, 3)
+
+	assert.Equal(t, ErrCannotRemoveOwner, service.RemoveMember(ctx, 1, 10, 1))
+	assert.Equal(t, ErrNotGroupOwner, service.RemoveMember(ctx, 2, 10, 3))
+
+	require.NoError(t, service.RemoveMember(ctx, 1, 10, 3))
+	assert.Equal(t, []int32{1, 2}, deps.repo.participants[10])
+	removed := deps.repo.lastSystemMessage()
+	assert.Equal(t, MessageTypeMemberRemoved, removed.Type)
+	assert.Equal(t, int32(3), removed.TargetUserID.Int32)
+
+	assert.Equal(t, ErrNotGroupMember, service.RemoveMember(ctx, 1, 10, 3))
+}
+
+func TestLeaveGroupHandsOverOwnership(t *testing.T) {
+	ctx := context.Background()
+	service, deps := newTestService(t)
+	deps.repo.addGroup(10, 1, 2, 3)
+
+	// A member leaving keeps the owner.
+	require.NoError(t, service.LeaveGroup(ctx, 3, 10))
+	assert.Equal(t, int32(1), deps.repo.conversations[10].OwnerID.Int32)
+	assert.Equal(t, "Carol Danvers left the group.", deps.repo.lastSystemMessage().Content)
+
+	// The owner leaving hands the group to the longest-standing member.
+	require.NoError(t, service.LeaveGroup(ctx, 1, 10))
+	assert.Equal(t, int32(2), deps.repo.conversations[10].OwnerID.Int32)
+	left := deps.repo.lastSystemMessage()
+	assert.Equal(t, MessageTypeMemberLeft, left.Type)
+	assert.Equal(t, "alice left the group. bob is now the owner.", left.Content)
+	assert.Equal(t, ErrDmForbidden, service.LeaveGroup(ctx, 1, 10))
+
+	// The last member leaving deletes the group.
+	require.NoError(t, service.LeaveGroup(ctx, 2, 10))
+	assert.Equal(t, []int32{10}, deps.repo.deleted)
+
+	deps.repo.conversations[30] = db.DmConversation{ID: 30}
+	deps.repo.participants[30] = []int32{1, 2}
+	assert.Equal(t, ErrNotGroupConversation, service.LeaveGroup(ctx, 1, 30))
+}
+
+func TestValidateEncryptedMessage(t *testing.T) {
+	ctx := context.Background()
+	service, deps := newTestService(t)
+	deps.repo.deviceOwners = map[int32]int32{11: 1, 12: 1, 21: 2, 31: 3}
+	participants := []dtos.UserSummaryDto{{UserID: 1}, {UserID: 2}}
+	ciphertext := "Y2lwaGVydGV4dA=="
+
+	tooMany := make([]dtos.DmEnvelopeDto, maxEnvelopes+1)
+	for i := range tooMany {
+		tooMany[i] = dtos.DmEnvelopeDto{DeviceID: int32(100 + i), Ciphertext: ciphertext}
+	}
+
+	tests := []struct {
+		name     string
+		outgoing OutgoingMessage
+		want     error
+	}{
+		{
+			name: "valid",
+			outgoing: OutgoingMessage{SenderDeviceID: 11, Envelopes: []dtos.DmEnvelopeDto{
+				{DeviceID: 11, Ciphertext: ciphertext}, {DeviceID: 12, Ciphertext: ciphertext}, {DeviceID: 21, Ciphertext: ciphertext},
+			}},
+		},
+		{
+			name:     "plaintext content",
+			outgoing: OutgoingMessage{Content: "hi", SenderDeviceID: 11, Envelopes: []dtos.DmEnvelopeDto{{DeviceID: 21, Ciphertext: ciphertext}}},
+			want:     ErrEncryptedConversation,
+		},
+		{
+			name:     "no envelopes",
+			outgoing: OutgoingMessage{SenderDeviceID: 11},
+			want:     ErrInvalidEnvelopes,
+		},
+		{
+			name:     "too many envelopes",
+			outgoing: OutgoingMessage{SenderDeviceID: 11, Envelopes: tooMany},
+			want:     ErrInvalidEnvelopes,
+		},
+		{
+			name:     "sender device belongs to someone else",
+			outgoing: OutgoingMessage{SenderDeviceID: 21, Envelopes: []dtos.DmEnvelopeDto{{DeviceID: 21, Ciphertext: ciphertext}}},
+			want:     ErrInvalidDevice,
+		},
+		{
+			name: "device addressed twice",
+			outgoing: OutgoingMessage{SenderDeviceID: 11, Envelopes: []dtos.DmEnvelopeDto{
+				{DeviceID: 21, Ciphertext: ciphertext}, {DeviceID: 21, Ciphertext: ciphertext},
+			}},
+			want: ErrInvalidEnvelopes,
+		},
+		{
+			name:     "ciphertext is not base64",
+			outgoing: OutgoingMessage{SenderDeviceID: 11, Envelopes: []dtos.DmEnvelopeDto{{DeviceID: 21, Ciphertext: "not base64!"}}},
+			want:     ErrInvalidEnvelopes,
+		},
+		{
+			name:     "ciphertext too large",
+			outgoing: OutgoingMessage{SenderDeviceID: 11, Envelopes: []dtos.DmEnvelopeDto{{DeviceID: 21, Ciphertext: strings.Repeat("A", maxCiphertextSize+4)}}},
+			want:     ErrInvalidEnvelopes,
+		},
+		{
+			name:     "unknown device",
+			outgoing: OutgoingMessage{SenderDeviceID: 11, Envelopes: []dtos.DmEnvelopeDto{{DeviceID: 99, Ciphertext: ciphertext}}},
+			want:     ErrInvalidEnvelopes,
+		},
+		{
+			name:     "device of a non-participant",
+			outgoing: OutgoingMessage{SenderDeviceID: 11, Envelopes: []dtos.DmEnvelopeDto{{DeviceID: 31, Ciphertext: ciphertext}}},
+			want:     ErrInvalidEnvelopes,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, service.validateEncryptedMessage(ctx, 1, participants, tt.outgoing))
+		})
+	}
+}
+
+func TestIsIncomingRequest(t *testing.T) {
+	tests := []struct {
+		name         string
+		conversation dtos.DmConversationDto
+		want         bool
+	}{
+		{"pending request from someone else", dtos.DmConversationDto{RequesterID: 2, RequestStatus: RequestStatusPending}, true},
+		{"ignored request from someone else", dtos.DmConversationDto{RequesterID: 2, RequestStatus: RequestStatusIgnored}, true},
+		{"accepted request", dtos.DmConversationDto{RequesterID: 2, RequestStatus: RequestStatusAccepted}, false},
+		{"own pending request", dtos.DmConversationDto{RequesterID: 1, RequestStatus: RequestStatusPending}, false},
+		{"conversation between friends", dtos.DmConversationDto{}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, isIncomingRequest(tt.conversation, 1))
+		})
+	}
+}
+
+func TestStoreMessageInMessageRequests(t *testing.T) {
+	ctx := context.Background()
+	service, deps := newTestService(t)
+	deps.privacy.dmPolicies[2] = privacy.DmPolicyEveryone
+	deps.repo.conversations[10] = db.DmConversation{
+		ID:            10,
+		RequesterID:   pgtype.Int4{Int32: 1, Valid: true},
+		RequestStatus: pgtype.Text{String: RequestStatusPending, Valid: true},
+	}
+	deps.repo.participants[10] = []int32{1, 2}
+	send := func(userID int32) error {
+		_, err := service.StoreMessage(ctx, userID, 10, OutgoingMessage{Content: "hi"})
+		return err
+	}
+
+	// The requester may follow up while the recipient's policy allows it.
+	require.NoError(t, send(1))
+	deps.privacy.dmPolicies[2] = privacy.DmPolicyFriends
+	assert.Equal(t, ErrDmRequiresFriendship, send(1))
+	deps.friendships.befriend(1, 2)
+	require.NoError(t, send(1))
+
+	// Ignoring stops follow-ups, with the same error as a policy refusal.
+	require.NoError(t, service.IgnoreRequest(ctx, 2, 10))
+	assert.Equal(t, ErrDmRequiresFriendship, send(1))
+
+	// Replying accepts the request, after which both sides can write.
+	require.NoError(t, send(2))
+	assert.Equal(t, RequestStatusAccepted, deps.repo.conversations[10].RequestStatus.String)
+	require.NoError(t, send(1))
+}
+
+func TestListMessagesHidesBlockedAuthorsInGroups(t *testing.T) {
+	ctx := context.Background()
+	service, deps := newTestService(t)
+	deps.repo.addGroup(10, 1, 2, 3)
+	deps.blocks.blocks[[2]int32{1, 2}] = true
+	deps.repo.messages = []db.ListDmMessagesByConversationRow{
+		{ID: 1, ConversationID: 10, UserID: 2, Content: "from bob", Type: MessageTypeDefault},
+		{ID: 2, ConversationID: 10, UserID: 3, Content: "from carol", Type: MessageTypeDefault},
+		{ID: 3, ConversationID: 10, UserID: 1, Content: "from alice", Type: MessageTypeDefault},
+		{ID: 4, ConversationID: 10, UserID: 2, Content: "bob left the group.", Type: MessageTypeMemberLeft},
+	}
+
+	contents := func(userID int32) []string {
+		messages, err := service.ListMessages(ctx, userID, 10, 0, 50, 0)
+		require.NoError(t, err)
+		var contents []string
+		for _, message := range messages {
+			contents = append(contents, message.Content)
+		}
+		return contents
+	}
+
+	// The block hides the pair's messages from each other only.
+	assert.Equal(t, []string{"from carol", "from alice", "bob left the group."}, contents(1))
+	assert.Equal(t, []string{"from bob", "from carol", "bob left the group."}, contents(2))
+	assert.Equal(t, []string{"from bob", "from carol", "from alice", "bob left the group."}, contents(3))
+}
@@ -5,42 +5,105 @@ import (
 	"encoding/json"
 	"fmt"
 	"log"
+	"slices"
 	"strconv"
 	"sync"
 
+	"github.com/andrelcunha/Concord/backend/internal/ratelimit"
+	"github.com/andrelcunha/Concord/backend/pkg/dtos"
 	"github.com/gofiber/fiber/v2"
 	ws "github.com/gofiber/websocket/v2"
 	"github.com/redis/go-redis/v9"
 )
 
 type WebSocketHandler struct {
-	service   *Service
-	Clients   map[string]map[*ws.Conn]bool
-	ClientsMu sync.RWMutex
-	PubSubs   map[string]*redis.PubSub
-	PubSubsMu sync.RWMutex
+	service     *Service
+	limiter     *ratelimit.Limiter
+	messageRule ratelimit.Rule
+	Clients     map[string]map[*ws.Conn]*dmClient
+	ClientsMu   sync.RWMutex
+	PubSubs     map[string]*redis.PubSub
+	PubSubsMu   sync.RWMutex
 }
 
+// dmClient is a connected member. hiddenUserIDs is a snapshot of the users
+// they have a block with, taken when the socket opens and extended by block
+// events; in groups, messages from those users are not delivered.
+type dmClient struct {
+	userID        int32
+	isGroup       bool
+	hiddenUserIDs []int32
+	// writeMu serializes writes: the read loop and the pubsub fan-out both
+	// write to the connection, and it allows one writer at a time.
+	writeMu sync.Mutex
+}
+
+func (c *dmClient) write(conn *ws.Conn, payload []byte) {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	if err := conn.WriteMessage(ws.TextMessage, payload); err != nil {
+		log.Printf("DM write error: %v", err)
+	}
+}
+
+// hides reports whether message comes from a user the client has a block
+// with. Only messages and read events are hidden; system messages about
+// membership still reach everyone.
+func (c *dmClient) hides(message dmWSResponse) bool {
+	hideable := message.Type == MessageTypeDefault || message.Type == EventTypeRead
+	return hideable && slices.Contains(c.hiddenUserIDs, message.UserID)
+}
+
+// dmWSMessage is a frame from the client: a message to send, or a "read"
+// frame carrying the newest message the user has seen. Encrypted
+// conversations send envelopes instead of content.
 type dmWSMessage struct {
-	Content string `json:"content"`
+	Type           string               `json:"type"`
+	Content        string               `json:"content"`
+	MessageID      int32                `json:"message_id"`
+	SenderDeviceID int32                `json:"sender_device_id"`
+	Envelopes      []dtos.DmEnvelopeDto `json:"envelopes"`
 }
 
 type dmWSResponse struct {
-	ID             int32  `json:"id"`
-	ConversationID int32  `json:"conversation_id"`
-	UserID         int32  `json:"user_id"`
-	Content        string `json:"content"`
-	Username       string `json:"username"`
-	CreatedAt      string `json:"created_at"`
-	AvatarURL      string `json:"avatar_url"`
-	AvatarColor    string `json:"avatar_color"`
+	ID             int32                `json:"id"`
+	ConversationID int32                `json:"conversation_id"`
+	UserID         int32                `json:"user_id"`
+	Content        string               `json:"content"`
+	Username       string               `json:"username"`
+	CreatedAt      string               `json:"created_at"`
+	AvatarURL      string               `json:"avatar_url"`
+	AvatarColor    string               `json:"avatar_color"`
+	Type           string               `json:"type"`
+	TargetUserID   int32                `json:"target_user_id,omitempty"`
+	SenderDeviceID int32                `json:"sender_device_id,omitempty"`
+	Envelopes      []dtos.DmEnvelopeDto `json:"envelopes,omitempty"`
 }
 
-func NewWebSocketHandler(service *Service) *WebSocketHandler {
+func newDmWSResponse(message dtos.DmMessageDto) dmWSResponse {
+	return dmWSResponse{
+		ID:             message.ID,
+		ConversationID: message.ConversationID,
+		UserID:         message.UserID,
+		Content:        message.Content,
+		Username:       message.Username,
+		CreatedAt:      message.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
+		AvatarURL:      message.AvatarURL,
+		AvatarColor:    message.AvatarColor,
+		Type:           message.Type,
+		TargetUserID:   message.TargetUserID,
+		SenderDeviceID: message.SenderDeviceID,
+		Envelopes:      message.Envelopes,
+	}
+}
+
+func NewWebSocketHandler(service *Service, limiter *ratelimit.Limiter, messageRule ratelimit.Rule) *WebSocketHandler {
 	return &WebSocketHandler{
-		service: service,
-		Clients: make(map[string]map[*ws.Conn]bool),
-		PubSubs: make(map[string]*redis.PubSub),
+		service:     service,
+		limiter:     limiter,
+		messageRule: messageRule,
+		Clients:     make(map[string]map[*ws.Conn]*dmClient),
+		PubSubs:     make(map[string]*redis.PubSub),
 	}
 }
 
@@ -67,14 +130,22 @@ func (h *WebSocketHandler) HandleConnection(c *fiber.Ctx) error {
 	avatarURL, _ := c.Locals("avatar_url").(string)
 	avatarColor, _ := c.Locals("avatar_color").(string)
 
-	if _, err := h.service.repo.GetDmConversationParticipant(c.Context(), int32(conversationID), userID); err != nil {
-		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": ErrDmForbidden.Error()})
+	conversation, _, err := h.service.checkAccess(c.Context(), userID, int32(conversationID))
+	if err != nil {
+		return dmErrorResponse(c, err)
+	}
+	client := &dmClient{userID: userID, isGroup: conversation.IsGroup}
+	if conversation.IsGroup {
+		client.hiddenUserIDs, err = h.service.blockRepo.ListBlockRelatedUserIDs(c.Context(), userID)
+		if err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+		}
 	}
 
 	return ws.New(func(conn *ws.Conn) {
 		key := fmt.Sprintf("%d", conversationID)
 
-		h.addClient(key, conn)
+		h.addClient(key, conn, client)
 		h.setupPubSub(key)
 
 		defer func() {
@@ -100,26 +171,35 @@ func (h *WebSocketHandler) HandleConnection(c *fiber.Ctx) error {
 				log.Printf("DM unmarshal error: %v", err)
 				continue
 			}
-			if wsMsg.Content == "" {
+			if wsMsg.Type == EventTypeRead {
+				if err := h.service.MarkRead(context.Background(), userID, int32(conversationID), wsMsg.MessageID); err != nil {
+					log.Printf("DM mark read error: %v", err)
+				}
+				continue
+			}
+			if wsMsg.Content == "" && len(wsMsg.Envelopes) == 0 {
 				continue
 			}
 
-			stored, err := h.service.StoreMessage(context.Background(), userID, int32(conversationID), wsMsg.Content)
+			if !h.allowMessage(conn, client) {
+				continue
+			}
+
+			stored, err := h.service.StoreMessage(context.Background(), userID, int32(conversationID), OutgoingMessage{
+				Content:        wsMsg.Content,
+				SenderDeviceID: wsMsg.SenderDeviceID,
+				Envelopes:      wsMsg.Envelopes,
+			})
 			if err != nil {
 				log.Printf("DM store error: %v", err)
+				h.writeSendError(conn, client, err)
 				continue
 			}
 
-			payload, err := json.Marshal(dmWSResponse{
-				ID:             stored.ID,
-				ConversationID: stored.ConversationID,
-				UserID:         stored.UserID,
-				Content:        stored.Content,
-				Username:       username,
-				CreatedAt:      stored.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
-				AvatarURL:      avatarURL,
-				AvatarColor:    avatarColor,
-			})
+			stored.Username = username
+			stored.AvatarURL = avatarURL
+			stored.AvatarColor = avatarColor
+			payload, err := json.Marshal(newDmWSResponse(stored))
 			if err != nil {
 				log.Printf("DM marshal error: %v", err)
 				continue
@@ -132,23 +212,102 @@ func (h *WebSocketHandler) HandleConnection(c *fiber.Ctx) error {
 	})(c)
 }
 
-func RegisterDmWebSocketRoutes(api fiber.Router, service *Service) {
-	handler := NewWebSocketHandler(service)
+func RegisterDmWebSocketRoutes(api fiber.Router, service *Service, limiter *ratelimit.Limiter, messageRule ratelimit.Rule) {
+	handler := NewWebSocketHandler(service, limiter, messageRule)
 	api.Get("/dms/ws", handler.HandleConnection)
 }
 
+// allowMessage shares the send budget with channel sockets by using the same
+// rule and per-user key.
+func (h *WebSocketHandler) allowMessage(conn *ws.Conn, client *dmClient) bool {
+	result, err := h.limiter.Allow(context.Background(), h.messageRule, fmt.Sprintf("user:%d", client.userID))
+	if err != nil {
+		log.Printf("DM rate limit error: %v", err)
+		return true
+	}
+	if result.Allowed {
+		return true
+	}
+
+	payload, _ := json.Marshal(fiber.Map{
+		"error":       "rate_limited",
+		"retry_after": int(result.RetryAfter.Seconds()) + 1,
+	})
+	client.write(conn, payload)
+	return false
+}
+
+// writeSendError tells the sender why a message was refused when the reason
+// is something the client can fix.
+func (h *WebSocketHandler) writeSendError(conn *ws.Conn, client *dmClient, err error) {
+	switch err {
+	case ErrEncryptedConversation, ErrNotEncryptedConversation, ErrInvalidEnvelopes, ErrInvalidDevice, ErrDmRequiresFriendship:
+		payload, _ := json.Marshal(fiber.Map{
+			"error":   "invalid_message",
+			"message": err.Error(),
+		})
+		client.write(conn, payload)
+	}
+}
+
 func (h *WebSocketHandler) handlePubSubMessages(pubsub *redis.PubSub, key string) {
 	for msg := range pubsub.Channel() {
+		var message dmWSResponse
+		if err := json.Unmarshal([]byte(msg.Payload), &message); err != nil {
+			log.Printf("DM unmarshal error: %v", err)
+			continue
+		}
+		if message.Type == EventTypeBlock {
+			h.applyBlock(key, message, []byte(msg.Payload))
+			continue
+		}
+		departed := message.Type == MessageTypeMemberRemoved || message.Type == MessageTypeMemberLeft
+
 		h.ClientsMu.RLock()
-		for client := range h.Clients[key] {
-			if err := client.WriteMessage(ws.TextMessage, []byte(msg.Payload)); err != nil {
-				log.Printf("DM write error: %v", err)
+		for conn, client := range h.Clients[key] {
+			if client.hides(message) {
+				continue
+			}
+			client.write(conn, []byte(msg.Payload))
+			// Closing ends the member's read loop, which unregisters the conn.
+			if departed && message.TargetUserID == client.userID {
+				conn.Close()
 			}
 		}
 		h.ClientsMu.RUnlock()
 	}
 }
 
+// applyBlock acts on a block between message.UserID and
+// message.TargetUserID. In a one-to-one conversation both users are told and
+// their sockets closed; in a group they stop seeing each other's messages and
+// nobody else is told.
+func (h *WebSocketHandler) applyBlock(key string, message dmWSResponse, payload []byte) {
+	h.ClientsMu.Lock()
+	defer h.ClientsMu.Unlock()
+
+	for conn, client := range h.Clients[key] {
+		var otherUserID int32
+		switch client.userID {
+		case message.UserID:
+			otherUserID = message.TargetUserID
+		case message.TargetUserID:
+			otherUserID = message.UserID
+		default:
+			continue
+		}
+
+		if client.isGroup {
+			if !slices.Contains(client.hiddenUserIDs, otherUserID) {
+				client.hiddenUserIDs = append(client.hiddenUserIDs, otherUserID)
+			}
+			continue
+		}
+		client.write(conn, payload)
+		conn.Close()
+	}
+}
+
 func (h *WebSocketHandler) setupPubSub(key string) {
 	h.PubSubsMu.Lock()
 	if h.PubSubs[key] == nil {
@@ -167,11 +326,11 @@ func (h *WebSocketHandler) closePubSub(key string) {
 	h.PubSubsMu.Unlock()
 }
 
-func (h *WebSocketHandler) addClient(key string, conn *ws.Conn) {
+func (h *WebSocketHandler) addClient(key string, conn *ws.Conn, client *dmClient) {
 	h.ClientsMu.Lock()
 	if h.Clients[key] == nil {
-		h.Clients[key] = make(map[*ws.Conn]bool)
+		h.Clients[key] = make(map[*ws.Conn]*dmClient)
 	}
-	h.Clients[key][conn] = true
+	h.Clients[key][conn] = client
 	h.ClientsMu.Unlock()
 }
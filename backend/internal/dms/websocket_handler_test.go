@@ -0,0 +1,36 @@
+package dms
+
+import (
+	"testing"
+
+	ws "github.com/gofiber/websocket/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGroupFanOutHidesBlockedPairsOnly(t *testing.T) {
+	alice := &dmClient{userID: 1, isGroup: true}
+	bob := &dmClient{userID: 2, isGroup: true}
+	carol := &dmClient{userID: 3, isGroup: true}
+	handler := &WebSocketHandler{Clients: map[string]map[*ws.Conn]*dmClient{
+		"10": {&ws.Conn{}: alice, &ws.Conn{}: bob, &ws.Conn{}: carol},
+	}}
+
+	handler.applyBlock("10", dmWSResponse{Type: EventTypeBlock, ConversationID: 10, UserID: 1, TargetUserID: 2}, nil)
+
+	fromAlice := dmWSResponse{Type: MessageTypeDefault, UserID: 1}
+	fromBob := dmWSResponse{Type: MessageTypeDefault, UserID: 2}
+	readByBob := dmWSResponse{Type: EventTypeRead, UserID: 2}
+	bobLeft := dmWSResponse{Type: MessageTypeMemberLeft, UserID: 2, TargetUserID: 2}
+
+	assert.True(t, alice.hides(fromBob))
+	assert.True(t, alice.hides(readByBob))
+	assert.False(t, alice.hides(bobLeft), "membership changes reach everyone")
+	assert.True(t, bob.hides(fromAlice))
+	assert.False(t, carol.hides(fromAlice))
+	assert.False(t, carol.hides(fromBob))
+
+	// A repeated block event does not duplicate the entry.
+	handler.applyBlock("10", dmWSResponse{Type: EventTypeBlock, ConversationID: 10, UserID: 2, TargetUserID: 1}, nil)
+	assert.Equal(t, []int32{2}, alice.hiddenUserIDs)
+	assert.Equal(t, []int32{1}, bob.hiddenUserIDs)
+}
@@ -0,0 +1,224 @@
+package events
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/andrelcunha/Concord/backend/internal/db"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// Delivery statuses.
+const (
+	DeliveryPending   = "pending"
+	DeliveryDelivered = "delivered"
+	DeliveryDead      = "dead"
+)
+
+// Deliveries are signed with HMAC-SHA256 over "<timestamp>.<body>" using the
+// subscription secret. Receivers should reject stale timestamps.
+const (
+	HeaderEventType  = "X-Concord-Event"
+	HeaderEventID    = "X-Concord-Event-Id"
+	HeaderDeliveryID = "X-Concord-Delivery"
+	HeaderTimestamp  = "X-Concord-Timestamp"
+	HeaderSignature  = "X-Concord-Signature"
+)
+
+const (
+	deliveryPollInterval = 2 * time.Second
+	deliveryBatchSize    = 20
+	deliveryTimeout      = 10 * time.Second
+	maxErrorLength       = 500
+)
+
+// DeliveryPolicy retries failed deliveries with exponential backoff starting
+// at BaseBackoff and capped at MaxBackoff. After MaxAttempts the delivery is
+// dead-lettered until redelivered by hand.
+type DeliveryPolicy struct {
+	MaxAttempts int
+	BaseBackoff time.Duration
+	MaxBackoff  time.Duration
+
+	// AllowPrivateNetworks permits plain http and loopback or private
+	// addresses, for local development only.
+	AllowPrivateNetworks bool
+}
+
+var DefaultDeliveryPolicy = DeliveryPolicy{
+	MaxAttempts: 8,
+	BaseBackoff: 30 * time.Second,
+	MaxBackoff:  6 * time.Hour,
+}
+
+var errPrivateAddress = errors.New("destination address is not publicly routable")
+
+// RunDeliveryWorker sends queued deliveries until ctx is cancelled. Several
+// instances can run at once; claims skip rows other workers hold.
+func (s *Service) RunDeliveryWorker(ctx context.Context) {
+	ticker := time.NewTicker(deliveryPollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.deliverBatch(ctx)
+		}
+	}
+}
+
+func (s *Service) deliverBatch(ctx context.Context) {
+	// The lease outlives the request timeout, so a claimed delivery is not
+	// picked up again while it is still in flight.
+	lease := int32((deliveryTimeout + 30*time.Second).Seconds())
+	deliveries, err := s.repo.ClaimDeliveries(ctx, deliveryBatchSize, lease)
+	if err != nil {
+		log.Printf("ClaimDeliveries error: %v", err)
+		return
+	}
+
+	var wg sync.WaitGroup
+	for _, delivery := range deliveries {
+		wg.Add(1)
+		go func(delivery db.ClaimEventDeliveriesRow) {
+			defer wg.Done()
+			s.attemptDelivery(ctx, delivery)
+		}(delivery)
+	}
+	wg.Wait()
+}
+
+func (s *Service) attemptDelivery(ctx context.Context, delivery db.ClaimEventDeliveriesRow) {
+	statusCode, err := s.send(ctx, delivery, time.Now())
+	if err == nil {
+		if err := s.repo.MarkDelivered(ctx, delivery.ID, int32(statusCode)); err != nil {
+			log.Printf("MarkDelivered error: %v", err)
+		}
+		return
+	}
+
+	status := DeliveryPending
+	if int(delivery.Attempts) >= s.policy.MaxAttempts {
+		status = DeliveryDead
+	}
+	errText := err.Error()
+	if len(errText) > maxErrorLength {
+		errText = errText[:maxErrorLength]
+	}
+
+	if err := s.repo.MarkFailed(ctx, db.MarkEventDeliveryFailedParams{
+		ID:             delivery.ID,
+		Status:         status,
+		LastStatusCode: pgtype.Int4{Int32: int32(statusCode), Valid: statusCode != 0},
+		LastError:      pgtype.Text{String: errText, Valid: true},
+		RetrySeconds:   int32(s.policy.backoff(int(delivery.Attempts)).Seconds()),
+	}); err != nil {
+		log.Printf("MarkFailed error: %v", err)
+	}
+}
+
+// send posts one signed delivery. Any non-2xx response is a failure.
+func (s *Service) send(ctx context.Context, delivery db.ClaimEventDeliveriesRow, now time.Time) (int, error) {
+	ctx, cancel := context.WithTimeout(ctx, deliveryTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, delivery.Url, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return 0, err
+	}
+	timestamp := strconv.FormatInt(now.Unix(), 10)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "Concord-Webhooks/1.0")
+	req.Header.Set(HeaderEventType, delivery.EventType)
+	req.Header.Set(HeaderEventID, delivery.EventID)
+	req.Header.Set(HeaderDeliveryID, strconv.Itoa(int(delivery.ID)))
+	req.Header.Set(HeaderTimestamp, timestamp)
+	req.Header.Set(HeaderSignature, Sign(delivery.Secret, timestamp, delivery.Payload))
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("endpoint responded with status %d", resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
+
+// Sign returns the signature header value for a payload.
+func Sign(secret, timestamp string, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(payload)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// backoff returns the wait after the given number of failed attempts.
+func (p DeliveryPolicy) backoff(attempts int) time.Duration {
+	delay := p.BaseBackoff
+	for i := 1; i < attempts && delay < p.MaxBackoff; i++ {
+		delay *= 2
+	}
+	if delay > p.MaxBackoff {
+		delay = p.MaxBackoff
+	}
+	return delay
+}
+
+// NewOutboundClient returns a client for calling user-supplied URLs. It
+// refuses to connect to loopback, private and link-local addresses unless
+// allowPrivateNetworks is set. The check runs on the resolved address, so DNS
+// cannot be used to reach internal services. Redirects are not followed.
+func NewOutboundClient(timeout time.Duration, allowPrivateNetworks bool) *http.Client {
+	dialer := &net.Dialer{Timeout: timeout}
+	if !allowPrivateNetworks {
+		dialer.Control = func(network, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			ip := net.ParseIP(host)
+			if ip == nil || !isPublicIP(ip) {
+				return errPrivateAddress
+			}
+			return nil
+		}
+	}
+
+	return &http.Client{
+		Timeout: timeout,
+		Transport: &http.Transport{
+			Proxy:                 nil,
+			DialContext:           dialer.DialContext,
+			TLSHandshakeTimeout:   timeout,
+			ResponseHeaderTimeout: timeout,
+			MaxIdleConnsPerHost:   2,
+		},
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
+
+func isPublicIP(ip net.IP) bool {
+	return ip.IsGlobalUnicast() && !ip.IsPrivate() && !ip.IsLoopback() && !ip.IsLinkLocalUnicast()
+}
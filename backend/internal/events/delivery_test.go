@@ -0,0 +1,94 @@
+package events
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/andrelcunha/Concord/backend/internal/db"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDeliveryPolicy_Backoff(t *testing.T) {
+	policy := DeliveryPolicy{BaseBackoff: 30 * time.Second, MaxBackoff: 5 * time.Minute}
+
+	assert.Equal(t, 30*time.Second, policy.backoff(1))
+	assert.Equal(t, time.Minute, policy.backoff(2))
+	assert.Equal(t, 4*time.Minute, policy.backoff(4))
+	assert.Equal(t, 5*time.Minute, policy.backoff(5))
+	assert.Equal(t, 5*time.Minute, policy.backoff(20))
+}
+
+func TestService_SendSignsPayload(t *testing.T) {
+	var received *http.Request
+	var body []byte
+	endpoint := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = r
+		body, _ = io.ReadAll(r.Body)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer endpoint.Close()
+
+	service := NewService(nil, nil)
+	service.SetDeliveryPolicy(DeliveryPolicy{MaxAttempts: 3, AllowPrivateNetworks: true})
+
+	delivery := db.ClaimEventDeliveriesRow{
+		ID:        7,
+		EventID:   "evt_123",
+		EventType: EventMemberJoined,
+		Payload:   []byte(`{"id":"evt_123","type":"member.joined"}`),
+		Url:       endpoint.URL,
+		Secret:    "whsec_test",
+	}
+	now := time.Unix(1700000000, 0)
+	statusCode, err := service.send(context.Background(), delivery, now)
+
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusNoContent, statusCode)
+	assert.Equal(t, delivery.Payload, body)
+	assert.Equal(t, EventMemberJoined, received.Header.Get(HeaderEventType))
+	assert.Equal(t, "evt_123", received.Header.Get(HeaderEventID))
+	assert.Equal(t, "7", received.Header.Get(HeaderDeliveryID))
+	assert.Equal(t, "1700000000", received.Header.Get(HeaderTimestamp))
+	assert.Equal(t, Sign("whsec_test", "1700000000", delivery.Payload), received.Header.Get(HeaderSignature))
+	assert.NotEqual(t, Sign("other", "1700000000", delivery.Payload), received.Header.Get(HeaderSignature))
+}
+
+func TestService_SendTreatsErrorsAndRedirectsAsFailures(t *testing.T) {
+	endpoint := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/redirect" {
+			http.Redirect(w, r, "/ok", http.StatusFound)
+			return
+		}
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer endpoint.Close()
+
+	service := NewService(nil, nil)
+	service.SetDeliveryPolicy(DeliveryPolicy{AllowPrivateNetworks: true})
+
+	statusCode, err := service.send(context.Background(), db.ClaimEventDeliveriesRow{Url: endpoint.URL}, time.Now())
+	assert.Error(t, err)
+	assert.Equal(t, http.StatusInternalServerError, statusCode)
+
+	statusCode, err = service.send(context.Background(), db.ClaimEventDeliveriesRow{Url: endpoint.URL + "/redirect"}, time.Now())
+	assert.Error(t, err)
+	assert.Equal(t, http.StatusFound, statusCode)
+}
+
+func TestService_SendRefusesPrivateNetworks(t *testing.T) {
+	endpoint := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Fatal("delivery reached a loopback address")
+	}))
+	defer endpoint.Close()
+
+	service := NewService(nil, nil)
+
+	_, err := service.send(context.Background(), db.ClaimEventDeliveriesRow{Url: endpoint.URL}, time.Now())
+	assert.ErrorIs(t, err, errPrivateAddress)
+	assert.False(t, service.validURL(endpoint.URL))
+	assert.True(t, service.validURL("https://hooks.example.com/concord"))
+}
@@ -0,0 +1,108 @@
+package events
+
+import (
+	"strconv"
+
+	"github.com/andrelcunha/Concord/backend/internal/middleware"
+	"github.com/gofiber/fiber/v2"
+)
+
+type Handler struct {
+	service *Service
+}
+
+func NewHandler(service *Service) *Handler {
+	return &Handler{service: service}
+}
+
+func (h *Handler) CreateSubscription(c *fiber.Ctx) error {
+	var req CreateSubscriptionRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request"})
+	}
+
+	userID := c.Locals("userID").(int32)
+	subscription, err := h.service.CreateSubscription(c.Context(), userID, req)
+	if err != nil {
+		return eventErrorResponse(c, err)
+	}
+	return c.Status(fiber.StatusCreated).JSON(subscription)
+}
+
+func (h *Handler) ListSubscriptions(c *fiber.Ctx) error {
+	userID := c.Locals("userID").(int32)
+	subscriptions, err := h.service.ListSubscriptions(c.Context(), userID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(fiber.Map{"subscriptions": subscriptions})
+}
+
+func (h *Handler) DeleteSubscription(c *fiber.Ctx) error {
+	subscriptionID, err := strconv.ParseInt(c.Params("id"), 10, 32)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid subscription ID"})
+	}
+
+	userID := c.Locals("userID").(int32)
+	if err := h.service.DeleteSubscription(c.Context(), userID, int32(subscriptionID)); err != nil {
+		return eventErrorResponse(c, err)
+	}
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+func (h *Handler) ListDeliveries(c *fiber.Ctx) error {
+	subscriptionID, err := strconv.ParseInt(c.Params("id"), 10, 32)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid subscription ID"})
+	}
+
+	userID := c.Locals("userID").(int32)
+	deliveries, err := h.service.ListDeliveries(c.Context(), userID, int32(subscriptionID), c.Query("status"))
+	if err != nil {
+		return eventErrorResponse(c, err)
+	}
+	return c.JSON(fiber.Map{"deliveries": deliveries})
+}
+
+func (h *Handler) Redeliver(c *fiber.Ctx) error {
+	subscriptionID, err := strconv.ParseInt(c.Params("id"), 10, 32)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid subscription ID"})
+	}
+	deliveryID, err := strconv.ParseInt(c.Params("deliveryId"), 10, 32)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid delivery ID"})
+	}
+
+	userID := c.Locals("userID").(int32)
+	if err := h.service.Redeliver(c.Context(), userID, int32(subscriptionID), int32(deliveryID)); err != nil {
+		return eventErrorResponse(c, err)
+	}
+	return c.SendStatus(fiber.StatusAccepted)
+}
+
+func eventErrorResponse(c *fiber.Ctx, err error) error {
+	switch err {
+	case ErrInvalidSubscriptionURL, ErrInvalidEventTypes, ErrInvalidDeliveryStatus:
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	case ErrNotServerOwner:
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": err.Error()})
+	case ErrSubscriptionNotFound, ErrDeliveryNotFound, ErrServerNotFound:
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
+	default:
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+}
+
+// RegisterEventRoutes mounts outgoing webhook subscriptions and their
+// delivery log. Only interactive sessions can manage them.
+func RegisterEventRoutes(api fiber.Router, service *Service) {
+	handler := NewHandler(service)
+	subscriptions := api.Group("/event-subscriptions", middleware.RequireInteractive())
+	subscriptions.Get("/", handler.ListSubscriptions)
+	subscriptions.Post("/", handler.CreateSubscription)
+	subscriptions.Delete("/:id", handler.DeleteSubscription)
+	subscriptions.Get("/:id/deliveries", handler.ListDeliveries)
+	subscriptions.Post("/:id/deliveries/:deliveryId/redeliver", handler.Redeliver)
+}
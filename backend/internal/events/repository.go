@@ -0,0 +1,108 @@
+package events
+
+import (
+	"context"
+
+	"github.com/andrelcunha/Concord/backend/internal/db"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type Repository interface {
+	CreateSubscription(ctx context.Context, ownerID, serverID int32, url, secret string, eventTypes []string) (db.CreateEventSubscriptionRow, error)
+	ListSubscriptions(ctx context.Context, ownerID int32) ([]db.ListEventSubscriptionsByOwnerRow, error)
+	GetSubscription(ctx context.Context, subscriptionID, ownerID int32) (db.GetEventSubscriptionRow, error)
+	DeleteSubscription(ctx context.Context, subscriptionID, ownerID int32) (bool, error)
+	GetChannelServerID(ctx context.Context, channelID int32) (int32, error)
+	EnqueueDeliveries(ctx context.Context, params db.EnqueueEventDeliveriesParams) (int64, error)
+	ClaimDeliveries(ctx context.Context, batchSize, leaseSeconds int32) ([]db.ClaimEventDeliveriesRow, error)
+	MarkDelivered(ctx context.Context, deliveryID, statusCode int32) error
+	MarkFailed(ctx context.Context, params db.MarkEventDeliveryFailedParams) error
+	ListDeliveries(ctx context.Context, subscriptionID int32, status string, limit int32) ([]db.EventDelivery, error)
+	Redeliver(ctx context.Context, deliveryID, subscriptionID int32) (bool, error)
+}
+
+type repository struct {
+	db *db.Queries
+}
+
+func NewRepository(dbPool *pgxpool.Pool) Repository {
+	return &repository{
+		db: db.New(dbPool),
+	}
+}
+
+func (r *repository) CreateSubscription(ctx context.Context, ownerID, serverID int32, url, secret string, eventTypes []string) (db.CreateEventSubscriptionRow, error) {
+	return r.db.CreateEventSubscription(ctx, db.CreateEventSubscriptionParams{
+		OwnerID:    ownerID,
+		ServerID:   pgtype.Int4{Int32: serverID, Valid: serverID != 0},
+		Url:        url,
+		Secret:     secret,
+		EventTypes: eventTypes,
+	})
+}
+
+func (r *repository) ListSubscriptions(ctx context.Context, ownerID int32) ([]db.ListEventSubscriptionsByOwnerRow, error) {
+	return r.db.ListEventSubscriptionsByOwner(ctx, ownerID)
+}
+
+func (r *repository) GetSubscription(ctx context.Context, subscriptionID, ownerID int32) (db.GetEventSubscriptionRow, error) {
+	return r.db.GetEventSubscription(ctx, db.GetEventSubscriptionParams{
+		ID:      subscriptionID,
+		OwnerID: ownerID,
+	})
+}
+
+func (r *repository) DeleteSubscription(ctx context.Context, subscriptionID, ownerID int32) (bool, error) {
+	rows, err := r.db.DeleteEventSubscription(ctx, db.DeleteEventSubscriptionParams{
+		ID:      subscriptionID,
+		OwnerID: ownerID,
+	})
+	return rows > 0, err
+}
+
+func (r *repository) GetChannelServerID(ctx context.Context, channelID int32) (int32, error) {
+	channel, err := r.db.GetChannel(ctx, channelID)
+	if err != nil {
+		return 0, err
+	}
+	return channel.ServerID, nil
+}
+
+func (r *repository) EnqueueDeliveries(ctx context.Context, params db.EnqueueEventDeliveriesParams) (int64, error) {
+	return r.db.EnqueueEventDeliveries(ctx, params)
+}
+
+func (r *repository) ClaimDeliveries(ctx context.Context, batchSize, leaseSeconds int32) ([]db.ClaimEventDeliveriesRow, error) {
+	return r.db.ClaimEventDeliveries(ctx, db.ClaimEventDeliveriesParams{
+		BatchSize:    batchSize,
+		LeaseSeconds: leaseSeconds,
+	})
+}
+
+func (r *repository) MarkDelivered(ctx context.Context, deliveryID, statusCode int32) error {
+	return r.db.MarkEventDeliveryDelivered(ctx, db.MarkEventDeliveryDeliveredParams{
+		ID:             deliveryID,
+		LastStatusCode: pgtype.Int4{Int32: statusCode, Valid: true},
+	})
+}
+
+func (r *repository) MarkFailed(ctx context.Context, params db.MarkEventDeliveryFailedParams) error {
+	return r.db.MarkEventDeliveryFailed(ctx, params)
+}
+
+func (r *repository) ListDeliveries(ctx context.Context, subscriptionID int32, status string, limit int32) ([]db.EventDelivery, error) {
+	return r.db.ListEventDeliveries(ctx, db.ListEventDeliveriesParams{
+		SubscriptionID: subscriptionID,
+		Status:         pgtype.Text{String: status, Valid: status != ""},
+		RowLimit:       limit,
+	})
+}
+
+func (r *repository) Redeliver(ctx context.Context, deliveryID, subscriptionID int32) (bool, error) {
+	rows, err := r.db.RedeliverEventDelivery(ctx, db.RedeliverEventDeliveryParams{
+		ID:             deliveryID,
+		SubscriptionID: subscriptionID,
+	})
+	return rows > 0, err
+}
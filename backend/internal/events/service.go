@@ -0,0 +1,311 @@
+package events
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"net/url"
+	"slices"
+	"time"
+
+	"github.com/andrelcunha/Concord/backend/internal/db"
+	"github.com/andrelcunha/Concord/backend/pkg/dtos"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// Event types that subscriptions can register for.
+const (
+	EventMessageCreated       = "message.created"
+	EventMemberJoined         = "member.joined"
+	EventFriendRequestCreated = "friend_request.created"
+)
+
+// Server subscriptions receive events from one server; user subscriptions
+// receive events addressed to their owner.
+var (
+	ServerEventTypes = []string{EventMessageCreated, EventMemberJoined}
+	UserEventTypes   = []string{EventFriendRequestCreated}
+)
+
+const (
+	secretPrefix        = "whsec_"
+	maxDeliveriesListed = 100
+)
+
+var (
+	ErrInvalidSubscriptionURL = errors.New("url must be an absolute https URL")
+	ErrInvalidEventTypes      = errors.New("event_types must list supported events for this subscription")
+	ErrSubscriptionNotFound   = errors.New("event subscription not found")
+	ErrDeliveryNotFound       = errors.New("delivery not found or already pending")
+	ErrInvalidDeliveryStatus  = errors.New("status must be pending, delivered or dead")
+	ErrServerNotFound         = errors.New("server not found")
+	ErrNotServerOwner         = errors.New("only the server owner can subscribe to server events")
+)
+
+// Event is something that happened in Concord. ServerID routes it to server
+// subscriptions, or ChannelID when the server is not known yet; UserID routes
+// it to the user's own subscriptions.
+type Event struct {
+	Type      string
+	ServerID  int32
+	ChannelID int32
+	UserID    int32
+	Data      interface{}
+}
+
+// Publisher queues events for delivery. Publishing never fails the caller's
+// request; errors are logged.
+type Publisher interface {
+	Publish(ctx context.Context, event Event)
+}
+
+// MemberJoinedData is the payload of member.joined.
+type MemberJoinedData struct {
+	ServerID int32 `json:"server_id"`
+	UserID   int32 `json:"user_id"`
+	IsBot    bool  `json:"is_bot"`
+}
+
+type eventEnvelope struct {
+	ID        string      `json:"id"`
+	Type      string      `json:"type"`
+	CreatedAt string      `json:"created_at"`
+	Data      interface{} `json:"data"`
+}
+
+type CreateSubscriptionRequest struct {
+	URL        string   `json:"url"`
+	EventTypes []string `json:"event_types"`
+	ServerID   int32    `json:"server_id"`
+}
+
+// ServerLookup is the part of servers.Repository needed to check server
+// ownership. Services that publish events import this package, so it cannot
+// import theirs.
+type ServerLookup interface {
+	GetServer(ctx context.Context, serverID int32) (db.Server, error)
+}
+
+type Service struct {
+	repo       Repository
+	serverRepo ServerLookup
+	policy     DeliveryPolicy
+	client     *http.Client
+}
+
+func NewService(repo Repository, serverRepo ServerLookup) *Service {
+	s := &Service{repo: repo, serverRepo: serverRepo}
+	s.SetDeliveryPolicy(DefaultDeliveryPolicy)
+	return s
+}
+
+func (s *Service) SetDeliveryPolicy(policy DeliveryPolicy) {
+	s.policy = policy
+	s.client = NewOutboundClient(deliveryTimeout, policy.AllowPrivateNetworks)
+}
+
+// CreateSubscription registers an endpoint. Server subscriptions are limited
+// to the server owner. The signing secret is only shown here.
+func (s *Service) CreateSubscription(ctx context.Context, ownerID int32, req CreateSubscriptionRequest) (dtos.EventSubscriptionDto, error) {
+	if !s.validURL(req.URL) {
+		return dtos.EventSubscriptionDto{}, ErrInvalidSubscriptionURL
+	}
+
+	allowed := UserEventTypes
+	if req.ServerID != 0 {
+		allowed = ServerEventTypes
+		server, err := s.serverRepo.GetServer(ctx, req.ServerID)
+		if err != nil {
+			return dtos.EventSubscriptionDto{}, ErrServerNotFound
+		}
+		if !server.CreatorID.Valid || server.CreatorID.Int32 != ownerID {
+			return dtos.EventSubscriptionDto{}, ErrNotServerOwner
+		}
+	}
+	eventTypes := slices.Compact(slices.Sorted(slices.Values(req.EventTypes)))
+	if len(eventTypes) == 0 {
+		return dtos.EventSubscriptionDto{}, ErrInvalidEventTypes
+	}
+	for _, eventType := range eventTypes {
+		if !slices.Contains(allowed, eventType) {
+			return dtos.EventSubscriptionDto{}, ErrInvalidEventTypes
+		}
+	}
+
+	secret, err := generateSecret()
+	if err != nil {
+		return dtos.EventSubscriptionDto{}, err
+	}
+
+	subscription, err := s.repo.CreateSubscription(ctx, ownerID, req.ServerID, req.URL, secret, eventTypes)
+	if err != nil {
+		return dtos.EventSubscriptionDto{}, err
+	}
+
+	subscriptionDto := toSubscriptionDto(db.GetEventSubscriptionRow(subscription))
+	subscriptionDto.Secret = secret
+	return subscriptionDto, nil
+}
+
+func (s *Service) ListSubscriptions(ctx context.Context, ownerID int32) ([]dtos.EventSubscriptionDto, error) {
+	subscriptions, err := s.repo.ListSubscriptions(ctx, ownerID)
+	if err != nil {
+		return nil, err
+	}
+
+	subscriptionDtos := make([]dtos.EventSubscriptionDto, 0, len(subscriptions))
+	for _, subscription := range subscriptions {
+		subscriptionDtos = append(subscriptionDtos, toSubscriptionDto(db.GetEventSubscriptionRow(subscription)))
+	}
+	return subscriptionDtos, nil
+}
+
+func (s *Service) DeleteSubscription(ctx context.Context, ownerID, subscriptionID int32) error {
+	deleted, err := s.repo.DeleteSubscription(ctx, subscriptionID, ownerID)
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		return ErrSubscriptionNotFound
+	}
+	return nil
+}
+
+// ListDeliveries returns the most recent deliveries for a subscription.
+// Filtering by "dead" gives the dead-letter view.
+func (s *Service) ListDeliveries(ctx context.Context, ownerID, subscriptionID int32, status string) ([]dtos.EventDeliveryDto, error) {
+	if status != "" && status != DeliveryPending && status != DeliveryDelivered && status != DeliveryDead {
+		return nil, ErrInvalidDeliveryStatus
+	}
+	if _, err := s.repo.GetSubscription(ctx, subscriptionID, ownerID); err != nil {
+		return nil, ErrSubscriptionNotFound
+	}
+
+	deliveries, err := s.repo.ListDeliveries(ctx, subscriptionID, status, maxDeliveriesListed)
+	if err != nil {
+		return nil, err
+	}
+
+	deliveryDtos := make([]dtos.EventDeliveryDto, 0, len(deliveries))
+	for _, delivery := range deliveries {
+		deliveryDtos = append(deliveryDtos, toDeliveryDto(delivery))
+	}
+	return deliveryDtos, nil
+}
+
+// Redeliver queues a delivered or dead delivery again with a fresh attempt
+// budget. The payload and event ID are unchanged so receivers can dedupe.
+func (s *Service) Redeliver(ctx context.Context, ownerID, subscriptionID, deliveryID int32) error {
+	if _, err := s.repo.GetSubscription(ctx, subscriptionID, ownerID); err != nil {
+		return ErrSubscriptionNotFound
+	}
+
+	queued, err := s.repo.Redeliver(ctx, deliveryID, subscriptionID)
+	if err != nil {
+		return err
+	}
+	if !queued {
+		return ErrDeliveryNotFound
+	}
+	return nil
+}
+
+// Publish queues one delivery per matching subscription. The rows are
+// written before returning, so queued events survive a restart.
+func (s *Service) Publish(ctx context.Context, event Event) {
+	serverID := event.ServerID
+	if serverID == 0 && event.ChannelID != 0 {
+		var err error
+		serverID, err = s.repo.GetChannelServerID(ctx, event.ChannelID)
+		if err != nil {
+			log.Printf("Publish %s: channel %d: %v", event.Type, event.ChannelID, err)
+			return
+		}
+	}
+
+	eventID, err := generateEventID()
+	if err != nil {
+		log.Printf("Publish %s: %v", event.Type, err)
+		return
+	}
+	payload, err := json.Marshal(eventEnvelope{
+		ID:        eventID,
+		Type:      event.Type,
+		CreatedAt: time.Now().UTC().Format("2006-01-02T15:04:05Z07:00"),
+		Data:      event.Data,
+	})
+	if err != nil {
+		log.Printf("Publish %s: %v", event.Type, err)
+		return
+	}
+
+	if _, err := s.repo.EnqueueDeliveries(ctx, db.EnqueueEventDeliveriesParams{
+		EventID:   eventID,
+		EventType: event.Type,
+		Payload:   payload,
+		ServerID:  pgtype.Int4{Int32: serverID, Valid: serverID != 0},
+		UserID:    pgtype.Int4{Int32: event.UserID, Valid: event.UserID != 0},
+	}); err != nil {
+		log.Printf("Publish %s: %v", event.Type, err)
+	}
+}
+
+func (s *Service) validURL(value string) bool {
+	parsed, err := url.Parse(value)
+	if err != nil || parsed.Host == "" {
+		return false
+	}
+	return parsed.Scheme == "https" || (s.policy.AllowPrivateNetworks && parsed.Scheme == "http")
+}
+
+func generateSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return secretPrefix + base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+func generateEventID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return "evt_" + hex.EncodeToString(b), nil
+}
+
+func toSubscriptionDto(subscription db.GetEventSubscriptionRow) dtos.EventSubscriptionDto {
+	return dtos.EventSubscriptionDto{
+		ID:         subscription.ID,
+		ServerID:   subscription.ServerID.Int32,
+		URL:        subscription.Url,
+		EventTypes: subscription.EventTypes,
+		CreatedAt:  subscription.CreatedAt.Time.Format("2006-01-02T15:04:05Z07:00"),
+	}
+}
+
+func toDeliveryDto(delivery db.EventDelivery) dtos.EventDeliveryDto {
+	deliveryDto := dtos.EventDeliveryDto{
+		ID:             delivery.ID,
+		EventID:        delivery.EventID,
+		EventType:      delivery.EventType,
+		Status:         delivery.Status,
+		Attempts:       delivery.Attempts,
+		LastStatusCode: delivery.LastStatusCode.Int32,
+		LastError:      delivery.LastError.String,
+		Payload:        delivery.Payload,
+		CreatedAt:      delivery.CreatedAt.Time.Format("2006-01-02T15:04:05Z07:00"),
+	}
+	if delivery.Status == DeliveryPending {
+		deliveryDto.NextAttemptAt = delivery.NextAttemptAt.Time.Format("2006-01-02T15:04:05Z07:00")
+	}
+	if delivery.DeliveredAt.Valid {
+		deliveryDto.DeliveredAt = delivery.DeliveredAt.Time.Format("2006-01-02T15:04:05Z07:00")
+	}
+	return deliveryDto
+}
@@ -3,6 +3,7 @@ package friendships
 import (
 	"strconv"
 
+	"github.com/andrelcunha/Concord/backend/internal/middleware"
 	"github.com/gofiber/fiber/v2"
 )
 
@@ -18,11 +19,25 @@ func (h *Handler) SearchUsers(c *fiber.Ctx) error {
 	userID := c.Locals("userID").(int32)
 	query := c.Query("query")
 
-	users, err := h.service.SearchUsers(c.Context(), userID, query, 20)
+	limit := c.QueryInt("limit", DefaultSearchLimit)
+	offset := c.QueryInt("offset", 0)
+
+	users, hasMore, err := h.service.SearchUsers(c.Context(), userID, query, int32(limit), int32(offset))
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(fiber.Map{"users": users, "has_more": hasMore})
+}
+
+func (h *Handler) ListSuggestions(c *fiber.Ctx) error {
+	userID := c.Locals("userID").(int32)
+	limit := c.QueryInt("limit", DefaultSuggestionLimit)
+
+	suggestions, err := h.service.ListSuggestions(c.Context(), userID, int32(limit))
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
 	}
-	return c.JSON(fiber.Map{"users": users})
+	return c.JSON(fiber.Map{"suggestions": suggestions})
 }
 
 func (h *Handler) SendFriendRequest(c *fiber.Ctx) error {
@@ -95,6 +110,19 @@ func (h *Handler) RejectFriendRequest(c *fiber.Ctx) error {
 	return c.SendStatus(fiber.StatusOK)
 }
 
+func (h *Handler) CancelFriendRequest(c *fiber.Ctx) error {
+	friendshipID, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid friendship ID"})
+	}
+
+	userID := c.Locals("userID").(int32)
+	if err := h.service.CancelFriendRequest(c.Context(), userID, int32(friendshipID)); err != nil {
+		return friendshipErrorResponse(c, err)
+	}
+	return c.SendStatus(fiber.StatusOK)
+}
+
 func (h *Handler) RemoveFriend(c *fiber.Ctx) error {
 	friendID, err := strconv.Atoi(c.Params("id"))
 	if err != nil {
@@ -116,8 +144,10 @@ func friendshipErrorResponse(c *fiber.Ctx, err error) error {
 		return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": err.Error()})
 	case ErrFriendshipNotFound:
 		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
-	case ErrFriendRequestNotPending, ErrNotFriendRequestRecipient:
+	case ErrFriendRequestNotPending, ErrNotFriendRequestRecipient, ErrNotFriendRequestRequester, ErrFriendRequestsNotAccepted:
 		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": err.Error()})
+	case ErrTooManyOutgoingRequests, ErrFriendRequestCooldown:
+		return c.Status(fiber.StatusTooManyRequests).JSON(fiber.Map{"error": err.Error()})
 	default:
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
 	}
@@ -125,13 +155,15 @@ func friendshipErrorResponse(c *fiber.Ctx, err error) error {
 
 func RegisterFriendshipRoutes(api fiber.Router, service *Service) {
 	handler := NewHandler(service)
-	friends := api.Group("/friends")
+	friends := api.Group("/friends", middleware.RequireInteractive())
 	friends.Get("/search", handler.SearchUsers)
+	friends.Get("/suggestions", handler.ListSuggestions)
 	friends.Get("/", handler.ListFriends)
 	friends.Post("/requests", handler.SendFriendRequest)
 	friends.Get("/requests/incoming", handler.ListIncomingRequests)
 	friends.Get("/requests/outgoing", handler.ListOutgoingRequests)
 	friends.Post("/requests/:id/accept", handler.AcceptFriendRequest)
 	friends.Post("/requests/:id/reject", handler.RejectFriendRequest)
+	friends.Delete("/requests/:id", handler.CancelFriendRequest)
 	friends.Delete("/:id", handler.RemoveFriend)
 }
@@ -2,22 +2,30 @@ package friendships
 
 import (
 	"context"
+	"time"
 
+	"github.com/andrelcunha/Concord/backend/internal/common"
 	"github.com/andrelcunha/Concord/backend/internal/db"
-	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jackc/pgx/v5/pgxpool"
 )
 
 type Repository interface {
-	SearchUsersByUsername(ctx context.Context, query string, limit int32) ([]db.SearchUsersByUsernameRow, error)
+	SearchUsersByUsername(ctx context.Context, searcherID int32, query string, limit, offset int32) ([]db.SearchUsersByUsernameRow, error)
 	CreateFriendship(ctx context.Context, userID, friendID, requesterID int32, status string) (db.Friendship, error)
 	GetFriendshipByUsers(ctx context.Context, userID, friendID int32) (db.Friendship, error)
 	GetFriendshipByID(ctx context.Context, id int32) (db.Friendship, error)
 	UpdateFriendshipStatus(ctx context.Context, userID, friendID int32, status string) (db.Friendship, error)
+	ReopenFriendRequest(ctx context.Context, userID, friendID, requesterID int32) (db.Friendship, error)
+	CountOutgoingRequests(ctx context.Context, requesterID int32) (int64, error)
+	DeleteExpiredRequests(ctx context.Context, cutoff time.Time) ([]db.DeleteExpiredFriendRequestsRow, error)
 	DeleteFriendship(ctx context.Context, userID, friendID int32) error
 	ListAcceptedFriends(ctx context.Context, userID int32) ([]db.ListAcceptedFriendsRow, error)
 	ListIncomingFriendRequests(ctx context.Context, userID int32) ([]db.ListIncomingFriendRequestsRow, error)
 	ListOutgoingFriendRequests(ctx context.Context, userID int32) ([]db.ListOutgoingFriendRequestsRow, error)
+	ListFriendSuggestions(ctx context.Context, userID, limit int32) ([]db.ListFriendSuggestionsRow, error)
+	UsersShareFriend(ctx context.Context, userID, otherUserID int32) (bool, error)
+	UsersShareServer(ctx context.Context, userID, otherUserID int32) (bool, error)
 }
 
 type repository struct {
@@ -30,10 +38,14 @@ func NewRepository(dbPool *pgxpool.Pool) Repository {
 	}
 }
 
-func (r *repository) SearchUsersByUsername(ctx context.Context, query string, limit int32) ([]db.SearchUsersByUsernameRow, error) {
+func (r *repository) SearchUsersByUsername(ctx context.Context, searcherID int32, query string, limit, offset int32) ([]db.SearchUsersByUsernameRow, error) {
 	return r.db.SearchUsersByUsername(ctx, db.SearchUsersByUsernameParams{
-		Column1: pgtype.Text{String: query, Valid: true},
-		Limit:   limit,
+		SearcherID:    searcherID,
+		Query:         query,
+		Pattern:       common.ContainsPattern(query),
+		PrefixPattern: common.PrefixPattern(query),
+		PageLimit:     limit,
+		PageOffset:    offset,
 	})
 }
 
@@ -65,6 +77,26 @@ func (r *repository) UpdateFriendshipStatus(ctx context.Context, userID, friendI
 	})
 }
 
+// ReopenFriendRequest turns an earlier rejected request into a new pending
+// one, keeping the pair's single row.
+func (r *repository) ReopenFriendRequest(ctx context.Context, userID, friendID, requesterID int32) (db.Friendship, error) {
+	return r.db.ReopenFriendRequest(ctx, db.ReopenFriendRequestParams{
+		UserID:      userID,
+		FriendID:    friendID,
+		RequesterID: requesterID,
+	})
+}
+
+func (r *repository) CountOutgoingRequests(ctx context.Context, requesterID int32) (int64, error) {
+	return r.db.CountOutgoingFriendRequests(ctx, requesterID)
+}
+
+// DeleteExpiredRequests removes pending requests sent before cutoff and
+// returns them.
+func (r *repository) DeleteExpiredRequests(ctx context.Context, cutoff time.Time) ([]db.DeleteExpiredFriendRequestsRow, error) {
+	return r.db.DeleteExpiredFriendRequests(ctx, pgtype.Timestamptz{Time: cutoff, Valid: true})
+}
+
 func (r *repository) DeleteFriendship(ctx context.Context, userID, friendID int32) error {
 	return r.db.DeleteFriendship(ctx, db.DeleteFriendshipParams{
 		UserID:   userID,
@@ -83,3 +115,26 @@ func (r *repository) ListIncomingFriendRequests(ctx context.Context, userID int3
 func (r *repository) ListOutgoingFriendRequests(ctx context.Context, userID int32) ([]db.ListOutgoingFriendRequestsRow, error) {
 	return r.db.ListOutgoingFriendRequests(ctx, userID)
 }
+
+func (r *repository) ListFriendSuggestions(ctx context.Context, userID, limit int32) ([]db.ListFriendSuggestionsRow, error) {
+	return r.db.ListFriendSuggestions(ctx, db.ListFriendSuggestionsParams{
+		UserID:    userID,
+		PageLimit: limit,
+	})
+}
+
+// UsersShareFriend reports whether the two users have an accepted friend in
+// common.
+func (r *repository) UsersShareFriend(ctx context.Context, userID, otherUserID int32) (bool, error) {
+	return r.db.UsersShareFriend(ctx, db.UsersShareFriendParams{
+		UserID:      userID,
+		OtherUserID: otherUserID,
+	})
+}
+
+func (r *repository) UsersShareServer(ctx context.Context, userID, otherUserID int32) (bool, error) {
+	return r.db.UsersShareServer(ctx, db.UsersShareServerParams{
+		UserID:   userID,
+		UserID_2: otherUserID,
+	})
+}
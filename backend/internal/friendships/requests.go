@@ -0,0 +1,130 @@
+package friendships
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/andrelcunha/Concord/backend/internal/db"
+	"github.com/andrelcunha/Concord/backend/internal/privacy"
+	"github.com/andrelcunha/Concord/backend/internal/realtime"
+)
+
+const expiryPollInterval = time.Hour
+
+// RequestPolicy limits friend requests. A requester may have at most
+// MaxOutgoing pending requests, pending requests are deleted after Expiry,
+// and a rejected requester must wait RejectCooldown before asking the same
+// user again. Zero values turn a limit off.
+type RequestPolicy struct {
+	MaxOutgoing    int
+	Expiry         time.Duration
+	RejectCooldown time.Duration
+}
+
+var DefaultRequestPolicy = RequestPolicy{
+	MaxOutgoing:    100,
+	Expiry:         30 * 24 * time.Hour,
+	RejectCooldown: 7 * 24 * time.Hour,
+}
+
+// CancelFriendRequest withdraws a pending request the current user sent.
+func (s *Service) CancelFriendRequest(ctx context.Context, currentUserID, friendshipID int32) error {
+	friendship, err := s.repo.GetFriendshipByID(ctx, friendshipID)
+	if err != nil {
+		return ErrFriendshipNotFound
+	}
+	if friendship.UserID != currentUserID && friendship.FriendID != currentUserID {
+		return ErrFriendshipNotFound
+	}
+	if friendship.Status != "pending" {
+		return ErrFriendRequestNotPending
+	}
+	if friendship.RequesterID != currentUserID {
+		return ErrNotFriendRequestRequester
+	}
+
+	if err := s.repo.DeleteFriendship(ctx, friendship.UserID, friendship.FriendID); err != nil {
+		return err
+	}
+	s.publishToPair(ctx, realtime.EventFriendRequestRemoved, friendship.ID, friendship.UserID, friendship.FriendID)
+	return nil
+}
+
+// checkCanReopen decides whether an existing row for the pair can become a
+// new request from requesterID. Only rejected requests can be reopened, and
+// the user who was rejected has to wait out the cooldown; the user who
+// rejected may send their own request at any time.
+func (s *Service) checkCanReopen(existing db.Friendship, requesterID int32, now time.Time) error {
+	if existing.Status != "rejected" {
+		return ErrFriendshipAlreadyExists
+	}
+	if existing.RequesterID != requesterID || !existing.RespondedAt.Valid {
+		return nil
+	}
+	if now.Before(existing.RespondedAt.Time.Add(s.policy.RejectCooldown)) {
+		return ErrFriendRequestCooldown
+	}
+	return nil
+}
+
+// checkRecipientAccepts applies the target's friend request policy to the
+// requester.
+func (s *Service) checkRecipientAccepts(ctx context.Context, requesterID, targetUserID int32) error {
+	settings, err := s.privacy.GetSettings(ctx, targetUserID)
+	if err != nil {
+		return err
+	}
+
+	var allowed bool
+	switch settings.FriendRequestPolicy {
+	case privacy.FriendRequestPolicyEveryone:
+		allowed = true
+	case privacy.FriendRequestPolicyFriendsOfFriends:
+		allowed, err = s.repo.UsersShareFriend(ctx, requesterID, targetUserID)
+	case privacy.FriendRequestPolicyServerMembers:
+		allowed, err = s.repo.UsersShareServer(ctx, requesterID, targetUserID)
+	}
+	if err != nil {
+		return err
+	}
+	if !allowed {
+		return ErrFriendRequestsNotAccepted
+	}
+	return nil
+}
+
+// RunExpiryWorker deletes stale pending requests until ctx is cancelled.
+func (s *Service) RunExpiryWorker(ctx context.Context) {
+	if s.policy.Expiry <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(expiryPollInterval)
+	defer ticker.Stop()
+
+	for {
+		s.expireRequests(ctx, time.Now())
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// expireRequests deletes stale pending requests and tells both users of each
+// one, like a cancelled request.
+func (s *Service) expireRequests(ctx context.Context, now time.Time) {
+	expired, err := s.repo.DeleteExpiredRequests(ctx, now.Add(-s.policy.Expiry))
+	if err != nil {
+		log.Printf("DeleteExpiredRequests error: %v", err)
+		return
+	}
+	for _, request := range expired {
+		s.publishToPair(ctx, realtime.EventFriendRequestRemoved, request.ID, request.UserID, request.FriendID)
+	}
+	if len(expired) > 0 {
+		log.Printf("Expired %d friend requests", len(expired))
+	}
+}
@@ -0,0 +1,64 @@
+package friendships
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/andrelcunha/Concord/backend/internal/db"
+	"github.com/andrelcunha/Concord/backend/internal/realtime"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckCanReopen(t *testing.T) {
+	service := &Service{policy: RequestPolicy{RejectCooldown: 24 * time.Hour}}
+	now := time.Now()
+	rejected := db.Friendship{
+		UserID:      1,
+		FriendID:    2,
+		RequesterID: 1,
+		Status:      "rejected",
+		RespondedAt: pgtype.Timestamptz{Time: now.Add(-time.Hour), Valid: true},
+	}
+
+	assert.ErrorIs(t, service.checkCanReopen(rejected, 1, now), ErrFriendRequestCooldown)
+	assert.NoError(t, service.checkCanReopen(rejected, 2, now), "the user who rejected can ask")
+	assert.NoError(t, service.checkCanReopen(rejected, 1, now.Add(24*time.Hour)))
+
+	for _, status := range []string{"pending", "accepted"} {
+		existing := rejected
+		existing.Status = status
+		assert.ErrorIs(t, service.checkCanReopen(existing, 2, now), ErrFriendshipAlreadyExists)
+	}
+}
+
+type recordingPublisher struct {
+	events map[int32][]realtime.Event
+}
+
+func (p *recordingPublisher) PublishToUser(ctx context.Context, userID int32, event realtime.Event) {
+	p.events[userID] = append(p.events[userID], event)
+}
+
+func TestExpireRequestsNotifiesBothUsers(t *testing.T) {
+	repo := &mockRepository{expired: []db.DeleteExpiredFriendRequestsRow{
+		{ID: 7, UserID: 1, FriendID: 2, RequesterID: 2},
+	}}
+	publisher := &recordingPublisher{events: map[int32][]realtime.Event{}}
+	service := &Service{repo: repo, realtime: publisher, policy: DefaultRequestPolicy}
+
+	service.expireRequests(context.Background(), time.Now())
+
+	assert.Equal(t, []realtime.Event{{
+		Type: realtime.EventFriendRequestRemoved,
+		Data: realtime.FriendRequestData{ID: 7, UserID: 2},
+	}}, publisher.events[1])
+	assert.Equal(t, []realtime.Event{{
+		Type: realtime.EventFriendRequestRemoved,
+		Data: realtime.FriendRequestData{ID: 7, UserID: 1},
+	}}, publisher.events[2])
+
+	service.expireRequests(context.Background(), time.Now())
+	assert.Len(t, publisher.events[1], 1)
+}
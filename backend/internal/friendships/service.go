@@ -3,27 +3,65 @@ package friendships
 import (
 	"context"
 	"errors"
+	"strings"
+	"time"
 
+	"github.com/andrelcunha/Concord/backend/internal/annotations"
 	"github.com/andrelcunha/Concord/backend/internal/blocks"
+	"github.com/andrelcunha/Concord/backend/internal/db"
+	"github.com/andrelcunha/Concord/backend/internal/events"
+	"github.com/andrelcunha/Concord/backend/internal/presence"
+	"github.com/andrelcunha/Concord/backend/internal/privacy"
+	"github.com/andrelcunha/Concord/backend/internal/realtime"
 	"github.com/andrelcunha/Concord/backend/pkg/dtos"
 )
 
 var (
-	ErrCannotFriendYourself         = errors.New("cannot send a friend request to yourself")
-	ErrFriendshipAlreadyExists      = errors.New("friendship already exists")
-	ErrFriendshipNotFound           = errors.New("friendship not found")
-	ErrFriendRequestNotPending      = errors.New("friend request is not pending")
-	ErrNotFriendRequestRecipient    = errors.New("only the recipient can respond to this request")
-	ErrBlockedRelationship          = errors.New("friendship is blocked")
+	ErrCannotFriendYourself      = errors.New("cannot send a friend request to yourself")
+	ErrFriendshipAlreadyExists   = errors.New("friendship already exists")
+	ErrFriendshipNotFound        = errors.New("friendship not found")
+	ErrFriendRequestNotPending   = errors.New("friend request is not pending")
+	ErrNotFriendRequestRecipient = errors.New("only the recipient can respond to this request")
+	ErrBlockedRelationship       = errors.New("friendship is blocked")
+	ErrNotFriendRequestRequester = errors.New("only the requester can cancel this request")
+	ErrTooManyOutgoingRequests   = errors.New("too many pending outgoing friend requests")
+	ErrFriendRequestCooldown     = errors.New("friend request was rejected recently")
+	ErrFriendRequestsNotAccepted = errors.New("this user does not accept friend requests from you")
+)
+
+const (
+	DefaultSearchLimit     = 20
+	MaxSearchLimit         = 50
+	DefaultSuggestionLimit = 10
+	MaxSuggestionLimit     = 50
 )
 
 type Service struct {
-	repo      Repository
-	blockRepo blocks.Repository
+	repo        Repository
+	blockRepo   blocks.Repository
+	annotations *annotations.Service
+	privacy     *privacy.Service
+	presence    *presence.Service
+	events      events.Publisher
+	realtime    realtime.Publisher
+	policy      RequestPolicy
+}
+
+func NewService(repo Repository, blockRepo blocks.Repository, annotationsService *annotations.Service, privacyService *privacy.Service, presenceService *presence.Service, events events.Publisher, realtime realtime.Publisher) *Service {
+	return &Service{
+		repo:        repo,
+		blockRepo:   blockRepo,
+		annotations: annotationsService,
+		privacy:     privacyService,
+		presence:    presenceService,
+		events:      events,
+		realtime:    realtime,
+		policy:      DefaultRequestPolicy,
+	}
 }
 
-func NewService(repo Repository, blockRepo blocks.Repository) *Service {
-	return &Service{repo: repo, blockRepo: blockRepo}
+func (s *Service) SetRequestPolicy(policy RequestPolicy) {
+	s.policy = policy
 }
 
 func normalizePair(a, b int32) (int32, int32) {
@@ -33,42 +71,79 @@ func normalizePair(a, b int32) (int32, int32) {
 	return b, a
 }
 
-func userSummary(userID int32, username, avatarURL, avatarColor string) dtos.UserSummaryDto {
+func userSummary(userID int32, username, displayName, avatarURL, avatarColor string) dtos.UserSummaryDto {
 	return dtos.UserSummaryDto{
 		UserID:      userID,
 		Username:    username,
+		DisplayName: displayName,
 		AvatarURL:   avatarURL,
 		AvatarColor: avatarColor,
 	}
 }
 
-func (s *Service) SearchUsers(ctx context.Context, userID int32, query string, limit int32) ([]dtos.UserSummaryDto, error) {
-	rows, err := s.repo.SearchUsersByUsername(ctx, query, limit)
+// SearchUsers ranks users by how closely their username matches the query.
+// Friends, pending or rejected requests and blocked users are excluded in
+// the query itself, so pages are always full.
+func (s *Service) SearchUsers(ctx context.Context, userID int32, query string, limit, offset int32) ([]dtos.UserSummaryDto, bool, error) {
+	if limit < 1 || limit > MaxSearchLimit {
+		limit = DefaultSearchLimit
+	}
+	if offset < 0 {
+		offset = 0
+	}
+
+	rows, err := s.repo.SearchUsersByUsername(ctx, userID, strings.TrimSpace(query), limit+1, offset)
 	if err != nil {
-		return nil, err
+		return nil, false, err
+	}
+
+	hasMore := len(rows) > int(limit)
+	if hasMore {
+		rows = rows[:limit]
 	}
 
-	var users []dtos.UserSummaryDto
+	notes, err := s.annotations.ForOwner(ctx, userID)
+	if err != nil {
+		return nil, false, err
+	}
+
+	users := make([]dtos.UserSummaryDto, 0, len(rows))
 	for _, row := range rows {
-		if row.ID == userID {
-			continue
-		}
+		users = append(users, userSummary(row.ID, row.Username, row.DisplayName.String, row.AvatarUrl.String, row.AvatarColor.String))
+	}
+	notes.ApplyAll(users)
+	return users, hasMore, nil
+}
 
-		low, high := normalizePair(userID, row.ID)
-		if _, err := s.repo.GetFriendshipByUsers(ctx, low, high); err == nil {
-			continue
-		}
-		if _, err := s.blockRepo.GetBlock(ctx, userID, row.ID); err == nil {
-			continue
-		}
-		if _, err := s.blockRepo.GetBlock(ctx, row.ID, userID); err == nil {
-			continue
-		}
+// ListSuggestions ranks friends of friends and members of shared servers,
+// weighting a mutual friend above a shared server. Existing friendship rows,
+// bots and blocks in either direction are excluded in the query.
+func (s *Service) ListSuggestions(ctx context.Context, userID, limit int32) ([]dtos.FriendSuggestionDto, error) {
+	if limit < 1 || limit > MaxSuggestionLimit {
+		limit = DefaultSuggestionLimit
+	}
 
-		users = append(users, userSummary(row.ID, row.Username, row.AvatarUrl.String, row.AvatarColor.String))
+	rows, err := s.repo.ListFriendSuggestions(ctx, userID, limit)
+	if err != nil {
+		return nil, err
 	}
 
-	return users, nil
+	notes, err := s.annotations.ForOwner(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	suggestions := make([]dtos.FriendSuggestionDto, 0, len(rows))
+	for _, row := range rows {
+		suggestion := dtos.FriendSuggestionDto{
+			UserSummaryDto: userSummary(row.ID, row.Username, row.DisplayName.String, row.AvatarUrl.String, row.AvatarColor.String),
+			MutualFriends:  row.MutualFriends,
+			MutualServers:  row.MutualServers,
+		}
+		notes.Apply(&suggestion.UserSummaryDto)
+		suggestions = append(suggestions, suggestion)
+	}
+	return suggestions, nil
 }
 
 func (s *Service) SendFriendRequest(ctx context.Context, requesterID, targetUserID int32) (dtos.FriendshipDto, error) {
@@ -83,24 +158,49 @@ func (s *Service) SendFriendRequest(ctx context.Context, requesterID, targetUser
 		return dtos.FriendshipDto{}, ErrBlockedRelationship
 	}
 
-	low, high := normalizePair(requesterID, targetUserID)
-	if _, err := s.repo.GetFriendshipByUsers(ctx, low, high); err == nil {
-		return dtos.FriendshipDto{}, ErrFriendshipAlreadyExists
+	count, err := s.repo.CountOutgoingRequests(ctx, requesterID)
+	if err != nil {
+		return dtos.FriendshipDto{}, err
+	}
+	if s.policy.MaxOutgoing > 0 && count >= int64(s.policy.MaxOutgoing) {
+		return dtos.FriendshipDto{}, ErrTooManyOutgoingRequests
 	}
 
-	friendship, err := s.repo.CreateFriendship(ctx, low, high, requesterID, "pending")
+	low, high := normalizePair(requesterID, targetUserID)
+	var friendship db.Friendship
+	existing, lookupErr := s.repo.GetFriendshipByUsers(ctx, low, high)
+	if lookupErr == nil {
+		if err := s.checkCanReopen(existing, requesterID, time.Now()); err != nil {
+			return dtos.FriendshipDto{}, err
+		}
+	}
+	if err := s.checkRecipientAccepts(ctx, requesterID, targetUserID); err != nil {
+		return dtos.FriendshipDto{}, err
+	}
+	if lookupErr == nil {
+		friendship, err = s.repo.ReopenFriendRequest(ctx, low, high, requesterID)
+	} else {
+		friendship, err = s.repo.CreateFriendship(ctx, low, high, requesterID, "pending")
+	}
 	if err != nil {
 		return dtos.FriendshipDto{}, err
 	}
 
-	return dtos.FriendshipDto{
+	friendshipDto := dtos.FriendshipDto{
 		ID:          friendship.ID,
 		UserID:      friendship.UserID,
 		FriendID:    friendship.FriendID,
 		RequesterID: friendship.RequesterID,
 		Status:      friendship.Status,
 		CreatedAt:   friendship.CreatedAt.Time.Format("2006-01-02T15:04:05Z07:00"),
-	}, nil
+	}
+	s.events.Publish(ctx, events.Event{
+		Type:   events.EventFriendRequestCreated,
+		UserID: targetUserID,
+		Data:   friendshipDto,
+	})
+	s.publishToPair(ctx, realtime.EventFriendRequestCreated, friendship.ID, requesterID, targetUserID)
+	return friendshipDto, nil
 }
 
 func (s *Service) AcceptFriendRequest(ctx context.Context, currentUserID, friendshipID int32) (dtos.FriendshipDto, error) {
@@ -125,6 +225,7 @@ func (s *Service) AcceptFriendRequest(ctx context.Context, currentUserID, friend
 	if err != nil {
 		return dtos.FriendshipDto{}, err
 	}
+	s.publishToPair(ctx, realtime.EventFriendAdded, 0, friendship.UserID, friendship.FriendID)
 
 	return dtos.FriendshipDto{
 		ID:          friendship.ID,
@@ -154,8 +255,11 @@ func (s *Service) RejectFriendRequest(ctx context.Context, currentUserID, friend
 		return ErrNotFriendRequestRecipient
 	}
 
-	_, err = s.repo.UpdateFriendshipStatus(ctx, friendship.UserID, friendship.FriendID, "rejected")
-	return err
+	if _, err := s.repo.UpdateFriendshipStatus(ctx, friendship.UserID, friendship.FriendID, "rejected"); err != nil {
+		return err
+	}
+	s.publishToPair(ctx, realtime.EventFriendRequestRemoved, friendship.ID, friendship.UserID, friendship.FriendID)
+	return nil
 }
 
 func (s *Service) RemoveFriend(ctx context.Context, currentUserID, targetUserID int32) error {
@@ -172,7 +276,23 @@ func (s *Service) RemoveFriend(ctx context.Context, currentUserID, targetUserID
 		return ErrFriendshipNotFound
 	}
 
-	return s.repo.DeleteFriendship(ctx, low, high)
+	if err := s.repo.DeleteFriendship(ctx, low, high); err != nil {
+		return err
+	}
+	s.publishToPair(ctx, realtime.EventFriendRemoved, 0, low, high)
+	return nil
+}
+
+// publishToPair sends eventType to both users, each with the other as the
+// subject. Request events carry the friendship ID as well.
+func (s *Service) publishToPair(ctx context.Context, eventType string, friendshipID, userID, otherUserID int32) {
+	for _, pair := range [][2]int32{{userID, otherUserID}, {otherUserID, userID}} {
+		var data any = realtime.UserData{UserID: pair[1]}
+		if friendshipID != 0 {
+			data = realtime.FriendRequestData{ID: friendshipID, UserID: pair[1]}
+		}
+		s.realtime.PublishToUser(ctx, pair[0], realtime.Event{Type: eventType, Data: data})
+	}
 }
 
 func (s *Service) ListFriends(ctx context.Context, userID int32) ([]dtos.FriendDto, error) {
@@ -181,6 +301,11 @@ func (s *Service) ListFriends(ctx context.Context, userID int32) ([]dtos.FriendD
 		return nil, err
 	}
 
+	notes, err := s.annotations.ForOwner(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
 	friends := make([]dtos.FriendDto, 0, len(rows))
 	for _, row := range rows {
 		if _, err := s.blockRepo.GetBlock(ctx, userID, row.ID); err == nil {
@@ -190,10 +315,24 @@ func (s *Service) ListFriends(ctx context.Context, userID int32) ([]dtos.FriendD
 			continue
 		}
 
-		friends = append(friends, dtos.FriendDto{
-			UserSummaryDto: userSummary(row.ID, row.Username, row.AvatarUrl.String, row.AvatarColor.String),
+		friend := dtos.FriendDto{
+			UserSummaryDto: userSummary(row.ID, row.Username, row.DisplayName.String, row.AvatarUrl.String, row.AvatarColor.String),
 			FriendedAt:     row.FriendedAt.Time.Format("2006-01-02T15:04:05Z07:00"),
-		})
+		}
+		notes.Apply(&friend.UserSummaryDto)
+		friends = append(friends, friend)
+	}
+
+	friendIDs := make([]int32, 0, len(friends))
+	for _, friend := range friends {
+		friendIDs = append(friendIDs, friend.UserID)
+	}
+	statuses, err := s.presence.ForUsers(ctx, friendIDs)
+	if err != nil {
+		return nil, err
+	}
+	for i := range friends {
+		statuses.Apply(&friends[i].UserSummaryDto)
 	}
 	return friends, nil
 }
@@ -204,6 +343,11 @@ func (s *Service) ListIncomingRequests(ctx context.Context, userID int32) ([]dto
 		return nil, err
 	}
 
+	notes, err := s.annotations.ForOwner(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
 	requests := make([]dtos.FriendshipDto, 0, len(rows))
 	for _, row := range rows {
 		requests = append(requests, dtos.FriendshipDto{
@@ -213,9 +357,12 @@ func (s *Service) ListIncomingRequests(ctx context.Context, userID int32) ([]dto
 			RequesterID: row.RequesterID,
 			Status:      row.Status,
 			CreatedAt:   row.CreatedAt.Time.Format("2006-01-02T15:04:05Z07:00"),
-			User:        userSummary(row.UserID, row.Username, row.AvatarUrl.String, row.AvatarColor.String),
+			User:        userSummary(row.RequesterID, row.Username, row.DisplayName.String, row.AvatarUrl.String, row.AvatarColor.String),
 		})
 	}
+	for i := range requests {
+		notes.Apply(&requests[i].User)
+	}
 
 	return requests, nil
 }
@@ -226,8 +373,18 @@ func (s *Service) ListOutgoingRequests(ctx context.Context, userID int32) ([]dto
 		return nil, err
 	}
 
+	notes, err := s.annotations.ForOwner(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
 	requests := make([]dtos.FriendshipDto, 0, len(rows))
 	for _, row := range rows {
+		// Pairs are stored lowest ID first, so the recipient can be either.
+		recipientID := row.FriendID
+		if recipientID == userID {
+			recipientID = row.UserID
+		}
 		requests = append(requests, dtos.FriendshipDto{
 			ID:          row.ID,
 			UserID:      row.UserID,
@@ -235,9 +392,12 @@ func (s *Service) ListOutgoingRequests(ctx context.Context, userID int32) ([]dto
 			RequesterID: row.RequesterID,
 			Status:      row.Status,
 			CreatedAt:   row.CreatedAt.Time.Format("2006-01-02T15:04:05Z07:00"),
-			User:        userSummary(row.FriendID, row.Username, row.AvatarUrl.String, row.AvatarColor.String),
+			User:        userSummary(recipientID, row.Username, row.DisplayName.String, row.AvatarUrl.String, row.AvatarColor.String),
 		})
 	}
+	for i := range requests {
+		notes.Apply(&requests[i].User)
+	}
 
 	return requests, nil
 }
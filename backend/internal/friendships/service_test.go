@@ -0,0 +1,78 @@
+package friendships
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/andrelcunha/Concord/backend/internal/annotations"
+	"github.com/andrelcunha/Concord/backend/internal/db"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type mockRepository struct {
+	Repository
+	incoming []db.ListIncomingFriendRequestsRow
+	outgoing []db.ListOutgoingFriendRequestsRow
+	expired  []db.DeleteExpiredFriendRequestsRow
+}
+
+func (m *mockRepository) DeleteExpiredRequests(ctx context.Context, cutoff time.Time) ([]db.DeleteExpiredFriendRequestsRow, error) {
+	expired := m.expired
+	m.expired = nil
+	return expired, nil
+}
+
+func (m *mockRepository) ListIncomingFriendRequests(ctx context.Context, userID int32) ([]db.ListIncomingFriendRequestsRow, error) {
+	return m.incoming, nil
+}
+
+func (m *mockRepository) ListOutgoingFriendRequests(ctx context.Context, userID int32) ([]db.ListOutgoingFriendRequestsRow, error) {
+	return m.outgoing, nil
+}
+
+type mockAnnotationRepository struct {
+	annotations.Repository
+	annotations []db.UserAnnotation
+}
+
+func (m *mockAnnotationRepository) ListAnnotations(ctx context.Context, ownerID int32) ([]db.UserAnnotation, error) {
+	return m.annotations, nil
+}
+
+// Pairs are stored lowest ID first, so these requests put the other user on
+// whichever side of the pair the viewer is not.
+func TestListRequestsSummarizeTheOtherUser(t *testing.T) {
+	ctx := context.Background()
+	repo := &mockRepository{
+		incoming: []db.ListIncomingFriendRequestsRow{
+			{ID: 1, UserID: 2, FriendID: 5, RequesterID: 5, Status: "pending", Username: "erin"},
+			{ID: 2, UserID: 1, FriendID: 2, RequesterID: 1, Status: "pending", Username: "alice"},
+		},
+		outgoing: []db.ListOutgoingFriendRequestsRow{
+			{ID: 3, UserID: 2, FriendID: 7, RequesterID: 2, Status: "pending", Username: "grace"},
+			{ID: 4, UserID: 1, FriendID: 2, RequesterID: 2, Status: "pending", Username: "alice"},
+		},
+	}
+	annotationRepo := &mockAnnotationRepository{annotations: []db.UserAnnotation{
+		{OwnerID: 2, TargetID: 5, Nickname: "Erin from work"},
+		{OwnerID: 2, TargetID: 1, Nickname: "Al"},
+	}}
+	service := &Service{repo: repo, annotations: annotations.NewService(annotationRepo)}
+
+	incoming, err := service.ListIncomingRequests(ctx, 2)
+	require.NoError(t, err)
+	require.Len(t, incoming, 2)
+	assert.Equal(t, int32(5), incoming[0].User.UserID)
+	assert.Equal(t, "Erin from work", incoming[0].User.Nickname)
+	assert.Equal(t, int32(1), incoming[1].User.UserID)
+	assert.Equal(t, "Al", incoming[1].User.Nickname)
+
+	outgoing, err := service.ListOutgoingRequests(ctx, 2)
+	require.NoError(t, err)
+	require.Len(t, outgoing, 2)
+	assert.Equal(t, int32(7), outgoing[0].User.UserID)
+	assert.Equal(t, int32(1), outgoing[1].User.UserID)
+	assert.Equal(t, "Al", outgoing[1].User.Nickname)
+}
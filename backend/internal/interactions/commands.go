@@ -0,0 +1,210 @@
+package interactions
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// Option types a command can declare.
+const (
+	OptionString  = "string"
+	OptionInteger = "integer"
+	OptionNumber  = "number"
+	OptionBoolean = "boolean"
+	OptionUser    = "user"
+	OptionChannel = "channel"
+)
+
+const (
+	maxCommandDescription = 100
+	maxCommandOptions     = 25
+	maxOptionChoices      = 25
+)
+
+var optionTypes = []string{OptionString, OptionInteger, OptionNumber, OptionBoolean, OptionUser, OptionChannel}
+
+var commandNamePattern = regexp.MustCompile(`^[a-z0-9_-]{1,32}$`)
+
+var (
+	ErrInvalidCommand = errors.New("invalid command definition")
+	ErrInvalidOptions = errors.New("invalid command options")
+)
+
+// CommandOption describes one named option of a slash command.
+type CommandOption struct {
+	Name        string   `json:"name"`
+	Description string   `json:"description,omitempty"`
+	Type        string   `json:"type"`
+	Required    bool     `json:"required,omitempty"`
+	Choices     []string `json:"choices,omitempty"`
+}
+
+func validateCommand(name, description string, options []CommandOption) error {
+	if !commandNamePattern.MatchString(name) {
+		return fmt.Errorf("%w: name must be 1-32 lowercase letters, digits, - or _", ErrInvalidCommand)
+	}
+	if utf8.RuneCountInString(description) > maxCommandDescription {
+		return fmt.Errorf("%w: description must be at most %d characters", ErrInvalidCommand, maxCommandDescription)
+	}
+	if len(options) > maxCommandOptions {
+		return fmt.Errorf("%w: at most %d options", ErrInvalidCommand, maxCommandOptions)
+	}
+
+	seen := map[string]bool{}
+	for _, option := range options {
+		if !commandNamePattern.MatchString(option.Name) {
+			return fmt.Errorf("%w: option name %q is invalid", ErrInvalidCommand, option.Name)
+		}
+		if seen[option.Name] {
+			return fmt.Errorf("%w: duplicate option %q", ErrInvalidCommand, option.Name)
+		}
+		seen[option.Name] = true
+		if !slices.Contains(optionTypes, option.Type) {
+			return fmt.Errorf("%w: option %q has unknown type %q", ErrInvalidCommand, option.Name, option.Type)
+		}
+		if len(option.Choices) > 0 && option.Type != OptionString {
+			return fmt.Errorf("%w: only string options can have choices", ErrInvalidCommand)
+		}
+		if len(option.Choices) > maxOptionChoices {
+			return fmt.Errorf("%w: option %q has more than %d choices", ErrInvalidCommand, option.Name, maxOptionChoices)
+		}
+		if utf8.RuneCountInString(option.Description) > maxCommandDescription {
+			return fmt.Errorf("%w: option %q description is too long", ErrInvalidCommand, option.Name)
+		}
+	}
+	return nil
+}
+
+// splitCommand returns the command name of a message like "/name ...". ok is
+// false when the message is not shaped like a command at all.
+func splitCommand(content string) (name, args string, ok bool) {
+	if !strings.HasPrefix(content, "/") {
+		return "", "", false
+	}
+	name, args, _ = strings.Cut(strings.TrimPrefix(content, "/"), " ")
+	if !commandNamePattern.MatchString(name) {
+		return "", "", false
+	}
+	return name, args, true
+}
+
+// parseOptions reads name:value pairs. Values containing spaces are quoted,
+// as in reason:"too many pings". Values are converted to the option's type.
+func parseOptions(args string, schema []CommandOption) (map[string]interface{}, error) {
+	raw, err := tokenizeOptions(args)
+	if err != nil {
+		return nil, err
+	}
+
+	values := make(map[string]interface{}, len(raw))
+	for name, value := range raw {
+		index := slices.IndexFunc(schema, func(option CommandOption) bool { return option.Name == name })
+		if index < 0 {
+			return nil, fmt.Errorf("%w: unknown option %q", ErrInvalidOptions, name)
+		}
+		converted, err := convertOption(schema[index], value)
+		if err != nil {
+			return nil, err
+		}
+		values[name] = converted
+	}
+
+	for _, option := range schema {
+		if _, ok := values[option.Name]; option.Required && !ok {
+			return nil, fmt.Errorf("%w: missing required option %q", ErrInvalidOptions, option.Name)
+		}
+	}
+	return values, nil
+}
+
+func tokenizeOptions(args string) (map[string]string, error) {
+	values := map[string]string{}
+	rest := strings.TrimSpace(args)
+	for rest != "" {
+		colon := strings.IndexByte(rest, ':')
+		space := strings.IndexAny(rest, " \t")
+		if colon <= 0 || (space >= 0 && space < colon) {
+			return nil, fmt.Errorf("%w: expected name:value", ErrInvalidOptions)
+		}
+		name := rest[:colon]
+		rest = rest[colon+1:]
+
+		var value string
+		if strings.HasPrefix(rest, `"`) {
+			end := closingQuote(rest)
+			if end < 0 {
+				return nil, fmt.Errorf("%w: unterminated quote in %q", ErrInvalidOptions, name)
+			}
+			value = strings.ReplaceAll(rest[1:end], `\"`, `"`)
+			rest = rest[end+1:]
+		} else {
+			end := strings.IndexAny(rest, " \t")
+			if end < 0 {
+				end = len(rest)
+			}
+			value = rest[:end]
+			rest = rest[end:]
+		}
+
+		if _, duplicate := values[name]; duplicate {
+			return nil, fmt.Errorf("%w: option %q given twice", ErrInvalidOptions, name)
+		}
+		values[name] = value
+		rest = strings.TrimSpace(rest)
+	}
+	return values, nil
+}
+
+func closingQuote(value string) int {
+	for i := 1; i < len(value); i++ {
+		switch value[i] {
+		case '\\':
+			i++
+		case '"':
+			return i
+		}
+	}
+	return -1
+}
+
+func convertOption(option CommandOption, value string) (interface{}, error) {
+	invalid := fmt.Errorf("%w: option %q must be a %s", ErrInvalidOptions, option.Name, option.Type)
+	switch option.Type {
+	case OptionString:
+		if len(option.Choices) > 0 && !slices.Contains(option.Choices, value) {
+			return nil, fmt.Errorf("%w: option %q must be one of %s", ErrInvalidOptions, option.Name, strings.Join(option.Choices, ", "))
+		}
+		return value, nil
+	case OptionInteger:
+		parsed, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return nil, invalid
+		}
+		return parsed, nil
+	case OptionNumber:
+		parsed, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return nil, invalid
+		}
+		return parsed, nil
+	case OptionBoolean:
+		parsed, err := strconv.ParseBool(value)
+		if err != nil {
+			return nil, invalid
+		}
+		return parsed, nil
+	case OptionUser, OptionChannel:
+		parsed, err := strconv.ParseInt(value, 10, 32)
+		if err != nil || parsed <= 0 {
+			return nil, fmt.Errorf("%w: option %q must be a %s ID", ErrInvalidOptions, option.Name, option.Type)
+		}
+		return int32(parsed), nil
+	default:
+		return nil, invalid
+	}
+}
@@ -0,0 +1,77 @@
+package interactions
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var banSchema = []CommandOption{
+	{Name: "user", Type: OptionUser, Required: true},
+	{Name: "reason", Type: OptionString},
+	{Name: "days", Type: OptionInteger},
+	{Name: "silent", Type: OptionBoolean},
+	{Name: "mode", Type: OptionString, Choices: []string{"soft", "hard"}},
+}
+
+func TestSplitCommand(t *testing.T) {
+	name, args, ok := splitCommand("/ban user:42 reason:spam")
+	assert.True(t, ok)
+	assert.Equal(t, "ban", name)
+	assert.Equal(t, "user:42 reason:spam", args)
+
+	name, args, ok = splitCommand("/ping")
+	assert.True(t, ok)
+	assert.Equal(t, "ping", name)
+	assert.Empty(t, args)
+
+	for _, content := range []string{"hello", "/", "/Ban", "/ ban", "//ban", "path/to/file"} {
+		_, _, ok := splitCommand(content)
+		assert.False(t, ok, content)
+	}
+}
+
+func TestParseOptions_ConvertsTypes(t *testing.T) {
+	values, err := parseOptions(`user:42 reason:"too many \"pings\"" days:7 silent:true mode:soft`, banSchema)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"user":   int32(42),
+		"reason": `too many "pings"`,
+		"days":   int64(7),
+		"silent": true,
+		"mode":   "soft",
+	}, values)
+}
+
+func TestParseOptions_Errors(t *testing.T) {
+	cases := map[string]string{
+		"missing required": "reason:spam",
+		"unknown option":   "user:1 colour:red",
+		"duplicate":        "user:1 user:2",
+		"bad integer":      "user:1 days:seven",
+		"bad user id":      "user:bob",
+		"bad choice":       "user:1 mode:medium",
+		"unterminated":     `user:1 reason:"spam`,
+		"no colon":         "user:1 spam",
+	}
+	for name, args := range cases {
+		_, err := parseOptions(args, banSchema)
+		assert.ErrorIs(t, err, ErrInvalidOptions, name)
+	}
+}
+
+func TestValidateCommand(t *testing.T) {
+	assert.NoError(t, validateCommand("ban", "Ban a member", banSchema))
+
+	assert.ErrorIs(t, validateCommand("Ban", "", nil), ErrInvalidCommand)
+	assert.ErrorIs(t, validateCommand("ban", "", []CommandOption{{Name: "x", Type: "date"}}), ErrInvalidCommand)
+	assert.ErrorIs(t, validateCommand("ban", "", []CommandOption{{Name: "x", Type: OptionString}, {Name: "x", Type: OptionString}}), ErrInvalidCommand)
+	assert.ErrorIs(t, validateCommand("ban", "", []CommandOption{{Name: "x", Type: OptionInteger, Choices: []string{"1"}}}), ErrInvalidCommand)
+}
+
+func TestValidateResponse(t *testing.T) {
+	assert.NoError(t, validateResponse(InteractionResponse{Type: ResponseDeferred}))
+	assert.NoError(t, validateResponse(InteractionResponse{Type: ResponseMessage, Content: "pong"}))
+	assert.ErrorIs(t, validateResponse(InteractionResponse{Type: ResponseMessage}), ErrInvalidResponse)
+	assert.ErrorIs(t, validateResponse(InteractionResponse{Type: "modal"}), ErrInvalidResponse)
+}
@@ -0,0 +1,213 @@
+package interactions
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"strconv"
+
+	"github.com/andrelcunha/Concord/backend/internal/middleware"
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/websocket/v2"
+)
+
+type Handler struct {
+	service *Service
+}
+
+func NewHandler(service *Service) *Handler {
+	return &Handler{service: service}
+}
+
+func (h *Handler) ListCommands(c *fiber.Ctx) error {
+	botID := c.Locals("userID").(int32)
+	commands, err := h.service.ListBotCommands(c.Context(), botID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(fiber.Map{"commands": commands})
+}
+
+func (h *Handler) RegisterCommand(c *fiber.Ctx) error {
+	var req CommandRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request"})
+	}
+
+	botID := c.Locals("userID").(int32)
+	command, err := h.service.RegisterCommand(c.Context(), botID, req)
+	if err != nil {
+		return interactionErrorResponse(c, err)
+	}
+	return c.JSON(command)
+}
+
+func (h *Handler) DeleteCommand(c *fiber.Ctx) error {
+	commandID, err := strconv.ParseInt(c.Params("id"), 10, 32)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid command ID"})
+	}
+
+	botID := c.Locals("userID").(int32)
+	if err := h.service.DeleteCommand(c.Context(), botID, int32(commandID)); err != nil {
+		return interactionErrorResponse(c, err)
+	}
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+func (h *Handler) ListServerCommands(c *fiber.Ctx) error {
+	serverID, err := strconv.ParseInt(c.Params("id"), 10, 32)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid server ID"})
+	}
+
+	userID := c.Locals("userID").(int32)
+	commands, err := h.service.ListServerCommands(c.Context(), userID, int32(serverID))
+	if err != nil {
+		return interactionErrorResponse(c, err)
+	}
+	return c.JSON(fiber.Map{"commands": commands})
+}
+
+func (h *Handler) SetEndpoint(c *fiber.Ctx) error {
+	botID, err := strconv.ParseInt(c.Params("id"), 10, 32)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid bot ID"})
+	}
+	var req struct {
+		URL string `json:"url"`
+	}
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request"})
+	}
+
+	userID := c.Locals("userID").(int32)
+	endpoint, err := h.service.SetEndpoint(c.Context(), userID, int32(botID), req.URL)
+	if err != nil {
+		return interactionErrorResponse(c, err)
+	}
+	return c.JSON(endpoint)
+}
+
+func (h *Handler) DeleteEndpoint(c *fiber.Ctx) error {
+	botID, err := strconv.ParseInt(c.Params("id"), 10, 32)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid bot ID"})
+	}
+
+	userID := c.Locals("userID").(int32)
+	if err := h.service.DeleteEndpoint(c.Context(), userID, int32(botID)); err != nil {
+		return interactionErrorResponse(c, err)
+	}
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+func (h *Handler) Callback(c *fiber.Ctx) error {
+	var resp InteractionResponse
+	if err := c.BodyParser(&resp); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request"})
+	}
+
+	botID := c.Locals("userID").(int32)
+	if err := h.service.Respond(c.Context(), botID, c.Params("id"), resp); err != nil {
+		return interactionErrorResponse(c, err)
+	}
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+func (h *Handler) Followup(c *fiber.Ctx) error {
+	var resp InteractionResponse
+	if err := c.BodyParser(&resp); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request"})
+	}
+
+	botID := c.Locals("userID").(int32)
+	if err := h.service.Followup(c.Context(), botID, c.Params("id"), resp); err != nil {
+		return interactionErrorResponse(c, err)
+	}
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// Gateway streams interactions to a connected bot. Bots without an HTTP
+// endpoint must hold a gateway connection to receive commands.
+func (h *Handler) Gateway(c *fiber.Ctx) error {
+	botID := c.Locals("userID").(int32)
+
+	return websocket.New(func(conn *websocket.Conn) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		pubsub := h.service.SubscribeGateway(ctx, botID)
+		defer pubsub.Close()
+		defer conn.Close()
+
+		// Wait for the subscription before telling the bot it is ready, so
+		// no interaction published after "ready" is lost.
+		if _, err := pubsub.Receive(ctx); err != nil {
+			log.Printf("Gateway subscribe error: %v", err)
+			return
+		}
+		ready, _ := json.Marshal(GatewayEvent{Type: "ready", BotID: botID})
+		if err := conn.WriteMessage(websocket.TextMessage, ready); err != nil {
+			return
+		}
+
+		// Bots only read from the gateway; a failed read means it closed.
+		go func() {
+			for {
+				if _, _, err := conn.ReadMessage(); err != nil {
+					cancel()
+					pubsub.Close()
+					return
+				}
+			}
+		}()
+
+		for msg := range pubsub.Channel() {
+			if err := conn.WriteMessage(websocket.TextMessage, []byte(msg.Payload)); err != nil {
+				log.Printf("Error writing message: %v", err)
+				return
+			}
+		}
+	})(c)
+}
+
+func interactionErrorResponse(c *fiber.Ctx, err error) error {
+	switch {
+	case errors.Is(err, ErrInvalidCommand), errors.Is(err, ErrInvalidOptions), errors.Is(err, ErrInvalidEndpointURL),
+		errors.Is(err, ErrInvalidResponse), errors.Is(err, ErrNotAcknowledged):
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	case errors.Is(err, ErrAlreadyAcknowledged):
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": err.Error()})
+	case errors.Is(err, ErrBotNotFound), errors.Is(err, ErrCommandNotFound), errors.Is(err, ErrInteractionNotFound):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
+	case errors.Is(err, ErrNotBotOwner), errors.Is(err, ErrNotServerMember):
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": err.Error()})
+	default:
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+}
+
+// RegisterInteractionRoutes mounts command registration and interaction
+// responses for bots, the gateway, server command listings for members and
+// endpoint management for bot owners.
+func RegisterInteractionRoutes(api fiber.Router, service *Service) {
+	handler := NewHandler(service)
+
+	commands := api.Group("/commands", middleware.RequireBot())
+	commands.Get("/", handler.ListCommands)
+	commands.Put("/", handler.RegisterCommand)
+	commands.Delete("/:id", handler.DeleteCommand)
+
+	interactions := api.Group("/interactions", middleware.RequireBot())
+	interactions.Post("/:id/callback", handler.Callback)
+	interactions.Post("/:id/followup", handler.Followup)
+
+	api.Get("/gateway", middleware.RequireBot(), handler.Gateway)
+	api.Get("/servers/:id/commands", middleware.RequireScope(middleware.ScopeMessagesRead), handler.ListServerCommands)
+
+	endpoints := api.Group("/bots/:id/interaction-endpoint", middleware.RequireInteractive())
+	endpoints.Put("/", handler.SetEndpoint)
+	endpoints.Delete("/", handler.DeleteEndpoint)
+}
@@ -0,0 +1,93 @@
+package interactions
+
+import (
+	"context"
+
+	"github.com/andrelcunha/Concord/backend/internal/db"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type Repository interface {
+	GetBot(ctx context.Context, botID int32) (db.GetBotRow, error)
+	IsServerMember(ctx context.Context, serverID, userID int32) (bool, error)
+	UpsertCommand(ctx context.Context, botID, serverID int32, name, description string, options []byte) (db.BotCommand, error)
+	ListBotCommands(ctx context.Context, botID int32) ([]db.BotCommand, error)
+	DeleteCommand(ctx context.Context, commandID, botID int32) (bool, error)
+	ListServerCommands(ctx context.Context, serverID int32) ([]db.ListServerCommandsRow, error)
+	FindChannelCommands(ctx context.Context, channelID int32, name string) ([]db.FindChannelCommandsRow, error)
+	SetEndpoint(ctx context.Context, botID int32, url, secret string) error
+	GetEndpoint(ctx context.Context, botID int32) (db.BotInteractionEndpoint, error)
+	DeleteEndpoint(ctx context.Context, botID int32) (bool, error)
+}
+
+type repository struct {
+	db *db.Queries
+}
+
+func NewRepository(dbPool *pgxpool.Pool) Repository {
+	return &repository{
+		db: db.New(dbPool),
+	}
+}
+
+func (r *repository) GetBot(ctx context.Context, botID int32) (db.GetBotRow, error) {
+	return r.db.GetBot(ctx, botID)
+}
+
+func (r *repository) IsServerMember(ctx context.Context, serverID, userID int32) (bool, error) {
+	return r.db.IsServerMember(ctx, db.IsServerMemberParams{
+		ServerID: serverID,
+		UserID:   userID,
+	})
+}
+
+func (r *repository) UpsertCommand(ctx context.Context, botID, serverID int32, name, description string, options []byte) (db.BotCommand, error) {
+	return r.db.UpsertBotCommand(ctx, db.UpsertBotCommandParams{
+		BotID:       botID,
+		ServerID:    pgtype.Int4{Int32: serverID, Valid: serverID != 0},
+		Name:        name,
+		Description: description,
+		Options:     options,
+	})
+}
+
+func (r *repository) ListBotCommands(ctx context.Context, botID int32) ([]db.BotCommand, error) {
+	return r.db.ListBotCommands(ctx, botID)
+}
+
+func (r *repository) DeleteCommand(ctx context.Context, commandID, botID int32) (bool, error) {
+	rows, err := r.db.DeleteBotCommand(ctx, db.DeleteBotCommandParams{
+		ID:    commandID,
+		BotID: botID,
+	})
+	return rows > 0, err
+}
+
+func (r *repository) ListServerCommands(ctx context.Context, serverID int32) ([]db.ListServerCommandsRow, error) {
+	return r.db.ListServerCommands(ctx, serverID)
+}
+
+func (r *repository) FindChannelCommands(ctx context.Context, channelID int32, name string) ([]db.FindChannelCommandsRow, error) {
+	return r.db.FindChannelCommands(ctx, db.FindChannelCommandsParams{
+		ID:   channelID,
+		Name: name,
+	})
+}
+
+func (r *repository) SetEndpoint(ctx context.Context, botID int32, url, secret string) error {
+	return r.db.UpsertBotInteractionEndpoint(ctx, db.UpsertBotInteractionEndpointParams{
+		BotID:  botID,
+		Url:    url,
+		Secret: secret,
+	})
+}
+
+func (r *repository) GetEndpoint(ctx context.Context, botID int32) (db.BotInteractionEndpoint, error) {
+	return r.db.GetBotInteractionEndpoint(ctx, botID)
+}
+
+func (r *repository) DeleteEndpoint(ctx context.Context, botID int32) (bool, error) {
+	rows, err := r.db.DeleteBotInteractionEndpoint(ctx, botID)
+	return rows > 0, err
+}
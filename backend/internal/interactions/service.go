@@ -0,0 +1,553 @@
+package interactions
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+	"unicode/utf8"
+
+	. "github.com/andrelcunha/Concord/backend/internal/common"
+	"github.com/andrelcunha/Concord/backend/internal/db"
+	"github.com/andrelcunha/Concord/backend/internal/events"
+	"github.com/andrelcunha/Concord/backend/pkg/dtos"
+	"github.com/jackc/pgx/v5"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	// InteractionTTL is how long a bot can respond to or follow up on an
+	// interaction.
+	InteractionTTL = 15 * time.Minute
+
+	// Response types a bot can answer an interaction with.
+	ResponseMessage  = "message"
+	ResponseDeferred = "deferred"
+
+	interactionTimeout = 3 * time.Second
+	maxResponseLength  = 2000
+	endpointSecretSize = 32
+)
+
+var (
+	ErrBotNotFound            = errors.New("bot not found")
+	ErrNotBotOwner            = errors.New("only the bot owner can do this")
+	ErrCommandNotFound        = errors.New("command not found")
+	ErrNotServerMember        = errors.New("not a server member")
+	ErrInvalidEndpointURL     = errors.New("url must be an absolute https URL")
+	ErrAmbiguousCommand       = errors.New("several bots in this server register this command")
+	ErrInteractionNotFound    = errors.New("interaction not found or expired")
+	ErrAlreadyAcknowledged    = errors.New("interaction already acknowledged")
+	ErrNotAcknowledged        = errors.New("interaction must be acknowledged before follow-ups")
+	ErrInvalidResponse        = errors.New("response type must be message or deferred, with content for messages")
+	errBotUnavailable         = errors.New("bot is not connected")
+	errUnexpectedResponseCode = errors.New("interaction endpoint returned an error status")
+)
+
+// MessagePoster posts messages into channels. It is implemented by
+// websocket.Service, which imports this package to dispatch commands.
+type MessagePoster interface {
+	PostMessage(ctx context.Context, channelID int32, author dtos.UserDto, content string) (MessageResponse, error)
+	SendEphemeral(ctx context.Context, channelID, userID int32, message MessageResponse) error
+}
+
+// Interaction is sent to the bot when a user invokes one of its commands.
+type Interaction struct {
+	ID        string             `json:"id"`
+	Type      string             `json:"type"`
+	BotID     int32              `json:"bot_id"`
+	ServerID  int32              `json:"server_id"`
+	ChannelID int32              `json:"channel_id"`
+	User      InteractionUser    `json:"user"`
+	Command   InteractionCommand `json:"command"`
+	CreatedAt string             `json:"created_at"`
+}
+
+type InteractionUser struct {
+	ID       int32  `json:"id"`
+	Username string `json:"username"`
+}
+
+type InteractionCommand struct {
+	ID      int32                  `json:"id"`
+	Name    string                 `json:"name"`
+	Options map[string]interface{} `json:"options"`
+}
+
+// InteractionResponse is a bot's answer. Deferred responses show the
+// invoker a placeholder; the bot then sends follow-ups.
+type InteractionResponse struct {
+	Type      string `json:"type"`
+	Content   string `json:"content"`
+	Ephemeral bool   `json:"ephemeral"`
+}
+
+type CommandRequest struct {
+	Name        string          `json:"name"`
+	Description string          `json:"description"`
+	Options     []CommandOption `json:"options"`
+	ServerID    int32           `json:"server_id"`
+}
+
+type Service struct {
+	repo                 Repository
+	redis                *redis.Client
+	messages             MessagePoster
+	client               *http.Client
+	allowPrivateNetworks bool
+}
+
+func NewService(repo Repository, redis *redis.Client, messages MessagePoster) *Service {
+	return &Service{
+		repo:     repo,
+		redis:    redis,
+		messages: messages,
+		client:   events.NewOutboundClient(interactionTimeout, false),
+	}
+}
+
+// SetAllowPrivateNetworks lets interaction URLs use plain http and private
+// addresses, for local development only.
+func (s *Service) SetAllowPrivateNetworks(allow bool) {
+	s.allowPrivateNetworks = allow
+	s.client = events.NewOutboundClient(interactionTimeout, allow)
+}
+
+// RegisterCommand creates or replaces one of the bot's commands. Server
+// commands need the bot to be in that server.
+func (s *Service) RegisterCommand(ctx context.Context, botID int32, req CommandRequest) (dtos.CommandDto, error) {
+	if err := validateCommand(req.Name, req.Description, req.Options); err != nil {
+		return dtos.CommandDto{}, err
+	}
+	if req.ServerID != 0 {
+		isMember, err := s.repo.IsServerMember(ctx, req.ServerID, botID)
+		if err != nil {
+			return dtos.CommandDto{}, err
+		}
+		if !isMember {
+			return dtos.CommandDto{}, ErrNotServerMember
+		}
+	}
+
+	if req.Options == nil {
+		req.Options = []CommandOption{}
+	}
+	options, err := json.Marshal(req.Options)
+	if err != nil {
+		return dtos.CommandDto{}, err
+	}
+
+	command, err := s.repo.UpsertCommand(ctx, botID, req.ServerID, req.Name, req.Description, options)
+	if err != nil {
+		return dtos.CommandDto{}, err
+	}
+	return toCommandDto(command, ""), nil
+}
+
+func (s *Service) ListBotCommands(ctx context.Context, botID int32) ([]dtos.CommandDto, error) {
+	commands, err := s.repo.ListBotCommands(ctx, botID)
+	if err != nil {
+		return nil, err
+	}
+
+	commandDtos := make([]dtos.CommandDto, 0, len(commands))
+	for _, command := range commands {
+		commandDtos = append(commandDtos, toCommandDto(command, ""))
+	}
+	return commandDtos, nil
+}
+
+func (s *Service) DeleteCommand(ctx context.Context, botID, commandID int32) error {
+	deleted, err := s.repo.DeleteCommand(ctx, commandID, botID)
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		return ErrCommandNotFound
+	}
+	return nil
+}
+
+// ListServerCommands lists the commands members can use in a server, for
+// autocomplete.
+func (s *Service) ListServerCommands(ctx context.Context, userID, serverID int32) ([]dtos.CommandDto, error) {
+	isMember, err := s.repo.IsServerMember(ctx, serverID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if !isMember {
+		return nil, ErrNotServerMember
+	}
+
+	commands, err := s.repo.ListServerCommands(ctx, serverID)
+	if err != nil {
+		return nil, err
+	}
+
+	commandDtos := make([]dtos.CommandDto, 0, len(commands))
+	for _, command := range commands {
+		commandDtos = append(commandDtos, toCommandDto(db.BotCommand{
+			ID:          command.ID,
+			BotID:       command.BotID,
+			ServerID:    command.ServerID,
+			Name:        command.Name,
+			Description: command.Description,
+			Options:     command.Options,
+			UpdatedAt:   command.UpdatedAt,
+		}, command.BotUsername))
+	}
+	return commandDtos, nil
+}
+
+// SetEndpoint makes the bot receive interactions over HTTP instead of the
+// gateway. The returned secret signs every request.
+func (s *Service) SetEndpoint(ctx context.Context, ownerID, botID int32, endpointURL string) (dtos.InteractionEndpointDto, error) {
+	if err := s.checkBotOwner(ctx, ownerID, botID); err != nil {
+		return dtos.InteractionEndpointDto{}, err
+	}
+	parsed, err := url.Parse(endpointURL)
+	if err != nil || parsed.Host == "" || !(parsed.Scheme == "https" || (s.allowPrivateNetworks && parsed.Scheme == "http")) {
+		return dtos.InteractionEndpointDto{}, ErrInvalidEndpointURL
+	}
+
+	secretBytes := make([]byte, endpointSecretSize)
+	if _, err := rand.Read(secretBytes); err != nil {
+		return dtos.InteractionEndpointDto{}, err
+	}
+	secret := "whsec_" + base64.RawURLEncoding.EncodeToString(secretBytes)
+
+	if err := s.repo.SetEndpoint(ctx, botID, endpointURL, secret); err != nil {
+		return dtos.InteractionEndpointDto{}, err
+	}
+	return dtos.InteractionEndpointDto{BotID: botID, URL: endpointURL, Secret: secret}, nil
+}
+
+// DeleteEndpoint switches the bot back to receiving interactions over the
+// gateway.
+func (s *Service) DeleteEndpoint(ctx context.Context, ownerID, botID int32) error {
+	if err := s.checkBotOwner(ctx, ownerID, botID); err != nil {
+		return err
+	}
+	if _, err := s.repo.DeleteEndpoint(ctx, botID); err != nil {
+		return err
+	}
+	return nil
+}
+
+// HandleCommand runs a message typed in a channel as a slash command. It
+// returns false when the message does not name a command registered in the
+// channel's server, so it can be posted as plain text. Input errors are
+// returned for the invoker; dispatch happens in the background.
+func (s *Service) HandleCommand(ctx context.Context, invoker dtos.UserDto, channelID int32, content string) (bool, error) {
+	name, args, ok := splitCommand(content)
+	if !ok {
+		return false, nil
+	}
+
+	commands, err := s.repo.FindChannelCommands(ctx, channelID, name)
+	if err != nil {
+		return true, err
+	}
+	if len(commands) == 0 {
+		return false, nil
+	}
+	command := commands[0]
+	for _, other := range commands[1:] {
+		if other.BotID != command.BotID {
+			return true, ErrAmbiguousCommand
+		}
+	}
+
+	var schema []CommandOption
+	if err := json.Unmarshal(command.Options, &schema); err != nil {
+		return true, err
+	}
+	options, err := parseOptions(args, schema)
+	if err != nil {
+		return true, err
+	}
+
+	interactionID, err := generateInteractionID()
+	if err != nil {
+		return true, err
+	}
+	interaction := Interaction{
+		ID:        interactionID,
+		Type:      "command",
+		BotID:     command.BotID,
+		ServerID:  command.ChannelServerID,
+		ChannelID: channelID,
+		User:      InteractionUser{ID: invoker.UserId, Username: invoker.Username},
+		Command:   InteractionCommand{ID: command.ID, Name: command.Name, Options: options},
+		CreatedAt: time.Now().UTC().Format("2006-01-02T15:04:05Z07:00"),
+	}
+	interactionJSON, err := json.Marshal(interaction)
+	if err != nil {
+		return true, err
+	}
+	if err := s.redis.Set(ctx, interactionKey(interactionID), interactionJSON, InteractionTTL).Err(); err != nil {
+		return true, err
+	}
+
+	bot := dtos.UserDto{
+		UserId:      command.BotID,
+		Username:    command.BotUsername,
+		AvatarUrl:   command.BotAvatarUrl.String,
+		AvatarColor: command.BotAvatarColor.String,
+		IsBot:       true,
+	}
+	go s.dispatch(context.Background(), interaction, interactionJSON, bot)
+	return true, nil
+}
+
+// Respond acknowledges an interaction. Each interaction accepts exactly one
+// response.
+func (s *Service) Respond(ctx context.Context, botID int32, interactionID string, resp InteractionResponse) error {
+	interaction, err := s.loadInteraction(ctx, botID, interactionID)
+	if err != nil {
+		return err
+	}
+	bot, err := s.botAuthor(ctx, botID)
+	if err != nil {
+		return err
+	}
+	return s.respond(ctx, interaction, bot, resp)
+}
+
+// Followup sends another message for an acknowledged interaction, usually
+// the result of a deferred command.
+func (s *Service) Followup(ctx context.Context, botID int32, interactionID string, resp InteractionResponse) error {
+	interaction, err := s.loadInteraction(ctx, botID, interactionID)
+	if err != nil {
+		return err
+	}
+	acknowledged, err := s.redis.Exists(ctx, interactionAckKey(interactionID)).Result()
+	if err != nil {
+		return err
+	}
+	if acknowledged == 0 {
+		return ErrNotAcknowledged
+	}
+	bot, err := s.botAuthor(ctx, botID)
+	if err != nil {
+		return err
+	}
+
+	resp.Type = ResponseMessage
+	if err := validateResponse(resp); err != nil {
+		return err
+	}
+	return s.deliverResponse(ctx, interaction, bot, resp)
+}
+
+// SubscribeGateway returns the stream of interactions for a connected bot.
+func (s *Service) SubscribeGateway(ctx context.Context, botID int32) *redis.PubSub {
+	return s.redis.Subscribe(ctx, gatewayChannel(botID))
+}
+
+// dispatch delivers the interaction to the bot's HTTP endpoint when one is
+// set, and to its gateway connections otherwise. Failures are reported to
+// the invoker only.
+func (s *Service) dispatch(ctx context.Context, interaction Interaction, interactionJSON []byte, bot dtos.UserDto) {
+	endpoint, err := s.repo.GetEndpoint(ctx, interaction.BotID)
+	switch {
+	case err == nil:
+		resp, err := s.callEndpoint(ctx, endpoint, interactionJSON)
+		if err == nil {
+			err = s.respond(ctx, interaction, bot, resp)
+		}
+		if err != nil {
+			log.Printf("Interaction %s for bot %d failed: %v", interaction.ID, interaction.BotID, err)
+			s.notifyInvoker(ctx, interaction, bot, fmt.Sprintf("%s did not respond to /%s.", bot.Username, interaction.Command.Name))
+		}
+	case errors.Is(err, pgx.ErrNoRows):
+		payload, _ := json.Marshal(GatewayEvent{Type: "interaction_create", Interaction: interaction})
+		receivers, err := s.redis.Publish(ctx, gatewayChannel(interaction.BotID), payload).Result()
+		if err == nil && receivers == 0 {
+			err = errBotUnavailable
+		}
+		if err != nil {
+			s.notifyInvoker(ctx, interaction, bot, fmt.Sprintf("%s is offline.", bot.Username))
+		}
+	default:
+		log.Printf("GetEndpoint error: %v", err)
+		s.notifyInvoker(ctx, interaction, bot, fmt.Sprintf("%s did not respond to /%s.", bot.Username, interaction.Command.Name))
+	}
+}
+
+func (s *Service) callEndpoint(ctx context.Context, endpoint db.BotInteractionEndpoint, interactionJSON []byte) (InteractionResponse, error) {
+	ctx, cancel := context.WithTimeout(ctx, interactionTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint.Url, bytes.NewReader(interactionJSON))
+	if err != nil {
+		return InteractionResponse{}, err
+	}
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "Concord-Interactions/1.0")
+	req.Header.Set(events.HeaderTimestamp, timestamp)
+	req.Header.Set(events.HeaderSignature, events.Sign(endpoint.Secret, timestamp, interactionJSON))
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return InteractionResponse{}, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return InteractionResponse{}, errUnexpectedResponseCode
+	}
+
+	var interactionResp InteractionResponse
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 64<<10)).Decode(&interactionResp); err != nil {
+		return InteractionResponse{}, err
+	}
+	return interactionResp, nil
+}
+
+func (s *Service) respond(ctx context.Context, interaction Interaction, bot dtos.UserDto, resp InteractionResponse) error {
+	if err := validateResponse(resp); err != nil {
+		return err
+	}
+	acknowledged, err := s.redis.SetNX(ctx, interactionAckKey(interaction.ID), 1, InteractionTTL).Result()
+	if err != nil {
+		return err
+	}
+	if !acknowledged {
+		return ErrAlreadyAcknowledged
+	}
+
+	if resp.Type == ResponseDeferred {
+		s.notifyInvoker(ctx, interaction, bot, fmt.Sprintf("%s is thinking...", bot.Username))
+		return nil
+	}
+	return s.deliverResponse(ctx, interaction, bot, resp)
+}
+
+func (s *Service) deliverResponse(ctx context.Context, interaction Interaction, bot dtos.UserDto, resp InteractionResponse) error {
+	if resp.Ephemeral {
+		return s.messages.SendEphemeral(ctx, interaction.ChannelID, interaction.User.ID, ephemeralFrom(interaction, bot, resp.Content))
+	}
+	_, err := s.messages.PostMessage(ctx, interaction.ChannelID, bot, resp.Content)
+	return err
+}
+
+func (s *Service) notifyInvoker(ctx context.Context, interaction Interaction, bot dtos.UserDto, content string) {
+	if err := s.messages.SendEphemeral(ctx, interaction.ChannelID, interaction.User.ID, ephemeralFrom(interaction, bot, content)); err != nil {
+		log.Printf("SendEphemeral error: %v", err)
+	}
+}
+
+func (s *Service) loadInteraction(ctx context.Context, botID int32, interactionID string) (Interaction, error) {
+	interactionJSON, err := s.redis.Get(ctx, interactionKey(interactionID)).Bytes()
+	if err != nil {
+		return Interaction{}, ErrInteractionNotFound
+	}
+	var interaction Interaction
+	if err := json.Unmarshal(interactionJSON, &interaction); err != nil || interaction.BotID != botID {
+		return Interaction{}, ErrInteractionNotFound
+	}
+	return interaction, nil
+}
+
+func (s *Service) botAuthor(ctx context.Context, botID int32) (dtos.UserDto, error) {
+	bot, err := s.repo.GetBot(ctx, botID)
+	if err != nil {
+		return dtos.UserDto{}, ErrBotNotFound
+	}
+	return dtos.UserDto{
+		UserId:      bot.ID,
+		Username:    bot.Username,
+		AvatarUrl:   bot.AvatarUrl.String,
+		AvatarColor: bot.AvatarColor.String,
+		IsBot:       true,
+	}, nil
+}
+
+func (s *Service) checkBotOwner(ctx context.Context, ownerID, botID int32) error {
+	bot, err := s.repo.GetBot(ctx, botID)
+	if err != nil {
+		return ErrBotNotFound
+	}
+	if !bot.BotOwnerID.Valid || bot.BotOwnerID.Int32 != ownerID {
+		return ErrNotBotOwner
+	}
+	return nil
+}
+
+func validateResponse(resp InteractionResponse) error {
+	switch resp.Type {
+	case ResponseDeferred:
+		return nil
+	case ResponseMessage:
+		if resp.Content == "" || utf8.RuneCountInString(resp.Content) > maxResponseLength {
+			return ErrInvalidResponse
+		}
+		return nil
+	default:
+		return ErrInvalidResponse
+	}
+}
+
+func ephemeralFrom(interaction Interaction, bot dtos.UserDto, content string) MessageResponse {
+	return MessageResponse{
+		ChannelID:   int(interaction.ChannelID),
+		UserID:      int(bot.UserId),
+		Content:     content,
+		Username:    bot.Username,
+		CreatedAt:   time.Now().UTC().Format("2006-01-02T15:04:05Z07:00"),
+		AvatarURL:   bot.AvatarUrl,
+		AvatarColor: bot.AvatarColor,
+		IsBot:       true,
+	}
+}
+
+func generateInteractionID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+func interactionKey(interactionID string) string {
+	return "interaction:" + interactionID
+}
+
+func interactionAckKey(interactionID string) string {
+	return "interaction_ack:" + interactionID
+}
+
+func gatewayChannel(botID int32) string {
+	return fmt.Sprintf("bot_gateway:%d", botID)
+}
+
+func toCommandDto(command db.BotCommand, botUsername string) dtos.CommandDto {
+	return dtos.CommandDto{
+		ID:          command.ID,
+		BotID:       command.BotID,
+		BotUsername: botUsername,
+		ServerID:    command.ServerID.Int32,
+		Name:        command.Name,
+		Description: command.Description,
+		Options:     command.Options,
+		UpdatedAt:   command.UpdatedAt.Time.Format("2006-01-02T15:04:05Z07:00"),
+	}
+}
+
+// GatewayEvent is a frame sent to bots connected to the gateway.
+type GatewayEvent struct {
+	Type        string      `json:"type"`
+	BotID       int32       `json:"bot_id,omitempty"`
+	Interaction Interaction `json:"interaction,omitempty"`
+}
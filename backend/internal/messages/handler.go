@@ -4,6 +4,7 @@ import (
 	"strconv"
 
 	. "github.com/andrelcunha/Concord/backend/internal/common"
+	"github.com/andrelcunha/Concord/backend/internal/middleware"
 	"github.com/gofiber/fiber/v2"
 )
 
@@ -21,26 +22,39 @@ func (h *MessageHandler) GetMessages(c *fiber.Ctx) error {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid channel ID"})
 	}
 
-	messageDtos, err := h.Service.ListMessagesByChannel(c.Context(), int32(channelID), 10, 0)
+	userID := c.Locals("userID").(int32)
+	canAccess, err := h.Service.CanAccessChannel(c.Context(), int32(channelID), userID)
+	if err != nil || !canAccess {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Not a server member"})
+	}
+
+	messageDtos, err := h.Service.ListMessagesByChannel(c.Context(), int32(channelID), userID, 10, 0)
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to get messages"})
 	}
 	response := make([]MessageResponse, len(messageDtos))
 	for i, dto := range messageDtos {
 		response[i] = MessageResponse{
-			ID:          dto.ID,
-			ChannelID:   dto.ChannelID,
-			UserID:      dto.UserID,
-			Content:     dto.Content,
-			Username:    dto.Username,
-			CreatedAt:   dto.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
-			AvatarURL:   dto.AvatarUrl,
-			AvatarColor: dto.AvatarColor,
+			ID:             dto.ID,
+			ChannelID:      dto.ChannelID,
+			UserID:         dto.UserID,
+			Content:        dto.Content,
+			Username:       dto.Username,
+			CreatedAt:      dto.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
+			AvatarURL:      dto.AvatarUrl,
+			AvatarColor:    dto.AvatarColor,
+			IsBot:          dto.IsBot,
+			WebhookID:      dto.WebhookID,
+			Embeds:         dto.Embeds,
+			AuthorBlocked:  dto.AuthorBlocked,
+			AuthorNickname: dto.AuthorNickname,
+			ServerNickname: dto.ServerNickname,
+			DisplayName:    dto.DisplayName,
 		}
 	}
 	return c.JSON(response)
 }
 func RegisterMessageRoutes(api fiber.Router, service *Service) {
 	handler := NewHandler(service)
-	api.Get("/channels/:id/messages", handler.GetMessages)
+	api.Get("/channels/:id/messages", middleware.RequireScope(middleware.ScopeMessagesRead), handler.GetMessages)
 }
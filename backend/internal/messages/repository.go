@@ -2,6 +2,7 @@ package messages
 
 import (
 	"context"
+	"encoding/json"
 
 	"github.com/andrelcunha/Concord/backend/internal/db"
 	"github.com/andrelcunha/Concord/backend/pkg/dtos"
@@ -15,7 +16,10 @@ type repository struct {
 
 type Repository interface {
 	CreateMessage(ctx context.Context, channelID, userID int32, content, username string) (dtos.MessageDto, error)
+	CreateWebhookMessage(ctx context.Context, channelID, webhookID int32, content, username, avatarURL string, embeds []dtos.Embed) (dtos.MessageDto, error)
 	ListMessagesByChannel(ctx context.Context, channelID, limit, offset int32) ([]dtos.MessageDto, error)
+	CanAccessChannel(ctx context.Context, channelID, userID int32) (bool, error)
+	GetMemberProfile(ctx context.Context, channelID, userID int32) (nickname, avatarURL string, err error)
 }
 
 func NewRepository(dbPool *pgxpool.Pool) Repository {
@@ -28,7 +32,7 @@ func NewRepository(dbPool *pgxpool.Pool) Repository {
 func (r *repository) CreateMessage(ctx context.Context, channelID int32, userID int32, content, username string) (dtos.MessageDto, error) {
 	message, err := r.db.CreateMessage(ctx, db.CreateMessageParams{
 		ChannelID: channelID,
-		UserID:    userID,
+		UserID:    pgtype.Int4{Int32: userID, Valid: true},
 		Content:   content,
 	})
 	if err != nil {
@@ -38,13 +42,50 @@ func (r *repository) CreateMessage(ctx context.Context, channelID int32, userID
 	messageDto := dtos.MessageDto{
 		ID:        int(message.ID),
 		ChannelID: int(message.ChannelID),
-		UserID:    int(message.UserID),
+		UserID:    int(message.UserID.Int32),
 		Content:   message.Content,
 		CreatedAt: message.CreatedAt.Time,
 	}
 	return messageDto, nil
 }
 
+// CreateWebhookMessage stores a message posted by a webhook. The display name
+// and avatar are kept on the message since there is no author account.
+func (r *repository) CreateWebhookMessage(ctx context.Context, channelID, webhookID int32, content, username, avatarURL string, embeds []dtos.Embed) (dtos.MessageDto, error) {
+	var embedsJSON []byte
+	if len(embeds) > 0 {
+		var err error
+		embedsJSON, err = json.Marshal(embeds)
+		if err != nil {
+			return dtos.MessageDto{}, err
+		}
+	}
+
+	message, err := r.db.CreateWebhookMessage(ctx, db.CreateWebhookMessageParams{
+		ChannelID:       channelID,
+		WebhookID:       pgtype.Int4{Int32: webhookID, Valid: true},
+		AuthorName:      pgtype.Text{String: username, Valid: true},
+		AuthorAvatarUrl: pgtype.Text{String: avatarURL, Valid: avatarURL != ""},
+		Content:         content,
+		Embeds:          embedsJSON,
+	})
+	if err != nil {
+		return dtos.MessageDto{}, err
+	}
+
+	return dtos.MessageDto{
+		ID:        int(message.ID),
+		ChannelID: int(message.ChannelID),
+		Username:  username,
+		Content:   message.Content,
+		CreatedAt: message.CreatedAt.Time,
+		AvatarUrl: avatarURL,
+		IsBot:     true,
+		WebhookID: int(message.WebhookID.Int32),
+		Embeds:    embeds,
+	}, nil
+}
+
 func (r *repository) ListMessagesByChannel(ctx context.Context, channelID, limit, offset int32) ([]dtos.MessageDto, error) {
 	messages, err := r.db.ListMessagesByChannel(ctx, db.ListMessagesByChannelParams{
 		ChannelID: channelID,
@@ -59,20 +100,57 @@ func (r *repository) ListMessagesByChannel(ctx context.Context, channelID, limit
 	var messageDtos []dtos.MessageDto
 	for _, m := range messages {
 		messageDtos = append(messageDtos, dtos.MessageDto{
-			ID:          int(m.ID),
-			ChannelID:   int(m.ChannelID),
-			UserID:      int(m.UserID),
-			Username:    extractText(m.Username),
-			Content:     m.Content,
-			CreatedAt:   m.CreatedAt.Time,
-			AvatarUrl:   extractText(m.AvatarUrl),
-			AvatarColor: extractText(m.AvatarColor),
+			ID:             int(m.ID),
+			ChannelID:      int(m.ChannelID),
+			UserID:         int(m.UserID.Int32),
+			Username:       m.Username,
+			Content:        m.Content,
+			CreatedAt:      m.CreatedAt.Time,
+			AvatarUrl:      m.AvatarUrl,
+			AvatarColor:    extractText(m.AvatarColor),
+			IsBot:          m.IsBot,
+			WebhookID:      int(m.WebhookID.Int32),
+			Embeds:         decodeEmbeds(m.Embeds),
+			ServerNickname: m.ServerNickname,
+			DisplayName:    m.DisplayName,
 		})
 	}
 
 	return messageDtos, nil
 }
 
+func (r *repository) CanAccessChannel(ctx context.Context, channelID, userID int32) (bool, error) {
+	return r.db.CanAccessChannel(ctx, db.CanAccessChannelParams{
+		ID:     channelID,
+		UserID: userID,
+	})
+}
+
+// GetMemberProfile returns the user's nickname and avatar in the server that
+// owns the channel; both are empty when unset.
+func (r *repository) GetMemberProfile(ctx context.Context, channelID, userID int32) (string, string, error) {
+	profile, err := r.db.GetChannelMemberProfile(ctx, db.GetChannelMemberProfileParams{
+		ID:     channelID,
+		UserID: userID,
+	})
+	if err != nil {
+		return "", "", err
+	}
+	return profile.Nickname.String, profile.AvatarUrl.String, nil
+}
+
+// decodeEmbeds ignores malformed embeds rather than failing the whole page.
+func decodeEmbeds(raw []byte) []dtos.Embed {
+	if len(raw) == 0 {
+		return nil
+	}
+	var embeds []dtos.Embed
+	if err := json.Unmarshal(raw, &embeds); err != nil {
+		return nil
+	}
+	return embeds
+}
+
 // Helper function to extract string values safely from pgtype.Text
 func extractText(t pgtype.Text) string {
 	if t.Valid {
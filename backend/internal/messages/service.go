@@ -3,25 +3,61 @@ package messages
 import (
 	"context"
 	"log"
+	"slices"
 
+	"github.com/andrelcunha/Concord/backend/internal/annotations"
+	"github.com/andrelcunha/Concord/backend/internal/blocks"
 	"github.com/andrelcunha/Concord/backend/pkg/dtos"
 )
 
 type Service struct {
-	repo Repository
+	repo        Repository
+	blockRepo   blocks.Repository
+	annotations *annotations.Service
 }
 
-func NewService(repo Repository) *Service {
+func NewService(repo Repository, blockRepo blocks.Repository, annotationsService *annotations.Service) *Service {
 	return &Service{
-		repo: repo,
+		repo:        repo,
+		blockRepo:   blockRepo,
+		annotations: annotationsService,
 	}
 }
 
-func (s *Service) ListMessagesByChannel(ctx context.Context, channelID, limit, offset int32) ([]dtos.MessageDto, error) {
+// ListMessagesByChannel returns a page of history as viewerID sees it:
+// messages from users they blocked are kept in place but marked, so clients
+// can collapse them, and authors carry the viewer's nickname for them.
+func (s *Service) ListMessagesByChannel(ctx context.Context, channelID, viewerID, limit, offset int32) ([]dtos.MessageDto, error) {
 	messages, err := s.repo.ListMessagesByChannel(ctx, channelID, limit, offset)
 	if err != nil {
 		log.Printf("ListMessagesByChannel error: %v", err)
 		return nil, err
 	}
+
+	blockedIDs, err := s.blockRepo.ListBlockedUserIDs(ctx, viewerID)
+	if err != nil {
+		log.Printf("ListBlockedUserIDs error: %v", err)
+		return nil, err
+	}
+	notes, err := s.annotations.ForOwner(ctx, viewerID)
+	if err != nil {
+		log.Printf("ForOwner error: %v", err)
+		return nil, err
+	}
+	for i := range messages {
+		if messages[i].WebhookID != 0 {
+			continue
+		}
+		if slices.Contains(blockedIDs, int32(messages[i].UserID)) {
+			messages[i].AuthorBlocked = true
+		}
+		messages[i].AuthorNickname = notes.Nickname(int32(messages[i].UserID))
+	}
 	return messages, nil
 }
+
+// CanAccessChannel reports whether the user is a member of the channel's
+// server.
+func (s *Service) CanAccessChannel(ctx context.Context, channelID, userID int32) (bool, error) {
+	return s.repo.CanAccessChannel(ctx, channelID, userID)
+}
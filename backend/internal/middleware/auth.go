@@ -1,27 +1,55 @@
 package middleware
 
 import (
+	"context"
 	"encoding/json"
 	"strings"
 
 	"github.com/andrelcunha/Concord/backend/pkg/dtos"
 	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/websocket/v2"
 	"github.com/golang-jwt/jwt/v5"
 )
 
-func Auth(secret string) fiber.Handler {
+// PersonalAccessTokenPrefix marks bearer tokens that are personal access
+// tokens rather than JWTs.
+const PersonalAccessTokenPrefix = "cpat_"
+
+type WebSocketTicketRedeemer interface {
+	RedeemWebSocketTicket(ctx context.Context, ticket string) (*dtos.UserDto, []string, error)
+}
+
+type PersonalAccessTokenAuthenticator interface {
+	AuthenticatePersonalAccessToken(ctx context.Context, token string) (*dtos.UserDto, []string, error)
+}
+
+type BotAuthenticator interface {
+	AuthenticateBot(ctx context.Context, token string) (*dtos.UserDto, error)
+}
+
+// Auth authenticates regular requests with a bearer JWT, a personal access
+// token or a "Bot <token>" credential. WebSocket upgrades are authenticated
+// only with a single-use ?ticket= so that long-lived credentials never appear
+// in URLs, logs or proxies.
+func Auth(secret string, tickets WebSocketTicketRedeemer, accessTokens PersonalAccessTokenAuthenticator, bots BotAuthenticator) fiber.Handler {
 	return func(c *fiber.Ctx) error {
-		tokenString := ""
-		authHeader := c.Get("Authorization")
-		if authHeader != "" {
-			tokenString = strings.TrimPrefix(authHeader, "Bearer ")
-		} else {
-			tokenString = c.Query("token")
+		if websocket.IsWebSocketUpgrade(c) {
+			return authenticateWebSocketTicket(c, tickets)
 		}
+
+		if botToken, isBot := strings.CutPrefix(c.Get("Authorization"), "Bot "); isBot {
+			return authenticateBot(c, bots, botToken)
+		}
+
+		tokenString := strings.TrimPrefix(c.Get("Authorization"), "Bearer ")
 		if tokenString == "" {
 			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Unauthorized"})
 		}
 
+		if strings.HasPrefix(tokenString, PersonalAccessTokenPrefix) {
+			return authenticatePersonalAccessToken(c, accessTokens, tokenString)
+		}
+
 		token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
 			if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
 				return nil, fiber.ErrUnauthorized
@@ -59,10 +87,60 @@ func Auth(secret string) fiber.Handler {
 			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Invalid user data"})
 		}
 
-		c.Locals("userID", int32(userID))
-		c.Locals("username", username)
-		c.Locals("avatar_url", userDto.AvatarUrl)
-		c.Locals("avatar_color", userDto.AvatarColor)
+		userDto.UserId = int32(userID)
+		userDto.Username = username
+		setUserLocals(c, &userDto)
 		return c.Next()
 	}
 }
+
+func setUserLocals(c *fiber.Ctx, userDto *dtos.UserDto) {
+	c.Locals("userID", userDto.UserId)
+	c.Locals("username", userDto.Username)
+	c.Locals("display_name", userDto.DisplayName)
+	c.Locals("avatar_url", userDto.AvatarUrl)
+	c.Locals("avatar_color", userDto.AvatarColor)
+	c.Locals("isBot", userDto.IsBot)
+}
+
+// authenticateWebSocketTicket restores the scopes the ticket was minted with,
+// so a token's ticket passes the same scope checks as the token itself.
+func authenticateWebSocketTicket(c *fiber.Ctx, tickets WebSocketTicketRedeemer) error {
+	userDto, scopes, err := tickets.RedeemWebSocketTicket(c.Context(), c.Query("ticket"))
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Invalid websocket ticket"})
+	}
+
+	setUserLocals(c, userDto)
+	if scopes != nil {
+		c.Locals("scopes", scopes)
+	}
+	return c.Next()
+}
+
+// authenticatePersonalAccessToken stores the granted scopes in locals so
+// RequireScope can enforce them per route.
+func authenticatePersonalAccessToken(c *fiber.Ctx, accessTokens PersonalAccessTokenAuthenticator, token string) error {
+	userDto, scopes, err := accessTokens.AuthenticatePersonalAccessToken(c.Context(), token)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Invalid token"})
+	}
+
+	setUserLocals(c, userDto)
+	c.Locals("scopes", scopes)
+	return c.Next()
+}
+
+// authenticateBot grants bots the fixed BotScopes, so the same per-route
+// scope checks that limit personal access tokens also limit bots.
+func authenticateBot(c *fiber.Ctx, bots BotAuthenticator, token string) error {
+	userDto, err := bots.AuthenticateBot(c.Context(), token)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Invalid bot token"})
+	}
+
+	userDto.IsBot = true
+	setUserLocals(c, userDto)
+	c.Locals("scopes", BotScopes)
+	return c.Next()
+}
@@ -0,0 +1,168 @@
+package middleware
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/andrelcunha/Concord/backend/pkg/dtos"
+	"github.com/gofiber/fiber/v2"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testSecret = "testsecret"
+
+// Credentials accepted by the fake authenticators in newAuthApp.
+const (
+	testAccessToken = PersonalAccessTokenPrefix + "reader"
+	testBotToken    = "bot-token"
+)
+
+type fakeTicket struct {
+	user   *dtos.UserDto
+	scopes []string
+}
+
+type fakeTicketRedeemer map[string]fakeTicket
+
+func (f fakeTicketRedeemer) RedeemWebSocketTicket(ctx context.Context, ticket string) (*dtos.UserDto, []string, error) {
+	stored, ok := f[ticket]
+	if !ok {
+		return nil, nil, errors.New("invalid ticket")
+	}
+	delete(f, ticket)
+	return stored.user, stored.scopes, nil
+}
+
+type fakeAccessTokens map[string][]string
+
+func (f fakeAccessTokens) AuthenticatePersonalAccessToken(ctx context.Context, token string) (*dtos.UserDto, []string, error) {
+	scopes, ok := f[token]
+	if !ok {
+		return nil, nil, errors.New("invalid token")
+	}
+	return &dtos.UserDto{UserId: 1, Username: "alice"}, scopes, nil
+}
+
+type fakeBots map[string]*dtos.UserDto
+
+func (f fakeBots) AuthenticateBot(ctx context.Context, token string) (*dtos.UserDto, error) {
+	bot, ok := f[token]
+	if !ok {
+		return nil, errors.New("invalid bot token")
+	}
+	copied := *bot
+	return &copied, nil
+}
+
+// newAuthApp serves GET /test behind Auth and the given guards, accepting
+// testAccessToken (messages:read only) and testBotToken.
+func newAuthApp(guards ...fiber.Handler) *fiber.App {
+	app := fiber.New()
+	auth := Auth(testSecret, fakeTicketRedeemer{},
+		fakeAccessTokens{testAccessToken: {ScopeMessagesRead}},
+		fakeBots{testBotToken: {UserId: 2, Username: "helper"}})
+	handlers := append([]fiber.Handler{auth}, guards...)
+	handlers = append(handlers, func(c *fiber.Ctx) error { return c.SendStatus(fiber.StatusOK) })
+	app.Get("/test", handlers...)
+	return app
+}
+
+func signTestJWT(t *testing.T, secret string, user dtos.UserDto) string {
+	t.Helper()
+	userJSON, err := json.Marshal(user)
+	require.NoError(t, err)
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"sub":      user.UserId,
+		"username": user.Username,
+		"user":     string(userJSON),
+	})
+	signed, err := token.SignedString([]byte(secret))
+	require.NoError(t, err)
+	return signed
+}
+
+// requestStatus sends GET /test with the given Authorization header value.
+func requestStatus(t *testing.T, app *fiber.App, authorization string) int {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodGet, "/test", nil)
+	if authorization != "" {
+		req.Header.Set("Authorization", authorization)
+	}
+	resp, err := app.Test(req)
+	require.NoError(t, err)
+	return resp.StatusCode
+}
+
+func newWebSocketRequest(target string) *http.Request {
+	req := httptest.NewRequest(http.MethodGet, target, nil)
+	req.Header.Set("Connection", "Upgrade")
+	req.Header.Set("Upgrade", "websocket")
+	return req
+}
+
+func newTicketApp(tickets WebSocketTicketRedeemer, guards ...fiber.Handler) *fiber.App {
+	app := fiber.New()
+	handlers := append([]fiber.Handler{Auth(testSecret, tickets, nil, nil)}, guards...)
+	handlers = append(handlers, func(c *fiber.Ctx) error { return c.SendStatus(fiber.StatusOK) })
+	app.Get("/ws", handlers...)
+	return app
+}
+
+func TestAuth_WebSocketTicketKeepsTokenScopes(t *testing.T) {
+	tickets := fakeTicketRedeemer{
+		"interactive": {user: &dtos.UserDto{UserId: 1, Username: "alice"}},
+		"scoped":      {user: &dtos.UserDto{UserId: 1, Username: "alice"}, scopes: []string{ScopeMessagesRead, ScopeMessagesSend}},
+	}
+	app := newTicketApp(tickets, RequireInteractive())
+
+	resp, err := app.Test(newWebSocketRequest("/ws?ticket=interactive"))
+	assert.NoError(t, err)
+	assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+
+	resp, err = app.Test(newWebSocketRequest("/ws?ticket=scoped"))
+	assert.NoError(t, err)
+	assert.Equal(t, fiber.StatusForbidden, resp.StatusCode)
+}
+
+func TestAuth_WebSocketTicketIsSingleUse(t *testing.T) {
+	tickets := fakeTicketRedeemer{"once": {user: &dtos.UserDto{UserId: 1, Username: "alice"}}}
+	app := newTicketApp(tickets)
+
+	resp, err := app.Test(newWebSocketRequest("/ws?ticket=once"))
+	require.NoError(t, err)
+	assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+
+	resp, err = app.Test(newWebSocketRequest("/ws?ticket=once"))
+	require.NoError(t, err)
+	assert.Equal(t, fiber.StatusUnauthorized, resp.StatusCode)
+}
+
+func TestAuth_WebSocketUpgradeIgnoresBearerTokens(t *testing.T) {
+	app := newTicketApp(fakeTicketRedeemer{})
+	req := newWebSocketRequest("/ws")
+	req.Header.Set("Authorization", "Bearer "+signTestJWT(t, testSecret, dtos.UserDto{UserId: 1, Username: "alice"}))
+
+	resp, err := app.Test(req)
+	require.NoError(t, err)
+	assert.Equal(t, fiber.StatusUnauthorized, resp.StatusCode)
+}
+
+func TestAuth_Credentials(t *testing.T) {
+	app := newAuthApp()
+	jwtToken := signTestJWT(t, testSecret, dtos.UserDto{UserId: 1, Username: "alice"})
+
+	assert.Equal(t, fiber.StatusOK, requestStatus(t, app, "Bearer "+jwtToken))
+	assert.Equal(t, fiber.StatusOK, requestStatus(t, app, "Bearer "+testAccessToken))
+	assert.Equal(t, fiber.StatusOK, requestStatus(t, app, "Bot "+testBotToken))
+
+	assert.Equal(t, fiber.StatusUnauthorized, requestStatus(t, app, ""))
+	assert.Equal(t, fiber.StatusUnauthorized, requestStatus(t, app, "Bearer "+signTestJWT(t, "othersecret", dtos.UserDto{UserId: 1, Username: "alice"})))
+	assert.Equal(t, fiber.StatusUnauthorized, requestStatus(t, app, "Bearer "+PersonalAccessTokenPrefix+"unknown"))
+	assert.Equal(t, fiber.StatusUnauthorized, requestStatus(t, app, "Bot unknown"))
+}
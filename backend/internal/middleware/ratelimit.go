@@ -0,0 +1,55 @@
+package middleware
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"strconv"
+
+	"github.com/andrelcunha/Concord/backend/internal/ratelimit"
+	"github.com/gofiber/fiber/v2"
+)
+
+// RateLimitKeyFunc picks the subject a request is counted against.
+type RateLimitKeyFunc func(c *fiber.Ctx) string
+
+func KeyByIP(c *fiber.Ctx) string {
+	return "ip:" + c.IP()
+}
+
+// KeyByUser falls back to the client IP for unauthenticated requests.
+func KeyByUser(c *fiber.Ctx) string {
+	if userID, ok := c.Locals("userID").(int32); ok {
+		return fmt.Sprintf("user:%d", userID)
+	}
+	return KeyByIP(c)
+}
+
+func KeyByIPAndUser(c *fiber.Ctx) string {
+	return KeyByIP(c) + ":" + KeyByUser(c)
+}
+
+// RateLimit rejects requests over rule with 429 and a Retry-After header.
+// If Redis is unavailable the request is let through rather than failing.
+func RateLimit(limiter *ratelimit.Limiter, rule ratelimit.Rule, keyFunc RateLimitKeyFunc) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		result, err := limiter.Allow(c.Context(), rule, keyFunc(c))
+		if err != nil {
+			log.Printf("rate limit %s: %v", rule.Name, err)
+			return c.Next()
+		}
+
+		c.Set("X-RateLimit-Limit", strconv.Itoa(result.Limit))
+		c.Set("X-RateLimit-Remaining", strconv.Itoa(result.Remaining))
+		if !result.Allowed {
+			SetRetryAfter(c, result.RetryAfter.Seconds())
+			return c.Status(fiber.StatusTooManyRequests).JSON(fiber.Map{"error": "Too many requests"})
+		}
+		return c.Next()
+	}
+}
+
+// SetRetryAfter writes a whole-second Retry-After header, rounding up.
+func SetRetryAfter(c *fiber.Ctx, seconds float64) {
+	c.Set(fiber.HeaderRetryAfter, strconv.Itoa(int(math.Max(1, math.Ceil(seconds)))))
+}
@@ -0,0 +1,76 @@
+package middleware
+
+import (
+	"slices"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Scopes that personal access tokens can be granted. Interactive sessions
+// (JWT logins) are not scoped and pass every scope check.
+const (
+	ScopeMessagesRead  = "messages:read"
+	ScopeMessagesSend  = "messages:send"
+	ScopeServersManage = "servers:manage"
+)
+
+var AllScopes = []string{ScopeMessagesRead, ScopeMessagesSend, ScopeServersManage}
+
+// BotScopes are granted to every bot token. Bots cannot create or join
+// servers themselves; server owners add them through the authorize flow.
+var BotScopes = []string{ScopeMessagesRead, ScopeMessagesSend}
+
+func IsValidScope(scope string) bool {
+	return slices.Contains(AllScopes, scope)
+}
+
+// RequireScope only lets scoped credentials through when they hold every
+// listed scope.
+func RequireScope(scopes ...string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		granted, isScoped := c.Locals("scopes").([]string)
+		if !isScoped {
+			return c.Next()
+		}
+		for _, scope := range scopes {
+			if !slices.Contains(granted, scope) {
+				return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Token is missing the " + scope + " scope"})
+			}
+		}
+		return c.Next()
+	}
+}
+
+// RequireInteractive rejects scoped credentials outright. Use it for account,
+// social and credential-management routes that tokens should never reach.
+func RequireInteractive() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if _, isScoped := c.Locals("scopes").([]string); isScoped {
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "This route requires an interactive login"})
+		}
+		return c.Next()
+	}
+}
+
+// RequireBot only lets bot tokens through, for routes that act as the bot.
+func RequireBot() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if isBot, _ := c.Locals("isBot").(bool); !isBot {
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "This route requires a bot token"})
+		}
+		return c.Next()
+	}
+}
+
+// RequireInteractiveOrBot lets interactive sessions and bot tokens through
+// but rejects personal access tokens.
+func RequireInteractiveOrBot() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		_, isScoped := c.Locals("scopes").([]string)
+		isBot, _ := c.Locals("isBot").(bool)
+		if isScoped && !isBot {
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "This route requires an interactive login or a bot token"})
+		}
+		return c.Next()
+	}
+}
@@ -0,0 +1,77 @@
+package middleware
+
+import (
+	"testing"
+
+	"github.com/andrelcunha/Concord/backend/pkg/dtos"
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestScopeGuards(t *testing.T) {
+	jwtAuth := "Bearer " + signTestJWT(t, testSecret, dtos.UserDto{UserId: 1, Username: "alice"})
+	patAuth := "Bearer " + testAccessToken
+	botAuth := "Bot " + testBotToken
+
+	tests := []struct {
+		name  string
+		guard fiber.Handler
+		want  map[string]int
+	}{
+		{
+			name:  "RequireScope held by token",
+			guard: RequireScope(ScopeMessagesRead),
+			want:  map[string]int{jwtAuth: fiber.StatusOK, patAuth: fiber.StatusOK, botAuth: fiber.StatusOK},
+		},
+		{
+			name:  "RequireScope missing from token",
+			guard: RequireScope(ScopeMessagesSend),
+			want:  map[string]int{jwtAuth: fiber.StatusOK, patAuth: fiber.StatusForbidden, botAuth: fiber.StatusOK},
+		},
+		{
+			name:  "RequireScope not granted to bots",
+			guard: RequireScope(ScopeServersManage),
+			want:  map[string]int{jwtAuth: fiber.StatusOK, patAuth: fiber.StatusForbidden, botAuth: fiber.StatusForbidden},
+		},
+		{
+			name:  "RequireInteractive",
+			guard: RequireInteractive(),
+			want:  map[string]int{jwtAuth: fiber.StatusOK, patAuth: fiber.StatusForbidden, botAuth: fiber.StatusForbidden},
+		},
+		{
+			name:  "RequireBot",
+			guard: RequireBot(),
+			want:  map[string]int{jwtAuth: fiber.StatusForbidden, patAuth: fiber.StatusForbidden, botAuth: fiber.StatusOK},
+		},
+		{
+			name:  "RequireInteractiveOrBot",
+			guard: RequireInteractiveOrBot(),
+			want:  map[string]int{jwtAuth: fiber.StatusOK, patAuth: fiber.StatusForbidden, botAuth: fiber.StatusOK},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := newAuthApp(tt.guard)
+			for authorization, want := range tt.want {
+				assert.Equal(t, want, requestStatus(t, app, authorization), authorization)
+			}
+		})
+	}
+}
+
+func TestScopeGuards_WebSocketTickets(t *testing.T) {
+	tickets := fakeTicketRedeemer{
+		"session": {user: &dtos.UserDto{UserId: 1, Username: "alice"}},
+		"reader":  {user: &dtos.UserDto{UserId: 1, Username: "alice"}, scopes: []string{ScopeMessagesRead}},
+	}
+	app := newTicketApp(tickets, RequireScope(ScopeMessagesSend))
+
+	resp, err := app.Test(newWebSocketRequest("/ws?ticket=session"))
+	assert.NoError(t, err)
+	assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+
+	resp, err = app.Test(newWebSocketRequest("/ws?ticket=reader"))
+	assert.NoError(t, err)
+	assert.Equal(t, fiber.StatusForbidden, resp.StatusCode)
+}
@@ -0,0 +1,30 @@
+package middleware
+
+import (
+	"context"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// TotpCodeHeader carries the current TOTP code for sensitive operations.
+const TotpCodeHeader = "X-TOTP-Code"
+
+type TotpVerifier interface {
+	VerifyFreshTotp(ctx context.Context, userID int32, code string) error
+}
+
+// RequireFreshTotp rejects the request unless the authenticated user either
+// has no two-factor enabled or supplied a valid, unused code in TotpCodeHeader.
+func RequireFreshTotp(verifier TotpVerifier) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		userID, ok := c.Locals("userID").(int32)
+		if !ok {
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Unauthorized"})
+		}
+
+		if err := verifier.VerifyFreshTotp(c.Context(), userID, c.Get(TotpCodeHeader)); err != nil {
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": err.Error()})
+		}
+		return c.Next()
+	}
+}
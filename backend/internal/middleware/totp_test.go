@@ -0,0 +1,66 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/andrelcunha/Concord/backend/pkg/dtos"
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeTotpVerifier accepts validCode once per user, like VerifyFreshTotp.
+type fakeTotpVerifier struct {
+	validCode string
+	used      map[int32]bool
+}
+
+func (f *fakeTotpVerifier) VerifyFreshTotp(ctx context.Context, userID int32, code string) error {
+	if code != f.validCode || f.used[userID] {
+		return errors.New("invalid two-factor code")
+	}
+	f.used[userID] = true
+	return nil
+}
+
+func TestRequireFreshTotp(t *testing.T) {
+	verifier := &fakeTotpVerifier{validCode: "123456", used: map[int32]bool{}}
+	app := fiber.New()
+	app.Get("/test", Auth(testSecret, nil, nil, nil), RequireFreshTotp(verifier), func(c *fiber.Ctx) error {
+		return c.SendStatus(fiber.StatusOK)
+	})
+	jwtToken := signTestJWT(t, testSecret, dtos.UserDto{UserId: 1, Username: "alice"})
+
+	send := func(code string) int {
+		req := httptest.NewRequest(http.MethodGet, "/test", nil)
+		req.Header.Set("Authorization", "Bearer "+jwtToken)
+		if code != "" {
+			req.Header.Set(TotpCodeHeader, code)
+		}
+		resp, err := app.Test(req)
+		require.NoError(t, err)
+		return resp.StatusCode
+	}
+
+	assert.Equal(t, fiber.StatusForbidden, send(""))
+	assert.Equal(t, fiber.StatusForbidden, send("000000"))
+	assert.Equal(t, fiber.StatusOK, send("123456"))
+	assert.Equal(t, fiber.StatusForbidden, send("123456"), "a code cannot be replayed")
+}
+
+func TestRequireFreshTotp_RequiresAuthenticatedUser(t *testing.T) {
+	app := fiber.New()
+	app.Get("/test", RequireFreshTotp(&fakeTotpVerifier{validCode: "123456", used: map[int32]bool{}}), func(c *fiber.Ctx) error {
+		return c.SendStatus(fiber.StatusOK)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/test", nil)
+	req.Header.Set(TotpCodeHeader, "123456")
+	resp, err := app.Test(req)
+	require.NoError(t, err)
+	assert.Equal(t, fiber.StatusUnauthorized, resp.StatusCode)
+}
@@ -0,0 +1,96 @@
+package presence
+
+import (
+	"errors"
+
+	"github.com/andrelcunha/Concord/backend/internal/middleware"
+	"github.com/gofiber/fiber/v2"
+)
+
+type Handler struct {
+	service *Service
+}
+
+func NewHandler(service *Service) *Handler {
+	return &Handler{service: service}
+}
+
+func (h *Handler) GetStatus(c *fiber.Ctx) error {
+	userID := c.Locals("userID").(int32)
+	status, err := h.service.GetStatus(c.Context(), userID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(status)
+}
+
+func (h *Handler) SetStatus(c *fiber.Ctx) error {
+	var req StatusUpdate
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request"})
+	}
+
+	userID := c.Locals("userID").(int32)
+	status, err := h.service.SetStatus(c.Context(), userID, req)
+	if err != nil {
+		return presenceErrorResponse(c, err)
+	}
+	return c.JSON(status)
+}
+
+func (h *Handler) ClearStatus(c *fiber.Ctx) error {
+	userID := c.Locals("userID").(int32)
+	status, err := h.service.ClearStatus(c.Context(), userID)
+	if err != nil {
+		return presenceErrorResponse(c, err)
+	}
+	return c.JSON(status)
+}
+
+func (h *Handler) SetActivity(c *fiber.Ctx) error {
+	var req ActivityUpdate
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request"})
+	}
+
+	userID := c.Locals("userID").(int32)
+	status, err := h.service.SetActivity(c.Context(), userID, req)
+	if err != nil {
+		return presenceErrorResponse(c, err)
+	}
+	return c.JSON(status)
+}
+
+func (h *Handler) ClearActivity(c *fiber.Ctx) error {
+	userID := c.Locals("userID").(int32)
+	status, err := h.service.ClearActivity(c.Context(), userID)
+	if err != nil {
+		return presenceErrorResponse(c, err)
+	}
+	return c.JSON(status)
+}
+
+func presenceErrorResponse(c *fiber.Ctx, err error) error {
+	switch {
+	case errors.Is(err, ErrStatusTextTooLong),
+		errors.Is(err, ErrStatusEmojiTooLong),
+		errors.Is(err, ErrStatusExpiryPassed),
+		errors.Is(err, ErrInvalidActivity),
+		errors.Is(err, ErrInvalidActivityTTL):
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	default:
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+}
+
+// RegisterPresenceRoutes exposes the caller's own status. Custom statuses are
+// set by people; activities may also be reported by bots.
+func RegisterPresenceRoutes(api fiber.Router, service *Service) {
+	handler := NewHandler(service)
+	presence := api.Group("/presence")
+	presence.Get("/status", middleware.RequireInteractiveOrBot(), handler.GetStatus)
+	presence.Put("/status", middleware.RequireInteractive(), handler.SetStatus)
+	presence.Delete("/status", middleware.RequireInteractive(), handler.ClearStatus)
+	presence.Put("/activity", middleware.RequireInteractiveOrBot(), handler.SetActivity)
+	presence.Delete("/activity", middleware.RequireInteractiveOrBot(), handler.ClearActivity)
+}
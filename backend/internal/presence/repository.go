@@ -0,0 +1,68 @@
+package presence
+
+import (
+	"context"
+	"time"
+
+	"github.com/andrelcunha/Concord/backend/internal/db"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type Repository interface {
+	ListStatuses(ctx context.Context, userIDs []int32) ([]db.UserStatus, error)
+	UpsertStatus(ctx context.Context, userID int32, text, emoji string, expiresAt *time.Time) (db.UserStatus, error)
+	DeleteStatus(ctx context.Context, userID int32) error
+	ListAudience(ctx context.Context, userID int32, maxServerMembers, limit int32) ([]int32, error)
+	ListVisible(ctx context.Context, viewerID int32, userIDs []int32) ([]int32, error)
+}
+
+type repository struct {
+	db *db.Queries
+}
+
+func NewRepository(dbPool *pgxpool.Pool) Repository {
+	return &repository{
+		db: db.New(dbPool),
+	}
+}
+
+// ListStatuses returns the unexpired custom statuses of the given users.
+func (r *repository) ListStatuses(ctx context.Context, userIDs []int32) ([]db.UserStatus, error) {
+	return r.db.ListUserStatuses(ctx, userIDs)
+}
+
+func (r *repository) UpsertStatus(ctx context.Context, userID int32, text, emoji string, expiresAt *time.Time) (db.UserStatus, error) {
+	params := db.UpsertUserStatusParams{
+		UserID: userID,
+		Text:   text,
+		Emoji:  emoji,
+	}
+	if expiresAt != nil {
+		params.ExpiresAt = pgtype.Timestamptz{Time: *expiresAt, Valid: true}
+	}
+	return r.db.UpsertUserStatus(ctx, params)
+}
+
+func (r *repository) DeleteStatus(ctx context.Context, userID int32) error {
+	return r.db.DeleteUserStatus(ctx, userID)
+}
+
+// ListAudience returns the users who are told when userID's status changes:
+// friends, then co-members of servers with at most maxServerMembers members,
+// up to limit users.
+func (r *repository) ListAudience(ctx context.Context, userID int32, maxServerMembers, limit int32) ([]int32, error) {
+	return r.db.ListStatusAudience(ctx, db.ListStatusAudienceParams{
+		UserID:           userID,
+		MaxServerMembers: maxServerMembers,
+		MaxAudience:      limit,
+	})
+}
+
+// ListVisible returns the users among userIDs whose status viewerID may see.
+func (r *repository) ListVisible(ctx context.Context, viewerID int32, userIDs []int32) ([]int32, error) {
+	return r.db.ListStatusVisibleUsers(ctx, db.ListStatusVisibleUsersParams{
+		UserIds:  userIDs,
+		ViewerID: viewerID,
+	})
+}
@@ -0,0 +1,368 @@
+package presence
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/andrelcunha/Concord/backend/internal/realtime"
+	"github.com/andrelcunha/Concord/backend/pkg/dtos"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	MaxStatusTextLength  = 128
+	MaxStatusEmojiLength = 64
+	MaxActivityLength    = 128
+	DefaultActivityTTL   = 15 * time.Minute
+	MaxActivityTTL       = 24 * time.Hour
+
+	// statusCacheTTL bounds how long a cached status, or the fact that a
+	// user has none, is trusted before Postgres is asked again.
+	statusCacheTTL = 24 * time.Hour
+
+	// Live status fan-out skips servers larger than maxLiveServerMembers and
+	// stops at maxLiveAudience users; everyone else sees the change the next
+	// time they load the user. fanOutQueueSize bounds pending fan-outs.
+	maxLiveServerMembers = 250
+	maxLiveAudience      = 1000
+	fanOutQueueSize      = 1024
+)
+
+var (
+	ErrStatusTextTooLong  = fmt.Errorf("status text must be at most %d characters", MaxStatusTextLength)
+	ErrStatusEmojiTooLong = fmt.Errorf("status emoji must be at most %d characters", MaxStatusEmojiLength)
+	ErrStatusExpiryPassed = errors.New("expires_at must be in the future")
+	ErrInvalidActivity    = fmt.Errorf("activity must be between 1 and %d characters", MaxActivityLength)
+	ErrInvalidActivityTTL = fmt.Errorf("expires_in must be between 1 and %d seconds", int(MaxActivityTTL.Seconds()))
+)
+
+// StatusUpdate sets the custom status. Leaving both Text and Emoji empty
+// clears it.
+type StatusUpdate struct {
+	Text      string     `json:"text"`
+	Emoji     string     `json:"emoji"`
+	ExpiresAt *time.Time `json:"expires_at"`
+}
+
+// ActivityUpdate reports what the user is doing. ExpiresIn is in seconds and
+// defaults to DefaultActivityTTL; clients refresh it while it holds.
+type ActivityUpdate struct {
+	Name      string `json:"name"`
+	ExpiresIn int    `json:"expires_in"`
+}
+
+// cachedStatus is the Redis copy of a custom status. An empty one records
+// that the user has none.
+type cachedStatus struct {
+	Text      string     `json:"text"`
+	Emoji     string     `json:"emoji"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+}
+
+type cachedActivity struct {
+	Name      string    `json:"name"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// Statuses holds the current status of some users, keyed by user. Users
+// without a status or activity are absent.
+type Statuses map[int32]dtos.UserStatusDto
+
+// Apply copies the user's status into the summary.
+func (s Statuses) Apply(user *dtos.UserSummaryDto) {
+	if status, ok := s[user.UserID]; ok {
+		user.Status = &status
+	}
+}
+
+func (s Statuses) ApplyAll(users []dtos.UserSummaryDto) {
+	for i := range users {
+		s.Apply(&users[i])
+	}
+}
+
+type Service struct {
+	repo     Repository
+	redis    *redis.Client
+	realtime realtime.Publisher
+	fanOut   chan int32
+}
+
+func NewService(repo Repository, redis *redis.Client, realtime realtime.Publisher) *Service {
+	return &Service{
+		repo:     repo,
+		redis:    redis,
+		realtime: realtime,
+		fanOut:   make(chan int32, fanOutQueueSize),
+	}
+}
+
+func (s *Service) SetStatus(ctx context.Context, userID int32, update StatusUpdate) (dtos.UserStatusDto, error) {
+	text := strings.TrimSpace(update.Text)
+	emoji := strings.TrimSpace(update.Emoji)
+	if text == "" && emoji == "" {
+		return s.ClearStatus(ctx, userID)
+	}
+	if utf8.RuneCountInString(text) > MaxStatusTextLength {
+		return dtos.UserStatusDto{}, ErrStatusTextTooLong
+	}
+	if utf8.RuneCountInString(emoji) > MaxStatusEmojiLength {
+		return dtos.UserStatusDto{}, ErrStatusEmojiTooLong
+	}
+	if update.ExpiresAt != nil && !update.ExpiresAt.After(time.Now()) {
+		return dtos.UserStatusDto{}, ErrStatusExpiryPassed
+	}
+
+	if _, err := s.repo.UpsertStatus(ctx, userID, text, emoji, update.ExpiresAt); err != nil {
+		return dtos.UserStatusDto{}, err
+	}
+	s.cacheStatus(ctx, userID, cachedStatus{Text: text, Emoji: emoji, ExpiresAt: update.ExpiresAt})
+	return s.publish(ctx, userID)
+}
+
+func (s *Service) ClearStatus(ctx context.Context, userID int32) (dtos.UserStatusDto, error) {
+	if err := s.repo.DeleteStatus(ctx, userID); err != nil {
+		return dtos.UserStatusDto{}, err
+	}
+	s.cacheStatus(ctx, userID, cachedStatus{})
+	return s.publish(ctx, userID)
+}
+
+// SetActivity stores the activity in Redis only; it lapses on its own unless
+// refreshed.
+func (s *Service) SetActivity(ctx context.Context, userID int32, update ActivityUpdate) (dtos.UserStatusDto, error) {
+	name := strings.TrimSpace(update.Name)
+	if name == "" || utf8.RuneCountInString(name) > MaxActivityLength {
+		return dtos.UserStatusDto{}, ErrInvalidActivity
+	}
+	ttl := DefaultActivityTTL
+	if update.ExpiresIn != 0 {
+		ttl = time.Duration(update.ExpiresIn) * time.Second
+		if ttl < time.Second || ttl > MaxActivityTTL {
+			return dtos.UserStatusDto{}, ErrInvalidActivityTTL
+		}
+	}
+
+	activityJSON, err := json.Marshal(cachedActivity{Name: name, ExpiresAt: time.Now().Add(ttl)})
+	if err != nil {
+		return dtos.UserStatusDto{}, err
+	}
+	previous, err := s.redis.SetArgs(ctx, activityKey(userID), activityJSON, redis.SetArgs{TTL: ttl, Get: true}).Result()
+	if err != nil && err != redis.Nil {
+		return dtos.UserStatusDto{}, err
+	}
+
+	// Clients refresh a running activity before it lapses; only a new name
+	// is news to anyone else.
+	var previousActivity cachedActivity
+	if json.Unmarshal([]byte(previous), &previousActivity) == nil && previousActivity.Name == name {
+		return s.GetStatus(ctx, userID)
+	}
+	return s.publish(ctx, userID)
+}
+
+func (s *Service) ClearActivity(ctx context.Context, userID int32) (dtos.UserStatusDto, error) {
+	if err := s.redis.Del(ctx, activityKey(userID)).Err(); err != nil {
+		return dtos.UserStatusDto{}, err
+	}
+	return s.publish(ctx, userID)
+}
+
+func (s *Service) GetStatus(ctx context.Context, userID int32) (dtos.UserStatusDto, error) {
+	statuses, err := s.ForUsers(ctx, []int32{userID})
+	if err != nil {
+		return dtos.UserStatusDto{}, err
+	}
+	return statuses[userID], nil
+}
+
+// ForUsers reads the statuses of many users in one round trip to Redis.
+// Custom statuses missing from the cache are loaded from Postgres and cached.
+func (s *Service) ForUsers(ctx context.Context, userIDs []int32) (Statuses, error) {
+	statuses := Statuses{}
+	if len(userIDs) == 0 {
+		return statuses, nil
+	}
+
+	keys := make([]string, 0, 2*len(userIDs))
+	for _, userID := range userIDs {
+		keys = append(keys, statusKey(userID))
+	}
+	for _, userID := range userIDs {
+		keys = append(keys, activityKey(userID))
+	}
+	values, err := s.redis.MGet(ctx, keys...).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	var misses []int32
+	for i, userID := range userIDs {
+		var status dtos.UserStatusDto
+		var cached cachedStatus
+		if raw, ok := values[i].(string); ok && json.Unmarshal([]byte(raw), &cached) == nil {
+			applyCustomStatus(&status, cached, now)
+		} else {
+			misses = append(misses, userID)
+		}
+
+		var activity cachedActivity
+		if raw, ok := values[len(userIDs)+i].(string); ok && json.Unmarshal([]byte(raw), &activity) == nil && activity.ExpiresAt.After(now) {
+			status.Activity = activity.Name
+			status.ActivityExpiresAt = activity.ExpiresAt.Format("2006-01-02T15:04:05Z07:00")
+		}
+		if status != (dtos.UserStatusDto{}) {
+			statuses[userID] = status
+		}
+	}
+
+	if len(misses) == 0 {
+		return statuses, nil
+	}
+	rows, err := s.repo.ListStatuses(ctx, misses)
+	if err != nil {
+		return nil, err
+	}
+	stored := make(map[int32]cachedStatus, len(rows))
+	for _, row := range rows {
+		cached := cachedStatus{Text: row.Text, Emoji: row.Emoji}
+		if row.ExpiresAt.Valid {
+			expiresAt := row.ExpiresAt.Time
+			cached.ExpiresAt = &expiresAt
+		}
+		stored[row.UserID] = cached
+	}
+	for _, userID := range misses {
+		cached := stored[userID]
+		s.cacheStatus(ctx, userID, cached)
+
+		status := statuses[userID]
+		applyCustomStatus(&status, cached, now)
+		if status != (dtos.UserStatusDto{}) {
+			statuses[userID] = status
+		}
+	}
+	return statuses, nil
+}
+
+// ForViewer is ForUsers limited to the users viewerID may see a status of:
+// themselves, friends and server co-members, the same audience that hears
+// status changes live.
+func (s *Service) ForViewer(ctx context.Context, viewerID int32, userIDs []int32) (Statuses, error) {
+	if len(userIDs) == 0 {
+		return Statuses{}, nil
+	}
+	visible, err := s.repo.ListVisible(ctx, viewerID, userIDs)
+	if err != nil {
+		return nil, err
+	}
+	return s.ForUsers(ctx, visible)
+}
+
+func applyCustomStatus(status *dtos.UserStatusDto, cached cachedStatus, now time.Time) {
+	if cached.Text == "" && cached.Emoji == "" {
+		return
+	}
+	if cached.ExpiresAt != nil {
+		if !cached.ExpiresAt.After(now) {
+			return
+		}
+		status.ExpiresAt = cached.ExpiresAt.Format("2006-01-02T15:04:05Z07:00")
+	}
+	status.Text = cached.Text
+	status.Emoji = cached.Emoji
+}
+
+// cacheStatus writes the status to Redis until it expires. Cache failures
+// are logged; Postgres stays the source of truth.
+func (s *Service) cacheStatus(ctx context.Context, userID int32, cached cachedStatus) {
+	ttl := statusCacheTTL
+	if cached.ExpiresAt != nil {
+		ttl = min(ttl, time.Until(*cached.ExpiresAt))
+	}
+	if ttl <= 0 {
+		cached = cachedStatus{}
+		ttl = statusCacheTTL
+	}
+
+	statusJSON, err := json.Marshal(cached)
+	if err != nil {
+		log.Printf("Status marshal error: %v", err)
+		return
+	}
+	if err := s.redis.Set(ctx, statusKey(userID), statusJSON, ttl).Err(); err != nil {
+		log.Printf("Status cache error: %v", err)
+	}
+}
+
+// publish sends the user's current status to their own sockets and queues
+// the fan-out to friends and server co-members, then returns it.
+func (s *Service) publish(ctx context.Context, userID int32) (dtos.UserStatusDto, error) {
+	status, err := s.GetStatus(ctx, userID)
+	if err != nil {
+		return dtos.UserStatusDto{}, err
+	}
+
+	s.realtime.PublishToUser(ctx, userID, statusEvent(userID, status))
+	select {
+	case s.fanOut <- userID:
+	default:
+		log.Printf("Status fan-out queue full, dropping update for user %d", userID)
+	}
+	return status, nil
+}
+
+// RunFanOutWorker sends queued status changes to each user's audience until
+// ctx is done. It reads the status again, so a burst of changes by one user
+// converges on the latest.
+func (s *Service) RunFanOutWorker(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case userID := <-s.fanOut:
+			s.fanOutStatus(ctx, userID)
+		}
+	}
+}
+
+func (s *Service) fanOutStatus(ctx context.Context, userID int32) {
+	status, err := s.GetStatus(ctx, userID)
+	if err != nil {
+		log.Printf("Status fan-out error: %v", err)
+		return
+	}
+	audience, err := s.repo.ListAudience(ctx, userID, maxLiveServerMembers, maxLiveAudience)
+	if err != nil {
+		log.Printf("ListAudience error: %v", err)
+		return
+	}
+
+	event := statusEvent(userID, status)
+	for _, recipientID := range audience {
+		s.realtime.PublishToUser(ctx, recipientID, event)
+	}
+}
+
+func statusEvent(userID int32, status dtos.UserStatusDto) realtime.Event {
+	data := realtime.StatusData{UserID: userID}
+	if status != (dtos.UserStatusDto{}) {
+		data.Status = &status
+	}
+	return realtime.Event{Type: realtime.EventStatusUpdated, Data: data}
+}
+
+func statusKey(userID int32) string {
+	return fmt.Sprintf("presence:status:%d", userID)
+}
+
+func activityKey(userID int32) string {
+	return fmt.Sprintf("presence:activity:%d", userID)
+}
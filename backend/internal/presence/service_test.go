@@ -0,0 +1,220 @@
+package presence
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/andrelcunha/Concord/backend/internal/db"
+	"github.com/andrelcunha/Concord/backend/internal/realtime"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type mockRepository struct {
+	statuses      map[int32]db.UserStatus
+	audiences     map[int32][]int32
+	visible       map[int32][]int32
+	listCalls     int
+	audienceLimit int32
+}
+
+func (m *mockRepository) ListStatuses(ctx context.Context, userIDs []int32) ([]db.UserStatus, error) {
+	m.listCalls++
+	var statuses []db.UserStatus
+	for _, userID := range userIDs {
+		if status, ok := m.statuses[userID]; ok {
+			statuses = append(statuses, status)
+		}
+	}
+	return statuses, nil
+}
+
+func (m *mockRepository) UpsertStatus(ctx context.Context, userID int32, text, emoji string, expiresAt *time.Time) (db.UserStatus, error) {
+	status := db.UserStatus{UserID: userID, Text: text, Emoji: emoji}
+	if expiresAt != nil {
+		status.ExpiresAt = pgtype.Timestamptz{Time: *expiresAt, Valid: true}
+	}
+	m.statuses[userID] = status
+	return status, nil
+}
+
+func (m *mockRepository) DeleteStatus(ctx context.Context, userID int32) error {
+	delete(m.statuses, userID)
+	return nil
+}
+
+func (m *mockRepository) ListAudience(ctx context.Context, userID int32, maxServerMembers, limit int32) ([]int32, error) {
+	m.audienceLimit = limit
+	return m.audiences[userID], nil
+}
+
+func (m *mockRepository) ListVisible(ctx context.Context, viewerID int32, userIDs []int32) ([]int32, error) {
+	var visible []int32
+	for _, userID := range userIDs {
+		if userID == viewerID || slices.Contains(m.visible[viewerID], userID) {
+			visible = append(visible, userID)
+		}
+	}
+	return visible, nil
+}
+
+type recordingPublisher struct {
+	events map[int32][]realtime.Event
+}
+
+func (p *recordingPublisher) PublishToUser(ctx context.Context, userID int32, event realtime.Event) {
+	p.events[userID] = append(p.events[userID], event)
+}
+
+func newTestService(t *testing.T, repo *mockRepository) (*Service, *recordingPublisher, *miniredis.Miniredis) {
+	mr, err := miniredis.Run()
+	if err != nil {
+		t.Fatalf("failed to start miniredis: %v", err)
+	}
+	t.Cleanup(mr.Close)
+	publisher := &recordingPublisher{events: map[int32][]realtime.Event{}}
+	return NewService(repo, redis.NewClient(&redis.Options{Addr: mr.Addr()}), publisher), publisher, mr
+}
+
+// drainFanOut runs the queued fan-outs the background worker would run.
+func drainFanOut(ctx context.Context, service *Service) {
+	for {
+		select {
+		case userID := <-service.fanOut:
+			service.fanOutStatus(ctx, userID)
+		default:
+			return
+		}
+	}
+}
+
+func TestSetStatusBroadcastsToAudience(t *testing.T) {
+	repo := &mockRepository{statuses: map[int32]db.UserStatus{}, audiences: map[int32][]int32{1: {2, 3}}}
+	service, publisher, _ := newTestService(t, repo)
+	ctx := context.Background()
+
+	status, err := service.SetStatus(ctx, 1, StatusUpdate{Text: " Heads down ", Emoji: "🎧"})
+	require.NoError(t, err)
+	assert.Equal(t, "Heads down", status.Text)
+	assert.Equal(t, "🎧", status.Emoji)
+
+	// The user's own sockets hear right away; the audience once the worker runs.
+	require.Len(t, publisher.events[1], 1)
+	assert.Empty(t, publisher.events[2])
+	drainFanOut(ctx, service)
+	assert.Equal(t, int32(maxLiveAudience), repo.audienceLimit)
+
+	for _, userID := range []int32{1, 2, 3} {
+		require.Len(t, publisher.events[userID], 1)
+		event := publisher.events[userID][0]
+		assert.Equal(t, realtime.EventStatusUpdated, event.Type)
+		data := event.Data.(realtime.StatusData)
+		assert.Equal(t, int32(1), data.UserID)
+		require.NotNil(t, data.Status)
+		assert.Equal(t, "Heads down", data.Status.Text)
+	}
+
+	_, err = service.ClearStatus(ctx, 1)
+	require.NoError(t, err)
+	drainFanOut(ctx, service)
+	assert.Nil(t, publisher.events[2][1].Data.(realtime.StatusData).Status)
+	assert.NotContains(t, repo.statuses, int32(1))
+}
+
+func TestSetStatusValidation(t *testing.T) {
+	service, _, _ := newTestService(t, &mockRepository{statuses: map[int32]db.UserStatus{}})
+	ctx := context.Background()
+
+	past := time.Now().Add(-time.Minute)
+	_, err := service.SetStatus(ctx, 1, StatusUpdate{Text: "Away", ExpiresAt: &past})
+	assert.Equal(t, ErrStatusExpiryPassed, err)
+
+	long := make([]rune, MaxStatusTextLength+1)
+	for i := range long {
+		long[i] = 'a'
+	}
+	_, err = service.SetStatus(ctx, 1, StatusUpdate{Text: string(long)})
+	assert.Equal(t, ErrStatusTextTooLong, err)
+}
+
+func TestForUsersLoadsMissesFromDatabaseOnce(t *testing.T) {
+	repo := &mockRepository{statuses: map[int32]db.UserStatus{
+		1: {UserID: 1, Text: "Out sick", Emoji: "🤒"},
+	}}
+	service, _, _ := newTestService(t, repo)
+	ctx := context.Background()
+
+	statuses, err := service.ForUsers(ctx, []int32{1, 2})
+	require.NoError(t, err)
+	assert.Equal(t, "Out sick", statuses[1].Text)
+	assert.NotContains(t, statuses, int32(2))
+	assert.Equal(t, 1, repo.listCalls)
+
+	// Both the status and the absence of one are now cached.
+	statuses, err = service.ForUsers(ctx, []int32{1, 2})
+	require.NoError(t, err)
+	assert.Equal(t, "Out sick", statuses[1].Text)
+	assert.Equal(t, 1, repo.listCalls)
+}
+
+func TestActivityExpires(t *testing.T) {
+	service, _, mr := newTestService(t, &mockRepository{statuses: map[int32]db.UserStatus{}})
+	ctx := context.Background()
+
+	status, err := service.SetActivity(ctx, 1, ActivityUpdate{Name: "In a meeting", ExpiresIn: 60})
+	require.NoError(t, err)
+	assert.Equal(t, "In a meeting", status.Activity)
+	assert.NotEmpty(t, status.ActivityExpiresAt)
+
+	mr.FastForward(2 * time.Minute)
+	status, err = service.GetStatus(ctx, 1)
+	require.NoError(t, err)
+	assert.Empty(t, status.Activity)
+
+	_, err = service.SetActivity(ctx, 1, ActivityUpdate{Name: "Deploying", ExpiresIn: int(MaxActivityTTL.Seconds()) + 1})
+	assert.Equal(t, ErrInvalidActivityTTL, err)
+	_, err = service.SetActivity(ctx, 1, ActivityUpdate{Name: "  "})
+	assert.Equal(t, ErrInvalidActivity, err)
+}
+
+func TestActivityRefreshDoesNotRebroadcast(t *testing.T) {
+	repo := &mockRepository{statuses: map[int32]db.UserStatus{}, audiences: map[int32][]int32{1: {2}}}
+	service, publisher, _ := newTestService(t, repo)
+	ctx := context.Background()
+
+	_, err := service.SetActivity(ctx, 1, ActivityUpdate{Name: "Deploying"})
+	require.NoError(t, err)
+	status, err := service.SetActivity(ctx, 1, ActivityUpdate{Name: "Deploying"})
+	require.NoError(t, err)
+	assert.Equal(t, "Deploying", status.Activity)
+	drainFanOut(ctx, service)
+	assert.Len(t, publisher.events[2], 1)
+
+	_, err = service.SetActivity(ctx, 1, ActivityUpdate{Name: "Reviewing"})
+	require.NoError(t, err)
+	drainFanOut(ctx, service)
+	assert.Len(t, publisher.events[2], 2)
+}
+
+func TestForViewerHidesStatusesOutsideTheAudience(t *testing.T) {
+	repo := &mockRepository{
+		statuses: map[int32]db.UserStatus{
+			1: {UserID: 1, Text: "Focusing"},
+			2: {UserID: 2, Text: "On call"},
+			3: {UserID: 3, Text: "Travelling"},
+		},
+		visible: map[int32][]int32{1: {2}},
+	}
+	service, _, _ := newTestService(t, repo)
+
+	statuses, err := service.ForViewer(context.Background(), 1, []int32{1, 2, 3})
+	require.NoError(t, err)
+	assert.Equal(t, "Focusing", statuses[1].Text)
+	assert.Equal(t, "On call", statuses[2].Text)
+	assert.NotContains(t, statuses, int32(3))
+}
@@ -0,0 +1,47 @@
+package privacy
+
+import (
+	"github.com/andrelcunha/Concord/backend/internal/middleware"
+	"github.com/gofiber/fiber/v2"
+)
+
+type Handler struct {
+	service *Service
+}
+
+func NewHandler(service *Service) *Handler {
+	return &Handler{service: service}
+}
+
+func (h *Handler) GetSettings(c *fiber.Ctx) error {
+	userID := c.Locals("userID").(int32)
+	settings, err := h.service.GetSettings(c.Context(), userID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(settings)
+}
+
+func (h *Handler) UpdateSettings(c *fiber.Ctx) error {
+	var req SettingsUpdate
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request"})
+	}
+
+	userID := c.Locals("userID").(int32)
+	settings, err := h.service.UpdateSettings(c.Context(), userID, req)
+	if err != nil {
+		if err == ErrInvalidDmPolicy || err == ErrInvalidFriendRequestPolicy {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(settings)
+}
+
+func RegisterPrivacyRoutes(api fiber.Router, service *Service) {
+	handler := NewHandler(service)
+	settings := api.Group("/privacy", middleware.RequireInteractive())
+	settings.Get("/", handler.GetSettings)
+	settings.Patch("/", handler.UpdateSettings)
+}
@@ -0,0 +1,38 @@
+package privacy
+
+import (
+	"context"
+
+	"github.com/andrelcunha/Concord/backend/internal/db"
+	"github.com/andrelcunha/Concord/backend/pkg/dtos"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type Repository interface {
+	GetSettings(ctx context.Context, userID int32) (db.UserPrivacySetting, error)
+	UpsertSettings(ctx context.Context, userID int32, settings dtos.PrivacySettingsDto) (db.UserPrivacySetting, error)
+}
+
+type repository struct {
+	db *db.Queries
+}
+
+func NewRepository(dbPool *pgxpool.Pool) Repository {
+	return &repository{
+		db: db.New(dbPool),
+	}
+}
+
+func (r *repository) GetSettings(ctx context.Context, userID int32) (db.UserPrivacySetting, error) {
+	return r.db.GetUserPrivacySettings(ctx, userID)
+}
+
+func (r *repository) UpsertSettings(ctx context.Context, userID int32, settings dtos.PrivacySettingsDto) (db.UserPrivacySetting, error) {
+	return r.db.UpsertUserPrivacySettings(ctx, db.UpsertUserPrivacySettingsParams{
+		UserID:              userID,
+		DmPolicy:            settings.DmPolicy,
+		FriendRequestPolicy: settings.FriendRequestPolicy,
+		Discoverable:        settings.Discoverable,
+		SendReadReceipts:    settings.SendReadReceipts,
+	})
+}
@@ -0,0 +1,115 @@
+package privacy
+
+import (
+	"context"
+	"errors"
+
+	"github.com/andrelcunha/Concord/backend/internal/db"
+	"github.com/andrelcunha/Concord/backend/pkg/dtos"
+	"github.com/jackc/pgx/v5"
+)
+
+// Who may open a DM with the user. Non-friends always arrive as message
+// requests.
+const (
+	DmPolicyFriends       = "friends"
+	DmPolicyServerMembers = "server_members"
+	DmPolicyEveryone      = "everyone"
+)
+
+// Who may send the user a friend request.
+const (
+	FriendRequestPolicyEveryone         = "everyone"
+	FriendRequestPolicyFriendsOfFriends = "friends_of_friends"
+	FriendRequestPolicyServerMembers    = "server_members"
+	FriendRequestPolicyNobody           = "nobody"
+)
+
+var (
+	ErrInvalidDmPolicy            = errors.New("dm_policy must be friends, server_members or everyone")
+	ErrInvalidFriendRequestPolicy = errors.New("friend_request_policy must be everyone, friends_of_friends, server_members or nobody")
+)
+
+// SettingsUpdate carries the fields of a partial update; nil fields keep
+// their current value.
+type SettingsUpdate struct {
+	DmPolicy            *string `json:"dm_policy"`
+	FriendRequestPolicy *string `json:"friend_request_policy"`
+	Discoverable        *bool   `json:"discoverable"`
+	SendReadReceipts    *bool   `json:"send_read_receipts"`
+}
+
+type Service struct {
+	repo Repository
+}
+
+func NewService(repo Repository) *Service {
+	return &Service{repo: repo}
+}
+
+// GetSettings returns the user's settings, or the defaults if they never
+// changed any.
+func (s *Service) GetSettings(ctx context.Context, userID int32) (dtos.PrivacySettingsDto, error) {
+	settings, err := s.repo.GetSettings(ctx, userID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return defaultSettings(), nil
+	}
+	if err != nil {
+		return dtos.PrivacySettingsDto{}, err
+	}
+	return settingsDto(settings), nil
+}
+
+func (s *Service) UpdateSettings(ctx context.Context, userID int32, update SettingsUpdate) (dtos.PrivacySettingsDto, error) {
+	current, err := s.GetSettings(ctx, userID)
+	if err != nil {
+		return dtos.PrivacySettingsDto{}, err
+	}
+	if update.DmPolicy != nil {
+		switch *update.DmPolicy {
+		case DmPolicyFriends, DmPolicyServerMembers, DmPolicyEveryone:
+			current.DmPolicy = *update.DmPolicy
+		default:
+			return dtos.PrivacySettingsDto{}, ErrInvalidDmPolicy
+		}
+	}
+	if update.FriendRequestPolicy != nil {
+		switch *update.FriendRequestPolicy {
+		case FriendRequestPolicyEveryone, FriendRequestPolicyFriendsOfFriends, FriendRequestPolicyServerMembers, FriendRequestPolicyNobody:
+			current.FriendRequestPolicy = *update.FriendRequestPolicy
+		default:
+			return dtos.PrivacySettingsDto{}, ErrInvalidFriendRequestPolicy
+		}
+	}
+	if update.Discoverable != nil {
+		current.Discoverable = *update.Discoverable
+	}
+	if update.SendReadReceipts != nil {
+		current.SendReadReceipts = *update.SendReadReceipts
+	}
+
+	settings, err := s.repo.UpsertSettings(ctx, userID, current)
+	if err != nil {
+		return dtos.PrivacySettingsDto{}, err
+	}
+	return settingsDto(settings), nil
+}
+
+func defaultSettings() dtos.PrivacySettingsDto {
+	return dtos.PrivacySettingsDto{
+		DmPolicy:            DmPolicyFriends,
+		FriendRequestPolicy: FriendRequestPolicyEveryone,
+		Discoverable:        true,
+		SendReadReceipts:    true,
+	}
+}
+
+func settingsDto(settings db.UserPrivacySetting) dtos.PrivacySettingsDto {
+	return dtos.PrivacySettingsDto{
+		DmPolicy:            settings.DmPolicy,
+		FriendRequestPolicy: settings.FriendRequestPolicy,
+		Discoverable:        settings.Discoverable,
+		SendReadReceipts:    settings.SendReadReceipts,
+		UpdatedAt:           settings.UpdatedAt.Time.Format("2006-01-02T15:04:05Z07:00"),
+	}
+}
@@ -0,0 +1,78 @@
+package privacy
+
+import (
+	"context"
+	"testing"
+
+	"github.com/andrelcunha/Concord/backend/internal/db"
+	"github.com/andrelcunha/Concord/backend/pkg/dtos"
+	"github.com/jackc/pgx/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type mockRepository struct {
+	settings map[int32]db.UserPrivacySetting
+}
+
+func (m *mockRepository) GetSettings(ctx context.Context, userID int32) (db.UserPrivacySetting, error) {
+	settings, ok := m.settings[userID]
+	if !ok {
+		return db.UserPrivacySetting{}, pgx.ErrNoRows
+	}
+	return settings, nil
+}
+
+func (m *mockRepository) UpsertSettings(ctx context.Context, userID int32, settings dtos.PrivacySettingsDto) (db.UserPrivacySetting, error) {
+	row := db.UserPrivacySetting{
+		UserID:              userID,
+		DmPolicy:            settings.DmPolicy,
+		FriendRequestPolicy: settings.FriendRequestPolicy,
+		Discoverable:        settings.Discoverable,
+		SendReadReceipts:    settings.SendReadReceipts,
+	}
+	m.settings[userID] = row
+	return row, nil
+}
+
+func newTestService() *Service {
+	return NewService(&mockRepository{settings: map[int32]db.UserPrivacySetting{}})
+}
+
+func stringPtr(value string) *string {
+	return &value
+}
+
+func TestGetSettingsDefaults(t *testing.T) {
+	settings, err := newTestService().GetSettings(context.Background(), 1)
+	require.NoError(t, err)
+	assert.Equal(t, DmPolicyFriends, settings.DmPolicy)
+	assert.Equal(t, FriendRequestPolicyEveryone, settings.FriendRequestPolicy)
+	assert.True(t, settings.Discoverable)
+	assert.True(t, settings.SendReadReceipts)
+}
+
+func TestUpdateSettingsKeepsUnsetFields(t *testing.T) {
+	service := newTestService()
+	ctx := context.Background()
+
+	discoverable := false
+	_, err := service.UpdateSettings(ctx, 1, SettingsUpdate{Discoverable: &discoverable})
+	require.NoError(t, err)
+	settings, err := service.UpdateSettings(ctx, 1, SettingsUpdate{FriendRequestPolicy: stringPtr(FriendRequestPolicyNobody)})
+	require.NoError(t, err)
+
+	assert.False(t, settings.Discoverable)
+	assert.Equal(t, FriendRequestPolicyNobody, settings.FriendRequestPolicy)
+	assert.Equal(t, DmPolicyFriends, settings.DmPolicy)
+}
+
+func TestUpdateSettingsRejectsUnknownPolicies(t *testing.T) {
+	service := newTestService()
+	ctx := context.Background()
+
+	_, err := service.UpdateSettings(ctx, 1, SettingsUpdate{DmPolicy: stringPtr("strangers")})
+	assert.ErrorIs(t, err, ErrInvalidDmPolicy)
+	_, err = service.UpdateSettings(ctx, 1, SettingsUpdate{FriendRequestPolicy: stringPtr("friends")})
+	assert.ErrorIs(t, err, ErrInvalidFriendRequestPolicy)
+}
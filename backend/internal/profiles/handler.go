@@ -0,0 +1,38 @@
+package profiles
+
+import (
+	"strconv"
+
+	"github.com/andrelcunha/Concord/backend/internal/middleware"
+	"github.com/gofiber/fiber/v2"
+)
+
+type Handler struct {
+	service *Service
+}
+
+func NewHandler(service *Service) *Handler {
+	return &Handler{service: service}
+}
+
+func (h *Handler) GetProfile(c *fiber.Ctx) error {
+	targetID, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid user ID"})
+	}
+
+	userID := c.Locals("userID").(int32)
+	profile, err := h.service.GetProfile(c.Context(), userID, int32(targetID))
+	if err != nil {
+		if err == ErrUserNotFound {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(profile)
+}
+
+func RegisterProfileRoutes(api fiber.Router, service *Service) {
+	handler := NewHandler(service)
+	api.Get("/users/:id/profile", middleware.RequireInteractive(), handler.GetProfile)
+}
@@ -0,0 +1,48 @@
+package profiles
+
+import (
+	"context"
+
+	"github.com/andrelcunha/Concord/backend/internal/db"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type Repository interface {
+	GetUser(ctx context.Context, userID int32) (db.GetUserByIDRow, error)
+	GetFriendship(ctx context.Context, userID, friendID int32) (db.Friendship, error)
+	ListAcceptedFriends(ctx context.Context, userID int32) ([]db.ListAcceptedFriendsRow, error)
+	ListMutualServers(ctx context.Context, viewerID, targetID int32) ([]db.ListMutualServersRow, error)
+}
+
+type repository struct {
+	db *db.Queries
+}
+
+func NewRepository(dbPool *pgxpool.Pool) Repository {
+	return &repository{
+		db: db.New(dbPool),
+	}
+}
+
+func (r *repository) GetUser(ctx context.Context, userID int32) (db.GetUserByIDRow, error) {
+	return r.db.GetUserByID(ctx, userID)
+}
+
+// GetFriendship expects the pair already normalized, lower ID first.
+func (r *repository) GetFriendship(ctx context.Context, userID, friendID int32) (db.Friendship, error) {
+	return r.db.GetFriendshipByUsers(ctx, db.GetFriendshipByUsersParams{
+		UserID:   userID,
+		FriendID: friendID,
+	})
+}
+
+func (r *repository) ListAcceptedFriends(ctx context.Context, userID int32) ([]db.ListAcceptedFriendsRow, error) {
+	return r.db.ListAcceptedFriends(ctx, userID)
+}
+
+func (r *repository) ListMutualServers(ctx context.Context, viewerID, targetID int32) ([]db.ListMutualServersRow, error) {
+	return r.db.ListMutualServers(ctx, db.ListMutualServersParams{
+		ViewerID: viewerID,
+		TargetID: targetID,
+	})
+}
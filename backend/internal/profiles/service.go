@@ -0,0 +1,182 @@
+package profiles
+
+import (
+	"context"
+	"errors"
+
+	"github.com/andrelcunha/Concord/backend/internal/annotations"
+	"github.com/andrelcunha/Concord/backend/internal/blocks"
+	"github.com/andrelcunha/Concord/backend/internal/presence"
+	"github.com/andrelcunha/Concord/backend/pkg/dtos"
+	"github.com/jackc/pgx/v5"
+)
+
+// Relationship values describe the target user as seen by the viewer.
+const (
+	RelationshipSelf            = "self"
+	RelationshipNone            = "none"
+	RelationshipFriend          = "friend"
+	RelationshipPendingIncoming = "pending_incoming"
+	RelationshipPendingOutgoing = "pending_outgoing"
+	RelationshipBlocked         = "blocked"
+)
+
+var ErrUserNotFound = errors.New("user not found")
+
+type Service struct {
+	repo        Repository
+	blockRepo   blocks.Repository
+	annotations *annotations.Service
+	presence    *presence.Service
+}
+
+func NewService(repo Repository, blockRepo blocks.Repository, annotationsService *annotations.Service, presenceService *presence.Service) *Service {
+	return &Service{repo: repo, blockRepo: blockRepo, annotations: annotationsService, presence: presenceService}
+}
+
+// GetProfile returns the target user with what the viewer has in common with
+// them. Mutual friends and servers are left empty across a block in either
+// direction; a block by the target is otherwise not revealed.
+func (s *Service) GetProfile(ctx context.Context, viewerID, targetID int32) (dtos.UserProfileDto, error) {
+	user, err := s.repo.GetUser(ctx, targetID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return dtos.UserProfileDto{}, ErrUserNotFound
+	}
+	if err != nil {
+		return dtos.UserProfileDto{}, err
+	}
+
+	profile := dtos.UserProfileDto{
+		User: dtos.UserSummaryDto{
+			UserID:      user.ID,
+			Username:    user.Username,
+			DisplayName: user.DisplayName.String,
+			AvatarURL:   user.AvatarUrl.String,
+			AvatarColor: user.AvatarColor.String,
+		},
+		Relationship:  RelationshipNone,
+		MutualFriends: []dtos.UserSummaryDto{},
+		MutualServers: []dtos.MutualServerDto{},
+	}
+	if viewerID == targetID {
+		profile.Relationship = RelationshipSelf
+		return profile, s.applyStatuses(ctx, viewerID, &profile)
+	}
+
+	notes, err := s.annotations.ForOwner(ctx, viewerID)
+	if err != nil {
+		return dtos.UserProfileDto{}, err
+	}
+	notes.Apply(&profile.User)
+
+	if _, err := s.blockRepo.GetBlock(ctx, viewerID, targetID); err == nil {
+		profile.Relationship = RelationshipBlocked
+		return profile, nil
+	}
+	if _, err := s.blockRepo.GetBlock(ctx, targetID, viewerID); err == nil {
+		return profile, nil
+	}
+
+	profile.Relationship, err = s.relationship(ctx, viewerID, targetID)
+	if err != nil {
+		return dtos.UserProfileDto{}, err
+	}
+	if profile.MutualFriends, err = s.mutualFriends(ctx, viewerID, targetID); err != nil {
+		return dtos.UserProfileDto{}, err
+	}
+	notes.ApplyAll(profile.MutualFriends)
+
+	servers, err := s.repo.ListMutualServers(ctx, viewerID, targetID)
+	if err != nil {
+		return dtos.UserProfileDto{}, err
+	}
+	for _, server := range servers {
+		profile.MutualServers = append(profile.MutualServers, dtos.MutualServerDto{ID: server.ID, Name: server.Name})
+	}
+	return profile, s.applyStatuses(ctx, viewerID, &profile)
+}
+
+// applyStatuses fills in the statuses of the user and their mutual friends
+// that the viewer may see. It is skipped across blocks, like the mutual lists.
+func (s *Service) applyStatuses(ctx context.Context, viewerID int32, profile *dtos.UserProfileDto) error {
+	userIDs := []int32{profile.User.UserID}
+	for _, friend := range profile.MutualFriends {
+		userIDs = append(userIDs, friend.UserID)
+	}
+	statuses, err := s.presence.ForViewer(ctx, viewerID, userIDs)
+	if err != nil {
+		return err
+	}
+	statuses.Apply(&profile.User)
+	statuses.ApplyAll(profile.MutualFriends)
+	return nil
+}
+
+func (s *Service) relationship(ctx context.Context, viewerID, targetID int32) (string, error) {
+	userID, friendID := viewerID, targetID
+	if friendID < userID {
+		userID, friendID = friendID, userID
+	}
+
+	friendship, err := s.repo.GetFriendship(ctx, userID, friendID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return RelationshipNone, nil
+	}
+	if err != nil {
+		return "", err
+	}
+
+	switch {
+	case friendship.Status == "accepted":
+		return RelationshipFriend, nil
+	case friendship.Status == "pending" && friendship.RequesterID == viewerID:
+		return RelationshipPendingOutgoing, nil
+	case friendship.Status == "pending":
+		return RelationshipPendingIncoming, nil
+	default:
+		return RelationshipNone, nil
+	}
+}
+
+// mutualFriends intersects both users' accepted friends, skipping anyone on
+// either side of a block with the viewer.
+func (s *Service) mutualFriends(ctx context.Context, viewerID, targetID int32) ([]dtos.UserSummaryDto, error) {
+	viewerFriends, err := s.repo.ListAcceptedFriends(ctx, viewerID)
+	if err != nil {
+		return nil, err
+	}
+	targetFriends, err := s.repo.ListAcceptedFriends(ctx, targetID)
+	if err != nil {
+		return nil, err
+	}
+	blockedIDs, err := s.blockRepo.ListBlockRelatedUserIDs(ctx, viewerID)
+	if err != nil {
+		return nil, err
+	}
+
+	excluded := make(map[int32]bool, len(blockedIDs))
+	for _, id := range blockedIDs {
+		excluded[id] = true
+	}
+	shared := make(map[int32]bool, len(viewerFriends))
+	for _, friend := range viewerFriends {
+		if !excluded[friend.ID] {
+			shared[friend.ID] = true
+		}
+	}
+
+	mutual := []dtos.UserSummaryDto{}
+	for _, friend := range targetFriends {
+		if !shared[friend.ID] {
+			continue
+		}
+		mutual = append(mutual, dtos.UserSummaryDto{
+			UserID:      friend.ID,
+			Username:    friend.Username,
+			DisplayName: friend.DisplayName.String,
+			AvatarURL:   friend.AvatarUrl.String,
+			AvatarColor: friend.AvatarColor.String,
+		})
+	}
+	return mutual, nil
+}
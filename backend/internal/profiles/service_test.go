@@ -0,0 +1,270 @@
+package profiles
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/andrelcunha/Concord/backend/internal/annotations"
+	"github.com/andrelcunha/Concord/backend/internal/blocks"
+	"github.com/andrelcunha/Concord/backend/internal/db"
+	"github.com/andrelcunha/Concord/backend/internal/presence"
+	"github.com/andrelcunha/Concord/backend/internal/realtime"
+	"github.com/jackc/pgx/v5"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type mockRepository struct {
+	friendships map[[2]int32]db.Friendship
+	servers     map[int32][]db.ListMutualServersRow
+}
+
+func newMockRepository() *mockRepository {
+	return &mockRepository{
+		friendships: map[[2]int32]db.Friendship{},
+		servers:     map[int32][]db.ListMutualServersRow{},
+	}
+}
+
+func (m *mockRepository) addFriendship(a, b, requesterID int32, status string) {
+	if b < a {
+		a, b = b, a
+	}
+	m.friendships[[2]int32{a, b}] = db.Friendship{UserID: a, FriendID: b, RequesterID: requesterID, Status: status}
+}
+
+func (m *mockRepository) GetUser(ctx context.Context, userID int32) (db.GetUserByIDRow, error) {
+	if userID > 100 {
+		return db.GetUserByIDRow{}, pgx.ErrNoRows
+	}
+	return db.GetUserByIDRow{ID: userID}, nil
+}
+
+func (m *mockRepository) GetFriendship(ctx context.Context, userID, friendID int32) (db.Friendship, error) {
+	friendship, ok := m.friendships[[2]int32{userID, friendID}]
+	if !ok {
+		return db.Friendship{}, pgx.ErrNoRows
+	}
+	return friendship, nil
+}
+
+func (m *mockRepository) ListAcceptedFriends(ctx context.Context, userID int32) ([]db.ListAcceptedFriendsRow, error) {
+	var friends []db.ListAcceptedFriendsRow
+	for pair, friendship := range m.friendships {
+		if friendship.Status != "accepted" {
+			continue
+		}
+		switch userID {
+		case pair[0]:
+			friends = append(friends, db.ListAcceptedFriendsRow{ID: pair[1]})
+		case pair[1]:
+			friends = append(friends, db.ListAcceptedFriendsRow{ID: pair[0]})
+		}
+	}
+	return friends, nil
+}
+
+func (m *mockRepository) ListMutualServers(ctx context.Context, viewerID, targetID int32) ([]db.ListMutualServersRow, error) {
+	return m.servers[targetID], nil
+}
+
+type mockBlockRepository struct {
+	blocks map[[2]int32]bool
+}
+
+func (m *mockBlockRepository) CreateBlock(ctx context.Context, blockerID, blockedID int32) (blocks.CreatedBlock, error) {
+	m.blocks[[2]int32{blockerID, blockedID}] = true
+	return blocks.CreatedBlock{Block: db.Block{BlockerID: blockerID, BlockedID: blockedID}}, nil
+}
+
+func (m *mockBlockRepository) DeleteBlock(ctx context.Context, blockerID, blockedID int32) error {
+	delete(m.blocks, [2]int32{blockerID, blockedID})
+	return nil
+}
+
+func (m *mockBlockRepository) GetBlock(ctx context.Context, blockerID, blockedID int32) (db.Block, error) {
+	if !m.blocks[[2]int32{blockerID, blockedID}] {
+		return db.Block{}, pgx.ErrNoRows
+	}
+	return db.Block{BlockerID: blockerID, BlockedID: blockedID}, nil
+}
+
+func (m *mockBlockRepository) ListBlockedUsers(ctx context.Context, blockerID int32) ([]db.ListBlockedUsersRow, error) {
+	return nil, nil
+}
+
+func (m *mockBlockRepository) ListBlockedUserIDs(ctx context.Context, blockerID int32) ([]int32, error) {
+	return nil, nil
+}
+
+func (m *mockBlockRepository) ListBlockRelatedUserIDs(ctx context.Context, userID int32) ([]int32, error) {
+	var ids []int32
+	for pair := range m.blocks {
+		switch userID {
+		case pair[0]:
+			ids = append(ids, pair[1])
+		case pair[1]:
+			ids = append(ids, pair[0])
+		}
+	}
+	return ids, nil
+}
+
+type mockAnnotationRepository struct {
+	annotations []db.UserAnnotation
+}
+
+func (m *mockAnnotationRepository) GetUser(ctx context.Context, userID int32) (db.GetUserByIDRow, error) {
+	return db.GetUserByIDRow{ID: userID}, nil
+}
+
+func (m *mockAnnotationRepository) GetAnnotation(ctx context.Context, ownerID, targetID int32) (db.UserAnnotation, error) {
+	return db.UserAnnotation{}, pgx.ErrNoRows
+}
+
+func (m *mockAnnotationRepository) ListAnnotations(ctx context.Context, ownerID int32) ([]db.UserAnnotation, error) {
+	var annotations []db.UserAnnotation
+	for _, annotation := range m.annotations {
+		if annotation.OwnerID == ownerID {
+			annotations = append(annotations, annotation)
+		}
+	}
+	return annotations, nil
+}
+
+func (m *mockAnnotationRepository) UpsertAnnotation(ctx context.Context, ownerID, targetID int32, nickname, note string) (db.UserAnnotation, error) {
+	return db.UserAnnotation{}, nil
+}
+
+func (m *mockAnnotationRepository) DeleteAnnotation(ctx context.Context, ownerID, targetID int32) error {
+	return nil
+}
+
+// mockPresenceRepository gives user 2 a custom status, visible to friends
+// and users sharing a server in repo.
+type mockPresenceRepository struct {
+	repo *mockRepository
+}
+
+func (m *mockPresenceRepository) ListVisible(ctx context.Context, viewerID int32, userIDs []int32) ([]int32, error) {
+	var visible []int32
+	for _, userID := range userIDs {
+		a, b := min(viewerID, userID), max(viewerID, userID)
+		isFriend := m.repo.friendships[[2]int32{a, b}].Status == "accepted"
+		if userID == viewerID || isFriend || len(m.repo.servers[userID]) > 0 {
+			visible = append(visible, userID)
+		}
+	}
+	return visible, nil
+}
+
+func (m *mockPresenceRepository) ListStatuses(ctx context.Context, userIDs []int32) ([]db.UserStatus, error) {
+	if slices.Contains(userIDs, 2) {
+		return []db.UserStatus{{UserID: 2, Text: "Reviewing PRs"}}, nil
+	}
+	return nil, nil
+}
+
+func (m *mockPresenceRepository) UpsertStatus(ctx context.Context, userID int32, text, emoji string, expiresAt *time.Time) (db.UserStatus, error) {
+	return db.UserStatus{}, nil
+}
+
+func (m *mockPresenceRepository) DeleteStatus(ctx context.Context, userID int32) error {
+	return nil
+}
+
+func (m *mockPresenceRepository) ListAudience(ctx context.Context, userID int32, maxServerMembers, limit int32) ([]int32, error) {
+	return nil, nil
+}
+
+type nopPublisher struct{}
+
+func (nopPublisher) PublishToUser(ctx context.Context, userID int32, event realtime.Event) {}
+
+func newTestService(t *testing.T) (*Service, *mockRepository, *mockBlockRepository) {
+	mr, err := miniredis.Run()
+	if err != nil {
+		t.Fatalf("failed to start miniredis: %v", err)
+	}
+	t.Cleanup(mr.Close)
+	repo := newMockRepository()
+	presenceService := presence.NewService(&mockPresenceRepository{repo: repo}, redis.NewClient(&redis.Options{Addr: mr.Addr()}), nopPublisher{})
+	blockRepo := &mockBlockRepository{blocks: map[[2]int32]bool{}}
+	annotationRepo := &mockAnnotationRepository{annotations: []db.UserAnnotation{
+		{OwnerID: 1, TargetID: 3, Nickname: "Sam"},
+		{OwnerID: 2, TargetID: 3, Nickname: "not visible to 1"},
+	}}
+	return NewService(repo, blockRepo, annotations.NewService(annotationRepo), presenceService), repo, blockRepo
+}
+
+func TestGetProfileListsMutualFriendsExceptBlocked(t *testing.T) {
+	service, repo, blockRepo := newTestService(t)
+	repo.addFriendship(1, 3, 1, "accepted")
+	repo.addFriendship(2, 3, 2, "accepted")
+	repo.addFriendship(1, 4, 1, "accepted")
+	repo.addFriendship(2, 4, 4, "accepted")
+	repo.addFriendship(1, 5, 1, "accepted")
+	repo.addFriendship(2, 6, 2, "accepted")
+	repo.addFriendship(1, 2, 2, "pending")
+	blockRepo.blocks[[2]int32{4, 1}] = true
+
+	profile, err := service.GetProfile(context.Background(), 1, 2)
+	require.NoError(t, err)
+	assert.Equal(t, RelationshipPendingIncoming, profile.Relationship)
+	require.Len(t, profile.MutualFriends, 1)
+	assert.Equal(t, int32(3), profile.MutualFriends[0].UserID)
+	assert.Equal(t, "Sam", profile.MutualFriends[0].Nickname)
+}
+
+func TestGetProfileShowsStatusToFriendsAndServerMembersOnly(t *testing.T) {
+	service, repo, _ := newTestService(t)
+	ctx := context.Background()
+
+	profile, err := service.GetProfile(ctx, 1, 2)
+	require.NoError(t, err)
+	assert.Nil(t, profile.User.Status)
+
+	repo.servers[2] = []db.ListMutualServersRow{{ID: 7, Name: "shared"}}
+	profile, err = service.GetProfile(ctx, 1, 2)
+	require.NoError(t, err)
+	require.NotNil(t, profile.User.Status)
+	assert.Equal(t, "Reviewing PRs", profile.User.Status.Text)
+
+	repo.servers = map[int32][]db.ListMutualServersRow{}
+	repo.addFriendship(1, 2, 1, "accepted")
+	profile, err = service.GetProfile(ctx, 1, 2)
+	require.NoError(t, err)
+	assert.Equal(t, RelationshipFriend, profile.Relationship)
+	require.NotNil(t, profile.User.Status)
+}
+
+func TestGetProfileHidesMutualsAcrossBlocks(t *testing.T) {
+	service, repo, blockRepo := newTestService(t)
+	repo.addFriendship(1, 3, 1, "accepted")
+	repo.addFriendship(2, 3, 2, "accepted")
+	repo.servers[2] = []db.ListMutualServersRow{{ID: 7, Name: "shared"}}
+
+	blockRepo.blocks[[2]int32{2, 1}] = true
+	profile, err := service.GetProfile(context.Background(), 1, 2)
+	require.NoError(t, err)
+	assert.Equal(t, RelationshipNone, profile.Relationship)
+	assert.Empty(t, profile.MutualFriends)
+	assert.Empty(t, profile.MutualServers)
+	assert.Nil(t, profile.User.Status)
+
+	blockRepo.blocks = map[[2]int32]bool{{1, 2}: true}
+	profile, err = service.GetProfile(context.Background(), 1, 2)
+	require.NoError(t, err)
+	assert.Equal(t, RelationshipBlocked, profile.Relationship)
+	assert.Empty(t, profile.MutualServers)
+}
+
+func TestGetProfileUnknownUser(t *testing.T) {
+	service, _, _ := newTestService(t)
+	_, err := service.GetProfile(context.Background(), 1, 101)
+	assert.ErrorIs(t, err, ErrUserNotFound)
+}
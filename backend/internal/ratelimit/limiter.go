@@ -0,0 +1,88 @@
+package ratelimit
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Rule allows Limit hits per sliding Window. Name namespaces the Redis keys so
+// different routes do not share budgets.
+type Rule struct {
+	Name   string
+	Limit  int
+	Window time.Duration
+}
+
+type Result struct {
+	Allowed    bool
+	Limit      int
+	Remaining  int
+	RetryAfter time.Duration
+}
+
+// slidingWindowScript keeps one sorted-set entry per hit, scored by its time
+// in milliseconds. Expired entries are trimmed before counting so the window
+// slides instead of resetting on fixed boundaries.
+var slidingWindowScript = redis.NewScript(`
+local key = KEYS[1]
+local now = tonumber(ARGV[1])
+local window = tonumber(ARGV[2])
+local limit = tonumber(ARGV[3])
+
+redis.call('ZREMRANGEBYSCORE', key, '-inf', now - window)
+local count = redis.call('ZCARD', key)
+if count < limit then
+  redis.call('ZADD', key, now, ARGV[4])
+  redis.call('PEXPIRE', key, window)
+  return {1, limit - count - 1, 0}
+end
+
+local oldest = redis.call('ZRANGE', key, 0, 0, 'WITHSCORES')
+return {0, 0, window - (now - tonumber(oldest[2]))}
+`)
+
+type Limiter struct {
+	redis *redis.Client
+}
+
+func NewLimiter(redis *redis.Client) *Limiter {
+	return &Limiter{redis: redis}
+}
+
+// Allow records a hit for subject under rule and reports whether it fits in
+// the window. Denied hits are not recorded.
+func (l *Limiter) Allow(ctx context.Context, rule Rule, subject string) (Result, error) {
+	member, err := randomMember()
+	if err != nil {
+		return Result{}, err
+	}
+
+	key := fmt.Sprintf("ratelimit:%s:%s", rule.Name, subject)
+	now := time.Now().UnixMilli()
+	values, err := slidingWindowScript.Run(ctx, l.redis, []string{key},
+		now, rule.Window.Milliseconds(), rule.Limit, fmt.Sprintf("%d-%s", now, member),
+	).Int64Slice()
+	if err != nil {
+		return Result{}, err
+	}
+
+	return Result{
+		Allowed:    values[0] == 1,
+		Limit:      rule.Limit,
+		Remaining:  int(values[1]),
+		RetryAfter: time.Duration(values[2]) * time.Millisecond,
+	}, nil
+}
+
+func randomMember() (string, error) {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
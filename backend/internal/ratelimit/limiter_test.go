@@ -0,0 +1,40 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLimiter_Allow(t *testing.T) {
+	ctx := context.Background()
+	mr, err := miniredis.Run()
+	if err != nil {
+		t.Fatalf("failed to start miniredis: %v", err)
+	}
+	t.Cleanup(mr.Close)
+	limiter := NewLimiter(redis.NewClient(&redis.Options{Addr: mr.Addr()}))
+	rule := Rule{Name: "test", Limit: 3, Window: time.Minute}
+
+	for i := 0; i < 3; i++ {
+		result, err := limiter.Allow(ctx, rule, "ip:1.2.3.4")
+		assert.NoError(t, err)
+		assert.True(t, result.Allowed)
+		assert.Equal(t, 2-i, result.Remaining)
+	}
+
+	result, err := limiter.Allow(ctx, rule, "ip:1.2.3.4")
+	assert.NoError(t, err)
+	assert.False(t, result.Allowed)
+	assert.Equal(t, 0, result.Remaining)
+	assert.True(t, result.RetryAfter > 0 && result.RetryAfter <= time.Minute)
+
+	// Other subjects have their own budget
+	result, err = limiter.Allow(ctx, rule, "ip:5.6.7.8")
+	assert.NoError(t, err)
+	assert.True(t, result.Allowed)
+}
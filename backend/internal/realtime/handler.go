@@ -0,0 +1,70 @@
+package realtime
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+
+	"github.com/andrelcunha/Concord/backend/internal/middleware"
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/websocket/v2"
+)
+
+type Handler struct {
+	service *Service
+}
+
+func NewHandler(service *Service) *Handler {
+	return &Handler{service: service}
+}
+
+// HandleConnection streams the user's events. Clients only read from this
+// socket; a failed read means it closed.
+func (h *Handler) HandleConnection(c *fiber.Ctx) error {
+	userID, ok := c.Locals("userID").(int32)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Unauthorized"})
+	}
+
+	return websocket.New(func(conn *websocket.Conn) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		pubsub := h.service.Subscribe(ctx, userID)
+		defer pubsub.Close()
+		defer conn.Close()
+
+		// Wait for the subscription before sending "ready", so no event
+		// published after it is lost.
+		if _, err := pubsub.Receive(ctx); err != nil {
+			log.Printf("Realtime subscribe error: %v", err)
+			return
+		}
+		ready, _ := json.Marshal(Event{Type: "ready", Data: UserData{UserID: userID}})
+		if err := conn.WriteMessage(websocket.TextMessage, ready); err != nil {
+			return
+		}
+
+		go func() {
+			for {
+				if _, _, err := conn.ReadMessage(); err != nil {
+					cancel()
+					pubsub.Close()
+					return
+				}
+			}
+		}()
+
+		for msg := range pubsub.Channel() {
+			if err := conn.WriteMessage(websocket.TextMessage, []byte(msg.Payload)); err != nil {
+				log.Printf("Realtime write error: %v", err)
+				return
+			}
+		}
+	})(c)
+}
+
+func RegisterRealtimeRoutes(api fiber.Router, service *Service) {
+	handler := NewHandler(service)
+	api.Get("/realtime/ws", middleware.RequireInteractive(), handler.HandleConnection)
+}
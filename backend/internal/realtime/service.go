@@ -0,0 +1,79 @@
+package realtime
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+
+	"github.com/andrelcunha/Concord/backend/pkg/dtos"
+	"github.com/redis/go-redis/v9"
+)
+
+// Social and status event types sent on a user's channel.
+const (
+	EventFriendRequestCreated = "friend_request.created"
+	EventFriendRequestRemoved = "friend_request.removed"
+	EventFriendAdded          = "friend.added"
+	EventFriendRemoved        = "friend.removed"
+	EventBlockCreated         = "block.created"
+	EventBlockRemoved         = "block.removed"
+	EventStatusUpdated        = "status.updated"
+)
+
+// Event is one frame on the user socket.
+type Event struct {
+	Type string `json:"type"`
+	Data any    `json:"data"`
+}
+
+// UserData identifies the other user an event is about.
+type UserData struct {
+	UserID int32 `json:"user_id"`
+}
+
+// FriendRequestData identifies a friend request and the other user on it.
+type FriendRequestData struct {
+	ID     int32 `json:"id"`
+	UserID int32 `json:"user_id"`
+}
+
+// StatusData carries a user's new status; a nil Status means it was cleared.
+type StatusData struct {
+	UserID int32               `json:"user_id"`
+	Status *dtos.UserStatusDto `json:"status"`
+}
+
+// Publisher sends events to every connection of one user. Publishing is
+// best effort; failures are logged and never fail the caller.
+type Publisher interface {
+	PublishToUser(ctx context.Context, userID int32, event Event)
+}
+
+type Service struct {
+	redis *redis.Client
+}
+
+func NewService(redis *redis.Client) *Service {
+	return &Service{redis: redis}
+}
+
+func (s *Service) PublishToUser(ctx context.Context, userID int32, event Event) {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		log.Printf("Realtime marshal error: %v", err)
+		return
+	}
+	if err := s.redis.Publish(ctx, userChannel(userID), payload).Err(); err != nil {
+		log.Printf("Realtime publish error: %v", err)
+	}
+}
+
+// Subscribe returns the stream of events for one user.
+func (s *Service) Subscribe(ctx context.Context, userID int32) *redis.PubSub {
+	return s.redis.Subscribe(ctx, userChannel(userID))
+}
+
+func userChannel(userID int32) string {
+	return fmt.Sprintf("user:%d", userID)
+}
@@ -0,0 +1,126 @@
+package search
+
+import (
+	"context"
+	"html"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/andrelcunha/Concord/backend/internal/db"
+	"github.com/andrelcunha/Concord/backend/pkg/dtos"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Engine runs a search whose access rules were already resolved. Another
+// backend, such as an external search service, can replace the Postgres one
+// as long as it only returns messages from the given servers and
+// conversations.
+type Engine interface {
+	Search(ctx context.Context, query Query) ([]dtos.SearchResultDto, error)
+}
+
+// Query is a resolved search. ServerIDs and ConversationIDs are the only
+// places results may come from; messages by ExcludedUserIDs are dropped.
+type Query struct {
+	Text            string
+	ServerIDs       []int32
+	ConversationIDs []int32
+	ChannelID       int32
+	AuthorID        int32
+	After           time.Time
+	Before          time.Time
+	HasAttachment   bool
+	MentionUsername string
+	ExcludedUserIDs []int32
+	Limit           int32
+	Offset          int32
+}
+
+// Snippet highlights are marked with private-use characters so the snippet
+// can be HTML-escaped before the markers become <mark> tags.
+const (
+	highlightStart = "\ue000"
+	highlightStop  = "\ue001"
+)
+
+var headlineOptions = `StartSel="` + highlightStart + `", StopSel="` + highlightStop + `", MaxWords=30, MinWords=10, MaxFragments=2, FragmentDelimiter=" ... "`
+
+var snippetReplacer = strings.NewReplacer(highlightStart, "<mark>", highlightStop, "</mark>")
+
+type postgresEngine struct {
+	db *db.Queries
+}
+
+// NewPostgresEngine searches the generated tsvector columns on messages and
+// dm_messages.
+func NewPostgresEngine(dbPool *pgxpool.Pool) Engine {
+	return &postgresEngine{db: db.New(dbPool)}
+}
+
+func (e *postgresEngine) Search(ctx context.Context, query Query) ([]dtos.SearchResultDto, error) {
+	params := db.SearchMessagesParams{
+		HeadlineOptions: headlineOptions,
+		Query:           query.Text,
+		ServerIds:       nonNil(query.ServerIDs),
+		ConversationIds: nonNil(query.ConversationIDs),
+		ExcludedUserIds: nonNil(query.ExcludedUserIDs),
+		HasAttachment:   query.HasAttachment,
+		PageLimit:       query.Limit,
+		PageOffset:      query.Offset,
+	}
+	if query.ChannelID != 0 {
+		params.ChannelID = pgtype.Int4{Int32: query.ChannelID, Valid: true}
+	}
+	if query.AuthorID != 0 {
+		params.AuthorID = pgtype.Int4{Int32: query.AuthorID, Valid: true}
+	}
+	if !query.After.IsZero() {
+		params.After = pgtype.Timestamptz{Time: query.After, Valid: true}
+	}
+	if !query.Before.IsZero() {
+		params.Before = pgtype.Timestamptz{Time: query.Before, Valid: true}
+	}
+	if query.MentionUsername != "" {
+		params.MentionPattern = pgtype.Text{String: mentionPattern(query.MentionUsername), Valid: true}
+	}
+
+	rows, err := e.db.SearchMessages(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]dtos.SearchResultDto, 0, len(rows))
+	for _, row := range rows {
+		results = append(results, dtos.SearchResultDto{
+			Type:           row.Source,
+			ID:             row.ID,
+			ServerID:       row.ServerID,
+			ChannelID:      row.ChannelID,
+			ConversationID: row.ConversationID,
+			UserID:         row.UserID,
+			Username:       row.Username,
+			AvatarURL:      row.AvatarUrl,
+			AvatarColor:    row.AvatarColor,
+			IsBot:          row.IsBot,
+			Content:        row.Content,
+			Snippet:        snippetReplacer.Replace(html.EscapeString(row.Snippet)),
+			CreatedAt:      row.CreatedAt.Time.Format("2006-01-02T15:04:05Z07:00"),
+		})
+	}
+	return results, nil
+}
+
+// mentionPattern matches "@username" as a whole word, case-insensitively.
+// Usernames may contain dots and dashes, so word boundaries are spelled out.
+func mentionPattern(username string) string {
+	return `(^|[^a-zA-Z0-9._-])@` + regexp.QuoteMeta(username) + `($|[^a-zA-Z0-9._-])`
+}
+
+func nonNil(ids []int32) []int32 {
+	if ids == nil {
+		return []int32{}
+	}
+	return ids
+}
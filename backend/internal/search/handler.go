@@ -0,0 +1,99 @@
+package search
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/andrelcunha/Concord/backend/internal/middleware"
+	"github.com/gofiber/fiber/v2"
+)
+
+type Handler struct {
+	service *Service
+}
+
+func NewHandler(service *Service) *Handler {
+	return &Handler{service: service}
+}
+
+// Search reads filters from the query string:
+// q, in, server_id, channel_id, author_id, mentions, after, before,
+// has=attachment, limit and offset. Dates are RFC 3339 or YYYY-MM-DD.
+func (h *Handler) Search(c *fiber.Ctx) error {
+	filters := Filters{
+		Query:         c.Query("q"),
+		In:            c.Query("in"),
+		HasAttachment: c.Query("has") == "attachment",
+	}
+	if has := c.Query("has"); has != "" && has != "attachment" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "has must be attachment"})
+	}
+
+	ids := map[string]*int32{
+		"server_id":  &filters.ServerID,
+		"channel_id": &filters.ChannelID,
+		"author_id":  &filters.AuthorID,
+		"mentions":   &filters.MentionsID,
+		"limit":      &filters.Limit,
+		"offset":     &filters.Offset,
+	}
+	for name, target := range ids {
+		value := c.Query(name)
+		if value == "" {
+			continue
+		}
+		parsed, err := strconv.ParseInt(value, 10, 32)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid " + name})
+		}
+		*target = int32(parsed)
+	}
+
+	dates := map[string]*time.Time{
+		"after":  &filters.After,
+		"before": &filters.Before,
+	}
+	for name, target := range dates {
+		value := c.Query(name)
+		if value == "" {
+			continue
+		}
+		parsed, err := parseDate(value)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid " + name})
+		}
+		*target = parsed
+	}
+
+	userID := c.Locals("userID").(int32)
+	results, err := h.service.Search(c.Context(), userID, filters)
+	if err != nil {
+		return searchErrorResponse(c, err)
+	}
+	return c.JSON(results)
+}
+
+func parseDate(value string) (time.Time, error) {
+	if parsed, err := time.Parse(time.RFC3339, value); err == nil {
+		return parsed, nil
+	}
+	return time.Parse("2006-01-02", value)
+}
+
+func searchErrorResponse(c *fiber.Ctx, err error) error {
+	switch err {
+	case ErrInvalidQuery, ErrInvalidScope, ErrInvalidDateRange, ErrInvalidPage:
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	case ErrChannelNotFound, ErrUserNotFound:
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
+	case ErrNotServerMember:
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": err.Error()})
+	default:
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+}
+
+func RegisterSearchRoutes(api fiber.Router, service *Service) {
+	handler := NewHandler(service)
+	api.Get("/search", middleware.RequireScope(middleware.ScopeMessagesRead), handler.Search)
+}
@@ -0,0 +1,45 @@
+package search
+
+import (
+	"context"
+
+	"github.com/andrelcunha/Concord/backend/internal/db"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type repository struct {
+	db *db.Queries
+}
+
+// Repository resolves what a user may search.
+type Repository interface {
+	ListMemberServerIDs(ctx context.Context, userID int32) ([]int32, error)
+	ListParticipantConversationIDs(ctx context.Context, userID int32) ([]int32, error)
+	ListBlockRelatedUserIDs(ctx context.Context, userID int32) ([]int32, error)
+	GetChannel(ctx context.Context, channelID int32) (db.GetChannelRow, error)
+	GetUser(ctx context.Context, userID int32) (db.GetUserByIDRow, error)
+}
+
+func NewRepository(dbPool *pgxpool.Pool) Repository {
+	return &repository{db: db.New(dbPool)}
+}
+
+func (r *repository) ListMemberServerIDs(ctx context.Context, userID int32) ([]int32, error) {
+	return r.db.ListMemberServerIDs(ctx, userID)
+}
+
+func (r *repository) ListParticipantConversationIDs(ctx context.Context, userID int32) ([]int32, error) {
+	return r.db.ListParticipantConversationIDs(ctx, userID)
+}
+
+func (r *repository) ListBlockRelatedUserIDs(ctx context.Context, userID int32) ([]int32, error) {
+	return r.db.ListBlockRelatedUserIDs(ctx, userID)
+}
+
+func (r *repository) GetChannel(ctx context.Context, channelID int32) (db.GetChannelRow, error) {
+	return r.db.GetChannel(ctx, channelID)
+}
+
+func (r *repository) GetUser(ctx context.Context, userID int32) (db.GetUserByIDRow, error) {
+	return r.db.GetUserByID(ctx, userID)
+}
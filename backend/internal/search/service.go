@@ -0,0 +1,156 @@
+package search
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/andrelcunha/Concord/backend/pkg/dtos"
+)
+
+// Where a search looks.
+const (
+	InAll     = ""
+	InServers = "servers"
+	InDMs     = "dms"
+)
+
+const (
+	DefaultLimit   = 25
+	MaxLimit       = 100
+	maxQueryLength = 200
+)
+
+var (
+	ErrInvalidQuery     = errors.New("query must be between 1 and 200 characters")
+	ErrInvalidScope     = errors.New("in must be servers or dms")
+	ErrInvalidDateRange = errors.New("after must be before before")
+	ErrInvalidPage      = errors.New("limit must be between 1 and 100 and offset must not be negative")
+	ErrNotServerMember  = errors.New("not a server member")
+	ErrChannelNotFound  = errors.New("channel not found")
+	ErrUserNotFound     = errors.New("user not found")
+)
+
+// Filters narrow a search. Zero values mean "any". Server and channel
+// filters only match channel messages.
+type Filters struct {
+	Query         string
+	In            string
+	ServerID      int32
+	ChannelID     int32
+	AuthorID      int32
+	MentionsID    int32
+	After         time.Time
+	Before        time.Time
+	HasAttachment bool
+	Limit         int32
+	Offset        int32
+}
+
+type Service struct {
+	repo   Repository
+	engine Engine
+}
+
+func NewService(repo Repository, engine Engine) *Service {
+	return &Service{repo: repo, engine: engine}
+}
+
+// Search finds messages the user can read: channel messages in servers they
+// are a member of and messages in their DM conversations. Messages from users
+// on either side of a block are left out.
+func (s *Service) Search(ctx context.Context, userID int32, filters Filters) (dtos.SearchResultsDto, error) {
+	filters.Query = strings.TrimSpace(filters.Query)
+	if filters.Query == "" || utf8.RuneCountInString(filters.Query) > maxQueryLength {
+		return dtos.SearchResultsDto{}, ErrInvalidQuery
+	}
+	if filters.In != InAll && filters.In != InServers && filters.In != InDMs {
+		return dtos.SearchResultsDto{}, ErrInvalidScope
+	}
+	if !filters.After.IsZero() && !filters.Before.IsZero() && !filters.After.Before(filters.Before) {
+		return dtos.SearchResultsDto{}, ErrInvalidDateRange
+	}
+	if filters.Limit == 0 {
+		filters.Limit = DefaultLimit
+	}
+	if filters.Limit < 1 || filters.Limit > MaxLimit || filters.Offset < 0 {
+		return dtos.SearchResultsDto{}, ErrInvalidPage
+	}
+
+	query := Query{
+		Text:          filters.Query,
+		ChannelID:     filters.ChannelID,
+		AuthorID:      filters.AuthorID,
+		After:         filters.After,
+		Before:        filters.Before,
+		HasAttachment: filters.HasAttachment,
+		Limit:         filters.Limit + 1,
+		Offset:        filters.Offset,
+	}
+
+	if filters.ChannelID != 0 {
+		channel, err := s.repo.GetChannel(ctx, filters.ChannelID)
+		if err != nil {
+			return dtos.SearchResultsDto{}, ErrChannelNotFound
+		}
+		if filters.ServerID != 0 && filters.ServerID != channel.ServerID {
+			return dtos.SearchResultsDto{}, ErrChannelNotFound
+		}
+		filters.ServerID = channel.ServerID
+	}
+	channelsOnly := filters.ServerID != 0 || filters.In == InServers
+
+	if filters.In != InDMs {
+		serverIDs, err := s.repo.ListMemberServerIDs(ctx, userID)
+		if err != nil {
+			return dtos.SearchResultsDto{}, err
+		}
+		if filters.ServerID != 0 {
+			if !slices.Contains(serverIDs, filters.ServerID) {
+				return dtos.SearchResultsDto{}, ErrNotServerMember
+			}
+			serverIDs = []int32{filters.ServerID}
+		}
+		query.ServerIDs = serverIDs
+	}
+	if !channelsOnly {
+		conversationIDs, err := s.repo.ListParticipantConversationIDs(ctx, userID)
+		if err != nil {
+			return dtos.SearchResultsDto{}, err
+		}
+		query.ConversationIDs = conversationIDs
+	}
+
+	excludedUserIDs, err := s.repo.ListBlockRelatedUserIDs(ctx, userID)
+	if err != nil {
+		return dtos.SearchResultsDto{}, err
+	}
+	query.ExcludedUserIDs = excludedUserIDs
+
+	if filters.MentionsID != 0 {
+		user, err := s.repo.GetUser(ctx, filters.MentionsID)
+		if err != nil {
+			return dtos.SearchResultsDto{}, ErrUserNotFound
+		}
+		query.MentionUsername = user.Username
+	}
+
+	results, err := s.engine.Search(ctx, query)
+	if err != nil {
+		return dtos.SearchResultsDto{}, err
+	}
+
+	hasMore := len(results) > int(filters.Limit)
+	if hasMore {
+		results = results[:filters.Limit]
+	}
+	return dtos.SearchResultsDto{
+		Results: results,
+		Limit:   filters.Limit,
+		Offset:  filters.Offset,
+		HasMore: hasMore,
+	}, nil
+}
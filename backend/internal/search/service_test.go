@@ -0,0 +1,146 @@
+package search
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/andrelcunha/Concord/backend/internal/db"
+	"github.com/andrelcunha/Concord/backend/pkg/dtos"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockRepository struct {
+	serverIDs       []int32
+	conversationIDs []int32
+	blockedUserIDs  []int32
+	channels        map[int32]db.GetChannelRow
+	users           map[int32]db.GetUserByIDRow
+}
+
+func (m *mockRepository) ListMemberServerIDs(ctx context.Context, userID int32) ([]int32, error) {
+	return m.serverIDs, nil
+}
+
+func (m *mockRepository) ListParticipantConversationIDs(ctx context.Context, userID int32) ([]int32, error) {
+	return m.conversationIDs, nil
+}
+
+func (m *mockRepository) ListBlockRelatedUserIDs(ctx context.Context, userID int32) ([]int32, error) {
+	return m.blockedUserIDs, nil
+}
+
+func (m *mockRepository) GetChannel(ctx context.Context, channelID int32) (db.GetChannelRow, error) {
+	channel, ok := m.channels[channelID]
+	if !ok {
+		return db.GetChannelRow{}, errors.New("no rows")
+	}
+	return channel, nil
+}
+
+func (m *mockRepository) GetUser(ctx context.Context, userID int32) (db.GetUserByIDRow, error) {
+	user, ok := m.users[userID]
+	if !ok {
+		return db.GetUserByIDRow{}, errors.New("no rows")
+	}
+	return user, nil
+}
+
+type recordingEngine struct {
+	query   Query
+	results []dtos.SearchResultDto
+}
+
+func (e *recordingEngine) Search(ctx context.Context, query Query) ([]dtos.SearchResultDto, error) {
+	e.query = query
+	return e.results, nil
+}
+
+func newTestService() (*Service, *recordingEngine) {
+	repo := &mockRepository{
+		serverIDs:       []int32{1, 2},
+		conversationIDs: []int32{7},
+		blockedUserIDs:  []int32{9},
+		channels: map[int32]db.GetChannelRow{
+			10: {ID: 10, ServerID: 1},
+			30: {ID: 30, ServerID: 3},
+		},
+		users: map[int32]db.GetUserByIDRow{5: {ID: 5, Username: "alice"}},
+	}
+	engine := &recordingEngine{}
+	return NewService(repo, engine), engine
+}
+
+func TestService_SearchResolvesAccess(t *testing.T) {
+	service, engine := newTestService()
+
+	_, err := service.Search(context.Background(), 1, Filters{Query: "deploy", MentionsID: 5})
+	assert.NoError(t, err)
+	assert.Equal(t, []int32{1, 2}, engine.query.ServerIDs)
+	assert.Equal(t, []int32{7}, engine.query.ConversationIDs)
+	assert.Equal(t, []int32{9}, engine.query.ExcludedUserIDs)
+	assert.Equal(t, "alice", engine.query.MentionUsername)
+	assert.Equal(t, int32(DefaultLimit+1), engine.query.Limit)
+}
+
+func TestService_SearchScopes(t *testing.T) {
+	service, engine := newTestService()
+
+	_, err := service.Search(context.Background(), 1, Filters{Query: "deploy", In: InDMs})
+	assert.NoError(t, err)
+	assert.Empty(t, engine.query.ServerIDs)
+	assert.Equal(t, []int32{7}, engine.query.ConversationIDs)
+
+	_, err = service.Search(context.Background(), 1, Filters{Query: "deploy", ChannelID: 10})
+	assert.NoError(t, err)
+	assert.Equal(t, []int32{1}, engine.query.ServerIDs)
+	assert.Empty(t, engine.query.ConversationIDs)
+	assert.Equal(t, int32(10), engine.query.ChannelID)
+
+	_, err = service.Search(context.Background(), 1, Filters{Query: "deploy", ServerID: 3})
+	assert.Equal(t, ErrNotServerMember, err)
+
+	_, err = service.Search(context.Background(), 1, Filters{Query: "deploy", ChannelID: 30})
+	assert.Equal(t, ErrNotServerMember, err)
+
+	_, err = service.Search(context.Background(), 1, Filters{Query: "deploy", ServerID: 2, ChannelID: 10})
+	assert.Equal(t, ErrChannelNotFound, err)
+}
+
+func TestService_SearchValidatesFilters(t *testing.T) {
+	service, _ := newTestService()
+	now := time.Now()
+
+	cases := map[error]Filters{
+		ErrInvalidQuery:     {Query: "   "},
+		ErrInvalidScope:     {Query: "deploy", In: "threads"},
+		ErrInvalidDateRange: {Query: "deploy", After: now, Before: now.Add(-time.Hour)},
+		ErrInvalidPage:      {Query: "deploy", Limit: MaxLimit + 1},
+		ErrUserNotFound:     {Query: "deploy", MentionsID: 404},
+	}
+	for expected, filters := range cases {
+		_, err := service.Search(context.Background(), 1, filters)
+		assert.Equal(t, expected, err)
+	}
+}
+
+func TestService_SearchPaginates(t *testing.T) {
+	service, engine := newTestService()
+	engine.results = []dtos.SearchResultDto{{ID: 1}, {ID: 2}, {ID: 3}}
+
+	page, err := service.Search(context.Background(), 1, Filters{Query: "deploy", Limit: 2, Offset: 4})
+	assert.NoError(t, err)
+	assert.True(t, page.HasMore)
+	assert.Len(t, page.Results, 2)
+	assert.Equal(t, int32(4), engine.query.Offset)
+
+	engine.results = engine.results[:2]
+	page, err = service.Search(context.Background(), 1, Filters{Query: "deploy", Limit: 2})
+	assert.NoError(t, err)
+	assert.False(t, page.HasMore)
+}
+
+func TestMentionPattern(t *testing.T) {
+	assert.Equal(t, `(^|[^a-zA-Z0-9._-])@j\.doe($|[^a-zA-Z0-9._-])`, mentionPattern("j.doe"))
+}
@@ -4,6 +4,7 @@ import (
 	"database/sql"
 	"strconv"
 
+	"github.com/andrelcunha/Concord/backend/internal/middleware"
 	"github.com/gofiber/fiber/v2"
 )
 
@@ -28,8 +29,16 @@ type ListUserServersResponse struct {
 	Servers []CreateServerResponse `json:"servers"`
 }
 
+type DiscoverServerResponse struct {
+	CreateServerResponse
+	MemberCount        int64  `json:"member_count"`
+	RecentMessageCount int64  `json:"recent_message_count"`
+	LastMessageAt      string `json:"last_message_at,omitempty"`
+}
+
 type DiscoverServersResponse struct {
-	Servers []CreateServerResponse `json:"servers"`
+	Servers []DiscoverServerResponse `json:"servers"`
+	HasMore bool                     `json:"has_more"`
 }
 
 func NewHandler(service *Service) *Handler {
@@ -117,29 +126,127 @@ func (h *Handler) DiscoverServers(c *fiber.Ctx) error {
 	userID := c.Locals("userID").(int32)
 	query := c.Query("query")
 
-	serverDtos, err := h.Service.ListDiscoverableServers(c.Context(), userID, query)
+	limit := c.QueryInt("limit", DefaultDiscoverLimit)
+	offset := c.QueryInt("offset", 0)
+
+	serverDtos, hasMore, err := h.Service.ListDiscoverableServers(c.Context(), userID, query, int32(limit), int32(offset))
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
 	}
 
-	servers := make([]CreateServerResponse, len(serverDtos))
+	servers := make([]DiscoverServerResponse, len(serverDtos))
 	for i, serverDto := range serverDtos {
-		servers[i] = CreateServerResponse{
-			ID:        serverDto.ID,
-			Name:      serverDto.Name,
-			CreatorID: serverDto.CreatorID,
-			IsPublic:  serverDto.IsPublic,
-			CreatedAt: serverDto.CreatedAt,
+		servers[i] = DiscoverServerResponse{
+			CreateServerResponse: CreateServerResponse{
+				ID:        serverDto.ID,
+				Name:      serverDto.Name,
+				CreatorID: serverDto.CreatorID,
+				IsPublic:  serverDto.IsPublic,
+				CreatedAt: serverDto.CreatedAt,
+			},
+			MemberCount:        serverDto.MemberCount,
+			RecentMessageCount: serverDto.RecentMessageCount,
+			LastMessageAt:      serverDto.LastMessageAt,
 		}
 	}
 
-	return c.JSON(DiscoverServersResponse{Servers: servers})
+	return c.JSON(DiscoverServersResponse{Servers: servers, HasMore: hasMore})
+}
+
+func (h *Handler) DeleteServer(c *fiber.Ctx) error {
+	serverID, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid server ID"})
+	}
+	userID := c.Locals("userID").(int32)
+
+	if err := h.Service.DeleteServer(c.Context(), int32(serverID), userID); err != nil {
+		return ownerErrorResponse(c, err)
+	}
+
+	return c.SendStatus(fiber.StatusOK)
+}
+
+// RequireOwner stops non-owners before later handlers run. It sits in front
+// of the fresh TOTP check so a refused request does not burn the user's code.
+func (h *Handler) RequireOwner(c *fiber.Ctx) error {
+	serverID, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid server ID"})
+	}
+	userID := c.Locals("userID").(int32)
+
+	if err := h.Service.CheckOwner(c.Context(), int32(serverID), userID); err != nil {
+		return ownerErrorResponse(c, err)
+	}
+	return c.Next()
+}
+
+func ownerErrorResponse(c *fiber.Ctx, err error) error {
+	switch err {
+	case ErrServerNotFound:
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Server not found"})
+	case ErrNotServerOwner:
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": err.Error()})
+	default:
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+}
+
+func (h *Handler) GetMemberProfile(c *fiber.Ctx) error {
+	serverID, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid server ID"})
+	}
+	userID := c.Locals("userID").(int32)
+
+	profile, err := h.Service.GetMemberProfile(c.Context(), int32(serverID), userID)
+	if err != nil {
+		return memberProfileErrorResponse(c, err)
+	}
+	return c.JSON(profile)
+}
+
+func (h *Handler) UpdateMemberProfile(c *fiber.Ctx) error {
+	serverID, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid server ID"})
+	}
+	var req MemberProfileUpdate
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request"})
+	}
+	userID := c.Locals("userID").(int32)
+
+	profile, err := h.Service.UpdateMemberProfile(c.Context(), int32(serverID), userID, req)
+	if err != nil {
+		return memberProfileErrorResponse(c, err)
+	}
+	return c.JSON(profile)
+}
+
+func memberProfileErrorResponse(c *fiber.Ctx, err error) error {
+	switch err {
+	case ErrNotServerMember:
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Not a server member"})
+	case ErrNicknameTooLong, ErrInvalidAvatarURL:
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	default:
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
 }
 
-func RegisterServersRoutes(api fiber.Router, service *Service) {
+// RegisterServersRoutes mounts the server routes. requireFreshTotp guards
+// destructive operations such as deleting a server.
+func RegisterServersRoutes(api fiber.Router, service *Service, requireFreshTotp fiber.Handler) {
 	handler := NewHandler(service)
-	api.Post("/servers", handler.CreateServer)
-	api.Get("/servers", handler.ListUserServers)
-	api.Get("/servers/discover", handler.DiscoverServers)
-	api.Post("/servers/:id/join", handler.JoinServer)
+	read := middleware.RequireScope(middleware.ScopeMessagesRead)
+	manage := middleware.RequireScope(middleware.ScopeServersManage)
+	api.Post("/servers", manage, handler.CreateServer)
+	api.Get("/servers", read, handler.ListUserServers)
+	api.Get("/servers/discover", read, handler.DiscoverServers)
+	api.Post("/servers/:id/join", manage, handler.JoinServer)
+	api.Delete("/servers/:id", manage, handler.RequireOwner, requireFreshTotp, handler.DeleteServer)
+	api.Get("/servers/:id/members/me", middleware.RequireInteractiveOrBot(), handler.GetMemberProfile)
+	api.Patch("/servers/:id/members/me", middleware.RequireInteractiveOrBot(), handler.UpdateMemberProfile)
 }
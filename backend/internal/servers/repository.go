@@ -3,7 +3,9 @@ package servers
 import (
 	"context"
 
+	"github.com/andrelcunha/Concord/backend/internal/common"
 	"github.com/andrelcunha/Concord/backend/internal/db"
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgtype"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
@@ -17,10 +19,15 @@ type Repository interface {
 	CreateServer(ctx context.Context, name string, userID int32, isPublic bool) (db.Server, error)
 	CreateDefaultChannel(ctx context.Context, serverID, userID int32) error
 	ListUserServers(ctx context.Context, userID int32) ([]db.Server, error)
-	ListDiscoverableServers(ctx context.Context, userID int32, query string) ([]db.Server, error)
+	ListDiscoverableServers(ctx context.Context, userID int32, query string, limit, offset int32) ([]db.ListDiscoverableServersRow, error)
 	IsServerMember(ctx context.Context, serverID, userID int32) (bool, error)
+	UsersShareServer(ctx context.Context, userID, otherUserID int32) (bool, error)
+	GetMemberProfile(ctx context.Context, serverID, userID int32) (db.GetServerMemberProfileRow, error)
+	UpdateMemberProfile(ctx context.Context, serverID, userID int32, nickname, avatarURL string) (db.UpdateServerMemberProfileRow, error)
 	JoinServer(ctx context.Context, serverID, userID int32) error
+	LeaveServer(ctx context.Context, serverID, userID int32) (bool, error)
 	GetServer(ctx context.Context, serverID int32) (db.Server, error)
+	DeleteServer(ctx context.Context, serverID int32) error
 }
 
 func NewRepository(dbPool *pgxpool.Pool) Repository {
@@ -50,45 +57,15 @@ func (r *repository) ListUserServers(ctx context.Context, userID int32) ([]db.Se
 	return r.db.ListUserServers(ctx, userID)
 }
 
-func (r *repository) ListDiscoverableServers(ctx context.Context, userID int32, query string) ([]db.Server, error) {
-	const discoverServersQuery = `
-SELECT s.id, s.name, s.creator_id, s.is_public, s.created_at
-FROM servers s
-LEFT JOIN server_members sm
-  ON sm.server_id = s.id
- AND sm.user_id = $1
-WHERE s.is_public = TRUE
-  AND sm.user_id IS NULL
-  AND ($2 = '' OR s.name ILIKE '%' || $2 || '%')
-ORDER BY s.created_at ASC
-`
-
-	rows, err := r.pool.Query(ctx, discoverServersQuery, userID, query)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-
-	var servers []db.Server
-	for rows.Next() {
-		var server db.Server
-		if err := rows.Scan(
-			&server.ID,
-			&server.Name,
-			&server.CreatorID,
-			&server.IsPublic,
-			&server.CreatedAt,
-		); err != nil {
-			return nil, err
-		}
-		servers = append(servers, server)
-	}
-
-	if err := rows.Err(); err != nil {
-		return nil, err
-	}
-
-	return servers, nil
+func (r *repository) ListDiscoverableServers(ctx context.Context, userID int32, query string, limit, offset int32) ([]db.ListDiscoverableServersRow, error) {
+	return r.db.ListDiscoverableServers(ctx, db.ListDiscoverableServersParams{
+		UserID:        userID,
+		Query:         query,
+		Pattern:       common.ContainsPattern(query),
+		PrefixPattern: common.PrefixPattern(query),
+		PageLimit:     limit,
+		PageOffset:    offset,
+	})
 }
 
 func (r *repository) IsServerMember(ctx context.Context, serverID, userID int32) (bool, error) {
@@ -98,6 +75,31 @@ func (r *repository) IsServerMember(ctx context.Context, serverID, userID int32)
 	})
 }
 
+func (r *repository) UsersShareServer(ctx context.Context, userID, otherUserID int32) (bool, error) {
+	return r.db.UsersShareServer(ctx, db.UsersShareServerParams{
+		UserID:   userID,
+		UserID_2: otherUserID,
+	})
+}
+
+func (r *repository) GetMemberProfile(ctx context.Context, serverID, userID int32) (db.GetServerMemberProfileRow, error) {
+	return r.db.GetServerMemberProfile(ctx, db.GetServerMemberProfileParams{
+		ServerID: serverID,
+		UserID:   userID,
+	})
+}
+
+// UpdateMemberProfile stores empty values as NULL, so they fall back to the
+// user's own name and avatar.
+func (r *repository) UpdateMemberProfile(ctx context.Context, serverID, userID int32, nickname, avatarURL string) (db.UpdateServerMemberProfileRow, error) {
+	return r.db.UpdateServerMemberProfile(ctx, db.UpdateServerMemberProfileParams{
+		ServerID:  serverID,
+		UserID:    userID,
+		Nickname:  pgtype.Text{String: nickname, Valid: nickname != ""},
+		AvatarUrl: pgtype.Text{String: avatarURL, Valid: avatarURL != ""},
+	})
+}
+
 func (r *repository) JoinServer(ctx context.Context, serverID, userID int32) error {
 	return r.db.JoinServer(ctx, db.JoinServerParams{
 		ServerID: serverID,
@@ -105,6 +107,39 @@ func (r *repository) JoinServer(ctx context.Context, serverID, userID int32) err
 	})
 }
 
+func (r *repository) LeaveServer(ctx context.Context, serverID, userID int32) (bool, error) {
+	rows, err := r.db.LeaveServer(ctx, db.LeaveServerParams{
+		ServerID: serverID,
+		UserID:   userID,
+	})
+	return rows > 0, err
+}
+
 func (r *repository) GetServer(ctx context.Context, serverID int32) (db.Server, error) {
 	return r.db.GetServer(ctx, serverID)
 }
+
+// DeleteServer removes the server with its channels (and, by cascade, their
+// messages) and memberships in a single transaction.
+func (r *repository) DeleteServer(ctx context.Context, serverID int32) error {
+	tx, err := r.pool.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return err
+	}
+
+	queries := db.New(tx)
+	if err := queries.DeleteServerChannels(ctx, serverID); err != nil {
+		tx.Rollback(ctx)
+		return err
+	}
+	if err := queries.DeleteServerMembers(ctx, serverID); err != nil {
+		tx.Rollback(ctx)
+		return err
+	}
+	if err := queries.DeleteServer(ctx, serverID); err != nil {
+		tx.Rollback(ctx)
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
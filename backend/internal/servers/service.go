@@ -2,16 +2,46 @@ package servers
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"unicode/utf8"
 
+	"github.com/andrelcunha/Concord/backend/internal/db"
+	"github.com/andrelcunha/Concord/backend/internal/events"
 	"github.com/andrelcunha/Concord/backend/pkg/dtos"
+	"github.com/jackc/pgx/v5"
 )
 
+var (
+	ErrServerNotFound   = errors.New("server not found")
+	ErrNotServerOwner   = errors.New("only the server owner can do this")
+	ErrNotServerMember  = errors.New("not a server member")
+	ErrNicknameTooLong  = fmt.Errorf("nickname must be at most %d characters", MaxMemberNicknameLength)
+	ErrInvalidAvatarURL = errors.New("avatar_url must be an http or https URL")
+)
+
+const (
+	DefaultDiscoverLimit    = 25
+	MaxDiscoverLimit        = 100
+	MaxMemberNicknameLength = 32
+)
+
+// MemberProfileUpdate changes how a member appears in one server. Nil fields
+// are left alone; empty strings clear them.
+type MemberProfileUpdate struct {
+	Nickname  *string `json:"nickname"`
+	AvatarURL *string `json:"avatar_url"`
+}
+
 type Service struct {
-	repo Repository
+	repo   Repository
+	events events.Publisher
 }
 
-func NewService(repo Repository) *Service {
-	return &Service{repo: repo}
+func NewService(repo Repository, events events.Publisher) *Service {
+	return &Service{repo: repo, events: events}
 }
 
 func (s *Service) CreateServer(ctx context.Context, name string, userID int32, isPublic bool) (dtos.ServerDto, error) {
@@ -37,16 +67,44 @@ func (r *Service) ListUserServers(ctx context.Context, userID int32) ([]dtos.Ser
 	return serversDto, nil
 }
 
-func (r *Service) ListDiscoverableServers(ctx context.Context, userID int32, query string) ([]dtos.ServerDto, error) {
-	serversDb, err := r.repo.ListDiscoverableServers(ctx, userID, query)
+// ListDiscoverableServers pages through public servers the user has not
+// joined, ranked by name relevance and then by members and recent activity.
+func (r *Service) ListDiscoverableServers(ctx context.Context, userID int32, query string, limit, offset int32) ([]dtos.DiscoverableServerDto, bool, error) {
+	if limit < 1 || limit > MaxDiscoverLimit {
+		limit = DefaultDiscoverLimit
+	}
+	if offset < 0 {
+		offset = 0
+	}
+
+	rows, err := r.repo.ListDiscoverableServers(ctx, userID, strings.TrimSpace(query), limit+1, offset)
 	if err != nil {
-		return nil, err
+		return nil, false, err
 	}
-	serversDto := make([]dtos.ServerDto, len(serversDb))
-	for i, serverDb := range serversDb {
-		serversDto[i] = dtos.FromServerDbToServerDto(serverDb)
+
+	hasMore := len(rows) > int(limit)
+	if hasMore {
+		rows = rows[:limit]
 	}
-	return serversDto, nil
+
+	serversDto := make([]dtos.DiscoverableServerDto, len(rows))
+	for i, row := range rows {
+		serversDto[i] = dtos.DiscoverableServerDto{
+			ServerDto: dtos.FromServerDbToServerDto(db.Server{
+				ID:        row.ID,
+				Name:      row.Name,
+				CreatorID: row.CreatorID,
+				IsPublic:  row.IsPublic,
+				CreatedAt: row.CreatedAt,
+			}),
+			MemberCount:        row.MemberCount,
+			RecentMessageCount: row.RecentMessageCount,
+		}
+		if row.LastMessageAt.Valid {
+			serversDto[i].LastMessageAt = row.LastMessageAt.Time.Format("2006-01-02T15:04:05Z07:00")
+		}
+	}
+	return serversDto, hasMore, nil
 }
 
 func (s *Service) IsServerMember(ctx context.Context, serverID, userID int32) (bool, error) {
@@ -54,7 +112,15 @@ func (s *Service) IsServerMember(ctx context.Context, serverID, userID int32) (b
 }
 
 func (s *Service) JoinServer(ctx context.Context, serverID, userID int32) error {
-	return s.repo.JoinServer(ctx, serverID, userID)
+	if err := s.repo.JoinServer(ctx, serverID, userID); err != nil {
+		return err
+	}
+	s.events.Publish(ctx, events.Event{
+		Type:     events.EventMemberJoined,
+		ServerID: serverID,
+		Data:     events.MemberJoinedData{ServerID: serverID, UserID: userID},
+	})
+	return nil
 }
 
 func (s *Service) GetServer(ctx context.Context, serverID int32) (dtos.ServerDto, error) {
@@ -64,3 +130,79 @@ func (s *Service) GetServer(ctx context.Context, serverID int32) (dtos.ServerDto
 	}
 	return dtos.FromServerDbToServerDto(serverDb), nil
 }
+
+func (s *Service) DeleteServer(ctx context.Context, serverID, userID int32) error {
+	if err := s.CheckOwner(ctx, serverID, userID); err != nil {
+		return err
+	}
+	return s.repo.DeleteServer(ctx, serverID)
+}
+
+// CheckOwner reports ErrNotServerOwner unless userID created the server.
+func (s *Service) CheckOwner(ctx context.Context, serverID, userID int32) error {
+	serverDb, err := s.repo.GetServer(ctx, serverID)
+	if err != nil {
+		return ErrServerNotFound
+	}
+	if !serverDb.CreatorID.Valid || serverDb.CreatorID.Int32 != userID {
+		return ErrNotServerOwner
+	}
+	return nil
+}
+
+func (s *Service) GetMemberProfile(ctx context.Context, serverID, userID int32) (dtos.ServerMemberProfileDto, error) {
+	profile, err := s.repo.GetMemberProfile(ctx, serverID, userID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return dtos.ServerMemberProfileDto{}, ErrNotServerMember
+	}
+	if err != nil {
+		return dtos.ServerMemberProfileDto{}, err
+	}
+	return memberProfileDto(profile.ServerID, profile.UserID, profile.Nickname.String, profile.AvatarUrl.String), nil
+}
+
+// UpdateMemberProfile sets the caller's own nickname and avatar in a server.
+// Channel history and new messages show them in place of the user's own.
+func (s *Service) UpdateMemberProfile(ctx context.Context, serverID, userID int32, update MemberProfileUpdate) (dtos.ServerMemberProfileDto, error) {
+	current, err := s.GetMemberProfile(ctx, serverID, userID)
+	if err != nil {
+		return dtos.ServerMemberProfileDto{}, err
+	}
+
+	nickname, avatarURL := current.Nickname, current.AvatarURL
+	if update.Nickname != nil {
+		nickname = strings.TrimSpace(*update.Nickname)
+		if utf8.RuneCountInString(nickname) > MaxMemberNicknameLength {
+			return dtos.ServerMemberProfileDto{}, ErrNicknameTooLong
+		}
+	}
+	if update.AvatarURL != nil {
+		avatarURL = strings.TrimSpace(*update.AvatarURL)
+		if avatarURL != "" && !isHTTPURL(avatarURL) {
+			return dtos.ServerMemberProfileDto{}, ErrInvalidAvatarURL
+		}
+	}
+
+	profile, err := s.repo.UpdateMemberProfile(ctx, serverID, userID, nickname, avatarURL)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return dtos.ServerMemberProfileDto{}, ErrNotServerMember
+	}
+	if err != nil {
+		return dtos.ServerMemberProfileDto{}, err
+	}
+	return memberProfileDto(profile.ServerID, profile.UserID, profile.Nickname.String, profile.AvatarUrl.String), nil
+}
+
+func memberProfileDto(serverID, userID int32, nickname, avatarURL string) dtos.ServerMemberProfileDto {
+	return dtos.ServerMemberProfileDto{
+		ServerID:  serverID,
+		UserID:    userID,
+		Nickname:  nickname,
+		AvatarURL: avatarURL,
+	}
+}
+
+func isHTTPURL(value string) bool {
+	parsed, err := url.Parse(value)
+	return err == nil && (parsed.Scheme == "http" || parsed.Scheme == "https") && parsed.Host != ""
+}
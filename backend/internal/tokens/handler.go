@@ -0,0 +1,74 @@
+package tokens
+
+import (
+	"strconv"
+
+	"github.com/andrelcunha/Concord/backend/internal/middleware"
+	"github.com/gofiber/fiber/v2"
+)
+
+type Handler struct {
+	service *Service
+}
+
+func NewHandler(service *Service) *Handler {
+	return &Handler{service: service}
+}
+
+func (h *Handler) CreateToken(c *fiber.Ctx) error {
+	var req struct {
+		Name          string   `json:"name"`
+		Scopes        []string `json:"scopes"`
+		ExpiresInDays int      `json:"expires_in_days"`
+	}
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request"})
+	}
+
+	userID := c.Locals("userID").(int32)
+	token, err := h.service.CreateToken(c.Context(), userID, req.Name, req.Scopes, req.ExpiresInDays)
+	if err != nil {
+		switch err {
+		case ErrInvalidTokenName, ErrInvalidScopes, ErrInvalidExpiry:
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		default:
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+		}
+	}
+	return c.Status(fiber.StatusCreated).JSON(token)
+}
+
+func (h *Handler) ListTokens(c *fiber.Ctx) error {
+	userID := c.Locals("userID").(int32)
+	tokens, err := h.service.ListTokens(c.Context(), userID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(fiber.Map{"tokens": tokens})
+}
+
+func (h *Handler) RevokeToken(c *fiber.Ctx) error {
+	tokenID, err := strconv.ParseInt(c.Params("id"), 10, 32)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid token ID"})
+	}
+
+	userID := c.Locals("userID").(int32)
+	if err := h.service.RevokeToken(c.Context(), userID, int32(tokenID)); err != nil {
+		if err == ErrTokenNotFound {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// RegisterTokenRoutes mounts token management. Tokens cannot mint or revoke
+// other tokens, so these routes require an interactive login.
+func RegisterTokenRoutes(api fiber.Router, service *Service) {
+	handler := NewHandler(service)
+	tokens := api.Group("/tokens", middleware.RequireInteractive())
+	tokens.Get("/", handler.ListTokens)
+	tokens.Post("/", handler.CreateToken)
+	tokens.Delete("/:id", handler.RevokeToken)
+}
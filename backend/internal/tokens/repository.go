@@ -0,0 +1,57 @@
+package tokens
+
+import (
+	"context"
+
+	"github.com/andrelcunha/Concord/backend/internal/db"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type Repository interface {
+	CreateToken(ctx context.Context, userID int32, name, tokenHash string, scopes []string, expiresAt pgtype.Timestamptz) (db.PersonalAccessToken, error)
+	ListTokens(ctx context.Context, userID int32) ([]db.PersonalAccessToken, error)
+	DeleteToken(ctx context.Context, id, userID int32) (bool, error)
+	GetTokenByHash(ctx context.Context, tokenHash string) (db.GetPersonalAccessTokenByHashRow, error)
+	TouchToken(ctx context.Context, id int32) error
+}
+
+type repository struct {
+	db *db.Queries
+}
+
+func NewRepository(dbPool *pgxpool.Pool) Repository {
+	return &repository{
+		db: db.New(dbPool),
+	}
+}
+
+func (r *repository) CreateToken(ctx context.Context, userID int32, name, tokenHash string, scopes []string, expiresAt pgtype.Timestamptz) (db.PersonalAccessToken, error) {
+	return r.db.CreatePersonalAccessToken(ctx, db.CreatePersonalAccessTokenParams{
+		UserID:    userID,
+		Name:      name,
+		TokenHash: tokenHash,
+		Scopes:    scopes,
+		ExpiresAt: expiresAt,
+	})
+}
+
+func (r *repository) ListTokens(ctx context.Context, userID int32) ([]db.PersonalAccessToken, error) {
+	return r.db.ListPersonalAccessTokensForUser(ctx, userID)
+}
+
+func (r *repository) DeleteToken(ctx context.Context, id, userID int32) (bool, error) {
+	rows, err := r.db.DeletePersonalAccessToken(ctx, db.DeletePersonalAccessTokenParams{
+		ID:     id,
+		UserID: userID,
+	})
+	return rows > 0, err
+}
+
+func (r *repository) GetTokenByHash(ctx context.Context, tokenHash string) (db.GetPersonalAccessTokenByHashRow, error) {
+	return r.db.GetPersonalAccessTokenByHash(ctx, tokenHash)
+}
+
+func (r *repository) TouchToken(ctx context.Context, id int32) error {
+	return r.db.TouchPersonalAccessToken(ctx, id)
+}
@@ -0,0 +1,171 @@
+package tokens
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"log"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/andrelcunha/Concord/backend/internal/db"
+	"github.com/andrelcunha/Concord/backend/internal/middleware"
+	"github.com/andrelcunha/Concord/backend/pkg/dtos"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const (
+	maxTokenNameLength = 100
+	maxExpiresInDays   = 365
+)
+
+var (
+	ErrInvalidTokenName = errors.New("token name must be between 1 and 100 characters")
+	ErrInvalidScopes    = errors.New("at least one valid scope is required")
+	ErrInvalidExpiry    = errors.New("expires_in_days must be between 1 and 365")
+	ErrTokenNotFound    = errors.New("token not found")
+	ErrInvalidToken     = errors.New("invalid or expired token")
+)
+
+type Service struct {
+	repo Repository
+}
+
+func NewService(repo Repository) *Service {
+	return &Service{repo: repo}
+}
+
+// CreateToken issues a new personal access token. The plaintext token is only
+// returned here; the database keeps its SHA-256 hash. expiresInDays of 0
+// means the token never expires.
+func (s *Service) CreateToken(ctx context.Context, userID int32, name string, scopes []string, expiresInDays int) (dtos.PersonalAccessTokenDto, error) {
+	name = strings.TrimSpace(name)
+	if name == "" || len(name) > maxTokenNameLength {
+		return dtos.PersonalAccessTokenDto{}, ErrInvalidTokenName
+	}
+	scopes, err := normalizeScopes(scopes)
+	if err != nil {
+		return dtos.PersonalAccessTokenDto{}, err
+	}
+	if expiresInDays < 0 || expiresInDays > maxExpiresInDays {
+		return dtos.PersonalAccessTokenDto{}, ErrInvalidExpiry
+	}
+
+	var expiresAt pgtype.Timestamptz
+	if expiresInDays > 0 {
+		expiresAt = pgtype.Timestamptz{Time: time.Now().AddDate(0, 0, expiresInDays), Valid: true}
+	}
+
+	token, err := generateToken()
+	if err != nil {
+		return dtos.PersonalAccessTokenDto{}, err
+	}
+
+	created, err := s.repo.CreateToken(ctx, userID, name, hashToken(token), scopes, expiresAt)
+	if err != nil {
+		return dtos.PersonalAccessTokenDto{}, err
+	}
+
+	tokenDto := toTokenDto(created)
+	tokenDto.Token = token
+	return tokenDto, nil
+}
+
+func (s *Service) ListTokens(ctx context.Context, userID int32) ([]dtos.PersonalAccessTokenDto, error) {
+	tokens, err := s.repo.ListTokens(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	tokenDtos := make([]dtos.PersonalAccessTokenDto, 0, len(tokens))
+	for _, token := range tokens {
+		tokenDtos = append(tokenDtos, toTokenDto(token))
+	}
+	return tokenDtos, nil
+}
+
+func (s *Service) RevokeToken(ctx context.Context, userID, tokenID int32) error {
+	deleted, err := s.repo.DeleteToken(ctx, tokenID, userID)
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		return ErrTokenNotFound
+	}
+	return nil
+}
+
+// AuthenticatePersonalAccessToken resolves a bearer token to its owner and
+// granted scopes. It satisfies middleware.PersonalAccessTokenAuthenticator.
+func (s *Service) AuthenticatePersonalAccessToken(ctx context.Context, token string) (*dtos.UserDto, []string, error) {
+	row, err := s.repo.GetTokenByHash(ctx, hashToken(token))
+	if err != nil {
+		return nil, nil, ErrInvalidToken
+	}
+	if row.ExpiresAt.Valid && time.Now().After(row.ExpiresAt.Time) {
+		return nil, nil, ErrInvalidToken
+	}
+
+	if err := s.repo.TouchToken(ctx, row.ID); err != nil {
+		log.Printf("Failed to update last use of token %d: %v", row.ID, err)
+	}
+
+	return &dtos.UserDto{
+		UserId:      row.UserID,
+		Username:    row.Username,
+		AvatarUrl:   row.AvatarUrl.String,
+		AvatarColor: row.AvatarColor.String,
+	}, row.Scopes, nil
+}
+
+// normalizeScopes drops duplicates and rejects unknown scopes.
+func normalizeScopes(scopes []string) ([]string, error) {
+	normalized := make([]string, 0, len(scopes))
+	for _, scope := range scopes {
+		if !middleware.IsValidScope(scope) {
+			return nil, ErrInvalidScopes
+		}
+		if !slices.Contains(normalized, scope) {
+			normalized = append(normalized, scope)
+		}
+	}
+	if len(normalized) == 0 {
+		return nil, ErrInvalidScopes
+	}
+	return normalized, nil
+}
+
+func generateToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return middleware.PersonalAccessTokenPrefix + base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// Tokens carry 256 bits of entropy, so a fast hash is sufficient and allows
+// direct lookup by hash.
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+func toTokenDto(token db.PersonalAccessToken) dtos.PersonalAccessTokenDto {
+	tokenDto := dtos.PersonalAccessTokenDto{
+		ID:        token.ID,
+		Name:      token.Name,
+		Scopes:    token.Scopes,
+		CreatedAt: token.CreatedAt.Time.Format("2006-01-02T15:04:05Z07:00"),
+	}
+	if token.ExpiresAt.Valid {
+		tokenDto.ExpiresAt = token.ExpiresAt.Time.Format("2006-01-02T15:04:05Z07:00")
+	}
+	if token.LastUsedAt.Valid {
+		tokenDto.LastUsedAt = token.LastUsedAt.Time.Format("2006-01-02T15:04:05Z07:00")
+	}
+	return tokenDto
+}
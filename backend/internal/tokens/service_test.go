@@ -0,0 +1,159 @@
+package tokens
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/andrelcunha/Concord/backend/internal/db"
+	"github.com/andrelcunha/Concord/backend/internal/middleware"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// mockRepository keeps tokens in memory, keyed by ID.
+type mockRepository struct {
+	tokens map[int32]*db.PersonalAccessToken
+	nextID int32
+}
+
+func newMockRepository() *mockRepository {
+	return &mockRepository{tokens: map[int32]*db.PersonalAccessToken{}}
+}
+
+func (m *mockRepository) CreateToken(ctx context.Context, userID int32, name, tokenHash string, scopes []string, expiresAt pgtype.Timestamptz) (db.PersonalAccessToken, error) {
+	m.nextID++
+	token := &db.PersonalAccessToken{
+		ID:        m.nextID,
+		UserID:    userID,
+		Name:      name,
+		TokenHash: tokenHash,
+		Scopes:    scopes,
+		ExpiresAt: expiresAt,
+		CreatedAt: pgtype.Timestamptz{Time: time.Now(), Valid: true},
+	}
+	m.tokens[token.ID] = token
+	return *token, nil
+}
+
+func (m *mockRepository) ListTokens(ctx context.Context, userID int32) ([]db.PersonalAccessToken, error) {
+	var tokens []db.PersonalAccessToken
+	for _, token := range m.tokens {
+		if token.UserID == userID {
+			tokens = append(tokens, *token)
+		}
+	}
+	return tokens, nil
+}
+
+func (m *mockRepository) DeleteToken(ctx context.Context, id, userID int32) (bool, error) {
+	token, ok := m.tokens[id]
+	if !ok || token.UserID != userID {
+		return false, nil
+	}
+	delete(m.tokens, id)
+	return true, nil
+}
+
+func (m *mockRepository) GetTokenByHash(ctx context.Context, tokenHash string) (db.GetPersonalAccessTokenByHashRow, error) {
+	for _, token := range m.tokens {
+		if token.TokenHash == tokenHash {
+			return db.GetPersonalAccessTokenByHashRow{
+				ID:        token.ID,
+				UserID:    token.UserID,
+				Scopes:    token.Scopes,
+				ExpiresAt: token.ExpiresAt,
+				Username:  "alice",
+			}, nil
+		}
+	}
+	return db.GetPersonalAccessTokenByHashRow{}, pgx.ErrNoRows
+}
+
+func (m *mockRepository) TouchToken(ctx context.Context, id int32) error {
+	m.tokens[id].LastUsedAt = pgtype.Timestamptz{Time: time.Now(), Valid: true}
+	return nil
+}
+
+func TestCreateTokenStoresOnlyTheHash(t *testing.T) {
+	repo := newMockRepository()
+	service := NewService(repo)
+
+	created, err := service.CreateToken(context.Background(), 1, " CI deploys ", []string{middleware.ScopeMessagesSend}, 30)
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(created.Token, middleware.PersonalAccessTokenPrefix))
+	assert.Equal(t, "CI deploys", created.Name)
+	assert.NotEmpty(t, created.ExpiresAt)
+
+	stored := repo.tokens[created.ID]
+	assert.Equal(t, hashToken(created.Token), stored.TokenHash)
+	assert.NotContains(t, stored.TokenHash, created.Token)
+	assert.WithinDuration(t, time.Now().AddDate(0, 0, 30), stored.ExpiresAt.Time, time.Minute)
+
+	listed, err := service.ListTokens(context.Background(), 1)
+	require.NoError(t, err)
+	require.Len(t, listed, 1)
+	assert.Empty(t, listed[0].Token)
+}
+
+func TestCreateTokenValidation(t *testing.T) {
+	service := NewService(newMockRepository())
+	ctx := context.Background()
+	scopes := []string{middleware.ScopeMessagesRead}
+
+	_, err := service.CreateToken(ctx, 1, "  ", scopes, 0)
+	assert.Equal(t, ErrInvalidTokenName, err)
+	_, err = service.CreateToken(ctx, 1, strings.Repeat("a", maxTokenNameLength+1), scopes, 0)
+	assert.Equal(t, ErrInvalidTokenName, err)
+
+	_, err = service.CreateToken(ctx, 1, "bot", scopes, maxExpiresInDays+1)
+	assert.Equal(t, ErrInvalidExpiry, err)
+	_, err = service.CreateToken(ctx, 1, "bot", scopes, -1)
+	assert.Equal(t, ErrInvalidExpiry, err)
+
+	created, err := service.CreateToken(ctx, 1, "forever", scopes, 0)
+	require.NoError(t, err)
+	assert.Empty(t, created.ExpiresAt)
+}
+
+func TestNormalizeScopes(t *testing.T) {
+	scopes, err := normalizeScopes([]string{middleware.ScopeMessagesRead, middleware.ScopeMessagesSend, middleware.ScopeMessagesRead})
+	require.NoError(t, err)
+	assert.Equal(t, []string{middleware.ScopeMessagesRead, middleware.ScopeMessagesSend}, scopes)
+
+	_, err = normalizeScopes(nil)
+	assert.Equal(t, ErrInvalidScopes, err)
+	_, err = normalizeScopes([]string{middleware.ScopeMessagesRead, "admin:everything"})
+	assert.Equal(t, ErrInvalidScopes, err)
+}
+
+func TestAuthenticateTracksUseAndRejectsExpiredOrRevoked(t *testing.T) {
+	repo := newMockRepository()
+	service := NewService(repo)
+	ctx := context.Background()
+
+	created, err := service.CreateToken(ctx, 1, "reader", []string{middleware.ScopeMessagesRead}, 7)
+	require.NoError(t, err)
+
+	user, scopes, err := service.AuthenticatePersonalAccessToken(ctx, created.Token)
+	require.NoError(t, err)
+	assert.Equal(t, int32(1), user.UserId)
+	assert.Equal(t, []string{middleware.ScopeMessagesRead}, scopes)
+	assert.True(t, repo.tokens[created.ID].LastUsedAt.Valid)
+
+	_, _, err = service.AuthenticatePersonalAccessToken(ctx, created.Token+"x")
+	assert.Equal(t, ErrInvalidToken, err)
+
+	repo.tokens[created.ID].ExpiresAt = pgtype.Timestamptz{Time: time.Now().Add(-time.Minute), Valid: true}
+	_, _, err = service.AuthenticatePersonalAccessToken(ctx, created.Token)
+	assert.Equal(t, ErrInvalidToken, err)
+
+	assert.Equal(t, ErrTokenNotFound, service.RevokeToken(ctx, 2, created.ID))
+	require.NoError(t, service.RevokeToken(ctx, 1, created.ID))
+	_, _, err = service.AuthenticatePersonalAccessToken(ctx, created.Token)
+	assert.Equal(t, ErrInvalidToken, err)
+	assert.Equal(t, ErrTokenNotFound, service.RevokeToken(ctx, 1, created.ID))
+}
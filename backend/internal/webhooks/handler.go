@@ -0,0 +1,145 @@
+package webhooks
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+
+	"github.com/andrelcunha/Concord/backend/internal/middleware"
+	"github.com/andrelcunha/Concord/backend/internal/ratelimit"
+	"github.com/gofiber/fiber/v2"
+)
+
+type Handler struct {
+	service     *Service
+	limiter     *ratelimit.Limiter
+	executeRule ratelimit.Rule
+}
+
+type CreateWebhookRequest struct {
+	Name      string `json:"name"`
+	AvatarURL string `json:"avatar_url"`
+}
+
+func NewHandler(service *Service, limiter *ratelimit.Limiter, executeRule ratelimit.Rule) *Handler {
+	return &Handler{
+		service:     service,
+		limiter:     limiter,
+		executeRule: executeRule,
+	}
+}
+
+func (h *Handler) CreateWebhook(c *fiber.Ctx) error {
+	channelID, err := strconv.ParseInt(c.Params("id"), 10, 32)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid channel ID"})
+	}
+	var req CreateWebhookRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request"})
+	}
+
+	userID := c.Locals("userID").(int32)
+	webhook, err := h.service.CreateWebhook(c.Context(), userID, int32(channelID), req.Name, req.AvatarURL)
+	if err != nil {
+		return webhookErrorResponse(c, err)
+	}
+	return c.Status(fiber.StatusCreated).JSON(webhook)
+}
+
+func (h *Handler) ListWebhooks(c *fiber.Ctx) error {
+	channelID, err := strconv.ParseInt(c.Params("id"), 10, 32)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid channel ID"})
+	}
+
+	userID := c.Locals("userID").(int32)
+	webhooks, err := h.service.ListWebhooks(c.Context(), userID, int32(channelID))
+	if err != nil {
+		return webhookErrorResponse(c, err)
+	}
+	return c.JSON(fiber.Map{"webhooks": webhooks})
+}
+
+func (h *Handler) DeleteWebhook(c *fiber.Ctx) error {
+	webhookID, err := strconv.ParseInt(c.Params("id"), 10, 32)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid webhook ID"})
+	}
+
+	userID := c.Locals("userID").(int32)
+	if err := h.service.DeleteWebhook(c.Context(), userID, int32(webhookID)); err != nil {
+		return webhookErrorResponse(c, err)
+	}
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// WebhookAuthScheme prefixes the token in the Authorization header of
+// execute requests, e.g. "Authorization: Webhook <token>".
+const WebhookAuthScheme = "Webhook "
+
+// ExecuteWebhook is the public endpoint behind a webhook's URL. The token
+// travels in the Authorization header so it never lands in access logs.
+func (h *Handler) ExecuteWebhook(c *fiber.Ctx) error {
+	webhookID, err := strconv.ParseInt(c.Params("id"), 10, 32)
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": ErrWebhookNotFound.Error()})
+	}
+	token, ok := strings.CutPrefix(c.Get("Authorization"), WebhookAuthScheme)
+	if !ok || token == "" {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": ErrInvalidWebhookAuth.Error()})
+	}
+	var req ExecuteWebhookRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request"})
+	}
+
+	// Each webhook shares one send budget, whoever holds the token.
+	result, err := h.limiter.Allow(context.Background(), h.executeRule, fmt.Sprintf("webhook:%d", webhookID))
+	if err != nil {
+		log.Printf("Rate limit error: %v", err)
+	} else if !result.Allowed {
+		middleware.SetRetryAfter(c, result.RetryAfter.Seconds())
+		return c.Status(fiber.StatusTooManyRequests).JSON(fiber.Map{"error": "Too many requests"})
+	}
+
+	message, err := h.service.ExecuteWebhook(c.Context(), int32(webhookID), token, req)
+	if err != nil {
+		return webhookErrorResponse(c, err)
+	}
+	return c.Status(fiber.StatusCreated).JSON(message)
+}
+
+func webhookErrorResponse(c *fiber.Ctx, err error) error {
+	switch err {
+	case ErrInvalidWebhookName, ErrInvalidAvatarURL, ErrEmptyMessage, ErrContentTooLong, ErrInvalidEmbeds:
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	case ErrInvalidWebhookAuth:
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": err.Error()})
+	case ErrNotChannelMember, ErrNotWebhookManager:
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": err.Error()})
+	case ErrChannelNotFound, ErrWebhookNotFound:
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
+	default:
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+}
+
+// RegisterWebhookRoutes mounts webhook management under the protected API.
+// Managing webhooks needs the same scope as creating channels.
+func RegisterWebhookRoutes(api fiber.Router, service *Service, limiter *ratelimit.Limiter, executeRule ratelimit.Rule) {
+	handler := NewHandler(service, limiter, executeRule)
+	manage := middleware.RequireScope(middleware.ScopeServersManage)
+	api.Get("/channels/:id/webhooks", manage, handler.ListWebhooks)
+	api.Post("/channels/:id/webhooks", manage, handler.CreateWebhook)
+	api.Delete("/webhooks/:id", manage, handler.DeleteWebhook)
+}
+
+// RegisterWebhookExecuteRoutes mounts the public webhook URL. It sits
+// outside /api because the webhook token is the only credential.
+func RegisterWebhookExecuteRoutes(app fiber.Router, service *Service, limiter *ratelimit.Limiter, executeRule ratelimit.Rule) {
+	handler := NewHandler(service, limiter, executeRule)
+	app.Post("/webhooks/:id", handler.ExecuteWebhook)
+}
@@ -0,0 +1,53 @@
+package webhooks
+
+import (
+	"context"
+
+	"github.com/andrelcunha/Concord/backend/internal/db"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type Repository interface {
+	GetChannel(ctx context.Context, channelID int32) (db.GetChannelRow, error)
+	CreateWebhook(ctx context.Context, channelID, userID int32, name, avatarURL, tokenHash string) (db.CreateWebhookRow, error)
+	ListWebhooks(ctx context.Context, channelID int32) ([]db.ListWebhooksByChannelRow, error)
+	GetWebhook(ctx context.Context, webhookID int32) (db.GetWebhookRow, error)
+	DeleteWebhook(ctx context.Context, webhookID int32) error
+}
+
+type repository struct {
+	db *db.Queries
+}
+
+func NewRepository(dbPool *pgxpool.Pool) Repository {
+	return &repository{
+		db: db.New(dbPool),
+	}
+}
+
+func (r *repository) GetChannel(ctx context.Context, channelID int32) (db.GetChannelRow, error) {
+	return r.db.GetChannel(ctx, channelID)
+}
+
+func (r *repository) CreateWebhook(ctx context.Context, channelID, userID int32, name, avatarURL, tokenHash string) (db.CreateWebhookRow, error) {
+	return r.db.CreateWebhook(ctx, db.CreateWebhookParams{
+		ChannelID: channelID,
+		CreatedBy: pgtype.Int4{Int32: userID, Valid: true},
+		Name:      name,
+		AvatarUrl: pgtype.Text{String: avatarURL, Valid: avatarURL != ""},
+		TokenHash: tokenHash,
+	})
+}
+
+func (r *repository) ListWebhooks(ctx context.Context, channelID int32) ([]db.ListWebhooksByChannelRow, error) {
+	return r.db.ListWebhooksByChannel(ctx, channelID)
+}
+
+func (r *repository) GetWebhook(ctx context.Context, webhookID int32) (db.GetWebhookRow, error) {
+	return r.db.GetWebhook(ctx, webhookID)
+}
+
+func (r *repository) DeleteWebhook(ctx context.Context, webhookID int32) error {
+	return r.db.DeleteWebhook(ctx, webhookID)
+}
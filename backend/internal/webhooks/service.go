@@ -0,0 +1,236 @@
+package webhooks
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"unicode/utf8"
+
+	. "github.com/andrelcunha/Concord/backend/internal/common"
+	"github.com/andrelcunha/Concord/backend/internal/db"
+	"github.com/andrelcunha/Concord/backend/internal/servers"
+	"github.com/andrelcunha/Concord/backend/internal/websocket"
+	"github.com/andrelcunha/Concord/backend/pkg/dtos"
+)
+
+const (
+	maxWebhookNameLength     = 80
+	maxContentLength         = 2000
+	maxEmbeds                = 10
+	maxEmbedTitleLength      = 256
+	maxEmbedDescriptionLen   = 4096
+	maxEmbedFields           = 25
+	maxEmbedFieldValueLength = 1024
+)
+
+var (
+	ErrInvalidWebhookName = errors.New("webhook name must be between 1 and 80 characters")
+	ErrInvalidAvatarURL   = errors.New("avatar_url must be an http or https URL")
+	ErrChannelNotFound    = errors.New("channel not found")
+	ErrNotChannelMember   = errors.New("not a server member")
+	ErrWebhookNotFound    = errors.New("webhook not found")
+	ErrNotWebhookManager  = errors.New("only the webhook creator or server owner can delete this webhook")
+	ErrInvalidWebhookAuth = errors.New("invalid webhook token")
+	ErrEmptyMessage       = errors.New("content or embeds are required")
+	ErrContentTooLong     = errors.New("content must be at most 2000 characters")
+	ErrInvalidEmbeds      = errors.New("invalid embeds")
+)
+
+// ExecuteWebhookRequest is the body accepted by a webhook URL. Username and
+// AvatarURL override the webhook's defaults for this message only.
+type ExecuteWebhookRequest struct {
+	Content   string       `json:"content"`
+	Username  string       `json:"username"`
+	AvatarURL string       `json:"avatar_url"`
+	Embeds    []dtos.Embed `json:"embeds"`
+}
+
+type Service struct {
+	repo       Repository
+	serverRepo servers.Repository
+	messages   *websocket.Service
+}
+
+func NewService(repo Repository, serverRepo servers.Repository, messages *websocket.Service) *Service {
+	return &Service{
+		repo:       repo,
+		serverRepo: serverRepo,
+		messages:   messages,
+	}
+}
+
+// CreateWebhook adds a webhook to a channel and returns it with its secret
+// token. Only the token hash is stored, so the token is shown this once.
+func (s *Service) CreateWebhook(ctx context.Context, userID, channelID int32, name, avatarURL string) (dtos.WebhookDto, error) {
+	name = strings.TrimSpace(name)
+	if name == "" || utf8.RuneCountInString(name) > maxWebhookNameLength {
+		return dtos.WebhookDto{}, ErrInvalidWebhookName
+	}
+	if avatarURL != "" && !isHTTPURL(avatarURL) {
+		return dtos.WebhookDto{}, ErrInvalidAvatarURL
+	}
+	if err := s.checkChannelMember(ctx, userID, channelID); err != nil {
+		return dtos.WebhookDto{}, err
+	}
+
+	token, err := generateWebhookToken()
+	if err != nil {
+		return dtos.WebhookDto{}, err
+	}
+
+	webhook, err := s.repo.CreateWebhook(ctx, channelID, userID, name, avatarURL, hashWebhookToken(token))
+	if err != nil {
+		return dtos.WebhookDto{}, err
+	}
+
+	webhookDto := toWebhookDto(db.ListWebhooksByChannelRow(webhook))
+	webhookDto.Token = token
+	return webhookDto, nil
+}
+
+func (s *Service) ListWebhooks(ctx context.Context, userID, channelID int32) ([]dtos.WebhookDto, error) {
+	if err := s.checkChannelMember(ctx, userID, channelID); err != nil {
+		return nil, err
+	}
+
+	webhooks, err := s.repo.ListWebhooks(ctx, channelID)
+	if err != nil {
+		return nil, err
+	}
+
+	webhookDtos := make([]dtos.WebhookDto, 0, len(webhooks))
+	for _, webhook := range webhooks {
+		webhookDtos = append(webhookDtos, toWebhookDto(webhook))
+	}
+	return webhookDtos, nil
+}
+
+// DeleteWebhook removes a webhook. Messages it posted stay in the channel.
+func (s *Service) DeleteWebhook(ctx context.Context, userID, webhookID int32) error {
+	webhook, err := s.repo.GetWebhook(ctx, webhookID)
+	if err != nil {
+		return ErrWebhookNotFound
+	}
+
+	isCreator := webhook.CreatedBy.Valid && webhook.CreatedBy.Int32 == userID
+	isServerOwner := webhook.ServerOwnerID.Valid && webhook.ServerOwnerID.Int32 == userID
+	if !isCreator && !isServerOwner {
+		return ErrNotWebhookManager
+	}
+
+	return s.repo.DeleteWebhook(ctx, webhookID)
+}
+
+// ExecuteWebhook posts a message into the webhook's channel. The token is the
+// only credential.
+func (s *Service) ExecuteWebhook(ctx context.Context, webhookID int32, token string, req ExecuteWebhookRequest) (MessageResponse, error) {
+	webhook, err := s.repo.GetWebhook(ctx, webhookID)
+	if err != nil {
+		return MessageResponse{}, ErrInvalidWebhookAuth
+	}
+	if subtle.ConstantTimeCompare([]byte(hashWebhookToken(token)), []byte(webhook.TokenHash)) != 1 {
+		return MessageResponse{}, ErrInvalidWebhookAuth
+	}
+
+	if err := validateMessage(req); err != nil {
+		return MessageResponse{}, err
+	}
+
+	username := strings.TrimSpace(req.Username)
+	if username == "" {
+		username = webhook.Name
+	}
+	avatarURL := req.AvatarURL
+	if avatarURL == "" {
+		avatarURL = webhook.AvatarUrl.String
+	}
+
+	return s.messages.PostWebhookMessage(ctx, webhook.ChannelID, webhook.ID, req.Content, username, avatarURL, req.Embeds)
+}
+
+func (s *Service) checkChannelMember(ctx context.Context, userID, channelID int32) error {
+	channel, err := s.repo.GetChannel(ctx, channelID)
+	if err != nil {
+		return ErrChannelNotFound
+	}
+	isMember, err := s.serverRepo.IsServerMember(ctx, channel.ServerID, userID)
+	if err != nil {
+		return err
+	}
+	if !isMember {
+		return ErrNotChannelMember
+	}
+	return nil
+}
+
+func validateMessage(req ExecuteWebhookRequest) error {
+	if strings.TrimSpace(req.Content) == "" && len(req.Embeds) == 0 {
+		return ErrEmptyMessage
+	}
+	if utf8.RuneCountInString(req.Content) > maxContentLength {
+		return ErrContentTooLong
+	}
+	if utf8.RuneCountInString(strings.TrimSpace(req.Username)) > maxWebhookNameLength {
+		return ErrInvalidWebhookName
+	}
+	if req.AvatarURL != "" && !isHTTPURL(req.AvatarURL) {
+		return ErrInvalidAvatarURL
+	}
+	if len(req.Embeds) > maxEmbeds {
+		return ErrInvalidEmbeds
+	}
+	for _, embed := range req.Embeds {
+		if utf8.RuneCountInString(embed.Title) > maxEmbedTitleLength ||
+			utf8.RuneCountInString(embed.Description) > maxEmbedDescriptionLen ||
+			len(embed.Fields) > maxEmbedFields {
+			return ErrInvalidEmbeds
+		}
+		// Embed links are rendered as anchors, so only web URLs are allowed.
+		if embed.URL != "" && !isHTTPURL(embed.URL) {
+			return ErrInvalidEmbeds
+		}
+		for _, field := range embed.Fields {
+			if field.Name == "" || utf8.RuneCountInString(field.Value) > maxEmbedFieldValueLength {
+				return ErrInvalidEmbeds
+			}
+		}
+	}
+	return nil
+}
+
+func isHTTPURL(value string) bool {
+	parsed, err := url.Parse(value)
+	return err == nil && (parsed.Scheme == "http" || parsed.Scheme == "https") && parsed.Host != ""
+}
+
+func generateWebhookToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+func hashWebhookToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+func toWebhookDto(webhook db.ListWebhooksByChannelRow) dtos.WebhookDto {
+	return dtos.WebhookDto{
+		ID:        webhook.ID,
+		ChannelID: webhook.ChannelID,
+		CreatedBy: webhook.CreatedBy.Int32,
+		Name:      webhook.Name,
+		AvatarURL: webhook.AvatarUrl.String,
+		URL:       fmt.Sprintf("/webhooks/%d", webhook.ID),
+		CreatedAt: webhook.CreatedAt.Time.Format("2006-01-02T15:04:05Z07:00"),
+	}
+}
@@ -5,34 +5,73 @@ import (
 	"encoding/json"
 	"fmt"
 	"log"
+	"slices"
 	"strconv"
+	"strings"
 	"sync"
 
+	"github.com/andrelcunha/Concord/backend/internal/annotations"
 	. "github.com/andrelcunha/Concord/backend/internal/common"
+	"github.com/andrelcunha/Concord/backend/internal/ratelimit"
+	"github.com/andrelcunha/Concord/backend/pkg/dtos"
 	"github.com/redis/go-redis/v9"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/websocket/v2"
 )
 
+// CommandHandler runs slash commands typed in a channel. It reports false
+// for messages that should be posted as plain text.
+type CommandHandler interface {
+	HandleCommand(ctx context.Context, invoker dtos.UserDto, channelID int32, content string) (bool, error)
+}
+
 type Handler struct {
-	service   *Service
-	Clients   map[string]map[*websocket.Conn]bool
-	ClientsMu sync.RWMutex
-	PubSubs   map[string]*redis.PubSub
-	PubSubsMu sync.RWMutex
+	service     *Service
+	commands    CommandHandler
+	limiter     *ratelimit.Limiter
+	messageRule ratelimit.Rule
+	Clients     map[string]map[*websocket.Conn]*channelClient
+	ClientsMu   sync.RWMutex
+	PubSubs     map[string]*redis.PubSub
+	PubSubsMu   sync.RWMutex
+}
+
+// channelClient is a connected user, the users they have blocked and their
+// private nicknames for others. Blocks are loaded when the socket opens and
+// extended by block events; nicknames only change on reconnect.
+type channelClient struct {
+	userID         int32
+	blockedUserIDs []int32
+	nicknames      annotations.Set
+	// writeMu serializes writes: the read loop and the pubsub fan-out both
+	// write to the connection, and it allows one writer at a time.
+	writeMu sync.Mutex
+}
+
+func (c *channelClient) write(conn *websocket.Conn, payload []byte) {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	if err := conn.WriteMessage(websocket.TextMessage, payload); err != nil {
+		log.Printf("Error writing message: %v", err)
+	}
 }
 
 type WSMessage struct {
 	Content string `json:"content"`
 }
 
-func NewHandler(service *Service) *Handler {
-	return &Handler{
-		service: service,
-		Clients: make(map[string]map[*websocket.Conn]bool),
-		PubSubs: make(map[string]*redis.PubSub),
+func NewHandler(service *Service, commands CommandHandler, limiter *ratelimit.Limiter, messageRule ratelimit.Rule) *Handler {
+	h := &Handler{
+		service:     service,
+		commands:    commands,
+		limiter:     limiter,
+		messageRule: messageRule,
+		Clients:     make(map[string]map[*websocket.Conn]*channelClient),
+		PubSubs:     make(map[string]*redis.PubSub),
 	}
+	go h.handleBlockEvents(service.redis.Subscribe(context.Background(), blocksChannel))
+	return h
 }
 
 func (h *Handler) HandleConnection(c *fiber.Ctx) error {
@@ -71,11 +110,36 @@ func (h *Handler) HandleConnection(c *fiber.Ctx) error {
 	if !ok {
 		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "invalid avatar_color"})
 	}
+	isBot, _ := c.Locals("isBot").(bool)
+	displayName, _ := c.Locals("display_name").(string)
+
+	canAccess, err := h.service.CanAccessChannel(c.Context(), int32(channelID), userID)
+	if err != nil || !canAccess {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Not a server member"})
+	}
+	blockedUserIDs, err := h.service.ListBlockedUserIDs(c.Context(), userID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to load blocked users"})
+	}
+	nicknames, err := h.service.ListAnnotations(c.Context(), userID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to load nicknames"})
+	}
+	client := &channelClient{userID: userID, blockedUserIDs: blockedUserIDs, nicknames: nicknames}
+
+	author := dtos.UserDto{
+		UserId:      userID,
+		Username:    username,
+		DisplayName: displayName,
+		AvatarUrl:   avatar_url,
+		AvatarColor: avatar_color,
+		IsBot:       isBot,
+	}
 
 	return websocket.New(func(conn *websocket.Conn) {
 		channelIDStr := fmt.Sprintf("%d", channelID)
 
-		h.addClient(channelIDStr, conn)
+		h.addClient(channelIDStr, conn, client)
 
 		h.setupPubSub(channelIDStr)
 
@@ -107,56 +171,155 @@ func (h *Handler) HandleConnection(c *fiber.Ctx) error {
 				continue
 			}
 
-			dbMessage, err := h.service.StoreMessage(context.Background(), int32(channelID), userID, string(wsMsg.Content), username)
-			if err != nil {
-				log.Printf("Error storing message: %v", err)
+			if !h.allowMessage(conn, client) {
 				continue
 			}
 
-			messageResponse := MessageResponse{
-				ID:          dbMessage.ID,
-				ChannelID:   dbMessage.ChannelID,
-				UserID:      dbMessage.UserID,
-				Content:     dbMessage.Content,
-				Username:    username,
-				CreatedAt:   dbMessage.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
-				AvatarURL:   avatar_url,
-				AvatarColor: avatar_color,
-			}
-			messageJSON, err := json.Marshal(messageResponse)
-			if err != nil {
-				log.Printf("Error marshaling message: %v", err)
-				continue
+			// Bots answer commands through interactions, never by typing them
+			if !isBot {
+				handled, err := h.commands.HandleCommand(context.Background(), author, int32(channelID), wsMsg.Content)
+				if err != nil {
+					writeCommandError(conn, client, err)
+					continue
+				}
+				if handled {
+					continue
+				}
 			}
 
-			if err := h.service.BroadcastMessage(context.Background(), int32(channelID), userID, messageJSON); err != nil {
-				log.Printf("Error broadcasting message: %v", err)
+			if _, err := h.service.PostMessage(context.Background(), int32(channelID), author, wsMsg.Content); err != nil {
+				log.Printf("Error posting message: %v", err)
 			}
 		}
 	})(c)
 }
 
-func RegisterWebSocketRoutes(api fiber.Router, service *Service) {
-	handler := NewHandler(service)
+func RegisterWebSocketRoutes(api fiber.Router, service *Service, commands CommandHandler, limiter *ratelimit.Limiter, messageRule ratelimit.Rule) {
+	handler := NewHandler(service, commands, limiter, messageRule)
 	api.Get("/ws", handler.HandleConnection)
 }
 
+// allowMessage applies the per-user send limit. Throttled senders get an
+// error frame on their own connection; nothing is stored or broadcast.
+func (h *Handler) allowMessage(conn *websocket.Conn, client *channelClient) bool {
+	result, err := h.limiter.Allow(context.Background(), h.messageRule, fmt.Sprintf("user:%d", client.userID))
+	if err != nil {
+		log.Printf("Rate limit error: %v", err)
+		return true
+	}
+	if result.Allowed {
+		return true
+	}
+
+	payload, _ := json.Marshal(fiber.Map{
+		"error":       "rate_limited",
+		"retry_after": int(result.RetryAfter.Seconds()) + 1,
+	})
+	client.write(conn, payload)
+	return false
+}
+
+// writeCommandError tells the invoker why their command was not run.
+func writeCommandError(conn *websocket.Conn, client *channelClient, err error) {
+	payload, _ := json.Marshal(fiber.Map{
+		"error":   "command_error",
+		"message": err.Error(),
+	})
+	client.write(conn, payload)
+}
+
+// handlePubSubMessages fans channel messages out to every connection, and
+// ephemeral messages only to the connections of the user they are for.
+// Users who blocked the author get the message marked author_blocked.
 func (h *Handler) handlePubSubMessages(pubsub *redis.PubSub, channelIDStr string) {
 	for msg := range pubsub.Channel() {
+		if strings.HasSuffix(msg.Channel, ":ephemeral") {
+			h.writeEphemeral(channelIDStr, msg.Payload)
+			continue
+		}
+
+		var message MessageResponse
+		if err := json.Unmarshal([]byte(msg.Payload), &message); err != nil {
+			log.Printf("Unmarshal error: %v", err)
+			continue
+		}
+		var blockedPayload []byte
+
 		h.ClientsMu.RLock()
-		for client := range h.Clients[channelIDStr] {
-			if err := client.WriteMessage(websocket.TextMessage, []byte(msg.Payload)); err != nil {
-				log.Printf("Error writing message: %v", err)
+		for conn, client := range h.Clients[channelIDStr] {
+			payload := []byte(msg.Payload)
+			if message.WebhookID == 0 {
+				blocked := slices.Contains(client.blockedUserIDs, int32(message.UserID))
+				nickname := client.nicknames.Nickname(int32(message.UserID))
+				switch {
+				case nickname != "":
+					personal := message
+					personal.AuthorBlocked = blocked
+					personal.AuthorNickname = nickname
+					payload, _ = json.Marshal(personal)
+				case blocked:
+					if blockedPayload == nil {
+						marked := message
+						marked.AuthorBlocked = true
+						blockedPayload, _ = json.Marshal(marked)
+					}
+					payload = blockedPayload
+				}
 			}
+			client.write(conn, payload)
 		}
 		h.ClientsMu.RUnlock()
 	}
 }
 
+// handleBlockEvents adds new blocks to the blocker's open sockets. Unblocks
+// take effect when the socket reconnects.
+func (h *Handler) handleBlockEvents(pubsub *redis.PubSub) {
+	for msg := range pubsub.Channel() {
+		var event blockEvent
+		if err := json.Unmarshal([]byte(msg.Payload), &event); err != nil {
+			log.Printf("Unmarshal error: %v", err)
+			continue
+		}
+
+		h.ClientsMu.Lock()
+		for _, clients := range h.Clients {
+			for _, client := range clients {
+				if client.userID == event.BlockerID && !slices.Contains(client.blockedUserIDs, event.BlockedID) {
+					client.blockedUserIDs = append(client.blockedUserIDs, event.BlockedID)
+				}
+			}
+		}
+		h.ClientsMu.Unlock()
+	}
+}
+
+func (h *Handler) writeEphemeral(channelIDStr, payload string) {
+	var ephemeral ephemeralMessage
+	if err := json.Unmarshal([]byte(payload), &ephemeral); err != nil {
+		log.Printf("Unmarshal error: %v", err)
+		return
+	}
+	messageJSON, err := json.Marshal(ephemeral.Message)
+	if err != nil {
+		log.Printf("Error marshaling message: %v", err)
+		return
+	}
+
+	h.ClientsMu.RLock()
+	for conn, client := range h.Clients[channelIDStr] {
+		if client.userID != ephemeral.UserID {
+			continue
+		}
+		client.write(conn, messageJSON)
+	}
+	h.ClientsMu.RUnlock()
+}
+
 func (h *Handler) setupPubSub(channelIDStr string) {
 	h.PubSubsMu.Lock()
 	if h.PubSubs[channelIDStr] == nil {
-		h.PubSubs[channelIDStr] = h.service.redis.Subscribe(context.Background(), "channel:"+channelIDStr)
+		h.PubSubs[channelIDStr] = h.service.redis.Subscribe(context.Background(), "channel:"+channelIDStr, "channel:"+channelIDStr+":ephemeral")
 		go h.handlePubSubMessages(h.PubSubs[channelIDStr], channelIDStr)
 	}
 	h.PubSubsMu.Unlock()
@@ -171,11 +334,11 @@ func (h *Handler) closePubSub(channelIDStr string) {
 	h.PubSubsMu.Unlock()
 }
 
-func (h *Handler) addClient(channelIDStr string, conn *websocket.Conn) {
+func (h *Handler) addClient(channelIDStr string, conn *websocket.Conn, client *channelClient) {
 	h.ClientsMu.Lock()
 	if h.Clients[channelIDStr] == nil {
-		h.Clients[channelIDStr] = make(map[*websocket.Conn]bool)
+		h.Clients[channelIDStr] = make(map[*websocket.Conn]*channelClient)
 	}
-	h.Clients[channelIDStr][conn] = true
+	h.Clients[channelIDStr][conn] = client
 	h.ClientsMu.Unlock()
 }
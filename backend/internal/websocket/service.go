@@ -2,26 +2,129 @@ package websocket
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 
+	"github.com/andrelcunha/Concord/backend/internal/annotations"
+	"github.com/andrelcunha/Concord/backend/internal/blocks"
+	. "github.com/andrelcunha/Concord/backend/internal/common"
+	"github.com/andrelcunha/Concord/backend/internal/events"
 	"github.com/andrelcunha/Concord/backend/internal/messages"
 	"github.com/andrelcunha/Concord/backend/pkg/dtos"
+	"github.com/jackc/pgx/v5"
 	"github.com/redis/go-redis/v9"
 )
 
+type ephemeralMessage struct {
+	UserID  int32           `json:"user_id"`
+	Message MessageResponse `json:"message"`
+}
+
+// blocksChannel carries new blocks to every instance, so open channel sockets
+// start collapsing the blocked user's messages without reconnecting.
+const blocksChannel = "user_blocks"
+
+type blockEvent struct {
+	BlockerID int32 `json:"blocker_id"`
+	BlockedID int32 `json:"blocked_id"`
+}
+
 type Service struct {
-	repo  messages.Repository
-	redis *redis.Client
+	repo        messages.Repository
+	blockRepo   blocks.Repository
+	annotations *annotations.Service
+	redis       *redis.Client
+	events      events.Publisher
 }
 
-func NewService(repo messages.Repository, redis *redis.Client) *Service {
+func NewService(repo messages.Repository, blockRepo blocks.Repository, annotationsService *annotations.Service, redis *redis.Client, events events.Publisher) *Service {
 	return &Service{
-		repo:  repo,
-		redis: redis,
+		repo:        repo,
+		blockRepo:   blockRepo,
+		annotations: annotationsService,
+		redis:       redis,
+		events:      events,
+	}
+}
+
+// UserBlocked implements blocks.Listener.
+func (s *Service) UserBlocked(ctx context.Context, blockerID, blockedID int32) {
+	payload, err := json.Marshal(blockEvent{BlockerID: blockerID, BlockedID: blockedID})
+	if err != nil {
+		log.Printf("Error marshaling block event: %v", err)
+		return
+	}
+	if err := s.redis.Publish(ctx, blocksChannel, payload).Err(); err != nil {
+		log.Printf("Publish error: %v", err)
 	}
 }
 
+// ListBlockedUserIDs returns the users whose messages userID's sockets mark
+// as blocked.
+func (s *Service) ListBlockedUserIDs(ctx context.Context, userID int32) ([]int32, error) {
+	return s.blockRepo.ListBlockedUserIDs(ctx, userID)
+}
+
+// ListAnnotations returns the nicknames userID's sockets show for authors.
+func (s *Service) ListAnnotations(ctx context.Context, userID int32) (annotations.Set, error) {
+	return s.annotations.ForOwner(ctx, userID)
+}
+
+// PostMessage stores a message from author, broadcasts it to the channel and
+// queues message.created. The author appears with their server nickname and
+// avatar when they set them.
+func (s *Service) PostMessage(ctx context.Context, channelID int32, author dtos.UserDto, content string) (MessageResponse, error) {
+	message, err := s.StoreMessage(ctx, channelID, author.UserId, content, author.Username)
+	if err != nil {
+		return MessageResponse{}, err
+	}
+
+	avatarURL := author.AvatarUrl
+	serverNickname, serverAvatarURL, err := s.repo.GetMemberProfile(ctx, channelID, author.UserId)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		log.Printf("GetMemberProfile error: %v", err)
+	}
+	if serverAvatarURL != "" {
+		avatarURL = serverAvatarURL
+	}
+
+	messageResponse := MessageResponse{
+		ID:             message.ID,
+		ChannelID:      message.ChannelID,
+		UserID:         message.UserID,
+		Content:        message.Content,
+		Username:       author.Username,
+		DisplayName:    author.DisplayName,
+		CreatedAt:      message.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
+		AvatarURL:      avatarURL,
+		AvatarColor:    author.AvatarColor,
+		IsBot:          author.IsBot,
+		ServerNickname: serverNickname,
+	}
+	messageJSON, err := json.Marshal(messageResponse)
+	if err != nil {
+		return MessageResponse{}, err
+	}
+	if err := s.BroadcastMessage(ctx, channelID, author.UserId, messageJSON); err != nil {
+		return MessageResponse{}, err
+	}
+	s.PublishMessageCreated(ctx, messageResponse)
+	return messageResponse, nil
+}
+
+// SendEphemeral shows a message to one user's connections in the channel.
+// It is not stored.
+func (s *Service) SendEphemeral(ctx context.Context, channelID, userID int32, message MessageResponse) error {
+	message.Ephemeral = true
+	payload, err := json.Marshal(ephemeralMessage{UserID: userID, Message: message})
+	if err != nil {
+		return err
+	}
+	return s.redis.Publish(ctx, fmt.Sprintf("channel:%d:ephemeral", channelID), payload).Err()
+}
+
 func (s *Service) StoreMessage(ctx context.Context, channelID, userID int32, content, username string) (dtos.MessageDto, error) {
 	message, err := s.repo.CreateMessage(ctx, channelID, userID, content, username)
 	if err != nil {
@@ -32,6 +135,47 @@ func (s *Service) StoreMessage(ctx context.Context, channelID, userID int32, con
 	return message, nil
 }
 
+// PostWebhookMessage stores a webhook message and broadcasts it to the
+// channel's subscribers like a message sent over the socket.
+func (s *Service) PostWebhookMessage(ctx context.Context, channelID, webhookID int32, content, username, avatarURL string, embeds []dtos.Embed) (MessageResponse, error) {
+	message, err := s.repo.CreateWebhookMessage(ctx, channelID, webhookID, content, username, avatarURL, embeds)
+	if err != nil {
+		log.Printf("CreateWebhookMessage error: %v", err)
+		return MessageResponse{}, err
+	}
+
+	messageResponse := MessageResponse{
+		ID:        message.ID,
+		ChannelID: message.ChannelID,
+		Content:   message.Content,
+		Username:  message.Username,
+		CreatedAt: message.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
+		AvatarURL: message.AvatarUrl,
+		IsBot:     message.IsBot,
+		WebhookID: message.WebhookID,
+		Embeds:    message.Embeds,
+	}
+	messageJSON, err := json.Marshal(messageResponse)
+	if err != nil {
+		return MessageResponse{}, err
+	}
+	if err := s.BroadcastMessage(ctx, channelID, 0, messageJSON); err != nil {
+		return MessageResponse{}, err
+	}
+	s.PublishMessageCreated(ctx, messageResponse)
+	return messageResponse, nil
+}
+
+// PublishMessageCreated queues message.created for the server's event
+// subscriptions.
+func (s *Service) PublishMessageCreated(ctx context.Context, message MessageResponse) {
+	s.events.Publish(ctx, events.Event{
+		Type:      events.EventMessageCreated,
+		ChannelID: int32(message.ChannelID),
+		Data:      message,
+	})
+}
+
 func (s *Service) ListMessagesByChannel(ctx context.Context, channelID, limit, offset int32) ([]dtos.MessageDto, error) {
 	messages, err := s.repo.ListMessagesByChannel(ctx, channelID, limit, offset)
 	if err != nil {
@@ -41,6 +185,10 @@ func (s *Service) ListMessagesByChannel(ctx context.Context, channelID, limit, o
 	return messages, nil
 }
 
+func (s *Service) CanAccessChannel(ctx context.Context, channelID, userID int32) (bool, error) {
+	return s.repo.CanAccessChannel(ctx, channelID, userID)
+}
+
 func (s *Service) BroadcastMessage(ctx context.Context, channelID, userID int32, messageJSON []byte) error {
 	channelIDStr := fmt.Sprintf("%d", channelID)
 	err := s.redis.Publish(ctx, "channel:"+channelIDStr, messageJSON).Err()
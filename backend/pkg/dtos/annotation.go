@@ -0,0 +1,10 @@
+package dtos
+
+// UserAnnotationDto is a private nickname and note the owner keeps about
+// another user.
+type UserAnnotationDto struct {
+	TargetID  int32  `json:"target_id"`
+	Nickname  string `json:"nickname"`
+	Note      string `json:"note"`
+	UpdatedAt string `json:"updated_at,omitempty"`
+}
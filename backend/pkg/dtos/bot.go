@@ -0,0 +1,12 @@
+package dtos
+
+type BotDto struct {
+	ID          int32  `json:"id"`
+	Username    string `json:"username"`
+	AvatarURL   string `json:"avatar_url"`
+	AvatarColor string `json:"avatar_color"`
+	OwnerID     int32  `json:"owner_id"`
+	IsBot       bool   `json:"is_bot"`
+	Token       string `json:"token,omitempty"` // Only returned when a token is issued
+	CreatedAt   string `json:"created_at"`
+}
@@ -0,0 +1,20 @@
+package dtos
+
+import "encoding/json"
+
+type CommandDto struct {
+	ID          int32           `json:"id"`
+	BotID       int32           `json:"bot_id"`
+	BotUsername string          `json:"bot_username,omitempty"`
+	ServerID    int32           `json:"server_id,omitempty"` // Omitted for global commands
+	Name        string          `json:"name"`
+	Description string          `json:"description"`
+	Options     json.RawMessage `json:"options"`
+	UpdatedAt   string          `json:"updated_at"`
+}
+
+type InteractionEndpointDto struct {
+	BotID  int32  `json:"bot_id"`
+	URL    string `json:"url"`
+	Secret string `json:"secret"` // Only returned when the endpoint is set
+}
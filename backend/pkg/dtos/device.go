@@ -0,0 +1,31 @@
+package dtos
+
+type OneTimePrekeyDto struct {
+	KeyID     int32  `json:"key_id"`
+	PublicKey string `json:"public_key"`
+}
+
+// DeviceDto is a registered device and its public keys. Keys are
+// base64-encoded and opaque to the server.
+type DeviceDto struct {
+	ID                    int32  `json:"id"`
+	Name                  string `json:"name"`
+	IdentityKey           string `json:"identity_key"`
+	SignedPrekeyID        int32  `json:"signed_prekey_id"`
+	SignedPrekey          string `json:"signed_prekey"`
+	SignedPrekeySignature string `json:"signed_prekey_signature"`
+	OneTimePrekeyCount    int64  `json:"one_time_prekey_count"`
+	CreatedAt             string `json:"created_at"`
+}
+
+// DeviceKeyBundleDto is what another user needs to start an encrypted
+// session with a device. OneTimePrekey is omitted once the device runs out.
+type DeviceKeyBundleDto struct {
+	DeviceID              int32             `json:"device_id"`
+	UserID                int32             `json:"user_id"`
+	IdentityKey           string            `json:"identity_key"`
+	SignedPrekeyID        int32             `json:"signed_prekey_id"`
+	SignedPrekey          string            `json:"signed_prekey"`
+	SignedPrekeySignature string            `json:"signed_prekey_signature"`
+	OneTimePrekey         *OneTimePrekeyDto `json:"one_time_prekey,omitempty"`
+}
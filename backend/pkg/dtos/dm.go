@@ -2,21 +2,51 @@ package dtos
 
 import "time"
 
+// DmConversationDto describes a one-to-one or group conversation. OtherUser
+// is only set for one-to-one conversations; Participants lists everyone.
 type DmConversationDto struct {
-	ID             int32          `json:"id"`
-	CreatedAt      string         `json:"created_at"`
-	OtherUser      UserSummaryDto `json:"other_user"`
-	LastMessage    string         `json:"last_message"`
-	LastMessageAt  string         `json:"last_message_at,omitempty"`
+	ID            int32            `json:"id"`
+	CreatedAt     string           `json:"created_at"`
+	IsGroup       bool             `json:"is_group"`
+	IsEncrypted   bool             `json:"is_encrypted"`
+	Name          string           `json:"name,omitempty"`
+	IconURL       string           `json:"icon_url,omitempty"`
+	OwnerID       int32            `json:"owner_id,omitempty"`
+	OtherUser     *UserSummaryDto  `json:"other_user,omitempty"`
+	Participants  []UserSummaryDto `json:"participants"`
+	RequesterID   int32            `json:"requester_id,omitempty"`
+	RequestStatus string           `json:"request_status,omitempty"` // "pending", "accepted" or "ignored" for message requests
+	ReadStates    []DmReadStateDto `json:"read_states,omitempty"`
+	LastMessage   string           `json:"last_message"`
+	LastMessageAt string           `json:"last_message_at,omitempty"`
+}
+
+// DmReadStateDto is how far a participant has read. Participants who turned
+// off read receipts are only reported to themselves.
+type DmReadStateDto struct {
+	UserID            int32  `json:"user_id"`
+	LastReadMessageID int32  `json:"last_read_message_id"`
+	LastReadAt        string `json:"last_read_at"`
 }
 
 type DmMessageDto struct {
-	ID             int32     `json:"id"`
-	ConversationID int32     `json:"conversation_id"`
-	UserID         int32     `json:"user_id"`
-	Username       string    `json:"username"`
-	Content        string    `json:"content"`
-	CreatedAt      time.Time `json:"created_at"`
-	AvatarURL      string    `json:"avatar_url,omitempty"`
-	AvatarColor    string    `json:"avatar_color"`
+	ID             int32           `json:"id"`
+	ConversationID int32           `json:"conversation_id"`
+	UserID         int32           `json:"user_id"`
+	Username       string          `json:"username"`
+	AuthorNickname string          `json:"author_nickname,omitempty"` // The viewer's private nickname for the author
+	Content        string          `json:"content"`
+	CreatedAt      time.Time       `json:"created_at"`
+	AvatarURL      string          `json:"avatar_url,omitempty"`
+	AvatarColor    string          `json:"avatar_color"`
+	Type           string          `json:"type"`                     // "default" or a group system message type
+	TargetUserID   int32           `json:"target_user_id,omitempty"` // Member a system message is about
+	SenderDeviceID int32           `json:"sender_device_id,omitempty"`
+	Envelopes      []DmEnvelopeDto `json:"envelopes,omitempty"` // Encrypted conversations only; Content is empty
+}
+
+// DmEnvelopeDto is a message encrypted for one recipient device.
+type DmEnvelopeDto struct {
+	DeviceID   int32  `json:"device_id"`
+	Ciphertext string `json:"ciphertext"`
 }
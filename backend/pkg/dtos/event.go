@@ -0,0 +1,26 @@
+package dtos
+
+import "encoding/json"
+
+type EventSubscriptionDto struct {
+	ID         int32    `json:"id"`
+	ServerID   int32    `json:"server_id,omitempty"`
+	URL        string   `json:"url"`
+	EventTypes []string `json:"event_types"`
+	Secret     string   `json:"secret,omitempty"` // Only returned when the subscription is created
+	CreatedAt  string   `json:"created_at"`
+}
+
+type EventDeliveryDto struct {
+	ID             int32           `json:"id"`
+	EventID        string          `json:"event_id"`
+	EventType      string          `json:"event_type"`
+	Status         string          `json:"status"`
+	Attempts       int32           `json:"attempts"`
+	NextAttemptAt  string          `json:"next_attempt_at,omitempty"`
+	LastStatusCode int32           `json:"last_status_code,omitempty"`
+	LastError      string          `json:"last_error,omitempty"`
+	Payload        json.RawMessage `json:"payload"`
+	CreatedAt      string          `json:"created_at"`
+	DeliveredAt    string          `json:"delivered_at,omitempty"`
+}
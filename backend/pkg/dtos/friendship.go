@@ -1,10 +1,18 @@
 package dtos
 
+// UserSummaryDto describes another user. Username is the unique handle and
+// DisplayName, when set, is what the user chose to be called. Nickname and
+// Note are the viewer's own private annotations and are only set in
+// responses to that viewer.
 type UserSummaryDto struct {
-	UserID      int32  `json:"user_id"`
-	Username    string `json:"username"`
-	AvatarURL   string `json:"avatar_url"`
-	AvatarColor string `json:"avatar_color"`
+	UserID      int32          `json:"user_id"`
+	Username    string         `json:"username"`
+	DisplayName string         `json:"display_name,omitempty"`
+	AvatarURL   string         `json:"avatar_url"`
+	AvatarColor string         `json:"avatar_color"`
+	Nickname    string         `json:"nickname,omitempty"`
+	Note        string         `json:"note,omitempty"`
+	Status      *UserStatusDto `json:"status,omitempty"`
 }
 
 type FriendshipDto struct {
@@ -28,3 +36,9 @@ type BlockDto struct {
 	CreatedAt string         `json:"created_at"`
 	User      UserSummaryDto `json:"user"`
 }
+
+type FriendSuggestionDto struct {
+	UserSummaryDto
+	MutualFriends int32 `json:"mutual_friends"`
+	MutualServers int32 `json:"mutual_servers"`
+}
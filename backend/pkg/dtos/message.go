@@ -11,4 +11,15 @@ type MessageDto struct {
 	CreatedAt   time.Time `json:"createdAt"`
 	AvatarUrl   string    `json:"avatarUrl,omitempty"`
 	AvatarColor string    `json:"avatarColor"`
+	IsBot       bool      `json:"isBot"`
+	WebhookID   int       `json:"webhookId,omitempty"`
+	Embeds      []Embed   `json:"embeds,omitempty"`
+	// AuthorBlocked is set per viewer when they have blocked the author.
+	AuthorBlocked bool `json:"authorBlocked,omitempty"`
+	// AuthorNickname is the viewer's private nickname for the author, if any.
+	AuthorNickname string `json:"authorNickname,omitempty"`
+	// ServerNickname is the author's nickname in the channel's server.
+	ServerNickname string `json:"serverNickname,omitempty"`
+	// DisplayName is the author's own display name, if they set one.
+	DisplayName string `json:"displayName,omitempty"`
 }
@@ -0,0 +1,11 @@
+package dtos
+
+// UserStatusDto is a user's custom status and what they are doing right
+// now. Either part is left out once it expires.
+type UserStatusDto struct {
+	Text              string `json:"text,omitempty"`
+	Emoji             string `json:"emoji,omitempty"`
+	ExpiresAt         string `json:"expires_at,omitempty"`
+	Activity          string `json:"activity,omitempty"`
+	ActivityExpiresAt string `json:"activity_expires_at,omitempty"`
+}
@@ -0,0 +1,9 @@
+package dtos
+
+type PrivacySettingsDto struct {
+	DmPolicy            string `json:"dm_policy"`             // "friends", "server_members" or "everyone"
+	FriendRequestPolicy string `json:"friend_request_policy"` // "everyone", "friends_of_friends", "server_members" or "nobody"
+	Discoverable        bool   `json:"discoverable"`          // Whether the user appears in friend search
+	SendReadReceipts    bool   `json:"send_read_receipts"`
+	UpdatedAt           string `json:"updated_at,omitempty"`
+}
@@ -0,0 +1,13 @@
+package dtos
+
+type MutualServerDto struct {
+	ID   int32  `json:"id"`
+	Name string `json:"name"`
+}
+
+type UserProfileDto struct {
+	User          UserSummaryDto    `json:"user"`
+	Relationship  string            `json:"relationship"`
+	MutualFriends []UserSummaryDto  `json:"mutual_friends"`
+	MutualServers []MutualServerDto `json:"mutual_servers"`
+}
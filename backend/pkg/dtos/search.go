@@ -0,0 +1,24 @@
+package dtos
+
+type SearchResultDto struct {
+	Type           string `json:"type"` // "channel" or "dm"
+	ID             int32  `json:"id"`
+	ServerID       int32  `json:"server_id,omitempty"`
+	ChannelID      int32  `json:"channel_id,omitempty"`
+	ConversationID int32  `json:"conversation_id,omitempty"`
+	UserID         int32  `json:"user_id"`
+	Username       string `json:"username"`
+	AvatarURL      string `json:"avatar_url"`
+	AvatarColor    string `json:"avatar_color"`
+	IsBot          bool   `json:"is_bot"`
+	Content        string `json:"content"`
+	Snippet        string `json:"snippet"` // HTML-escaped, matches wrapped in <mark>
+	CreatedAt      string `json:"created_at"`
+}
+
+type SearchResultsDto struct {
+	Results []SearchResultDto `json:"results"`
+	Limit   int32             `json:"limit"`
+	Offset  int32             `json:"offset"`
+	HasMore bool              `json:"has_more"`
+}
@@ -20,3 +20,20 @@ func FromServerDbToServerDto(server db.Server) ServerDto {
 		CreatedAt: server.CreatedAt.Time.Format("2006-01-02T15:04:05Z07:00"),
 	}
 }
+
+// DiscoverableServerDto adds the popularity signals used to rank discovery.
+type DiscoverableServerDto struct {
+	ServerDto
+	MemberCount        int64  `json:"memberCount"`
+	RecentMessageCount int64  `json:"recentMessageCount"`
+	LastMessageAt      string `json:"lastMessageAt,omitempty"`
+}
+
+// ServerMemberProfileDto is how a member appears inside one server. Empty
+// fields fall back to the user's own username and avatar.
+type ServerMemberProfileDto struct {
+	ServerID  int32  `json:"serverId"`
+	UserID    int32  `json:"userId"`
+	Nickname  string `json:"nickname"`
+	AvatarURL string `json:"avatarUrl"`
+}
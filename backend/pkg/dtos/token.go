@@ -0,0 +1,11 @@
+package dtos
+
+type PersonalAccessTokenDto struct {
+	ID         int32    `json:"id"`
+	Name       string   `json:"name"`
+	Scopes     []string `json:"scopes"`
+	Token      string   `json:"token,omitempty"` // Only returned when the token is created
+	ExpiresAt  string   `json:"expires_at,omitempty"`
+	LastUsedAt string   `json:"last_used_at,omitempty"`
+	CreatedAt  string   `json:"created_at"`
+}
@@ -3,7 +3,18 @@ package dtos
 type UserDto struct {
 	UserId      int32  `json:"user_id"`
 	Username    string `json:"username"`
+	DisplayName string `json:"display_name,omitempty"`
 	Password    string `json:"-"` // Omit password from JSON
 	AvatarUrl   string `json:"avatar_url"`
 	AvatarColor string `json:"avatar_color"`
+	TotpEnabled bool   `json:"-"`
+	IsBot       bool   `json:"is_bot"`
+}
+
+// UsernameHistoryDto is a handle the user gave up. Other users cannot take
+// it before ReservedUntil.
+type UsernameHistoryDto struct {
+	Username      string `json:"username"`
+	ChangedAt     string `json:"changed_at"`
+	ReservedUntil string `json:"reserved_until"`
 }
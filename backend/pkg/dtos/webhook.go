@@ -0,0 +1,28 @@
+package dtos
+
+type WebhookDto struct {
+	ID        int32  `json:"id"`
+	ChannelID int32  `json:"channel_id"`
+	CreatedBy int32  `json:"created_by,omitempty"`
+	Name      string `json:"name"`
+	AvatarURL string `json:"avatar_url,omitempty"`
+	URL       string `json:"url"`
+	Token     string `json:"token,omitempty"` // Only returned when the webhook is created
+	CreatedAt string `json:"created_at"`
+}
+
+// Embed is a rich attachment posted by a webhook.
+type Embed struct {
+	Title       string       `json:"title,omitempty"`
+	Description string       `json:"description,omitempty"`
+	URL         string       `json:"url,omitempty"`
+	Color       int          `json:"color,omitempty"`
+	Fields      []EmbedField `json:"fields,omitempty"`
+	Footer      string       `json:"footer,omitempty"`
+}
+
+type EmbedField struct {
+	Name   string `json:"name"`
+	Value  string `json:"value"`
+	Inline bool   `json:"inline,omitempty"`
+}
@@ -9,6 +9,7 @@ import {
 import { AppShell } from '@/components/layout/AppShell'
 import { RegisterPage } from '@/features/auth/RegisterPage'
 import { LoginPage } from '@/features/auth/LoginPage'
+import { SsoCallbackPage } from '@/features/auth/SsoCallbackPage'
 import { AppHomePage } from '@/features/home/AppHomePage'
 import { ChannelPage } from '@/features/chat/ChannelPage'
 import { DmIndexPage } from '@/features/dm/DmIndexPage'
@@ -41,6 +42,10 @@ const router = createBrowserRouter([
     path: '/login',
     element: <LoginPage />,
   },
+  {
+    path: '/login/sso/callback',
+    element: <SsoCallbackPage />,
+  },
   {
     path: '/register',
     element: <RegisterPage />,
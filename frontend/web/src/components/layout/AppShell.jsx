@@ -4,7 +4,9 @@ import { Outlet, useLocation, useParams } from 'react-router-dom'
 import { ChannelSidebar } from '@/components/layout/ChannelSidebar'
 import { useChannelsStore } from '@/features/channels/store'
 import { useChatStore } from '@/features/chat/store'
+import { getConversationTitle, getUserDisplayName } from '@/features/dm/conversation'
 import { useDmStore } from '@/features/dm/store'
+import { useSocialEvents } from '@/features/dm/useSocialEvents'
 import { ServerRail } from '@/components/layout/ServerRail'
 import { UserPanel } from '@/components/layout/UserPanel'
 import { useServersStore } from '@/features/servers/store'
@@ -39,6 +41,8 @@ export function AppShell() {
   const rejectFriendRequest = useDmStore((state) => state.rejectFriendRequest)
   const [isInboxOpen, setIsInboxOpen] = React.useState(false)
 
+  useSocialEvents()
+
   React.useEffect(() => {
     fetchServers()
     fetchDmConversations()
@@ -48,6 +52,8 @@ export function AppShell() {
   }, [fetchBlockedUsers, fetchDmConversations, fetchFriends, fetchIncomingRequests, fetchServers])
 
   React.useEffect(() => {
+    // Friends, requests and blocks arrive live through useSocialEvents; this
+    // only catches up after the tab was hidden and keeps polling DMs.
     function refreshDmState() {
       if (document.visibilityState !== 'visible') {
         return
@@ -59,7 +65,13 @@ export function AppShell() {
       fetchBlockedUsers({ silent: true })
     }
 
-    const intervalId = window.setInterval(refreshDmState, 10000)
+    function refreshConversations() {
+      if (document.visibilityState === 'visible') {
+        fetchDmConversations({ silent: true })
+      }
+    }
+
+    const intervalId = window.setInterval(refreshConversations, 10000)
     document.addEventListener('visibilitychange', refreshDmState)
     window.addEventListener('focus', refreshDmState)
 
@@ -115,7 +127,7 @@ export function AppShell() {
     ? location.pathname === '/app/dm'
       ? 'Friends'
       : params.conversationId
-      ? activeConversation?.other_user?.username ?? 'Direct message'
+      ? getConversationTitle(activeConversation)
       : 'Direct messages'
     : isSettings
       ? 'Settings'
@@ -246,7 +258,7 @@ export function AppShell() {
                     )}
 
                     <div className="min-w-0 flex-1">
-                      <p className="truncate font-semibold text-concord-text">{request.user.username}</p>
+                      <p className="truncate font-semibold text-concord-text">{getUserDisplayName(request.user)}</p>
                       <p className="mt-1 text-sm text-concord-muted">Sent you a friend request</p>
                     </div>
 
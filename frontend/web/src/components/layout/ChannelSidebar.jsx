@@ -3,7 +3,9 @@ import { NavLink, useLocation, useNavigate, useParams } from 'react-router-dom'
 
 import { CollapsibleSidebarGroup } from '@/components/layout/CollapsibleSidebarGroup'
 import { useChannelsStore } from '@/features/channels/store'
+import { getConversationAvatar, getConversationTitle, getUserDisplayName } from '@/features/dm/conversation'
 import { useDmStore } from '@/features/dm/store'
+import { getServerMemberProfileRequest, updateServerMemberProfileRequest } from '@/features/servers/api'
 import { useServersStore } from '@/features/servers/store'
 import { getChannelRoute, getDmRoute } from '@/lib/navigation'
 
@@ -42,6 +44,7 @@ export function ChannelSidebar() {
   const [channelName, setChannelName] = React.useState('')
   const [isTextChannelsExpanded, setIsTextChannelsExpanded] = React.useState(true)
   const [dmSearch, setDmSearch] = React.useState('')
+  const [serverNicknameError, setServerNicknameError] = React.useState('')
 
   const selectedChannel = activeChannels.find((channel) => String(channel.id) === params.channelId)
   const selectedConversation = conversations.find(
@@ -60,6 +63,21 @@ export function ChannelSidebar() {
     }
   }, [fetchFriends, isDialogOpen, isDmRoute])
 
+  // Server nicknames only change how you appear in this server's channels.
+  async function handleEditServerNickname() {
+    setServerNicknameError('')
+    try {
+      const profile = await getServerMemberProfileRequest(params.serverId)
+      const nickname = window.prompt(`Nickname in ${activeServer?.name ?? 'this server'}`, profile.nickname ?? '')
+      if (nickname === null) {
+        return
+      }
+      await updateServerMemberProfileRequest(params.serverId, { nickname })
+    } catch (error) {
+      setServerNicknameError(error.response?.data?.error ?? 'Could not save the nickname.')
+    }
+  }
+
   async function handleCreateChannel(event) {
     event.preventDefault()
 
@@ -147,8 +165,19 @@ export function ChannelSidebar() {
             >
               +
             </button>
+          ) : params.serverId ? (
+            <button
+              type="button"
+              onClick={handleEditServerNickname}
+              className="rounded-full bg-concord-panel px-3 py-2 text-xs font-semibold text-concord-muted transition hover:bg-concord-panel-soft hover:text-concord-text"
+            >
+              Nickname
+            </button>
           ) : null}
         </div>
+        {serverNicknameError ? (
+          <p className="mt-2 text-xs text-concord-danger">{serverNicknameError}</p>
+        ) : null}
       </div>
 
       <div className="flex-1 overflow-auto px-4 py-4 md:min-h-0">
@@ -182,23 +211,23 @@ export function ChannelSidebar() {
                     ].join(' ')
                   }
                 >
-                  {conversation.other_user.avatar_url ? (
+                  {getConversationAvatar(conversation).url ? (
                     <img
-                      src={conversation.other_user.avatar_url}
-                      alt={conversation.other_user.username}
+                      src={getConversationAvatar(conversation).url}
+                      alt={getConversationTitle(conversation)}
                       className="h-9 w-9 rounded-full object-cover"
                     />
                   ) : (
                     <div
                       className="flex h-9 w-9 shrink-0 items-center justify-center rounded-full text-xs font-bold text-slate-950"
-                      style={{ backgroundColor: conversation.other_user.avatar_color || '#5ad1b2' }}
+                      style={{ backgroundColor: getConversationAvatar(conversation).color }}
                     >
-                      {conversation.other_user.username.slice(0, 1).toUpperCase()}
+                      {getConversationAvatar(conversation).initial}
                     </div>
                   )}
 
                   <div className="min-w-0 flex-1">
-                    <p className="truncate font-medium">{conversation.other_user.username}</p>
+                    <p className="truncate font-medium">{getConversationTitle(conversation)}</p>
                     {conversation.last_message ? (
                       <p className="truncate text-xs text-concord-muted">{conversation.last_message}</p>
                     ) : null}
@@ -209,7 +238,7 @@ export function ChannelSidebar() {
                     onClick={(event) => handleHideConversation(event, conversation.id)}
                     disabled={hidingConversationId === conversation.id}
                     className="rounded-full px-2 py-1 text-xs text-concord-muted opacity-0 transition hover:bg-concord-panel hover:text-concord-text group-hover:opacity-100 disabled:opacity-100"
-                    aria-label={`Hide conversation with ${conversation.other_user.username}`}
+                    aria-label={`Hide conversation with ${getConversationTitle(conversation)}`}
                   >
                     {hidingConversationId === conversation.id ? '...' : 'x'}
                   </button>
@@ -358,7 +387,7 @@ export function ChannelSidebar() {
                         </div>
                       )}
                       <div className="min-w-0 flex-1">
-                        <p className="truncate text-sm font-semibold text-concord-text">{friend.username}</p>
+                        <p className="truncate text-sm font-semibold text-concord-text">{getUserDisplayName(friend)}</p>
                       </div>
                     </button>
                   ))}
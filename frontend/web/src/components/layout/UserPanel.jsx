@@ -2,6 +2,8 @@ import React from 'react'
 import { NavLink } from 'react-router-dom'
 
 import { SettingsCogIcon } from '@/components/icons/SettingsCogIcon'
+import { clearMyStatusRequest, getMyStatusRequest, setMyStatusRequest } from '@/features/dm/api'
+import { getUserStatusLabel } from '@/features/dm/conversation'
 import { useSessionStore } from '@/lib/sessionStore'
 
 function getInitial(username) {
@@ -10,6 +12,36 @@ function getInitial(username) {
 
 export function UserPanel() {
   const currentUser = useSessionStore((state) => state.currentUser)
+  const [status, setStatus] = React.useState(null)
+
+  React.useEffect(() => {
+    let isCancelled = false
+    getMyStatusRequest()
+      .then((nextStatus) => {
+        if (!isCancelled) {
+          setStatus(nextStatus)
+        }
+      })
+      .catch(() => {})
+    return () => {
+      isCancelled = true
+    }
+  }, [currentUser?.userId])
+
+  async function handleEditStatus() {
+    const text = window.prompt('Set a custom status, or leave empty to clear it', status?.text ?? '')
+    if (text === null) {
+      return
+    }
+    try {
+      const nextStatus = text.trim()
+        ? await setMyStatusRequest({ text, emoji: status?.emoji ?? '' })
+        : await clearMyStatusRequest()
+      setStatus(nextStatus)
+    } catch (_error) {
+      // Keep showing the previous status.
+    }
+  }
 
   return (
     <div className="border-t border-concord-border/60 bg-concord-panel px-4 py-3">
@@ -31,9 +63,16 @@ export function UserPanel() {
 
         <div className="min-w-0 flex-1">
           <p className="truncate text-sm font-semibold text-concord-text">
-            {currentUser?.username ?? 'Concord user'}
+            {currentUser?.displayName || currentUser?.username || 'Concord user'}
           </p>
-          <p className="mt-0.5 truncate text-xs text-concord-muted">Online</p>
+          <button
+            type="button"
+            onClick={handleEditStatus}
+            className="mt-0.5 block max-w-full truncate text-left text-xs text-concord-muted transition hover:text-concord-text"
+            title="Set a custom status"
+          >
+            {getUserStatusLabel({ status }) || 'Online'}
+          </button>
         </div>
 
         <NavLink
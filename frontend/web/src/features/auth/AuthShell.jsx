@@ -15,6 +15,7 @@ export function AuthShell({
   isSubmitting,
   errorMessage,
   successMessage,
+  children,
 }) {
   return (
     <div className="flex min-h-screen items-center justify-center bg-concord-night px-4 py-10">
@@ -72,6 +73,8 @@ export function AuthShell({
           </button>
         </form>
 
+        {children}
+
         <p className="mt-6 text-sm text-concord-muted">
           {alternateLabel}{' '}
           <Link to={alternateTo} className="font-semibold text-concord-accent hover:text-concord-accent-strong">
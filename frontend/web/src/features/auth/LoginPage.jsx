@@ -1,7 +1,7 @@
 import React from 'react'
 import { useNavigate } from 'react-router-dom'
 
-import { loginRequest } from '@/features/auth/api'
+import { beginSsoLoginRequest, loginRequest, ssoProvidersRequest } from '@/features/auth/api'
 import { AuthShell } from '@/features/auth/AuthShell'
 import { useSessionStore } from '@/lib/sessionStore'
 
@@ -14,6 +14,21 @@ export function LoginPage() {
   const [password, setPassword] = React.useState('')
   const [errorMessage, setErrorMessage] = React.useState('')
   const [isSubmitting, setIsSubmitting] = React.useState(false)
+  const [ssoProviders, setSsoProviders] = React.useState([])
+
+  React.useEffect(() => {
+    let isCancelled = false
+    ssoProvidersRequest()
+      .then((providers) => {
+        if (!isCancelled) {
+          setSsoProviders(providers)
+        }
+      })
+      .catch(() => {})
+    return () => {
+      isCancelled = true
+    }
+  }, [])
 
   React.useEffect(() => {
     return () => {
@@ -44,6 +59,19 @@ export function LoginPage() {
     }
   }
 
+  async function handleSsoLogin(provider) {
+    setErrorMessage('')
+    setIsSubmitting(true)
+
+    try {
+      const data = await beginSsoLoginRequest(provider)
+      window.location.assign(data.authorization_url)
+    } catch (error) {
+      setErrorMessage(error.response?.data?.error ?? 'Single sign-on failed')
+      setIsSubmitting(false)
+    }
+  }
+
   return (
     <AuthShell
       title="Welcome back"
@@ -59,6 +87,22 @@ export function LoginPage() {
       isSubmitting={isSubmitting}
       errorMessage={errorMessage}
       successMessage={registerSuccessMessage}
-    />
+    >
+      {ssoProviders.length > 0 ? (
+        <div className="mt-4 space-y-2">
+          {ssoProviders.map((provider) => (
+            <button
+              key={provider}
+              type="button"
+              disabled={isSubmitting}
+              onClick={() => handleSsoLogin(provider)}
+              className="w-full rounded-2xl border border-concord-border px-4 py-3 font-semibold text-concord-text transition hover:border-concord-accent"
+            >
+              Continue with {provider}
+            </button>
+          ))}
+        </div>
+      ) : null}
+    </AuthShell>
   )
 }
@@ -0,0 +1,66 @@
+import React from 'react'
+import { Link, useNavigate, useSearchParams } from 'react-router-dom'
+
+import { completeSsoLoginRequest } from '@/features/auth/api'
+import { useSessionStore } from '@/lib/sessionStore'
+
+export function SsoCallbackPage() {
+  const navigate = useNavigate()
+  const [searchParams] = useSearchParams()
+  const setSession = useSessionStore((state) => state.setSession)
+  const [errorMessage, setErrorMessage] = React.useState('')
+  const hasStarted = React.useRef(false)
+
+  React.useEffect(() => {
+    // The state is single use, so guard against StrictMode double effects.
+    if (hasStarted.current) {
+      return
+    }
+    hasStarted.current = true
+
+    const providerError = searchParams.get('error_description') ?? searchParams.get('error')
+    if (providerError) {
+      setErrorMessage(providerError)
+      return
+    }
+
+    completeSsoLoginRequest({
+      state: searchParams.get('state') ?? '',
+      code: searchParams.get('code') ?? '',
+    })
+      .then((data) => {
+        if (data.mfa_required) {
+          setErrorMessage('This account uses two-factor authentication, which is not supported in the web client yet.')
+          return
+        }
+        setSession({
+          accessToken: data.access_token,
+          refreshToken: data.refresh_token,
+          expiresIn: 15 * 60,
+        })
+        navigate('/app', { replace: true })
+      })
+      .catch((error) => {
+        setErrorMessage(error.response?.data?.error ?? 'Single sign-on failed')
+      })
+  }, [navigate, searchParams, setSession])
+
+  return (
+    <div className="flex min-h-screen items-center justify-center bg-concord-night px-4 py-10">
+      <div className="w-full max-w-md rounded-[2rem] border border-concord-border bg-concord-panel/90 p-8 text-concord-text">
+        {errorMessage ? (
+          <>
+            <div className="rounded-2xl border border-concord-danger/30 bg-concord-danger/10 px-4 py-3 text-sm text-concord-danger">
+              {errorMessage}
+            </div>
+            <Link to="/login" className="mt-6 block text-sm font-semibold text-concord-accent hover:text-concord-accent-strong">
+              Back to login
+            </Link>
+          </>
+        ) : (
+          <p className="text-sm text-concord-muted">Signing you in...</p>
+        )}
+      </div>
+    </div>
+  )
+}
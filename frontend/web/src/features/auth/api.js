@@ -16,3 +16,18 @@ export async function refreshRequest(refreshToken) {
   })
   return response.data
 }
+
+export async function ssoProvidersRequest() {
+  const response = await apiClient.get('/login/oidc')
+  return response.data.providers ?? []
+}
+
+export async function beginSsoLoginRequest(provider) {
+  const response = await apiClient.get(`/login/oidc/${encodeURIComponent(provider)}`)
+  return response.data
+}
+
+export async function completeSsoLoginRequest({ state, code }) {
+  const response = await apiClient.post('/login/oidc/callback', { state, code })
+  return response.data
+}
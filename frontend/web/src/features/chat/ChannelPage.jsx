@@ -5,6 +5,7 @@ import { useChatStore } from '@/features/chat/store'
 import { useChannelsStore } from '@/features/channels/store'
 import { useServersStore } from '@/features/servers/store'
 import { useSessionStore } from '@/lib/sessionStore'
+import { buildWebSocketUrl } from '@/lib/websocketTicket'
 
 function formatMessageTime(value) {
   const date = new Date(value)
@@ -18,6 +19,48 @@ function formatMessageTime(value) {
   }).format(date)
 }
 
+function formatEmbedColor(color) {
+  return color ? `#${color.toString(16).padStart(6, '0')}` : undefined
+}
+
+function MessageEmbed({ embed }) {
+  return (
+    <div
+      className="mt-2 max-w-xl rounded-xl border-l-4 border-concord-accent bg-concord-panel-alt/80 px-4 py-3"
+      style={{ borderLeftColor: formatEmbedColor(embed.color) }}
+    >
+      {embed.title ? (
+        embed.url ? (
+          <a
+            href={embed.url}
+            target="_blank"
+            rel="noreferrer"
+            className="font-semibold text-concord-accent hover:underline"
+          >
+            {embed.title}
+          </a>
+        ) : (
+          <p className="font-semibold text-concord-text">{embed.title}</p>
+        )
+      ) : null}
+      {embed.description ? (
+        <p className="mt-1 whitespace-pre-wrap text-sm leading-6 text-concord-text/90">{embed.description}</p>
+      ) : null}
+      {embed.fields?.length ? (
+        <div className="mt-2 grid gap-2 sm:grid-cols-2">
+          {embed.fields.map((field, fieldIndex) => (
+            <div key={fieldIndex} className={field.inline ? '' : 'sm:col-span-2'}>
+              <p className="text-xs font-semibold text-concord-text">{field.name}</p>
+              <p className="whitespace-pre-wrap text-sm text-concord-text/90">{field.value}</p>
+            </div>
+          ))}
+        </div>
+      ) : null}
+      {embed.footer ? <p className="mt-2 text-xs text-concord-muted">{embed.footer}</p> : null}
+    </div>
+  )
+}
+
 function getMessageInitial(username) {
   return username?.slice(0, 1).toUpperCase() || '?'
 }
@@ -76,6 +119,7 @@ export function ChannelPage() {
   const [draftMessage, setDraftMessage] = React.useState('')
   const [sendError, setSendError] = React.useState('')
   const [reconnectNonce, setReconnectNonce] = React.useState(0)
+  const [revealedMessageIds, setRevealedMessageIds] = React.useState({})
   const socketRef = React.useRef(null)
   const reconnectTimeoutRef = React.useRef(null)
   const messagesContainerRef = React.useRef(null)
@@ -99,49 +143,84 @@ export function ChannelPage() {
       return undefined
     }
 
-    const websocketUrl = new URL('/api/ws', import.meta.env.VITE_WS_URL)
-    websocketUrl.searchParams.set('channel_id', channelId)
-    websocketUrl.searchParams.set('token', accessToken)
+    let socket = null
+    let isCancelled = false
 
-    setConnectionState(channelId, 'connecting')
-    const socket = new WebSocket(websocketUrl.toString())
-    socketRef.current = socket
-
-    socket.onopen = () => {
-      setConnectionState(channelId, 'connected')
-      setSendError('')
-      if (reconnectTimeoutRef.current) {
-        clearTimeout(reconnectTimeoutRef.current)
-        reconnectTimeoutRef.current = null
-      }
+    function scheduleReconnect() {
+      reconnectTimeoutRef.current = window.setTimeout(() => {
+        setReconnectNonce((value) => value + 1)
+      }, 2000)
     }
 
-    socket.onmessage = (event) => {
+    async function connect() {
+      setConnectionState(channelId, 'connecting')
+
+      let websocketUrl
       try {
-        const parsedMessage = JSON.parse(event.data)
-        reconcileIncomingMessage(channelId, parsedMessage, currentUser?.username ?? '')
+        websocketUrl = await buildWebSocketUrl('/api/ws', { channel_id: channelId })
       } catch (_error) {
-        setSendError('Received an unreadable live message payload.')
+        if (!isCancelled) {
+          setConnectionState(channelId, 'disconnected')
+          scheduleReconnect()
+        }
+        return
+      }
+      if (isCancelled) {
+        return
       }
-    }
 
-    socket.onerror = () => {
-      setSendError('The live connection ran into a problem. Trying again soon...')
-    }
+      socket = new WebSocket(websocketUrl)
+      socketRef.current = socket
 
-    socket.onclose = () => {
-      setConnectionState(channelId, 'disconnected')
-      reconnectTimeoutRef.current = window.setTimeout(() => {
-        setReconnectNonce((value) => value + 1)
-      }, 2000)
+      socket.onopen = () => {
+        setConnectionState(channelId, 'connected')
+        setSendError('')
+        if (reconnectTimeoutRef.current) {
+          clearTimeout(reconnectTimeoutRef.current)
+          reconnectTimeoutRef.current = null
+        }
+      }
+
+      socket.onmessage = (event) => {
+        try {
+          const parsedMessage = JSON.parse(event.data)
+          if (parsedMessage.error === 'rate_limited') {
+            setSendError(`You are sending messages too quickly. Try again in ${parsedMessage.retry_after}s.`)
+            return
+          }
+          if (parsedMessage.error === 'command_error') {
+            setSendError(parsedMessage.message)
+            return
+          }
+          if (parsedMessage.ephemeral) {
+            // Ephemeral messages are not stored, so they have no ID of their own
+            parsedMessage.id = `ephemeral-${channelId}-${Date.now()}-${Math.random()}`
+          }
+          reconcileIncomingMessage(channelId, parsedMessage, currentUser?.username ?? '')
+        } catch (_error) {
+          setSendError('Received an unreadable live message payload.')
+        }
+      }
+
+      socket.onerror = () => {
+        setSendError('The live connection ran into a problem. Trying again soon...')
+      }
+
+      socket.onclose = () => {
+        setConnectionState(channelId, 'disconnected')
+        scheduleReconnect()
+      }
     }
 
+    connect()
+
     return () => {
+      isCancelled = true
       if (reconnectTimeoutRef.current) {
         clearTimeout(reconnectTimeoutRef.current)
         reconnectTimeoutRef.current = null
       }
-      socket.close()
+      socket?.close()
       socketRef.current = null
     }
   }, [
@@ -170,17 +249,23 @@ export function ChannelPage() {
 
     const optimisticId = `optimistic-${channelId}-${Date.now()}`
 
-    addOptimisticMessage(channelId, {
-      id: optimisticId,
-      channel_id: Number(channelId),
-      user_id: -1,
-      content,
-      username: currentUser?.username ?? 'You',
-      created_at: new Date().toISOString(),
-      avatar_url: currentUser?.avatarUrl ?? '',
-      avatar_color: currentUser?.avatarColor ?? '#5ad1b2',
-      optimisticState: 'sending',
-    })
+    // Slash commands may be answered by a bot instead of being posted
+    const isCommand = content.startsWith('/')
+
+    if (!isCommand) {
+      addOptimisticMessage(channelId, {
+        id: optimisticId,
+        channel_id: Number(channelId),
+        user_id: -1,
+        content,
+        username: currentUser?.username ?? 'You',
+        display_name: currentUser?.displayName ?? '',
+        created_at: new Date().toISOString(),
+        avatar_url: currentUser?.avatarUrl ?? '',
+        avatar_color: currentUser?.avatarColor ?? '#5ad1b2',
+        optimisticState: 'sending',
+      })
+    }
 
     try {
       socketRef.current.send(
@@ -277,6 +362,23 @@ export function ChannelPage() {
             messages.map((message, index) => {
               const grouped = isSameAuthorBlock(message, messages[index - 1])
 
+              if (message.author_blocked && !revealedMessageIds[message.id]) {
+                return (
+                  <p key={message.id} className="px-3 text-xs text-concord-muted">
+                    Message from a blocked user
+                    <button
+                      type="button"
+                      onClick={() =>
+                        setRevealedMessageIds((current) => ({ ...current, [message.id]: true }))
+                      }
+                      className="ml-2 font-semibold text-concord-accent hover:underline"
+                    >
+                      Show
+                    </button>
+                  </p>
+                )
+              }
+
               return (
               <article
                 key={message.id}
@@ -306,7 +408,22 @@ export function ChannelPage() {
                 <div className="min-w-0 flex-1">
                   {!grouped ? (
                     <div className="flex flex-wrap items-center gap-x-3 gap-y-1">
-                      <span className="font-semibold text-concord-text">{message.username}</span>
+                      <span className="font-semibold text-concord-text">
+                        {message.author_nickname ||
+                          message.server_nickname ||
+                          message.display_name ||
+                          message.username}
+                      </span>
+                      {message.is_bot ? (
+                        <span className="rounded-md bg-concord-accent/20 px-1.5 py-0.5 text-[10px] font-semibold uppercase tracking-[0.18em] text-concord-accent">
+                          {message.webhook_id ? 'Webhook' : 'Bot'}
+                        </span>
+                      ) : null}
+                      {message.ephemeral ? (
+                        <span className="text-[10px] uppercase tracking-[0.18em] text-concord-muted">
+                          Only you can see this
+                        </span>
+                      ) : null}
                       <span className="text-xs uppercase tracking-[0.22em] text-concord-muted">
                         {formatMessageTime(message.created_at)}
                       </span>
@@ -326,6 +443,9 @@ export function ChannelPage() {
                   <p className={`${grouped ? '' : 'mt-2'} whitespace-pre-wrap text-sm leading-7 text-concord-text/92`}>
                     {message.content}
                   </p>
+                  {message.embeds?.map((embed, embedIndex) => (
+                    <MessageEmbed key={embedIndex} embed={embed} />
+                  ))}
                 </div>
               </article>
               )
@@ -1,8 +1,11 @@
 import React from 'react'
 import { useParams } from 'react-router-dom'
 
+import { getDmConversationRequest } from '@/features/dm/api'
+import { getConversationTitle, getUserDisplayName } from '@/features/dm/conversation'
 import { useDmStore } from '@/features/dm/store'
 import { useSessionStore } from '@/lib/sessionStore'
+import { buildWebSocketUrl } from '@/lib/websocketTicket'
 
 function formatMessageTime(value) {
   const date = new Date(value)
@@ -25,9 +28,49 @@ function isSameAuthorBlock(currentMessage, previousMessage) {
     return false
   }
 
+  if (isSystemMessage(currentMessage) || isSystemMessage(previousMessage)) {
+    return false
+  }
+
   return currentMessage.username === previousMessage.username
 }
 
+// getSeenLabel describes who has read the viewer's latest message.
+function getSeenLabel(conversation, lastMessage, readStates, currentUserId) {
+  if (!lastMessage || !Number.isInteger(lastMessage.id) || String(lastMessage.user_id) !== String(currentUserId)) {
+    return ''
+  }
+
+  const readers = (conversation?.participants ?? []).filter(
+    (participant) =>
+      String(participant.user_id) !== String(currentUserId) &&
+      (readStates[participant.user_id] ?? 0) >= lastMessage.id,
+  )
+  if (readers.length === 0) {
+    return ''
+  }
+
+  return conversation.is_group
+    ? `Seen by ${readers.map((reader) => getUserDisplayName(reader)).join(', ')}`
+    : 'Seen'
+}
+
+// Live messages carry no nickname, so fall back to the author's entry in the
+// conversation's participants.
+function getAuthorName(conversation, message) {
+  if (message.author_nickname) {
+    return message.author_nickname
+  }
+  const participant = (conversation?.participants ?? []).find(
+    (candidate) => String(candidate.user_id) === String(message.user_id),
+  )
+  return participant?.nickname || message.display_name || participant?.display_name || message.username
+}
+
+function isSystemMessage(message) {
+  return Boolean(message.type) && message.type !== 'default'
+}
+
 function MessageSkeleton({ grouped = false, widthClass = 'w-64' }) {
   return (
     <article className="flex gap-4 rounded-[1.5rem] px-3 py-3">
@@ -73,13 +116,18 @@ export function DmConversationPage() {
   const isLoadingMessages = loadingByConversationId[String(conversationId)]
   const messageError = errorByConversationId[String(conversationId)]
   const isBlocked = messageError === 'direct message is blocked'
+  // The web client has no device keys, so it can read the history of an
+  // encrypted conversation but cannot send to it.
+  const isEncrypted = Boolean(conversation?.is_encrypted)
   const connectionState = connectionStateByConversationId[String(conversationId)] ?? 'idle'
   const [draftMessage, setDraftMessage] = React.useState('')
   const [sendError, setSendError] = React.useState('')
   const [reconnectNonce, setReconnectNonce] = React.useState(0)
+  const [readStates, setReadStates] = React.useState({})
   const socketRef = React.useRef(null)
   const reconnectTimeoutRef = React.useRef(null)
   const messagesContainerRef = React.useRef(null)
+  const lastReportedReadIdRef = React.useRef(0)
 
   React.useEffect(() => {
     if (conversationId) {
@@ -93,6 +141,55 @@ export function DmConversationPage() {
     setSendError('')
   }, [conversationId])
 
+  // The conversation list does not carry read pointers, so load them here and
+  // keep them current from live "read" events.
+  React.useEffect(() => {
+    let isCancelled = false
+    setReadStates({})
+    lastReportedReadIdRef.current = 0
+
+    if (conversationId) {
+      getDmConversationRequest(conversationId)
+        .then((detail) => {
+          if (isCancelled) {
+            return
+          }
+          const nextReadStates = {}
+          for (const readState of detail.read_states ?? []) {
+            nextReadStates[readState.user_id] = readState.last_read_message_id
+          }
+          setReadStates((current) => ({ ...nextReadStates, ...current }))
+        })
+        .catch(() => {})
+    }
+
+    return () => {
+      isCancelled = true
+    }
+  }, [conversationId])
+
+  const latestMessageId = [...messages]
+    .reverse()
+    .find((message) => Number.isInteger(message.id))?.id
+
+  const lastMessage = messages[messages.length - 1]
+  const seenLabel = getSeenLabel(conversation, lastMessage, readStates, currentUser?.userId)
+
+  React.useEffect(() => {
+    const socket = socketRef.current
+    if (
+      connectionState !== 'connected' ||
+      !latestMessageId ||
+      latestMessageId <= lastReportedReadIdRef.current ||
+      socket?.readyState !== WebSocket.OPEN
+    ) {
+      return
+    }
+
+    socket.send(JSON.stringify({ type: 'read', message_id: latestMessageId }))
+    lastReportedReadIdRef.current = latestMessageId
+  }, [connectionState, latestMessageId])
+
   React.useEffect(() => {
     const container = messagesContainerRef.current
     if (!container) {
@@ -107,55 +204,94 @@ export function DmConversationPage() {
       return undefined
     }
 
-    const websocketUrl = new URL('/api/dms/ws', import.meta.env.VITE_WS_URL)
-    websocketUrl.searchParams.set('conversation_id', conversationId)
-    websocketUrl.searchParams.set('token', accessToken)
-
-    setConnectionState(conversationId, 'connecting')
-    const socket = new WebSocket(websocketUrl.toString())
-    socketRef.current = socket
+    let socket = null
+    let isCancelled = false
 
-    socket.onopen = () => {
-      setConnectionState(conversationId, 'connected')
-      setSendError('')
-      if (reconnectTimeoutRef.current) {
-        clearTimeout(reconnectTimeoutRef.current)
-        reconnectTimeoutRef.current = null
-      }
+    function scheduleReconnect() {
+      reconnectTimeoutRef.current = window.setTimeout(() => {
+        setReconnectNonce((value) => value + 1)
+      }, 2000)
     }
 
-    socket.onmessage = (event) => {
+    async function connect() {
+      setConnectionState(conversationId, 'connecting')
+
+      let websocketUrl
       try {
-        const parsedMessage = JSON.parse(event.data)
-        reconcileIncomingMessage(conversationId, parsedMessage, currentUser?.username ?? '')
+        websocketUrl = await buildWebSocketUrl('/api/dms/ws', { conversation_id: conversationId })
       } catch (_error) {
-        setSendError('Received an unreadable live message payload.')
+        if (!isCancelled) {
+          setConnectionState(conversationId, 'disconnected')
+          scheduleReconnect()
+        }
+        return
+      }
+      if (isCancelled) {
+        return
       }
-    }
 
-    socket.onerror = () => {
-      setSendError('The live connection ran into a problem. Trying again soon...')
-    }
+      socket = new WebSocket(websocketUrl)
+      socketRef.current = socket
 
-    socket.onclose = () => {
-      setConnectionState(conversationId, 'disconnected')
-      reconnectTimeoutRef.current = window.setTimeout(() => {
-        setReconnectNonce((value) => value + 1)
-      }, 2000)
+      socket.onopen = () => {
+        setConnectionState(conversationId, 'connected')
+        setSendError('')
+        if (reconnectTimeoutRef.current) {
+          clearTimeout(reconnectTimeoutRef.current)
+          reconnectTimeoutRef.current = null
+        }
+      }
+
+      socket.onmessage = (event) => {
+        try {
+          const parsedMessage = JSON.parse(event.data)
+          if (parsedMessage.error === 'rate_limited') {
+            setSendError(`You are sending messages too quickly. Try again in ${parsedMessage.retry_after}s.`)
+            return
+          }
+          if (parsedMessage.type === 'block') {
+            fetchMessagesForConversation(conversationId)
+            return
+          }
+          if (parsedMessage.type === 'read') {
+            setReadStates((current) => ({
+              ...current,
+              [parsedMessage.user_id]: parsedMessage.last_read_message_id,
+            }))
+            return
+          }
+          reconcileIncomingMessage(conversationId, parsedMessage, currentUser?.username ?? '')
+        } catch (_error) {
+          setSendError('Received an unreadable live message payload.')
+        }
+      }
+
+      socket.onerror = () => {
+        setSendError('The live connection ran into a problem. Trying again soon...')
+      }
+
+      socket.onclose = () => {
+        setConnectionState(conversationId, 'disconnected')
+        scheduleReconnect()
+      }
     }
 
+    connect()
+
     return () => {
+      isCancelled = true
       if (reconnectTimeoutRef.current) {
         clearTimeout(reconnectTimeoutRef.current)
         reconnectTimeoutRef.current = null
       }
-      socket.close()
+      socket?.close()
       socketRef.current = null
     }
   }, [
     accessToken,
     conversationId,
     currentUser?.username,
+    fetchMessagesForConversation,
     hasConversation,
     isBlocked,
     reconnectNonce,
@@ -177,6 +313,11 @@ export function DmConversationPage() {
       return
     }
 
+    if (isEncrypted) {
+      setSendError('Encrypted conversations can only be used from a registered device.')
+      return
+    }
+
     if (!socketRef.current || socketRef.current.readyState !== WebSocket.OPEN) {
       setSendError('Live connection is not ready yet.')
       return
@@ -190,6 +331,7 @@ export function DmConversationPage() {
       user_id: -1,
       content,
       username: currentUser?.username ?? 'You',
+      display_name: currentUser?.displayName ?? '',
       created_at: new Date().toISOString(),
       avatar_url: currentUser?.avatarUrl ?? '',
       avatar_color: currentUser?.avatarColor ?? '#5ad1b2',
@@ -281,7 +423,7 @@ export function DmConversationPage() {
 
           {!isLoadingMessages && !messageError && !isBlocked && messages.length === 0 ? (
             <div className="rounded-[1.5rem] border border-concord-border bg-concord-panel-alt/80 px-5 py-6 text-sm leading-6 text-concord-muted">
-              No messages yet. Start the conversation with {conversation.other_user.username}.
+              No messages yet. Start the conversation with {getConversationTitle(conversation)}.
             </div>
           ) : null}
 
@@ -291,6 +433,17 @@ export function DmConversationPage() {
             messages.map((message, index) => {
               const grouped = isSameAuthorBlock(message, messages[index - 1])
 
+              if (isSystemMessage(message)) {
+                return (
+                  <p key={message.id} className="px-3 text-center text-xs text-concord-muted">
+                    {message.content}
+                    <span className="ml-2 uppercase tracking-[0.22em]">
+                      {formatMessageTime(message.created_at)}
+                    </span>
+                  </p>
+                )
+              }
+
               return (
                 <article
                   key={message.id}
@@ -320,7 +473,7 @@ export function DmConversationPage() {
                   <div className="min-w-0 flex-1">
                     {!grouped ? (
                       <div className="flex flex-wrap items-center gap-x-3 gap-y-1">
-                        <span className="font-semibold text-concord-text">{message.username}</span>
+                        <span className="font-semibold text-concord-text">{getAuthorName(conversation, message)}</span>
                         <span className="text-xs uppercase tracking-[0.22em] text-concord-muted">
                           {formatMessageTime(message.created_at)}
                         </span>
@@ -337,13 +490,23 @@ export function DmConversationPage() {
                         ) : null}
                       </div>
                     ) : null}
-                    <p className={`${grouped ? '' : 'mt-2'} whitespace-pre-wrap text-sm leading-7 text-concord-text/92`}>
-                      {message.content}
-                    </p>
+                    {message.envelopes ? (
+                      <p className={`${grouped ? '' : 'mt-2'} text-sm italic leading-7 text-concord-muted`}>
+                        Encrypted message
+                      </p>
+                    ) : (
+                      <p className={`${grouped ? '' : 'mt-2'} whitespace-pre-wrap text-sm leading-7 text-concord-text/92`}>
+                        {message.content}
+                      </p>
+                    )}
                   </div>
                 </article>
               )
             })}
+
+          {!isLoadingMessages && !isBlocked && seenLabel ? (
+            <p className="px-3 text-right text-xs text-concord-muted">{seenLabel}</p>
+          ) : null}
         </div>
 
         <div className="mt-4 border-t border-concord-border/60 px-1 py-4 md:px-2">
@@ -355,14 +518,16 @@ export function DmConversationPage() {
                 placeholder={
                   isBlocked
                     ? 'Messaging unavailable'
-                    : `Message ${conversation.other_user.username}`
+                    : isEncrypted
+                      ? 'End-to-end encrypted'
+                      : `Message ${getConversationTitle(conversation)}`
                 }
-                disabled={isBlocked}
+                disabled={isBlocked || isEncrypted}
                 className="min-w-0 flex-1 rounded-2xl border border-concord-border bg-concord-panel-alt px-4 py-3 text-sm text-concord-text outline-none transition focus:border-concord-accent"
               />
               <button
                 type="submit"
-                disabled={isBlocked}
+                disabled={isBlocked || isEncrypted}
                 className="rounded-2xl bg-concord-accent px-5 py-3 text-sm font-semibold text-slate-950 transition hover:bg-concord-accent-strong"
               >
                 Send
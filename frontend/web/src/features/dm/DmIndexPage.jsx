@@ -1,6 +1,11 @@
 import React from 'react'
 import { useNavigate } from 'react-router-dom'
 
+import { updateUserAnnotationRequest } from '@/features/dm/api'
+import { getUserDisplayName, getUserStatusLabel } from '@/features/dm/conversation'
+import { FriendSuggestionsList } from '@/features/dm/FriendSuggestionsList'
+import { MessageRequestsList } from '@/features/dm/MessageRequestsList'
+import { OutgoingFriendRequestsList } from '@/features/dm/OutgoingFriendRequestsList'
 import { useDmStore } from '@/features/dm/store'
 import { getDmRoute } from '@/lib/navigation'
 
@@ -57,6 +62,7 @@ export function DmIndexPage() {
   const [search, setSearch] = React.useState('')
   const [mode, setMode] = React.useState('friends')
   const [openMenuUserId, setOpenMenuUserId] = React.useState(null)
+  const [annotationError, setAnnotationError] = React.useState('')
 
   React.useEffect(() => {
     fetchFriends()
@@ -103,7 +109,7 @@ export function DmIndexPage() {
   const normalizedSearch = search.trim().toLowerCase()
   const onlineFriends = friends
   const visibleFriends = (activeFilter === 'online' ? onlineFriends : friends).filter((friend) =>
-    friend.username.toLowerCase().includes(normalizedSearch),
+    getUserDisplayName(friend).toLowerCase().includes(normalizedSearch),
   )
   const visibleBlockedUsers = blockedUsers.filter((user) =>
     (user.user?.username ?? '').toLowerCase().includes(normalizedSearch),
@@ -130,6 +136,23 @@ export function DmIndexPage() {
     }
   }
 
+  async function handleEditNickname(event, friend) {
+    event.stopPropagation()
+    const nickname = window.prompt(`Nickname for ${friend.username}, only visible to you`, friend.nickname ?? '')
+    if (nickname === null) {
+      return
+    }
+
+    setAnnotationError('')
+    try {
+      await updateUserAnnotationRequest(friend.user_id, { nickname })
+      setOpenMenuUserId(null)
+      fetchFriends({ silent: true })
+    } catch (error) {
+      setAnnotationError(error.response?.data?.error ?? 'Could not save the nickname.')
+    }
+  }
+
   async function handleBlockUser(event, userId) {
     event.stopPropagation()
     const blocked = await blockUser(userId)
@@ -192,6 +215,34 @@ export function DmIndexPage() {
           >
             Blocked
           </button>
+          <button
+            type="button"
+            onClick={() => {
+              setActiveFilter('requests')
+              setMode('friends')
+            }}
+            className={`rounded-full px-3 py-1.5 transition ${
+              activeFilter === 'requests'
+                ? 'bg-concord-accent text-slate-950'
+                : 'bg-concord-panel-alt text-concord-muted hover:text-concord-text'
+            }`}
+          >
+            Message Requests
+          </button>
+          <button
+            type="button"
+            onClick={() => {
+              setActiveFilter('pending')
+              setMode('friends')
+            }}
+            className={`rounded-full px-3 py-1.5 transition ${
+              activeFilter === 'pending'
+                ? 'bg-concord-accent text-slate-950'
+                : 'bg-concord-panel-alt text-concord-muted hover:text-concord-text'
+            }`}
+          >
+            Pending
+          </button>
           <button
             type="button"
             onClick={() => {
@@ -284,7 +335,13 @@ export function DmIndexPage() {
                 </div>
               ))}
             </div>
+
+            {!search.trim() ? <FriendSuggestionsList /> : null}
           </>
+        ) : activeFilter === 'requests' ? (
+          <MessageRequestsList />
+        ) : activeFilter === 'pending' ? (
+          <OutgoingFriendRequestsList />
         ) : (
           <>
             <div className="mb-4">
@@ -309,6 +366,12 @@ export function DmIndexPage() {
               </p>
             ) : null}
 
+            {activeFilter !== 'blocked' && annotationError ? (
+              <p className="rounded-2xl border border-concord-danger/30 bg-concord-danger/10 px-4 py-3 text-sm text-concord-danger">
+                {annotationError}
+              </p>
+            ) : null}
+
             {activeFilter === 'blocked' && isLoadingBlockedUsers ? (
               <p className="text-sm text-concord-muted">Loading blocked users...</p>
             ) : null}
@@ -413,13 +476,16 @@ export function DmIndexPage() {
                       className="flex h-11 w-11 items-center justify-center rounded-full text-sm font-bold text-slate-950"
                       style={{ backgroundColor: friend.avatar_color || '#5ad1b2' }}
                     >
-                      {friend.username.slice(0, 1).toUpperCase()}
+                      {getUserDisplayName(friend).slice(0, 1).toUpperCase()}
                     </div>
                   )}
 
                   <div className="min-w-0 flex-1">
-                    <p className="truncate font-semibold text-concord-text">{friend.username}</p>
-                    <p className="mt-1 text-sm text-concord-muted">Online</p>
+                    <p className="truncate font-semibold text-concord-text">{getUserDisplayName(friend)}</p>
+                    <p className="mt-1 truncate text-sm text-concord-muted">
+                      {friend.nickname ? `${friend.username} · ` : ''}
+                      {getUserStatusLabel(friend) || friend.note || 'Online'}
+                    </p>
                   </div>
 
                   <div className="relative">
@@ -440,11 +506,18 @@ export function DmIndexPage() {
                         className="absolute right-0 top-12 z-20 min-w-44 rounded-2xl border border-concord-border bg-concord-panel p-2 shadow-[0_18px_40px_rgba(0,0,0,0.35)]"
                         onClick={(event) => event.stopPropagation()}
                       >
+                        <button
+                          type="button"
+                          onClick={(event) => handleEditNickname(event, friend)}
+                          className="flex w-full rounded-xl px-3 py-2 text-left text-sm text-concord-text transition hover:bg-concord-panel-soft"
+                        >
+                          Edit Nickname
+                        </button>
                         <button
                           type="button"
                           onClick={(event) => handleRemoveFriend(event, friend.user_id)}
                           disabled={Boolean(friendActionByUserId[String(friend.user_id)])}
-                          className="flex w-full rounded-xl px-3 py-2 text-left text-sm text-concord-text transition hover:bg-concord-panel-soft disabled:cursor-not-allowed disabled:opacity-60"
+                          className="mt-1 flex w-full rounded-xl px-3 py-2 text-left text-sm text-concord-text transition hover:bg-concord-panel-soft disabled:cursor-not-allowed disabled:opacity-60"
                         >
                           {friendActionByUserId[String(friend.user_id)] === 'removing'
                             ? 'Removing...'
@@ -0,0 +1,116 @@
+import React from 'react'
+
+import { listFriendSuggestionsRequest, sendFriendRequestRequest } from '@/features/dm/api'
+
+function describeMutuals(suggestion) {
+  const parts = []
+  if (suggestion.mutual_friends > 0) {
+    parts.push(`${suggestion.mutual_friends} mutual friend${suggestion.mutual_friends === 1 ? '' : 's'}`)
+  }
+  if (suggestion.mutual_servers > 0) {
+    parts.push(`${suggestion.mutual_servers} mutual server${suggestion.mutual_servers === 1 ? '' : 's'}`)
+  }
+  return parts.join(' · ')
+}
+
+export function FriendSuggestionsList() {
+  const [suggestions, setSuggestions] = React.useState([])
+  const [isLoading, setIsLoading] = React.useState(true)
+  const [error, setError] = React.useState('')
+  const [sendingById, setSendingById] = React.useState({})
+
+  React.useEffect(() => {
+    let cancelled = false
+
+    listFriendSuggestionsRequest()
+      .then((data) => {
+        if (!cancelled) {
+          setSuggestions(data.suggestions ?? [])
+        }
+      })
+      .catch(() => {
+        if (!cancelled) {
+          setError('Could not load friend suggestions.')
+        }
+      })
+      .finally(() => {
+        if (!cancelled) {
+          setIsLoading(false)
+        }
+      })
+
+    return () => {
+      cancelled = true
+    }
+  }, [])
+
+  async function handleAdd(userId) {
+    setSendingById((current) => ({ ...current, [userId]: true }))
+    try {
+      await sendFriendRequestRequest(userId)
+      setSuggestions((current) => current.filter((suggestion) => suggestion.user_id !== userId))
+    } catch (_error) {
+      setError('Could not send the friend request.')
+    } finally {
+      setSendingById((current) => {
+        const next = { ...current }
+        delete next[userId]
+        return next
+      })
+    }
+  }
+
+  if (isLoading || (!error && suggestions.length === 0)) {
+    return null
+  }
+
+  return (
+    <div className="mt-6 space-y-2">
+      <p className="mb-4 text-xs font-semibold uppercase tracking-[0.28em] text-concord-muted">
+        People You May Know
+      </p>
+
+      {error ? (
+        <p className="rounded-2xl border border-concord-danger/30 bg-concord-danger/10 px-4 py-3 text-sm text-concord-danger">
+          {error}
+        </p>
+      ) : null}
+
+      {suggestions.map((suggestion) => (
+        <div
+          key={suggestion.user_id}
+          className="flex items-center gap-4 rounded-2xl border border-concord-border bg-concord-panel-alt/70 px-4 py-3"
+        >
+          {suggestion.avatar_url ? (
+            <img
+              src={suggestion.avatar_url}
+              alt={suggestion.username}
+              className="h-11 w-11 rounded-full object-cover"
+            />
+          ) : (
+            <div
+              className="flex h-11 w-11 items-center justify-center rounded-full text-sm font-bold text-slate-950"
+              style={{ backgroundColor: suggestion.avatar_color || '#5ad1b2' }}
+            >
+              {suggestion.username.slice(0, 1).toUpperCase()}
+            </div>
+          )}
+
+          <div className="min-w-0 flex-1">
+            <p className="truncate font-semibold text-concord-text">{suggestion.username}</p>
+            <p className="mt-1 text-sm text-concord-muted">{describeMutuals(suggestion)}</p>
+          </div>
+
+          <button
+            type="button"
+            onClick={() => handleAdd(suggestion.user_id)}
+            disabled={Boolean(sendingById[suggestion.user_id])}
+            className="rounded-full bg-concord-accent px-4 py-2 text-sm font-semibold text-slate-950 transition hover:bg-concord-accent-strong disabled:cursor-not-allowed disabled:opacity-60"
+          >
+            {sendingById[suggestion.user_id] ? 'Sending...' : 'Add'}
+          </button>
+        </div>
+      ))}
+    </div>
+  )
+}
@@ -0,0 +1,141 @@
+import React from 'react'
+import { useNavigate } from 'react-router-dom'
+
+import {
+  acceptMessageRequestRequest,
+  blockMessageRequestRequest,
+  ignoreMessageRequestRequest,
+  listMessageRequestsRequest,
+} from '@/features/dm/api'
+import { getConversationAvatar, getConversationTitle } from '@/features/dm/conversation'
+import { useDmStore } from '@/features/dm/store'
+import { getDmRoute } from '@/lib/navigation'
+
+export function MessageRequestsList() {
+  const navigate = useNavigate()
+  const fetchConversations = useDmStore((state) => state.fetchConversations)
+  const [requests, setRequests] = React.useState([])
+  const [isLoading, setIsLoading] = React.useState(true)
+  const [error, setError] = React.useState('')
+  const [actionById, setActionById] = React.useState({})
+
+  React.useEffect(() => {
+    let cancelled = false
+
+    listMessageRequestsRequest()
+      .then((data) => {
+        if (!cancelled) {
+          setRequests(data.requests ?? [])
+        }
+      })
+      .catch(() => {
+        if (!cancelled) {
+          setError('Could not load message requests.')
+        }
+      })
+      .finally(() => {
+        if (!cancelled) {
+          setIsLoading(false)
+        }
+      })
+
+    return () => {
+      cancelled = true
+    }
+  }, [])
+
+  async function runAction(conversationId, action, request) {
+    setActionById((current) => ({ ...current, [conversationId]: action }))
+    try {
+      await request(conversationId)
+      setRequests((current) => current.filter((conversation) => conversation.id !== conversationId))
+      if (action === 'accepting') {
+        await fetchConversations({ silent: true })
+        navigate(getDmRoute(conversationId))
+      }
+    } catch (_error) {
+      setError('Could not update the message request.')
+    } finally {
+      setActionById((current) => {
+        const next = { ...current }
+        delete next[conversationId]
+        return next
+      })
+    }
+  }
+
+  if (isLoading) {
+    return <p className="text-sm text-concord-muted">Loading message requests...</p>
+  }
+
+  return (
+    <div className="space-y-2">
+      {error ? (
+        <p className="rounded-2xl border border-concord-danger/30 bg-concord-danger/10 px-4 py-3 text-sm text-concord-danger">
+          {error}
+        </p>
+      ) : null}
+
+      {!error && requests.length === 0 ? (
+        <div className="rounded-2xl border border-concord-border bg-concord-panel-alt/80 px-5 py-6 text-sm leading-6 text-concord-muted">
+          Messages from server members who are not your friends will appear here.
+        </div>
+      ) : null}
+
+      {requests.map((conversation) => {
+        const avatar = getConversationAvatar(conversation)
+        const action = actionById[conversation.id]
+
+        return (
+          <div
+            key={conversation.id}
+            className="flex items-center gap-4 rounded-2xl border border-concord-border bg-concord-panel-alt/70 px-4 py-3"
+          >
+            {avatar.url ? (
+              <img src={avatar.url} alt={getConversationTitle(conversation)} className="h-11 w-11 rounded-full object-cover" />
+            ) : (
+              <div
+                className="flex h-11 w-11 items-center justify-center rounded-full text-sm font-bold text-slate-950"
+                style={{ backgroundColor: avatar.color }}
+              >
+                {avatar.initial}
+              </div>
+            )}
+
+            <div className="min-w-0 flex-1">
+              <p className="truncate font-semibold text-concord-text">{getConversationTitle(conversation)}</p>
+              {conversation.last_message ? (
+                <p className="mt-1 truncate text-sm text-concord-muted">{conversation.last_message}</p>
+              ) : null}
+            </div>
+
+            <button
+              type="button"
+              onClick={() => runAction(conversation.id, 'accepting', acceptMessageRequestRequest)}
+              disabled={Boolean(action)}
+              className="rounded-full bg-concord-accent px-4 py-2 text-sm font-semibold text-slate-950 transition hover:bg-concord-accent-strong disabled:cursor-not-allowed disabled:opacity-60"
+            >
+              {action === 'accepting' ? 'Accepting...' : 'Accept'}
+            </button>
+            <button
+              type="button"
+              onClick={() => runAction(conversation.id, 'ignoring', ignoreMessageRequestRequest)}
+              disabled={Boolean(action)}
+              className="rounded-full px-3 py-2 text-sm text-concord-muted transition hover:bg-concord-panel hover:text-concord-text disabled:cursor-not-allowed disabled:opacity-60"
+            >
+              {action === 'ignoring' ? 'Ignoring...' : 'Ignore'}
+            </button>
+            <button
+              type="button"
+              onClick={() => runAction(conversation.id, 'blocking', blockMessageRequestRequest)}
+              disabled={Boolean(action)}
+              className="rounded-full px-3 py-2 text-sm text-concord-danger transition hover:bg-concord-danger/10 disabled:cursor-not-allowed disabled:opacity-60"
+            >
+              {action === 'blocking' ? 'Blocking...' : 'Block'}
+            </button>
+          </div>
+        )
+      })}
+    </div>
+  )
+}
@@ -0,0 +1,107 @@
+import React from 'react'
+
+import { cancelFriendRequestRequest, listOutgoingFriendRequestsRequest } from '@/features/dm/api'
+
+export function OutgoingFriendRequestsList() {
+  const [requests, setRequests] = React.useState([])
+  const [isLoading, setIsLoading] = React.useState(true)
+  const [error, setError] = React.useState('')
+  const [cancellingById, setCancellingById] = React.useState({})
+
+  React.useEffect(() => {
+    let cancelled = false
+
+    listOutgoingFriendRequestsRequest()
+      .then((data) => {
+        if (!cancelled) {
+          setRequests(data.requests ?? [])
+        }
+      })
+      .catch(() => {
+        if (!cancelled) {
+          setError('Could not load pending friend requests.')
+        }
+      })
+      .finally(() => {
+        if (!cancelled) {
+          setIsLoading(false)
+        }
+      })
+
+    return () => {
+      cancelled = true
+    }
+  }, [])
+
+  async function handleCancel(friendshipId) {
+    setCancellingById((current) => ({ ...current, [friendshipId]: true }))
+    try {
+      await cancelFriendRequestRequest(friendshipId)
+      setRequests((current) => current.filter((request) => request.id !== friendshipId))
+    } catch (_error) {
+      setError('Could not cancel the friend request.')
+    } finally {
+      setCancellingById((current) => {
+        const next = { ...current }
+        delete next[friendshipId]
+        return next
+      })
+    }
+  }
+
+  if (isLoading) {
+    return <p className="text-sm text-concord-muted">Loading pending friend requests...</p>
+  }
+
+  return (
+    <div className="space-y-2">
+      {error ? (
+        <p className="rounded-2xl border border-concord-danger/30 bg-concord-danger/10 px-4 py-3 text-sm text-concord-danger">
+          {error}
+        </p>
+      ) : null}
+
+      {!error && requests.length === 0 ? (
+        <div className="rounded-2xl border border-concord-border bg-concord-panel-alt/80 px-5 py-6 text-sm leading-6 text-concord-muted">
+          Friend requests you send will appear here until they are answered or expire.
+        </div>
+      ) : null}
+
+      {requests.map((request) => (
+        <div
+          key={request.id}
+          className="flex items-center gap-4 rounded-2xl border border-concord-border bg-concord-panel-alt/70 px-4 py-3"
+        >
+          {request.user?.avatar_url ? (
+            <img
+              src={request.user.avatar_url}
+              alt={request.user.username}
+              className="h-11 w-11 rounded-full object-cover"
+            />
+          ) : (
+            <div
+              className="flex h-11 w-11 items-center justify-center rounded-full text-sm font-bold text-slate-950"
+              style={{ backgroundColor: request.user?.avatar_color || '#5ad1b2' }}
+            >
+              {(request.user?.username ?? '?').slice(0, 1).toUpperCase()}
+            </div>
+          )}
+
+          <div className="min-w-0 flex-1">
+            <p className="truncate font-semibold text-concord-text">{request.user?.username}</p>
+            <p className="mt-1 text-sm text-concord-muted">Outgoing friend request</p>
+          </div>
+
+          <button
+            type="button"
+            onClick={() => handleCancel(request.id)}
+            disabled={Boolean(cancellingById[request.id])}
+            className="rounded-full px-3 py-2 text-sm text-concord-muted transition hover:bg-concord-panel hover:text-concord-text disabled:cursor-not-allowed disabled:opacity-60"
+          >
+            {cancellingById[request.id] ? 'Cancelling...' : 'Cancel'}
+          </button>
+        </div>
+      ))}
+    </div>
+  )
+}
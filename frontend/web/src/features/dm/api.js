@@ -40,6 +40,36 @@ export async function searchUsersRequest(query) {
   return response.data
 }
 
+export async function listFriendSuggestionsRequest() {
+  const response = await apiClient.get('/api/friends/suggestions')
+  return response.data
+}
+
+export async function getUserProfileRequest(userId) {
+  const response = await apiClient.get(`/api/users/${userId}/profile`)
+  return response.data
+}
+
+export async function updateUserAnnotationRequest(userId, annotation) {
+  const response = await apiClient.patch(`/api/users/${userId}/annotation`, annotation)
+  return response.data
+}
+
+export async function getMyStatusRequest() {
+  const response = await apiClient.get('/api/presence/status')
+  return response.data
+}
+
+export async function setMyStatusRequest(status) {
+  const response = await apiClient.put('/api/presence/status', status)
+  return response.data
+}
+
+export async function clearMyStatusRequest() {
+  const response = await apiClient.delete('/api/presence/status')
+  return response.data
+}
+
 export async function sendFriendRequestRequest(targetUserId) {
   const response = await apiClient.post('/api/friends/requests', {
     target_user_id: Number(targetUserId),
@@ -52,6 +82,15 @@ export async function listIncomingFriendRequestsRequest() {
   return response.data
 }
 
+export async function listOutgoingFriendRequestsRequest() {
+  const response = await apiClient.get('/api/friends/requests/outgoing')
+  return response.data
+}
+
+export async function cancelFriendRequestRequest(friendshipId) {
+  await apiClient.delete(`/api/friends/requests/${friendshipId}`)
+}
+
 export async function acceptFriendRequestRequest(friendshipId) {
   const response = await apiClient.post(`/api/friends/requests/${friendshipId}/accept`)
   return response.data
@@ -84,3 +123,21 @@ export async function unblockUserRequest(blockedUserId) {
     },
   })
 }
+
+export async function listMessageRequestsRequest() {
+  const response = await apiClient.get('/api/dms/requests')
+  return response.data
+}
+
+export async function acceptMessageRequestRequest(conversationId) {
+  const response = await apiClient.post(`/api/dms/requests/${conversationId}/accept`)
+  return response.data
+}
+
+export async function ignoreMessageRequestRequest(conversationId) {
+  await apiClient.post(`/api/dms/requests/${conversationId}/ignore`)
+}
+
+export async function blockMessageRequestRequest(conversationId) {
+  await apiClient.post(`/api/dms/requests/${conversationId}/block`)
+}
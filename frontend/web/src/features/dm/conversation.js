@@ -0,0 +1,48 @@
+// Users carry the viewer's private nickname for them, if they set one, ahead
+// of their own display name.
+export function getUserDisplayName(user) {
+  return user?.nickname || user?.display_name || user?.username
+}
+
+// The custom status wins over a reported activity, which is more transient.
+export function getUserStatusLabel(user) {
+  const status = user?.status
+  if (!status) {
+    return ''
+  }
+  const custom = [status.emoji, status.text].filter(Boolean).join(' ')
+  return custom || status.activity || ''
+}
+
+export function getConversationTitle(conversation) {
+  if (!conversation) {
+    return 'Direct message'
+  }
+
+  if (!conversation.is_group) {
+    return getUserDisplayName(conversation.other_user) ?? 'Direct message'
+  }
+
+  if (conversation.name) {
+    return conversation.name
+  }
+
+  return (conversation.participants ?? []).map((participant) => getUserDisplayName(participant)).join(', ') || 'Group'
+}
+
+// Groups use their icon and name; one-to-one conversations use the other member.
+export function getConversationAvatar(conversation) {
+  if (conversation?.is_group) {
+    return {
+      url: conversation.icon_url,
+      color: '#7c8cff',
+      initial: getConversationTitle(conversation).slice(0, 1).toUpperCase(),
+    }
+  }
+
+  return {
+    url: conversation?.other_user?.avatar_url,
+    color: conversation?.other_user?.avatar_color || '#5ad1b2',
+    initial: getUserDisplayName(conversation?.other_user)?.slice(0, 1).toUpperCase() || '?',
+  }
+}
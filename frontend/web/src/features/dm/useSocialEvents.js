@@ -0,0 +1,83 @@
+import React from 'react'
+
+import { useDmStore } from '@/features/dm/store'
+import { useSessionStore } from '@/lib/sessionStore'
+import { buildWebSocketUrl } from '@/lib/websocketTicket'
+
+const RECONNECT_DELAY_MS = 2000
+
+// useSocialEvents keeps friends, friend requests, blocks and friends' statuses
+// in sync through the user socket. Events only say what changed, so the lists
+// are refetched.
+export function useSocialEvents() {
+  const accessToken = useSessionStore((state) => state.accessToken)
+  const fetchFriends = useDmStore((state) => state.fetchFriends)
+  const fetchIncomingRequests = useDmStore((state) => state.fetchIncomingRequests)
+  const fetchBlockedUsers = useDmStore((state) => state.fetchBlockedUsers)
+
+  React.useEffect(() => {
+    if (!accessToken) {
+      return undefined
+    }
+
+    let socket = null
+    let reconnectTimeout = null
+    let isCancelled = false
+
+    function handleEvent(event) {
+      if (event.type.startsWith('friend_request.')) {
+        fetchIncomingRequests({ silent: true })
+      }
+      if (
+        event.type.startsWith('friend.') ||
+        event.type.startsWith('block.') ||
+        event.type.startsWith('status.')
+      ) {
+        fetchFriends({ silent: true })
+      }
+      if (event.type.startsWith('block.')) {
+        fetchBlockedUsers({ silent: true })
+      }
+    }
+
+    async function connect() {
+      let websocketUrl
+      try {
+        websocketUrl = await buildWebSocketUrl('/api/realtime/ws', {})
+      } catch (_error) {
+        scheduleReconnect()
+        return
+      }
+      if (isCancelled) {
+        return
+      }
+
+      socket = new WebSocket(websocketUrl)
+      socket.onmessage = (message) => {
+        try {
+          handleEvent(JSON.parse(message.data))
+        } catch (_error) {
+          // Ignore frames this client does not understand.
+        }
+      }
+      socket.onclose = () => {
+        scheduleReconnect()
+      }
+    }
+
+    function scheduleReconnect() {
+      if (isCancelled) {
+        return
+      }
+      reconnectTimeout = window.setTimeout(connect, RECONNECT_DELAY_MS)
+    }
+
+    connect()
+
+    return () => {
+      isCancelled = true
+      window.clearTimeout(reconnectTimeout)
+      socket?.close()
+    }
+  }, [accessToken, fetchBlockedUsers, fetchFriends, fetchIncomingRequests])
+}
@@ -122,7 +122,8 @@ export function AppHomePage() {
               >
                 <p className="text-lg font-semibold text-concord-text">{server.name}</p>
                 <p className="mt-2 text-sm text-concord-muted">
-                  Public server · ID {server.id}
+                  {server.member_count} {server.member_count === 1 ? 'member' : 'members'} ·{' '}
+                  {server.recent_message_count} messages this week
                 </p>
                 <button
                   type="button"
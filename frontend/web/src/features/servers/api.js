@@ -23,3 +23,13 @@ export async function joinServerRequest(serverId) {
   const response = await apiClient.post(`/api/servers/${serverId}/join`)
   return response.data
 }
+
+export async function getServerMemberProfileRequest(serverId) {
+  const response = await apiClient.get(`/api/servers/${serverId}/members/me`)
+  return response.data
+}
+
+export async function updateServerMemberProfileRequest(serverId, profile) {
+  const response = await apiClient.patch(`/api/servers/${serverId}/members/me`, profile)
+  return response.data
+}
@@ -1,5 +1,12 @@
 import React from 'react'
 
+import {
+  changeUsernameRequest,
+  getPrivacySettingsRequest,
+  listUsernameHistoryRequest,
+  updatePrivacySettingsRequest,
+  updateProfileRequest,
+} from '@/features/settings/api'
 import { useSessionStore } from '@/lib/sessionStore'
 
 function formatSessionExpiry(expiresAt) {
@@ -73,6 +80,238 @@ function SettingsCard({ eyebrow, title, children }) {
   )
 }
 
+function PrivacyToggle({ label, description, checked, disabled, onChange }) {
+  return (
+    <label className="flex items-start gap-4 rounded-[1.5rem] border border-concord-border bg-concord-panel-alt/80 p-4">
+      <input
+        type="checkbox"
+        checked={checked}
+        disabled={disabled}
+        onChange={(event) => onChange(event.target.checked)}
+        className="mt-1 h-4 w-4 accent-concord-accent"
+      />
+      <span>
+        <span className="block text-sm font-semibold text-concord-text">{label}</span>
+        <span className="mt-1 block text-sm leading-6 text-concord-muted">{description}</span>
+      </span>
+    </label>
+  )
+}
+
+function PrivacySelect({ label, description, value, options, disabled, onChange }) {
+  return (
+    <label className="block rounded-[1.5rem] border border-concord-border bg-concord-panel-alt/80 p-4">
+      <span className="block text-sm font-semibold text-concord-text">{label}</span>
+      <span className="mt-1 block text-sm leading-6 text-concord-muted">{description}</span>
+      <select
+        value={value}
+        disabled={disabled}
+        onChange={(event) => onChange(event.target.value)}
+        className="mt-3 w-full rounded-2xl border border-concord-border bg-concord-panel px-4 py-2 text-sm text-concord-text outline-none transition focus:border-concord-accent"
+      >
+        {options.map((option) => (
+          <option key={option.value} value={option.value}>
+            {option.label}
+          </option>
+        ))}
+      </select>
+    </label>
+  )
+}
+
+const DM_POLICY_OPTIONS = [
+  { value: 'friends', label: 'Friends only' },
+  { value: 'server_members', label: 'Friends and server members' },
+  { value: 'everyone', label: 'Everyone' },
+]
+
+const FRIEND_REQUEST_POLICY_OPTIONS = [
+  { value: 'everyone', label: 'Everyone' },
+  { value: 'friends_of_friends', label: 'Friends of friends' },
+  { value: 'server_members', label: 'Server members' },
+  { value: 'nobody', label: 'Nobody' },
+]
+
+function PrivacySettingsCard() {
+  const [settings, setSettings] = React.useState(null)
+  const [isSaving, setIsSaving] = React.useState(false)
+  const [error, setError] = React.useState('')
+
+  React.useEffect(() => {
+    getPrivacySettingsRequest()
+      .then(setSettings)
+      .catch(() => setError('Could not load privacy settings.'))
+  }, [])
+
+  async function updateSetting(changes) {
+    setIsSaving(true)
+    setError('')
+    try {
+      setSettings(await updatePrivacySettingsRequest(changes))
+    } catch (_error) {
+      setError('Could not save privacy settings.')
+    } finally {
+      setIsSaving(false)
+    }
+  }
+
+  return (
+    <SettingsCard eyebrow="Privacy" title="Who can reach you">
+      <div className="grid gap-3">
+        {error ? <p className="text-sm text-concord-danger">{error}</p> : null}
+        <PrivacySelect
+          label="Who can send you direct messages"
+          description="Messages from people who are not your friends arrive as message requests."
+          value={settings?.dm_policy ?? 'friends'}
+          options={DM_POLICY_OPTIONS}
+          disabled={!settings || isSaving}
+          onChange={(value) => updateSetting({ dm_policy: value })}
+        />
+        <PrivacySelect
+          label="Who can send you friend requests"
+          description="Friends of friends share an accepted friend with you; server members share a server with you."
+          value={settings?.friend_request_policy ?? 'everyone'}
+          options={FRIEND_REQUEST_POLICY_OPTIONS}
+          disabled={!settings || isSaving}
+          onChange={(value) => updateSetting({ friend_request_policy: value })}
+        />
+        <PrivacyToggle
+          label="Appear in friend search"
+          description="Let people find you by username when adding friends."
+          checked={Boolean(settings?.discoverable)}
+          disabled={!settings || isSaving}
+          onChange={(value) => updateSetting({ discoverable: value })}
+        />
+        <PrivacyToggle
+          label="Send read receipts"
+          description="Let people in your direct messages see when you have read their messages."
+          checked={Boolean(settings?.send_read_receipts)}
+          disabled={!settings || isSaving}
+          onChange={(value) => updateSetting({ send_read_receipts: value })}
+        />
+      </div>
+    </SettingsCard>
+  )
+}
+
+function ProfileSettingsCard() {
+  const currentUser = useSessionStore((state) => state.currentUser)
+  const updateCurrentUser = useSessionStore((state) => state.updateCurrentUser)
+  const [displayName, setDisplayName] = React.useState(currentUser?.displayName ?? '')
+  const [username, setUsername] = React.useState(currentUser?.username ?? '')
+  const [history, setHistory] = React.useState([])
+  const [isSaving, setIsSaving] = React.useState(false)
+  const [error, setError] = React.useState('')
+
+  React.useEffect(() => {
+    listUsernameHistoryRequest()
+      .then(setHistory)
+      .catch(() => setError('Could not load your previous usernames.'))
+  }, [])
+
+  async function saveDisplayName(event) {
+    event.preventDefault()
+    setIsSaving(true)
+    setError('')
+    try {
+      updateCurrentUser(await updateProfileRequest({ display_name: displayName }))
+    } catch (error) {
+      setError(error.response?.data?.error ?? 'Could not save your display name.')
+    } finally {
+      setIsSaving(false)
+    }
+  }
+
+  async function saveUsername(event) {
+    event.preventDefault()
+    setIsSaving(true)
+    setError('')
+    try {
+      updateCurrentUser(await changeUsernameRequest(username))
+      setHistory(await listUsernameHistoryRequest())
+    } catch (error) {
+      setError(error.response?.data?.error ?? 'Could not change your username.')
+    } finally {
+      setIsSaving(false)
+    }
+  }
+
+  return (
+    <SettingsCard eyebrow="Profile" title="How people see you">
+      <div className="grid gap-3">
+        {error ? <p className="text-sm text-concord-danger">{error}</p> : null}
+        <form
+          onSubmit={saveDisplayName}
+          className="rounded-[1.5rem] border border-concord-border bg-concord-panel-alt/80 p-4"
+        >
+          <label className="block text-sm font-semibold text-concord-text" htmlFor="display-name">
+            Display name
+          </label>
+          <p className="mt-1 text-sm leading-6 text-concord-muted">
+            Shown next to your messages instead of your username. Leave it empty to use your
+            username.
+          </p>
+          <div className="mt-3 flex gap-3">
+            <input
+              id="display-name"
+              value={displayName}
+              maxLength={32}
+              disabled={isSaving}
+              onChange={(event) => setDisplayName(event.target.value)}
+              className="w-full rounded-2xl border border-concord-border bg-concord-panel px-4 py-2 text-sm text-concord-text outline-none transition focus:border-concord-accent"
+            />
+            <button
+              type="submit"
+              disabled={isSaving}
+              className="rounded-2xl bg-concord-accent px-4 py-2 text-sm font-semibold text-slate-950 transition hover:opacity-90 disabled:opacity-50"
+            >
+              Save
+            </button>
+          </div>
+        </form>
+        <form
+          onSubmit={saveUsername}
+          className="rounded-[1.5rem] border border-concord-border bg-concord-panel-alt/80 p-4"
+        >
+          <label className="block text-sm font-semibold text-concord-text" htmlFor="username">
+            Username
+          </label>
+          <p className="mt-1 text-sm leading-6 text-concord-muted">
+            Your unique handle. It can change once every 30 days, and your old username stays
+            reserved for you for 14 days.
+          </p>
+          <div className="mt-3 flex gap-3">
+            <input
+              id="username"
+              value={username}
+              maxLength={32}
+              disabled={isSaving}
+              onChange={(event) => setUsername(event.target.value)}
+              className="w-full rounded-2xl border border-concord-border bg-concord-panel px-4 py-2 text-sm text-concord-text outline-none transition focus:border-concord-accent"
+            />
+            <button
+              type="submit"
+              disabled={isSaving || username.trim() === currentUser?.username}
+              className="rounded-2xl bg-concord-accent px-4 py-2 text-sm font-semibold text-slate-950 transition hover:opacity-90 disabled:opacity-50"
+            >
+              Change
+            </button>
+          </div>
+          {history.length > 0 ? (
+            <ul className="mt-3 grid gap-1 text-sm text-concord-muted">
+              {history.map((entry) => (
+                <li key={`${entry.username}-${entry.changed_at}`}>
+                  {entry.username}, held until {formatDateTime(entry.reserved_until)}
+                </li>
+              ))}
+            </ul>
+          ) : null}
+        </form>
+      </div>
+    </SettingsCard>
+  )
+}
+
 export function SettingsPage() {
   const currentUser = useSessionStore((state) => state.currentUser)
   const expiresAt = useSessionStore((state) => state.expiresAt)
@@ -100,7 +339,7 @@ export function SettingsPage() {
             )}
             <div>
               <p className="text-lg font-semibold text-concord-text">
-                {currentUser?.username ?? 'Signed-in user'}
+                {currentUser?.displayName || currentUser?.username || 'Signed-in user'}
               </p>
               <p className="mt-1 text-sm text-concord-muted">
                 User ID: {currentUser?.userId ?? 'Unavailable'}
@@ -134,12 +373,15 @@ export function SettingsPage() {
           </div>
         </div>
         <p className="mt-5 max-w-3xl text-sm leading-7 text-concord-muted">
-          This page is now a real account surface. Editable profile fields such as display name,
-          avatar upload, and server-specific nicknames can land here once the backend model is
-          ready for them.
+          Display name and username live in the profile card below. Server-specific nicknames are
+          set from each server's channel sidebar.
         </p>
       </SettingsCard>
 
+      <ProfileSettingsCard />
+
+      <PrivacySettingsCard />
+
       <div className="grid gap-6 xl:grid-cols-[1.2fr_0.8fr]">
         <SettingsCard eyebrow="Session" title="Current session health">
           <div className="grid gap-3">
@@ -172,17 +414,10 @@ export function SettingsPage() {
         <SettingsCard eyebrow="Coming Next" title="Profile controls we can add later">
           <div className="grid gap-3">
             <div className="rounded-[1.5rem] border border-concord-border bg-concord-panel-alt/80 p-4">
-              <p className="text-sm font-semibold text-concord-text">Profile identity</p>
-              <p className="mt-2 text-sm leading-6 text-concord-muted">
-                Full name, display name, and avatar upload are the most natural first edits once the
-                backend supports profile updates.
-              </p>
-            </div>
-            <div className="rounded-[1.5rem] border border-concord-border bg-concord-panel-alt/80 p-4">
-              <p className="text-sm font-semibold text-concord-text">Server-specific nicknames</p>
+              <p className="text-sm font-semibold text-concord-text">Avatar upload</p>
               <p className="mt-2 text-sm leading-6 text-concord-muted">
-                A nickname layer can come after the base account profile so each server can keep its
-                own social identity without changing the global username.
+                Uploading a global avatar is the natural next profile edit now that display names
+                and usernames can change.
               </p>
             </div>
             <div className="rounded-[1.5rem] border border-concord-border bg-concord-panel-alt/80 p-4">
@@ -0,0 +1,26 @@
+import { apiClient } from '@/lib/apiClient'
+
+export async function getPrivacySettingsRequest() {
+  const response = await apiClient.get('/api/privacy')
+  return response.data
+}
+
+export async function updatePrivacySettingsRequest(changes) {
+  const response = await apiClient.patch('/api/privacy', changes)
+  return response.data
+}
+
+export async function updateProfileRequest(changes) {
+  const response = await apiClient.patch('/api/auth/profile', changes)
+  return response.data
+}
+
+export async function changeUsernameRequest(username) {
+  const response = await apiClient.post('/api/auth/username', { username })
+  return response.data
+}
+
+export async function listUsernameHistoryRequest() {
+  const response = await apiClient.get('/api/auth/username/history')
+  return response.data.usernames ?? []
+}
@@ -35,6 +35,7 @@ export const useSessionStore = create(
           const parsedJwt = parseJwtPayload(accessToken)
           const rawUser = parsedJwt.user ?? {}
           const username = rawUser.username ?? parsedJwt.username ?? ''
+          const displayName = rawUser.display_name ?? rawUser.displayName ?? ''
           const avatarUrl = rawUser.avatar_url ?? rawUser.avatarUrl ?? ''
           const avatarColor = rawUser.avatar_color ?? rawUser.avatarColor ?? '#5ad1b2'
           const userId = rawUser.user_id ?? rawUser.userId ?? parsedJwt.userId ?? null
@@ -49,12 +50,25 @@ export const useSessionStore = create(
               ? {
                   userId,
                   username,
+                  displayName,
                   avatarUrl,
                   avatarColor,
                 }
               : null,
           }
         }),
+      // Profile edits only reach the access token on the next refresh, so the
+      // settings page patches the cached user right away.
+      updateCurrentUser: (user) =>
+        set((state) => ({
+          currentUser: state.currentUser
+            ? {
+                ...state.currentUser,
+                username: user.username ?? state.currentUser.username,
+                displayName: user.display_name ?? '',
+              }
+            : state.currentUser,
+        })),
       setRegisterSuccessMessage: (message) =>
         set({
           registerSuccessMessage: message,
@@ -0,0 +1,20 @@
+import { apiClient } from '@/lib/apiClient'
+
+// WebSocket upgrades cannot carry an Authorization header, so the backend
+// hands out a short-lived single-use ticket to put in the socket URL instead.
+export async function createWebSocketTicketRequest() {
+  const response = await apiClient.post('/api/ws/ticket')
+  return response.data.ticket
+}
+
+export async function buildWebSocketUrl(path, params) {
+  const ticket = await createWebSocketTicketRequest()
+  const websocketUrl = new URL(path, import.meta.env.VITE_WS_URL)
+
+  Object.entries(params).forEach(([key, value]) => {
+    websocketUrl.searchParams.set(key, value)
+  })
+  websocketUrl.searchParams.set('ticket', ticket)
+
+  return websocketUrl.toString()
+}